@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// accessibleMode reports whether menus should be screen-reader friendly: settings.terminal.accessible
+// or an ACCESSIBLE environment variable other than 0/false
+func accessibleMode(config Config) bool {
+	if value := strings.ToLower(strings.TrimSpace(os.Getenv("ACCESSIBLE"))); value != "" && value != "0" && value != "false" {
+		return true
+	}
+	return config.Settings != nil && config.Settings.Terminal != nil && config.Settings.Terminal.Accessible
+}
+
+// accessibleEnvironmentLine describes one environment in full, without truncation or symbols
+func accessibleEnvironmentLine(number int, env Environment) string {
+	line := fmt.Sprintf("%d. %s, %s", number, env.Name, env.URL)
+	if env.Model != "" {
+		line += ", " + trf("model %s", env.Model)
+	}
+	return line
+}
+
+// accessibleSelection prints the environments once as a plain numbered list and reads a number.
+// Nothing is redrawn and no control characters are written, so a screen reader reads it in order.
+func accessibleSelection(config Config) (Environment, error) {
+	count := len(config.Environments)
+	fmt.Println(tr("Select environment:"))
+	for i, env := range config.Environments {
+		fmt.Println(accessibleEnvironmentLine(i+1, env))
+	}
+
+	for {
+		input, err := regularInput(trf("Enter number (1-%d, Enter to cancel): ", count))
+		if err != nil {
+			return Environment{}, fmt.Errorf("environment selection failed: %w", err)
+		}
+		if input == "" {
+			return Environment{}, fmt.Errorf("selection cancelled")
+		}
+		if choice, err := strconv.Atoi(input); err == nil && choice >= 1 && choice <= count {
+			return config.Environments[choice-1], nil
+		}
+		fmt.Println(trf("Please enter a number between 1 and %d.", count))
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestAccessibleMode(t *testing.T) {
+	accessible := Config{Settings: &ConfigSettings{Terminal: &TerminalSettings{Accessible: true}}}
+	tests := []struct {
+		name   string
+		env    string
+		config Config
+		want   bool
+	}{
+		{name: "off by default"},
+		{name: "setting", config: accessible, want: true},
+		{name: "ACCESSIBLE=1", env: "1", want: true},
+		{name: "ACCESSIBLE=0", env: "0"},
+		{name: "ACCESSIBLE=false", env: "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACCESSIBLE", tt.env)
+			if got := accessibleMode(tt.config); got != tt.want {
+				t.Errorf("accessibleMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessibleEnvironmentLine(t *testing.T) {
+	env := Environment{Name: "production-with-a-very-long-name", URL: "https://api.openai.com/v1", Model: "gpt-5"}
+	if got, want := accessibleEnvironmentLine(2, env), "2. production-with-a-very-long-name, https://api.openai.com/v1, model gpt-5"; got != want {
+		t.Errorf("accessibleEnvironmentLine() = %q, want %q", got, want)
+	}
+}
+
+func TestAccessibleSelection(t *testing.T) {
+	config := Config{Environments: []Environment{{Name: "dev", URL: "https://dev.example.com"}, {Name: "prod", URL: "https://prod.example.com"}}}
+	withStdin(t, "2\n")
+	env, err := accessibleSelection(config)
+	if err != nil || env.Name != "prod" {
+		t.Fatalf("accessibleSelection() = %q, %v; want prod", env.Name, err)
+	}
+
+	withStdin(t, "\n")
+	if _, err := accessibleSelection(config); err == nil {
+		t.Error("an empty answer should cancel the selection")
+	}
+}
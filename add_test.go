@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSecretFromStdin(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "single line", input: "sk-ci-1234567890\n", want: "sk-ci-1234567890"},
+		{name: "no trailing newline", input: "  sk-ci-1234567890  ", want: "sk-ci-1234567890"},
+		{name: "only the first line", input: "sk-ci-1234567890\nsecond line\n", want: "sk-ci-1234567890"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "control characters", input: "sk-\x01ci\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSecretFromStdin(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("readSecretFromStdin() = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunAddWithKeyFromStdin(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPathOverride = original }()
+
+	withStdin(t, "sk-ci-1234567890\n")
+	if err := runAddWithOptions(addOptions{KeyFromStdin: true, Name: "ci", URL: "https://api.example.com/v1", Model: "gpt-5", EnvVars: "REGION=eu\nTIER=batch"}); err != nil {
+		t.Fatalf("runAddWithOptions() error = %v", err)
+	}
+	withStdin(t, "sk-az-1234567890")
+	if err := runAddWithOptions(addOptions{KeyFromStdin: true, Preset: "azure", Name: "az"}); err == nil || !strings.Contains(err.Error(), "needs --url") {
+		t.Errorf("expected a --url error for a preset template, got %v", err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := config.Environments[0]
+	if len(config.Environments) != 1 || env.APIKey != "sk-ci-1234567890" || env.Model != "gpt-5" || env.EnvVars["REGION"] != "eu" || env.EnvVars["TIER"] != "batch" {
+		t.Errorf("added environments = %+v", config.Environments)
+	}
+
+	result := parseArguments([]string{"add", "--name", "ci", "--url", "https://api.example.com/v1", "--env-var", "A=1", "--env-var", "B=2", "--api-key-stdin"})
+	if result.Error != nil || result.CCEFlags["key_from_stdin"] != "true" || result.CCEFlags["env_vars"] != "A=1\nB=2" {
+		t.Errorf("add parse = %+v", result)
+	}
+	for _, args := range [][]string{
+		{"add", "--name", "ci"},
+		{"add", "--api-key-stdin", "--key-from-clipboard"},
+		{"add", "--api-key-stdin", "--env", "prod"},
+	} {
+		if result := parseArguments(args); result.Error == nil {
+			t.Errorf("parseArguments(%q) accepted invalid flags", args)
+		}
+	}
+}
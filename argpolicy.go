@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Argument policies: what happens when a codex argument looks dangerous
+const (
+	argPolicyWarn   = "warn"   // Print a warning and launch anyway (default)
+	argPolicyStrict = "strict" // Reject the launch; --no-arg-check is refused (shared machines)
+	argPolicyOff    = "off"    // Skip the built-in checks; deny patterns still apply
+)
+
+// dangerousArgPatterns are the built-in substrings that make an argument look dangerous
+var dangerousArgPatterns = []string{"rm -rf", "sudo", "/etc/passwd", "../"}
+
+// argPolicy decides which codex arguments are accepted
+type argPolicy struct {
+	mode     string
+	allow    []*regexp.Regexp
+	deny     []*regexp.Regexp
+	warnOnly bool // Report deny and strict matches as warnings (content after --)
+}
+
+// validateArgPolicySettings checks settings.validation.arg_policy and its pattern lists
+func validateArgPolicySettings(validation *ValidationSettings) error {
+	_, err := newArgPolicy(validation)
+	return err
+}
+
+// newArgPolicy builds the argument policy from settings.validation
+func newArgPolicy(validation *ValidationSettings) (argPolicy, error) {
+	policy := argPolicy{mode: argPolicyWarn}
+	if validation == nil {
+		return policy, nil
+	}
+
+	switch validation.ArgPolicy {
+	case "":
+	case argPolicyWarn, argPolicyStrict, argPolicyOff:
+		policy.mode = validation.ArgPolicy
+	default:
+		return argPolicy{}, fmt.Errorf("unknown arg_policy '%s' (use warn, strict or off)", validation.ArgPolicy)
+	}
+
+	var err error
+	if policy.allow, err = compileArgPatterns("arg_allow", validation.ArgAllow); err != nil {
+		return argPolicy{}, err
+	}
+	if policy.deny, err = compileArgPatterns("arg_deny", validation.ArgDeny); err != nil {
+		return argPolicy{}, err
+	}
+	return policy, nil
+}
+
+// compileArgPatterns compiles one of the allow/deny regular expression lists
+func compileArgPatterns(field string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern '%s': %w", field, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// check applies the policy to args. An allow match skips every other check for that
+// argument, a deny match rejects it, and the built-in patterns follow the mode. With
+// warnOnly set, rejections become warnings.
+func (p argPolicy) check(args []string) error {
+	for _, arg := range args {
+		if firstMatch(p.allow, arg) != nil {
+			continue
+		}
+		if re := firstMatch(p.deny, arg); re != nil {
+			if !p.warnOnly {
+				return fmt.Errorf("argument matches arg_deny pattern '%s': %s", re.String(), arg)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: argument after -- matches arg_deny pattern '%s': %s\n", re.String(), arg)
+			continue
+		}
+
+		switch {
+		case p.mode == argPolicyOff:
+			continue
+		case p.mode == argPolicyStrict && !p.warnOnly:
+			if err := validatePassthroughArgs([]string{arg}); err != nil {
+				return err
+			}
+		default:
+			warnShellMetacharacters(arg)
+			if pattern := dangerousArgPattern(arg); pattern != "" {
+				fmt.Fprintf(os.Stderr, "Warning: argument contains '%s' (launching anyway; set settings.validation.arg_policy to \"strict\" to block): %s\n", pattern, arg)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPassthroughArgs applies the configured argument policy to codex arguments.
+// --no-arg-check skips it, except under the strict policy. Arguments after an explicit
+// -- are codex content and only warned about unless settings.validation.strict_passthrough.
+func checkPassthroughArgs(config Config, args []string, opts launchOptions) error {
+	var validation *ValidationSettings
+	if config.Settings != nil {
+		validation = config.Settings.Validation
+	}
+	policy, err := newArgPolicy(validation)
+	if err != nil {
+		return err
+	}
+
+	if opts.NoArgCheck {
+		if policy.mode == argPolicyStrict {
+			return fmt.Errorf("--no-arg-check is not allowed when settings.validation.arg_policy is \"strict\"")
+		}
+		return nil
+	}
+	policy.warnOnly = opts.Separator && (validation == nil || !validation.StrictPassthrough)
+	return policy.check(args)
+}
+
+// validatePassthroughArgs applies the built-in strict rules: it rejects arguments
+// containing a dangerous pattern and warns about shell metacharacters
+func validatePassthroughArgs(args []string) error {
+	for _, arg := range args {
+		warnShellMetacharacters(arg)
+		if dangerousArgPattern(arg) != "" {
+			return fmt.Errorf("potentially dangerous argument rejected: %s", arg)
+		}
+	}
+	return nil
+}
+
+// warnShellMetacharacters warns about arguments that would mean something else in a shell
+func warnShellMetacharacters(arg string) {
+	if strings.ContainsAny(arg, ";&|`") || strings.Contains(arg, "$(") {
+		fmt.Fprintf(os.Stderr, "Warning: Argument contains shell metacharacters: %s\n", arg)
+	}
+}
+
+// dangerousArgPattern returns the built-in pattern arg contains, or "" if none
+func dangerousArgPattern(arg string) string {
+	for _, pattern := range dangerousArgPatterns {
+		if strings.Contains(arg, pattern) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// firstMatch returns the first of patterns that matches arg, or nil
+func firstMatch(patterns []*regexp.Regexp, arg string) *regexp.Regexp {
+	for _, re := range patterns {
+		if re.MatchString(arg) {
+			return re
+		}
+	}
+	return nil
+}
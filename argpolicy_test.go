@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPassthroughArgs(t *testing.T) {
+	withValidation := func(validation ValidationSettings) Config {
+		return Config{Settings: &ConfigSettings{Validation: &validation}}
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		args    []string
+		opts    launchOptions
+		wantErr string
+	}{
+		{name: "warn by default", config: Config{}, args: []string{"exec", "explain the sudoers file in ../etc"}},
+		{name: "explicit warn", config: withValidation(ValidationSettings{ArgPolicy: "warn"}), args: []string{"rm -rf build"}},
+		{name: "strict rejects", config: withValidation(ValidationSettings{ArgPolicy: "strict"}), args: []string{"exec", "sudo ls"}, wantErr: "dangerous argument rejected"},
+		{name: "strict accepts safe", config: withValidation(ValidationSettings{ArgPolicy: "strict"}), args: []string{"exec", "list files"}},
+		{name: "off skips built-ins", config: withValidation(ValidationSettings{ArgPolicy: "off"}), args: []string{"cat /etc/passwd"}},
+		{
+			name:   "allow overrides strict",
+			config: withValidation(ValidationSettings{ArgPolicy: "strict", ArgAllow: []string{"^explain "}}),
+			args:   []string{"explain /etc/sudoers"},
+		},
+		{
+			name:    "deny rejects in warn mode",
+			config:  withValidation(ValidationSettings{ArgDeny: []string{"--dangerously-bypass"}}),
+			args:    []string{"--dangerously-bypass-approvals-and-sandbox"},
+			wantErr: "arg_deny pattern",
+		},
+		{
+			name:    "deny rejects in off mode",
+			config:  withValidation(ValidationSettings{ArgPolicy: "off", ArgDeny: []string{"^--yolo$"}}),
+			args:    []string{"--yolo"},
+			wantErr: "arg_deny pattern",
+		},
+		{
+			name:   "allow beats deny",
+			config: withValidation(ValidationSettings{ArgAllow: []string{"^--yolo$"}, ArgDeny: []string{"yolo"}}),
+			args:   []string{"--yolo"},
+		},
+		{
+			name:   "no-arg-check skips deny",
+			config: withValidation(ValidationSettings{ArgDeny: []string{"sudo"}}),
+			args:   []string{"sudo"},
+			opts:   launchOptions{NoArgCheck: true},
+		},
+		{
+			name:    "no-arg-check refused under strict",
+			config:  withValidation(ValidationSettings{ArgPolicy: "strict"}),
+			args:    []string{"exec"},
+			opts:    launchOptions{NoArgCheck: true},
+			wantErr: "--no-arg-check is not allowed",
+		},
+		{
+			name:   "strict only warns after separator",
+			config: withValidation(ValidationSettings{ArgPolicy: "strict"}),
+			args:   []string{"exec", "explain /etc/sudoers"},
+			opts:   launchOptions{Separator: true},
+		},
+		{
+			name:   "deny only warns after separator",
+			config: withValidation(ValidationSettings{ArgDeny: []string{"sudo"}}),
+			args:   []string{"sudo"},
+			opts:   launchOptions{Separator: true},
+		},
+		{
+			name:    "strict passthrough checks after separator",
+			config:  withValidation(ValidationSettings{ArgPolicy: "strict", StrictPassthrough: true}),
+			args:    []string{"exec", "sudo ls"},
+			opts:    launchOptions{Separator: true},
+			wantErr: "dangerous argument rejected",
+		},
+		{name: "unknown policy", config: withValidation(ValidationSettings{ArgPolicy: "block"}), wantErr: "unknown arg_policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPassthroughArgs(tt.config, tt.args, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkPassthroughArgs() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSeparator(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"-e", "prod", "--", "exec"}, want: true},
+		{args: []string{"-e", "prod", "exec"}},
+		{args: []string{"--"}, want: true},
+		{args: []string{"exec", "--", "task"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseArguments(tt.args).Separator; got != tt.want {
+			t.Errorf("parseArguments(%q).Separator = %t, want %t", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestValidateArgPolicySettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation *ValidationSettings
+		wantErr    bool
+	}{
+		{name: "unset", validation: nil},
+		{name: "strict with patterns", validation: &ValidationSettings{ArgPolicy: "strict", ArgAllow: []string{"^explain "}, ArgDeny: []string{`\bshutdown\b`}}},
+		{name: "unknown policy", validation: &ValidationSettings{ArgPolicy: "deny"}, wantErr: true},
+		{name: "bad allow pattern", validation: &ValidationSettings{ArgAllow: []string{"("}}, wantErr: true},
+		{name: "bad deny pattern", validation: &ValidationSettings{ArgDeny: []string{"[a-"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateArgPolicySettings(tt.validation); (err != nil) != tt.wantErr {
+				t.Errorf("validateArgPolicySettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseNoArgCheckFlag(t *testing.T) {
+	result := parseArguments([]string{"--no-arg-check", "-e", "prod", "--", "exec", "sudo -l"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if opts := launchOptionsFromFlags(result); !opts.NoArgCheck || !opts.Separator {
+		t.Errorf("launch options = %+v, want NoArgCheck and Separator", opts)
+	}
+	if len(result.ClaudeArgs) != 2 {
+		t.Errorf("unexpected codex args: %v", result.ClaudeArgs)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultAuditMaxSizeKB is the audit log size that triggers rotation
+const defaultAuditMaxSizeKB = 1024
+
+// auditRecord is one JSONL line in the audit log
+type auditRecord struct {
+	Timestamp      string   `json:"timestamp"`
+	Environment    string   `json:"environment"`
+	KeyFingerprint string   `json:"key_fingerprint"`
+	Args           []string `json:"args"`
+	ExitCode       *int     `json:"exit_code,omitempty"`   // Unknown when codex replaces the cde process
+	DurationMS     *int64   `json:"duration_ms,omitempty"` // Set in subprocess launch mode
+}
+
+// secretArgPattern matches inline secrets such as --api-key=xxx or token=xxx
+var secretArgPattern = regexp.MustCompile(`(?i)^(.*(?:key|token|secret|password)[^=]*=).+$`)
+
+// envNameOverridePattern matches codex provider settings whose values name environment
+// variables rather than hold secrets, such as the env_key override applyRequestHeaders adds
+var envNameOverridePattern = regexp.MustCompile(`^model_providers\.[^=]+\.(?:env_key|env_http_headers)=`)
+
+// auditEnabled reports whether settings.audit.enabled is set
+func auditEnabled(config Config) bool {
+	return config.Settings != nil && config.Settings.Audit != nil && config.Settings.Audit.Enabled
+}
+
+// getAuditPath returns the active profile's audit log
+func getAuditPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "audit.jsonl"), nil
+}
+
+// keyFingerprint returns a short, non-reversible identifier for an API key
+func keyFingerprint(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// auditAPIKey returns the key a launch used. Secret references are resolved (normally from the
+// in-memory cache) so the fingerprint identifies the key itself, not where it is stored.
+func auditAPIKey(env Environment) string {
+	apiKey, err := resolveSecret(env.APIKey)
+	if err != nil {
+		return ""
+	}
+	return apiKey
+}
+
+// sanitizeArgs masks secrets in codex arguments before they are logged
+func sanitizeArgs(args []string, apiKey string) []string {
+	sanitized := make([]string, len(args))
+	maskNext := false
+	for i, arg := range args {
+		switch {
+		case maskNext:
+			arg = "***"
+			maskNext = false
+		case envNameOverridePattern.MatchString(arg):
+			// Variable names only; masking them would make the command unusable
+		case secretArgPattern.MatchString(arg):
+			arg = secretArgPattern.ReplaceAllString(arg, "${1}***")
+		case strings.HasPrefix(arg, "-") && secretArgPattern.MatchString(arg+"=x"):
+			// Flag whose value is the next argument (e.g. --api-key sk-...)
+			maskNext = true
+		}
+		if apiKey != "" && strings.Contains(arg, apiKey) {
+			arg = strings.ReplaceAll(arg, apiKey, "***")
+		}
+		sanitized[i] = arg
+	}
+	return sanitized
+}
+
+// rotateAuditLog moves the log aside once it exceeds maxSizeKB (one previous generation is kept)
+func rotateAuditLog(path string, maxSizeKB int) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < int64(maxSizeKB)*1024 {
+		return nil
+	}
+	return os.Rename(path, path+".1")
+}
+
+// appendAuditRecord writes a record to the audit log, rotating by size first
+func appendAuditRecord(config Config, record auditRecord) error {
+	path, err := getAuditPath()
+	if err != nil {
+		return fmt.Errorf("audit log path resolution failed: %w", err)
+	}
+	if err := ensureConfigDir(); err != nil {
+		return fmt.Errorf("audit log write failed: %w", err)
+	}
+
+	maxSizeKB := defaultAuditMaxSizeKB
+	if config.Settings.Audit.MaxSizeKB > 0 {
+		maxSizeKB = config.Settings.Audit.MaxSizeKB
+	}
+	if err := rotateAuditLog(path, maxSizeKB); err != nil {
+		return fmt.Errorf("audit log rotation failed: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("audit record serialization failed: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("audit log open failed: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("audit log write failed: %w", err)
+	}
+	return nil
+}
+
+// recordLaunch appends an audit record for a launch when auditing is enabled.
+// Audit failures never block a launch; they are reported as warnings.
+func recordLaunch(config Config, env Environment, args []string, exitCode *int) {
+	if !auditEnabled(config) {
+		return
+	}
+
+	apiKey := auditAPIKey(env)
+	record := auditRecord{
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		Environment:    env.Name,
+		KeyFingerprint: keyFingerprint(apiKey),
+		Args:           sanitizeArgs(args, apiKey),
+		ExitCode:       exitCode,
+	}
+	if err := appendAuditRecord(config, record); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// recordLaunchResult appends an audit record for a finished subprocess launch
+func recordLaunchResult(config Config, env Environment, args []string, exitCode int, duration time.Duration) {
+	if !auditEnabled(config) {
+		return
+	}
+
+	durationMS := duration.Milliseconds()
+	apiKey := auditAPIKey(env)
+	record := auditRecord{
+		Timestamp:      time.Now().Add(-duration).UTC().Format(time.RFC3339),
+		Environment:    env.Name,
+		KeyFingerprint: keyFingerprint(apiKey),
+		Args:           sanitizeArgs(args, apiKey),
+		ExitCode:       &exitCode,
+		DurationMS:     &durationMS,
+	}
+	if err := appendAuditRecord(config, record); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// readAuditRecords returns the last n records from the audit log (all when n <= 0)
+func readAuditRecords(n int) ([]auditRecord, error) {
+	path, err := getAuditPath()
+	if err != nil {
+		return nil, fmt.Errorf("audit log path resolution failed: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []auditRecord{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("audit log open failed: %w", err)
+	}
+	defer f.Close()
+
+	records := []auditRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Skip partially written lines
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit log read failed: %w", err)
+	}
+
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+	return records, nil
+}
+
+// runAuditShow prints the most recent audit records
+func runAuditShow(last int) error {
+	records, err := readAuditRecords(last)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No launches recorded. Enable with settings.audit.enabled in config.json.")
+		return nil
+	}
+
+	for _, record := range records {
+		exit := "-"
+		if record.ExitCode != nil {
+			exit = fmt.Sprintf("%d", *record.ExitCode)
+		}
+		took := "-"
+		if record.DurationMS != nil {
+			took = (time.Duration(*record.DurationMS) * time.Millisecond).String()
+		}
+		fmt.Printf("%s  %-15s  %s  exit=%s  took=%s  codex %s\n",
+			record.Timestamp, record.Environment, record.KeyFingerprint, exit, took, formatCommandLine(record.Args))
+	}
+	return nil
+}
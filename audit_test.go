@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeArgs(t *testing.T) {
+	args := []string{"-m", "gpt-5", "--api-key", "sk-secret", "-c", "token=abc", "say sk-live-123 please"}
+	got := sanitizeArgs(args, "sk-live-123")
+	want := []string{"-m", "gpt-5", "--api-key", "***", "-c", "token=***", "say *** please"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sanitizeArgs() = %v, want %v", got, want)
+	}
+
+	// Header provider overrides name variables; they stay copy-pasteable
+	env := Environment{Name: "gw", URL: "https://gw.example.com/v1", APIKey: "sk-gw-123", Headers: map[string]string{"X-Api-Key": "secret-value"}}
+	overrides := applyRequestHeaders(env, nil)
+	if got := sanitizeArgs(overrides, env.APIKey); !reflect.DeepEqual(got, overrides) {
+		t.Errorf("sanitizeArgs() masked provider overrides: %v", got)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	fp := keyFingerprint("sk-test-123")
+	if !strings.HasPrefix(fp, "sha256:") || len(fp) != len("sha256:")+12 {
+		t.Errorf("unexpected fingerprint: %s", fp)
+	}
+	if strings.Contains(fp, "sk-test") {
+		t.Error("fingerprint must not contain the key")
+	}
+	if keyFingerprint("") != "" {
+		t.Error("empty key should have empty fingerprint")
+	}
+}
+
+func TestAuditRecordAndRead(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	env := Environment{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-test"}
+
+	// Disabled: nothing written
+	recordLaunch(Config{}, env, []string{"proto"}, nil)
+	if records, _ := readAuditRecords(0); len(records) != 0 {
+		t.Fatalf("expected no records when disabled, got %d", len(records))
+	}
+
+	config := Config{Settings: &ConfigSettings{Audit: &AuditSettings{Enabled: true}}}
+	for i := 0; i < 3; i++ {
+		recordLaunch(config, env, []string{"proto"}, nil)
+	}
+	code := 2
+	recordLaunch(config, env, []string{"exec"}, &code)
+
+	records, err := readAuditRecords(2)
+	if err != nil {
+		t.Fatalf("readAuditRecords() failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected last 2 records, got %d", len(records))
+	}
+	last := records[1]
+	if last.Environment != "prod" || last.ExitCode == nil || *last.ExitCode != 2 || last.Args[0] != "exec" {
+		t.Errorf("unexpected last record: %+v", last)
+	}
+
+	path, _ := getAuditPath()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("audit log should be 0600: %v %v", info, err)
+	}
+}
+
+func TestAuditFingerprintsResolvedKey(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+	// Both references were resolved at launch to the same key
+	refs := []string{"op://Work/audit-a/credential", "op://Shared/audit-b/credential"}
+	secretCache.Lock()
+	for _, ref := range refs {
+		secretCache.values[ref] = "sk-shared-0123456789abcdef"
+	}
+	secretCache.Unlock()
+	defer func() {
+		secretCache.Lock()
+		for _, ref := range refs {
+			delete(secretCache.values, ref)
+		}
+		secretCache.Unlock()
+	}()
+
+	config := Config{Settings: &ConfigSettings{Audit: &AuditSettings{Enabled: true}}}
+	for _, ref := range refs {
+		recordLaunch(config, Environment{Name: "prod", APIKey: ref}, []string{"proto"}, nil)
+	}
+
+	records, err := readAuditRecords(0)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("readAuditRecords() = %d records, %v", len(records), err)
+	}
+	want := keyFingerprint("sk-shared-0123456789abcdef")
+	for _, record := range records {
+		if record.KeyFingerprint != want {
+			t.Errorf("fingerprint = %s, want %s (of the resolved key)", record.KeyFingerprint, want)
+		}
+	}
+}
+
+func TestRotateAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := ioutil.WriteFile(path, make([]byte, 2048), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotateAuditLog(path, 1); err != nil {
+		t.Fatalf("rotateAuditLog() failed: %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Error("expected rotated log file")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected original log moved aside")
+	}
+}
+
+func TestParseArgumentsAudit(t *testing.T) {
+	result := parseArguments([]string{"audit", "show", "--last", "5"})
+	if result.Error != nil || result.Subcommand != "audit-show" || result.CCEFlags["last"] != "5" {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+	if r := parseArguments([]string{"audit"}); r.Error == nil {
+		t.Error("expected error without show subcommand")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultAutoFlags are injected by 'cde auto' when settings.auto_flags is not configured
+var defaultAutoFlags = []string{"-a", "never", "--sandbox", "workspace-write"}
+
+// codexFlagAliases maps short codex flags to their long form
+var codexFlagAliases = map[string]string{
+	"-a": "--ask-for-approval",
+	"-s": "--sandbox",
+	"-m": "--model",
+	"-c": "--config",
+	"-p": "--profile",
+	"-C": "--cd",
+	"-i": "--image",
+}
+
+// codexValueFlags are codex flags (long form) that consume the next argument
+var codexValueFlags = map[string]bool{
+	"--ask-for-approval": true,
+	"--sandbox":          true,
+	"--model":            true,
+	"--config":           true,
+	"--profile":          true,
+	"--cd":               true,
+	"--image":            true,
+}
+
+// codexShortcutFlags imply approval and sandbox settings, so they replace both
+var codexShortcutFlags = map[string]bool{
+	"--full-auto": true,
+	"--dangerously-bypass-approvals-and-sandbox": true,
+}
+
+// flagGroup is one codex flag with its value, if any
+type flagGroup struct {
+	name string   // Canonical long flag name
+	args []string // Original tokens
+}
+
+// canonicalFlagName returns the long form of a flag token, without any =value suffix
+func canonicalFlagName(arg string) string {
+	name, _, _ := strings.Cut(arg, "=")
+	if long, ok := codexFlagAliases[name]; ok {
+		return long
+	}
+	return name
+}
+
+// splitFlagGroups splits flags into flag/value groups, reporting a flag missing its value
+func splitFlagGroups(flags []string) ([]flagGroup, error) {
+	groups := []flagGroup{}
+	for i := 0; i < len(flags); i++ {
+		arg := flags[i]
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("'%s' is not a flag", arg)
+		}
+		name := canonicalFlagName(arg)
+		if codexValueFlags[name] && !strings.Contains(arg, "=") {
+			if i+1 >= len(flags) {
+				return nil, fmt.Errorf("flag %s requires a value", arg)
+			}
+			groups = append(groups, flagGroup{name: name, args: flags[i : i+2]})
+			i++
+			continue
+		}
+		groups = append(groups, flagGroup{name: name, args: flags[i : i+1]})
+	}
+	return groups, nil
+}
+
+// validateAutoFlags checks that configured auto flags are well-formed codex flags
+func validateAutoFlags(flags []string) error {
+	if _, err := splitFlagGroups(flags); err != nil {
+		return err
+	}
+	return validatePassthroughArgs(flags)
+}
+
+// autoFlagsFor returns the auto flags for env: per-environment, then settings, then defaults
+func autoFlagsFor(config Config, env Environment) []string {
+	if env.AutoFlags != nil {
+		return env.AutoFlags
+	}
+	if config.Settings != nil && config.Settings.AutoFlags != nil {
+		return config.Settings.AutoFlags
+	}
+	return defaultAutoFlags
+}
+
+// mergeAutoFlags prepends auto flags to args, dropping any flag the user set explicitly.
+// Explicit --full-auto or --dangerously-bypass-approvals-and-sandbox also replaces
+// configured approval and sandbox flags.
+func mergeAutoFlags(autoFlags, args []string) ([]string, error) {
+	groups, err := splitFlagGroups(autoFlags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto flags: %w", err)
+	}
+
+	explicit := make(map[string]bool)
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			explicit[canonicalFlagName(arg)] = true
+		}
+	}
+	for shortcut := range codexShortcutFlags {
+		if explicit[shortcut] {
+			explicit["--ask-for-approval"] = true
+			explicit["--sandbox"] = true
+		}
+	}
+
+	merged := make([]string, 0, len(autoFlags)+len(args))
+	for _, group := range groups {
+		// -c overrides are per-key, so they never conflict as a whole
+		if explicit[group.name] && group.name != "--config" {
+			continue
+		}
+		if codexShortcutFlags[group.name] && (explicit["--ask-for-approval"] || explicit["--sandbox"]) {
+			continue
+		}
+		merged = append(merged, group.args...)
+	}
+	return append(merged, args...), nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeAutoFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		autoFlags []string
+		args      []string
+		want      []string
+	}{
+		{"defaults", defaultAutoFlags, []string{"proto"}, []string{"-a", "never", "--sandbox", "workspace-write", "proto"}},
+		{"explicit sandbox wins", defaultAutoFlags, []string{"-s", "read-only"}, []string{"-a", "never", "-s", "read-only"}},
+		{"explicit approval long form", defaultAutoFlags, []string{"--ask-for-approval=on-request"}, []string{"--sandbox", "workspace-write", "--ask-for-approval=on-request"}},
+		{"full-auto replaces both", defaultAutoFlags, []string{"--full-auto"}, []string{"--full-auto"}},
+		{"configured shortcut dropped by explicit approval", []string{"--full-auto"}, []string{"-a", "never"}, []string{"-a", "never"}},
+		{"config overrides are kept", []string{"-c", "model_reasoning_effort=high"}, []string{"-c", "x=1"}, []string{"-c", "model_reasoning_effort=high", "-c", "x=1"}},
+		{"flags after separator ignored", defaultAutoFlags, []string{"--", "-a"}, []string{"-a", "never", "--sandbox", "workspace-write", "--", "-a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeAutoFlags(tt.autoFlags, tt.args)
+			if err != nil {
+				t.Fatalf("mergeAutoFlags failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeAutoFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAutoFlags(t *testing.T) {
+	valid := [][]string{nil, defaultAutoFlags, {"--full-auto"}, {"--sandbox=read-only"}}
+	for _, flags := range valid {
+		if err := validateAutoFlags(flags); err != nil {
+			t.Errorf("validateAutoFlags(%v) unexpected error: %v", flags, err)
+		}
+	}
+
+	invalid := [][]string{{"-a"}, {"never"}, {"--sandbox", "workspace-write", "extra"}}
+	for _, flags := range invalid {
+		if err := validateAutoFlags(flags); err == nil {
+			t.Errorf("validateAutoFlags(%v) expected error", flags)
+		}
+	}
+}
+
+func TestAutoFlagsFor(t *testing.T) {
+	config := Config{Settings: &ConfigSettings{AutoFlags: []string{"--full-auto"}}}
+	env := Environment{Name: "dev"}
+
+	if got := autoFlagsFor(Config{}, env); !reflect.DeepEqual(got, defaultAutoFlags) {
+		t.Errorf("expected defaults, got %v", got)
+	}
+	if got := autoFlagsFor(config, env); !reflect.DeepEqual(got, []string{"--full-auto"}) {
+		t.Errorf("expected settings flags, got %v", got)
+	}
+	env.AutoFlags = []string{"-a", "on-request"}
+	if got := autoFlagsFor(config, env); !reflect.DeepEqual(got, env.AutoFlags) {
+		t.Errorf("expected environment flags, got %v", got)
+	}
+}
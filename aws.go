@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+)
+
+// authTypeAWS resolves AWS credentials for a SigV4-signing (Bedrock-compatible) endpoint
+const authTypeAWS = "aws"
+
+// awsPlaceholderKey is passed as OPENAI_API_KEY when an AWS environment has no key:
+// codex requires one, while the signing proxy authenticates with AWS credentials
+const awsPlaceholderKey = "aws-sigv4"
+
+var (
+	awsRegionPattern  = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]*)?-[a-z]+-\d+$`)
+	awsProfilePattern = regexp.MustCompile(`^[A-Za-z0-9_.+=,@-]+$`)
+	awsRolePattern    = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/[A-Za-z0-9_+=,.@/-]+$`)
+)
+
+// awsCredentialVars are inherited credentials that would override the configured profile
+var awsCredentialVars = map[string]bool{
+	"AWS_ACCESS_KEY_ID":     true,
+	"AWS_SECRET_ACCESS_KEY": true,
+	"AWS_SESSION_TOKEN":     true,
+}
+
+// validateAWSAuth checks the region, profile and role of an aws auth block
+func validateAWSAuth(auth *AuthSettings) error {
+	if !awsRegionPattern.MatchString(auth.Region) {
+		return fmt.Errorf("region '%s' is not a valid AWS region", auth.Region)
+	}
+	if auth.Profile != "" && !awsProfilePattern.MatchString(auth.Profile) {
+		return fmt.Errorf("invalid AWS profile name '%s'", auth.Profile)
+	}
+	if auth.RoleARN != "" && !awsRolePattern.MatchString(auth.RoleARN) {
+		return fmt.Errorf("role_arn '%s' is not an IAM role ARN", auth.RoleARN)
+	}
+	return nil
+}
+
+// usesAWSAuth reports whether env authenticates with AWS credentials
+func usesAWSAuth(env Environment) bool {
+	return env.Auth != nil && env.Auth.Type == authTypeAWS
+}
+
+// awsEnvVars returns the AWS variables codex and its signing proxy need
+func awsEnvVars(env Environment) map[string]string {
+	if !usesAWSAuth(env) {
+		return nil
+	}
+	vars := map[string]string{
+		"AWS_REGION":         env.Auth.Region,
+		"AWS_DEFAULT_REGION": env.Auth.Region,
+	}
+	// An assumed role is exported as temporary credentials instead of a profile
+	if env.Auth.Profile != "" && env.Auth.RoleARN == "" {
+		vars["AWS_PROFILE"] = env.Auth.Profile
+	}
+	return vars
+}
+
+// awsCLIArgs appends the profile and region options to an aws command
+func awsCLIArgs(auth *AuthSettings, args ...string) []string {
+	if auth.Profile != "" {
+		args = append(args, "--profile", auth.Profile)
+	}
+	return append(args, "--region", auth.Region, "--output", "json")
+}
+
+// runAWSCommand runs the AWS CLI, which may prompt for MFA or SSO sign-in
+func runAWSCommand(auth *AuthSettings, args ...string) ([]byte, error) {
+	output, err := runSecretCommand("aws", awsCLIArgs(auth, args...)...)
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("AWS CLI 'aws' not found in PATH")
+	}
+	return output, err
+}
+
+// resolveAWSCredentials verifies that env's AWS credentials resolve before launch. With
+// role_arn the role is assumed and its temporary credentials are added to EnvVars.
+func resolveAWSCredentials(env Environment) (Environment, error) {
+	auth := env.Auth
+	if env.APIKey == "" {
+		env.APIKey = awsPlaceholderKey
+	}
+
+	if auth.RoleARN == "" {
+		output, err := runAWSCommand(auth, "sts", "get-caller-identity")
+		if err != nil {
+			return env, fmt.Errorf("AWS credentials did not resolve (aws sts get-caller-identity): %w", err)
+		}
+		var identity struct {
+			Arn string `json:"Arn"`
+		}
+		if err := json.Unmarshal(output, &identity); err != nil || identity.Arn == "" {
+			return env, fmt.Errorf("AWS identity response could not be parsed")
+		}
+		logInfof("AWS identity for '%s': %s", env.Name, identity.Arn)
+		return env, nil
+	}
+
+	output, err := runAWSCommand(auth, "sts", "assume-role", "--role-arn", auth.RoleARN, "--role-session-name", "cde-"+env.Name)
+	if err != nil {
+		return env, fmt.Errorf("AWS role assumption failed for %s: %w", auth.RoleARN, err)
+	}
+	var assumed struct {
+		Credentials struct {
+			AccessKeyID     string `json:"AccessKeyId"`
+			SecretAccessKey string `json:"SecretAccessKey"`
+			SessionToken    string `json:"SessionToken"`
+			Expiration      string `json:"Expiration"`
+		} `json:"Credentials"`
+	}
+	if err := json.Unmarshal(output, &assumed); err != nil || assumed.Credentials.AccessKeyID == "" {
+		return env, fmt.Errorf("AWS assume-role response could not be parsed")
+	}
+	logInfof("assumed %s for '%s' until %s", auth.RoleARN, env.Name, assumed.Credentials.Expiration)
+
+	env.EnvVars = copyStringMap(env.EnvVars)
+	if env.EnvVars == nil {
+		env.EnvVars = make(map[string]string)
+	}
+	env.EnvVars["AWS_ACCESS_KEY_ID"] = assumed.Credentials.AccessKeyID
+	env.EnvVars["AWS_SECRET_ACCESS_KEY"] = assumed.Credentials.SecretAccessKey
+	env.EnvVars["AWS_SESSION_TOKEN"] = assumed.Credentials.SessionToken
+	return env, nil
+}
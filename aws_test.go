@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestValidateAWSAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    *AuthSettings
+		wantErr bool
+	}{
+		{name: "region only", auth: &AuthSettings{Type: "aws", Region: "us-west-2"}},
+		{name: "profile", auth: &AuthSettings{Type: "aws", Region: "eu-central-1", Profile: "bedrock-dev"}},
+		{name: "gov region and role", auth: &AuthSettings{Type: "aws", Region: "us-gov-west-1", RoleARN: "arn:aws-us-gov:iam::123456789012:role/path/Bedrock"}},
+		{name: "missing region", auth: &AuthSettings{Type: "aws"}, wantErr: true},
+		{name: "bad region", auth: &AuthSettings{Type: "aws", Region: "uswest2"}, wantErr: true},
+		{name: "bad profile", auth: &AuthSettings{Type: "aws", Region: "us-east-1", Profile: "dev profile"}, wantErr: true},
+		{name: "user arn", auth: &AuthSettings{Type: "aws", Region: "us-east-1", RoleARN: "arn:aws:iam::123456789012:user/alice"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAuthSettings(tt.auth); (err != nil) != tt.wantErr {
+				t.Errorf("validateAuthSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveAWSCredentialsProfile(t *testing.T) {
+	oldRun := runSecretCommand
+	defer func() { runSecretCommand = oldRun }()
+
+	var gotArgs []string
+	runSecretCommand = func(name string, args ...string) ([]byte, error) {
+		gotArgs = append([]string{name}, args...)
+		return []byte(`{"UserId":"AID","Account":"123456789012","Arn":"arn:aws:iam::123456789012:user/dev"}`), nil
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAINHERITED")
+	env := Environment{Name: "bedrock", URL: "http://127.0.0.1:8080/v1", Auth: &AuthSettings{Type: "aws", Region: "us-west-2", Profile: "bedrock-dev"}}
+	resolved, err := resolveEnvironmentSecrets(env)
+	if err != nil {
+		t.Fatalf("resolveEnvironmentSecrets() error = %v", err)
+	}
+	if want := "aws sts get-caller-identity --profile bedrock-dev --region us-west-2 --output json"; strings.Join(gotArgs, " ") != want {
+		t.Errorf("aws invoked as %q, want %q", strings.Join(gotArgs, " "), want)
+	}
+	if resolved.APIKey != awsPlaceholderKey {
+		t.Errorf("APIKey = %q, want placeholder", resolved.APIKey)
+	}
+
+	vars, err := prepareEnvironment(resolved)
+	if err != nil {
+		t.Fatalf("prepareEnvironment() error = %v", err)
+	}
+	for _, want := range []string{"AWS_PROFILE=bedrock-dev", "AWS_REGION=us-west-2", "AWS_DEFAULT_REGION=us-west-2"} {
+		if !contains(vars, want) {
+			t.Errorf("launch environment missing %s", want)
+		}
+	}
+	if contains(vars, "AWS_ACCESS_KEY_ID=AKIAINHERITED") {
+		t.Error("inherited AWS credentials should not override the profile")
+	}
+}
+
+func TestResolveAWSCredentialsRole(t *testing.T) {
+	oldRun := runSecretCommand
+	defer func() { runSecretCommand = oldRun }()
+
+	runSecretCommand = func(name string, args ...string) ([]byte, error) {
+		if len(args) < 2 || args[1] != "assume-role" {
+			return nil, fmt.Errorf("unexpected command %v", args)
+		}
+		return []byte(`{"Credentials":{"AccessKeyId":"ASIATEMP","SecretAccessKey":"temp-secret","SessionToken":"temp-token","Expiration":"2026-10-16T13:00:00Z"}}`), nil
+	}
+
+	env := Environment{Name: "bedrock", URL: "http://127.0.0.1:8080/v1", APIKey: "proxy-key", EnvVars: map[string]string{"EXTRA": "1"},
+		Auth: &AuthSettings{Type: "aws", Region: "us-east-1", Profile: "base", RoleARN: "arn:aws:iam::123456789012:role/Bedrock"}}
+	resolved, err := resolveEnvironmentSecrets(env)
+	if err != nil {
+		t.Fatalf("resolveEnvironmentSecrets() error = %v", err)
+	}
+	if resolved.APIKey != "proxy-key" {
+		t.Errorf("configured API key replaced: %q", resolved.APIKey)
+	}
+	if resolved.EnvVars["AWS_ACCESS_KEY_ID"] != "ASIATEMP" || resolved.EnvVars["AWS_SESSION_TOKEN"] != "temp-token" {
+		t.Errorf("temporary credentials not exported: %v", resolved.EnvVars)
+	}
+	if _, exists := env.EnvVars["AWS_ACCESS_KEY_ID"]; exists {
+		t.Error("original environment was modified")
+	}
+	if _, exists := awsEnvVars(resolved)["AWS_PROFILE"]; exists {
+		t.Error("AWS_PROFILE should not be set alongside assumed-role credentials")
+	}
+}
+
+func TestResolveAWSCredentialsFailure(t *testing.T) {
+	oldRun := runSecretCommand
+	defer func() { runSecretCommand = oldRun }()
+
+	runSecretCommand = func(name string, args ...string) ([]byte, error) {
+		return nil, &exec.Error{Name: "aws", Err: exec.ErrNotFound}
+	}
+	env := Environment{Name: "bedrock", URL: "http://127.0.0.1:8080/v1", Auth: &AuthSettings{Type: "aws", Region: "us-east-1"}}
+	_, err := resolveEnvironmentSecrets(env)
+	if err == nil || errorCodeOf(err) != codeAuthFailed || !strings.Contains(err.Error(), "not found in PATH") {
+		t.Errorf("error = %v, want %s mentioning the missing CLI", err, codeAuthFailed)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyDeploymentMap(t *testing.T) {
+	env := Environment{
+		Name:          "azure-prod",
+		URL:           "https://myres.openai.azure.com/openai",
+		APIKey:        "azure-key",
+		Provider:      "azure",
+		Model:         "gpt-5",
+		DeploymentMap: map[string]string{"gpt-5": "prod-gpt5", "o4-mini": "prod-o4"},
+	}
+
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"env model mapped", []string{"proto"}, []string{"-m", "prod-gpt5", "proto"}},
+		{"user model mapped", []string{"-m", "o4-mini"}, []string{"-m", "prod-o4"}},
+		{"unknown model untouched", []string{"-m", "gpt-4.1"}, []string{"-m", "gpt-4.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prepareCodexArgs(env, tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prepareCodexArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := applyDeploymentMap(env, []string{"--model=o4-mini"}); got[0] != "--model=prod-o4" {
+		t.Errorf("expected --model= form to be mapped, got %v", got)
+	}
+
+	// Non-azure environments ignore the map
+	env.Provider = "openai"
+	if got := applyDeploymentMap(env, []string{"-m", "gpt-5"}); got[1] != "gpt-5" {
+		t.Errorf("non-azure environment should not map models, got %v", got)
+	}
+}
+
+func TestAzureProviderEnvVars(t *testing.T) {
+	env := Environment{
+		Name:          "azure-prod",
+		URL:           "https://myres.openai.azure.com/openai",
+		APIKey:        "azure-key",
+		Provider:      "azure",
+		Model:         "gpt-5",
+		APIVersion:    "2025-04-01-preview",
+		DeploymentMap: map[string]string{"gpt-5": "prod-gpt5"},
+		EnvVars:       map[string]string{"AZURE_OPENAI_API_VERSION": "old"},
+	}
+
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		t.Fatalf("prepareEnvironment() failed: %v", err)
+	}
+
+	found := make(map[string][]string)
+	for _, envVar := range envVars {
+		key, value, _ := strings.Cut(envVar, "=")
+		found[key] = append(found[key], value)
+	}
+
+	expect := map[string]string{
+		"AZURE_OPENAI_API_VERSION": "2025-04-01-preview",
+		"OPENAI_API_VERSION":       "2025-04-01-preview",
+		"AZURE_OPENAI_API_KEY":     "azure-key",
+		"OPENAI_MODEL":             "prod-gpt5",
+	}
+	for key, want := range expect {
+		if values := found[key]; len(values) != 1 || values[0] != want {
+			t.Errorf("%s = %v, want [%s]", key, values, want)
+		}
+	}
+}
+
+func TestValidateAzureFields(t *testing.T) {
+	env := Environment{
+		Name:          "azure",
+		URL:           "https://myres.openai.azure.com/openai",
+		APIKey:        "k",
+		DeploymentMap: map[string]string{"gpt-5": ""},
+	}
+	if err := validateEnvironment(env); err == nil {
+		t.Error("expected error for empty deployment name")
+	}
+
+	env.DeploymentMap = nil
+	env.APIVersion = "2024 bad"
+	if err := validateEnvironment(env); err == nil {
+		t.Error("expected error for invalid API version")
+	}
+}
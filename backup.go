@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultBackupMaxCount bounds the backups directory when no retention is configured
+const defaultBackupMaxCount = 20
+
+// backupTimestampLayout matches the timestamp createBackup embeds in file names
+const backupTimestampLayout = "20060102-150405"
+
+// BackupSettings configures retention of automatic config backups
+type BackupSettings struct {
+	MaxCount   int   `json:"max_count,omitempty"`    // Keep at most this many backups (default 20)
+	MaxAgeDays int   `json:"max_age_days,omitempty"` // Delete backups older than this; 0 keeps all ages
+	OnSave     *bool `json:"on_save,omitempty"`      // Back up before every save (default true); destructive commands still take labeled backups
+}
+
+// backupInfo describes one backup file
+type backupInfo struct {
+	ID      string // File name without "config-" and ".json", used by 'backup restore'
+	Path    string
+	Created time.Time
+	Size    int64
+}
+
+// backupRetention returns the configured max count and max age
+func backupRetention(config Config) (int, time.Duration) {
+	maxCount := defaultBackupMaxCount
+	var maxAge time.Duration
+	if config.Settings != nil && config.Settings.Backup != nil {
+		if config.Settings.Backup.MaxCount > 0 {
+			maxCount = config.Settings.Backup.MaxCount
+		}
+		if config.Settings.Backup.MaxAgeDays > 0 {
+			maxAge = time.Duration(config.Settings.Backup.MaxAgeDays) * 24 * time.Hour
+		}
+	}
+	return maxCount, maxAge
+}
+
+// backupOnSave reports whether every save backs up the previous file (settings.backup.on_save)
+func backupOnSave(config Config) bool {
+	if config.Settings == nil || config.Settings.Backup == nil || config.Settings.Backup.OnSave == nil {
+		return true
+	}
+	return *config.Settings.Backup.OnSave
+}
+
+// backupID returns the ID 'backup restore' accepts for a backup file: the timestamp of an
+// automatic backup, or the whole name of a labeled one
+func backupID(path string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "config-"), ".json")
+}
+
+// listBackups returns backups sorted oldest first
+func (cb *configBackup) listBackups() ([]backupInfo, error) {
+	entries, err := ioutil.ReadDir(cb.backupDir)
+	if os.IsNotExist(err) {
+		return []backupInfo{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	backups := []backupInfo{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasPrefix(name, "config-") && !strings.HasPrefix(name, "pre-")) || filepath.Ext(name) != ".json" {
+			continue
+		}
+		id := backupID(name)
+		timestamp := id
+		if len(id) > len(backupTimestampLayout) {
+			timestamp = id[len(id)-len(backupTimestampLayout):]
+		}
+		created, err := time.ParseInLocation(backupTimestampLayout, timestamp, time.Local)
+		if err != nil {
+			created = entry.ModTime()
+		}
+		backups = append(backups, backupInfo{
+			ID:      id,
+			Path:    filepath.Join(cb.backupDir, name),
+			Created: created,
+			Size:    entry.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Created.Before(backups[j].Created) })
+	return backups, nil
+}
+
+// prune deletes backups beyond maxCount (oldest first) or older than maxAge, returning their IDs
+func (cb *configBackup) prune(maxCount int, maxAge time.Duration, now time.Time) ([]string, error) {
+	backups, err := cb.listBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for i, backup := range backups {
+		overCount := maxCount > 0 && len(backups)-i > maxCount
+		tooOld := maxAge > 0 && now.Sub(backup.Created) > maxAge
+		if !overCount && !tooOld {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, fmt.Errorf("failed to delete backup %s: %w", backup.ID, err)
+		}
+		removed = append(removed, backup.ID)
+	}
+	return removed, nil
+}
+
+// findBackup resolves a backup by ID or file name
+func (cb *configBackup) findBackup(id string) (backupInfo, error) {
+	id = backupID(id)
+	backups, err := cb.listBackups()
+	if err != nil {
+		return backupInfo{}, err
+	}
+	for _, backup := range backups {
+		if backup.ID == id {
+			return backup, nil
+		}
+	}
+	return backupInfo{}, fmt.Errorf("backup '%s' not found (see 'cde backup list')", id)
+}
+
+// restore replaces the configuration with a backup, backing up the current file first
+func (cb *configBackup) restore(id string) (backupInfo, error) {
+	backup, err := cb.findBackup(id)
+	if err != nil {
+		return backupInfo{}, err
+	}
+	if err := detectCorruption(backup.Path); err != nil {
+		return backupInfo{}, fmt.Errorf("backup %s is not usable: %w", backup.ID, err)
+	}
+
+	if _, err := cb.createBackup(); err != nil {
+		return backupInfo{}, fmt.Errorf("failed to back up current configuration: %w", err)
+	}
+	if err := copyFile(backup.Path, cb.originalPath); err != nil {
+		return backupInfo{}, fmt.Errorf("failed to restore backup %s: %w", backup.ID, err)
+	}
+	return backup, nil
+}
+
+// currentConfigBackup returns the backup manager for the active config path
+func currentConfigBackup() (*configBackup, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("configuration loading failed: %w", err)
+	}
+	return newConfigBackup(configPath), nil
+}
+
+// runBackupList prints available backups, newest first
+func runBackupList() error {
+	cb, err := currentConfigBackup()
+	if err != nil {
+		return err
+	}
+	backups, err := cb.listBackups()
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		fmt.Println("No backups found.")
+		return nil
+	}
+
+	fmt.Printf("Backups in %s (%d):\n", cb.backupDir, len(backups))
+	for i := len(backups) - 1; i >= 0; i-- {
+		backup := backups[i]
+		fmt.Printf("  %s  %s  %d bytes\n", backup.ID, backup.Created.Format("2006-01-02 15:04:05"), backup.Size)
+	}
+	return nil
+}
+
+// runBackupRestore restores the configuration from a backup ID
+func runBackupRestore(id string) error {
+	if err := requireWritableConfig(); err != nil {
+		return err
+	}
+
+	cb, err := currentConfigBackup()
+	if err != nil {
+		return err
+	}
+
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	backup, err := cb.restore(id)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the restored file loads cleanly
+	if _, err := loadConfig(); err != nil {
+		return fmt.Errorf("restored backup %s but configuration validation failed: %w", backup.ID, err)
+	}
+
+	fmt.Printf("Configuration restored from backup %s.\n", backup.ID)
+	return nil
+}
+
+// runBackupPrune applies the retention policy now
+func runBackupPrune() error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	cb, err := currentConfigBackup()
+	if err != nil {
+		return err
+	}
+
+	maxCount, maxAge := backupRetention(config)
+	removed, err := cb.prune(maxCount, maxAge, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pruned %d backup(s).\n", len(removed))
+	return nil
+}
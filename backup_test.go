@@ -0,0 +1,186 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeTestBackups creates backup files with the given timestamp IDs
+func writeTestBackups(t *testing.T, cb *configBackup, ids ...string) {
+	t.Helper()
+	if err := os.MkdirAll(cb.backupDir, 0700); err != nil {
+		t.Fatalf("failed to create backup dir: %v", err)
+	}
+	for _, id := range ids {
+		path := filepath.Join(cb.backupDir, "config-"+id+".json")
+		if err := ioutil.WriteFile(path, []byte(`{"environments":[]}`), 0600); err != nil {
+			t.Fatalf("failed to write backup: %v", err)
+		}
+	}
+}
+
+func TestBackupListAndPrune(t *testing.T) {
+	cb := newConfigBackup(filepath.Join(t.TempDir(), "config.json"))
+	writeTestBackups(t, cb, "20260103-120000", "20260101-120000", "20260102-120000", "20260104-120000")
+
+	backups, err := cb.listBackups()
+	if err != nil {
+		t.Fatalf("listBackups failed: %v", err)
+	}
+	ids := []string{}
+	for _, backup := range backups {
+		ids = append(ids, backup.ID)
+	}
+	want := []string{"20260101-120000", "20260102-120000", "20260103-120000", "20260104-120000"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("listBackups order = %v, want %v", ids, want)
+	}
+
+	removed, err := cb.prune(3, 0, time.Now())
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []string{"20260101-120000"}) {
+		t.Errorf("prune by count removed %v", removed)
+	}
+
+	now := time.Date(2026, 1, 4, 13, 0, 0, 0, time.Local)
+	removed, err = cb.prune(0, 36*time.Hour, now)
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []string{"20260102-120000"}) {
+		t.Errorf("prune by age removed %v", removed)
+	}
+
+	if backups, _ := cb.listBackups(); len(backups) != 2 {
+		t.Errorf("expected 2 backups left, got %d", len(backups))
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+
+	first := Config{Environments: []Environment{{Name: "first", URL: "https://a.example.com", APIKey: "sk-first1234567890"}}}
+	if err := saveConfig(first); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	cb := newConfigBackup(configPathOverride)
+	backupPath, err := cb.createBackup()
+	if err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+	// Rename to a fixed ID so the next save cannot collide within the same second
+	fixedPath := filepath.Join(cb.backupDir, "config-20200101-000000.json")
+	if err := os.Rename(backupPath, fixedPath); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+
+	second := Config{Environments: []Environment{{Name: "second", URL: "https://b.example.com", APIKey: "sk-second123456789"}}}
+	if err := saveConfig(second); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := handleCommand([]string{"backup", "restore", "20200101-000000"}); err != nil {
+		t.Fatalf("backup restore failed: %v", err)
+	}
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(loaded.Environments) != 1 || loaded.Environments[0].Name != "first" {
+		t.Errorf("expected restored config, got %+v", loaded.Environments)
+	}
+
+	if _, err := cb.findBackup("19990101-000000"); err == nil {
+		t.Error("expected error for unknown backup id")
+	}
+}
+
+func TestLabeledBackups(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+
+	onSave := false
+	config := Config{
+		Environments: []Environment{
+			{Name: "prod", URL: "https://a.example.com", APIKey: "sk-prod1234567890"},
+			{Name: "dev", URL: "https://b.example.com", APIKey: "sk-dev12345678901"},
+		},
+		Settings: &ConfigSettings{Backup: &BackupSettings{OnSave: &onSave}},
+	}
+	for i := 0; i < 2; i++ {
+		if err := saveConfig(config); err != nil {
+			t.Fatalf("saveConfig failed: %v", err)
+		}
+	}
+	cb := newConfigBackup(configPathOverride)
+	if backups, _ := cb.listBackups(); len(backups) != 0 {
+		t.Fatalf("on_save=false still created %d backup(s)", len(backups))
+	}
+
+	if err := handleCommand([]string{"remove", "dev", "--force", "--no-backup"}); err != nil {
+		t.Fatalf("remove --no-backup failed: %v", err)
+	}
+	if backups, _ := cb.listBackups(); len(backups) != 0 {
+		t.Fatalf("--no-backup created %d backup(s)", len(backups))
+	}
+
+	// Destructive commands take a labeled backup even with on_save disabled
+	if err := handleCommand([]string{"remove", "prod", "--force"}); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	backups, err := cb.listBackups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected one labeled backup, got %v (%v)", backups, err)
+	}
+	id := backups[0].ID
+	if len(id) != len("pre-remove-prod-20060102-150405") || id[:len("pre-remove-prod-")] != "pre-remove-prod-" {
+		t.Fatalf("labeled backup ID = %s", id)
+	}
+	if time.Since(backups[0].Created) > time.Minute {
+		t.Errorf("created time %v not parsed from the labeled name", backups[0].Created)
+	}
+
+	if err := handleCommand([]string{"backup", "restore", id}); err != nil {
+		t.Fatalf("backup restore %s failed: %v", id, err)
+	}
+	loaded, err := loadConfig()
+	if err != nil || len(loaded.Environments) != 1 || loaded.Environments[0].Name != "prod" {
+		t.Errorf("restored config = %+v, %v", loaded.Environments, err)
+	}
+}
+
+func TestBackupRetention(t *testing.T) {
+	count, age := backupRetention(Config{})
+	if count != defaultBackupMaxCount || age != 0 {
+		t.Errorf("unexpected defaults: %d, %v", count, age)
+	}
+
+	config := Config{Settings: &ConfigSettings{Backup: &BackupSettings{MaxCount: 5, MaxAgeDays: 2}}}
+	count, age = backupRetention(config)
+	if count != 5 || age != 48*time.Hour {
+		t.Errorf("unexpected retention: %d, %v", count, age)
+	}
+}
+
+func TestParseArgumentsBackup(t *testing.T) {
+	if r := parseArguments([]string{"backup", "list"}); r.Error != nil || r.Subcommand != "backup-list" {
+		t.Errorf("unexpected parse result: %+v", r)
+	}
+	if r := parseArguments([]string{"backup", "restore", "20260101-120000"}); r.Error != nil || r.CCEFlags["backup_id"] != "20260101-120000" {
+		t.Errorf("unexpected parse result: %+v", r)
+	}
+	for _, args := range [][]string{{"backup"}, {"backup", "restore"}, {"backup", "drop"}} {
+		if r := parseArguments(args); r.Error == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
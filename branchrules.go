@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// projectConfigName is the per-repository file read from the workspace root
+const projectConfigName = ".cde.json"
+
+// branchRuleSourcePrefix starts the launch plan source of an environment chosen by a branch rule
+const branchRuleSourcePrefix = "branch rule "
+
+// projectConfig is the content of a repository's .cde.json
+type projectConfig struct {
+	BranchRules map[string]string `json:"branch_rules,omitempty"` // Branch name or glob -> environment; "*" matches any branch
+}
+
+// loadProjectConfig reads .cde.json from workspace; a missing file is an empty config
+func loadProjectConfig(workspace string) (projectConfig, string, error) {
+	var project projectConfig
+	file := filepath.Join(workspace, projectConfigName)
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return project, "", nil
+	}
+	if err != nil {
+		return project, file, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if err := json.Unmarshal(stripJSONC(data), &project); err != nil {
+		return project, file, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	return project, file, nil
+}
+
+// gitDir returns the git directory of the repository rooted at workspace, following the
+// "gitdir:" file that worktrees and submodules use
+func gitDir(workspace string) (string, error) {
+	dotGit := filepath.Join(workspace, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+	data, err := ioutil.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(content, "gitdir:") {
+		return "", fmt.Errorf("unrecognized .git file in %s", workspace)
+	}
+	target := strings.TrimSpace(strings.TrimPrefix(content, "gitdir:"))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(workspace, target)
+	}
+	return target, nil
+}
+
+// currentBranch reads the checked-out branch from .git/HEAD without running git. A detached
+// HEAD has no branch and returns "".
+func currentBranch(workspace string) (string, error) {
+	dir, err := gitDir(workspace)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read git HEAD: %w", err)
+	}
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref: refs/heads/") {
+		return "", nil
+	}
+	return strings.TrimPrefix(head, "ref: refs/heads/"), nil
+}
+
+// matchBranchRule returns the rule key selecting branch: an exact name first, then the longest
+// matching glob (e.g. "release/*"), then "*", which also covers a detached HEAD
+func matchBranchRule(rules map[string]string, branch string) (string, bool) {
+	if _, exact := rules[branch]; exact && branch != "" {
+		return branch, true
+	}
+	best := ""
+	for pattern := range rules {
+		if pattern == "*" || branch == "" || !strings.ContainsAny(pattern, "*?[") {
+			continue
+		}
+		if matched, _ := path.Match(pattern, branch); matched && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best != "" {
+		return best, true
+	}
+	if _, catchAll := rules["*"]; catchAll {
+		return "*", true
+	}
+	return "", false
+}
+
+// validateBranchRules checks the rule patterns and environment names
+func validateBranchRules(rules map[string]string) error {
+	for pattern, name := range rules {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid branch pattern '%s'", pattern)
+		}
+		if err := validateName(name); err != nil {
+			return fmt.Errorf("branch rule '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// branchRuleEnvironment returns the environment the working directory's .cde.json assigns to
+// the current git branch, and the source describing the rule. A rule naming an environment that
+// is not configured is an error rather than a silent fallback to the menu.
+func branchRuleEnvironment(config Config) (string, string, error) {
+	dir, err := workingDir()
+	if err != nil {
+		return "", "", nil
+	}
+	workspace := workspaceRoot(dir)
+	project, file, err := loadProjectConfig(workspace)
+	if err != nil || len(project.BranchRules) == 0 {
+		return "", "", err
+	}
+	if err := validateBranchRules(project.BranchRules); err != nil {
+		return "", "", fmt.Errorf("invalid %s: %w", file, err)
+	}
+
+	branch, err := currentBranch(workspace)
+	if err != nil {
+		logDebugf("branch rules skipped: %v", err)
+		return "", "", nil
+	}
+	rule, found := matchBranchRule(project.BranchRules, branch)
+	if !found {
+		return "", "", nil
+	}
+	name := project.BranchRules[rule]
+	if _, exists := findEnvironmentByName(config, name); !exists {
+		return "", "", fmt.Errorf("%s maps branch rule '%s' to environment '%s', which is not configured", file, rule, name)
+	}
+	if branch == "" {
+		branch = "(detached HEAD)"
+	}
+	return name, fmt.Sprintf("%s'%s' for branch %s", branchRuleSourcePrefix, rule, branch), nil
+}
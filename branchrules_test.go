@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchBranchRule(t *testing.T) {
+	rules := map[string]string{"main": "prod", "release/*": "staging", "release/2026-*": "freeze", "*": "dev"}
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"main", "main"},
+		{"release/1.4", "release/*"},
+		{"release/2026-q4", "release/2026-*"},
+		{"feature/login", "*"},
+		{"", "*"},
+	}
+	for _, tt := range tests {
+		if got, _ := matchBranchRule(rules, tt.branch); got != tt.want {
+			t.Errorf("matchBranchRule(%q) = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+	if _, found := matchBranchRule(map[string]string{"main": "prod"}, "dev"); found {
+		t.Error("expected no rule for an unlisted branch without '*'")
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"branch", "ref: refs/heads/feature/x\n", "feature/x"},
+		{"detached", "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := t.TempDir()
+			if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte(tt.head), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := currentBranch(repo); err != nil || got != tt.want {
+				t.Errorf("currentBranch() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	// Worktrees and submodules point at their git directory from a .git file
+	worktree := t.TempDir()
+	gitDir := filepath.Join(t.TempDir(), "worktrees", "wt")
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/hotfix\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := currentBranch(worktree); err != nil || got != "hotfix" {
+		t.Errorf("currentBranch(worktree) = %q, %v; want hotfix", got, err)
+	}
+}
+
+func TestBranchRulesSelectEnvironment(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+	t.Setenv("CDE_ENV", "")
+
+	config := Config{Environments: []Environment{
+		{Name: "dev", URL: "https://api.openai.com/v1", APIKey: "sk-dev-123456"},
+		{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-123456"},
+	}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(projectConfigName, `{
+  // prod credentials only on main
+  "branch_rules": {"main": "prod", "*": "dev"}
+}`)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	for branch, want := range map[string]string{"main": "prod", "experiment": "dev"} {
+		writeFile(filepath.Join(".git", "HEAD"), "ref: refs/heads/"+branch+"\n")
+		plan, err := planLaunch("", nil, launchOptions{NoPrompt: true})
+		if err != nil {
+			t.Fatalf("planLaunch() on %s error = %v", branch, err)
+		}
+		if plan.Environment.Name != want || !strings.HasPrefix(plan.Source, branchRuleSourcePrefix) {
+			t.Errorf("on %s selected %q via %q, want %q via a branch rule", branch, plan.Environment.Name, plan.Source, want)
+		}
+	}
+
+	// A rule naming an environment that does not exist fails instead of opening the menu
+	writeFile(projectConfigName, `{"branch_rules": {"*": "staging"}}`)
+	if _, err := planLaunch("", nil, launchOptions{NoPrompt: true}); err == nil || !strings.Contains(err.Error(), "staging") {
+		t.Errorf("expected an error naming the missing environment, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+// Budget actions
+const (
+	budgetActionWarn  = "warn"
+	budgetActionBlock = "block"
+)
+
+// usageRetentionDays is how many days of launch counts usage.json keeps
+const usageRetentionDays = 31
+
+// BudgetSettings limits how often an environment may be launched
+type BudgetSettings struct {
+	MaxLaunchesPerDay int    `json:"max_launches_per_day,omitempty"`
+	Action            string `json:"action,omitempty"` // "warn" (default) or "block"; --override bypasses a block
+}
+
+// usageCounts records launches per local day (YYYY-MM-DD) and environment
+type usageCounts struct {
+	Days     map[string]map[string]int `json:"days"`
+	LastUsed map[string]time.Time      `json:"last_used,omitempty"` // Latest launch of each environment, for 'list --sort recent'
+	Launches map[string][]time.Time    `json:"launches,omitempty"`  // Launch times within the last hour of rate-limited environments
+}
+
+// validateBudgetSettings checks limits and the action name
+func validateBudgetSettings(budget *BudgetSettings) error {
+	if budget == nil {
+		return nil
+	}
+	if budget.MaxLaunchesPerDay < 0 {
+		return fmt.Errorf("max_launches_per_day must not be negative")
+	}
+	switch budget.Action {
+	case "", budgetActionWarn, budgetActionBlock:
+		return nil
+	}
+	return fmt.Errorf("unknown budget action '%s' (use warn or block)", budget.Action)
+}
+
+// getUsagePath returns the active profile's launch counter file
+func getUsagePath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "usage.json"), nil
+}
+
+// loadUsage reads usage.json; a missing or unreadable file counts as no usage
+func loadUsage() usageCounts {
+	usage := usageCounts{Days: map[string]map[string]int{}}
+	path, err := getUsagePath()
+	if err != nil {
+		return usage
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, &usage)
+	}
+	if usage.Days == nil {
+		usage.Days = map[string]map[string]int{}
+	}
+	return usage
+}
+
+// usageDay returns the counter key for t
+func usageDay(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+// launchesToday returns how many times envName was launched today
+func launchesToday(envName string, now time.Time) int {
+	return loadUsage().Days[usageDay(now)][envName]
+}
+
+// recordUsage notes a launch of envName as its last use and, when counted, adds it to today's
+// launch count, dropping days past the retention window. Launch times are kept for an hour
+// when timed (rate-limited environments).
+func recordUsage(envName string, now time.Time, counted, timed bool) error {
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	usage := loadUsage()
+	if usage.LastUsed == nil {
+		usage.LastUsed = map[string]time.Time{}
+	}
+	usage.LastUsed[envName] = now.UTC()
+	if counted {
+		today := usageDay(now)
+		if usage.Days[today] == nil {
+			usage.Days[today] = map[string]int{}
+		}
+		usage.Days[today][envName]++
+	}
+	for name, launches := range usage.Launches {
+		if usage.Launches[name] = recentLaunches(launches, now); len(usage.Launches[name]) == 0 {
+			delete(usage.Launches, name)
+		}
+	}
+	if timed {
+		if usage.Launches == nil {
+			usage.Launches = map[string][]time.Time{}
+		}
+		usage.Launches[envName] = append(usage.Launches[envName], now.UTC())
+	}
+
+	days := make([]string, 0, len(usage.Days))
+	for day := range usage.Days {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for len(days) > usageRetentionDays {
+		delete(usage.Days, days[0])
+		days = days[1:]
+	}
+
+	path, err := getUsagePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return fmt.Errorf("usage serialization failed: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("usage write failed: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("usage write failed: %w", err)
+	}
+	return nil
+}
+
+// checkBudget enforces env's budget before a launch: it warns when the daily limit is
+// reached, or fails when the action is block and override is not set
+func checkBudget(env Environment, override bool, now time.Time) error {
+	if env.Budget == nil || env.Budget.MaxLaunchesPerDay == 0 {
+		return nil
+	}
+	used := launchesToday(env.Name, now)
+	if used < env.Budget.MaxLaunchesPerDay {
+		return nil
+	}
+
+	message := fmt.Sprintf("environment '%s' reached its budget of %d launches today (%d used)", env.Name, env.Budget.MaxLaunchesPerDay, used)
+	if env.Budget.Action == budgetActionBlock && !override {
+		return withErrorCode(codeBudgetExceeded, fmt.Errorf("launch blocked: %s; rerun with --override to launch anyway", message))
+	}
+	if override && env.Budget.Action == budgetActionBlock {
+		fmt.Fprintf(os.Stderr, "Warning: %s; launching because of --override\n", message)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	}
+	return nil
+}
+
+// recordLaunchUsage records when env was last launched, counts the launch for environments
+// with a budget and keeps its time for rate-limited ones; failures only warn
+func recordLaunchUsage(env Environment, now time.Time) {
+	counted := env.Budget != nil && env.Budget.MaxLaunchesPerDay > 0
+	if !counted && !rateLimited(env) && envOnlySource() != "" {
+		// Env-only mode leaves the config directory alone unless a budget or rate limit needs counting
+		return
+	}
+	if err := recordUsage(env.Name, now, counted, rateLimited(env)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: launch count not recorded: %v\n", err)
+	}
+}
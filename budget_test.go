@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateBudgetSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  *BudgetSettings
+		wantErr bool
+	}{
+		{name: "none", budget: nil},
+		{name: "warn by default", budget: &BudgetSettings{MaxLaunchesPerDay: 5}},
+		{name: "block", budget: &BudgetSettings{MaxLaunchesPerDay: 5, Action: "block"}},
+		{name: "negative limit", budget: &BudgetSettings{MaxLaunchesPerDay: -1}, wantErr: true},
+		{name: "unknown action", budget: &BudgetSettings{MaxLaunchesPerDay: 5, Action: "deny"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateBudgetSettings(tt.budget); (err != nil) != tt.wantErr {
+				t.Errorf("validateBudgetSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	blocked := Environment{Name: "expensive", Budget: &BudgetSettings{MaxLaunchesPerDay: 2, Action: "block"}}
+	warned := Environment{Name: "cheap", Budget: &BudgetSettings{MaxLaunchesPerDay: 1}}
+
+	for i := 0; i < 2; i++ {
+		if err := checkBudget(blocked, false, now); err != nil {
+			t.Fatalf("launch %d blocked early: %v", i+1, err)
+		}
+		recordLaunchUsage(blocked, now)
+	}
+	if got := launchesToday("expensive", now); got != 2 {
+		t.Fatalf("launchesToday() = %d, want 2", got)
+	}
+
+	err := checkBudget(blocked, false, now)
+	if err == nil || errorCodeOf(err) != codeBudgetExceeded {
+		t.Fatalf("checkBudget() error = %v, want %s", err, codeBudgetExceeded)
+	}
+	if err := checkBudget(blocked, true, now); err != nil {
+		t.Errorf("--override should bypass the block: %v", err)
+	}
+
+	// The counter resets the next day
+	if err := checkBudget(blocked, false, now.Add(24*time.Hour)); err != nil {
+		t.Errorf("budget not reset on a new day: %v", err)
+	}
+
+	recordLaunchUsage(warned, now)
+	if err := checkBudget(warned, false, now); err != nil {
+		t.Errorf("warn action should not block: %v", err)
+	}
+}
+
+func TestRecordUsageRetention(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	for day := 0; day < usageRetentionDays+5; day++ {
+		if err := recordUsage("dev", start.AddDate(0, 0, day), true, false); err != nil {
+			t.Fatalf("recordUsage() error = %v", err)
+		}
+	}
+	usage := loadUsage()
+	if len(usage.Days) != usageRetentionDays {
+		t.Errorf("kept %d days, want %d", len(usage.Days), usageRetentionDays)
+	}
+	if _, exists := usage.Days[usageDay(start)]; exists {
+		t.Error("oldest day was not pruned")
+	}
+}
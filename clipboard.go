@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardTool reads and clears the system clipboard through an external command
+type clipboardTool struct {
+	name  string
+	read  []string // Prints the clipboard contents to stdout
+	clear []string // Empties the clipboard (run with empty stdin)
+}
+
+// clipboardTools lists the commands tried in order for the current platform; tests replace it
+var clipboardTools = systemClipboardTools
+
+// systemClipboardTools returns the clipboard commands for this OS, preferring Wayland over X11
+func systemClipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{name: "pbpaste", read: []string{"pbpaste"}, clear: []string{"pbcopy"}}}
+	case "windows":
+		return []clipboardTool{{
+			name:  "powershell",
+			read:  []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"},
+			clear: []string{"powershell", "-NoProfile", "-Command", "Add-Type -AssemblyName System.Windows.Forms; [System.Windows.Forms.Clipboard]::Clear()"},
+		}}
+	}
+	tools := []clipboardTool{}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{name: "wl-paste", read: []string{"wl-paste", "--no-newline"}, clear: []string{"wl-copy", "--clear"}})
+	}
+	return append(tools,
+		clipboardTool{name: "xclip", read: []string{"xclip", "-selection", "clipboard", "-o"}, clear: []string{"xclip", "-selection", "clipboard", "-i"}},
+		clipboardTool{name: "xsel", read: []string{"xsel", "--clipboard", "--output"}, clear: []string{"xsel", "--clipboard", "--clear"}},
+	)
+}
+
+// findClipboardTool returns the first clipboard tool installed on this system
+func findClipboardTool() (clipboardTool, error) {
+	tools := clipboardTools()
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool.read[0]); err == nil {
+			return tool, nil
+		}
+		names = append(names, tool.name)
+	}
+	return clipboardTool{}, fmt.Errorf("no clipboard tool found (tried %s)", strings.Join(names, ", "))
+}
+
+// readClipboardKey reads an API key from the clipboard, trimming surrounding whitespace.
+// With clear set, the clipboard is emptied right after reading, even if the key is invalid.
+func readClipboardKey(clear bool) (string, error) {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return "", err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(tool.read[0], tool.read[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w", tool.name, err)
+	}
+	key := strings.TrimSpace(stdout.String())
+
+	if clear {
+		clearCmd := exec.Command(tool.clear[0], tool.clear[1:]...)
+		clearCmd.Stdin = strings.NewReader("")
+		if err := clearCmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to clear the clipboard: %v\n", err)
+		}
+	}
+
+	if key == "" {
+		return "", fmt.Errorf("clipboard is empty")
+	}
+	if err := validateAPIKey(key); err != nil {
+		return "", fmt.Errorf("clipboard does not hold a valid API key: %w", err)
+	}
+	return key, nil
+}
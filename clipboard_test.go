@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReadClipboardKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard tools use sh")
+	}
+	original := clipboardTools
+	defer func() { clipboardTools = original }()
+
+	dir := t.TempDir()
+	cleared := filepath.Join(dir, "cleared")
+	fakeTool := func(contents string) func() []clipboardTool {
+		return func() []clipboardTool {
+			return []clipboardTool{{
+				name:  "fake",
+				read:  []string{"sh", "-c", "printf '%s' \"$0\"", contents},
+				clear: []string{"sh", "-c", "touch \"$0\"", cleared},
+			}}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		contents  string
+		clear     bool
+		want      string
+		wantErr   bool
+		wantClear bool
+	}{
+		{name: "trims whitespace", contents: "  sk-clip-1234567890\n", want: "sk-clip-1234567890"},
+		{name: "clears afterwards", contents: "sk-clip-1234567890", clear: true, want: "sk-clip-1234567890", wantClear: true},
+		{name: "empty clipboard", contents: " \n", wantErr: true},
+		{name: "invalid key is still cleared", contents: "sk-\x01bad", clear: true, wantErr: true, wantClear: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clipboardTools = fakeTool(tt.contents)
+			got, err := readClipboardKey(tt.clear)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("readClipboardKey() = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+			if _, statErr := ioutil.ReadFile(cleared); (statErr == nil) != tt.wantClear {
+				t.Errorf("clipboard cleared = %v, want %v", statErr == nil, tt.wantClear)
+			}
+			os.Remove(cleared)
+		})
+	}
+
+	clipboardTools = func() []clipboardTool {
+		return []clipboardTool{{name: "missing", read: []string{"cde-no-such-clipboard-tool"}}}
+	}
+	if _, err := readClipboardKey(false); err == nil {
+		t.Error("expected an error without a clipboard tool")
+	}
+}
+
+func TestParseAddClipboardFlags(t *testing.T) {
+	result := parseArguments([]string{"add", "--preset", "openai", "--key-from-clipboard", "--clear-clipboard"})
+	if result.Error != nil || result.CCEFlags["preset"] != "openai" || result.CCEFlags["key_from_clipboard"] != "true" || result.CCEFlags["clear_clipboard"] != "true" {
+		t.Errorf("add parse = %+v", result)
+	}
+	if result := parseArguments([]string{"add", "--clear-clipboard"}); result.Error == nil {
+		t.Error("expected an error for --clear-clipboard without --key-from-clipboard")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCloneEnvironmentDeepCopy(t *testing.T) {
+	src := Environment{
+		Name:        "prod",
+		URL:         "https://api.openai.com/v1",
+		APIKey:      "sk-test",
+		Model:       "gpt-5",
+		EnvVars:     map[string]string{"OPENAI_TIMEOUT": "30"},
+		ModelParams: map[string]string{"model_reasoning_effort": "high"},
+	}
+
+	clone := cloneEnvironment(src, "prod-copy")
+	if clone.Name != "prod-copy" || clone.URL != src.URL || clone.APIKey != src.APIKey || clone.Model != src.Model {
+		t.Fatalf("clone fields not copied: %+v", clone)
+	}
+
+	clone.EnvVars["OPENAI_TIMEOUT"] = "60"
+	clone.ModelParams["model_reasoning_effort"] = "low"
+	if src.EnvVars["OPENAI_TIMEOUT"] != "30" || src.ModelParams["model_reasoning_effort"] != "high" {
+		t.Error("modifying clone maps changed the source environment")
+	}
+}
+
+func TestParseArgumentsClone(t *testing.T) {
+	result := parseArguments([]string{"clone", "prod", "staging", "--model", "o4-mini"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.Subcommand != "clone" || result.CCEFlags["clone_source"] != "prod" ||
+		result.CCEFlags["clone_target"] != "staging" || result.CCEFlags["model"] != "o4-mini" {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+
+	if r := parseArguments([]string{"clone", "prod"}); r.Error == nil {
+		t.Error("expected error when new name is missing")
+	}
+	if r := parseArguments([]string{"clone", "prod", "new", "--bogus", "x"}); r.Error == nil {
+		t.Error("expected error for unknown clone flag")
+	}
+	if r := parseArguments([]string{"clone", "prod", "new", "--env-var", "A=1", "--env-var", "B="}); r.Error != nil || r.CCEFlags["env_vars"] != "A=1\nB=" {
+		t.Errorf("unexpected --env-var parse result: %+v", r)
+	}
+	if r := parseArguments([]string{"clone", "prod", "new", "--url"}); r.Error == nil {
+		t.Error("expected error for flag without value")
+	}
+}
+
+func TestRunClone(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	config := Config{Environments: []Environment{{
+		Name:    "prod",
+		URL:     "https://api.openai.com/v1",
+		APIKey:  "sk-test",
+		Model:   "gpt-5",
+		EnvVars: map[string]string{"OPENAI_TIMEOUT": "30"},
+	}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	err := runClone(map[string]string{"clone_source": "prod", "clone_target": "prod-mini", "model": "o4-mini"})
+	if err != nil {
+		t.Fatalf("runClone() failed: %v", err)
+	}
+
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() failed: %v", err)
+	}
+	index, exists := findEnvironmentByName(loaded, "prod-mini")
+	if !exists {
+		t.Fatal("cloned environment not saved")
+	}
+	cloned := loaded.Environments[index]
+	if cloned.Model != "o4-mini" || cloned.URL != "https://api.openai.com/v1" || cloned.EnvVars["OPENAI_TIMEOUT"] != "30" {
+		t.Errorf("unexpected cloned environment: %+v", cloned)
+	}
+
+	// Duplicate target, missing source, and invalid override must all fail
+	if err := runClone(map[string]string{"clone_source": "prod", "clone_target": "prod-mini"}); err == nil {
+		t.Error("expected error cloning onto existing name")
+	}
+	if err := runClone(map[string]string{"clone_source": "missing", "clone_target": "x"}); err == nil {
+		t.Error("expected error for missing source")
+	}
+	if err := runClone(map[string]string{"clone_source": "prod", "clone_target": "bad-url", "url": "ftp://x"}); err == nil {
+		t.Error("expected validation error for invalid URL override")
+	}
+	if err := runClone(map[string]string{"clone_source": "prod", "clone_target": "bad-var", "env_vars": "NOVALUE"}); err == nil {
+		t.Error("expected error for --env-var without '='")
+	}
+}
+
+func TestRunCloneEnvVarOverrides(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	config := Config{Environments: []Environment{{
+		Name:    "prod",
+		URL:     "https://api.openai.com/v1",
+		APIKey:  "sk-test",
+		EnvVars: map[string]string{"OPENAI_TIMEOUT": "30", "OPENAI_ORG": "org-1"},
+	}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	// An empty value unsets the variable; other variables are kept
+	err := runClone(map[string]string{"clone_source": "prod", "clone_target": "prod-slow", "env_vars": "OPENAI_TIMEOUT=120\nOPENAI_ORG=\nOPENAI_PROJECT=p1"})
+	if err != nil {
+		t.Fatalf("runClone() failed: %v", err)
+	}
+
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() failed: %v", err)
+	}
+	index, exists := findEnvironmentByName(loaded, "prod-slow")
+	if !exists {
+		t.Fatal("cloned environment not saved")
+	}
+	want := map[string]string{"OPENAI_TIMEOUT": "120", "OPENAI_PROJECT": "p1"}
+	if got := loaded.Environments[index].EnvVars; len(got) != len(want) || got["OPENAI_TIMEOUT"] != "120" || got["OPENAI_PROJECT"] != "p1" {
+		t.Errorf("cloned env_vars = %v, want %v", got, want)
+	}
+	source, _ := findEnvironmentByName(loaded, "prod")
+	if loaded.Environments[source].EnvVars["OPENAI_ORG"] != "org-1" {
+		t.Error("clone overrides changed the source environment")
+	}
+}
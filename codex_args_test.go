@@ -29,9 +29,58 @@ func TestPrepareCodexArgs_ModelInjection(t *testing.T) {
 	}
 }
 
-func TestApplyAutoFlags(t *testing.T) {
+func TestPrepareCodexArgs_ModelAlreadyDetermined(t *testing.T) {
+	env := Environment{Name: "dev", URL: "https://api.openai.com/v1", APIKey: "sk-test", Model: "gpt-5"}
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"config override", []string{"-c", "model=o4-mini", "exec", "hi"}},
+		{"quoted config override", []string{"--config", `model="o4-mini"`}},
+		{"config equals form", []string{"--config=model=o4-mini"}},
+		{"profile", []string{"--profile", "work", "exec", "hi"}},
+		{"short profile", []string{"-p", "work"}},
+		{"profile equals form", []string{"--profile=work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := prepareCodexArgs(env, tt.args); !reflect.DeepEqual(out, tt.args) {
+				t.Errorf("expected no -m injection, got %v", out)
+			}
+		})
+	}
+
+	// Overrides of other keys still get the environment's model
+	out := prepareCodexArgs(env, []string{"-c", "model_reasoning_effort=high"})
+	if len(out) < 2 || out[0] != "-m" || out[1] != "gpt-5" {
+		t.Errorf("expected -m gpt-5 to be injected, got %v", out)
+	}
+}
+
+func TestArgsModel(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-m", "gpt-5", "exec"}, "gpt-5"},
+		{[]string{"--model=gpt-5"}, "gpt-5"},
+		{[]string{"-c", `model="o4-mini"`}, "o4-mini"},
+		{[]string{"--config=model=o4-mini"}, "o4-mini"},
+		{[]string{"-c", "model_provider=azure", "--profile", "work"}, ""},
+	}
+	for _, tt := range tests {
+		if got := argsModel(tt.args); got != tt.want {
+			t.Errorf("argsModel(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestMergeDefaultAutoFlags(t *testing.T) {
 	args := []string{"proto"}
-	result := applyAutoFlags(args)
+	result, err := mergeAutoFlags(defaultAutoFlags, args)
+	if err != nil {
+		t.Fatalf("mergeAutoFlags() failed: %v", err)
+	}
 	if len(result) != len(args)+4 {
 		t.Fatalf("expected 4 flags added, got %d: %v", len(result)-len(args), result)
 	}
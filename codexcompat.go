@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// codexVersionTimeout bounds 'codex --version'
+const codexVersionTimeout = 3 * time.Second
+
+// codexCompatRule records a known incompatibility between flags cde generates and codex releases
+type codexCompatRule struct {
+	Feature  string   // cde feature that emits the flags
+	Flags    []string // codex flags involved
+	Releases string   // Affected releases, for messages
+	Affects  func(version string) bool
+}
+
+// legacyCodexPattern matches the date-stamped versions of the TypeScript codex CLI
+// (0.1.2504161510), which the Rust codex replaced
+var legacyCodexPattern = regexp.MustCompile(`^0\.1\.\d{10}$`)
+
+// isLegacyCodex reports whether version is a TypeScript codex release
+func isLegacyCodex(version string) bool {
+	return legacyCodexPattern.MatchString(version)
+}
+
+// codexCompatRules lists known incompatibilities only; versions without a matching rule are
+// assumed compatible. The TypeScript codex has no -c overrides (its -c/--config opens the
+// instructions file), no --sandbox, an -a that takes suggest/auto-edit/full-auto, and a
+// differently named bypass flag.
+var codexCompatRules = []codexCompatRule{
+	{Feature: "model_params and header overrides", Flags: []string{"-c", "--config"}, Releases: "TypeScript codex 0.1.x", Affects: isLegacyCodex},
+	{Feature: "auto flags: approval policy", Flags: []string{"-a", "--ask-for-approval"}, Releases: "TypeScript codex 0.1.x", Affects: isLegacyCodex},
+	{Feature: "auto flags: sandbox", Flags: []string{"-s", "--sandbox"}, Releases: "TypeScript codex 0.1.x", Affects: isLegacyCodex},
+	{Feature: "auto flags: bypass", Flags: []string{"--dangerously-bypass-approvals-and-sandbox"}, Releases: "TypeScript codex 0.1.x", Affects: isLegacyCodex},
+}
+
+// codexVersionPattern extracts the version from output such as "codex-cli 0.21.0"
+var codexVersionPattern = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.]+)?`)
+
+// codexVersionCache remembers the version of a codex binary until it changes on disk
+type codexVersionCache struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Version string    `json:"version"`
+}
+
+// codexVersionCheckEnabled reports whether the pre-launch check runs (default on)
+func codexVersionCheckEnabled(config Config) bool {
+	return config.Settings == nil || config.Settings.CodexVersionCheck == nil || *config.Settings.CodexVersionCheck
+}
+
+// parseCodexVersion returns the version number in 'codex --version' output
+func parseCodexVersion(output string) (string, bool) {
+	version := codexVersionPattern.FindString(output)
+	return version, version != ""
+}
+
+// getCodexVersionCachePath returns the cache file next to config.json
+func getCodexVersionCachePath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "codex_version.json"), nil
+}
+
+// detectCodexVersion runs 'codex --version', reusing the cached result while the binary is unchanged
+func detectCodexVersion() (string, string, error) {
+	codexPath, err := exec.LookPath(targetCommand)
+	if err != nil {
+		return "", "", fmt.Errorf("%s not found in PATH", targetCommand)
+	}
+	info, err := os.Stat(codexPath)
+	if err != nil {
+		return "", codexPath, fmt.Errorf("codex path verification failed: %w", err)
+	}
+
+	cachePath, cacheErr := getCodexVersionCachePath()
+	if cacheErr == nil {
+		var cache codexVersionCache
+		if data, err := ioutil.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cache) == nil {
+			if cache.Path == codexPath && cache.Size == info.Size() && cache.ModTime.Equal(info.ModTime()) && cache.Version != "" {
+				return cache.Version, codexPath, nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), codexVersionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, codexPath, "--version").Output()
+	if err != nil {
+		return "", codexPath, fmt.Errorf("codex --version failed: %w", err)
+	}
+	version, ok := parseCodexVersion(string(output))
+	if !ok {
+		return "", codexPath, fmt.Errorf("could not parse codex version from %q", string(output))
+	}
+
+	if cacheErr == nil {
+		cache := codexVersionCache{Path: codexPath, ModTime: info.ModTime(), Size: info.Size(), Version: version}
+		if data, err := json.Marshal(cache); err == nil && ensureConfigDir() == nil {
+			ioutil.WriteFile(cachePath, data, 0600)
+		}
+	}
+	return version, codexPath, nil
+}
+
+// codexCompatWarnings lists problems running codex version with args
+func codexCompatWarnings(version string, args []string) []string {
+	warnings := []string{}
+	used := make(map[string]bool, len(args))
+	for _, arg := range args {
+		used[arg] = true
+		if flag, _, found := strings.Cut(arg, "="); found && strings.HasPrefix(flag, "-") {
+			used[flag] = true
+		}
+	}
+	for _, rule := range codexCompatRules {
+		if !rule.Affects(version) {
+			continue
+		}
+		for _, flag := range rule.Flags {
+			if used[flag] {
+				warnings = append(warnings, fmt.Sprintf("codex %s does not support %s as cde uses it (%s; affects %s)", version, flag, rule.Feature, rule.Releases))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// warnCodexCompatibility prints pre-launch compatibility warnings; detection failures stay silent
+// because the launcher reports a missing codex itself
+func warnCodexCompatibility(config Config, args []string) {
+	if !codexVersionCheckEnabled(config) {
+		return
+	}
+	version, _, err := detectCodexVersion()
+	if err != nil {
+		return
+	}
+	for _, warning := range codexCompatWarnings(version, args) {
+		fmt.Fprintf(os.Stderr, "Warning: %s (disable with settings.codex_version_check=false)\n", warning)
+	}
+}
+
+// runCodexVersion prints the installed codex version and the known incompatibilities
+func runCodexVersion() error {
+	if config, err := loadConfigLazy(); err == nil {
+		useTargetCommand(config)
+	}
+	version, codexPath, err := detectCodexVersion()
+	if err != nil {
+		return fmt.Errorf("Codex launcher failed: %w", err)
+	}
+
+	fmt.Printf("%s %s (%s)\n\n", targetCommand, version, codexPath)
+	fmt.Println("Known incompatibilities:")
+	for _, rule := range codexCompatRules {
+		mark := "ok"
+		if rule.Affects(version) {
+			mark = "unsupported"
+		}
+		fmt.Printf("  %-34s %-45s %-24s %s\n", rule.Feature, strings.Join(rule.Flags, "/"), rule.Releases, mark)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseCodexVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+		ok     bool
+	}{
+		{"codex-cli 0.21.0\n", "0.21.0", true},
+		{"codex 0.1.2504161510", "0.1.2504161510", true},
+		{"codex-cli 1.2.0-alpha.3", "1.2.0-alpha.3", true},
+		{"codex dev build", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCodexVersion(tt.output)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseCodexVersion(%q) = %q, %v; want %q, %v", tt.output, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCodexCompatWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		args    []string
+		want    []string // Substrings expected, one per warning
+	}{
+		{"current codex", "0.21.0", []string{"-m", "gpt-5", "-c", "x=y", "-a", "never"}, nil},
+		{"future codex", "1.3.0", []string{"-m", "gpt-5", "-c", "x=y", "--sandbox", "read-only"}, nil},
+		{"typescript codex with injected flags", "0.1.2504161510", []string{"-m", "gpt-5", "-c", "x=y", "--sandbox=workspace-write"},
+			[]string{"-c as cde uses it (model_params", "--sandbox as cde uses it (auto flags: sandbox"}},
+		{"typescript codex without affected flags", "0.1.2504161510", []string{"-m", "gpt-5", "--full-auto"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := codexCompatWarnings(tt.version, tt.args)
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("warnings = %v, want %d", warnings, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("warning %d = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectCodexVersionCachesResult(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	// The fake codex counts invocations in a file next to itself
+	binDir := t.TempDir()
+	counter := filepath.Join(binDir, "calls")
+	script := "#!/bin/sh\necho x >> " + counter + "\necho 'codex-cli 0.22.1'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	for i := 0; i < 3; i++ {
+		version, _, err := detectCodexVersion()
+		if err != nil {
+			t.Fatalf("detectCodexVersion failed: %v", err)
+		}
+		if version != "0.22.1" {
+			t.Errorf("version = %s, want 0.22.1", version)
+		}
+	}
+
+	data, _ := os.ReadFile(counter)
+	if calls := strings.Count(string(data), "x"); calls != 1 {
+		t.Errorf("codex --version ran %d times, want 1 (cached)", calls)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Markers delimiting the part of codex's config.toml that cde owns; everything
+// outside them is user-authored and preserved byte for byte
+const (
+	codexProfilesBegin = "# >>> cde managed profiles (regenerate with 'cde export-codex-profiles') >>>"
+	codexProfilesEnd   = "# <<< cde managed profiles <<<"
+)
+
+// codexProviderPrefix prefixes the model provider ids cde defines, e.g. cde-prod
+const codexProviderPrefix = "cde-"
+
+// tomlTablePattern matches a TOML table header such as [profiles.dev] or [model_providers."x"]
+var tomlTablePattern = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]`)
+
+// bareModelParamPattern matches model_params keys that can be written as plain profile keys
+var bareModelParamPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// getCodexConfigPath returns codex's config.toml, honouring CODEX_HOME
+func getCodexConfigPath() (string, error) {
+	if home := os.Getenv("CODEX_HOME"); home != "" {
+		return filepath.Join(home, "config.toml"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("home directory lookup failed: %w", err)
+	}
+	return filepath.Join(homeDir, ".codex", "config.toml"), nil
+}
+
+// splitManagedBlock separates content into the text before, inside and after the cde markers.
+// found is false when the file has no managed block yet.
+func splitManagedBlock(content string) (before, managed, after string, found bool, err error) {
+	start := strings.Index(content, codexProfilesBegin)
+	if start < 0 {
+		if strings.Contains(content, codexProfilesEnd) {
+			return "", "", "", false, fmt.Errorf("config.toml has a cde end marker without a begin marker")
+		}
+		return content, "", "", false, nil
+	}
+	rest := content[start+len(codexProfilesBegin):]
+	end := strings.Index(rest, codexProfilesEnd)
+	if end < 0 {
+		return "", "", "", false, fmt.Errorf("config.toml has a cde begin marker without an end marker")
+	}
+	after = strings.TrimPrefix(rest[end+len(codexProfilesEnd):], "\n")
+	return content[:start], strings.Trim(rest[:end], "\n"), after, true, nil
+}
+
+// userTables returns the table names defined outside the managed block
+func userTables(content string) map[string]bool {
+	tables := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		if match := tomlTablePattern.FindStringSubmatch(line); match != nil {
+			name := strings.ReplaceAll(match[1], `"`, "")
+			name = strings.ReplaceAll(name, " ", "")
+			tables[name] = true
+		}
+	}
+	return tables
+}
+
+// codexProfileBlock renders the provider and profile tables for one environment
+func codexProfileBlock(env Environment) string {
+	providerID := codexProviderPrefix + env.Name
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "[model_providers.%s]\n", providerID)
+	fmt.Fprintf(&b, "name = %s\n", tomlString("cde "+env.Name))
+	fmt.Fprintf(&b, "base_url = %s\n", tomlString(env.URL))
+	// The key itself never lands in config.toml; codex reads it from the environment
+	fmt.Fprintf(&b, "env_key = %s\n", tomlString("OPENAI_API_KEY"))
+	fmt.Fprintf(&b, "wire_api = %s\n", tomlString("responses"))
+	if isAzureEnvironment(env) && env.APIVersion != "" {
+		fmt.Fprintf(&b, "query_params = { %s = %s }\n", tomlString("api-version"), tomlString(env.APIVersion))
+	}
+	if len(env.Headers) > 0 {
+		names := make([]string, 0, len(env.Headers))
+		for name := range env.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		mappings := make([]string, 0, len(names))
+		for _, name := range names {
+			mappings = append(mappings, fmt.Sprintf("%s = %s", tomlString(name), tomlString(headerEnvVarName(name))))
+		}
+		fmt.Fprintf(&b, "env_http_headers = { %s }\n", strings.Join(mappings, ", "))
+	}
+
+	fmt.Fprintf(&b, "\n[profiles.%s]\n", env.Name)
+	fmt.Fprintf(&b, "model_provider = %s\n", tomlString(providerID))
+	if env.Model != "" {
+		model := resolveModelAlias(env, env.Model)
+		if deployment, ok := env.DeploymentMap[model]; ok && isAzureEnvironment(env) {
+			model = deployment
+		}
+		fmt.Fprintf(&b, "model = %s\n", tomlString(model))
+	}
+	keys := make([]string, 0, len(env.ModelParams))
+	for key := range env.ModelParams {
+		if bareModelParamPattern.MatchString(key) && key != "model" && key != "model_provider" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// model_params values are already TOML, as for codex -c
+		fmt.Fprintf(&b, "%s = %s\n", key, env.ModelParams[key])
+	}
+	return b.String()
+}
+
+// renderCodexProfiles builds the managed block for config, skipping environments whose
+// tables the user already defined outside it. It returns the block and the skipped names.
+func renderCodexProfiles(config Config, existing map[string]bool) (string, []string) {
+	blocks := []string{}
+	skipped := []string{}
+	for _, env := range config.Environments {
+		if existing["profiles."+env.Name] || existing["model_providers."+codexProviderPrefix+env.Name] {
+			skipped = append(skipped, env.Name)
+			continue
+		}
+		blocks = append(blocks, codexProfileBlock(env))
+	}
+	return strings.TrimRight(strings.Join(blocks, "\n"), "\n"), skipped
+}
+
+// mergeCodexProfiles replaces (or appends) the managed block in content
+func mergeCodexProfiles(content string, config Config) (string, string, []string, error) {
+	before, oldManaged, after, found, err := splitManagedBlock(content)
+	if err != nil {
+		return "", "", nil, err
+	}
+	managed, skipped := renderCodexProfiles(config, userTables(before+after))
+
+	block := codexProfilesBegin + "\n" + managed + "\n" + codexProfilesEnd + "\n"
+	if managed == "" {
+		block = codexProfilesBegin + "\n" + codexProfilesEnd + "\n"
+	}
+	if !found {
+		if before != "" && !strings.HasSuffix(before, "\n") {
+			before += "\n"
+		}
+		if before != "" {
+			before += "\n"
+		}
+	}
+	return before + block + after, oldManaged, skipped, nil
+}
+
+// lineDiff returns a minimal line diff of old and new with "-"/"+"/" " prefixes
+func lineDiff(oldText, newText string) []string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := []string{}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			diff = append(diff, "  "+oldLines[i])
+			i++
+			j++
+		case j < len(newLines) && (i == len(oldLines) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff = append(diff, "+ "+newLines[j])
+			j++
+		default:
+			diff = append(diff, "- "+oldLines[i])
+			i++
+		}
+	}
+	return diff
+}
+
+// splitLines splits text into lines, returning none for empty text
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+// runExportCodexProfiles writes codex profiles derived from cde environments into config.toml
+func runExportCodexProfiles(dryRun bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	codexPath, err := getCodexConfigPath()
+	if err != nil {
+		return err
+	}
+
+	content := ""
+	perm := os.FileMode(0600)
+	if data, err := ioutil.ReadFile(codexPath); err == nil {
+		content = string(data)
+		if info, statErr := os.Stat(codexPath); statErr == nil {
+			perm = info.Mode().Perm()
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("codex config read failed: %w", err)
+	}
+
+	updated, oldManaged, skipped, err := mergeCodexProfiles(content, config)
+	if err != nil {
+		return fmt.Errorf("codex config merge failed: %w", err)
+	}
+	for _, name := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipping '%s'; %s already defines its profile or provider outside the cde block\n", name, codexPath)
+	}
+
+	_, newManaged, _, _, _ := splitManagedBlock(updated)
+	if dryRun {
+		fmt.Printf("Dry run: %s will not be modified.\n", codexPath)
+		if oldManaged == newManaged {
+			fmt.Println("No changes.")
+			return nil
+		}
+		for _, line := range lineDiff(oldManaged, newManaged) {
+			fmt.Println(line)
+		}
+		return nil
+	}
+	if updated == content {
+		fmt.Printf("%s is up to date\n", codexPath)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(codexPath), 0700); err != nil {
+		return fmt.Errorf("codex config directory creation failed: %w", err)
+	}
+	tempPath := codexPath + ".cde.tmp"
+	if err := ioutil.WriteFile(tempPath, []byte(updated), perm); err != nil {
+		return fmt.Errorf("codex config write failed: %w", err)
+	}
+	if err := os.Rename(tempPath, codexPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("codex config write failed (atomic move): %w", err)
+	}
+
+	fmt.Printf("Wrote %d profile(s) to %s; use 'codex --profile <name>' with OPENAI_API_KEY set\n", len(config.Environments)-len(skipped), codexPath)
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodexProfileBlock(t *testing.T) {
+	env := Environment{
+		Name:         "prod",
+		URL:          "https://api.example.com/v1",
+		APIKey:       "sk-prod-123456",
+		Model:        "smart",
+		ModelAliases: map[string]string{"smart": "gpt-5"},
+		ModelParams:  map[string]string{"model_reasoning_effort": `"high"`, "tools.web_search": "true"},
+		Headers:      map[string]string{"X-Org-Id": "org-1"},
+	}
+
+	block := codexProfileBlock(env)
+	for _, want := range []string{
+		"[model_providers.cde-prod]\n",
+		`base_url = "https://api.example.com/v1"`,
+		`env_key = "OPENAI_API_KEY"`,
+		`env_http_headers = { "X-Org-Id" = "CDE_HEADER_X_ORG_ID" }`,
+		"[profiles.prod]\n",
+		`model_provider = "cde-prod"`,
+		`model = "gpt-5"`,
+		`model_reasoning_effort = "high"`,
+	} {
+		if !strings.Contains(block, want) {
+			t.Errorf("block missing %q:\n%s", want, block)
+		}
+	}
+	if strings.Contains(block, "sk-prod-123456") {
+		t.Error("block leaked the API key")
+	}
+	if strings.Contains(block, "tools.web_search") {
+		t.Error("dotted model_params key should not be written to the profile")
+	}
+}
+
+func TestMergeCodexProfiles(t *testing.T) {
+	config := Config{Environments: []Environment{
+		{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456"},
+		{Name: "mine", URL: "https://mine.example.com", APIKey: "sk-mine-123456"},
+	}}
+	user := "model = \"o3\"\n\n[profiles.mine]\nmodel = \"gpt-4o\"\n"
+
+	merged, oldManaged, skipped, err := mergeCodexProfiles(user, config)
+	if err != nil {
+		t.Fatalf("mergeCodexProfiles() error = %v", err)
+	}
+	if oldManaged != "" || !reflect.DeepEqual(skipped, []string{"mine"}) {
+		t.Errorf("oldManaged = %q, skipped = %v", oldManaged, skipped)
+	}
+	if !strings.HasPrefix(merged, user+"\n"+codexProfilesBegin) || !strings.Contains(merged, "[profiles.dev]") {
+		t.Errorf("user content not preserved or block missing:\n%s", merged)
+	}
+
+	// Regenerating replaces the block in place and keeps trailing user content
+	merged += "\n[profiles.after]\nmodel = \"o4-mini\"\n"
+	config.Environments[0].Model = "gpt-5"
+	again, oldManaged, _, err := mergeCodexProfiles(merged, config)
+	if err != nil {
+		t.Fatalf("second merge error = %v", err)
+	}
+	if !strings.Contains(oldManaged, "[profiles.dev]") || strings.Count(again, codexProfilesBegin) != 1 {
+		t.Errorf("block was not replaced in place:\n%s", again)
+	}
+	if !strings.Contains(again, `model = "gpt-5"`) || !strings.HasSuffix(again, "[profiles.after]\nmodel = \"o4-mini\"\n") {
+		t.Errorf("unexpected merge result:\n%s", again)
+	}
+
+	if _, _, _, err := mergeCodexProfiles(codexProfilesBegin+"\n[profiles.x]\n", config); err == nil {
+		t.Error("missing end marker should be an error")
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc", "a\nc\nd")
+	want := []string{"  a", "- b", "  c", "+ d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lineDiff() = %v, want %v", got, want)
+	}
+}
+
+func TestRunExportCodexProfiles(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+
+	if err := saveConfig(Config{Environments: []Environment{
+		{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456", Model: "gpt-5"},
+	}}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	codexPath := filepath.Join(codexHome, "config.toml")
+	if err := runExportCodexProfiles(true); err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if _, err := os.Stat(codexPath); !os.IsNotExist(err) {
+		t.Fatal("dry run wrote config.toml")
+	}
+
+	if err := runExportCodexProfiles(false); err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	data, err := ioutil.ReadFile(codexPath)
+	if err != nil {
+		t.Fatalf("config.toml not written: %v", err)
+	}
+	if !strings.Contains(string(data), "[profiles.dev]") || strings.Contains(string(data), "sk-dev-123456") {
+		t.Errorf("unexpected config.toml:\n%s", data)
+	}
+}
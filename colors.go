@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// colorTheme holds SGR parameters for each UI role; empty means uncolored
+type colorTheme struct {
+	Selected string // Highlighted menu entry
+	URL      string // Secondary URL text
+	Warning  string // Truncation and other warnings
+}
+
+// colorThemes are the values accepted by settings.terminal.theme
+var colorThemes = map[string]colorTheme{
+	"default": {Selected: "1;36", URL: "2", Warning: "33"},
+	"light":   {Selected: "1;34", URL: "90", Warning: "35"},
+	"mono":    {Selected: "1;7", URL: "2", Warning: "1"},
+	"none":    {},
+}
+
+// noColorRequested is set by the --no-color flag
+var noColorRequested bool
+
+// ansiEscapePattern matches SGR color sequences
+var ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// validateTheme checks that a configured theme name exists
+func validateTheme(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, exists := colorThemes[name]; !exists {
+		names := make([]string, 0, len(colorThemes))
+		for themeName := range colorThemes {
+			names = append(names, themeName)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown theme '%s' (available: %s)", name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// colorsDisabled reports whether NO_COLOR, --no-color, accessible mode or the terminal settings
+// turn colors off
+func colorsDisabled(config Config) bool {
+	if noColorRequested || os.Getenv("NO_COLOR") != "" || accessibleMode(config) {
+		return true
+	}
+	if config.Settings != nil && config.Settings.Terminal != nil && config.Settings.Terminal.DisableANSI {
+		return true
+	}
+	return !platformSupportsANSI()
+}
+
+// activeTheme returns the configured theme, or the empty theme when colors are disabled
+// or stdout is not a terminal
+func activeTheme(config Config) colorTheme {
+	if colorsDisabled(config) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return colorTheme{}
+	}
+	name := "default"
+	if config.Settings != nil && config.Settings.Terminal != nil && config.Settings.Terminal.Theme != "" {
+		name = config.Settings.Terminal.Theme
+	}
+	return colorThemes[name]
+}
+
+// colorize wraps text in an SGR sequence; an empty code leaves text unchanged
+func colorize(code, text string) string {
+	if code == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// visibleLen returns the display length of s, ignoring color sequences
+func visibleLen(s string) int {
+	return len(ansiEscapePattern.ReplaceAllString(s, ""))
+}
+
+// colorizeMenuLine applies theme colors to a line produced by formatSingleLine
+func colorizeMenuLine(line string, selected bool, theme colorTheme) string {
+	if selected {
+		return colorize(theme.Selected, line)
+	}
+
+	// Dim the "(url)" segment when it survived truncation intact
+	start := strings.Index(line, " (")
+	end := strings.LastIndex(line, ") [")
+	if start < 0 || end <= start {
+		return line
+	}
+	return line[:start+1] + colorize(theme.URL, line[start+1:end+1]) + line[end+1:]
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	if got := colorize("", "text"); got != "text" {
+		t.Errorf("empty code should leave text unchanged, got %q", got)
+	}
+	if got := colorize("33", "warn"); got != "\x1b[33mwarn\x1b[0m" {
+		t.Errorf("unexpected colorized text %q", got)
+	}
+	if got := visibleLen(colorize("1;36", "abc")); got != 3 {
+		t.Errorf("visibleLen = %d, want 3", got)
+	}
+}
+
+func TestColorizeMenuLine(t *testing.T) {
+	theme := colorThemes["default"]
+	line := "  prod (https://api.openai.com/v1) [gpt-5]"
+
+	dimmed := colorizeMenuLine(line, false, theme)
+	if !strings.Contains(dimmed, "\x1b[2m(https://api.openai.com/v1)\x1b[0m") {
+		t.Errorf("expected dimmed URL, got %q", dimmed)
+	}
+	if stripped := ansiEscapePattern.ReplaceAllString(dimmed, ""); stripped != line {
+		t.Errorf("colors changed visible text: %q", stripped)
+	}
+
+	selected := colorizeMenuLine(line, true, theme)
+	if !strings.HasPrefix(selected, "\x1b[1;36m") {
+		t.Errorf("expected highlighted selection, got %q", selected)
+	}
+
+	if got := colorizeMenuLine(line, false, colorThemes["none"]); got != line {
+		t.Errorf("theme none should not add colors, got %q", got)
+	}
+}
+
+func TestRenderRowsKeepsColorsWhenTruncating(t *testing.T) {
+	out := &bytes.Buffer{}
+	oldOutput := menuOutput
+	menuOutput = out
+	defer func() { menuOutput = oldOutput }()
+
+	state := initializeDisplayState()
+	state.terminalWidth = 20
+	renderer := newLineRenderer(state, true)
+	renderer.theme = colorThemes["default"]
+	renderer.RenderRows(2, 0, "", func(prefix string, i int) string {
+		return prefix + "environment-with-a-very-long-name"
+	})
+
+	selected := state.currentLines[0]
+	if !strings.HasPrefix(selected, "\x1b[1;36m") || !strings.HasSuffix(selected, "...\x1b[0m") {
+		t.Errorf("truncated selection lost its highlight: %q", selected)
+	}
+	if got := visibleLen(selected); got != 20 {
+		t.Errorf("visible length = %d, want 20", got)
+	}
+	if !strings.Contains(out.String(), selected) {
+		t.Errorf("highlighted row was not drawn as composed: %q", out.String())
+	}
+}
+
+func TestColorsDisabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if !colorsDisabled(Config{}) {
+		t.Error("expected NO_COLOR to disable colors")
+	}
+	if theme := activeTheme(Config{}); theme != (colorTheme{}) {
+		t.Errorf("expected empty theme, got %+v", theme)
+	}
+
+	t.Setenv("NO_COLOR", "")
+	config := Config{Settings: &ConfigSettings{Terminal: &TerminalSettings{DisableANSI: true}}}
+	if !colorsDisabled(config) {
+		t.Error("expected disable_ansi to disable colors")
+	}
+
+	noColorRequested = true
+	defer func() { noColorRequested = false }()
+	if !colorsDisabled(Config{}) {
+		t.Error("expected --no-color to disable colors")
+	}
+}
+
+func TestParseArgumentsNoColor(t *testing.T) {
+	result := parseArguments([]string{"--no-color", "list"})
+	if result.Subcommand != "list" || result.CCEFlags["no_color"] != "true" {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+	result = parseArguments([]string{"-e", "prod", "--no-color", "--", "exec"})
+	if result.CCEFlags["env"] != "prod" || result.CCEFlags["no_color"] != "true" || len(result.ClaudeArgs) != 1 {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+}
+
+func TestValidateTheme(t *testing.T) {
+	for _, name := range []string{"", "default", "light", "mono", "none"} {
+		if err := validateTheme(name); err != nil {
+			t.Errorf("validateTheme(%q) unexpected error: %v", name, err)
+		}
+	}
+	if err := validateTheme("neon"); err == nil {
+		t.Error("expected error for unknown theme")
+	}
+}
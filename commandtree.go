@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cdeFlag is one flag of a command's flag set
+type cdeFlag struct {
+	Names []string // Spellings, e.g. --quiet and -q
+	Key   string   // CCEFlags key the flag sets
+	Value bool     // Takes a value, given as --flag value or --flag=value
+	Sep   string   // With Value: the flag repeats and its values are joined with Sep
+	Set   string   // Without Value: what the flag stores (default "true")
+}
+
+// cdeCommand is a node of the management command tree. A command either dispatches to
+// Subcommands (backup list, sync pull) or takes its own Flags and positional arguments,
+// stored under ArgKeys in order.
+type cdeCommand struct {
+	ID          string // ParseResult.Subcommand; defaults to the command path joined by '-'
+	Flags       []cdeFlag
+	Subcommands map[string]*cdeCommand
+	Usage       string // Error for a missing or unknown subcommand, or a wrong number of arguments
+	ArgKeys     []string
+	MinArgs     int
+	Passthrough bool                            // Arguments after -- are codex arguments
+	Check       func(result *ParseResult) error // Validates flag combinations once parsing is done
+}
+
+// globalFlags may follow any management command unless the command defines the same name
+// itself (list --verbose shows details; it does not enable debug logging)
+var globalFlags = []cdeFlag{
+	{Names: []string{"--no-color"}, Key: "no_color"},
+	{Names: []string{"--config"}, Key: "config", Value: true},
+	{Names: []string{"--lang"}, Key: "lang", Value: true},
+	{Names: []string{"--verbose"}, Key: "log_level", Set: "debug"},
+	{Names: []string{"-vv"}, Key: "log_level", Set: "trace"},
+}
+
+// boolFlag declares a flag that stores "true" under key
+func boolFlag(key string, names ...string) cdeFlag {
+	return cdeFlag{Names: names, Key: key}
+}
+
+// valueFlag declares a flag that takes a value stored under key
+func valueFlag(key string, names ...string) cdeFlag {
+	return cdeFlag{Names: names, Key: key, Value: true}
+}
+
+// cdeSubcommands is the management command tree. These commands take only CDE arguments
+// (compare passes what follows -- to codex); launches, auto, which, wrap and preset are
+// parsed as launches instead.
+var cdeSubcommands = map[string]*cdeCommand{
+	"list": {Flags: []cdeFlag{
+		boolFlag("verbose", "--verbose", "-v"), boolFlag("quiet", "--quiet", "-q"), boolFlag("table", "--table"),
+		boolFlag("json", "--json"), valueFlag("sort", "--sort"),
+	}},
+	"search": {
+		Flags:   []cdeFlag{boolFlag("quiet", "--quiet", "-q"), boolFlag("table", "--table")},
+		ArgKeys: []string{"pattern"}, MinArgs: 1, Usage: "search command requires a pattern",
+	},
+	"add": {
+		Flags: []cdeFlag{
+			boolFlag("key_from_clipboard", "--key-from-clipboard"), boolFlag("clear_clipboard", "--clear-clipboard"),
+			boolFlag("key_from_stdin", "--api-key-stdin"), valueFlag("preset", "--preset"), valueFlag("name", "--name"),
+			valueFlag("url", "--url"), valueFlag("model", "--model"),
+			{Names: []string{"--env-var"}, Key: "env_vars", Value: true, Sep: "\n"},
+		},
+		Check: checkAddFlags,
+	},
+	"remove": {
+		Flags:   []cdeFlag{boolFlag("force", "--force", "-f"), boolFlag("all", "--all"), boolFlag("no_backup", "--no-backup")},
+		ArgKeys: []string{"remove_target"}, Usage: "remove command accepts a single environment name",
+		Check: checkRemoveFlags,
+	},
+	"clone": {
+		Flags: []cdeFlag{
+			valueFlag("url", "--url"), valueFlag("model", "--model"),
+			{Names: []string{"--env-var"}, Key: "env_vars", Value: true, Sep: "\n"},
+		},
+		ArgKeys: []string{"clone_source", "clone_target"}, MinArgs: 2,
+		Usage: "clone command requires source and new environment names",
+	},
+	"backup": {
+		Usage: "backup command requires 'list', 'restore <id>' or 'prune'",
+		Subcommands: map[string]*cdeCommand{
+			"list":    {},
+			"prune":   {},
+			"restore": {ArgKeys: []string{"backup_id"}, MinArgs: 1, Usage: "backup restore requires backup id"},
+		},
+	},
+	"sync": {
+		Usage: "sync command requires 'pull' or 'push'",
+		Subcommands: map[string]*cdeCommand{
+			"pull": {Flags: syncFlags},
+			"push": {Flags: syncFlags},
+		},
+	},
+	"pin": {
+		Flags:   []cdeFlag{boolFlag("list", "--list"), boolFlag("unpin", "--unpin")},
+		ArgKeys: []string{"env"}, Usage: "usage: cde pin <env> | --list | --unpin",
+		Check: checkPinFlags,
+	},
+	"dedupe": {Flags: []cdeFlag{boolFlag("dry_run", "--dry-run")}},
+	"restore": {
+		ArgKeys: []string{"restore_target"}, MinArgs: 1, Usage: "restore command requires environment name",
+	},
+	"rotate-key": {
+		Flags:   []cdeFlag{valueFlag("expires", "--expires")},
+		ArgKeys: []string{"rotate_target", "key"}, MinArgs: 1,
+		Usage: "usage: cde rotate-key <env> [key] [--expires <date>]",
+	},
+	"show-key": {
+		Flags:   []cdeFlag{valueFlag("key", "--key")},
+		ArgKeys: []string{"env"}, MinArgs: 1, Usage: "show-key command requires environment name",
+	},
+	"audit": {
+		Usage: "audit command requires 'show' subcommand",
+		Subcommands: map[string]*cdeCommand{
+			"show": {Flags: []cdeFlag{valueFlag("last", "--last")}},
+		},
+	},
+	"env": {
+		Usage: "env command usage: env print <name> [--shell <shell>] [--no-secrets]",
+		Subcommands: map[string]*cdeCommand{
+			"print": {
+				Flags:   []cdeFlag{valueFlag("shell", "--shell"), boolFlag("no_secrets", "--no-secrets")},
+				ArgKeys: []string{"env"}, MinArgs: 1,
+				Usage: "env command usage: env print <name> [--shell <shell>] [--no-secrets]",
+			},
+		},
+	},
+	"compare": {
+		Flags: []cdeFlag{
+			{Names: []string{"--env", "-e"}, Key: "compare_envs", Value: true, Sep: ","},
+			valueFlag("out", "--out"),
+		},
+		Usage:       "compare takes environments with -e; codex arguments go after --",
+		Passthrough: true,
+	},
+	"replay": {
+		Flags:   []cdeFlag{boolFlag("dry_run", "--dry-run"), boolFlag("json", "--json")},
+		ArgKeys: []string{"snapshot_file"}, MinArgs: 1, Usage: "replay command requires a snapshot file",
+	},
+	"profile": {
+		Usage: "profile command usage: profile list | profile use <name>",
+		Subcommands: map[string]*cdeCommand{
+			"list": {},
+			"use":  {ArgKeys: []string{"profile"}, MinArgs: 1, Usage: "profile command usage: profile list | profile use <name>"},
+		},
+	},
+	"config": {
+		Usage: "config command requires 'validate', 'schema', 'edit', 'diff' or 'repair'",
+		Subcommands: map[string]*cdeCommand{
+			"validate": {ArgKeys: []string{"config_file"}, Usage: configUsage},
+			"schema":   {},
+			"edit":     {},
+			"diff":     {ArgKeys: []string{"config_file", "config_file_b"}, Usage: configUsage},
+			"repair":   {},
+		},
+	},
+	"import-from": {
+		Flags:   []cdeFlag{boolFlag("dry_run", "--dry-run"), boolFlag("no_backup", "--no-backup")},
+		ArgKeys: []string{"import_source"}, MinArgs: 1,
+		Usage: "import-from command requires a source (codex or claude-code-env)",
+	},
+	"export-codex-profiles": {Flags: []cdeFlag{boolFlag("dry_run", "--dry-run")}},
+	"models":                {Flags: []cdeFlag{valueFlag("env", "--env", "-e")}},
+	"serve-metrics":         {Flags: []cdeFlag{valueFlag("listen", "--listen")}},
+	"update-check":          {},
+	"self-update":           {},
+	"codex-version":         {},
+	"doctor":                {Flags: []cdeFlag{boolFlag("selftest", "--selftest")}},
+	"help": {
+		Flags:   []cdeFlag{boolFlag("help_all", "--help-all"), boolFlag("help_man", "--man")},
+		ArgKeys: []string{"help_topic"}, Usage: "help usage: help [command] [--help-all] [--man]",
+	},
+}
+
+// syncFlags are the flags of sync pull and sync push
+var syncFlags = []cdeFlag{boolFlag("dry_run", "--dry-run"), boolFlag("force", "--force"), boolFlag("theirs", "--theirs")}
+
+const configUsage = "config command usage: config validate [file] | config schema | config edit | config diff [fileA] [fileB] | config repair"
+
+// parseCDECommand parses the arguments of the management command args[0] into result
+func parseCDECommand(args []string, result *ParseResult) error {
+	// 'cde add --help' shows the page for add, whatever else is on the line
+	if args[0] != "help" {
+		for _, arg := range args[1:] {
+			if arg == "--" {
+				break
+			}
+			if arg == "--help" || arg == "-h" {
+				result.Subcommand = "help"
+				result.CCEFlags["help_topic"] = args[0]
+				return nil
+			}
+		}
+	}
+
+	cmd, path, rest := cdeSubcommands[args[0]], args[0], args[1:]
+	for len(cmd.Subcommands) > 0 {
+		// Global flags may come before the subcommand name
+		var err error
+		if rest, err = parseLeadingGlobalFlags(rest, result); err != nil {
+			return err
+		}
+		if len(rest) == 0 {
+			return fmt.Errorf("%s", cmd.Usage)
+		}
+		sub, ok := cmd.Subcommands[rest[0]]
+		if !ok {
+			if strings.HasPrefix(rest[0], "-") {
+				return fmt.Errorf("%s", cmd.Usage)
+			}
+			return fmt.Errorf("unknown %s subcommand: %s", path, rest[0])
+		}
+		cmd, path, rest = sub, path+" "+rest[0], rest[1:]
+	}
+
+	if err := parseCommandFlags(cmd, path, rest, result); err != nil {
+		return err
+	}
+	if cmd.Check != nil {
+		if err := cmd.Check(result); err != nil {
+			return err
+		}
+	}
+	result.Subcommand = cmd.ID
+	if result.Subcommand == "" {
+		result.Subcommand = strings.ReplaceAll(path, " ", "-")
+	}
+	return nil
+}
+
+// parseLeadingGlobalFlags consumes global flags ahead of a subcommand name
+func parseLeadingGlobalFlags(args []string, result *ParseResult) ([]string, error) {
+	flags := flagSet(nil)
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		flag, ok := flags[name]
+		if !ok || !strings.HasPrefix(args[0], "-") {
+			return args, nil
+		}
+		consumed, err := applyFlag(flag, name, value, hasValue, args[1:], result)
+		if err != nil {
+			return nil, err
+		}
+		args = args[1+consumed:]
+	}
+	return args, nil
+}
+
+// flagSet indexes a command's own flags and the global flags it does not shadow by name
+func flagSet(own []cdeFlag) map[string]cdeFlag {
+	flags := map[string]cdeFlag{}
+	for _, flag := range globalFlags {
+		for _, name := range flag.Names {
+			flags[name] = flag
+		}
+	}
+	for _, flag := range own {
+		for _, name := range flag.Names {
+			flags[name] = flag
+		}
+	}
+	return flags
+}
+
+// applyFlag stores one parsed flag and returns how many of the following arguments it used
+func applyFlag(flag cdeFlag, name, value string, hasValue bool, next []string, result *ParseResult) (int, error) {
+	if !flag.Value {
+		if hasValue {
+			return 0, fmt.Errorf("flag %s does not take a value", name)
+		}
+		result.CCEFlags[flag.Key] = "true"
+		if flag.Set != "" {
+			result.CCEFlags[flag.Key] = flag.Set
+		}
+		return 0, nil
+	}
+
+	consumed := 0
+	if !hasValue {
+		if len(next) == 0 {
+			return 0, fmt.Errorf("flag %s requires a value", name)
+		}
+		value, consumed = next[0], 1
+	}
+	if previous := result.CCEFlags[flag.Key]; flag.Sep != "" && previous != "" {
+		value = previous + flag.Sep + value
+	}
+	result.CCEFlags[flag.Key] = value
+	return consumed, nil
+}
+
+// parseCommandFlags parses the flags and positional arguments of a leaf command
+func parseCommandFlags(cmd *cdeCommand, path string, args []string, result *ParseResult) error {
+	flags := flagSet(cmd.Flags)
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if !cmd.Passthrough {
+				return fmt.Errorf("%s does not take codex arguments after --", path)
+			}
+			result.ClaudeArgs = append(result.ClaudeArgs, args[i+1:]...)
+			result.Separator = true
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		flag, ok := flags[name]
+		if !ok {
+			if cmd.Passthrough {
+				return fmt.Errorf("unknown %s flag: %s (codex arguments go after --)", path, name)
+			}
+			return fmt.Errorf("unknown %s flag: %s", path, name)
+		}
+		consumed, err := applyFlag(flag, name, value, hasValue, args[i+1:], result)
+		if err != nil {
+			return err
+		}
+		i += consumed
+	}
+
+	if len(positional) < cmd.MinArgs || len(positional) > len(cmd.ArgKeys) {
+		if cmd.Usage != "" {
+			return fmt.Errorf("%s", cmd.Usage)
+		}
+		return fmt.Errorf("%s takes no arguments, got %s", path, strings.Join(positional, " "))
+	}
+	for i, value := range positional {
+		result.CCEFlags[cmd.ArgKeys[i]] = value
+	}
+	return nil
+}
+
+// checkAddFlags rejects add flag combinations that cannot work together
+func checkAddFlags(result *ParseResult) error {
+	flags := result.CCEFlags
+	if flags["clear_clipboard"] != "" && flags["key_from_clipboard"] == "" {
+		return fmt.Errorf("--clear-clipboard requires --key-from-clipboard")
+	}
+	if flags["key_from_stdin"] != "" && flags["key_from_clipboard"] != "" {
+		return fmt.Errorf("--api-key-stdin cannot be combined with --key-from-clipboard")
+	}
+	if flags["key_from_stdin"] == "" {
+		for _, flag := range []string{"name", "url", "model", "env_vars"} {
+			if flags[flag] != "" {
+				return fmt.Errorf("add flags --name, --url, --model and --env-var require --api-key-stdin")
+			}
+		}
+	}
+	return nil
+}
+
+// checkRemoveFlags requires exactly one of an environment name and --all
+func checkRemoveFlags(result *ParseResult) error {
+	target, all := result.CCEFlags["remove_target"] != "", result.CCEFlags["all"] != ""
+	if !target && !all {
+		return fmt.Errorf("remove command requires environment name")
+	}
+	if target && all {
+		return fmt.Errorf("remove --all cannot be combined with an environment name")
+	}
+	return nil
+}
+
+// checkPinFlags requires exactly one of an environment name, --list and --unpin
+func checkPinFlags(result *ParseResult) error {
+	given := 0
+	for _, key := range []string{"env", "list", "unpin"} {
+		if result.CCEFlags[key] != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return fmt.Errorf("usage: cde pin <env> | --list | --unpin")
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// compareColumnSeparator divides the side-by-side columns
+const compareColumnSeparator = " │ "
+
+// compareMinColumnWidth is the narrowest column before output falls back to stacked blocks
+const compareMinColumnWidth = 24
+
+// compareResult is one environment's run of the compared codex invocation
+type compareResult struct {
+	Env      string
+	URL      string
+	Output   string
+	ExitCode int
+	Duration time.Duration
+	Err      error // cde could not run codex at all
+}
+
+// status summarizes how the run ended
+func (r compareResult) status() string {
+	if r.Err != nil {
+		return "error: " + r.Err.Error()
+	}
+	return fmt.Sprintf("%.1fs, exit %d", r.Duration.Seconds(), r.ExitCode)
+}
+
+// runCodexCaptured runs codex with stdout and stderr captured and no stdin; tests replace it
+var runCodexCaptured = func(env Environment, args []string) (string, int, error) {
+	if err := checkCodexExists(); err != nil {
+		return "", -1, fmt.Errorf("Codex launcher failed: %w", err)
+	}
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		return "", -1, fmt.Errorf("Codex launcher failed: %w", err)
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(targetCommand, args...)
+	cmd.Env = envVars
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	exitCode, err := runCodexChild(cmd)
+	return output.String(), exitCode, err
+}
+
+// runCompare runs the same codex invocation against each environment in turn and shows the
+// outputs side by side, or writes them to outDir. Runs are sequential so they never edit the
+// same workspace at once.
+func runCompare(envNames []string, codexArgs []string, outDir string) error {
+	if len(envNames) < 2 {
+		return fmt.Errorf("compare requires at least two environments (-e a -e b)")
+	}
+	seen := map[string]bool{}
+	for _, name := range envNames {
+		if seen[name] {
+			return fmt.Errorf("environment '%s' is listed twice", name)
+		}
+		seen[name] = true
+	}
+	if len(codexArgs) == 0 || codexArgs[0] != "exec" {
+		return fmt.Errorf("compare needs a non-interactive codex command, e.g. cde compare -e a -e b -- exec \"<prompt>\"")
+	}
+
+	// Plan every launch first so a typo or missing key fails before any run
+	plans := make([]launchPlan, len(envNames))
+	for i, name := range envNames {
+		plan, err := planLaunch(name, codexArgs, launchOptions{NoPrompt: true, Separator: true})
+		if err != nil {
+			return err
+		}
+		plan.Environment, err = resolveEnvironmentSecrets(plan.Environment)
+		if err != nil {
+			return fmt.Errorf("API key resolution failed: %w", err)
+		}
+		if err := checkBudget(plan.Environment, false, time.Now()); err != nil {
+			return err
+		}
+		if err := checkRateLimit(plan.Environment, false, time.Now()); err != nil {
+			return err
+		}
+		plans[i] = plan
+	}
+
+	results := make([]compareResult, len(plans))
+	for i, plan := range plans {
+		env := plan.Environment
+		fmt.Fprintf(os.Stderr, "[%d/%d] Running codex with %s (%s)...\n", i+1, len(plans), env.Name, env.URL)
+		recordLaunchUsage(env, time.Now())
+
+		start := time.Now()
+		output, exitCode, err := runCodexCaptured(env, plan.Args)
+		results[i] = compareResult{Env: env.Name, URL: env.URL, Output: output, ExitCode: exitCode, Duration: time.Since(start), Err: err}
+		if err == nil {
+			recordLaunchResult(plan.Config, env, plan.Args, exitCode, results[i].Duration)
+		}
+	}
+
+	if outDir != "" {
+		if err := writeCompareOutputs(outDir, results); err != nil {
+			return err
+		}
+	} else {
+		renderComparison(os.Stdout, results, detectTerminalLayout().Width)
+	}
+
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil || result.ExitCode != 0 {
+			failed = append(failed, result.Env)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("codex execution failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// writeCompareOutputs saves each output as <dir>/<env>.txt and prints a timing summary
+func writeCompareOutputs(dir string, results []compareResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for _, result := range results {
+		path := filepath.Join(dir, result.Env+".txt")
+		if err := ioutil.WriteFile(path, []byte(result.Output), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("%-20s %-24s %s\n", result.Env, result.status(), path)
+	}
+	return nil
+}
+
+// renderComparison prints the outputs in columns, one per environment, with timing in the
+// headers; terminals too narrow for the columns get one block per environment instead
+func renderComparison(w io.Writer, results []compareResult, width int) {
+	n := len(results)
+	columnWidth := (width - utf8.RuneCountInString(compareColumnSeparator)*(n-1)) / n
+	if columnWidth < compareMinColumnWidth {
+		for _, result := range results {
+			fmt.Fprintf(w, "=== %s (%s) ===\n%s\n", result.Env, result.status(), strings.TrimRight(cleanCompareOutput(result.Output), "\n"))
+		}
+		return
+	}
+
+	columns := make([][]string, n)
+	rows := 0
+	for i, result := range results {
+		columns[i] = append([]string{result.Env + " (" + result.status() + ")", strings.Repeat("─", columnWidth)},
+			wrapColumn(cleanCompareOutput(result.Output), columnWidth)...)
+		if len(columns[i]) > rows {
+			rows = len(columns[i])
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		cells := make([]string, n)
+		for i, column := range columns {
+			cell := ""
+			if row < len(column) {
+				cell = column[row]
+			}
+			cells[i] = padColumn(cell, columnWidth)
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, compareColumnSeparator), " "))
+	}
+}
+
+// cleanCompareOutput removes color codes and expands tabs so column widths hold
+func cleanCompareOutput(output string) string {
+	output = ansiEscapePattern.ReplaceAllString(output, "")
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	return strings.ReplaceAll(output, "\t", "    ")
+}
+
+// wrapColumn splits text into lines of at most width runes
+func wrapColumn(text string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		runes := []rune(line)
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+// padColumn truncates or pads cell to exactly width runes
+func padColumn(cell string, width int) string {
+	runes := []rune(cell)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return cell + strings.Repeat(" ", width-len(runes))
+}
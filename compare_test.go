@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderComparison(t *testing.T) {
+	results := []compareResult{
+		{Env: "a", Output: "\x1b[32mhello\x1b[0m\nsecond line", Duration: 1500 * time.Millisecond},
+		{Env: "b", Output: strings.Repeat("x", 40), Duration: 2 * time.Second, ExitCode: 1},
+	}
+
+	var out bytes.Buffer
+	renderComparison(&out, results, 63)
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "a (1.5s, exit 0)") || !strings.Contains(lines[0], "│ b (2.0s, exit 1)") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], "hello ") || strings.Contains(out.String(), "\x1b") {
+		t.Errorf("first output row = %q, want color codes stripped", lines[2])
+	}
+	// The 40-character line wraps in a 30-column layout
+	if !strings.HasSuffix(lines[2], strings.Repeat("x", 30)) || !strings.HasSuffix(lines[3], strings.Repeat("x", 10)) {
+		t.Errorf("wrapped rows = %q / %q", lines[2], lines[3])
+	}
+
+	out.Reset()
+	renderComparison(&out, results, 40)
+	if !strings.Contains(out.String(), "=== a (1.5s, exit 0) ===\nhello\nsecond line\n=== b") {
+		t.Errorf("narrow layout should stack outputs:\n%s", out.String())
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	if err := saveConfig(Config{Environments: []Environment{
+		{Name: "prodA", URL: "https://a.example.com/v1", APIKey: "sk-a-1234567890", Model: "gpt-5"},
+		{Name: "prodB", URL: "https://b.example.com/v1", APIKey: "sk-b-1234567890"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][]string
+	originalRun := runCodexCaptured
+	runCodexCaptured = func(env Environment, args []string) (string, int, error) {
+		calls = append(calls, append([]string{env.Name}, args...))
+		return "answer from " + env.Name + "\n", 0, nil
+	}
+	defer func() { runCodexCaptured = originalRun }()
+
+	outDir := filepath.Join(t.TempDir(), "out")
+	if err := handleCommand([]string{"compare", "-e", "prodA", "-e", "prodB", "--out", outDir, "--", "exec", "say hi"}); err != nil {
+		t.Fatalf("compare failed: %v", err)
+	}
+	want := [][]string{{"prodA", "-m", "gpt-5", "exec", "say hi"}, {"prodB", "exec", "say hi"}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("codex calls = %q, want %q", calls, want)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(outDir, "prodB.txt"))
+	if err != nil || string(data) != "answer from prodB\n" {
+		t.Errorf("prodB.txt = %q, %v", data, err)
+	}
+
+	errorCases := map[string][]string{
+		"one environment":   {"compare", "-e", "prodA", "--", "exec", "hi"},
+		"duplicate":         {"compare", "-e", "prodA", "-e", "prodA", "--", "exec", "hi"},
+		"interactive codex": {"compare", "-e", "prodA", "-e", "prodB"},
+		"unknown env":       {"compare", "-e", "prodA", "-e", "nope", "--", "exec", "hi"},
+		"unknown flag":      {"compare", "-e", "prodA", "-e", "prodB", "exec"},
+	}
+	for name, args := range errorCases {
+		calls = nil
+		if err := handleCommand(args); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if len(calls) != 0 {
+			t.Errorf("%s: codex ran %d time(s) before the error", name, len(calls))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// completionKinds lists what 'cde __complete' can print
+var completionKinds = []string{"env-names"}
+
+// readEnvironmentNames streams config.json and returns only the environment names,
+// skipping validation and every other field so shell completion stays fast
+func readEnvironmentNames(r io.Reader) ([]string, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "environments" {
+			if err := skipValue(decoder); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := expectDelim(decoder, '['); err != nil {
+			return nil, err
+		}
+		for decoder.More() {
+			name, err := readNameField(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+	return names, nil
+}
+
+// readNameField reads one environment object and returns its name
+func readNameField(decoder *json.Decoder) (string, error) {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return "", err
+	}
+	var name string
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if key == "name" {
+			if err := decoder.Decode(&name); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := skipValue(decoder); err != nil {
+			return "", err
+		}
+	}
+	_, err := decoder.Token() // closing '}'
+	return name, err
+}
+
+// skipValue consumes the next JSON value without decoding it
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// expectDelim consumes the next token and checks that it is the given delimiter
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected '%s' in configuration, got %v", delim, token)
+	}
+	return nil
+}
+
+// runComplete prints completion candidates one per line. It never fails loudly:
+// a missing or broken config just yields no candidates.
+func runComplete(kind string) error {
+	switch kind {
+	case "env-names":
+		if envOnlySource() != "" {
+			if config, err := loadEnvOnlyConfig(); err == nil {
+				for _, env := range config.Environments {
+					fmt.Println(env.Name)
+				}
+			}
+			return nil
+		}
+		configPath, err := getConfigPath()
+		if err != nil {
+			return nil
+		}
+		file, err := os.Open(configPath)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+		names, _ := readEnvironmentNames(newJSONCReader(file))
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown completion kind '%s' (supported: %v)", kind, completionKinds)
+	}
+}
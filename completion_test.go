@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadEnvironmentNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "names among other fields",
+			config: `{"settings":{"hooks":{"pre_launch":["a"]}},"environments":[{"url":"https://x","name":"prod","env_vars":{"name":"nested"}},{"name":"staging","headers":[{"k":"v"}]}]}`,
+			want:   []string{"prod", "staging"},
+		},
+		{name: "no environments", config: `{"settings":{}}`},
+		{name: "empty list", config: `{"environments":[]}`},
+		{name: "unnamed entry skipped", config: `{"environments":[{"url":"https://x"},{"name":"dev"}]}`, want: []string{"dev"}},
+		{name: "truncated", config: `{"environments":[{"name":"prod"`, wantErr: true},
+		{name: "not an object", config: `[]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEnvironmentNames(strings.NewReader(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readEnvironmentNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readEnvironmentNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCompleteCommand(t *testing.T) {
+	result := parseArguments([]string{"__complete", "env-names"})
+	if result.Error != nil || result.Subcommand != "complete" || result.CCEFlags["complete_kind"] != "env-names" {
+		t.Errorf("parseArguments() = %+v", result)
+	}
+	if result := parseArguments([]string{"__complete"}); result.Error == nil {
+		t.Error("__complete without a kind should fail")
+	}
+	if err := runComplete("commands"); err == nil {
+		t.Error("unknown completion kind should fail")
+	}
+}
+
+func BenchmarkReadEnvironmentNames(b *testing.B) {
+	var entries []string
+	for i := 0; i < 2000; i++ {
+		entries = append(entries, fmt.Sprintf(`{"name":"env-%d","url":"https://api-%d.example.com/v1","api_key":"sk-%040d","env_vars":{"A":"1","B":"2"}}`, i, i, i))
+	}
+	config := `{"environments":[` + strings.Join(entries, ",") + `]}`
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := readEnvironmentNames(strings.NewReader(config)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -18,14 +19,29 @@ type configBackup struct {
 
 // newConfigBackup creates a backup manager
 func newConfigBackup(configPath string) *configBackup {
+	// Profiles share a directory, so each non-default config file keeps its backups apart
+	backupDir := filepath.Dir(configPath) + "/backups"
+	if base := filepath.Base(configPath); base != "config.json" {
+		backupDir = filepath.Join(backupDir, strings.TrimSuffix(base, filepath.Ext(base)))
+	}
 	return &configBackup{
 		originalPath: configPath,
-		backupDir:    filepath.Dir(configPath) + "/backups",
+		backupDir:    backupDir,
 	}
 }
 
 // createBackup creates a timestamped backup of the configuration
 func (cb *configBackup) createBackup() (string, error) {
+	return cb.createBackupNamed("config-")
+}
+
+// createLabeledBackup creates pre-<label>-<timestamp>.json ahead of a destructive command
+func (cb *configBackup) createLabeledBackup(label string) (string, error) {
+	return cb.createBackupNamed("pre-" + label + "-")
+}
+
+// createBackupNamed copies the configuration to <prefix><timestamp>.json in the backup directory
+func (cb *configBackup) createBackupNamed(prefix string) (string, error) {
 	// Ensure backup directory exists
 	if err := os.MkdirAll(cb.backupDir, 0700); err != nil {
 		return "", fmt.Errorf("failed to create backup directory: %w", err)
@@ -37,8 +53,8 @@ func (cb *configBackup) createBackup() (string, error) {
 	}
 
 	// Create timestamped backup filename
-	timestamp := time.Now().Format("20060102-150405")
-	backupPath := filepath.Join(cb.backupDir, fmt.Sprintf("config-%s.json", timestamp))
+	timestamp := time.Now().Format(backupTimestampLayout)
+	backupPath := filepath.Join(cb.backupDir, prefix+timestamp+".json")
 
 	// Read original file
 	data, err := ioutil.ReadFile(cb.originalPath)
@@ -65,10 +81,11 @@ func detectCorruption(configPath string) error {
 		return fmt.Errorf("configuration file is empty")
 	}
 
-	// Basic JSON validation
+	// Basic JSON validation (comments and trailing commas are allowed)
+	data = stripJSONC(data)
 	var testConfig Config
 	if err := json.Unmarshal(data, &testConfig); err != nil {
-		return fmt.Errorf("configuration file contains invalid JSON: %w", err)
+		return fmt.Errorf("configuration file contains invalid JSON: %w", describeJSONError(data, err))
 	}
 
 	// Check for null JSON or other invalid structures
@@ -80,42 +97,28 @@ func detectCorruption(configPath string) error {
 	return nil
 }
 
-// repairConfiguration attempts to repair corrupted configuration
-func repairConfiguration(configPath string) error {
+// backupRestoreHint points at 'cde backup restore' when a usable backup of configPath exists
+func backupRestoreHint(configPath string) string {
 	backup := newConfigBackup(configPath)
-
-	// Create backup of corrupted file
-	if backupPath, err := backup.createBackup(); err == nil && backupPath != "" {
-		fmt.Printf("Corrupted configuration backed up to: %s\n", backupPath)
-	}
-
-	// Try to find the most recent valid backup
-	if validBackup, err := findValidBackup(backup.backupDir); err == nil && validBackup != "" {
-		fmt.Printf("Restoring from backup: %s\n", validBackup)
-		return copyFile(validBackup, configPath)
+	validBackup, err := findValidBackup(backup.backupDir)
+	if err != nil {
+		return ""
 	}
-
-	// No valid backup found, create minimal configuration
-	fmt.Println("No valid backup found, creating minimal configuration...")
-	minimalConfig := Config{Environments: []Environment{}}
-	return saveConfigDirect(minimalConfig, configPath)
+	id := backupID(validBackup)
+	return fmt.Sprintf("\nto restore the last valid backup run 'cde backup restore %s' (see 'cde backup list')", id)
 }
 
 // findValidBackup searches for the most recent valid backup
 func findValidBackup(backupDir string) (string, error) {
-	entries, err := ioutil.ReadDir(backupDir)
+	backups, err := (&configBackup{backupDir: backupDir}).listBackups()
 	if err != nil {
 		return "", err
 	}
 
-	// Sort by modification time (newest first)
-	for i := len(entries) - 1; i >= 0; i-- {
-		entry := entries[i]
-		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
-			backupPath := filepath.Join(backupDir, entry.Name())
-			if detectCorruption(backupPath) == nil {
-				return backupPath, nil
-			}
+	// Newest first
+	for i := len(backups) - 1; i >= 0; i-- {
+		if detectCorruption(backups[i].Path) == nil {
+			return backups[i].Path, nil
 		}
 	}
 
@@ -146,20 +149,27 @@ func saveConfigDirect(config Config, configPath string) error {
 	return ioutil.WriteFile(configPath, data, 0600)
 }
 
-// configPathOverride allows tests to override the config path
+// configPathOverride is set by --config (and by tests) and bypasses profile selection
 var configPathOverride string
 
-// getConfigPath returns the path to the configuration file
+// getConfigPath returns the path to the configuration file: --config, else the active profile's file
 func getConfigPath() (string, error) {
 	if configPathOverride != "" {
+		logTracef("config path %s (override)", configPathOverride)
 		return configPathOverride, nil
 	}
 
-	home, err := os.UserHomeDir()
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	profile, _, err := activeProfile(configDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("profile selection failed: %w", err)
 	}
-	return filepath.Join(home, ".codex-env", "config.json"), nil
+	path := filepath.Join(configDir, profileConfigName(profile))
+	logTracef("config path %s (profile %s)", path, profile)
+	return path, nil
 }
 
 // ensureConfigDir creates the configuration directory with proper permissions
@@ -201,6 +211,29 @@ func ensureConfigDir() error {
 
 // loadConfig reads and parses the configuration file with comprehensive error handling and recovery
 func loadConfig() (Config, error) {
+	config, err := loadConfigLazy()
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Validate all environments
+	for i, env := range config.Environments {
+		if err := validateEnvironment(env); err != nil {
+			return Config{}, fmt.Errorf("configuration validation failed for environment %d (%s): %w", i, env.Name, err)
+		}
+		logTracef("environment '%s' passed validation", env.Name)
+	}
+	return config, nil
+}
+
+// loadConfigLazy loads the configuration and validates its settings but not the individual
+// environments; callers validate the environment they use with validateSelectedEnvironment
+func loadConfigLazy() (Config, error) {
+	// Env-only mode never reads config.json (containers, read-only filesystems)
+	if envOnlySource() != "" {
+		return loadEnvOnlyConfig()
+	}
+
 	configPath, err := getConfigPath()
 	if err != nil {
 		return Config{}, fmt.Errorf("configuration loading failed: %w", err)
@@ -209,6 +242,7 @@ func loadConfig() (Config, error) {
 	// Check if file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		// Return empty configuration if file doesn't exist (not an error)
+		logDebugf("no configuration at %s; starting empty", configPath)
 		return Config{Environments: []Environment{}}, nil
 	} else if err != nil {
 		return Config{}, fmt.Errorf("configuration file access failed: %w", err)
@@ -225,10 +259,13 @@ func loadConfig() (Config, error) {
 		return Config{Environments: []Environment{}}, nil
 	}
 
+	// Comments and trailing commas (JSONC) are allowed; offsets in errors are unchanged
+	data = stripJSONC(data)
+
 	// Parse JSON
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return Config{}, fmt.Errorf("configuration file parsing failed (invalid JSON): %w", err)
+		return Config{}, fmt.Errorf("configuration file parsing failed (invalid JSON): %w%s%s", describeJSONError(data, err), backupRestoreHint(configPath), salvageHint(data))
 	}
 
 	// Validate structure includes environments key when file isn't empty
@@ -244,18 +281,32 @@ func loadConfig() (Config, error) {
 		config.Environments = []Environment{}
 	}
 
-	// Validate all environments
-	for i, env := range config.Environments {
-		if err := validateEnvironment(env); err != nil {
-			return Config{}, fmt.Errorf("configuration validation failed for environment %d (%s): %w", i, env.Name, err)
-		}
+	if err := validateSettings(config.Settings); err != nil {
+		return Config{}, err
 	}
 
+	config.nameIndex = indexEnvironments(config.Environments)
+	logDebugf("loaded %d environment(s) from %s", len(config.Environments), configPath)
 	return config, nil
 }
 
+// saveOptions controls the backup saveConfig takes of the file it replaces
+type saveOptions struct {
+	BackupLabel string // Take a labeled backup (pre-<label>-<timestamp>.json) and print how to restore it
+	NoBackup    bool   // Skip the backup entirely (--no-backup)
+}
+
 // saveConfig writes the configuration to file with atomic operations, backup, and proper permissions
 func saveConfig(config Config) error {
+	return saveConfigWithOptions(config, saveOptions{})
+}
+
+// saveConfigWithOptions saves the configuration, backing up the previous file as opts asks
+func saveConfigWithOptions(config Config, opts saveOptions) error {
+	if err := requireWritableConfig(); err != nil {
+		return err
+	}
+
 	// Validate configuration before saving
 	for i, env := range config.Environments {
 		if err := validateEnvironment(env); err != nil {
@@ -276,10 +327,27 @@ func saveConfig(config Config) error {
 	// Create backup before saving (if file exists)
 	backup := newConfigBackup(configPath)
 	if _, err := os.Stat(configPath); err == nil {
-		if backupPath, backupErr := backup.createBackup(); backupErr != nil {
-			fmt.Printf("Warning: failed to create backup: %v\n", backupErr)
-		} else if backupPath != "" {
-			fmt.Printf("Configuration backed up to: %s\n", backupPath)
+		switch {
+		case opts.NoBackup:
+		case opts.BackupLabel != "":
+			// The user is told how to undo the command, so a failed backup stops it
+			backupPath, backupErr := backup.createLabeledBackup(opts.BackupLabel)
+			if backupErr != nil {
+				return fmt.Errorf("configuration save failed: %w", backupErr)
+			}
+			fmt.Printf("Configuration backed up to: %s (undo with 'cde backup restore %s')\n", backupPath, backupID(backupPath))
+		case backupOnSave(config):
+			if backupPath, backupErr := backup.createBackup(); backupErr != nil {
+				fmt.Printf("Warning: failed to create backup: %v\n", backupErr)
+			} else if backupPath != "" {
+				fmt.Printf("Configuration backed up to: %s\n", backupPath)
+			}
+		}
+
+		// Apply the retention policy so the backups directory stays bounded
+		maxCount, maxAge := backupRetention(config)
+		if _, pruneErr := backup.prune(maxCount, maxAge, time.Now()); pruneErr != nil {
+			fmt.Printf("Warning: failed to prune backups: %v\n", pruneErr)
 		}
 	}
 
@@ -289,6 +357,15 @@ func saveConfig(config Config) error {
 		return fmt.Errorf("configuration serialization failed: %w", err)
 	}
 
+	// Keep the user's comments, spacing and key order when the file is hand-edited JSONC
+	if existing, readErr := ioutil.ReadFile(configPath); readErr == nil && len(bytes.TrimSpace(existing)) > 0 {
+		if preserved, layoutErr := preserveJSONCLayout(existing, data); layoutErr == nil {
+			data = preserved
+		} else {
+			logDebugf("rewriting %s without its previous layout: %v", configPath, layoutErr)
+		}
+	}
+
 	// Use atomic write pattern (temp file + rename)
 	tempPath := configPath + ".tmp"
 
@@ -338,11 +415,16 @@ func saveConfig(config Config) error {
 		}
 	}
 
+	logDebugf("saved %d environment(s) to %s", len(config.Environments), configPath)
 	return nil
 }
 
 // findEnvironmentByName searches for an environment by name and returns its index
 func findEnvironmentByName(config Config, name string) (int, bool) {
+	// The index is built at load time; entries added or moved since then fall back to a scan
+	if i, ok := config.nameIndex[name]; ok && i < len(config.Environments) && config.Environments[i].Name == name {
+		return i, true
+	}
 	for i, env := range config.Environments {
 		if env.Name == name {
 			return i, true
@@ -351,19 +433,93 @@ func findEnvironmentByName(config Config, name string) (int, bool) {
 	return -1, false
 }
 
-// equalEnvironments compares two environments for equality, including EnvVars maps
+// indexEnvironments maps each environment name to its position
+func indexEnvironments(environments []Environment) map[string]int {
+	index := make(map[string]int, len(environments))
+	for i, env := range environments {
+		if _, exists := index[env.Name]; !exists {
+			index[env.Name] = i
+		}
+	}
+	return index
+}
+
+// validateSelectedEnvironment validates an environment loaded with loadConfigLazy before it is used
+func validateSelectedEnvironment(env Environment) error {
+	if err := validateEnvironment(env); err != nil {
+		return fmt.Errorf("configuration validation failed for environment '%s': %w", env.Name, err)
+	}
+	return nil
+}
+
+// equalEnvironments compares two environments for equality, including all map fields
 func equalEnvironments(a, b Environment) bool {
-	if a.Name != b.Name || a.URL != b.URL || a.APIKey != b.APIKey || a.Model != b.Model {
+	if a.Name != b.Name || a.URL != b.URL || a.APIKey != b.APIKey || a.Model != b.Model ||
+		a.Provider != b.Provider || a.APIVersion != b.APIVersion || !equalStringSlices(a.AutoFlags, b.AutoFlags) ||
+		a.URLStrategy != b.URLStrategy || !equalStringSlices(a.URLs, b.URLs) || a.WireAPI != b.WireAPI {
+		return false
+	}
+
+	return equalStringMaps(a.EnvVars, b.EnvVars) && equalStringMaps(a.ModelParams, b.ModelParams) &&
+		equalStringMaps(a.Keys, b.Keys) && equalStringMaps(a.DeploymentMap, b.DeploymentMap) &&
+		equalStringMaps(a.ModelAliases, b.ModelAliases) && equalStringMaps(a.Headers, b.Headers) &&
+		equalProxySettings(a.Proxy, b.Proxy) && equalBudgetSettings(a.Budget, b.Budget) &&
+		equalAuthSettings(a.Auth, b.Auth)
+}
+
+// equalBudgetSettings compares budgets, treating nil and the zero budget as equal
+func equalBudgetSettings(a, b *BudgetSettings) bool {
+	var zero BudgetSettings
+	if a == nil {
+		a = &zero
+	}
+	if b == nil {
+		b = &zero
+	}
+	return *a == *b
+}
+
+// equalAuthSettings compares auth blocks
+func equalAuthSettings(a, b *AuthSettings) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Type == b.Type && a.Flow == b.Flow && a.TokenURL == b.TokenURL &&
+		a.DeviceAuthorization == b.DeviceAuthorization && a.ClientID == b.ClientID &&
+		a.ClientSecret == b.ClientSecret && equalStringSlices(a.Scopes, b.Scopes) && a.Audience == b.Audience &&
+		a.Profile == b.Profile && a.Region == b.Region && a.RoleARN == b.RoleARN &&
+		a.Project == b.Project && a.Location == b.Location && a.Account == b.Account && a.ADC == b.ADC
+}
+
+// equalProxySettings compares proxy settings, treating nil and an empty URL as equal
+func equalProxySettings(a, b *ProxySettings) bool {
+	if a == nil || b == nil {
+		return (a == nil || a.URL == "") && (b == nil || b.URL == "")
+	}
+	return a.URL == b.URL && equalStringSlices(a.NoProxy, b.NoProxy)
+}
+
+// equalStringSlices compares two string slices element by element
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
 		return false
 	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
 
-	// Compare EnvVars maps
-	if len(a.EnvVars) != len(b.EnvVars) {
+// equalStringMaps compares two string maps, treating nil and empty as equal
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
 		return false
 	}
 
-	for key, valueA := range a.EnvVars {
-		valueB, exists := b.EnvVars[key]
+	for key, valueA := range a {
+		valueB, exists := b[key]
 		if !exists || valueA != valueB {
 			return false
 		}
@@ -372,6 +528,82 @@ func equalEnvironments(a, b Environment) bool {
 	return true
 }
 
+// cloneEnvironment returns a deep copy of env renamed to name
+func cloneEnvironment(env Environment, name string) Environment {
+	clone := env
+	clone.Name = name
+	clone.EnvVars = copyStringMap(env.EnvVars)
+	clone.ModelParams = copyStringMap(env.ModelParams)
+	clone.Keys = copyStringMap(env.Keys)
+	clone.DeploymentMap = copyStringMap(env.DeploymentMap)
+	clone.ModelAliases = copyStringMap(env.ModelAliases)
+	clone.Headers = copyStringMap(env.Headers)
+	if env.AutoFlags != nil {
+		clone.AutoFlags = append([]string{}, env.AutoFlags...)
+	}
+	if env.URLs != nil {
+		clone.URLs = append([]string{}, env.URLs...)
+	}
+	if env.Budget != nil {
+		budget := *env.Budget
+		clone.Budget = &budget
+	}
+	if env.Proxy != nil {
+		proxy := *env.Proxy
+		proxy.NoProxy = append([]string(nil), env.Proxy.NoProxy...)
+		clone.Proxy = &proxy
+	}
+	if env.Auth != nil {
+		auth := *env.Auth
+		auth.Scopes = append([]string(nil), env.Auth.Scopes...)
+		clone.Auth = &auth
+	}
+	if env.RateLimit != nil {
+		rateLimit := *env.RateLimit
+		clone.RateLimit = &rateLimit
+	}
+	if env.EnvPassthrough != nil {
+		passthrough := *env.EnvPassthrough
+		passthrough.Allow = append([]string(nil), env.EnvPassthrough.Allow...)
+		passthrough.Deny = append([]string(nil), env.EnvPassthrough.Deny...)
+		clone.EnvPassthrough = &passthrough
+	}
+	return clone
+}
+
+// selectAPIKey returns a copy of env whose APIKey is the named key from env.Keys
+func selectAPIKey(env Environment, keyName string) (Environment, error) {
+	apiKey, exists := env.Keys[keyName]
+	if !exists {
+		return Environment{}, fmt.Errorf("key '%s' not found in environment '%s'", keyName, env.Name)
+	}
+	selected := cloneEnvironment(env, env.Name)
+	selected.APIKey = apiKey
+	return selected, nil
+}
+
+// rotateAPIKey swaps env.APIKey with the named key so the named key becomes active
+func rotateAPIKey(env Environment, keyName string) (Environment, error) {
+	rotated, err := selectAPIKey(env, keyName)
+	if err != nil {
+		return Environment{}, err
+	}
+	rotated.Keys[keyName] = env.APIKey
+	return rotated, nil
+}
+
+// copyStringMap returns an independent copy of m (nil stays nil)
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
+
 // addEnvironmentToConfig adds a new environment to the configuration after validation
 func addEnvironmentToConfig(config *Config, env Environment) error {
 	// Validate environment first
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// configSecretFieldPattern matches field names holding credentials (api_key, keys,
+// client_secret) but not ones that merely mention them (token_url)
+var configSecretFieldPattern = regexp.MustCompile(`(?i)(key|token|secret|password)s?$`)
+
+// configFieldChange is one field that differs between two versions of a configuration.
+// Old or New is empty when the field is unset on that side.
+type configFieldChange struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// configEnvDiff describes how one environment differs between two configurations
+type configEnvDiff struct {
+	Name    string
+	Action  string // "added", "removed" or "changed"
+	Changes []configFieldChange
+}
+
+// configDocument is a configuration file decoded without the Config struct, so fields this
+// version of cde does not know about are compared too
+type configDocument struct {
+	Environments []map[string]interface{} `json:"environments"`
+	Settings     map[string]interface{}   `json:"settings"`
+}
+
+// loadConfigDocument reads a config.json or backup for diffing
+func loadConfigDocument(path string) (configDocument, error) {
+	var doc configDocument
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return doc, fmt.Errorf("configuration file read failed: %w", err)
+	}
+	if err := json.Unmarshal(stripJSONC(data), &doc); err != nil {
+		return doc, fmt.Errorf("failed to parse %s: %w", path, describeJSONError(data, err))
+	}
+	return doc, nil
+}
+
+// flattenConfigValue records v under dotted paths; objects are expanded, other values are
+// rendered as compact JSON (strings without quotes)
+func flattenConfigValue(prefix string, v interface{}, out map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenConfigValue(path, child, out)
+		}
+	case string:
+		out[prefix] = value
+	case nil:
+	default:
+		data, _ := json.Marshal(value)
+		out[prefix] = string(data)
+	}
+}
+
+// maskConfigValue hides credentials the same way 'env print' and dry runs do: secret fields,
+// secret-looking env_vars, header values and proxy passwords
+func maskConfigValue(path, value string) string {
+	if value == "" {
+		return value
+	}
+	segments := strings.Split(path, ".")
+	switch {
+	case segments[0] == "headers":
+		return maskAPIKey(value)
+	case segments[0] == "env_vars":
+		if secretVarPattern.MatchString(segments[len(segments)-1]) {
+			return maskAPIKey(value)
+		}
+		return value
+	case segments[0] == "proxy":
+		return redactProxyURL(value)
+	}
+	for _, segment := range segments {
+		if configSecretFieldPattern.MatchString(segment) {
+			return maskAPIKey(value)
+		}
+	}
+	return value
+}
+
+// diffConfigFields compares two flattened objects, returning masked changes in path order
+func diffConfigFields(oldObject, newObject map[string]interface{}) []configFieldChange {
+	oldFields, newFields := map[string]string{}, map[string]string{}
+	flattenConfigValue("", oldObject, oldFields)
+	flattenConfigValue("", newObject, newFields)
+
+	paths := []string{}
+	for path := range oldFields {
+		paths = append(paths, path)
+	}
+	for path := range newFields {
+		if _, exists := oldFields[path]; !exists {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []configFieldChange{}
+	for _, path := range paths {
+		oldValue, newValue := oldFields[path], newFields[path]
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, configFieldChange{Path: path, Old: maskConfigValue(path, oldValue), New: maskConfigValue(path, newValue)})
+	}
+	return changes
+}
+
+// diffConfigDocuments compares environments by name, in the order of the newer document
+// followed by removed ones, and the settings block
+func diffConfigDocuments(oldDoc, newDoc configDocument) ([]configEnvDiff, []configFieldChange) {
+	oldByName := map[string]map[string]interface{}{}
+	for _, env := range oldDoc.Environments {
+		name, _ := env["name"].(string)
+		oldByName[name] = env
+	}
+	newNames := map[string]bool{}
+
+	diffs := []configEnvDiff{}
+	for _, env := range newDoc.Environments {
+		name, _ := env["name"].(string)
+		newNames[name] = true
+		if oldEnv, exists := oldByName[name]; exists {
+			if changes := diffConfigFields(oldEnv, env); len(changes) > 0 {
+				diffs = append(diffs, configEnvDiff{Name: name, Action: "changed", Changes: changes})
+			}
+		} else {
+			diffs = append(diffs, configEnvDiff{Name: name, Action: "added", Changes: diffConfigFields(nil, env)})
+		}
+	}
+	for _, env := range oldDoc.Environments {
+		if name, _ := env["name"].(string); !newNames[name] {
+			diffs = append(diffs, configEnvDiff{Name: name, Action: "removed", Changes: diffConfigFields(env, nil)})
+		}
+	}
+	return diffs, diffConfigFields(oldDoc.Settings, newDoc.Settings)
+}
+
+// printConfigDiff writes a diff in a +/-/~ summary with one line per changed field
+func printConfigDiff(w io.Writer, oldLabel, newLabel string, diffs []configEnvDiff, settings []configFieldChange) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldLabel, newLabel)
+	if len(diffs) == 0 && len(settings) == 0 {
+		fmt.Fprintln(w, "No differences.")
+		return
+	}
+
+	markers := map[string]string{"added": "+", "removed": "-", "changed": "~"}
+	for _, diff := range diffs {
+		fmt.Fprintf(w, "%s %s (%s)\n", markers[diff.Action], diff.Name, diff.Action)
+		for _, change := range diff.Changes {
+			printFieldChange(w, diff.Action, change)
+		}
+	}
+	if len(settings) > 0 {
+		fmt.Fprintln(w, "~ settings (changed)")
+		for _, change := range settings {
+			printFieldChange(w, "changed", change)
+		}
+	}
+	fmt.Fprintf(w, "%d environment(s) differ", len(diffs))
+	if len(settings) > 0 {
+		fmt.Fprintf(w, ", %d setting(s) changed", len(settings))
+	}
+	fmt.Fprintln(w, ".")
+}
+
+// printFieldChange prints one field line; added and removed environments show a single value
+func printFieldChange(w io.Writer, action string, change configFieldChange) {
+	switch {
+	case action == "added":
+		fmt.Fprintf(w, "    %s: %s\n", change.Path, change.New)
+	case action == "removed":
+		fmt.Fprintf(w, "    %s: %s\n", change.Path, change.Old)
+	case change.Old == "":
+		fmt.Fprintf(w, "    %s: (unset) -> %s\n", change.Path, change.New)
+	case change.New == "":
+		fmt.Fprintf(w, "    %s: %s -> (unset)\n", change.Path, change.Old)
+	case change.Old == change.New:
+		// Both sides mask to the same text
+		fmt.Fprintf(w, "    %s: changed (secret)\n", change.Path)
+	default:
+		fmt.Fprintf(w, "    %s: %s -> %s\n", change.Path, change.Old, change.New)
+	}
+}
+
+// resolveDiffSource returns the path for a 'config diff' argument: an existing file, or
+// the ID of a backup of the active configuration
+func resolveDiffSource(arg string, cb *configBackup) (string, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return arg, nil
+	}
+	backup, err := cb.findBackup(arg)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is neither a file nor a backup ID (see 'cde backup list')", arg)
+	}
+	return backup.Path, nil
+}
+
+// runConfigDiff compares two configuration files. With one argument it is compared with the
+// current configuration; with none, the latest backup is.
+func runConfigDiff(fileA, fileB string) error {
+	cb, err := currentConfigBackup()
+	if err != nil {
+		return err
+	}
+
+	oldPath, newPath := fileA, fileB
+	if oldPath == "" {
+		backups, err := cb.listBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			return fmt.Errorf("no backups to compare with (see 'cde backup list')")
+		}
+		oldPath = backups[len(backups)-1].Path
+	} else if oldPath, err = resolveDiffSource(oldPath, cb); err != nil {
+		return err
+	}
+	if newPath == "" {
+		newPath = cb.originalPath
+	} else if newPath, err = resolveDiffSource(newPath, cb); err != nil {
+		return err
+	}
+
+	oldDoc, err := loadConfigDocument(oldPath)
+	if err != nil {
+		return err
+	}
+	newDoc, err := loadConfigDocument(newPath)
+	if err != nil {
+		return err
+	}
+	diffs, settings := diffConfigDocuments(oldDoc, newDoc)
+	printConfigDiff(os.Stdout, oldPath, newPath, diffs, settings)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffConfigDocuments(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+	ioutil.WriteFile(oldPath, []byte(`{
+  // JSONC is accepted
+  "environments": [
+    {"name": "prod", "url": "https://api.example.com", "api_key": "sk-old-1234567890", "model": "gpt-4.1",
+     "env_vars": {"REGION": "us", "AUTH_TOKEN": "tok-old-123456"}},
+    {"name": "gone", "url": "https://gone.example.com", "api_key": "sk-gone-1234567890"}
+  ],
+}`), 0600)
+	ioutil.WriteFile(newPath, []byte(`{
+  "environments": [
+    {"name": "prod", "url": "https://api.example.com", "api_key": "sk-new-0987654321", "model": "gpt-5",
+     "env_vars": {"REGION": "eu", "AUTH_TOKEN": "tok-new-654321"}, "auth": {"type": "oauth", "token_url": "https://idp.example.com/token"}},
+    {"name": "dev", "url": "http://localhost:8080", "api_key": "sk-dev-1234567890"}
+  ],
+  "settings": {"theme": "mono"}
+}`), 0600)
+
+	oldDoc, err := loadConfigDocument(oldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDoc, err := loadConfigDocument(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, settings := diffConfigDocuments(oldDoc, newDoc)
+
+	var out bytes.Buffer
+	printConfigDiff(&out, oldPath, newPath, diffs, settings)
+	got := out.String()
+	for _, want := range []string{
+		"~ prod (changed)\n",
+		"    api_key: sk-o*********7890 -> sk-n*********4321\n",
+		"    auth.token_url: (unset) -> https://idp.example.com/token\n",
+		"    env_vars.REGION: us -> eu\n",
+		"    model: gpt-4.1 -> gpt-5\n",
+		"+ dev (added)\n    api_key: sk-d*********7890\n",
+		"- gone (removed)\n",
+		"~ settings (changed)\n    theme: (unset) -> mono\n",
+		"3 environment(s) differ, 1 setting(s) changed.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff output missing %q:\n%s", want, got)
+		}
+	}
+	for _, secret := range []string{"sk-old-1234567890", "sk-new-0987654321", "tok-old-123456", "tok-new-654321"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("diff output leaks %s", secret)
+		}
+	}
+
+	out.Reset()
+	printConfigDiff(&out, oldPath, oldPath, nil, nil)
+	if !strings.Contains(out.String(), "No differences.") {
+		t.Errorf("identical files: %s", out.String())
+	}
+}
+
+func TestRunConfigDiffDefaultsToLatestBackup(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPathOverride = original }()
+
+	if err := runConfigDiff("", ""); err == nil {
+		t.Error("expected an error without backups")
+	}
+	for _, model := range []string{"gpt-4.1", "gpt-5"} {
+		if err := saveConfig(Config{Environments: []Environment{{Name: "prod", URL: "https://api.example.com", APIKey: "sk-prod-1234567890", Model: model}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runConfigDiff("", ""); err != nil {
+		t.Errorf("runConfigDiff() error = %v", err)
+	}
+	if err := runConfigDiff("no-such-backup", ""); err == nil {
+		t.Error("expected an error for an unknown file or backup ID")
+	}
+
+	result := parseArguments([]string{"config", "diff", "a.json", "b.json"})
+	if result.Error != nil || result.Subcommand != "config-diff" || result.CCEFlags["config_file"] != "a.json" || result.CCEFlags["config_file_b"] != "b.json" {
+		t.Errorf("config diff parse = %+v", result)
+	}
+}
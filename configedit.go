@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// configEditTemplate seeds config.json when 'cde config edit' runs before anything was added
+const configEditTemplate = "{\n  // Add environments here, e.g.\n  // {\"name\": \"prod\", \"url\": \"https://api.openai.com/v1\", \"api_key\": \"sk-...\"}\n  \"environments\": []\n}\n"
+
+// editorCommand returns $VISUAL, then $EDITOR, then the platform default
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// runEditor opens path in the editor and waits for it to exit; tests replace it.
+// The editor value may carry arguments (e.g. "code --wait"), so it goes through the shell.
+var runEditor = func(editor, path string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	} else {
+		cmd = exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runConfigEdit opens config.json in the user's editor and validates it after each save.
+// An invalid result can be edited again or reverted from the pre-edit backup.
+func runConfigEdit() error {
+	if err := requireWritableConfig(); err != nil {
+		return err
+	}
+
+	configPath, err := getConfigPath()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	original, err := ioutil.ReadFile(configPath)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("configuration file read failed: %w", err)
+	}
+
+	backupPath := ""
+	if existed {
+		if backupPath, err = newConfigBackup(configPath).createBackup(); err != nil {
+			return fmt.Errorf("configuration edit failed: %w", err)
+		}
+		fmt.Printf("Configuration backed up to: %s\n", backupPath)
+	} else if err := ioutil.WriteFile(configPath, []byte(configEditTemplate), 0600); err != nil {
+		return fmt.Errorf("configuration edit failed: %w", err)
+	}
+
+	revert := func() error {
+		if !existed {
+			return os.Remove(configPath)
+		}
+		return copyFile(backupPath, configPath)
+	}
+
+	editor := editorCommand()
+	for {
+		if err := runEditor(editor, configPath); err != nil {
+			if revertErr := revert(); revertErr != nil {
+				return fmt.Errorf("editor '%s' failed: %v (revert also failed: %w)", editor, err, revertErr)
+			}
+			return fmt.Errorf("editor '%s' failed, changes reverted: %w", editor, err)
+		}
+
+		data, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return fmt.Errorf("configuration file read failed: %w", err)
+		}
+		if existed && bytes.Equal(data, original) {
+			fmt.Println("No changes made.")
+			return nil
+		}
+
+		violations, err := validateConfigData(data)
+		if err == nil && len(violations) == 0 {
+			fmt.Printf("Configuration saved: %s\n", configPath)
+			return nil
+		}
+
+		if err != nil {
+			fmt.Printf("%s: %v\n", configPath, err)
+		}
+		for _, v := range violations {
+			fmt.Printf("%s:%d: %s: %s\n", configPath, v.Line, v.Path, v.Message)
+		}
+
+		answer, promptErr := regularInput(tr("Configuration is invalid. [e]dit again or [r]evert? [E/r]: "))
+		if promptErr == nil && answer != "" && !strings.HasPrefix(strings.ToLower(answer), "e") {
+			promptErr = fmt.Errorf("aborted")
+		}
+		if promptErr != nil {
+			if err := revert(); err != nil {
+				return fmt.Errorf("configuration revert failed: %w", err)
+			}
+			fmt.Println("Changes reverted.")
+			return fmt.Errorf("configuration edit aborted: the file was invalid and has been reverted")
+		}
+	}
+}
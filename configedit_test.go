@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withEditor replaces runEditor with one that writes each of contents in turn
+func withEditor(t *testing.T, contents ...string) *int {
+	t.Helper()
+	calls := 0
+	original := runEditor
+	runEditor = func(editor, path string) error {
+		if calls >= len(contents) {
+			t.Fatalf("editor opened %d times, expected %d", calls+1, len(contents))
+		}
+		calls++
+		if contents[calls-1] == "" {
+			return nil // Quit without saving
+		}
+		return ioutil.WriteFile(path, []byte(contents[calls-1]), 0600)
+	}
+	t.Cleanup(func() { runEditor = original })
+	return &calls
+}
+
+// withStdin feeds input to prompts read from os.Stdin
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() failed: %v", err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatalf("pipe write failed: %v", err)
+	}
+	w.Close()
+	original := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = original; r.Close() })
+}
+
+func TestRunConfigEdit(t *testing.T) {
+	const valid = `{"environments": [{"name": "prod", "url": "https://api.openai.com/v1", "api_key": "sk-prod-1234567890"}]}`
+	const edited = `{"environments": [{"name": "dev", "url": "http://localhost:8080/v1", "api_key": "sk-dev-1234567890"}]}`
+	const invalid = `{"environments": [{"name": "dev", "url": "not-a-url", "api_key": "sk-dev-1234567890"}]}`
+
+	tests := []struct {
+		name     string
+		existing string
+		edits    []string
+		input    string
+		want     string
+		wantErr  bool
+	}{
+		{name: "valid edit", existing: valid, edits: []string{edited}, want: edited},
+		{name: "no changes", existing: valid, edits: []string{""}, want: valid},
+		{name: "re-edit after invalid", existing: valid, edits: []string{invalid, edited}, input: "e\n", want: edited},
+		{name: "revert after invalid", existing: valid, edits: []string{invalid}, input: "r\n", want: valid, wantErr: true},
+		{name: "non-interactive reverts", existing: valid, edits: []string{"{"}, want: valid, wantErr: true},
+		{name: "new file from template", edits: []string{edited}, want: edited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			original := configPathOverride
+			configPathOverride = configPath
+			defer func() { configPathOverride = original }()
+
+			if tt.existing != "" {
+				if err := ioutil.WriteFile(configPath, []byte(tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			calls := withEditor(t, tt.edits...)
+			withStdin(t, tt.input)
+
+			err := runConfigEdit()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runConfigEdit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if *calls != len(tt.edits) {
+				t.Errorf("editor opened %d times, want %d", *calls, len(tt.edits))
+			}
+			data, err := ioutil.ReadFile(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("config.json = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunConfigEditTemplateIsValid(t *testing.T) {
+	violations, err := validateConfigData([]byte(configEditTemplate))
+	if err != nil || len(violations) != 0 {
+		t.Errorf("template should be valid: %v %+v", err, violations)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano -w")
+	if got := editorCommand(); got != "nano -w" {
+		t.Errorf("editorCommand() = %q, want $EDITOR", got)
+	}
+	t.Setenv("VISUAL", "code --wait")
+	if got := editorCommand(); !strings.HasPrefix(got, "code") {
+		t.Errorf("editorCommand() = %q, want $VISUAL to win", got)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// configSchemaJSON is the JSON Schema for config.json, printed by 'cde config schema'
+//
+//go:embed config.schema.json
+var configSchemaJSON []byte
+
+// jsonSchema is the subset of JSON Schema used by config.schema.json
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties,omitempty"` // false or a schema
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// configViolation is one problem found by 'cde config validate'
+type configViolation struct {
+	Path    string // e.g. environments[1].url
+	Line    int
+	Message string
+}
+
+// loadConfigSchema parses the embedded schema
+func loadConfigSchema() (*jsonSchema, error) {
+	var schema jsonSchema
+	if err := json.Unmarshal(configSchemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("embedded config schema is invalid: %w", err)
+	}
+	return &schema, nil
+}
+
+// resolve follows a local "#/$defs/name" reference
+func (s *jsonSchema) resolve(root *jsonSchema) *jsonSchema {
+	for s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return &jsonSchema{}
+		}
+		s = def
+	}
+	return s
+}
+
+// check validates value against the schema and appends every violation found
+func (s *jsonSchema) check(root *jsonSchema, value interface{}, path string, out *[]configViolation) {
+	s = s.resolve(root)
+	report := func(format string, args ...interface{}) {
+		*out = append(*out, configViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Enum) > 0 {
+		for _, allowed := range s.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return
+			}
+		}
+		report("must be one of %s", formatEnum(s.Enum))
+		return
+	}
+
+	if s.Type != "" && jsonTypeOf(value) != s.Type && !(s.Type == "number" && jsonTypeOf(value) == "integer") {
+		report("must be %s, not %s", withArticle(s.Type), jsonTypeOf(value))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*out = append(*out, configViolation{Path: joinJSONPath(path, name), Message: "missing required field"})
+			}
+		}
+		additional, closed := s.additionalSchema()
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := joinJSONPath(path, key)
+			if property, ok := s.Properties[key]; ok {
+				property.check(root, v[key], child, out)
+			} else if additional != nil {
+				additional.check(root, v[key], child, out)
+			} else if closed {
+				*out = append(*out, configViolation{Path: child, Message: "unknown field"})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(root, item, fmt.Sprintf("%s[%d]", path, i), out)
+			}
+		}
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			if *s.MinLength == 1 {
+				report("must not be empty")
+			} else {
+				report("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.MaxLength != nil && len(v) > *s.MaxLength {
+			report("must be at most %d characters", *s.MaxLength)
+		}
+	case json.Number:
+		if n, err := v.Float64(); err == nil && s.Minimum != nil && n < *s.Minimum {
+			report("must be at least %g", *s.Minimum)
+		}
+	}
+}
+
+// additionalSchema interprets additionalProperties: a schema for extra keys, or closed
+// when extra keys are not allowed
+func (s *jsonSchema) additionalSchema() (schema *jsonSchema, closed bool) {
+	raw := bytes.TrimSpace(s.AdditionalProperties)
+	switch {
+	case len(raw) == 0, string(raw) == "true":
+		return nil, false
+	case string(raw) == "false":
+		return nil, true
+	}
+	var additional jsonSchema
+	if err := json.Unmarshal(raw, &additional); err != nil {
+		return nil, false
+	}
+	return &additional, false
+}
+
+// jsonTypeOf names the JSON Schema type of a value decoded with UseNumber
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// withArticle returns "an object", "a string", ...
+func withArticle(word string) string {
+	if strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an " + word
+	}
+	return "a " + word
+}
+
+// formatEnum lists allowed values, skipping the empty string
+func formatEnum(values []interface{}) string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			names = append(names, fmt.Sprintf("%v", value))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// joinJSONPath appends an object key to a path
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexJSONLines maps every path in the document to the line its key or value starts on
+func indexJSONLines(data []byte) (map[string]int, error) {
+	lines := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	lineAt := func() int {
+		return 1 + bytes.Count(data[:dec.InputOffset()], []byte("\n"))
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if _, seen := lines[path]; !seen {
+			lines[path] = lineAt()
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := joinJSONPath(path, fmt.Sprint(key))
+				lines[child] = lineAt()
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	return lines, walk("")
+}
+
+// lineForPath returns the line of path, or of its nearest ancestor present in the file
+func lineForPath(lines map[string]int, path string) int {
+	for {
+		if line, ok := lines[path]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			return lines[""]
+		}
+		path = path[:cut]
+	}
+}
+
+// validateConfigData checks a config file against the schema and every registered
+// validator, returning all violations sorted by line. A syntax error is returned as err.
+func validateConfigData(data []byte) ([]configViolation, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	data = stripJSONC(data)
+
+	if err := json.Unmarshal(data, new(interface{})); err != nil {
+		return nil, fmt.Errorf("configuration file parsing failed (invalid JSON): %w", describeJSONError(data, err))
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var document interface{}
+	if err := dec.Decode(&document); err != nil {
+		return nil, fmt.Errorf("configuration file parsing failed (invalid JSON): %w", err)
+	}
+	lines, err := indexJSONLines(data)
+	if err != nil {
+		return nil, fmt.Errorf("configuration file parsing failed (invalid JSON): %w", err)
+	}
+
+	schema, err := loadConfigSchema()
+	if err != nil {
+		return nil, err
+	}
+	var violations []configViolation
+	schema.check(schema, document, "", &violations)
+
+	// Field validators run on whatever decoded; type errors were reported by the schema
+	var config Config
+	_ = json.Unmarshal(data, &config)
+	addViolation := func(path string, err error) {
+		for _, existing := range violations {
+			if existing.Path == path || strings.HasPrefix(existing.Path, path+".") || strings.HasPrefix(existing.Path, path+"[") {
+				return // The schema already explained this field
+			}
+		}
+		violations = append(violations, configViolation{Path: path, Message: err.Error()})
+	}
+	if config.Settings != nil {
+		for _, v := range settingsValidators {
+			if err := v.validate(config.Settings); err != nil {
+				addViolation("settings."+v.field, err)
+			}
+		}
+	}
+	for i, env := range config.Environments {
+		for _, v := range environmentValidators {
+			if err := v.validate(env); err != nil {
+				addViolation(fmt.Sprintf("environments[%d].%s", i, v.field), err)
+			}
+		}
+	}
+
+	for i := range violations {
+		violations[i].Line = lineForPath(lines, violations[i].Path)
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+		return violations[i].Path < violations[j].Path
+	})
+	return violations, nil
+}
+
+// runConfigValidate reports every problem in a config file (default: the active config.json)
+func runConfigValidate(file string) error {
+	var data []byte
+	var err error
+	if source := envOnlySource(); file == "" && source != "" {
+		// Env-only mode: check the document cde would actually use
+		file = source
+		data, err = envOnlyData()
+		if err != nil {
+			return fmt.Errorf("configuration loading failed: %w", err)
+		}
+	} else {
+		if file == "" {
+			configPath, err := getConfigPath()
+			if err != nil {
+				return fmt.Errorf("configuration loading failed: %w", err)
+			}
+			file = configPath
+		}
+		data, err = ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("configuration file read failed: %w", err)
+		}
+	}
+	violations, err := validateConfigData(data)
+	if err != nil {
+		if json.Valid(stripJSONC(data)) {
+			return err
+		}
+		return fmt.Errorf("%w%s", err, salvageHint(data))
+	}
+
+	for _, v := range violations {
+		fmt.Fprintf(os.Stdout, "%s:%d: %s: %s\n", file, v.Line, v.Path, v.Message)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("configuration validation failed: %d problem(s) in %s", len(violations), file)
+	}
+	fmt.Printf("%s is valid\n", file)
+	return nil
+}
+
+// runConfigSchema prints the JSON Schema for config.json
+func runConfigSchema() error {
+	_, err := os.Stdout.Write(configSchemaJSON)
+	return err
+}
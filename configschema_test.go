@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigData(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []configViolation
+	}{
+		{
+			name: "valid",
+			config: `{
+  "environments": [
+    {"name": "prod", "url": "https://api.openai.com/v1", "api_key": "sk-prod-1234567890", "model": "gpt-5"}
+  ],
+  "settings": {"launch_mode": "subprocess", "hooks": {"enabled": true, "timeout_seconds": 10}}
+}`,
+		},
+		{name: "empty file", config: "  \n"},
+		{
+			name: "every problem reported",
+			config: `{
+  "environments": [
+    {"name": "prod", "url": "https://api.openai.com/v1", "api_key": "sk-prod-1234567890"},
+    {
+      "name": "bad name!",
+      "url": "not-a-url",
+      "api_key": "sk-staging-1234567890",
+      "modle": "gpt-5"
+    }
+  ],
+  "settings": {
+    "launch_mode": "fork",
+    "hooks": {"timeout_seconds": "30"}
+  }
+}`,
+			want: []configViolation{
+				{Path: "environments[1].name", Line: 5},
+				{Path: "environments[1].url", Line: 6},
+				{Path: "environments[1].modle", Line: 8},
+				{Path: "settings.launch_mode", Line: 12},
+				{Path: "settings.hooks.timeout_seconds", Line: 13},
+			},
+		},
+		{
+			name:   "missing required fields",
+			config: `{"environments": [{"name": "prod"}], "settings": {"terminal": {"theme": "dark"}}}`,
+			want: []configViolation{
+				{Path: "environments[0].url", Line: 1},
+				{Path: "settings.terminal.theme", Line: 1},
+			},
+		},
+		{name: "missing environments", config: `{"settings": {}}`, want: []configViolation{{Path: "environments", Line: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateConfigData([]byte(tt.config))
+			if err != nil {
+				t.Fatalf("validateConfigData() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d violations %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, v := range got {
+				if v.Path != tt.want[i].Path || v.Line != tt.want[i].Line || v.Message == "" {
+					t.Errorf("violation %d = %+v, want path %q on line %d", i, v, tt.want[i].Path, tt.want[i].Line)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigDataSyntaxError(t *testing.T) {
+	if _, err := validateConfigData([]byte(`{"environments": [}`)); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("error = %v, want an invalid JSON error", err)
+	}
+}
+
+// TestConfigSchemaCoversStructs keeps config.schema.json in step with the Go types
+func TestConfigSchemaCoversStructs(t *testing.T) {
+	schema, err := loadConfigSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var walk func(s *jsonSchema, typ reflect.Type, path string)
+	walk = func(s *jsonSchema, typ reflect.Type, path string) {
+		s = s.resolve(schema)
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+			if s.Items != nil {
+				s = s.Items.resolve(schema)
+			}
+		}
+		if typ.Kind() != reflect.Struct || typ.String() == "time.Time" {
+			return
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			property, ok := s.Properties[name]
+			if !ok {
+				t.Errorf("schema is missing %s.%s", path, name)
+				continue
+			}
+			walk(property, field.Type, path+"."+name)
+		}
+	}
+	walk(schema, reflect.TypeOf(Config{}), "config")
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(valid, []byte(`{"environments": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte(`{"environments": [], "extra": true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runConfigValidate(valid); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	if err := runConfigValidate(invalid); err == nil || !strings.Contains(err.Error(), "1 problem(s)") {
+		t.Errorf("invalid config error = %v", err)
+	}
+}
+
+func TestParseConfigCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		file    string
+		wantErr bool
+	}{
+		{args: []string{"config", "validate"}, want: "config-validate"},
+		{args: []string{"config", "validate", "other.json"}, want: "config-validate", file: "other.json"},
+		{args: []string{"config", "schema"}, want: "config-schema"},
+		{args: []string{"config", "schema", "extra"}, wantErr: true},
+		{args: []string{"config"}, wantErr: true},
+		{args: []string{"config", "edit"}, want: "config-edit"},
+		{args: []string{"config", "edit", "other.json"}, wantErr: true},
+		{args: []string{"config", "show"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		result := parseArguments(tt.args)
+		if (result.Error != nil) != tt.wantErr {
+			t.Errorf("parseArguments(%q) error = %v, wantErr %v", tt.args, result.Error, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (result.Subcommand != tt.want || result.CCEFlags["config_file"] != tt.file) {
+			t.Errorf("parseArguments(%q) = %s %q", tt.args, result.Subcommand, result.CCEFlags["config_file"])
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// confirmLaunch asks before launching an environment marked confirm_before_use. The user types
+// the environment name or y; --yes skips the question, and without a terminal the launch is
+// refused rather than assumed.
+func confirmLaunch(env Environment, assumeYes bool) error {
+	if !env.ConfirmBeforeUse || assumeYes {
+		return nil
+	}
+	if !confirmationPromptsAllowed() {
+		return fmt.Errorf("environment '%s' requires confirmation before use; rerun with --yes to launch without a terminal", env.Name)
+	}
+
+	answer, err := regularInput(trf("Environment '%s' (%s) requires confirmation. Type its name or y to launch: ", env.Name, env.URL))
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	if answer == env.Name || strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes") {
+		return nil
+	}
+	return fmt.Errorf("launch of '%s' cancelled", env.Name)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestConfirmLaunch(t *testing.T) {
+	original := confirmationPromptsAllowed
+	defer func() { confirmationPromptsAllowed = original }()
+
+	prod := Environment{Name: "prod", URL: "https://api.openai.com/v1", ConfirmBeforeUse: true}
+	tests := []struct {
+		name        string
+		env         Environment
+		assumeYes   bool
+		interactive bool
+		input       string
+		wantErr     bool
+	}{
+		{name: "not marked", env: Environment{Name: "dev"}},
+		{name: "--yes", env: prod, assumeYes: true},
+		{name: "typed name", env: prod, interactive: true, input: "prod\n"},
+		{name: "typed y", env: prod, interactive: true, input: "Y\n"},
+		{name: "declined", env: prod, interactive: true, input: "n\n", wantErr: true},
+		{name: "wrong name", env: prod, interactive: true, input: "dev\n", wantErr: true},
+		{name: "no terminal", env: prod, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interactive := tt.interactive
+			confirmationPromptsAllowed = func() bool { return interactive }
+			withStdin(t, tt.input)
+			if err := confirmLaunch(tt.env, tt.assumeYes); (err != nil) != tt.wantErr {
+				t.Errorf("confirmLaunch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// crashExitCode is the exit status after a panic (EX_SOFTWARE)
+const crashExitCode = 70
+
+// startupTerminalState is the terminal mode when cde started, restored if cde panics
+var startupTerminalState *term.State
+
+// snapshotTerminal records the terminal mode so a panic in raw mode can undo it
+func snapshotTerminal() {
+	fd := stdinFd()
+	if term.IsTerminal(fd) {
+		startupTerminalState, _ = term.GetState(fd)
+	}
+}
+
+// restoreTerminalAfterPanic puts the terminal back into its startup mode and resets colors
+func restoreTerminalAfterPanic() {
+	if startupTerminalState != nil {
+		term.Restore(stdinFd(), startupTerminalState)
+	}
+	disableMouse()
+	leaveAltScreen()
+	if term.IsTerminal(int(os.Stdout.Fd())) && platformSupportsANSI() {
+		fmt.Fprint(os.Stdout, "\033[0m\n")
+	}
+}
+
+// getCrashDir returns the crash report directory next to config.json
+func getCrashDir() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "crash"), nil
+}
+
+// crashConfigSummary describes the configuration without URLs, keys or env var values
+func crashConfigSummary() (summary string) {
+	defer func() {
+		if value := recover(); value != nil {
+			summary = fmt.Sprintf("  unavailable (loading panicked: %v)\n", value)
+		}
+	}()
+
+	var builder strings.Builder
+	if path, err := getConfigPath(); err == nil {
+		builder.WriteString(fmt.Sprintf("  path: %s\n", path))
+	}
+	config, err := loadConfig()
+	if err != nil {
+		builder.WriteString(fmt.Sprintf("  load error: %v\n", err))
+		return builder.String()
+	}
+	builder.WriteString(fmt.Sprintf("  environments: %d\n", len(config.Environments)))
+	for _, env := range config.Environments {
+		provider := env.Provider
+		if provider == "" {
+			provider = "openai"
+		}
+		builder.WriteString(fmt.Sprintf("    - %s (provider %s, model set: %t, env vars: %d)\n", env.Name, provider, env.Model != "", len(env.EnvVars)))
+	}
+	builder.WriteString(fmt.Sprintf("  launch_mode: %s\n", launchModeFor(config)))
+	return builder.String()
+}
+
+// formatCrashReport renders a panic report; secrets in arguments are masked
+func formatCrashReport(value interface{}, stack []byte, now time.Time, args []string, configSummary string) string {
+	var builder strings.Builder
+	builder.WriteString("cde crash report\n")
+	builder.WriteString(fmt.Sprintf("Time: %s\n", now.UTC().Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("Version: %s (commit: %s, built: %s)\n", version, commit, date))
+	builder.WriteString(fmt.Sprintf("Go: %s\n", runtime.Version()))
+	builder.WriteString(fmt.Sprintf("Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH))
+	builder.WriteString(fmt.Sprintf("Args: %s\n", formatCommandLine(sanitizeArgs(args, ""))))
+	builder.WriteString(fmt.Sprintf("Panic: %v\n", value))
+	builder.WriteString("\nConfiguration:\n")
+	builder.WriteString(configSummary)
+	builder.WriteString("\nStack:\n")
+	builder.Write(stack)
+	return builder.String()
+}
+
+// writeCrashReport saves a panic report to the crash directory and returns its path
+func writeCrashReport(report string, now time.Time) (string, error) {
+	dir, err := getCrashDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("crash directory creation failed: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.txt", now.Format("20060102-150405"), os.Getpid()))
+	if err := ioutil.WriteFile(path, []byte(report), 0600); err != nil {
+		return "", fmt.Errorf("crash report write failed: %w", err)
+	}
+	return path, nil
+}
+
+// recoverFromPanic is deferred by main: it restores the terminal, writes a crash report
+// and exits instead of leaving a raw-mode terminal and a bare stack trace behind
+func recoverFromPanic() {
+	value := recover()
+	if value == nil {
+		return
+	}
+	stack := debug.Stack()
+	restoreTerminalAfterPanic()
+
+	now := time.Now()
+	report := formatCrashReport(value, stack, now, os.Args[1:], crashConfigSummary())
+	fmt.Fprintf(os.Stderr, "cde crashed unexpectedly: %v\n", value)
+	if path, err := writeCrashReport(report, now); err != nil {
+		fmt.Fprintf(os.Stderr, "Crash report could not be saved (%v); details follow:\n\n%s", err, report)
+	} else {
+		fmt.Fprintf(os.Stderr, "A crash report was saved to %s\nPlease attach it when filing an issue.\n", path)
+	}
+	os.Exit(crashExitCode)
+}
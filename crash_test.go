@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatCrashReport(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	config := Config{Environments: []Environment{
+		{Name: "prod", URL: "https://gateway.internal.example.com", APIKey: "sk-crash-secret-123456", Model: "gpt-5", EnvVars: map[string]string{"ORG": "acme-private"}},
+	}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig() error = %v", err)
+	}
+
+	report := formatCrashReport("index out of range", []byte("goroutine 1 [running]:\nmain.main()\n"),
+		time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), []string{"-e", "prod", "--api-key", "sk-arg-secret"}, crashConfigSummary())
+
+	for _, want := range []string{
+		"Time: 2026-10-16T12:00:00Z",
+		"Version: " + version,
+		"Platform: " + runtime.GOOS + "/" + runtime.GOARCH,
+		"Panic: index out of range",
+		"environments: 1",
+		"- prod (provider openai, model set: true, env vars: 1)",
+		"goroutine 1 [running]",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	for _, secret := range []string{"sk-crash-secret-123456", "sk-arg-secret", "gateway.internal", "acme-private"} {
+		if strings.Contains(report, secret) {
+			t.Errorf("report leaks %q:\n%s", secret, report)
+		}
+	}
+}
+
+func TestWriteCrashReport(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	path, err := writeCrashReport("cde crash report\n", time.Now())
+	if err != nil {
+		t.Fatalf("writeCrashReport() error = %v", err)
+	}
+	if filepath.Base(filepath.Dir(path)) != "crash" {
+		t.Errorf("report written to %s, want the crash directory", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || string(data) != "cde crash report\n" {
+		t.Fatalf("report content = %q, err %v", data, err)
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+			t.Errorf("report permissions = %v, want 0600", info.Mode().Perm())
+		}
+	}
+}
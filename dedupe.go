@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// duplicateKey identifies the endpoint and key an environment uses; environments whose keys
+// come from auth have none and are never duplicates
+func duplicateKey(env Environment) string {
+	if env.APIKey == "" {
+		return ""
+	}
+	return normalizeImportURL(env.URL) + " " + keyFingerprint(env.APIKey)
+}
+
+// findDuplicatesOf returns the names of configured environments with env's URL and API key
+func findDuplicatesOf(config Config, env Environment) []string {
+	key := duplicateKey(env)
+	if key == "" {
+		return nil
+	}
+	var names []string
+	for _, existing := range config.Environments {
+		if existing.Name != env.Name && duplicateKey(existing) == key {
+			names = append(names, existing.Name)
+		}
+	}
+	return names
+}
+
+// duplicateGroups returns the sets of environments sharing a URL and API key, in config order
+func duplicateGroups(config Config) [][]Environment {
+	byKey := make(map[string][]Environment)
+	var order []string
+	for _, env := range config.Environments {
+		key := duplicateKey(env)
+		if key == "" {
+			continue
+		}
+		if _, seen := byKey[key]; !seen {
+			order = append(order, key)
+		}
+		byKey[key] = append(byKey[key], env)
+	}
+	groups := [][]Environment{}
+	for _, key := range order {
+		if len(byKey[key]) > 1 {
+			groups = append(groups, byKey[key])
+		}
+	}
+	return groups
+}
+
+// mergeEnvironments copies the model and map entries (env vars, named keys, aliases, params,
+// headers) that kept lacks from the duplicates; kept's own values always win
+func mergeEnvironments(kept Environment, duplicates []Environment) Environment {
+	merged := cloneEnvironment(kept, kept.Name)
+	fill := func(target *map[string]string, source map[string]string) {
+		for key, value := range source {
+			if *target == nil {
+				*target = make(map[string]string)
+			}
+			if _, exists := (*target)[key]; !exists {
+				(*target)[key] = value
+			}
+		}
+	}
+	for _, env := range duplicates {
+		fill(&merged.EnvVars, env.EnvVars)
+		fill(&merged.Keys, env.Keys)
+		fill(&merged.ModelAliases, env.ModelAliases)
+		fill(&merged.ModelParams, env.ModelParams)
+		fill(&merged.Headers, env.Headers)
+		if merged.Model == "" {
+			merged.Model = env.Model
+		}
+	}
+	return merged
+}
+
+// dedupeMerge is one group the user chose to collapse into a single environment
+type dedupeMerge struct {
+	Keep   string
+	Remove []string
+}
+
+// runDedupe lists environments that share a URL and API key and, on a terminal, merges each
+// group into the environment the user keeps. The others go to the trash.
+func runDedupe(dryRun bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	groups := duplicateGroups(config)
+	if len(groups) == 0 {
+		fmt.Println("No duplicate environments found.")
+		return nil
+	}
+
+	for i, group := range groups {
+		fmt.Printf("\nGroup %d: %s (key %s)\n", i+1, group[0].URL, maskAPIKey(group[0].APIKey))
+		for j, env := range group {
+			model := env.Model
+			if model == "" {
+				model = "(default)"
+			}
+			fmt.Printf("  [%d] %s  model %s, %d env var(s)\n", j+1, env.Name, model, len(env.EnvVars))
+		}
+	}
+	if dryRun {
+		return nil
+	}
+	if !confirmationPromptsAllowed() {
+		fmt.Println("\nRun 'cde dedupe' on a terminal to merge them.")
+		return nil
+	}
+
+	var merges []dedupeMerge
+	for i, group := range groups {
+		answer, err := regularInput(trf("\nGroup %d: keep which environment? [1-%d, Enter to skip]: ", i+1, len(group)))
+		if err != nil {
+			return fmt.Errorf("failed to read choice: %w", err)
+		}
+		if answer == "" {
+			continue
+		}
+		choice, err := strconv.Atoi(answer)
+		if err != nil || choice < 1 || choice > len(group) {
+			fmt.Printf("Invalid choice '%s'; skipping group %d.\n", answer, i+1)
+			continue
+		}
+		merge := dedupeMerge{Keep: group[choice-1].Name}
+		for _, env := range group {
+			if env.Name != merge.Keep {
+				merge.Remove = append(merge.Remove, env.Name)
+			}
+		}
+		merges = append(merges, merge)
+	}
+	if len(merges) == 0 {
+		fmt.Println("Nothing merged.")
+		return nil
+	}
+
+	if err := updateConfigWithOptions(saveOptions{BackupLabel: "dedupe"}, func(config *Config) error {
+		return applyDedupeMerges(config, merges)
+	}); err != nil {
+		return err
+	}
+	for _, merge := range merges {
+		for _, name := range merge.Remove {
+			fmt.Printf("Merged '%s' into '%s' (undo with 'cde restore %s').\n", name, merge.Keep, name)
+		}
+	}
+	return nil
+}
+
+// applyDedupeMerges merges each group into its kept environment and trashes the rest. Groups
+// that no longer share a URL and key (changed by another process) are an error.
+func applyDedupeMerges(config *Config, merges []dedupeMerge) error {
+	now := time.Now()
+	for _, merge := range merges {
+		keepIndex, exists := findEnvironmentByName(*config, merge.Keep)
+		if !exists {
+			return fmt.Errorf("environment '%s' not found", merge.Keep)
+		}
+		kept := config.Environments[keepIndex]
+
+		var duplicates []Environment
+		for _, name := range merge.Remove {
+			index, exists := findEnvironmentByName(*config, name)
+			if !exists || duplicateKey(config.Environments[index]) != duplicateKey(kept) {
+				return fmt.Errorf("environment '%s' changed since it was listed; run 'cde dedupe' again", name)
+			}
+			duplicates = append(duplicates, config.Environments[index])
+		}
+
+		config.Environments[keepIndex] = mergeEnvironments(kept, duplicates)
+		for _, name := range merge.Remove {
+			if err := moveToTrash(config, name, now); err != nil {
+				return fmt.Errorf("failed to remove environment: %w", err)
+			}
+		}
+	}
+	purgeExpiredTrash(config, now)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateGroups(t *testing.T) {
+	config := Config{Environments: []Environment{
+		{Name: "a", URL: "https://api.example.com/v1", APIKey: "sk-same-1234567890"},
+		{Name: "b", URL: "https://API.example.com/v1/", APIKey: "sk-same-1234567890"},
+		{Name: "c", URL: "https://api.example.com/v1", APIKey: "sk-other-1234567890"},
+		{Name: "d", URL: "https://other.example.com", APIKey: "sk-same-1234567890"},
+		{Name: "e", URL: "https://idp.example.com"},
+		{Name: "f", URL: "https://idp.example.com"},
+	}}
+
+	groups := duplicateGroups(config)
+	if len(groups) != 1 || len(groups[0]) != 2 || groups[0][0].Name != "a" || groups[0][1].Name != "b" {
+		t.Errorf("duplicateGroups() = %+v", groups)
+	}
+	if got := findDuplicatesOf(config, Environment{Name: "new", URL: "https://api.example.com/v1", APIKey: "sk-same-1234567890"}); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("findDuplicatesOf() = %v", got)
+	}
+	if got := findDuplicatesOf(config, Environment{Name: "new", URL: "https://idp.example.com"}); got != nil {
+		t.Errorf("environments without keys are never duplicates, got %v", got)
+	}
+}
+
+func TestRunDedupe(t *testing.T) {
+	originalPath := configPathOverride
+	originalPrompts := confirmationPromptsAllowed
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() {
+		configPathOverride = originalPath
+		confirmationPromptsAllowed = originalPrompts
+	}()
+
+	if err := saveConfig(Config{Environments: []Environment{
+		{Name: "prod", URL: "https://api.example.com/v1", APIKey: "sk-same-1234567890", EnvVars: map[string]string{"REGION": "us"}},
+		{Name: "dev", URL: "http://localhost:8080", APIKey: "sk-dev-1234567890"},
+		{Name: "prod-copy", URL: "https://api.example.com/v1", APIKey: "sk-same-1234567890", Model: "gpt-5",
+			EnvVars: map[string]string{"REGION": "eu", "TIER": "batch"}, Keys: map[string]string{"backup": "sk-backup-1234567890"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	confirmationPromptsAllowed = func() bool { return false }
+	if err := runDedupe(false); err != nil {
+		t.Fatalf("runDedupe() without a terminal error = %v", err)
+	}
+	if config, _ := loadConfig(); len(config.Environments) != 3 {
+		t.Fatal("runDedupe() changed the config without a terminal")
+	}
+
+	confirmationPromptsAllowed = func() bool { return true }
+	withStdin(t, "1\n")
+	if err := runDedupe(false); err != nil {
+		t.Fatalf("runDedupe() error = %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Environments) != 2 || len(config.Trash) != 1 || config.Trash[0].Environment.Name != "prod-copy" {
+		t.Fatalf("after dedupe: environments %+v, trash %+v", config.Environments, config.Trash)
+	}
+	prod := config.Environments[0]
+	want := map[string]string{"REGION": "us", "TIER": "batch"}
+	if !reflect.DeepEqual(prod.EnvVars, want) || prod.Keys["backup"] != "sk-backup-1234567890" || prod.Model != "gpt-5" {
+		t.Errorf("merged environment = %+v", prod)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -50,3 +51,122 @@ func TestRenderFullContentClearing(t *testing.T) {
 	// Clean up
 	state.ClearDisplay()
 }
+
+// TestSelectionChangeRedrawsOnlyChangedRows verifies that moving the selection updates two rows in place
+func TestSelectionChangeRedrawsOnlyChangedRows(t *testing.T) {
+	state := initializeDisplayState()
+	state.terminalWidth = 20
+	renderer := newLineRenderer(state, true)
+
+	state.UpdateContent([]string{"Header", "► env1", "  env2", "  env3"}, 0)
+	if !state.contentChanged {
+		t.Fatal("first render should be a full render")
+	}
+
+	state.UpdateContent([]string{"Header", "  env1", "► env2", "  env3"}, 1)
+	if state.contentChanged || !state.selectionChanged {
+		t.Fatalf("contentChanged=%t selectionChanged=%t, want a row update", state.contentChanged, state.selectionChanged)
+	}
+	if len(state.changedLines) != 2 || state.changedLines[0] != 1 || state.changedLines[1] != 2 {
+		t.Errorf("changedLines = %v, want [1 2]", state.changedLines)
+	}
+
+	pad := func(s string) string { return "\r" + s + strings.Repeat(" ", 20-visibleLen(s)) + "\r" }
+	if got, want := renderer.lineUpdate(1, "  env1"), "\033[2A"+pad("  env1")+"\033[2B"; got != want {
+		t.Errorf("lineUpdate(1) = %q, want %q", got, want)
+	}
+	if got, want := renderer.lineUpdate(3, "  env3"), pad("  env3"); got != want {
+		t.Errorf("lineUpdate(last) = %q, want %q", got, want)
+	}
+
+	// A different row count falls back to a full render
+	state.UpdateContent([]string{"Header", "► env1"}, 0)
+	if !state.contentChanged {
+		t.Error("changing the number of rows should trigger a full render")
+	}
+}
+
+// countingWriter records each write so tests can check frames are flushed in one call
+type countingWriter struct {
+	writes []string
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.writes = append(cw.writes, string(p))
+	return len(p), nil
+}
+
+// TestMenuFramesFlushOnce verifies that each render reaches the terminal as a single write
+func TestMenuFramesFlushOnce(t *testing.T) {
+	out := &countingWriter{}
+	oldOutput := menuOutput
+	menuOutput = out
+	defer func() { menuOutput = oldOutput }()
+
+	state := initializeDisplayState()
+	renderer := newLineRenderer(state, true)
+	environments := []Environment{
+		{Name: "alpha", URL: "https://alpha.example.com"},
+		{Name: "beta", URL: "https://beta.example.com"},
+		{Name: "gamma", URL: "https://gamma.example.com"},
+	}
+
+	renderer.RenderMenu(environments, 0, "Header")
+	if len(out.writes) != 1 {
+		t.Fatalf("full render made %d writes, want 1", len(out.writes))
+	}
+	if !strings.Contains(out.writes[0], "alpha") || !strings.Contains(out.writes[0], "gamma") {
+		t.Errorf("full render missing rows: %q", out.writes[0])
+	}
+
+	renderer.RenderMenu(environments, 1, "Header")
+	if len(out.writes) != 2 {
+		t.Fatalf("selection change made %d writes, want 1", len(out.writes)-1)
+	}
+	update := out.writes[1]
+	if !strings.Contains(update, "alpha") || !strings.Contains(update, "beta") || strings.Contains(update, "gamma") {
+		t.Errorf("selection change should redraw only alpha and beta: %q", update)
+	}
+}
+
+func TestAltScreenEnabled(t *testing.T) {
+	off := false
+	on := true
+	tests := []struct {
+		name     string
+		terminal *TerminalSettings
+		want     bool
+	}{
+		{name: "default", want: true},
+		{name: "explicitly on", terminal: &TerminalSettings{AltScreen: &on}, want: true},
+		{name: "disabled", terminal: &TerminalSettings{AltScreen: &off}},
+		{name: "ansi disabled", terminal: &TerminalSettings{DisableANSI: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Settings: &ConfigSettings{Terminal: tt.terminal}}
+			if got := altScreenEnabled(config); got != tt.want {
+				t.Errorf("altScreenEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeaveAltScreenOnlyWhenEntered(t *testing.T) {
+	out := &countingWriter{}
+	oldOutput := menuOutput
+	menuOutput = out
+	defer func() { menuOutput = oldOutput }()
+
+	leaveAltScreen()
+	if len(out.writes) != 0 {
+		t.Errorf("leaving without entering wrote %q", out.writes)
+	}
+	enterAltScreen()
+	leaveAltScreen()
+	leaveAltScreen()
+	if len(out.writes) != 2 || out.writes[0] != altScreenEnter || out.writes[1] != altScreenLeave {
+		t.Errorf("writes = %q, want enter then a single leave", out.writes)
+	}
+}
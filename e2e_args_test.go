@@ -23,7 +23,10 @@ func TestRunAutoArgsAssembly(t *testing.T) {
 		t.Fatalf("expected model injection prefix %v, got %v", wantPrefix, injected)
 	}
 
-	auto := applyAutoFlags(injected)
+	auto, err := mergeAutoFlags(defaultAutoFlags, injected)
+	if err != nil {
+		t.Fatalf("mergeAutoFlags() failed: %v", err)
+	}
 	wantAutoPrefix := []string{"-a", "never", "--sandbox", "workspace-write"}
 	if len(auto) < 6 || !reflect.DeepEqual(auto[:4], wantAutoPrefix) {
 		t.Fatalf("expected auto flags prefix %v, got %v", wantAutoPrefix, auto)
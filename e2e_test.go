@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// e2eMainEnv makes the test binary run cde's main instead of the tests, so the self-test
+// harness can start it as cde
+const e2eMainEnv = "CDE_E2E_MAIN"
+
+func TestMain(m *testing.M) {
+	if testBinaryIsFakeCodex() {
+		os.Exit(runFakeCodex())
+	}
+	if os.Getenv(e2eMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testBinaryIsFakeCodex reports whether the harness started this test binary as codex
+func testBinaryIsFakeCodex() bool {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return name == defaultTargetCommand && os.Getenv(fakeCodexReportEnv) != ""
+}
+
+// runFakeCodex behaves like fakeCodexScript: it answers --version like codex, otherwise writes
+// its report and returns the requested exit status
+func runFakeCodex() int {
+	args := os.Args[1:]
+	if len(args) == 1 && args[0] == "--version" {
+		fmt.Printf("codex-cli %s\n", fakeCodexVersion)
+		return 0
+	}
+
+	var report strings.Builder
+	for _, arg := range args {
+		report.WriteString("arg=" + arg + "\n")
+	}
+	for _, pair := range os.Environ() {
+		report.WriteString("env=" + pair + "\n")
+	}
+	if err := ioutil.WriteFile(os.Getenv(fakeCodexReportEnv), []byte(report.String()), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "fake codex: failed to write report: %v\n", err)
+		return 1
+	}
+	code, _ := strconv.Atoi(os.Getenv(fakeCodexExitEnv))
+	return code
+}
+
+// installTestBinaryAsCodex links the test binary into bin as codex
+func installTestBinaryAsCodex(bin string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fake := filepath.Join(bin, defaultTargetCommand)
+	if runtime.GOOS == "windows" {
+		fake += ".exe"
+	}
+	if err := os.Symlink(exe, fake); err != nil {
+		// Symlinks need extra privileges on Windows; a copy works everywhere
+		data, err := ioutil.ReadFile(exe)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(fake, data, 0755)
+	}
+	return nil
+}
+
+// newE2EHarness runs this test binary as cde, and as the fake codex on its PATH
+func newE2EHarness(t *testing.T) *selfTestHarness {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("cannot locate test binary: %v", err)
+	}
+	h, err := newSelfTestHarness(exe, t.TempDir(), installTestBinaryAsCodex, e2eMainEnv+"=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestEndToEndLaunch(t *testing.T) {
+	h := newE2EHarness(t)
+	for _, tc := range selfTestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			report, status, output, err := h.run(tc.Exit, tc.Args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report == nil {
+				t.Fatalf("codex was not launched (exit %d):\n%s", status, output)
+			}
+			if err := tc.Check(report, status); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestEndToEndScriptFakeCodex runs the cases against the script 'cde doctor --selftest' uses
+func TestEndToEndScriptFakeCodex(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the batch fake codex is not exercised by the tests")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("cannot locate test binary: %v", err)
+	}
+	h, err := newSelfTestHarness(exe, t.TempDir(), installFakeCodexScript, e2eMainEnv+"=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if !runSelfTestCases(h, &out) {
+		t.Errorf("self-test against the script fake failed:\n%s", out.String())
+	}
+}
+
+func TestParseFakeCodexReport(t *testing.T) {
+	report := parseFakeCodexReport([]byte("arg=-m\r\narg=gpt 5\nenv=A=1=2\nenv=MULTI=first\nsecond line\narg=\n"))
+	if got := strings.Join(report.Args, "|"); got != "-m|gpt 5|" {
+		t.Errorf("args = %q", got)
+	}
+	if report.Env["A"] != "1=2" || report.Env["MULTI"] != "first" || len(report.Env) != 2 {
+		t.Errorf("env = %v", report.Env)
+	}
+}
+
+func TestEndToEndUserModelWins(t *testing.T) {
+	h := newE2EHarness(t)
+	report, _, output, err := h.run(0, "-e", "selftest", "--", "-m", "gpt-mine")
+	if err != nil || report == nil {
+		t.Fatalf("launch failed (%v):\n%s", err, output)
+	}
+	if got := strings.Join(report.Args, " "); got != "-m gpt-mine" {
+		t.Errorf("codex args = %q, want the user's model only", got)
+	}
+}
+
+func TestEndToEndUnknownEnvironment(t *testing.T) {
+	h := newE2EHarness(t)
+	report, status, output, err := h.run(0, "-e", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report != nil || status == 0 {
+		t.Errorf("expected cde to fail without launching codex, got exit %d:\n%s", status, output)
+	}
+	if !strings.Contains(output, "missing") {
+		t.Errorf("error should name the environment:\n%s", output)
+	}
+}
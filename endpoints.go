@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/url"
+	"os"
+)
+
+// URL rotation strategies for environments with several endpoints
+const (
+	urlStrategyRoundRobin = "round-robin" // Default: the next endpoint on every launch
+	urlStrategyRandom     = "random"
+	urlStrategySticky     = "sticky" // Keep the last endpoint while it passes a health check
+)
+
+// endpointState remembers rotation progress per environment between launches
+type endpointState struct {
+	Next   int    `json:"next,omitempty"`   // Round-robin position
+	Sticky string `json:"sticky,omitempty"` // Endpoint kept by the sticky strategy
+}
+
+// environmentEndpoints returns url followed by the distinct entries of urls
+func environmentEndpoints(env Environment) []string {
+	endpoints := []string{env.URL}
+	seen := map[string]bool{env.URL: true}
+	for _, u := range env.URLs {
+		if !seen[u] {
+			seen[u] = true
+			endpoints = append(endpoints, u)
+		}
+	}
+	return endpoints
+}
+
+// expandEndpoints returns one copy of each environment per endpoint, so probes rank every URL
+func expandEndpoints(envs []Environment) []Environment {
+	expanded := make([]Environment, 0, len(envs))
+	for _, env := range envs {
+		for _, endpoint := range environmentEndpoints(env) {
+			variant := env
+			variant.URL = endpoint
+			expanded = append(expanded, variant)
+		}
+	}
+	return expanded
+}
+
+// endpointLabel names an environment in probe output, adding the host when it has several URLs
+func endpointLabel(env Environment) string {
+	if len(env.URLs) == 0 {
+		return env.Name
+	}
+	if parsed, err := url.Parse(env.URL); err == nil && parsed.Host != "" {
+		return env.Name + "@" + parsed.Host
+	}
+	return env.Name + "@" + env.URL
+}
+
+// validateURLStrategy checks url_strategy and the extra endpoints
+func validateURLStrategy(env Environment) error {
+	switch env.URLStrategy {
+	case "", urlStrategyRoundRobin, urlStrategyRandom, urlStrategySticky:
+	default:
+		return fmt.Errorf("unknown url_strategy '%s' (use round-robin, random or sticky)", env.URLStrategy)
+	}
+	for i, u := range env.URLs {
+		if err := validateTemplateURL(u); err != nil {
+			return fmt.Errorf("invalid urls[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// getEndpointStatePath returns the active profile's rotation state file
+func getEndpointStatePath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "endpoints.json"), nil
+}
+
+// loadEndpointState reads endpoints.json; a missing or unreadable file means no history
+func loadEndpointState() map[string]endpointState {
+	state := map[string]endpointState{}
+	path, err := getEndpointStatePath()
+	if err != nil {
+		return state
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveEndpointState writes endpoints.json atomically
+func saveEndpointState(state map[string]endpointState) error {
+	path, err := getEndpointStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("endpoint state serialization failed: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("endpoint state write failed: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("endpoint state write failed: %w", err)
+	}
+	return nil
+}
+
+// probeEndpointHealthy is the health check used by the sticky strategy; tests replace it
+var probeEndpointHealthy = func(env Environment) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultProbeTimeout)
+	defer cancel()
+	return probeEndpoint(ctx, httpClientFor(env, 0), env).healthy()
+}
+
+// pickEndpoint chooses the URL for this launch according to env.URLStrategy. With commit
+// unset (which, --dry-run) the choice is reported but the rotation does not advance.
+func pickEndpoint(env Environment, commit bool) (string, error) {
+	endpoints := environmentEndpoints(env)
+	if len(endpoints) == 1 {
+		return env.URL, nil
+	}
+	if env.URLStrategy == urlStrategyRandom {
+		return endpoints[rand.Intn(len(endpoints))], nil
+	}
+
+	// Concurrent launches may race on the state file; the worst case is a repeated endpoint
+	all := loadEndpointState()
+	state := all[env.Name]
+	chosen := ""
+	switch env.URLStrategy {
+	case urlStrategySticky:
+		start := 0
+		for i, endpoint := range endpoints {
+			if endpoint == state.Sticky {
+				start = i
+			}
+		}
+		for i := range endpoints {
+			candidate := env
+			candidate.URL = endpoints[(start+i)%len(endpoints)]
+			if probeEndpointHealthy(candidate) {
+				chosen = candidate.URL
+				break
+			}
+			fmt.Printf("Endpoint %s of '%s' is unhealthy, trying the next one\n", candidate.URL, env.Name)
+		}
+		if chosen == "" {
+			return "", fmt.Errorf("no healthy endpoint among %d URLs of environment '%s'", len(endpoints), env.Name)
+		}
+		state.Sticky = chosen
+	default:
+		index := state.Next % len(endpoints)
+		if index < 0 {
+			index = 0
+		}
+		chosen = endpoints[index]
+		state.Next = (index + 1) % len(endpoints)
+	}
+
+	if commit {
+		all[env.Name] = state
+		if err := saveEndpointState(all); err != nil {
+			logDebugf("endpoint rotation state not saved: %v", err)
+		}
+	}
+	return chosen, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPickEndpointRoundRobin(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	env := Environment{Name: "gw", URL: "https://a.example.com/v1", URLs: []string{"https://b.example.com/v1", "https://a.example.com/v1", "https://c.example.com/v1"}}
+	if got := environmentEndpoints(env); len(got) != 3 {
+		t.Fatalf("environmentEndpoints() = %v, want duplicates removed", got)
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		endpoint, err := pickEndpoint(env, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, endpoint)
+	}
+	want := []string{"https://a.example.com/v1", "https://b.example.com/v1", "https://c.example.com/v1", "https://a.example.com/v1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rotation = %v, want %v", got, want)
+	}
+
+	// Without commit (which, --dry-run) the rotation does not advance
+	first, _ := pickEndpoint(env, false)
+	second, _ := pickEndpoint(env, false)
+	if first != second || first != "https://b.example.com/v1" {
+		t.Errorf("uncommitted picks = %s, %s", first, second)
+	}
+
+	single := Environment{Name: "one", URL: "https://only.example.com"}
+	if endpoint, _ := pickEndpoint(single, true); endpoint != single.URL {
+		t.Errorf("single endpoint = %s", endpoint)
+	}
+}
+
+func TestPickEndpointSticky(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	healthy := map[string]bool{"https://a.example.com": true, "https://b.example.com": true}
+	originalProbe := probeEndpointHealthy
+	probeEndpointHealthy = func(env Environment) bool { return healthy[env.URL] }
+	defer func() { probeEndpointHealthy = originalProbe }()
+
+	env := Environment{Name: "gw", URL: "https://a.example.com", URLs: []string{"https://b.example.com"}, URLStrategy: urlStrategySticky}
+	for i := 0; i < 2; i++ {
+		if endpoint, _ := pickEndpoint(env, true); endpoint != "https://a.example.com" {
+			t.Fatalf("sticky pick %d = %s, want the first endpoint", i, endpoint)
+		}
+	}
+
+	healthy["https://a.example.com"] = false
+	if endpoint, _ := pickEndpoint(env, true); endpoint != "https://b.example.com" {
+		t.Fatalf("sticky pick after failure = %s, want the next healthy endpoint", endpoint)
+	}
+	healthy["https://a.example.com"] = true
+	if endpoint, _ := pickEndpoint(env, true); endpoint != "https://b.example.com" {
+		t.Errorf("sticky pick = %s, want to stay on the working endpoint", endpoint)
+	}
+
+	healthy["https://a.example.com"], healthy["https://b.example.com"] = false, false
+	if _, err := pickEndpoint(env, true); err == nil {
+		t.Error("expected an error when no endpoint is healthy")
+	}
+}
+
+func TestSelectFastestRanksEndpoints(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	config := Config{Environments: []Environment{{Name: "gw", URL: slow.URL, URLs: []string{fast.URL}, APIKey: "k"}}}
+	env, err := selectFastestEnvironment(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.URL != fast.URL {
+		t.Errorf("selected %s, want the faster endpoint %s", env.URL, fast.URL)
+	}
+	if !strings.HasPrefix(endpointLabel(env), "gw@127.0.0.1:") {
+		t.Errorf("endpointLabel() = %s", endpointLabel(env))
+	}
+}
+
+func TestValidateURLStrategy(t *testing.T) {
+	tests := []struct {
+		env     Environment
+		wantErr bool
+	}{
+		{env: Environment{URLStrategy: "random", URLs: []string{"https://{region}.example.com"}}},
+		{env: Environment{URLStrategy: "fastest"}, wantErr: true},
+		{env: Environment{URLs: []string{"ftp://x"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateURLStrategy(tt.env); (err != nil) != tt.wantErr {
+			t.Errorf("validateURLStrategy(%+v) error = %v, wantErr %v", tt.env, err, tt.wantErr)
+		}
+	}
+}
@@ -149,6 +149,22 @@ func TestArrowKeyParsing(t *testing.T) {
 		{"regular char", []byte{'a'}, ArrowNone, 'a', false},
 		{"empty input", []byte{}, ArrowNone, 0, true},
 		{"invalid sequence", []byte{0x1b, '[', 'Z'}, ArrowNone, 0, true},
+		{"crlf enter", []byte{'\r', '\n'}, ArrowNone, '\n', false},
+		{"ss3 arrow up", []byte{0x1b, 'O', 'A'}, ArrowUp, 0, false},
+		{"conpty modified arrow down", []byte{0x1b, '[', '1', ';', '5', 'B'}, ArrowDown, 0, false},
+		{"windows scan code up", []byte{0xe0, 0x48}, ArrowUp, 0, false},
+		{"windows scan code down", []byte{0xe0, 0x50}, ArrowDown, 0, false},
+		{"windows null-prefixed left", []byte{0x00, 0x4b}, ArrowLeft, 0, false},
+		{"windows scan code right", []byte{0xe0, 0x4d}, ArrowRight, 0, false},
+		{"page up", []byte("\x1b[5~"), KeyPageUp, 0, false},
+		{"page down", []byte("\x1b[6~"), KeyPageDown, 0, false},
+		{"modified page down", []byte("\x1b[6;5~"), KeyPageDown, 0, false},
+		{"home csi", []byte("\x1b[H"), KeyHome, 0, false},
+		{"end ss3", []byte("\x1bOF"), KeyEnd, 0, false},
+		{"home vt", []byte("\x1b[1~"), KeyHome, 0, false},
+		{"end rxvt", []byte("\x1b[8~"), KeyEnd, 0, false},
+		{"windows scan code page down", []byte{0xe0, 0x51}, KeyPageDown, 0, false},
+		{"unknown tilde sequence", []byte("\x1b[3~"), ArrowNone, 0, true},
 	}
 
 	for _, tc := range testCases {
@@ -177,6 +193,46 @@ func TestArrowKeyParsing(t *testing.T) {
 	}
 }
 
+// TestNavigateSelection tests menu navigation keys
+func TestNavigateSelection(t *testing.T) {
+	testCases := []struct {
+		name    string
+		index   int
+		arrow   ArrowKey
+		char    rune
+		want    int
+		handled bool
+	}{
+		{"up wraps", 0, ArrowUp, 0, 9, true},
+		{"down", 3, ArrowDown, 0, 4, true},
+		{"k up", 3, ArrowNone, 'k', 2, true},
+		{"j wraps", 9, ArrowNone, 'j', 0, true},
+		{"g first", 5, ArrowNone, 'g', 0, true},
+		{"G last", 5, ArrowNone, 'G', 9, true},
+		{"home", 5, KeyHome, 0, 0, true},
+		{"end", 5, KeyEnd, 0, 9, true},
+		{"page down", 2, KeyPageDown, 0, 6, true},
+		{"page down clamps", 8, KeyPageDown, 0, 9, true},
+		{"page up clamps", 2, KeyPageUp, 0, 0, true},
+		{"digit selects", 0, ArrowNone, '3', 2, true},
+		{"zero ignored", 4, ArrowNone, '0', 4, false},
+		{"enter not navigation", 4, ArrowNone, '\n', 4, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, handled := navigateSelection(tc.index, 10, 4, tc.arrow, tc.char)
+			if got != tc.want || handled != tc.handled {
+				t.Errorf("navigateSelection() = (%d, %v), want (%d, %v)", got, handled, tc.want, tc.handled)
+			}
+		})
+	}
+
+	if _, handled := navigateSelection(0, 3, 4, ArrowNone, '5'); handled {
+		t.Error("digit beyond environment count should be ignored")
+	}
+}
+
 // TestHeadlessDetection tests headless mode detection
 func TestHeadlessDetection(t *testing.T) {
 	t.Run("CI environment detection", func(t *testing.T) {
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envOnlyEnvironmentName names the environment built from OPENAI_* variables
+const envOnlyEnvironmentName = "openai-env"
+
+// configWriteSubcommands change config.json or its backups and are refused in env-only mode
+// before any prompt is shown
+var configWriteSubcommands = map[string]bool{
+	"add":            true,
+	"remove":         true,
+	"restore":        true,
+	"backup-restore": true,
+	"backup-prune":   true,
+	"sync-pull":      true,
+	"clone":          true,
+	"dedupe":         true,
+	"rotate-key":     true,
+	"import-from":    true,
+	"config-edit":    true,
+	"config-repair":  true,
+}
+
+// envOnlySource reports where env-only mode takes its environments from, or "" when
+// config.json is used. CDE_ENVIRONMENTS_JSON always enables it; the OPENAI_* variables
+// only do with CDE_ENV_ONLY=1, since many shells export OPENAI_API_KEY for other tools.
+func envOnlySource() string {
+	if strings.TrimSpace(os.Getenv("CDE_ENVIRONMENTS_JSON")) != "" {
+		return "CDE_ENVIRONMENTS_JSON"
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("CDE_ENV_ONLY"))) {
+	case "1", "true", "yes", "on":
+		return "OPENAI_* variables"
+	}
+	return ""
+}
+
+// envOnlyData returns the configuration document for env-only mode. CDE_ENVIRONMENTS_JSON
+// may hold a full config object or just the environments array.
+func envOnlyData() ([]byte, error) {
+	if raw := strings.TrimSpace(os.Getenv("CDE_ENVIRONMENTS_JSON")); raw != "" {
+		if strings.HasPrefix(raw, "[") {
+			raw = `{"environments": ` + raw + `}`
+		}
+		return []byte(raw), nil
+	}
+
+	baseURL := strings.TrimSpace(os.Getenv("OPENAI_BASE_URL"))
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	apiKey := strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
+	if apiKey == "" {
+		return nil, fmt.Errorf("CDE_ENV_ONLY is set but OPENAI_API_KEY is empty")
+	}
+	env := Environment{Name: envOnlyEnvironmentName, URL: baseURL, APIKey: apiKey, Model: strings.TrimSpace(os.Getenv("OPENAI_MODEL"))}
+	return json.Marshal(Config{Environments: []Environment{env}})
+}
+
+// loadEnvOnlyConfig builds the in-memory configuration for env-only mode without touching config.json
+func loadEnvOnlyConfig() (Config, error) {
+	source := envOnlySource()
+	data, err := envOnlyData()
+	if err != nil {
+		return Config{}, fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	data = stripJSONC(data)
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("configuration parsing failed (invalid JSON in %s): %w", source, describeJSONError(data, err))
+	}
+	if config.Environments == nil {
+		config.Environments = []Environment{}
+	}
+	if err := validateSettings(config.Settings); err != nil {
+		return Config{}, err
+	}
+
+	config.nameIndex = indexEnvironments(config.Environments)
+	logDebugf("env-only mode: loaded %d environment(s) from %s", len(config.Environments), source)
+	return config, nil
+}
+
+// requireWritableConfig fails when the configuration comes from the environment rather than config.json
+func requireWritableConfig() error {
+	source := envOnlySource()
+	if source == "" {
+		return nil
+	}
+	return withErrorCode(codeConfigReadOnly, fmt.Errorf("configuration is read-only: environments come from %s (env-only mode); unset CDE_ENVIRONMENTS_JSON and CDE_ENV_ONLY to manage config.json", source))
+}
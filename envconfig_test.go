@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvOnlyConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		vars      map[string]string
+		wantNames []string
+		wantURL   string
+		wantErr   string
+	}{
+		{
+			name:      "environments array",
+			vars:      map[string]string{"CDE_ENVIRONMENTS_JSON": `[{"name": "prod", "url": "https://api.openai.com/v1", "api_key": "sk-prod-1234567890"}]`},
+			wantNames: []string{"prod"},
+			wantURL:   "https://api.openai.com/v1",
+		},
+		{
+			name: "full config object",
+			vars: map[string]string{"CDE_ENVIRONMENTS_JSON": `{
+  // injected by the deployment
+  "environments": [
+    {"name": "a", "url": "https://a.example.com/v1", "api_key": "sk-a-1234567890"},
+    {"name": "b", "url": "https://b.example.com/v1", "api_key": "sk-b-1234567890"},
+  ],
+  "settings": {"launch_mode": "subprocess"}
+}`},
+			wantNames: []string{"a", "b"},
+			wantURL:   "https://a.example.com/v1",
+		},
+		{
+			name:      "OPENAI variables",
+			vars:      map[string]string{"CDE_ENV_ONLY": "1", "OPENAI_BASE_URL": "http://localhost:8080/v1", "OPENAI_API_KEY": "sk-local-1234567890"},
+			wantNames: []string{envOnlyEnvironmentName},
+			wantURL:   "http://localhost:8080/v1",
+		},
+		{
+			name:      "OPENAI default URL",
+			vars:      map[string]string{"CDE_ENV_ONLY": "true", "OPENAI_API_KEY": "sk-local-1234567890"},
+			wantNames: []string{envOnlyEnvironmentName},
+			wantURL:   "https://api.openai.com/v1",
+		},
+		{name: "missing key", vars: map[string]string{"CDE_ENV_ONLY": "1"}, wantErr: "OPENAI_API_KEY is empty"},
+		{name: "invalid JSON", vars: map[string]string{"CDE_ENVIRONMENTS_JSON": `[{"name": }]`}, wantErr: "invalid JSON in CDE_ENVIRONMENTS_JSON"},
+		{name: "invalid settings", vars: map[string]string{"CDE_ENVIRONMENTS_JSON": `{"environments": [], "settings": {"launch_mode": "fork"}}`}, wantErr: "settings.launch_mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"CDE_ENVIRONMENTS_JSON", "CDE_ENV_ONLY", "OPENAI_BASE_URL", "OPENAI_API_KEY", "OPENAI_MODEL"} {
+				t.Setenv(name, tt.vars[name])
+			}
+
+			config, err := loadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfig() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if len(config.Environments) != len(tt.wantNames) {
+				t.Fatalf("got %d environments, want %v", len(config.Environments), tt.wantNames)
+			}
+			for i, name := range tt.wantNames {
+				if config.Environments[i].Name != name {
+					t.Errorf("environment %d = %q, want %q", i, config.Environments[i].Name, name)
+				}
+			}
+			if config.Environments[0].URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", config.Environments[0].URL, tt.wantURL)
+			}
+			if _, ok := findEnvironmentByName(config, tt.wantNames[0]); !ok {
+				t.Error("name index not built")
+			}
+		})
+	}
+}
+
+func TestEnvOnlyModeSkipsConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing", "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	t.Setenv("CDE_ENVIRONMENTS_JSON", `[{"name": "prod", "url": "https://api.openai.com/v1", "api_key": "sk-prod-1234567890"}]`)
+
+	if _, err := loadConfig(); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	writes := map[string]error{
+		"saveConfig":   saveConfig(Config{Environments: []Environment{}}),
+		"updateConfig": updateConfig(func(config *Config) error { return nil }),
+		"add":          handleCommand([]string{"add"}),
+		"rotate-key":   handleCommand([]string{"rotate-key", "prod", "sk-new-1234567890"}),
+		"config edit":  handleCommand([]string{"config", "edit"}),
+	}
+	for name, err := range writes {
+		var coded *codedError
+		if !errors.As(err, &coded) || coded.Code != codeConfigReadOnly || !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("%s error = %v, want a read-only error", name, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Dir(configPath)); !os.IsNotExist(err) {
+		t.Errorf("env-only mode created the config directory: %v", err)
+	}
+}
@@ -249,3 +249,50 @@ func TestCommonSystemVarDetection(t *testing.T) {
 		}
 	}
 }
+
+// TestEnvVarsOverrideInheritedAndManaged verifies EnvVars replace inherited variables
+// and cannot override the cde-managed OPENAI_* variables
+func TestEnvVarsOverrideInheritedAndManaged(t *testing.T) {
+	t.Setenv("CDE_TEST_INHERITED", "from-shell")
+	t.Setenv("CDE_TEST_UNSET", "from-shell")
+
+	env := Environment{
+		Name:   "override",
+		URL:    "https://api.openai.com/v1",
+		APIKey: "sk-test",
+		EnvVars: map[string]string{
+			"CDE_TEST_INHERITED": "from-env",
+			"CDE_TEST_UNSET":     "",
+			"OPENAI_BASE_URL":    "https://evil.example.com",
+		},
+	}
+
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		t.Fatalf("prepareEnvironment() failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, envVar := range envVars {
+		key, value, _ := strings.Cut(envVar, "=")
+		counts[key]++
+		switch key {
+		case "CDE_TEST_INHERITED":
+			if value != "from-env" {
+				t.Errorf("expected EnvVars value to win, got %s", envVar)
+			}
+		case "OPENAI_BASE_URL":
+			if value != env.URL {
+				t.Errorf("expected managed base URL, got %s", envVar)
+			}
+		case "CDE_TEST_UNSET":
+			t.Errorf("empty EnvVars value should unset inherited variable, got %s", envVar)
+		}
+	}
+
+	for key, count := range counts {
+		if count > 1 {
+			t.Errorf("variable %s appears %d times", key, count)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errorCode is a stable identifier for a class of failure. Wrappers and IDE
+// integrations match on these, so codes are never renumbered or reused;
+// new failure classes get the next free number in their group.
+type errorCode string
+
+// Error code registry
+const (
+	codeGeneral            errorCode = "CDE-GEN-001"  // Unclassified failure
+	codeArgParse           errorCode = "CDE-ARG-001"  // Unknown command, flag or missing flag value
+	codeArgValidation      errorCode = "CDE-ARG-002"  // Rejected codex passthrough or CDE flag value
+	codeConfigLoad         errorCode = "CDE-CONF-001" // config.json unreadable, corrupt or invalid
+	codeEnvNotFound        errorCode = "CDE-CONF-002" // Named environment, key, preset or backup does not exist
+	codeConfigSave         errorCode = "CDE-CONF-003" // config.json could not be written
+	codeConfigLocked       errorCode = "CDE-CONF-004" // Another cde process holds the config lock
+	codeNoEnvironments     errorCode = "CDE-CONF-005" // No environments configured or none selected
+	codeConfigReadOnly     errorCode = "CDE-CONF-006" // Write command used in env-only mode (CDE_ENVIRONMENTS_JSON / CDE_ENV_ONLY)
+	codeCodexNotFound      errorCode = "CDE-EXEC-001" // codex is not in PATH
+	codeCodexNotExecutable errorCode = "CDE-EXEC-002" // codex exists but cannot be executed
+	codeCodexExecution     errorCode = "CDE-EXEC-003" // codex failed to start or exited abnormally
+	codeTerminal           errorCode = "CDE-TERM-001" // Terminal capability or raw mode failure
+	codePermission         errorCode = "CDE-PERM-001" // File permission or access failure
+	codeNetwork            errorCode = "CDE-NET-001"  // Update check, sync or health probe request failed
+	codeOffline            errorCode = "CDE-NET-002"  // Network access skipped because CDE_OFFLINE is set
+	codeSecretResolution   errorCode = "CDE-SEC-001"  // op:// or vault:// API key reference could not be resolved
+	codeBudgetExceeded     errorCode = "CDE-BUD-001"  // Launch blocked by an environment budget
+	codeRateLimited        errorCode = "CDE-BUD-002"  // Launch blocked by an environment rate limit
+	codeHookFailed         errorCode = "CDE-HOOK-001" // A pre_launch hook failed or timed out
+	codeAuthFailed         errorCode = "CDE-AUTH-001" // OAuth/gcloud token or AWS credentials could not be obtained for an environment
+)
+
+// codedError attaches a stable code to an error without changing its message
+type codedError struct {
+	Code errorCode
+	Err  error
+}
+
+func (e *codedError) Error() string { return e.Err.Error() }
+func (e *codedError) Unwrap() error { return e.Err }
+
+// withErrorCode tags err with code; nil stays nil
+func withErrorCode(code errorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{Code: code, Err: err}
+}
+
+// errorCodeOf returns the explicit code attached to err, or classifies it by message
+func errorCodeOf(err error) errorCode {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+
+	errStr := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(errStr, "offline mode"):
+		return codeOffline
+	case strings.Contains(errStr, "api key resolution") || strings.Contains(errStr, "secret resolution"):
+		return codeSecretResolution
+	case strings.Contains(errStr, "locked by another process"):
+		return codeConfigLocked
+	case strings.Contains(errStr, "argument parsing") || strings.Contains(errStr, "requires a value") ||
+		strings.Contains(errStr, "unknown") && strings.Contains(errStr, "flag"):
+		return codeArgParse
+	case strings.Contains(errStr, "argument validation") || strings.Contains(errStr, "cannot be combined") ||
+		strings.Contains(errStr, "flag --json"):
+		return codeArgValidation
+	case strings.Contains(errStr, "no environments"):
+		return codeNoEnvironments
+	case strings.Contains(errStr, "not found") && !strings.Contains(errStr, "codex"):
+		return codeEnvNotFound
+	case strings.Contains(errStr, "configuration save") || strings.Contains(errStr, "configuration update"):
+		return codeConfigSave
+	case strings.Contains(errStr, "configuration"):
+		return codeConfigLoad
+	case strings.Contains(errStr, "codex not found"):
+		return codeCodexNotFound
+	case strings.Contains(errStr, "not executable"):
+		return codeCodexNotExecutable
+	case strings.Contains(errStr, "codex") && (strings.Contains(errStr, "execution") || strings.Contains(errStr, "launcher")):
+		return codeCodexExecution
+	case strings.Contains(errStr, "terminal") || strings.Contains(errStr, "raw mode") || strings.Contains(errStr, "tty"):
+		return codeTerminal
+	case strings.Contains(errStr, "permission") || strings.Contains(errStr, "access denied"):
+		return codePermission
+	case strings.Contains(errStr, "fetch failed") || strings.Contains(errStr, "push failed") ||
+		strings.Contains(errStr, "update check") || strings.Contains(errStr, "request failed"):
+		return codeNetwork
+	}
+	return codeGeneral
+}
+
+// writeErrorJSON prints err as a single JSON object for --json consumers
+func writeErrorJSON(w io.Writer, err error, category string, exitCode int) {
+	payload := struct {
+		Error struct {
+			Code     errorCode `json:"code"`
+			Category string    `json:"category"`
+			Message  string    `json:"message"`
+			ExitCode int       `json:"exit_code"`
+		} `json:"error"`
+	}{}
+	payload.Error.Code = errorCodeOf(err)
+	payload.Error.Category = category
+	payload.Error.Message = err.Error()
+	payload.Error.ExitCode = exitCode
+
+	data, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		fmt.Fprintf(w, "{\"error\":{\"code\":%q,\"message\":%q}}\n", codeGeneral, err.Error())
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorCode
+	}{
+		{"explicit code wins", withErrorCode(codeTerminal, fmt.Errorf("configuration loading failed")), codeTerminal},
+		{"wrapped explicit code", fmt.Errorf("Codex launcher failed: %w", withErrorCode(codeCodexNotFound, fmt.Errorf("x"))), codeCodexNotFound},
+		{"config load", fmt.Errorf("configuration loading failed: invalid JSON"), codeConfigLoad},
+		{"env not found", fmt.Errorf("environment 'prod' not found"), codeEnvNotFound},
+		{"config locked", fmt.Errorf("configuration update failed: config is locked by another process"), codeConfigLocked},
+		{"config save", fmt.Errorf("configuration save failed: disk full"), codeConfigSave},
+		{"missing flag value", fmt.Errorf("flag --env requires a value"), codeArgParse},
+		{"argument validation", fmt.Errorf("argument validation failed: potentially dangerous argument"), codeArgValidation},
+		{"flag conflict", fmt.Errorf("flags --env and --failover cannot be combined"), codeArgValidation},
+		{"secret", fmt.Errorf("API key resolution failed: op read failed"), codeSecretResolution},
+		{"offline", fmt.Errorf("sync skipped: offline mode (CDE_OFFLINE) is set"), codeOffline},
+		{"network", fmt.Errorf("sync fetch failed: connection refused"), codeNetwork},
+		{"codex execution", fmt.Errorf("Codex execution failed: exit status 1"), codeCodexExecution},
+		{"general", fmt.Errorf("something odd"), codeGeneral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCodeOf(tt.err); got != tt.want {
+				t.Errorf("errorCodeOf(%q) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCodeFormat(t *testing.T) {
+	pattern := regexp.MustCompile(`^CDE-[A-Z]+-\d{3}$`)
+	codes := []errorCode{
+		codeGeneral, codeArgParse, codeArgValidation, codeConfigLoad, codeEnvNotFound, codeConfigSave,
+		codeConfigLocked, codeNoEnvironments, codeCodexNotFound, codeCodexNotExecutable, codeCodexExecution,
+		codeTerminal, codePermission, codeNetwork, codeOffline, codeSecretResolution,
+	}
+	seen := map[errorCode]bool{}
+	for _, code := range codes {
+		if !pattern.MatchString(string(code)) {
+			t.Errorf("code %s does not match CDE-<GROUP>-NNN", code)
+		}
+		if seen[code] {
+			t.Errorf("code %s is used twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestFormatErrorCarriesCode(t *testing.T) {
+	err := newErrorContext("codex verification", "launcher").withCode(codeCodexNotFound).formatError(fmt.Errorf("codex not found in PATH"))
+	if errorCodeOf(err) != codeCodexNotFound {
+		t.Errorf("formatError code = %s, want %s", errorCodeOf(err), codeCodexNotFound)
+	}
+
+	var buf bytes.Buffer
+	writeErrorJSON(&buf, fmt.Errorf("Codex launcher failed: %w", err), "codex_execution", 3)
+	var payload struct {
+		Error struct {
+			Code     string `json:"code"`
+			Category string `json:"category"`
+			Message  string `json:"message"`
+			ExitCode int    `json:"exit_code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid JSON error output: %v\n%s", err, buf.String())
+	}
+	if payload.Error.Code != string(codeCodexNotFound) || payload.Error.ExitCode != 3 || payload.Error.Category != "codex_execution" {
+		t.Errorf("unexpected payload: %+v", payload.Error)
+	}
+}
@@ -43,7 +43,7 @@ func TestErrorRecoveryScenarios(t *testing.T) {
 			},
 			{
 				name:     "invalid_json_syntax",
-				content:  `{"environments": [{"name": "test", "url": "https://api.anthropic.com",}]}`,
+				content:  `{"environments": [{"name": "test", "url": }]}`,
 				expected: "parsing failed",
 			},
 			{
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// modelRejectedPattern matches the errors providers return for an unknown or unsupported
+// model. It is kept tight so unrelated failures never trigger a retry.
+var modelRejectedPattern = regexp.MustCompile(`(?i)\bmodel_not_found\b|\bthe model [^\n]{1,80} does not exist\b|\bunsupported model\b|\bmodel [^\n]{1,80} is not supported\b|\bmodel not found\b`)
+
+// maxScannedLine bounds how much of a single output line the detector keeps
+const maxScannedLine = 4096
+
+// lineDetector watches codex's stderr line by line for a pattern, such as a rejected model
+type lineDetector struct {
+	pattern *regexp.Regexp
+	mu      sync.Mutex
+	partial []byte
+	matched bool
+}
+
+// newLineDetector creates a detector for pattern
+func newLineDetector(pattern *regexp.Regexp) *lineDetector {
+	return &lineDetector{pattern: pattern}
+}
+
+// Write scans complete lines; it never fails so codex's output is never held up
+func (d *lineDetector) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.partial = append(d.partial, p...)
+	for {
+		newline := strings.IndexByte(string(d.partial), '\n')
+		if newline < 0 {
+			break
+		}
+		d.scan(d.partial[:newline])
+		d.partial = d.partial[newline+1:]
+	}
+	if len(d.partial) > maxScannedLine {
+		d.scan(d.partial)
+		d.partial = d.partial[:0]
+	}
+	return len(p), nil
+}
+
+// scan records a match in one line of output
+func (d *lineDetector) scan(line []byte) {
+	if !d.matched && d.pattern.Match(line) {
+		d.matched = true
+	}
+}
+
+// Matched reports whether codex printed a matching line, including an unterminated last line
+func (d *lineDetector) Matched() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scan(d.partial)
+	return d.matched
+}
+
+// backupKeyName is the named key a subprocess launch is retried with when the provider rejects api_key
+const backupKeyName = "backup"
+
+// backupKeyFallbackEnabled reports whether a launch of env may retry with its backup key: it
+// has one, no key was picked with --key and it does not authenticate through an auth block
+func backupKeyFallbackEnabled(env Environment, keyName string) bool {
+	_, exists := env.Keys[backupKeyName]
+	return exists && keyName == "" && env.Auth == nil
+}
+
+// backupKeyRejected reports whether a failed subprocess run was rejected for its api_key.
+// Only a 401 seen on stderr counts, so nothing is retried when stderr is a terminal.
+func backupKeyRejected(exitCode int, detector *lineDetector) bool {
+	if exitCode == 0 || exitCode == sessionTimeoutExitCode || detector == nil {
+		return false
+	}
+	return detector.Matched()
+}
+
+// relaunchWithBackupKey runs codex once more with planned's backup key in place of env's
+// api_key, within what is left of opts.Timeout. It returns the environment it relaunched and
+// the combined run time; when no relaunch is possible it explains why and returns the original
+// run's result.
+func relaunchWithBackupKey(planned, env Environment, args []string, opts subprocessOptions, exitCode int, elapsed time.Duration) (Environment, int, time.Duration, error) {
+	remaining, ok := remainingTimeout(opts.Timeout, elapsed)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "API key for '%s' was rejected; not retrying with key '%s' because the session limit is spent\n", env.Name, backupKeyName)
+		return env, exitCode, elapsed, nil
+	}
+	backup, err := selectAPIKey(planned, backupKeyName)
+	if err == nil {
+		backup, err = resolveEnvironmentSecrets(backup)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "API key for '%s' was rejected and key '%s' could not be resolved: %v\n", env.Name, backupKeyName, err)
+		return env, exitCode, elapsed, nil
+	}
+	fmt.Fprintf(os.Stderr, "API key for '%s' was rejected; retrying once with key '%s'\n", env.Name, backupKeyName)
+	retry := cloneEnvironment(env, env.Name)
+	retry.APIKey = backup.APIKey
+	opts.Timeout = remaining
+	exitCode, retryElapsed, err := launchCodexSubprocess(retry, args, opts)
+	return retry, exitCode, elapsed + retryElapsed, err
+}
+
+// modelFallbackEnabled reports whether a launch of env with args may retry with fallback_model:
+// the environment has one, settings.model_fallback is not false and the fallback differs
+func modelFallbackEnabled(config Config, env Environment, args []string) bool {
+	if env.FallbackModel == "" {
+		return false
+	}
+	if config.Settings != nil && config.Settings.ModelFallback != nil && !*config.Settings.ModelFallback {
+		return false
+	}
+	return argsModel(args) != fallbackModelArg(env)
+}
+
+// fallbackModelArg returns the fallback model as codex receives it, after aliases and the
+// Azure deployment map
+func fallbackModelArg(env Environment) string {
+	model := resolveModelAlias(env, env.FallbackModel)
+	if deployment, ok := env.DeploymentMap[model]; ok && isAzureEnvironment(env) {
+		model = deployment
+	}
+	return model
+}
+
+// isModelOverride reports whether a -c override sets the model
+func isModelOverride(override string) bool {
+	_, ok := modelOverride(override)
+	return ok
+}
+
+// withFallbackModel returns env and args switched to env's fallback_model
+func withFallbackModel(env Environment, args []string) (Environment, []string) {
+	fallback := fallbackModelArg(env)
+	env = cloneEnvironment(env, env.Name)
+	env.Model = env.FallbackModel
+
+	replaced := make([]string, 0, len(args)+2)
+	found := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case (args[i] == "-m" || args[i] == "--model") && i+1 < len(args):
+			replaced = append(replaced, args[i], fallback)
+			i++
+			found = true
+		case strings.HasPrefix(args[i], "--model="):
+			replaced = append(replaced, "--model="+fallback)
+			found = true
+		case (args[i] == "-c" || args[i] == "--config") && i+1 < len(args) && isModelOverride(args[i+1]):
+			replaced = append(replaced, args[i], "model="+fallback)
+			i++
+			found = true
+		default:
+			replaced = append(replaced, args[i])
+		}
+	}
+	if !found {
+		replaced = append([]string{"-m", fallback}, replaced...)
+	}
+	return env, replaced
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestModelRejectedPattern(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   bool
+	}{
+		{"error code", []string{`{"error":{"code":"model_not_found"}}` + "\n"}, true},
+		{"does not exist", []string{"Error: The model `gpt-9` does not exist or you do not have access to it.\n"}, true},
+		{"unsupported", []string{"400 Unsupported model: gpt-9\n"}, true},
+		{"not supported", []string{"error: model 'gpt-9' is not supported on this endpoint\n"}, true},
+		{"split across writes", []string{"stream error: mod", "el_not_fou", "nd\n"}, true},
+		{"unterminated last line", []string{"Model not found"}, true},
+		{"rate limit", []string{"429 Too Many Requests: rate limit reached for model gpt-5\n"}, false},
+		{"auth failure", []string{"401 Unauthorized: invalid api key\n"}, false},
+		{"model on another line", []string{"the model\n", "does not exist\n"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := newLineDetector(modelRejectedPattern)
+			for _, chunk := range tt.writes {
+				if n, err := detector.Write([]byte(chunk)); err != nil || n != len(chunk) {
+					t.Fatalf("Write() = %d, %v", n, err)
+				}
+			}
+			if got := detector.Matched(); got != tt.want {
+				t.Errorf("Matched() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFallbackModel(t *testing.T) {
+	env := Environment{Name: "gw", Model: "gpt-5", FallbackModel: "mini", ModelAliases: map[string]string{"mini": "gpt-5-mini"}}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"short flag", []string{"-m", "gpt-5", "exec", "hi"}, []string{"-m", "gpt-5-mini", "exec", "hi"}},
+		{"long flag", []string{"--model", "gpt-5"}, []string{"--model", "gpt-5-mini"}},
+		{"equals form", []string{"--model=gpt-5", "exec"}, []string{"--model=gpt-5-mini", "exec"}},
+		{"no model flag", []string{"exec", "hi"}, []string{"-m", "gpt-5-mini", "exec", "hi"}},
+		{"config override", []string{"-c", "model=gpt-5", "exec"}, []string{"-c", "model=gpt-5-mini", "exec"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbackEnv, args := withFallbackModel(env, tt.args)
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("args = %v, want %v", args, tt.want)
+			}
+			if fallbackEnv.Model != "mini" || env.Model != "gpt-5" {
+				t.Errorf("fallback model = %q, original = %q", fallbackEnv.Model, env.Model)
+			}
+		})
+	}
+}
+
+func TestModelFallbackEnabled(t *testing.T) {
+	disabled := false
+	env := Environment{Name: "gw", Model: "gpt-5", FallbackModel: "gpt-4.1"}
+	tests := []struct {
+		name   string
+		config Config
+		env    Environment
+		args   []string
+		want   bool
+	}{
+		{"fallback set", Config{}, env, []string{"-m", "gpt-5"}, true},
+		{"no fallback", Config{}, Environment{Name: "gw", Model: "gpt-5"}, []string{"-m", "gpt-5"}, false},
+		{"opted out", Config{Settings: &ConfigSettings{ModelFallback: &disabled}}, env, []string{"-m", "gpt-5"}, false},
+		{"already the fallback", Config{}, env, []string{"-m", "gpt-4.1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := modelFallbackEnabled(tt.config, tt.env, tt.args); got != tt.want {
+				t.Errorf("modelFallbackEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemainingTimeout(t *testing.T) {
+	tests := []struct {
+		timeout, elapsed time.Duration
+		want             time.Duration
+		wantOK           bool
+	}{
+		{0, time.Hour, 0, true},
+		{time.Minute, 20 * time.Second, 40 * time.Second, true},
+		{time.Minute, time.Minute, 0, false},
+		{time.Minute, 2 * time.Minute, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := remainingTimeout(tt.timeout, tt.elapsed)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("remainingTimeout(%s, %s) = %s, %v; want %s, %v", tt.timeout, tt.elapsed, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSubprocessLaunchRetriesWithFallbackModel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	// Fake codex that rejects gpt-bad and logs every model it is started with (skipping
+	// the version check)
+	binDir := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\n[ \"$1\" = -m ] || exit 0\necho \"$2\" >> \"" + calls + "\"\n" +
+		"if [ \"$2\" = gpt-bad ]; then echo 'Error: unsupported model gpt-bad' >&2; exit 1; fi\n" +
+		"[ \"$OPENAI_MODEL\" = gpt-good ] || exit 8\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	disabled := false
+	tests := []struct {
+		name      string
+		settings  *ConfigSettings
+		terminal  bool
+		wantCalls string
+		wantExit  int
+	}{
+		{"retried", &ConfigSettings{LaunchMode: launchModeSubprocess}, false, "gpt-bad\ngpt-good\n", 0},
+		{"opted out", &ConfigSettings{LaunchMode: launchModeSubprocess, ModelFallback: &disabled}, false, "gpt-bad\n", 1},
+		{"stderr is a terminal", &ConfigSettings{LaunchMode: launchModeSubprocess}, true, "gpt-bad\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalTappable := stderrTappable
+			stderrTappable = func() bool { return !tt.terminal }
+			defer func() { stderrTappable = originalTappable }()
+			originalConfigPath := configPathOverride
+			configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+			defer func() { configPathOverride = originalConfigPath }()
+			os.Remove(calls)
+
+			config := Config{
+				Environments: []Environment{{Name: "gw", URL: "https://api.openai.com/v1", APIKey: "sk-fallback-test", Model: "gpt-bad", FallbackModel: "gpt-good"}},
+				Settings:     tt.settings,
+			}
+			if err := saveConfig(config); err != nil {
+				t.Fatalf("saveConfig failed: %v", err)
+			}
+
+			err := runDefaultWithOptions("gw", []string{"exec", "hi"}, launchOptions{})
+			var childExit *childExitError
+			switch {
+			case tt.wantExit == 0 && err != nil:
+				t.Fatalf("expected success after fallback, got %v", err)
+			case tt.wantExit != 0 && (!errors.As(err, &childExit) || childExit.Code != tt.wantExit):
+				t.Fatalf("expected exit status %d, got %v", tt.wantExit, err)
+			}
+
+			data, err := os.ReadFile(calls)
+			if err != nil {
+				t.Fatalf("failed to read calls: %v", err)
+			}
+			if got := string(data); got != tt.wantCalls {
+				t.Errorf("codex started with %q, want %q", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// errFormUnavailable signals that the terminal cannot host the form editor
+var errFormUnavailable = fmt.Errorf("form editor unavailable")
+
+// formField is one editable line of the environment form
+type formField struct {
+	Key      string // Identifier used when building the environment
+	Label    string
+	Value    string
+	Secret   bool // Render as asterisks
+	Validate func(string) error
+	Err      string // Inline validation error from the last submit attempt
+}
+
+// formAction is the outcome of a key press in the form
+type formAction int
+
+const (
+	formContinue formAction = iota
+	formSubmit
+	formCancel
+)
+
+// envForm holds the state of the multi-field environment editor
+type envForm struct {
+	fields []formField
+	focus  int
+}
+
+// newEnvironmentForm builds the form fields for add, pre-filled from an optional preset
+func newEnvironmentForm(config Config, preset *ProviderPreset) *envForm {
+	form := &envForm{}
+
+	form.fields = append(form.fields, formField{
+		Key:   "name",
+		Label: "Environment name",
+		Validate: func(value string) error {
+			if err := validateName(value); err != nil {
+				return err
+			}
+			if _, exists := findEnvironmentByName(config, value); exists {
+				return fmt.Errorf("environment '%s' already exists", value)
+			}
+			return nil
+		},
+	})
+
+	urlField := formField{
+		Key:   "url",
+		Label: "Base URL",
+		Validate: func(value string) error {
+			// An untouched preset template still needs its placeholders filled; placeholders
+			// typed deliberately become launch-time template variables
+			if match := urlPlaceholderPattern.FindString(value); match != "" && preset != nil && value == preset.URLTemplate {
+				return fmt.Errorf("replace %s in the URL", match)
+			}
+			return validateTemplateURL(value)
+		},
+	}
+	if preset != nil {
+		urlField.Value = preset.URLTemplate
+	}
+	form.fields = append(form.fields, urlField)
+
+	form.fields = append(form.fields, formField{
+		Key:      "api_key",
+		Label:    "API Key",
+		Secret:   true,
+		Validate: validateAPIKey,
+	})
+
+	modelField := formField{Key: "model", Label: "Model (optional)", Validate: validateModel}
+	if preset != nil {
+		modelField.Value = preset.DefaultModel
+	}
+	form.fields = append(form.fields, modelField)
+
+	if preset != nil && preset.APIVersion != "" {
+		form.fields = append(form.fields, formField{
+			Key:   "api_version",
+			Label: "API version",
+			Value: preset.APIVersion,
+			Validate: func(value string) error {
+				if value == "" {
+					return fmt.Errorf("API version cannot be empty")
+				}
+				return nil
+			},
+		})
+	}
+
+	if preset != nil {
+		keys := make([]string, 0, len(preset.EnvVars))
+		for key := range preset.EnvVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			form.fields = append(form.fields, formField{
+				Key:   "env:" + key,
+				Label: key,
+				Value: preset.EnvVars[key],
+				Validate: func(value string) error {
+					if value == "" {
+						return fmt.Errorf("value is required")
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	form.fields = append(form.fields, formField{
+		Key:      "env_vars",
+		Label:    "Env vars (KEY=VALUE, comma separated)",
+		Validate: func(value string) error { _, err := parseEnvVarList(value); return err },
+	})
+
+	return form
+}
+
+// parseEnvVarList parses "KEY=VALUE, KEY2=VALUE2" into a map
+func parseEnvVarList(value string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, val, found := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !found || !isValidEnvVarName(key) {
+			return nil, fmt.Errorf("invalid entry '%s' (use KEY=VALUE)", entry)
+		}
+		vars[key] = strings.TrimSpace(val)
+	}
+	return vars, nil
+}
+
+// setValue pre-fills the field with the given key; empty values leave it unchanged
+func (f *envForm) setValue(key, value string) {
+	if value == "" {
+		return
+	}
+	for i := range f.fields {
+		if f.fields[i].Key == key {
+			f.fields[i].Value = value
+		}
+	}
+}
+
+// validate checks every field, records inline errors and focuses the first invalid field
+func (f *envForm) validate() bool {
+	valid := true
+	for i := range f.fields {
+		f.fields[i].Err = ""
+		if f.fields[i].Validate == nil {
+			continue
+		}
+		if err := f.fields[i].Validate(f.fields[i].Value); err != nil {
+			f.fields[i].Err = err.Error()
+			if valid {
+				f.focus = i
+			}
+			valid = false
+		}
+	}
+	return valid
+}
+
+// handleKey applies one key press to the form state
+func (f *envForm) handleKey(input []byte) formAction {
+	field := &f.fields[f.focus]
+
+	// Pasted text arrives as a single read
+	if len(input) > 1 && input[0] >= 32 && input[0] <= 126 {
+		for _, b := range input {
+			if b >= 32 && b <= 126 {
+				field.Value += string(b)
+			}
+		}
+		return formContinue
+	}
+
+	arrow, char, err := parseKeyInput(input)
+	if err != nil {
+		return formContinue
+	}
+
+	switch arrow {
+	case ArrowUp:
+		f.focus = (f.focus - 1 + len(f.fields)) % len(f.fields)
+		return formContinue
+	case ArrowDown:
+		f.focus = (f.focus + 1) % len(f.fields)
+		return formContinue
+	case ArrowLeft, ArrowRight:
+		return formContinue
+	}
+
+	switch char {
+	case '\x1b', '\x03':
+		return formCancel
+	case '\t':
+		f.focus = (f.focus + 1) % len(f.fields)
+	case '\n':
+		// Enter advances to the next field; on the last field it submits
+		if f.focus < len(f.fields)-1 {
+			f.focus++
+			return formContinue
+		}
+		if f.validate() {
+			return formSubmit
+		}
+	case 127, 8:
+		if len(field.Value) > 0 {
+			field.Value = field.Value[:len(field.Value)-1]
+		}
+	default:
+		if char >= 32 && char <= 126 {
+			field.Value += string(char)
+		}
+	}
+	return formContinue
+}
+
+// render returns the form lines; the focused field is marked with '>'
+func (f *envForm) render(title string) []string {
+	lines := []string{title, ""}
+	for i, field := range f.fields {
+		value := field.Value
+		if field.Secret {
+			value = strings.Repeat("*", len(value))
+		}
+		marker := "  "
+		if i == f.focus {
+			marker = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%s: %s", marker, field.Label, value))
+		if field.Err != "" {
+			lines = append(lines, fmt.Sprintf("    ! %s", field.Err))
+		}
+	}
+	lines = append(lines, "", tr("↑↓/Tab move, Enter next (submit on last field), Esc cancel"))
+	return lines
+}
+
+// environment builds the environment from validated form values
+func (f *envForm) environment(preset *ProviderPreset) Environment {
+	env := Environment{EnvVars: make(map[string]string)}
+	if preset != nil {
+		env.Provider = preset.Name
+	}
+	for _, field := range f.fields {
+		switch {
+		case field.Key == "name":
+			env.Name = field.Value
+		case field.Key == "url":
+			env.URL = field.Value
+		case field.Key == "api_key":
+			env.APIKey = field.Value
+		case field.Key == "model":
+			env.Model = field.Value
+		case field.Key == "api_version":
+			env.APIVersion = field.Value
+		case strings.HasPrefix(field.Key, "env:"):
+			env.EnvVars[strings.TrimPrefix(field.Key, "env:")] = field.Value
+		case field.Key == "env_vars":
+			extra, _ := parseEnvVarList(field.Value)
+			for key, value := range extra {
+				env.EnvVars[key] = value
+			}
+		}
+	}
+	return env
+}
+
+// formPromptForEnvironment runs the form editor in raw mode.
+// Returns errFormUnavailable when the terminal cannot support it.
+func formPromptForEnvironment(config Config, preset *ProviderPreset, apiKey string) (Environment, error) {
+	if config.Settings != nil && config.Settings.Terminal != nil && config.Settings.Terminal.ForceFallback {
+		return Environment{}, errFormUnavailable
+	}
+	// Screen readers cannot follow a form that redraws in place; use the line prompts
+	if accessibleMode(config) {
+		return Environment{}, errFormUnavailable
+	}
+
+	caps := detectTerminalCapabilities()
+	if !caps.IsTerminal || !caps.SupportsRaw || !caps.SupportsANSI {
+		return Environment{}, errFormUnavailable
+	}
+
+	if err := uiTerminal.MakeRaw(); err != nil {
+		return Environment{}, errFormUnavailable
+	}
+	defer restoreTerminal()
+
+	title := "Add environment"
+	if preset != nil {
+		title = fmt.Sprintf("Add environment (preset '%s')", preset.Name)
+	}
+
+	form := newEnvironmentForm(config, preset)
+	form.setValue("api_key", apiKey)
+	buffer := make([]byte, 64)
+	rendered := 0
+
+	for {
+		// Move back over the previous frame and clear it before redrawing
+		if rendered > 0 {
+			fmt.Fprintf(uiTerminal, "\x1b[%dA\r\x1b[J", rendered)
+		}
+		lines := form.render(title)
+		fmt.Fprint(uiTerminal, strings.Join(lines, "\r\n")+"\r\n")
+		rendered = len(lines)
+
+		n, err := uiTerminal.Read(buffer)
+		if err != nil {
+			return Environment{}, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		switch form.handleKey(buffer[:n]) {
+		case formSubmit:
+			return form.environment(preset), nil
+		case formCancel:
+			return Environment{}, fmt.Errorf("environment creation cancelled")
+		}
+	}
+}
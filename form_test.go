@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// typeInto sends each character of text to the form as a separate key press
+func typeInto(form *envForm, text string) {
+	for _, b := range []byte(text) {
+		form.handleKey([]byte{b})
+	}
+}
+
+func TestEnvironmentFormSubmit(t *testing.T) {
+	config := Config{Environments: []Environment{{Name: "existing", URL: "https://a.example.com", APIKey: "sk-existing"}}}
+	form := newEnvironmentForm(config, nil)
+
+	typeInto(form, "existing")
+	form.handleKey([]byte("\r"))
+	typeInto(form, "https://api.example.com/v1")
+	form.handleKey([]byte("\x1b[B")) // Arrow down
+	typeInto(form, "sk-test1234567890")
+	form.handleKey([]byte("\t"))
+	typeInto(form, "gpt-5")
+	form.handleKey([]byte("\r"))
+	form.handleKey([]byte("OPENAI_TIMEOUT=30"))
+
+	// Duplicate name fails inline and moves focus back to the name field
+	if action := form.handleKey([]byte("\r")); action != formContinue {
+		t.Fatalf("expected submit to be rejected, got %v", action)
+	}
+	if form.focus != 0 || !strings.Contains(form.fields[0].Err, "already exists") {
+		t.Fatalf("expected inline error on name field, focus=%d err=%q", form.focus, form.fields[0].Err)
+	}
+	if !strings.Contains(strings.Join(form.render("Add"), "\n"), "! environment 'existing' already exists") {
+		t.Error("expected inline error in rendered form")
+	}
+
+	form.handleKey([]byte{127})
+	form.handleKey([]byte{127})
+	form.focus = len(form.fields) - 1
+	if action := form.handleKey([]byte("\r")); action != formSubmit {
+		t.Fatalf("expected submit, got %v (errors: %+v)", action, form.fields)
+	}
+
+	env := form.environment(nil)
+	if env.Name != "existi" || env.URL != "https://api.example.com/v1" || env.APIKey != "sk-test1234567890" ||
+		env.Model != "gpt-5" || env.EnvVars["OPENAI_TIMEOUT"] != "30" {
+		t.Errorf("unexpected environment: %+v", env)
+	}
+}
+
+func TestEnvironmentFormCancelAndMasking(t *testing.T) {
+	form := newEnvironmentForm(Config{}, nil)
+	form.focus = 2
+	typeInto(form, "sk-secret")
+
+	rendered := strings.Join(form.render("Add"), "\n")
+	if strings.Contains(rendered, "sk-secret") || !strings.Contains(rendered, "*********") {
+		t.Errorf("expected masked API key, got:\n%s", rendered)
+	}
+
+	if action := form.handleKey([]byte{0x1b}); action != formCancel {
+		t.Errorf("expected Esc to cancel, got %v", action)
+	}
+}
+
+func TestEnvironmentFormPreset(t *testing.T) {
+	preset := ProviderPreset{
+		Name:        "azure",
+		URLTemplate: "https://{resource}.openai.azure.com/openai",
+		APIVersion:  "2025-04-01-preview",
+		EnvVars:     map[string]string{"GW_TEAM": ""},
+	}
+	form := newEnvironmentForm(Config{}, &preset)
+
+	if form.validate() {
+		t.Fatal("expected validation to fail with unfilled placeholders")
+	}
+	for _, field := range form.fields {
+		if field.Key == "url" && !strings.Contains(field.Err, "{resource}") {
+			t.Errorf("expected placeholder error on URL, got %q", field.Err)
+		}
+		if field.Key == "env:GW_TEAM" && field.Err == "" {
+			t.Error("expected required preset variable error")
+		}
+	}
+}
+
+func TestParseEnvVarList(t *testing.T) {
+	vars, err := parseEnvVarList("A=1, B_2 = two ,")
+	if err != nil || vars["A"] != "1" || vars["B_2"] != "two" || len(vars) != 2 {
+		t.Errorf("unexpected result %v, %v", vars, err)
+	}
+	if _, err := parseEnvVarList("NOVALUE"); err == nil {
+		t.Error("expected error for entry without '='")
+	}
+	if _, err := parseEnvVarList("1BAD=x"); err == nil {
+		t.Error("expected error for invalid name")
+	}
+}
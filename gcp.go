@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// authTypeGCP obtains a Google Cloud access token (Vertex AI and other Google-fronted endpoints)
+const authTypeGCP = "gcp"
+
+// gcpTokenLifetime is how long gcloud access tokens stay valid
+const gcpTokenLifetime = time.Hour
+
+// authRejectedPattern matches codex reporting that the provider rejected its credentials with
+// a 401. Like modelRejectedPattern it is kept tight so other failures never trigger a relaunch.
+var authRejectedPattern = regexp.MustCompile(`(?i)\b401\b[^\n]{0,40}\bunauthori[sz]ed\b|\bunauthori[sz]ed\b[^\n]{0,40}\b401\b|\bstatus(?: code)?[:= ]+401\b|\bUNAUTHENTICATED\b`)
+
+var (
+	gcpProjectPattern  = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	gcpLocationPattern = regexp.MustCompile(`^[a-z]+(-[a-z]+[0-9]*)*$`)
+)
+
+// validateGCPAuth checks the project, location and account of a gcp auth block
+func validateGCPAuth(auth *AuthSettings) error {
+	if auth.Project != "" && !gcpProjectPattern.MatchString(auth.Project) {
+		return fmt.Errorf("project '%s' is not a valid Google Cloud project ID", auth.Project)
+	}
+	if auth.Location != "" && !gcpLocationPattern.MatchString(auth.Location) {
+		return fmt.Errorf("location '%s' is not a valid Google Cloud location", auth.Location)
+	}
+	if strings.ContainsAny(auth.Account, " \t\r\n") || strings.HasPrefix(auth.Account, "-") {
+		return fmt.Errorf("invalid account '%s'", auth.Account)
+	}
+	if auth.ADC && auth.Account != "" {
+		return fmt.Errorf("account cannot be combined with adc")
+	}
+	return nil
+}
+
+// usesGCPAuth reports whether env authenticates with a Google Cloud access token
+func usesGCPAuth(env Environment) bool {
+	return env.Auth != nil && env.Auth.Type == authTypeGCP
+}
+
+// gcpEnvVars returns the Google Cloud variables codex and its tools expect
+func gcpEnvVars(env Environment) map[string]string {
+	if !usesGCPAuth(env) {
+		return nil
+	}
+	vars := map[string]string{}
+	if env.Auth.Project != "" {
+		vars["GOOGLE_CLOUD_PROJECT"] = env.Auth.Project
+		vars["CLOUDSDK_CORE_PROJECT"] = env.Auth.Project
+	}
+	if env.Auth.Location != "" {
+		vars["GOOGLE_CLOUD_LOCATION"] = env.Auth.Location
+	}
+	return vars
+}
+
+// gcloudTokenArgs returns the gcloud arguments that print an access token
+func gcloudTokenArgs(auth *AuthSettings) []string {
+	args := []string{"auth", "print-access-token"}
+	if auth.ADC {
+		args = []string{"auth", "application-default", "print-access-token"}
+	}
+	if auth.Account != "" {
+		args = append(args, auth.Account)
+	}
+	return append(args, "--quiet")
+}
+
+// resolveGCPToken runs gcloud and uses the printed access token as the API key
+func resolveGCPToken(env Environment) (Environment, error) {
+	output, err := runSecretCommand("gcloud", gcloudTokenArgs(env.Auth)...)
+	if errors.Is(err, exec.ErrNotFound) {
+		return env, fmt.Errorf("Google Cloud CLI 'gcloud' not found in PATH")
+	}
+	if err != nil {
+		return env, fmt.Errorf("gcloud access token request failed: %w", err)
+	}
+	token := strings.TrimSpace(string(output))
+	if token == "" || strings.ContainsAny(token, " \t\r\n") {
+		return env, fmt.Errorf("gcloud returned no usable access token")
+	}
+	logInfof("obtained a Google Cloud access token for '%s'", env.Name)
+	env.APIKey = token
+	return env, nil
+}
+
+// gcpTokenRejected reports whether a failed subprocess run of env was rejected for its access
+// token. codex keeps the token it started with, so requests after expiry fail with 401. When
+// stderr was watched (detector is set) the 401 itself decides; on a terminal, where it cannot
+// be, a failed session that outlived the token counts.
+func gcpTokenRejected(env Environment, exitCode int, elapsed time.Duration, detector *lineDetector) bool {
+	if !usesGCPAuth(env) || exitCode == 0 || exitCode == sessionTimeoutExitCode {
+		return false
+	}
+	if detector != nil {
+		return detector.Matched()
+	}
+	return elapsed >= gcpTokenLifetime
+}
+
+// relaunchWithFreshGCPToken mints a new access token for env and runs codex once more with the
+// same arguments, within what is left of opts.Timeout. It returns the environment with the new
+// token and the combined run time; when no relaunch is possible it explains why and returns the
+// original run's result.
+func relaunchWithFreshGCPToken(env Environment, args []string, opts subprocessOptions, exitCode int, elapsed time.Duration) (Environment, int, time.Duration, error) {
+	remaining, ok := remainingTimeout(opts.Timeout, elapsed)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "The Google Cloud access token for '%s' was rejected; not relaunching because the session limit is spent\n", env.Name)
+		return env, exitCode, elapsed, nil
+	}
+	refreshed, err := resolveGCPToken(env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "The Google Cloud access token for '%s' was rejected and could not be refreshed: %v\n", env.Name, err)
+		return env, exitCode, elapsed, nil
+	}
+	fmt.Fprintf(os.Stderr, "The Google Cloud access token for '%s' was rejected; relaunching once with a fresh token ('codex resume --last' continues an interactive conversation)\n", env.Name)
+	opts.Timeout = remaining
+	exitCode, retryElapsed, err := launchCodexSubprocess(refreshed, args, opts)
+	return refreshed, exitCode, elapsed + retryElapsed, err
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateGCPAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    *AuthSettings
+		wantErr bool
+	}{
+		{name: "defaults", auth: &AuthSettings{Type: "gcp"}},
+		{name: "project and location", auth: &AuthSettings{Type: "gcp", Project: "my-project-123", Location: "us-central1"}},
+		{name: "adc", auth: &AuthSettings{Type: "gcp", ADC: true, Location: "global"}},
+		{name: "bad project", auth: &AuthSettings{Type: "gcp", Project: "My_Project"}, wantErr: true},
+		{name: "bad location", auth: &AuthSettings{Type: "gcp", Location: "US Central"}, wantErr: true},
+		{name: "flag as account", auth: &AuthSettings{Type: "gcp", Account: "--impersonate"}, wantErr: true},
+		{name: "account with adc", auth: &AuthSettings{Type: "gcp", ADC: true, Account: "me@example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAuthSettings(tt.auth); (err != nil) != tt.wantErr {
+				t.Errorf("validateAuthSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveGCPToken(t *testing.T) {
+	oldRun := runSecretCommand
+	defer func() { runSecretCommand = oldRun }()
+
+	tests := []struct {
+		name     string
+		auth     *AuthSettings
+		wantArgs string
+	}{
+		{name: "user credentials", auth: &AuthSettings{Type: "gcp", Account: "me@example.com"}, wantArgs: "gcloud auth print-access-token me@example.com --quiet"},
+		{name: "adc", auth: &AuthSettings{Type: "gcp", ADC: true}, wantArgs: "gcloud auth application-default print-access-token --quiet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs string
+			runSecretCommand = func(name string, args ...string) ([]byte, error) {
+				gotArgs = strings.Join(append([]string{name}, args...), " ")
+				return []byte("ya29.token-value\n"), nil
+			}
+			env := Environment{Name: "vertex", URL: "https://us-central1-aiplatform.googleapis.com/v1", APIKey: "unused", Auth: tt.auth}
+			resolved, err := resolveEnvironmentSecrets(env)
+			if err != nil {
+				t.Fatalf("resolveEnvironmentSecrets() error = %v", err)
+			}
+			if gotArgs != tt.wantArgs {
+				t.Errorf("gcloud invoked as %q, want %q", gotArgs, tt.wantArgs)
+			}
+			if resolved.APIKey != "ya29.token-value" {
+				t.Errorf("APIKey = %q, want the access token", resolved.APIKey)
+			}
+		})
+	}
+
+	runSecretCommand = func(name string, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("exit status 1")
+	}
+	_, err := resolveEnvironmentSecrets(Environment{Name: "vertex", Auth: &AuthSettings{Type: "gcp"}})
+	if err == nil || errorCodeOf(err) != codeAuthFailed {
+		t.Errorf("error = %v, want %s", err, codeAuthFailed)
+	}
+}
+
+func TestGCPEnvVars(t *testing.T) {
+	env := Environment{Name: "vertex", Auth: &AuthSettings{Type: "gcp", Project: "my-project-123", Location: "europe-west4"}}
+	vars := providerEnvVars(env)
+	for key, want := range map[string]string{
+		"GOOGLE_CLOUD_PROJECT":  "my-project-123",
+		"CLOUDSDK_CORE_PROJECT": "my-project-123",
+		"GOOGLE_CLOUD_LOCATION": "europe-west4",
+	} {
+		if vars[key] != want {
+			t.Errorf("%s = %q, want %q", key, vars[key], want)
+		}
+	}
+}
+
+func TestAuthRejectedPattern(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"unexpected status 401 Unauthorized: Request had invalid authentication credentials.", true},
+		{"Error: status code: 401", true},
+		{`{"error":{"code":401,"status":"UNAUTHENTICATED"}}`, true},
+		{"Unauthorized (401)", true},
+		{"429 Too Many Requests", false},
+		{"wrote 401 lines to main.go", false},
+		{"403 Forbidden: permission denied", false},
+	}
+	for _, tt := range tests {
+		if got := authRejectedPattern.MatchString(tt.line); got != tt.want {
+			t.Errorf("authRejectedPattern.MatchString(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestGCPTokenRejected(t *testing.T) {
+	gcpEnv := Environment{Name: "vertex", Auth: &AuthSettings{Type: "gcp"}}
+	rejected := newLineDetector(authRejectedPattern)
+	rejected.Write([]byte("unexpected status 401 Unauthorized\n"))
+	quiet := newLineDetector(authRejectedPattern)
+	tests := []struct {
+		name     string
+		env      Environment
+		exitCode int
+		elapsed  time.Duration
+		detector *lineDetector
+		want     bool
+	}{
+		{name: "401 seen", env: gcpEnv, exitCode: 1, elapsed: time.Minute, detector: rejected, want: true},
+		{name: "watched without 401", env: gcpEnv, exitCode: 1, elapsed: 90 * time.Minute, detector: quiet},
+		{name: "terminal, long failed session", env: gcpEnv, exitCode: 1, elapsed: 90 * time.Minute, want: true},
+		{name: "terminal, short failed session", env: gcpEnv, exitCode: 1, elapsed: 10 * time.Minute},
+		{name: "clean exit", env: gcpEnv, elapsed: 90 * time.Minute, detector: rejected},
+		{name: "session limit", env: gcpEnv, exitCode: sessionTimeoutExitCode, elapsed: 90 * time.Minute},
+		{name: "other auth", env: Environment{Name: "openai"}, exitCode: 1, detector: rejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gcpTokenRejected(tt.env, tt.exitCode, tt.elapsed, tt.detector); got != tt.want {
+				t.Errorf("gcpTokenRejected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubprocessLaunchRefreshesRejectedGCPToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	// Fake codex that rejects the first token with a 401 and logs every token it is started
+	// with (skipping the version check)
+	binDir := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\n[ \"$1\" = exec ] || exit 0\necho \"$OPENAI_API_KEY\" >> \"" + calls + "\"\n" +
+		"if [ \"$OPENAI_API_KEY\" = ya29.first ]; then echo 'unexpected status 401 Unauthorized' >&2; exit 1; fi\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tokens := []string{"ya29.first", "ya29.second"}
+	oldRun := runSecretCommand
+	defer func() { runSecretCommand = oldRun }()
+	runSecretCommand = func(name string, args ...string) ([]byte, error) {
+		if name != "gcloud" || len(tokens) == 0 {
+			return nil, fmt.Errorf("unexpected command %s %v", name, args)
+		}
+		token := tokens[0]
+		tokens = tokens[1:]
+		return []byte(token + "\n"), nil
+	}
+	originalTappable := stderrTappable
+	stderrTappable = func() bool { return true }
+	defer func() { stderrTappable = originalTappable }()
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	config := Config{
+		Environments: []Environment{{Name: "vertex", URL: "https://us-central1-aiplatform.googleapis.com/v1", Auth: &AuthSettings{Type: "gcp"}}},
+		Settings:     &ConfigSettings{LaunchMode: launchModeSubprocess},
+	}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := runDefaultWithOptions("vertex", []string{"exec", "hi"}, launchOptions{}); err != nil {
+		t.Fatalf("expected success after refreshing the token, got %v", err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("failed to read calls: %v", err)
+	}
+	if got := string(data); got != "ya29.first\nya29.second\n" {
+		t.Errorf("codex started with tokens %q, want the first then the refreshed one", got)
+	}
+}
@@ -6,4 +6,4 @@ toolchain go1.24.5
 
 require golang.org/x/term v0.33.0
 
-require golang.org/x/sys v0.34.0 // indirect
+require golang.org/x/sys v0.34.0
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// headerProviderID is the codex model provider cde defines when an environment needs extra
+// headers; codex's built-in openai provider cannot carry custom headers
+const headerProviderID = "cde"
+
+// headerEnvPrefix prefixes the variables that carry header values to codex, keeping
+// values (often credentials) out of the process arguments
+const headerEnvPrefix = "CDE_HEADER_"
+
+// Wire APIs the headers provider can speak; codex calls them the same
+const (
+	wireAPIResponses = "responses"
+	wireAPIChat      = "chat"
+)
+
+// headerNamePattern matches an HTTP header field name (RFC 7230 token)
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// validateEnvironmentHeaders checks env's headers. Azure environments cannot have them: the
+// provider that carries them would replace codex's Azure provider and its api-version.
+func validateEnvironmentHeaders(env Environment) error {
+	if len(env.Headers) > 0 && isAzureEnvironment(env) {
+		return fmt.Errorf("headers are not supported for Azure environments")
+	}
+	return validateHeaders(env.Headers)
+}
+
+// validateWireAPI checks wire_api; empty means responses
+func validateWireAPI(wireAPI string) error {
+	switch wireAPI {
+	case "", wireAPIResponses, wireAPIChat:
+		return nil
+	}
+	return fmt.Errorf("unknown wire_api '%s' (use %s or %s)", wireAPI, wireAPIResponses, wireAPIChat)
+}
+
+// validateHeaders checks header names and values and that their variable names stay distinct
+func validateHeaders(headers map[string]string) error {
+	seen := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid header name '%s'", name)
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("header '%s' value must not contain line breaks", name)
+		}
+		envName := headerEnvVarName(name)
+		if other, exists := seen[envName]; exists {
+			return fmt.Errorf("headers '%s' and '%s' differ only in case or punctuation", other, name)
+		}
+		seen[envName] = name
+	}
+	return nil
+}
+
+// headerEnvVarName returns the variable carrying a header value, e.g. X-Org-Id -> CDE_HEADER_X_ORG_ID
+func headerEnvVarName(name string) string {
+	var b strings.Builder
+	b.WriteString(headerEnvPrefix)
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// headerEnvVars returns the header value variables for the codex child
+func headerEnvVars(env Environment) map[string]string {
+	if len(env.Headers) == 0 {
+		return nil
+	}
+	vars := make(map[string]string, len(env.Headers))
+	for name, value := range env.Headers {
+		vars[headerEnvVarName(name)] = value
+	}
+	return vars
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	return strconv.Quote(s)
+}
+
+// applyRequestHeaders prepends '-c' overrides defining a codex model provider that sends the
+// environment's headers. Values travel in CDE_HEADER_* variables via env_http_headers.
+// Keys already set on the command line or in model_params are left alone, and Azure
+// environments (which validation keeps free of headers) keep their own provider.
+func applyRequestHeaders(env Environment, codexArgs []string) []string {
+	if len(env.Headers) == 0 || isAzureEnvironment(env) {
+		return codexArgs
+	}
+	wireAPI := env.WireAPI
+	if wireAPI == "" {
+		wireAPI = wireAPIResponses
+	}
+
+	names := make([]string, 0, len(env.Headers))
+	for name := range env.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	mappings := make([]string, 0, len(names))
+	for _, name := range names {
+		mappings = append(mappings, fmt.Sprintf("%s=%s", tomlString(name), tomlString(headerEnvVarName(name))))
+	}
+
+	prefix := "model_providers." + headerProviderID + "."
+	settings := []struct{ key, value string }{
+		{"model_provider", tomlString(headerProviderID)},
+		{prefix + "name", tomlString(headerProviderID)},
+		{prefix + "base_url", tomlString(env.URL)},
+		{prefix + "env_key", tomlString("OPENAI_API_KEY")},
+		{prefix + "wire_api", tomlString(wireAPI)},
+		{prefix + "env_http_headers", "{" + strings.Join(mappings, ",") + "}"},
+	}
+
+	userKeys := configOverrideKeys(codexArgs)
+	overrides := make([]string, 0, len(settings)*2)
+	for _, setting := range settings {
+		if userKeys[setting.key] {
+			continue
+		}
+		if _, exists := env.ModelParams[setting.key]; exists {
+			continue
+		}
+		overrides = append(overrides, "-c", setting.key+"="+setting.value)
+	}
+	return append(overrides, codexArgs...)
+}
+
+// setRequestHeaders adds the environment's headers to a request cde makes itself
+func setRequestHeaders(req *http.Request, env Environment) {
+	for name, value := range env.Headers {
+		req.Header.Set(name, value)
+	}
+}
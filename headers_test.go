@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{name: "none", headers: nil},
+		{name: "valid", headers: map[string]string{"X-Org-Id": "org-1", "X-Gateway-Auth": "Bearer abc"}},
+		{name: "space in name", headers: map[string]string{"X Org": "org-1"}, wantErr: true},
+		{name: "colon in name", headers: map[string]string{"X-Org:": "org-1"}, wantErr: true},
+		{name: "header injection", headers: map[string]string{"X-Org-Id": "org-1\r\nX-Admin: 1"}, wantErr: true},
+		{name: "variable collision", headers: map[string]string{"X-Org-Id": "a", "x_org_id": "b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHeaders(tt.headers); (err != nil) != tt.wantErr {
+				t.Errorf("validateHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyRequestHeaders(t *testing.T) {
+	env := Environment{
+		Name:    "gw",
+		URL:     "https://gw.example.com/v1",
+		APIKey:  "sk-gw-123456",
+		Headers: map[string]string{"X-Org-Id": "org-1", "X-Team": "platform"},
+	}
+
+	got := applyRequestHeaders(env, []string{"-m", "gpt-5"})
+	want := []string{
+		"-c", `model_provider="cde"`,
+		"-c", `model_providers.cde.name="cde"`,
+		"-c", `model_providers.cde.base_url="https://gw.example.com/v1"`,
+		"-c", `model_providers.cde.env_key="OPENAI_API_KEY"`,
+		"-c", `model_providers.cde.wire_api="responses"`,
+		"-c", `model_providers.cde.env_http_headers={"X-Org-Id"="CDE_HEADER_X_ORG_ID","X-Team"="CDE_HEADER_X_TEAM"}`,
+		"-m", "gpt-5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyRequestHeaders() =\n%v\nwant\n%v", got, want)
+	}
+
+	// User and model_params overrides of the same keys win
+	env.ModelParams = map[string]string{"model_providers.cde.wire_api": `"chat"`}
+	got = applyRequestHeaders(env, []string{"-c", `model_provider="other"`})
+	for _, arg := range got {
+		if arg == `model_provider="cde"` || arg == `model_providers.cde.wire_api="responses"` {
+			t.Errorf("applyRequestHeaders() overrode a user setting: %v", got)
+		}
+	}
+
+	if args := applyRequestHeaders(Environment{Name: "plain"}, []string{"-m", "o3"}); !reflect.DeepEqual(args, []string{"-m", "o3"}) {
+		t.Errorf("environment without headers changed args: %v", args)
+	}
+
+	// wire_api selects the chat API for gateways without the Responses API
+	env.ModelParams = nil
+	env.WireAPI = wireAPIChat
+	got = applyRequestHeaders(env, nil)
+	if !reflect.DeepEqual(got[8:10], []string{"-c", `model_providers.cde.wire_api="chat"`}) {
+		t.Errorf("applyRequestHeaders() with wire_api chat = %v", got)
+	}
+
+	// Azure environments keep codex's Azure provider
+	azure := Environment{Name: "az", Provider: "azure", Headers: map[string]string{"X-Team": "platform"}}
+	if args := applyRequestHeaders(azure, []string{"-m", "o3"}); !reflect.DeepEqual(args, []string{"-m", "o3"}) {
+		t.Errorf("Azure environment provider replaced: %v", args)
+	}
+}
+
+func TestValidateEnvironmentHeadersAndWireAPI(t *testing.T) {
+	base := Environment{Name: "gw", URL: "https://gw.example.com/v1", APIKey: "sk-gw-123456", Headers: map[string]string{"X-Org-Id": "org-1"}}
+	tests := []struct {
+		name    string
+		modify  func(env *Environment)
+		wantErr bool
+	}{
+		{name: "default wire_api", modify: func(env *Environment) {}},
+		{name: "chat", modify: func(env *Environment) { env.WireAPI = "chat" }},
+		{name: "responses", modify: func(env *Environment) { env.WireAPI = "responses" }},
+		{name: "unknown wire_api", modify: func(env *Environment) { env.WireAPI = "completions" }, wantErr: true},
+		{name: "headers on azure", modify: func(env *Environment) { env.Provider = "azure" }, wantErr: true},
+		{name: "azure without headers", modify: func(env *Environment) { env.Provider = "azure"; env.Headers = nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := base
+			tt.modify(&env)
+			if err := validateEnvironment(env); (err != nil) != tt.wantErr {
+				t.Errorf("validateEnvironment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHeadersReachCodexAndProbes(t *testing.T) {
+	env := Environment{
+		Name:    "gw",
+		URL:     "https://gw.example.com/v1",
+		APIKey:  "sk-gw-123456",
+		Headers: map[string]string{"X-Org-Id": "org-1"},
+	}
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		t.Fatalf("prepareEnvironment() error = %v", err)
+	}
+	if !contains(envVars, "CDE_HEADER_X_ORG_ID=org-1") {
+		t.Errorf("prepareEnvironment() missing header variable: %v", envVars)
+	}
+	if masked := maskedExport(exportPair{"CDE_HEADER_X_ORG_ID", "org-1-secret-value"}); masked.Value == "org-1-secret-value" {
+		t.Error("maskedExport() showed a header value")
+	}
+
+	var gotOrg string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotOrg = r.Header.Get("X-Org-Id")
+	}))
+	defer server.Close()
+
+	env.URL = server.URL
+	results := probeEnvironments(context.Background(), []Environment{env}, time.Second)
+	if !results[0].healthy() || gotOrg != "org-1" {
+		t.Errorf("probe err = %v, X-Org-Id = %q", results[0].Err, gotOrg)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Headless policies: what a non-interactive launch without an explicit environment does
+const (
+	headlessPolicyFirst   = "first"   // Use the first configured environment (default)
+	headlessPolicyDefault = "default" // Use settings.default_env
+	headlessPolicyFail    = "fail"    // Refuse to launch
+)
+
+// validateHeadlessPolicy checks settings.headless_policy and the default_env it may need
+func validateHeadlessPolicy(settings *ConfigSettings) error {
+	switch settings.HeadlessPolicy {
+	case "", headlessPolicyFirst, headlessPolicyFail:
+	case headlessPolicyDefault:
+		if settings.DefaultEnv == "" {
+			return fmt.Errorf("headless_policy \"default\" requires settings.default_env")
+		}
+	default:
+		return fmt.Errorf("unknown headless_policy '%s' (use first, default or fail)", settings.HeadlessPolicy)
+	}
+	if settings.DefaultEnv != "" {
+		if err := validateName(settings.DefaultEnv); err != nil {
+			return fmt.Errorf("invalid default_env: %w", err)
+		}
+	}
+	return nil
+}
+
+// headlessPolicy returns the configured policy, defaulting to "first"
+func headlessPolicy(config Config) string {
+	if config.Settings == nil || config.Settings.HeadlessPolicy == "" {
+		return headlessPolicyFirst
+	}
+	return config.Settings.HeadlessPolicy
+}
+
+// stdinPiped reports whether stdin is a pipe or file rather than a terminal. Launches never
+// read piped stdin for menus or prompts; it belongs to codex (cat prompt.txt | cde -- exec).
+func stdinPiped() bool {
+	return !uiTerminal.IsTerminal()
+}
+
+// selectHeadlessEnvironment applies the headless policy and returns the environment with the
+// reason it was chosen. requireEnv (--require-env) forces the "fail" policy.
+func selectHeadlessEnvironment(config Config, requireEnv bool) (Environment, string, error) {
+	policy := headlessPolicy(config)
+	if requireEnv {
+		policy = headlessPolicyFail
+	}
+
+	switch policy {
+	case headlessPolicyFail:
+		return Environment{}, "", fmt.Errorf("no environment specified for a non-interactive launch; pass -e <name> or set CDE_ENV")
+	case headlessPolicyDefault:
+		index, exists := findEnvironmentByName(config, config.Settings.DefaultEnv)
+		if !exists {
+			return Environment{}, "", fmt.Errorf("settings.default_env '%s' not found", config.Settings.DefaultEnv)
+		}
+		return config.Environments[index], "settings.default_env", nil
+	}
+
+	switch len(config.Environments) {
+	case 0:
+		return Environment{}, "", fmt.Errorf("no environments available for headless mode")
+	case 1:
+		return config.Environments[0], "only configured environment", nil
+	}
+	fmt.Printf("Headless mode: using first environment '%s'\n", config.Environments[0].Name)
+	return config.Environments[0], "headless_policy=first", nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateHeadlessPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings ConfigSettings
+		wantErr  bool
+	}{
+		{name: "unset", settings: ConfigSettings{}},
+		{name: "first", settings: ConfigSettings{HeadlessPolicy: "first"}},
+		{name: "fail", settings: ConfigSettings{HeadlessPolicy: "fail"}},
+		{name: "default with env", settings: ConfigSettings{HeadlessPolicy: "default", DefaultEnv: "staging"}},
+		{name: "default without env", settings: ConfigSettings{HeadlessPolicy: "default"}, wantErr: true},
+		{name: "unknown policy", settings: ConfigSettings{HeadlessPolicy: "random"}, wantErr: true},
+		{name: "invalid default env", settings: ConfigSettings{DefaultEnv: "bad name!"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHeadlessPolicy(&tt.settings); (err != nil) != tt.wantErr {
+				t.Errorf("validateHeadlessPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectHeadlessEnvironment(t *testing.T) {
+	environments := []Environment{{Name: "prod"}, {Name: "staging"}}
+
+	tests := []struct {
+		name       string
+		config     Config
+		requireEnv bool
+		wantEnv    string
+		wantSource string
+		wantErr    string
+	}{
+		{name: "first by default", config: Config{Environments: environments}, wantEnv: "prod", wantSource: "headless_policy=first"},
+		{name: "only environment", config: Config{Environments: environments[1:]}, wantEnv: "staging", wantSource: "only configured environment"},
+		{
+			name:       "default env",
+			config:     Config{Environments: environments, Settings: &ConfigSettings{HeadlessPolicy: "default", DefaultEnv: "staging"}},
+			wantEnv:    "staging",
+			wantSource: "settings.default_env",
+		},
+		{
+			name:    "default env missing",
+			config:  Config{Environments: environments, Settings: &ConfigSettings{HeadlessPolicy: "default", DefaultEnv: "dev"}},
+			wantErr: "default_env 'dev' not found",
+		},
+		{
+			name:    "fail policy",
+			config:  Config{Environments: environments[:1], Settings: &ConfigSettings{HeadlessPolicy: "fail"}},
+			wantErr: "pass -e <name>",
+		},
+		{name: "require env flag", config: Config{Environments: environments}, requireEnv: true, wantErr: "pass -e <name>"},
+		{name: "no environments", config: Config{}, wantErr: "no environments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, source, err := selectHeadlessEnvironment(tt.config, tt.requireEnv)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectHeadlessEnvironment() error = %v", err)
+			}
+			if env.Name != tt.wantEnv || source != tt.wantSource {
+				t.Errorf("got %s via %q, want %s via %q", env.Name, source, tt.wantEnv, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestParseRequireEnvFlag(t *testing.T) {
+	result := parseArguments([]string{"--require-env", "-e", "prod", "--", "exec", "task"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if opts := launchOptionsFromFlags(result); !opts.RequireEnv {
+		t.Error("--require-env should set RequireEnv")
+	}
+	if result.CCEFlags["env"] != "prod" || len(result.ClaudeArgs) != 2 {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+}
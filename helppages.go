@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// flagDoc describes one flag or argument on a help page
+type flagDoc struct {
+	Flag string
+	Desc string
+}
+
+// commandDoc describes a subcommand for 'cde help', 'cde help <command>', --help-all and the man page
+type commandDoc struct {
+	Name        string
+	Usage       string // Arguments after "cde"
+	Short       string // Usage in the command overview when Usage is too long
+	Summary     string // One line for the command overview
+	Flags       []flagDoc
+	Examples    []string
+	LaunchFlags bool // Also accepts the launch options
+}
+
+// commandDocs are the subcommands in overview order
+var commandDocs = []commandDoc{
+	{Name: "list", Usage: "list [-v|--verbose] [--sort name|recent|url] [--table] [-q|--quiet] [--json]", Short: "list [-v] [--table]", Summary: "List all configured environments",
+		Flags: []flagDoc{
+			{"-v, --verbose", "Show model aliases, deployment maps, auto flags and other details"},
+			{"--sort name|recent|url", "Sort by name, most recently used or URL"},
+			{"--table", "Table output with one environment per line"},
+			{"-q, --quiet", "Print environment names only"},
+			{"--json", "Print environments as a JSON array with masked keys"},
+		},
+		Examples: []string{"cde list --table --sort recent", "cde list -q", "cde list --json"}},
+	{Name: "search", Usage: "search <pattern> [-q] [--table]", Summary: "Search by name, URL, model, provider or env var name (exit code 1 when nothing matches)",
+		Flags:    []flagDoc{{"-q, --quiet", "Print environment names only"}, {"--table", "Table output"}},
+		Examples: []string{"cde search azure"}},
+	{Name: "add", Usage: "add [--preset <p>] [--key-from-clipboard [--clear-clipboard]] [--api-key-stdin --name <n> --url <u> [--model <m>] [--env-var KEY=VALUE]...]", Short: "add [--preset <p>]", Summary: "Add an environment (optional model; presets: openai, azure, openrouter, local)",
+		Flags: []flagDoc{
+			{"--preset <p>", "Use a provider preset (openai, azure, openrouter, local)"},
+			{"--key-from-clipboard", "Read the API key from the clipboard"},
+			{"--clear-clipboard", "Clear the clipboard after reading"},
+			{"--api-key-stdin", "Read the key from stdin and add without prompts (requires --name and --url)"},
+			{"--name <n>, --url <u>", "Environment name and API URL"},
+			{"--model <m>", "Default model"},
+			{"--env-var KEY=VALUE", "Extra environment variable (repeatable)"},
+		},
+		Examples: []string{"cde add --preset azure", "echo \"$KEY\" | cde add --api-key-stdin --name ci --url https://api.openai.com/v1"}},
+	{Name: "remove", Usage: "remove <name> [-f|--force] [--all] [--no-backup]", Short: "remove <name>", Summary: "Remove an environment (creates a pre-remove-* backup first)",
+		Flags: []flagDoc{
+			{"-f, --force", "Skip confirmation"},
+			{"--all", "Remove every environment (requires --force)"},
+			{"--no-backup", "Do not create a backup"},
+		},
+		Examples: []string{"cde remove staging", "cde remove --all --force"}},
+	{Name: "restore", Usage: "restore <name>", Summary: "Restore a removed environment from the trash (kept 30 days by default)",
+		Examples: []string{"cde restore staging"}},
+	{Name: "backup", Usage: "backup list|restore <id>|prune", Summary: "Manage config backups (retention in settings.backup)",
+		Flags: []flagDoc{
+			{"list", "List config backups (newest first)"},
+			{"restore <id>", "Restore config.json from a backup"},
+			{"prune", "Apply the retention policy now"},
+		},
+		Examples: []string{"cde backup list", "cde backup restore 20250101-120000"}},
+	{Name: "wrap", Usage: "wrap [options] -- <command> [args...]", Short: "wrap -e <env> -- <cmd>", Summary: "Run any command (aider, llm, scripts) with the environment's variables, without codex argument handling",
+		Examples: []string{"cde wrap -e prod -- aider", "cde wrap -e dev -- llm \"hello\""}, LaunchFlags: true},
+	{Name: "compare", Usage: "compare -e a -e b [--out DIR] -- exec \"<prompt>\"", Short: "compare -e a -e b -- exec \"<prompt>\"", Summary: "Run the same codex exec against several environments and show outputs and timings side by side",
+		Flags: []flagDoc{
+			{"-e, --env <name>", "Environment to compare (at least two, repeatable)"},
+			{"--out DIR", "Write each environment's output to DIR/<env>.txt"},
+		},
+		Examples: []string{"cde compare -e prodA -e prodB -- exec \"explain main.go\""}},
+	{Name: "replay", Usage: "replay <file> [--dry-run] [--json]", Short: "replay <file>", Summary: "Relaunch codex with the environment, URL and arguments recorded by --snapshot",
+		Flags:    []flagDoc{{"--dry-run", "Preview only"}, {"--json", "Print the preview as JSON"}},
+		Examples: []string{"cde replay launch.json --dry-run"}},
+	{Name: "profile", Usage: "profile list|use <name>", Summary: "List or switch config profiles (config.<name>.json; CDE_PROFILE wins, default is config.json)",
+		Examples: []string{"cde profile use work"}},
+	{Name: "sync", Usage: "sync pull|push [--dry-run] [--theirs|--force]", Short: "sync pull|push", Summary: "Sync with the team's shared environment list (keys excluded)",
+		Flags:    []flagDoc{{"--dry-run", "Preview changes only"}, {"--theirs", "Take the remote version on conflicts (pull)"}, {"--force", "Overwrite the remote (push)"}},
+		Examples: []string{"cde sync pull --dry-run"}},
+	{Name: "clone", Usage: "clone <src> <new> [--url <u>] [--model <m>] [--env-var KEY=VALUE]...", Short: "clone <src> <new>", Summary: "Copy an environment (prompts when --url/--model/--env-var are not given)",
+		Flags:    []flagDoc{{"--env-var KEY=VALUE", "Set (KEY=VALUE) or remove (KEY=) an env var of the copy (repeatable)"}},
+		Examples: []string{"cde clone prod prod-eu --url https://eu.example.com/v1", "cde clone prod prod-slow --env-var OPENAI_TIMEOUT=120"}},
+	{Name: "dedupe", Usage: "dedupe [--dry-run]", Summary: "Find and merge environments with the same URL and key",
+		Flags: []flagDoc{{"--dry-run", "Only list the duplicate groups"}}},
+	{Name: "pin", Usage: "pin <env>|--list|--unpin", Summary: "Pin the current directory (git repository root) to an environment that a plain cde then selects",
+		Flags:    []flagDoc{{"--list", "List all pins"}, {"--unpin", "Unpin the current directory"}},
+		Examples: []string{"cde pin staging"}},
+	{Name: "rotate-key", Usage: "rotate-key <name> [key] [--expires <date>]", Summary: "Swap a named key (backup by default) with the current api_key",
+		Flags:    []flagDoc{{"--expires <date>", "Expiry date of the new key (YYYY-MM-DD)"}},
+		Examples: []string{"cde rotate-key prod backup --expires 2026-01-01"}},
+	{Name: "show-key", Usage: "show-key <name> [--key <k>]", Summary: "Show the full API key after confirmation",
+		Flags: []flagDoc{{"--key <k>", "Show a named key instead of api_key"}}},
+	{Name: "preset", Usage: "preset <name> [options] [-- args...]", Summary: "Launch with a named argument set from settings.presets",
+		Examples: []string{"cde preset review -e prod"}, LaunchFlags: true},
+	{Name: "env", Usage: "env print <name> [--shell bash|zsh|fish|powershell] [--no-secrets]", Short: "env print <name>", Summary: "Print environment variable export statements",
+		Flags:    []flagDoc{{"--shell <shell>", "Shell syntax of the exports"}, {"--no-secrets", "Mask secrets"}},
+		Examples: []string{"eval \"$(cde env print prod)\""}},
+	{Name: "audit", Usage: "audit show [--last N]", Summary: "Show recent launch audit records (requires settings.audit.enabled)",
+		Flags: []flagDoc{{"--last N", "Number of records to show (default 20)"}}},
+	{Name: "models", Usage: "models [-e <name>]", Summary: "List the models from an environment's /models endpoint, then launch one or save it as the default",
+		Flags: []flagDoc{{"-e, --env <name>", "Environment to query"}}},
+	{Name: "config", Usage: "config validate [file]|schema|repair|diff [a] [b]|edit", Short: "config <action>", Summary: "Validate, repair, compare or edit the config file",
+		Flags: []flagDoc{
+			{"validate [file]", "Check the config against the JSON Schema and field validators and list every problem (with path and line)"},
+			{"schema", "Print the config file's JSON Schema (for editor completion and validation)"},
+			{"repair", "Recovery wizard for a corrupt config: shows salvageable environments and backups, restores after confirmation"},
+			{"diff [a] [b]", "Compare two configs field by field (latest backup against the current config by default; arguments are files or backup IDs)"},
+			{"edit", "Edit the config in $VISUAL/$EDITOR and validate it on save"},
+		},
+		Examples: []string{"cde config validate", "cde config diff 20250101-120000"}},
+	{Name: "import-from", Usage: "import-from codex|claude-code-env [--dry-run] [--no-backup]", Short: "import-from codex|claude-code-env", Summary: "Import environments from codex/claude-code-env configs and environment variables (deduplicated by URL)",
+		Flags: []flagDoc{{"--dry-run", "Preview only"}, {"--no-backup", "Do not create a pre-import-* backup"}}},
+	{Name: "export-codex-profiles", Usage: "export-codex-profiles [--dry-run]", Summary: "Write environments as profiles in ~/.codex/config.toml (only the cde-managed block is updated)",
+		Flags: []flagDoc{{"--dry-run", "Show the diff without writing"}}},
+	{Name: "serve-metrics", Usage: "serve-metrics [--listen addr]", Summary: "Expose launch metrics in Prometheus format on /metrics (requires settings.metrics.enabled)",
+		Flags: []flagDoc{{"--listen addr", "Listen address (default 127.0.0.1:9464)"}}},
+	{Name: "codex-version", Usage: "codex-version", Summary: "Show the installed codex version and known incompatibilities (disable the pre-launch check with settings.codex_version_check)"},
+	{Name: "doctor", Usage: "doctor [--selftest]", Summary: "Check the config and the codex install",
+		Flags:    []flagDoc{{"--selftest", "Run launches against a fake codex: model injection, env export, exit status, auto flags"}},
+		Examples: []string{"cde doctor --selftest"}},
+	{Name: "update-check", Usage: "update-check", Summary: "Check for a newer release (skipped when CDE_OFFLINE=1)"},
+	{Name: "self-update", Usage: "self-update", Summary: "Download and verify the latest release, then atomically replace the current binary"},
+	{Name: "which", Usage: "which [auto] [options] [-- args]", Summary: "Show the environment that would be selected, the final codex arguments and environment variables (no launch, secrets masked)",
+		Examples: []string{"cde which -e prod -- exec hi"}, LaunchFlags: true},
+	{Name: "auto", Usage: "auto [options] [-- args]", Summary: "Auto-approve with a sandbox (default -a never --sandbox workspace-write, configurable with auto_flags)",
+		Examples: []string{"cde auto -e dev -- mcp"}, LaunchFlags: true},
+	{Name: "help", Usage: "help [command] [--help-all] [--man]", Short: "help [command]", Summary: "Show help (cde <command> --help shows one command)",
+		Flags:    []flagDoc{{"--help-all", "Print the overview followed by every command's help"}, {"--man", "Print the man page (roff)"}},
+		Examples: []string{"cde help add", "cde help --man > cde.1"}},
+}
+
+// launchOptionDocs are the options accepted by a launch, 'which', 'auto', 'preset' and 'wrap'
+var launchOptionDocs = []flagDoc{
+	{"-e, --env <name>", "Select the environment (CDE_ENV when omitted; CDE_MODEL overrides the environment's default model)"},
+	{"--key <name>", "Launch with a named key from the environment's keys"},
+	{"--fastest", "Probe every environment concurrently and pick the lowest-latency healthy endpoint"},
+	{"--failover a,b,c", "Health-check in order and use the first healthy environment"},
+	{"--dry-run", "Select, validate and prepare arguments, then print the final command and environment changes without launching codex"},
+	{"--override", "Launch despite an environment budget with budget.action=block"},
+	{"--yes", "Skip the launch confirmation of confirm_before_use environments"},
+	{"--timeout <duration>", "Stop codex after a session timeout such as 30m and exit with status 124 (runs as a child process)"},
+	{"--ignore-rate-limit", "Launch despite the environment's rate_limit"},
+	{"--keep-existing", "Keep the shell's value when OPENAI_* and similar variables conflict with the environment"},
+	{"--require-env", "Fail when a non-interactive run names no environment with -e or CDE_ENV (or set settings.headless_policy)"},
+	{"--no-arg-check", "Skip argument checks for this launch (not allowed when settings.validation.arg_policy is strict)"},
+	{"--json", "Print --dry-run/which results as JSON; errors go to stderr as JSON with an error code"},
+	{"--no-color", "Disable colored output (NO_COLOR is also honored)"},
+	{"--var name=value", "Set a {name} placeholder in url/env_vars (repeatable; missing values are prompted for in a terminal)"},
+	{"--snapshot <file>", "Write a reproducible manifest before launch (version, environment, URL, model, arguments; no secrets)"},
+	{"--config <path>", "Use this config file instead of the current profile (before launch arguments, or after a management subcommand)"},
+	{"--verbose, -vv", "Debug/trace logging to stderr (or set CDE_LOG_LEVEL=error|warn|info|debug|trace; CDE_LOG_FILE writes to a file)"},
+	{"--lang <code>", "Interface language (en, zh-CN; defaults to settings.language or LANG)"},
+	{"-h, --help", "Show help"},
+}
+
+// findCommandDoc returns the help page for a subcommand
+func findCommandDoc(name string) (commandDoc, bool) {
+	for _, doc := range commandDocs {
+		if doc.Name == name {
+			return doc, true
+		}
+	}
+	return commandDoc{}, false
+}
+
+// helpColumnWidth is the width of the name column on help pages
+const helpColumnWidth = 20
+
+// helpTextWidth returns the terminal width of text, counting CJK characters as two columns
+func helpTextWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		width++
+		if r >= 0x1100 {
+			width++
+		}
+	}
+	return width
+}
+
+// printHelpEntries prints flag or command rows with the translated description aligned after the name
+func printHelpEntries(w io.Writer, entries []flagDoc) {
+	for _, entry := range entries {
+		if width := helpTextWidth(entry.Flag); width < helpColumnWidth {
+			fmt.Fprintf(w, "  %s%s%s\n", entry.Flag, strings.Repeat(" ", helpColumnWidth-width), tr(entry.Desc))
+		} else {
+			fmt.Fprintf(w, "  %s  %s\n", entry.Flag, tr(entry.Desc))
+		}
+	}
+}
+
+// commandOverview lists every subcommand with its usage and summary for 'cde help'
+func commandOverview() []flagDoc {
+	entries := make([]flagDoc, len(commandDocs))
+	for i, doc := range commandDocs {
+		usage := doc.Usage
+		if doc.Short != "" {
+			usage = doc.Short
+		}
+		entries[i] = flagDoc{usage, doc.Summary}
+	}
+	return entries
+}
+
+// writeCommandHelp prints the help page of one subcommand
+func writeCommandHelp(w io.Writer, doc commandDoc) {
+	fmt.Fprintf(w, tr("Usage: cde %s")+"\n\n", doc.Usage)
+	fmt.Fprintf(w, "%s\n", tr(doc.Summary))
+	if len(doc.Flags) > 0 {
+		fmt.Fprintln(w, "\n"+tr("Options:"))
+		printHelpEntries(w, doc.Flags)
+	}
+	if doc.LaunchFlags {
+		fmt.Fprintln(w, "\n"+tr("Launch options:"))
+		printHelpEntries(w, launchOptionDocs)
+	}
+	if len(doc.Examples) > 0 {
+		fmt.Fprintln(w, "\n"+tr("Examples:"))
+		for _, example := range doc.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+// runHelp shows the overview, one command's page (topic), every page (all) or the man page
+func runHelp(topic string, all, man bool) error {
+	switch {
+	case man:
+		writeManPage(os.Stdout)
+	case all:
+		showHelp()
+		for _, doc := range commandDocs {
+			fmt.Printf("\n%s\n\n", strings.Repeat("─", 40))
+			writeCommandHelp(os.Stdout, doc)
+		}
+	case topic != "":
+		doc, ok := findCommandDoc(topic)
+		if !ok {
+			return fmt.Errorf("unknown help topic '%s' (run 'cde help' for the list of commands)", topic)
+		}
+		writeCommandHelp(os.Stdout, doc)
+	default:
+		showHelp()
+	}
+	return nil
+}
+
+// roffEscape escapes text for a man page line
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// writeManPage writes a cde(1) man page in roff generated from the command definitions
+func writeManPage(w io.Writer) {
+	fmt.Fprintf(w, ".TH CDE 1 \"\" \"cde %s\" \"User Commands\"\n", roffEscape(version))
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `cde \- launch codex with a selected API environment`)
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B cde\n[command] [options] [\\-\\- %s\\-args...]\n", roffEscape(targetCommand))
+	fmt.Fprintln(w, ".SH OPTIONS")
+	for _, option := range launchOptionDocs {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roffEscape(option.Flag), roffEscape(tr(option.Desc)))
+	}
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, doc := range commandDocs {
+		fmt.Fprintf(w, ".SS %s\n", roffEscape(doc.Name))
+		fmt.Fprintf(w, ".B cde %s\n.PP\n%s\n", roffEscape(doc.Usage), roffEscape(tr(doc.Summary)))
+		for _, flag := range doc.Flags {
+			fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roffEscape(flag.Flag), roffEscape(tr(flag.Desc)))
+		}
+		if len(doc.Examples) > 0 {
+			fmt.Fprintln(w, ".PP\n.nf")
+			for _, example := range doc.Examples {
+				fmt.Fprintf(w, "%s\n", roffEscape(example))
+			}
+			fmt.Fprintln(w, ".fi")
+		}
+	}
+}
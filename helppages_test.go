@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommandDocsCoverSubcommands(t *testing.T) {
+	for name := range cdeSubcommands {
+		if _, ok := findCommandDoc(name); !ok {
+			t.Errorf("subcommand %q has no help page", name)
+		}
+	}
+	for _, name := range []string{"wrap", "preset", "which", "auto"} {
+		if doc, ok := findCommandDoc(name); !ok || !doc.LaunchFlags {
+			t.Errorf("launch subcommand %q needs a help page listing the launch options", name)
+		}
+	}
+}
+
+func TestParseArgumentsHelpTopics(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantTopic string
+		wantAll   bool
+		wantMan   bool
+		wantErr   bool
+	}{
+		{name: "overview", args: []string{"help"}},
+		{name: "help add", args: []string{"help", "add"}, wantTopic: "add"},
+		{name: "add --help", args: []string{"add", "--name", "x", "--help"}, wantTopic: "add"},
+		{name: "list -h", args: []string{"list", "-h"}, wantTopic: "list"},
+		{name: "wrap --help", args: []string{"wrap", "-e", "prod", "--help"}, wantTopic: "wrap"},
+		{name: "codex help after --", args: []string{"wrap", "-e", "prod", "--", "aider", "--help"}},
+		{name: "help-all", args: []string{"--help-all"}, wantAll: true},
+		{name: "man page", args: []string{"help", "--man"}, wantMan: true},
+		{name: "two topics", args: []string{"help", "add", "list"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if (result.Error != nil) != tt.wantErr {
+				t.Fatalf("parseArguments() error = %v, wantErr %v", result.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.name == "codex help after --" {
+				if result.Subcommand != "wrap" {
+					t.Errorf("--help after -- belongs to the command, got subcommand %q", result.Subcommand)
+				}
+				return
+			}
+			if result.Subcommand != "help" {
+				t.Fatalf("subcommand = %q, want help", result.Subcommand)
+			}
+			if got := result.CCEFlags["help_topic"]; got != tt.wantTopic {
+				t.Errorf("help_topic = %q, want %q", got, tt.wantTopic)
+			}
+			if (result.CCEFlags["help_all"] == "true") != tt.wantAll || (result.CCEFlags["help_man"] == "true") != tt.wantMan {
+				t.Errorf("unexpected help flags: %v", result.CCEFlags)
+			}
+		})
+	}
+}
+
+func TestWriteCommandHelp(t *testing.T) {
+	doc, _ := findCommandDoc("remove")
+	var out bytes.Buffer
+	writeCommandHelp(&out, doc)
+	for _, want := range []string{"Usage: cde remove <name>", "  -f, --force", "Examples:", "  cde remove staging"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("help page missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Launch options") {
+		t.Error("remove does not take launch options")
+	}
+}
+
+func TestWriteManPage(t *testing.T) {
+	var out bytes.Buffer
+	writeManPage(&out)
+	page := out.String()
+	for _, want := range []string{".TH CDE 1", ".SH OPTIONS", ".SH COMMANDS", ".SS compare", `.B \-\-dry\-run`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("man page missing %q", want)
+		}
+	}
+	for _, line := range strings.Split(page, "\n") {
+		if strings.HasPrefix(line, "'") {
+			t.Errorf("unescaped control character at line start: %q", line)
+		}
+	}
+}
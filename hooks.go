@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHookTimeout bounds each hook command unless settings.hooks.timeout_seconds is set
+const defaultHookTimeout = 30 * time.Second
+
+// Hook stages, also passed to commands as CDE_HOOK
+const (
+	hookPreLaunch = "pre_launch"
+	hookPostExit  = "post_exit"
+)
+
+// hookBaseEnvVars are inherited by hook commands; everything else (API keys, tokens,
+// OPENAI_* variables) is withheld unless listed in settings.hooks.pass_env
+var hookBaseEnvVars = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "LC_ALL", "TZ",
+	"TMPDIR", "TMP", "TEMP", "DISPLAY", "XDG_RUNTIME_DIR", "DBUS_SESSION_BUS_ADDRESS",
+	"SYSTEMROOT", "COMSPEC", "PATHEXT", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+}
+
+// HookSettings configures commands run around a launch. Hooks only run when enabled is set.
+type HookSettings struct {
+	Enabled        bool     `json:"enabled,omitempty"`
+	PreLaunch      []string `json:"pre_launch,omitempty"`      // Run before codex starts; a failure aborts the launch
+	PostExit       []string `json:"post_exit,omitempty"`       // Run after codex exits (subprocess launch mode only)
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // Per-command limit (default 30)
+	PassEnv        []string `json:"pass_env,omitempty"`        // Extra variables hooks may inherit
+}
+
+// validateHookSettings checks hook commands, the timeout and pass_env names
+func validateHookSettings(hooks *HookSettings) error {
+	if hooks == nil {
+		return nil
+	}
+	if hooks.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeout_seconds must not be negative")
+	}
+	for _, stage := range [][]string{hooks.PreLaunch, hooks.PostExit} {
+		for _, command := range stage {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("hook command cannot be empty")
+			}
+		}
+	}
+	for _, name := range hooks.PassEnv {
+		if !isValidEnvVarName(name) {
+			return fmt.Errorf("invalid pass_env variable name '%s'", name)
+		}
+	}
+	return nil
+}
+
+// activeHooks returns the hook settings when hooks are opted in, else nil
+func activeHooks(config Config) *HookSettings {
+	if config.Settings == nil || config.Settings.Hooks == nil || !config.Settings.Hooks.Enabled {
+		return nil
+	}
+	return config.Settings.Hooks
+}
+
+// hookTimeout returns the per-command time limit
+func hookTimeout(hooks *HookSettings) time.Duration {
+	if hooks.TimeoutSeconds > 0 {
+		return time.Duration(hooks.TimeoutSeconds) * time.Second
+	}
+	return defaultHookTimeout
+}
+
+// hookEnvironment builds the restricted variable set a hook command runs with
+func hookEnvironment(hooks *HookSettings, stage string, env Environment, extra map[string]string) []string {
+	vars := map[string]string{}
+	for _, name := range append(append([]string{}, hookBaseEnvVars...), hooks.PassEnv...) {
+		if value, exists := os.LookupEnv(name); exists {
+			vars[name] = value
+		}
+	}
+	vars["CDE_HOOK"] = stage
+	vars["CDE_ENV_NAME"] = env.Name
+	vars["CDE_ENV_URL"] = env.URL
+	vars["CDE_ENV_MODEL"] = env.Model
+	vars["CDE_ENV_KEY_MASKED"] = maskAPIKey(env.APIKey)
+	for key, value := range extra {
+		vars[key] = value
+	}
+	return sortedEnvPairs(vars)
+}
+
+// hookShell returns the shell invocation for a hook command on this platform
+func hookShell(command string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+	return "sh", []string{"-c", command}
+}
+
+// runHookCommand runs one hook with a restricted environment, no stdin and a time limit.
+// Its output goes to stderr so it never mixes with cde's own output.
+func runHookCommand(command string, envVars []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	name, args := hookShell(command)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = envVars
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	configureHookProcess(cmd)
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// runPreLaunchHooks runs settings.hooks.pre_launch in order; the first failure aborts the launch
+func runPreLaunchHooks(config Config, env Environment) error {
+	hooks := activeHooks(config)
+	if hooks == nil {
+		return nil
+	}
+	envVars := hookEnvironment(hooks, hookPreLaunch, env, nil)
+	for _, command := range hooks.PreLaunch {
+		logDebugf("running pre_launch hook %q", command)
+		if err := runHookCommand(command, envVars, hookTimeout(hooks)); err != nil {
+			return withErrorCode(codeHookFailed, fmt.Errorf("pre_launch hook %q failed: %w", command, err))
+		}
+	}
+	return nil
+}
+
+// runPostExitHooks runs settings.hooks.post_exit after codex exits; failures only warn
+func runPostExitHooks(config Config, env Environment, exitCode int, elapsed time.Duration) {
+	hooks := activeHooks(config)
+	if hooks == nil {
+		return
+	}
+	envVars := hookEnvironment(hooks, hookPostExit, env, map[string]string{
+		"CDE_EXIT_CODE":   strconv.Itoa(exitCode),
+		"CDE_DURATION_MS": strconv.FormatInt(elapsed.Milliseconds(), 10),
+	})
+	for _, command := range hooks.PostExit {
+		logDebugf("running post_exit hook %q", command)
+		if err := runHookCommand(command, envVars, hookTimeout(hooks)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: post_exit hook %q failed: %v\n", command, err)
+		}
+	}
+}
+
+// warnSkippedPostExitHooks notes that exec mode cannot run post_exit hooks
+func warnSkippedPostExitHooks(config Config) {
+	if hooks := activeHooks(config); hooks != nil && len(hooks.PostExit) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: post_exit hooks need \"launch_mode\": \"subprocess\"; they will not run\n")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateHookSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   *HookSettings
+		wantErr bool
+	}{
+		{name: "none", hooks: nil},
+		{name: "valid", hooks: &HookSettings{Enabled: true, PreLaunch: []string{"vpn-up"}, PassEnv: []string{"AWS_PROFILE"}}},
+		{name: "empty command", hooks: &HookSettings{PostExit: []string{"  "}}, wantErr: true},
+		{name: "negative timeout", hooks: &HookSettings{TimeoutSeconds: -1}, wantErr: true},
+		{name: "bad pass_env", hooks: &HookSettings{PassEnv: []string{"AWS-PROFILE"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHookSettings(tt.hooks); (err != nil) != tt.wantErr {
+				t.Errorf("validateHookSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookEnvironment(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-inherited-secret")
+	t.Setenv("AWS_PROFILE", "corp")
+	t.Setenv("UNLISTED_TOKEN", "hidden")
+
+	hooks := &HookSettings{Enabled: true, PassEnv: []string{"AWS_PROFILE"}}
+	env := Environment{Name: "prod", URL: "https://api.example.com/v1", APIKey: "sk-prod-1234567890abcdef", Model: "gpt-5"}
+	vars := hookEnvironment(hooks, hookPostExit, env, map[string]string{"CDE_EXIT_CODE": "3"})
+	joined := strings.Join(vars, "\n")
+
+	for _, want := range []string{
+		"CDE_HOOK=post_exit",
+		"CDE_ENV_NAME=prod",
+		"CDE_ENV_URL=https://api.example.com/v1",
+		"CDE_ENV_KEY_MASKED=" + maskAPIKey(env.APIKey),
+		"CDE_EXIT_CODE=3",
+		"AWS_PROFILE=corp",
+	} {
+		if !contains(vars, want) {
+			t.Errorf("hook environment missing %s", want)
+		}
+	}
+	for _, secret := range []string{"sk-inherited-secret", "sk-prod-1234567890abcdef", "UNLISTED_TOKEN"} {
+		if strings.Contains(joined, secret) {
+			t.Errorf("hook environment leaks %s", secret)
+		}
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands below use sh")
+	}
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+	env := Environment{Name: "dev", URL: "https://api.example.com", APIKey: "sk-dev-1234567890abcdef"}
+	config := Config{Settings: &ConfigSettings{Hooks: &HookSettings{
+		Enabled:   true,
+		PreLaunch: []string{`echo "pre $CDE_ENV_NAME" >> ` + out},
+		PostExit:  []string{`echo "post $CDE_EXIT_CODE" >> ` + out},
+	}}}
+
+	if err := runPreLaunchHooks(config, env); err != nil {
+		t.Fatalf("runPreLaunchHooks() error = %v", err)
+	}
+	runPostExitHooks(config, env, 2, time.Second)
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook output missing: %v", err)
+	}
+	if string(data) != "pre dev\npost 2\n" {
+		t.Errorf("hook output = %q", data)
+	}
+
+	// Hooks do nothing without the explicit opt-in
+	config.Settings.Hooks.Enabled = false
+	config.Settings.Hooks.PreLaunch = []string{"exit 1"}
+	if err := runPreLaunchHooks(config, env); err != nil {
+		t.Errorf("disabled hooks ran: %v", err)
+	}
+
+	config.Settings.Hooks.Enabled = true
+	err = runPreLaunchHooks(config, env)
+	if err == nil || errorCodeOf(err) != codeHookFailed {
+		t.Errorf("failing pre_launch hook error = %v, want %s", err, codeHookFailed)
+	}
+}
+
+func TestRunHookCommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands below use sh")
+	}
+
+	start := time.Now()
+	err := runHookCommand("sleep 5", nil, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("runHookCommand() error = %v, want timeout", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("timeout not enforced (took %s)", time.Since(start))
+	}
+}
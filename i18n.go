@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Supported languages; messages are written in English and translated through a catalog
+const (
+	languageEnglish  = "en"
+	languageChinese  = "zh-CN"
+	defaultLanguage  = languageEnglish
+	languageFlagHint = "use en or zh-CN"
+)
+
+// messageCatalogs translate English messages, keyed by language
+var messageCatalogs = map[string]map[string]string{
+	languageChinese: zhCNMessages,
+}
+
+// activeLanguage is the language of help, prompts and error summaries for this invocation
+var activeLanguage = defaultLanguage
+
+// normalizeLanguage maps a language setting or locale (zh, zh_CN.UTF-8, en-US) to a supported
+// language, or "" when it is not supported
+func normalizeLanguage(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if cut := strings.IndexAny(value, ".@"); cut >= 0 {
+		value = value[:cut]
+	}
+	switch {
+	case value == "zh" || strings.HasPrefix(value, "zh-") || strings.HasPrefix(value, "zh_"):
+		return languageChinese
+	case value == "en" || strings.HasPrefix(value, "en-") || strings.HasPrefix(value, "en_"):
+		return languageEnglish
+	}
+	return ""
+}
+
+// validateLanguage checks a --lang value or settings.language
+func validateLanguage(value string) error {
+	if value != "" && normalizeLanguage(value) == "" {
+		return fmt.Errorf("unsupported language '%s' (%s)", value, languageFlagHint)
+	}
+	return nil
+}
+
+// localeLanguage returns the language of the user's locale (LC_ALL, LC_MESSAGES, LANG)
+func localeLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if language := normalizeLanguage(value); language != "" {
+				return language
+			}
+			return defaultLanguage
+		}
+	}
+	return defaultLanguage
+}
+
+// selectLanguage sets the active language: --lang, then settings.language, then the locale
+func selectLanguage(flag string) {
+	if flag != "" {
+		activeLanguage = normalizeLanguage(flag)
+		return
+	}
+	if config, err := loadConfigLazy(); err == nil && config.Settings != nil && config.Settings.Language != "" {
+		if language := normalizeLanguage(config.Settings.Language); language != "" {
+			activeLanguage = language
+			return
+		}
+	}
+	activeLanguage = localeLanguage()
+}
+
+// tr translates an English message into the active language, falling back to English
+func tr(message string) string {
+	if translated, ok := messageCatalogs[activeLanguage][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// trf translates a format string and formats it
+func trf(format string, args ...interface{}) string {
+	return fmt.Sprintf(tr(format), args...)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useLanguage switches the active language for the duration of the test
+func useLanguage(t *testing.T, language string) {
+	t.Helper()
+	original := activeLanguage
+	activeLanguage = language
+	t.Cleanup(func() { activeLanguage = original })
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"en", languageEnglish},
+		{"en_US.UTF-8", languageEnglish},
+		{"zh", languageChinese},
+		{"zh-CN", languageChinese},
+		{"zh_CN.UTF-8", languageChinese},
+		{" ZH_cn ", languageChinese},
+		{"C.UTF-8", ""},
+		{"fr_FR", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLanguage(tt.value); got != tt.want {
+			t.Errorf("normalizeLanguage(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+	if err := validateLanguage("fr"); err == nil {
+		t.Error("validateLanguage(fr) should fail")
+	}
+}
+
+func TestSelectLanguage(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPathOverride = original }()
+
+	tests := []struct {
+		name    string
+		flag    string
+		setting string
+		lcAll   string
+		lang    string
+		wanted  string
+	}{
+		{name: "default", wanted: languageEnglish},
+		{name: "LANG", lang: "zh_CN.UTF-8", wanted: languageChinese},
+		{name: "LC_ALL wins over LANG", lcAll: "en_US.UTF-8", lang: "zh_CN.UTF-8", wanted: languageEnglish},
+		{name: "unsupported locale", lang: "fr_FR.UTF-8", wanted: languageEnglish},
+		{name: "setting wins over LANG", setting: "zh-CN", lang: "en_US.UTF-8", wanted: languageChinese},
+		{name: "flag wins", flag: "en", setting: "zh-CN", lang: "zh_CN.UTF-8", wanted: languageEnglish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLanguage(t, languageEnglish)
+			config := `{"environments": [], "settings": {"language": "` + tt.setting + `"}}`
+			if err := ioutil.WriteFile(configPathOverride, []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_MESSAGES", "")
+			t.Setenv("LANG", tt.lang)
+			selectLanguage(tt.flag)
+			if activeLanguage != tt.wanted {
+				t.Errorf("activeLanguage = %q, want %q", activeLanguage, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	useLanguage(t, languageChinese)
+	if got := tr("Examples:"); got != "示例:" {
+		t.Errorf("tr(Examples:) = %q", got)
+	}
+	if got := trf("Enter number (1-%d): ", 3); got != "输入编号（1-3）: " {
+		t.Errorf("trf() = %q", got)
+	}
+	if got := tr("not in the catalog"); got != "not in the catalog" {
+		t.Errorf("missing messages should fall back to English, got %q", got)
+	}
+
+	doc, _ := findCommandDoc("remove")
+	var out bytes.Buffer
+	writeCommandHelp(&out, doc)
+	for _, want := range []string{"用法: cde remove <name>", "跳过确认", "示例:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("help page missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestChineseCatalogCoversHelp(t *testing.T) {
+	messages := []string{}
+	for _, doc := range commandDocs {
+		messages = append(messages, doc.Summary)
+		for _, flag := range doc.Flags {
+			messages = append(messages, flag.Desc)
+		}
+	}
+	for _, option := range launchOptionDocs {
+		messages = append(messages, option.Desc)
+	}
+	for _, message := range messages {
+		if _, ok := zhCNMessages[message]; !ok {
+			t.Errorf("no zh-CN translation for %q", message)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// importCandidate is an environment discovered in another tool's configuration
+type importCandidate struct {
+	Env    Environment
+	Origin string // Where it was found, for display
+}
+
+// importSources maps 'cde import-from' source names to their scanners
+var importSources = map[string]func() ([]importCandidate, error){
+	"codex":           scanCodexSources,
+	"claude-code-env": scanClaudeCodeEnvSources,
+}
+
+// tomlKeyValuePattern matches a simple 'key = value' TOML line
+var tomlKeyValuePattern = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s*=\s*(.+?)\s*$`)
+
+// invalidNameCharsPattern matches runs of characters environment names cannot contain
+var invalidNameCharsPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// parseSimpleTOML reads the string values of a TOML document by table name. It covers
+// what codex's config.toml uses for providers and profiles; other value types are skipped.
+func parseSimpleTOML(content string) map[string]map[string]string {
+	tables := map[string]map[string]string{"": {}}
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if match := tomlTablePattern.FindStringSubmatch(line); match != nil && !strings.HasPrefix(line, "[[") {
+			current = strings.ReplaceAll(strings.ReplaceAll(match[1], `"`, ""), " ", "")
+			if tables[current] == nil {
+				tables[current] = map[string]string{}
+			}
+			continue
+		}
+		match := tomlKeyValuePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if value, ok := parseTOMLString(match[2]); ok {
+			tables[current][match[1]] = value
+		}
+	}
+	return tables
+}
+
+// parseTOMLString decodes a basic ("...") or literal ('...') string, ignoring a trailing comment
+func parseTOMLString(raw string) (string, bool) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		for end := 1; end < len(raw); end++ {
+			if raw[end] == '\\' {
+				end++
+				continue
+			}
+			if raw[end] == '"' {
+				value, err := strconv.Unquote(raw[:end+1])
+				return value, err == nil
+			}
+		}
+	case strings.HasPrefix(raw, "'"):
+		if end := strings.Index(raw[1:], "'"); end >= 0 {
+			return raw[1 : end+1], true
+		}
+	}
+	return "", false
+}
+
+// codexCandidatesFromTOML turns codex model providers with a base_url into candidates.
+// API keys are read from each provider's env_key variable; the model comes from a profile
+// (or the top level) that selects the provider.
+func codexCandidatesFromTOML(content, origin string) []importCandidate {
+	tables := parseSimpleTOML(content)
+
+	models := map[string]string{}
+	if provider := tables[""]["model_provider"]; provider != "" {
+		models[provider] = tables[""]["model"]
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !strings.HasPrefix(name, "profiles.") {
+			continue
+		}
+		profile := tables[name]
+		if provider := profile["model_provider"]; provider != "" && models[provider] == "" {
+			models[provider] = profile["model"]
+		}
+	}
+
+	candidates := []importCandidate{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, "model_providers.") {
+			continue
+		}
+		provider := tables[name]
+		id := strings.TrimPrefix(name, "model_providers.")
+		if provider["base_url"] == "" {
+			continue
+		}
+		env := Environment{Name: id, URL: provider["base_url"], Model: models[id]}
+		if keyVar := provider["env_key"]; keyVar != "" {
+			env.APIKey = os.Getenv(keyVar)
+		}
+		candidates = append(candidates, importCandidate{Env: env, Origin: origin + " [" + name + "]"})
+	}
+	return candidates
+}
+
+// scanCodexSources reads codex's config.toml and the OPENAI_* variables of this shell
+func scanCodexSources() ([]importCandidate, error) {
+	candidates := []importCandidate{}
+	codexPath, err := getCodexConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := ioutil.ReadFile(codexPath); err == nil {
+		candidates = append(candidates, codexCandidatesFromTOML(string(data), codexPath)...)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("codex config read failed: %w", err)
+	}
+
+	if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
+		candidates = append(candidates, importCandidate{
+			Env:    Environment{Name: "openai-env", URL: baseURL, APIKey: os.Getenv("OPENAI_API_KEY"), Model: os.Getenv("OPENAI_MODEL")},
+			Origin: "OPENAI_BASE_URL",
+		})
+	}
+	return candidates, nil
+}
+
+// scanClaudeCodeEnvSources reads claude-code-env's config.json and the ANTHROPIC_* variables
+func scanClaudeCodeEnvSources() ([]importCandidate, error) {
+	candidates := []importCandidate{}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("home directory lookup failed: %w", err)
+	}
+
+	path := filepath.Join(homeDir, ".claude-code-env", "config.json")
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var other struct {
+			Environments []Environment `json:"environments"`
+		}
+		if err := json.Unmarshal(data, &other); err != nil {
+			return nil, fmt.Errorf("claude-code-env config parse failed: %w", err)
+		}
+		for _, env := range other.Environments {
+			candidates = append(candidates, importCandidate{
+				Env:    Environment{Name: env.Name, URL: env.URL, APIKey: env.APIKey, Model: env.Model, EnvVars: copyStringMap(env.EnvVars)},
+				Origin: path,
+			})
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("claude-code-env config read failed: %w", err)
+	}
+
+	if baseURL := os.Getenv("ANTHROPIC_BASE_URL"); baseURL != "" {
+		apiKey := os.Getenv("ANTHROPIC_AUTH_TOKEN")
+		if apiKey == "" {
+			apiKey = os.Getenv("ANTHROPIC_API_KEY")
+		}
+		candidates = append(candidates, importCandidate{
+			Env:    Environment{Name: "anthropic-env", URL: baseURL, APIKey: apiKey},
+			Origin: "ANTHROPIC_BASE_URL",
+		})
+	}
+	return candidates, nil
+}
+
+// normalizeImportURL makes URLs comparable for deduplication
+func normalizeImportURL(rawURL string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(rawURL), "/"))
+}
+
+// dedupeCandidates drops candidates whose URL is already configured or seen earlier
+func dedupeCandidates(config Config, candidates []importCandidate) ([]importCandidate, []importCandidate) {
+	seen := make(map[string]bool, len(config.Environments))
+	for _, env := range config.Environments {
+		seen[normalizeImportURL(env.URL)] = true
+	}
+	kept := []importCandidate{}
+	dropped := []importCandidate{}
+	for _, candidate := range candidates {
+		key := normalizeImportURL(candidate.Env.URL)
+		if seen[key] {
+			dropped = append(dropped, candidate)
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, candidate)
+	}
+	return kept, dropped
+}
+
+// uniqueImportName returns name, made valid and suffixed until it is unused
+func uniqueImportName(name string, taken map[string]bool) string {
+	name = invalidNameCharsPattern.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "imported"
+	}
+	if len(name) > 45 {
+		name = name[:45]
+	}
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	return candidate
+}
+
+// runImportFrom bootstraps environments from a sibling tool's configuration
+func runImportFrom(source string, dryRun, noBackup bool) error {
+	scan, exists := importSources[source]
+	if !exists {
+		return fmt.Errorf("unknown import source '%s' (use codex or claude-code-env)", source)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	candidates, err := scan()
+	if err != nil {
+		return err
+	}
+	candidates, duplicates := dedupeCandidates(config, candidates)
+	for _, duplicate := range duplicates {
+		reason := "URL already configured"
+		if names := findDuplicatesOf(config, duplicate.Env); len(names) > 0 {
+			reason = fmt.Sprintf("same URL and API key as '%s'", names[0])
+		}
+		fmt.Printf("Skipping %s (%s): %s\n", duplicate.Env.URL, duplicate.Origin, reason)
+	}
+	if len(candidates) == 0 {
+		fmt.Printf("No new environments found for %s\n", source)
+		return nil
+	}
+
+	interactive := isInteractiveInput() && !dryRun
+	taken := make(map[string]bool, len(config.Environments))
+	for _, env := range config.Environments {
+		taken[env.Name] = true
+	}
+
+	imported := []Environment{}
+	for _, candidate := range candidates {
+		env := candidate.Env
+		env.Name = uniqueImportName(env.Name, taken)
+		fmt.Printf("\nFound %s (%s)\n", env.URL, candidate.Origin)
+
+		if interactive {
+			input, err := regularInput(trf("Name [%s] (- to skip): ", env.Name))
+			if err != nil {
+				return fmt.Errorf("import prompt failed: %w", err)
+			}
+			if input == "-" {
+				continue
+			}
+			if input != "" {
+				if err := validateName(input); err != nil || taken[input] {
+					fmt.Printf("Invalid or duplicate name '%s'; skipping\n", input)
+					continue
+				}
+				env.Name = input
+			}
+			if env.APIKey == "" {
+				if env.APIKey, err = secureInput(tr("API Key (Enter to skip): ")); err != nil {
+					return fmt.Errorf("import prompt failed: %w", err)
+				}
+			}
+		}
+		if env.APIKey == "" {
+			fmt.Printf("Skipping '%s': no API key found\n", env.Name)
+			continue
+		}
+		if err := validateEnvironment(env); err != nil {
+			fmt.Printf("Skipping '%s': %v\n", env.Name, err)
+			continue
+		}
+		taken[env.Name] = true
+		imported = append(imported, env)
+		fmt.Printf("  -> %s (key %s)\n", env.Name, maskAPIKey(env.APIKey))
+	}
+
+	if len(imported) == 0 {
+		fmt.Println("\nNothing imported")
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("\nDry run: %d environment(s) would be imported\n", len(imported))
+		return nil
+	}
+
+	err = updateConfigWithOptions(saveOptions{BackupLabel: "import-" + source, NoBackup: noBackup}, func(config *Config) error {
+		for _, env := range imported {
+			if _, exists := findEnvironmentByName(*config, env.Name); exists {
+				return fmt.Errorf("environment '%s' already exists", env.Name)
+			}
+			config.Environments = append(config.Environments, env)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nImported %d environment(s) from %s\n", len(imported), source)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCodexCandidatesFromTOML(t *testing.T) {
+	t.Setenv("GATEWAY_KEY", "sk-gateway-123456")
+	content := `model = "o3"
+model_provider = "gateway"
+
+[model_providers.gateway]
+name = "Gateway"
+base_url = "https://gw.example.com/v1" # team gateway
+env_key = "GATEWAY_KEY"
+
+[model_providers."local"]
+base_url = 'http://localhost:11434/v1'
+
+[model_providers.nourl]
+name = "missing base url"
+
+[profiles.fast]
+model_provider = "local"
+model = "qwen3"
+`
+	candidates := codexCandidatesFromTOML(content, "config.toml")
+	got := []Environment{}
+	for _, candidate := range candidates {
+		got = append(got, candidate.Env)
+	}
+	want := []Environment{
+		{Name: "gateway", URL: "https://gw.example.com/v1", APIKey: "sk-gateway-123456", Model: "o3"},
+		{Name: "local", URL: "http://localhost:11434/v1", Model: "qwen3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("codexCandidatesFromTOML() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDedupeCandidatesAndNames(t *testing.T) {
+	config := Config{Environments: []Environment{{Name: "dev", URL: "https://dev.example.com/"}}}
+	candidates := []importCandidate{
+		{Env: Environment{Name: "dev", URL: "https://DEV.example.com"}},
+		{Env: Environment{Name: "a", URL: "https://a.example.com"}},
+		{Env: Environment{Name: "b", URL: "https://a.example.com/"}},
+	}
+	kept, dropped := dedupeCandidates(config, candidates)
+	if len(kept) != 1 || kept[0].Env.Name != "a" || len(dropped) != 2 {
+		t.Errorf("kept = %+v, dropped = %+v", kept, dropped)
+	}
+
+	taken := map[string]bool{"dev": true, "dev-2": true}
+	if name := uniqueImportName("dev", taken); name != "dev-3" {
+		t.Errorf("uniqueImportName(dev) = %s, want dev-3", name)
+	}
+	if name := uniqueImportName("My Gateway!", taken); name != "My-Gateway" {
+		t.Errorf("uniqueImportName(My Gateway!) = %s, want My-Gateway", name)
+	}
+}
+
+func TestRunImportFromClaudeCodeEnv(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	if err := os.MkdirAll(filepath.Join(home, ".claude-code-env"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	other := `{"environments": [
+		{"name": "work", "url": "https://work.example.com", "api_key": "sk-work-123456", "model": "gpt-5"},
+		{"name": "dup", "url": "https://dev.example.com/", "api_key": "sk-dup-123456"},
+		{"name": "nokey", "url": "https://nokey.example.com", "api_key": ""}
+	]}`
+	if err := ioutil.WriteFile(filepath.Join(home, ".claude-code-env", "config.json"), []byte(other), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveConfig(Config{Environments: []Environment{{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456"}}}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := runImportFrom("claude-code-env", false, false); err != nil {
+		t.Fatalf("runImportFrom() error = %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(config.Environments) != 2 || config.Environments[1].Name != "work" || config.Environments[1].Model != "gpt-5" {
+		t.Errorf("unexpected environments: %+v", config.Environments)
+	}
+
+	if err := runImportFrom("nope", false, false); err == nil {
+		t.Error("unknown source should fail")
+	}
+}
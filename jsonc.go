@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// jsoncStripper states
+const (
+	jsoncCode = iota
+	jsoncString
+	jsoncStringEscape
+	jsoncSlash // Saw '/', not yet known to start a comment
+	jsoncLineComment
+	jsoncBlockComment
+	jsoncBlockStar // Saw '*' inside a block comment
+)
+
+// jsoncStripper turns JSONC (// and /* */ comments, trailing commas) into JSON by blanking
+// those bytes with spaces. Line breaks are kept and nothing moves, so line and column
+// numbers in decoder errors still point into the original file.
+type jsoncStripper struct {
+	state   int
+	holding bool   // A comma was seen and is held until the next significant byte
+	pending []byte // The held comma and the blanks after it
+}
+
+// emit writes a byte, or holds it behind a pending comma
+func (s *jsoncStripper) emit(out []byte, b byte) []byte {
+	if s.holding {
+		s.pending = append(s.pending, b)
+		return out
+	}
+	return append(out, b)
+}
+
+// significant releases a held comma, blanking it when b closes an object or array, then writes b
+func (s *jsoncStripper) significant(out []byte, b byte) []byte {
+	if s.holding {
+		if b == '}' || b == ']' {
+			s.pending[0] = ' '
+		}
+		out = append(out, s.pending...)
+		s.holding = false
+	}
+	return append(out, b)
+}
+
+// blank returns the replacement for a comment byte
+func blank(b byte) byte {
+	if b == '\n' || b == '\r' {
+		return b
+	}
+	return ' '
+}
+
+// write processes one input byte
+func (s *jsoncStripper) write(out []byte, b byte) []byte {
+	switch s.state {
+	case jsoncString:
+		switch b {
+		case '\\':
+			s.state = jsoncStringEscape
+		case '"':
+			s.state = jsoncCode
+		}
+		return append(out, b)
+	case jsoncStringEscape:
+		s.state = jsoncString
+		return append(out, b)
+	case jsoncSlash:
+		switch b {
+		case '/':
+			s.state = jsoncLineComment
+			return s.emit(s.emit(out, ' '), ' ')
+		case '*':
+			s.state = jsoncBlockComment
+			return s.emit(s.emit(out, ' '), ' ')
+		}
+		s.state = jsoncCode
+		out = s.significant(out, '/')
+		return s.write(out, b)
+	case jsoncLineComment:
+		if b == '\n' {
+			s.state = jsoncCode
+		}
+		return s.emit(out, blank(b))
+	case jsoncBlockComment, jsoncBlockStar:
+		switch {
+		case b == '/' && s.state == jsoncBlockStar:
+			s.state = jsoncCode
+		case b == '*':
+			s.state = jsoncBlockStar
+		default:
+			s.state = jsoncBlockComment
+		}
+		return s.emit(out, blank(b))
+	}
+
+	switch b {
+	case ' ', '\t', '\n', '\r':
+		return s.emit(out, b)
+	case '/':
+		s.state = jsoncSlash
+		return out
+	case ',':
+		if s.holding {
+			out = append(out, s.pending...)
+		}
+		s.holding = true
+		s.pending = append(s.pending[:0], ',')
+		return out
+	case '"':
+		s.state = jsoncString
+	}
+	return s.significant(out, b)
+}
+
+// flush releases held bytes at the end of the input
+func (s *jsoncStripper) flush(out []byte) []byte {
+	if s.state == jsoncSlash {
+		s.state = jsoncCode
+		out = s.significant(out, '/')
+	}
+	if s.holding {
+		out = append(out, s.pending...)
+		s.holding = false
+	}
+	return out
+}
+
+// stripJSONC converts a JSONC document to JSON of the same length
+func stripJSONC(data []byte) []byte {
+	var s jsoncStripper
+	out := make([]byte, 0, len(data))
+	for _, b := range data {
+		out = s.write(out, b)
+	}
+	return s.flush(out)
+}
+
+// jsoncReader strips JSONC while streaming, for readers that never load the whole file
+type jsoncReader struct {
+	r        io.Reader
+	stripper jsoncStripper
+	chunk    []byte
+	buf      []byte
+	err      error
+}
+
+// newJSONCReader wraps r so that it yields plain JSON
+func newJSONCReader(r io.Reader) io.Reader {
+	return &jsoncReader{r: r, chunk: make([]byte, 4096)}
+}
+
+// Read implements io.Reader
+func (j *jsoncReader) Read(p []byte) (int, error) {
+	for len(j.buf) == 0 && j.err == nil {
+		n, err := j.r.Read(j.chunk)
+		for _, b := range j.chunk[:n] {
+			j.buf = j.stripper.write(j.buf, b)
+		}
+		if err != nil {
+			j.buf = j.stripper.flush(j.buf)
+			j.err = err
+		}
+	}
+	if len(j.buf) == 0 {
+		return 0, j.err
+	}
+	n := copy(p, j.buf)
+	j.buf = j.buf[n:]
+	return n, nil
+}
+
+// jsoncValue is a JSON value that remembers the text around its parts, so a document can
+// be edited and written back with its comments, spacing and key order intact
+type jsoncValue struct {
+	kind          byte   // '{', '[' or 0 for a scalar
+	raw           []byte // Scalar text
+	members       []*jsoncMember
+	trailing      []byte // Whitespace and comments before the closing bracket
+	trailingComma bool
+}
+
+// jsoncMember is an object member or an array item (without a key)
+type jsoncMember struct {
+	leading []byte // Whitespace and comments before the key or item
+	key     []byte // Quoted key; nil for array items
+	colon   []byte // Text from the end of the key to the value, including ':'
+	value   *jsoncValue
+	after   []byte // Comments after the value; written after the comma
+}
+
+// jsoncDocument is a parsed JSONC file
+type jsoncDocument struct {
+	leading  []byte
+	root     *jsoncValue
+	trailing []byte
+}
+
+// jsoncParser is a recursive descent parser that keeps all source text
+type jsoncParser struct {
+	data []byte
+	pos  int
+}
+
+// parseJSONCDocument parses a JSONC document
+func parseJSONCDocument(data []byte) (*jsoncDocument, error) {
+	p := &jsoncParser{data: data}
+	doc := &jsoncDocument{leading: p.trivia()}
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	doc.root = root
+	doc.trailing = p.trivia()
+	if p.pos != len(data) {
+		return nil, fmt.Errorf("unexpected content at offset %d", p.pos)
+	}
+	return doc, nil
+}
+
+// trivia consumes whitespace and comments
+func (p *jsoncParser) trivia() []byte {
+	start := p.pos
+	for p.pos < len(p.data) {
+		switch {
+		case bytes.IndexByte([]byte(" \t\r\n"), p.data[p.pos]) >= 0:
+			p.pos++
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end
+			}
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end + 4
+			}
+		default:
+			return p.data[start:p.pos]
+		}
+	}
+	return p.data[start:p.pos]
+}
+
+// value parses an object, array or scalar at the current position
+func (p *jsoncParser) value() (*jsoncValue, error) {
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("unexpected end of input")
+	}
+	switch p.data[p.pos] {
+	case '{':
+		return p.container('{', '}')
+	case '[':
+		return p.container('[', ']')
+	case '"':
+		raw, err := p.str()
+		return &jsoncValue{raw: raw}, err
+	}
+	start := p.pos
+	for p.pos < len(p.data) && bytes.IndexByte([]byte(" \t\r\n,]}/"), p.data[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, fmt.Errorf("unexpected '%c' at offset %d", p.data[p.pos], p.pos)
+	}
+	return &jsoncValue{raw: p.data[start:p.pos]}, nil
+}
+
+// str consumes a quoted string and returns it with its quotes
+func (p *jsoncParser) str() ([]byte, error) {
+	start := p.pos
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+			return p.data[start:p.pos], nil
+		}
+	}
+	return nil, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+// container parses an object or array
+func (p *jsoncParser) container(open, close byte) (*jsoncValue, error) {
+	v := &jsoncValue{kind: open}
+	p.pos++
+	for {
+		leading := p.trivia()
+		if p.pos >= len(p.data) {
+			return nil, fmt.Errorf("unexpected end of input")
+		}
+		if p.data[p.pos] == close {
+			v.trailing = leading
+			v.trailingComma = len(v.members) > 0
+			p.pos++
+			return v, nil
+		}
+
+		m := &jsoncMember{leading: leading}
+		if open == '{' {
+			if p.data[p.pos] != '"' {
+				return nil, fmt.Errorf("expected a key at offset %d", p.pos)
+			}
+			key, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			m.key = key
+			colonStart := p.pos
+			p.trivia()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return nil, fmt.Errorf("expected ':' at offset %d", p.pos)
+			}
+			p.pos++
+			p.trivia()
+			m.colon = p.data[colonStart:p.pos]
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		m.value = value
+		m.after = p.trivia()
+		v.members = append(v.members, m)
+
+		if p.pos >= len(p.data) {
+			return nil, fmt.Errorf("unexpected end of input")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case close:
+			// Keep the line break before the bracket with the bracket, not with the last value
+			last := v.members[len(v.members)-1]
+			if cut := bytes.LastIndexByte(last.after, '\n'); cut >= 0 && len(bytes.TrimSpace(last.after[cut:])) == 0 {
+				last.after, v.trailing = last.after[:cut], last.after[cut:]
+			}
+			p.pos++
+			return v, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or '%c' at offset %d", close, p.pos)
+		}
+	}
+}
+
+// write renders the value with its original text
+func (v *jsoncValue) write(buf *bytes.Buffer) {
+	if v.kind == 0 {
+		buf.Write(v.raw)
+		return
+	}
+	buf.WriteByte(v.kind)
+	for i, m := range v.members {
+		buf.Write(m.leading)
+		if m.key != nil {
+			buf.Write(m.key)
+			buf.Write(m.colon)
+		}
+		m.value.write(buf)
+		if i < len(v.members)-1 || v.trailingComma {
+			buf.WriteByte(',')
+		}
+		buf.Write(m.after)
+	}
+	buf.Write(v.trailing)
+	if v.kind == '{' {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+}
+
+// bytes renders the document
+func (d *jsoncDocument) bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(d.leading)
+	d.root.write(&buf)
+	buf.Write(d.trailing)
+	return buf.Bytes()
+}
+
+// mergeJSONC returns updated laid out like old: members and items that still exist keep
+// their comments, spacing and position; new ones are added at the end in updated's format
+func mergeJSONC(old, updated *jsoncValue) *jsoncValue {
+	if old.kind != updated.kind {
+		return updated
+	}
+	if old.kind == 0 {
+		if sameJSON(old.raw, updated.raw) {
+			return old
+		}
+		return updated
+	}
+
+	result := &jsoncValue{kind: old.kind, trailing: old.trailing, trailingComma: old.trailingComma}
+	if old.kind == '{' {
+		next := make(map[string]*jsoncMember, len(updated.members))
+		for _, m := range updated.members {
+			next[memberKey(m)] = m
+		}
+		for _, m := range old.members {
+			if match, ok := next[memberKey(m)]; ok {
+				delete(next, memberKey(m))
+				merged := *m
+				merged.value = mergeJSONC(m.value, match.value)
+				result.members = append(result.members, &merged)
+			}
+		}
+		for _, m := range updated.members {
+			if _, added := next[memberKey(m)]; added {
+				result.members = append(result.members, restyleMember(m, old))
+			}
+		}
+	} else {
+		previous := make(map[string][]*jsoncMember)
+		for i, m := range old.members {
+			id := itemIdentity(m, i)
+			previous[id] = append(previous[id], m)
+		}
+		for i, m := range updated.members {
+			id := itemIdentity(m, i)
+			if candidates := previous[id]; len(candidates) > 0 {
+				previous[id] = candidates[1:]
+				merged := *candidates[0]
+				merged.value = mergeJSONC(candidates[0].value, m.value)
+				result.members = append(result.members, &merged)
+				continue
+			}
+			result.members = append(result.members, restyleMember(m, old))
+		}
+	}
+
+	switch {
+	case len(result.members) == 0:
+		result.trailingComma = false
+		if !bytes.Contains(result.trailing, []byte("/")) {
+			result.trailing = nil
+		}
+	case len(old.members) == 0 && !bytes.Contains(old.trailing, []byte("/")):
+		result.trailing = updated.trailing
+	}
+	return result
+}
+
+// restyleMember formats a member added to container like its existing members: on the
+// same line for one-line containers, otherwise at the indentation of the last member
+func restyleMember(m *jsoncMember, container *jsoncValue) *jsoncMember {
+	if len(container.members) == 0 {
+		return m
+	}
+	template := container.members[len(container.members)-1].leading
+	var buf bytes.Buffer
+	m.value.write(&buf)
+	restyled := *m
+
+	cut := bytes.LastIndexByte(template, '\n')
+	if cut < 0 {
+		var compact bytes.Buffer
+		if json.Compact(&compact, buf.Bytes()) != nil {
+			return m
+		}
+		restyled.leading = []byte(" ")
+		restyled.colon = []byte(": ")
+		restyled.value = reparseJSONC(compact.Bytes(), m.value)
+		return &restyled
+	}
+
+	// MarshalIndent indents two spaces per level; use the file's own unit instead
+	line := template[cut+1:]
+	indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+	depth := len(m.leading[bytes.LastIndexByte(m.leading, '\n')+1:]) / 2
+	if depth == 0 || len(indent)%depth != 0 {
+		return m
+	}
+	unit := indent[:len(indent)/depth]
+	if !bytes.Equal(bytes.Repeat(unit, depth), indent) {
+		return m
+	}
+	restyled.leading = append([]byte("\n"), indent...)
+	restyled.value = reparseJSONC(reindentJSON(buf.Bytes(), unit), m.value)
+	return &restyled
+}
+
+// reindentJSON replaces the two-space indentation of MarshalIndent output with unit per level
+func reindentJSON(data, unit []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		trimmed := bytes.TrimLeft(lines[i], " ")
+		levels := (len(lines[i]) - len(trimmed)) / 2
+		lines[i] = append(bytes.Repeat(unit, levels), trimmed...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// reparseJSONC parses data as a value, falling back when it does not parse
+func reparseJSONC(data []byte, fallback *jsoncValue) *jsoncValue {
+	doc, err := parseJSONCDocument(data)
+	if err != nil {
+		return fallback
+	}
+	return doc.root
+}
+
+// memberKey decodes an object member's key
+func memberKey(m *jsoncMember) string {
+	var key string
+	if err := json.Unmarshal(m.key, &key); err != nil {
+		return string(m.key)
+	}
+	return key
+}
+
+// itemIdentity matches array items across edits: objects with a "name" (environments)
+// by name, anything else by position
+func itemIdentity(m *jsoncMember, index int) string {
+	if m.value.kind == '{' {
+		for _, member := range m.value.members {
+			if memberKey(member) == "name" && member.value.kind == 0 {
+				var name string
+				if json.Unmarshal(member.value.raw, &name) == nil {
+					return "name:" + name
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// sameJSON reports whether two scalar literals hold the same value
+func sameJSON(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal(stripJSONC(a), &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// preserveJSONCLayout rewrites marshaled, the new JSON for a file whose current contents
+// are existing, so that comments, spacing and key order in existing survive the edit
+func preserveJSONCLayout(existing, marshaled []byte) ([]byte, error) {
+	doc, err := parseJSONCDocument(existing)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := parseJSONCDocument(marshaled)
+	if err != nil {
+		return nil, err
+	}
+	doc.root = mergeJSONC(doc.root, updated.root)
+	return doc.bytes(), nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain JSON", input: `{"a": [1, 2]}`, want: `{"a": [1, 2]}`},
+		{name: "line comment", input: "{\"a\": 1 // one\n}", want: "{\"a\": 1       \n}"},
+		{name: "block comment", input: "{/* x\n y */\"a\": 1}", want: "{    \n     \"a\": 1}"},
+		{name: "trailing comma in object", input: `{"a": 1,}`, want: `{"a": 1 }`},
+		{name: "trailing comma in array", input: "[1, 2, // last\n]", want: "[1, 2         \n]"},
+		{name: "comment-like text in strings", input: `{"url": "https://x/*y*/", "s": "a,}"}`, want: `{"url": "https://x/*y*/", "s": "a,}"}`},
+		{name: "escaped quote", input: `{"a": "\"//", "b": 1,}`, want: `{"a": "\"//", "b": 1 }`},
+		{name: "stray slash kept", input: `{"a": 1 / 2}`, want: `{"a": 1 / 2}`},
+		{name: "comma before comment before value", input: "[1, /* two */ 2]", want: "[1,           2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(stripJSONC([]byte(tt.input)))
+			if got != tt.want {
+				t.Errorf("stripJSONC() = %q, want %q", got, tt.want)
+			}
+			if len(got) != len(tt.input) {
+				t.Errorf("length changed from %d to %d", len(tt.input), len(got))
+			}
+
+			streamed, err := ioutil.ReadAll(newJSONCReader(iotest.OneByteReader(strings.NewReader(tt.input))))
+			if err != nil || string(streamed) != tt.want {
+				t.Errorf("jsoncReader = %q, %v, want %q", streamed, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEnvironmentNamesJSONC(t *testing.T) {
+	config := "{\n  // team endpoints\n  \"environments\": [\n    {\"name\": \"prod\"}, /* primary */\n    {\"name\": \"dev\",},\n  ],\n}"
+	names, err := readEnvironmentNames(newJSONCReader(strings.NewReader(config)))
+	if err != nil || !reflect.DeepEqual(names, []string{"prod", "dev"}) {
+		t.Errorf("readEnvironmentNames() = %v, %v", names, err)
+	}
+}
+
+func TestPreserveJSONCLayout(t *testing.T) {
+	existing := `// Team configuration
+{
+  "settings": {
+    "launch_mode": "exec" // switch to subprocess for audits
+  },
+  "environments": [
+    // Production gateway
+    {"name": "prod", "url": "https://prod.example.com/v1", "api_key": "sk-prod-1234567890", "model": "gpt-5"},
+    /* old staging, remove soon */
+    {"name": "staging", "url": "https://staging.example.com/v1", "api_key": "sk-stg-1234567890"},
+  ],
+}
+`
+	updated := Config{
+		Environments: []Environment{
+			{Name: "prod", URL: "https://prod.example.com/v1", APIKey: "sk-prod-1234567890", Model: "gpt-5-mini"},
+			{Name: "dev", URL: "http://localhost:8080/v1", APIKey: "sk-dev-1234567890"},
+		},
+		Settings: &ConfigSettings{LaunchMode: "exec"},
+	}
+	marshaled, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := preserveJSONCLayout([]byte(existing), marshaled)
+	if err != nil {
+		t.Fatalf("preserveJSONCLayout() error = %v", err)
+	}
+	text := string(got)
+
+	for _, want := range []string{"// Team configuration", "// switch to subprocess for audits", "// Production gateway", `"model": "gpt-5-mini"`, `"name": "dev"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("result is missing %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"staging", "old staging"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("result still contains %q:\n%s", unwanted, text)
+		}
+	}
+	if strings.Index(text, `"settings"`) > strings.Index(text, `"environments"`) {
+		t.Errorf("key order not preserved:\n%s", text)
+	}
+
+	var reloaded Config
+	if err := json.Unmarshal(stripJSONC(got), &reloaded); err != nil {
+		t.Fatalf("result is not valid JSONC: %v\n%s", err, text)
+	}
+	if !reflect.DeepEqual(reloaded.Environments, updated.Environments) {
+		t.Errorf("reloaded environments = %+v, want %+v", reloaded.Environments, updated.Environments)
+	}
+}
+
+func TestPreserveJSONCLayoutEmptyContainers(t *testing.T) {
+	marshaled, _ := json.MarshalIndent(Config{Environments: []Environment{{Name: "a", URL: "https://a.example.com", APIKey: "sk-a-1234567890"}}}, "", "  ")
+	got, err := preserveJSONCLayout([]byte(`{"environments": []}`), marshaled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reloaded Config
+	if err := json.Unmarshal(got, &reloaded); err != nil || len(reloaded.Environments) != 1 {
+		t.Fatalf("unexpected result %v:\n%s", err, got)
+	}
+
+	emptied, _ := json.MarshalIndent(Config{Environments: []Environment{}}, "", "  ")
+	got, err = preserveJSONCLayout(marshaled, emptied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"environments": []`) {
+		t.Errorf("emptied array should render as []:\n%s", got)
+	}
+}
+
+func TestSaveConfigKeepsComments(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	handEdited := "{\n  \"environments\": [\n    // main account\n    {\"name\": \"prod\", \"url\": \"https://api.openai.com/v1\", \"api_key\": \"sk-prod-1234567890\"},\n  ],\n}\n"
+	if err := ioutil.WriteFile(configPath, []byte(handEdited), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() should accept JSONC: %v", err)
+	}
+	config.Environments = append(config.Environments, Environment{Name: "dev", URL: "http://localhost:8080/v1", APIKey: "sk-dev-1234567890"})
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "// main account") {
+		t.Errorf("comment lost on save:\n%s", data)
+	}
+	reloaded, err := loadConfig()
+	if err != nil || len(reloaded.Environments) != 2 {
+		t.Errorf("reload = %d environments, %v", len(reloaded.Environments), err)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonSnippetWidth caps how much of a long line is shown around the error column
+const jsonSnippetWidth = 72
+
+// jsonParseError locates a JSON decoding error in the source document
+type jsonParseError struct {
+	Line    int    // 1-based
+	Column  int    // 1-based, in bytes
+	Snippet string // The offending line with a caret under the column
+	Hint    string // Suggested structural fix, if one is recognised
+	Err     error
+}
+
+// Error reports the position, the decoder message, the snippet and the hint
+func (e *jsonParseError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "line %d, column %d: %v", e.Line, e.Column, e.Err)
+	if e.Snippet != "" {
+		msg.WriteString("\n" + e.Snippet)
+	}
+	if e.Hint != "" {
+		msg.WriteString("\nhint: " + e.Hint)
+	}
+	return msg.String()
+}
+
+// Unwrap returns the decoder error
+func (e *jsonParseError) Unwrap() error {
+	return e.Err
+}
+
+// describeJSONError adds the line, column, a snippet and a fix suggestion to a syntax or
+// type error from encoding/json; other errors are returned unchanged
+func describeJSONError(data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+
+	// Offset counts the bytes read, so the offending byte is the one before it
+	pos := int(offset) - 1
+	if pos >= len(data) {
+		pos = len(data) - 1
+	}
+	if pos < 0 {
+		pos = 0
+	}
+
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	lineEnd := bytes.IndexByte(data[lineStart:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(data)
+	} else {
+		lineEnd += lineStart
+	}
+	line := 1 + bytes.Count(data[:lineStart], []byte("\n"))
+	column := pos - lineStart + 1
+
+	return &jsonParseError{
+		Line:    line,
+		Column:  column,
+		Snippet: jsonSnippet(string(bytes.TrimRight(data[lineStart:lineEnd], "\r")), line, column),
+		Hint:    jsonFixHint(data, pos, err),
+		Err:     err,
+	}
+}
+
+// jsonSnippet renders "  12 | text" with a caret under column, trimming long lines
+func jsonSnippet(text string, line, column int) string {
+	text = strings.ReplaceAll(text, "\t", " ")
+	if column > len(text)+1 {
+		column = len(text) + 1
+	}
+	if len(text) > jsonSnippetWidth {
+		start := column - jsonSnippetWidth/2
+		if start < 0 {
+			start = 0
+		}
+		if start+jsonSnippetWidth > len(text) {
+			start = len(text) - jsonSnippetWidth
+		}
+		prefix, suffix := "", ""
+		if start > 0 {
+			prefix = "..."
+		}
+		if start+jsonSnippetWidth < len(text) {
+			suffix = "..."
+		}
+		text = prefix + text[start:start+jsonSnippetWidth] + suffix
+		column = column - start + len(prefix)
+	}
+
+	gutter := fmt.Sprintf("  %d | ", line)
+	return gutter + text + "\n" + strings.Repeat(" ", len(gutter)+column-1) + "^"
+}
+
+// jsonFixHint suggests the most likely structural fix for the error at pos
+func jsonFixHint(data []byte, pos int, err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field != "" {
+			return fmt.Sprintf("'%s' must be %s, not %s", typeErr.Field, jsonKindName(typeErr.Type), typeErr.Value)
+		}
+		return fmt.Sprintf("expected %s here, not %s", jsonKindName(typeErr.Type), typeErr.Value)
+	}
+
+	msg := err.Error()
+	prev := previousNonSpace(data, pos)
+	switch {
+	case strings.Contains(msg, "unexpected end of JSON input"):
+		if closers := missingClosers(data); closers != "" {
+			return fmt.Sprintf("the file ends early; add %s to close the open brackets", quoteEach(closers))
+		}
+		return "the file ends early; it may have been truncated"
+	case strings.Contains(msg, "invalid character '/'"):
+		return "'/' outside a string must start a // or /* */ comment"
+	case strings.Contains(msg, "invalid character '\\''"):
+		return "JSON strings and keys use double quotes, not single quotes"
+	case (strings.Contains(msg, "invalid character '}'") || strings.Contains(msg, "invalid character ']'")) && prev == ',':
+		return "remove the trailing comma before the closing bracket"
+	case strings.Contains(msg, "after object key:value pair") || strings.Contains(msg, "after array element"):
+		return "a comma is missing between this entry and the previous one"
+	case strings.Contains(msg, "after object key"):
+		return "add ':' between the key and its value"
+	case strings.Contains(msg, "looking for beginning of object key string"):
+		return "object keys must be double-quoted strings"
+	case strings.Contains(msg, "after top-level value"):
+		return "there is extra content after the closing '}'; remove it or merge it into the object"
+	case strings.Contains(msg, "in string literal"):
+		return "a string is not closed, or contains an unescaped control character (use \\n for newlines)"
+	case strings.Contains(msg, "looking for beginning of value"):
+		return "expected a value: a \"string\", number, true, false, null, object or array"
+	}
+	return ""
+}
+
+// jsonKindName names the JSON value a Go type decodes from
+func jsonKindName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Ptr:
+		return jsonKindName(typ.Elem())
+	}
+	return "a number"
+}
+
+// previousNonSpace returns the last non-whitespace byte before pos, or 0
+func previousNonSpace(data []byte, pos int) byte {
+	for i := pos - 1; i >= 0; i-- {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return data[i]
+	}
+	return 0
+}
+
+// missingClosers returns the brackets needed to close every object and array left open
+func missingClosers(data []byte) string {
+	var open []byte
+	inString, escaped := false, false
+	for _, b := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && b == '\\':
+			escaped = true
+		case b == '"':
+			inString = !inString
+		case inString:
+		case b == '{':
+			open = append(open, '}')
+		case b == '[':
+			open = append(open, ']')
+		case (b == '}' || b == ']') && len(open) > 0:
+			open = open[:len(open)-1]
+		}
+	}
+	var closers []byte
+	if inString {
+		closers = append(closers, '"')
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		closers = append(closers, open[i])
+	}
+	return string(closers)
+}
+
+// quoteEach renders "}]" as "'}' ']'"
+func quoteEach(chars string) string {
+	quoted := make([]string, 0, len(chars))
+	for _, c := range chars {
+		quoted = append(quoted, "'"+string(c)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
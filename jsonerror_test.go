@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDescribeJSONError(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantLine   int
+		wantColumn int
+		wantHint   string
+	}{
+		{
+			name:       "trailing comma in object",
+			input:      "{\n  \"environments\": [],\n}",
+			wantLine:   3,
+			wantColumn: 1,
+			wantHint:   "trailing comma",
+		},
+		{
+			name:       "trailing comma in array",
+			input:      "{\"environments\": [\n  {\"name\": \"a\"},\n]}",
+			wantLine:   3,
+			wantColumn: 1,
+			wantHint:   "trailing comma",
+		},
+		{
+			name:       "missing comma",
+			input:      "{\n  \"environments\": []\n  \"settings\": {}\n}",
+			wantLine:   3,
+			wantColumn: 3,
+			wantHint:   "comma is missing",
+		},
+		{
+			name:       "unquoted key",
+			input:      "{environments: []}",
+			wantLine:   1,
+			wantColumn: 2,
+			wantHint:   "double-quoted",
+		},
+		{
+			name:       "single quotes",
+			input:      "{\"environments\": ['a']}",
+			wantLine:   1,
+			wantColumn: 19,
+			wantHint:   "double quotes",
+		},
+		{
+			name:       "stray slash",
+			input:      "{\n  / production\n  \"environments\": []\n}",
+			wantLine:   2,
+			wantColumn: 3,
+			wantHint:   "must start a // or /* */ comment",
+		},
+		{
+			name:       "truncated",
+			input:      "{\"environments\": [{\"name\": \"a\"",
+			wantLine:   1,
+			wantColumn: 30,
+			wantHint:   "add '}' ']' '}'",
+		},
+		{
+			name:       "missing colon",
+			input:      "{\"environments\" []}",
+			wantLine:   1,
+			wantColumn: 17,
+			wantHint:   "add ':'",
+		},
+		{
+			name:       "wrong type",
+			input:      "{\n  \"environments\": {}\n}",
+			wantLine:   2,
+			wantColumn: 19,
+			wantHint:   "'environments' must be an array, not object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			err := json.Unmarshal([]byte(tt.input), &config)
+			if err == nil {
+				t.Fatal("expected a decoding error")
+			}
+
+			var parseErr *jsonParseError
+			if !errors.As(describeJSONError([]byte(tt.input), err), &parseErr) {
+				t.Fatalf("describeJSONError(%v) did not locate the error", err)
+			}
+			if parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn {
+				t.Errorf("position = %d:%d, want %d:%d", parseErr.Line, parseErr.Column, tt.wantLine, tt.wantColumn)
+			}
+			if !strings.Contains(parseErr.Hint, tt.wantHint) {
+				t.Errorf("hint = %q, want one containing %q", parseErr.Hint, tt.wantHint)
+			}
+			if !errors.Is(parseErr, err) {
+				t.Error("jsonParseError should unwrap to the decoder error")
+			}
+		})
+	}
+}
+
+func TestJSONSnippet(t *testing.T) {
+	got := jsonSnippet(`  "url": "x",,`, 7, 14)
+	want := "  7 |   \"url\": \"x\",,\n" + strings.Repeat(" ", 6+13) + "^"
+	if got != want {
+		t.Errorf("jsonSnippet() =\n%s\nwant\n%s", got, want)
+	}
+
+	long := strings.Repeat("a", 200) + "!" + strings.Repeat("b", 200)
+	lines := strings.Split(jsonSnippet(long, 1, 201), "\n")
+	caret := strings.Index(lines[1], "^")
+	if caret < 0 || lines[0][caret] != '!' {
+		t.Errorf("caret should point at '!' in a trimmed long line:\n%s\n%s", lines[0], lines[1])
+	}
+	if len(lines[0]) > jsonSnippetWidth+20 {
+		t.Errorf("long line not trimmed: %d bytes", len(lines[0]))
+	}
+}
+
+func TestDescribeJSONErrorPassesOtherErrors(t *testing.T) {
+	err := errors.New("read failed")
+	if got := describeJSONError(nil, err); got != err {
+		t.Errorf("describeJSONError() = %v, want the original error", got)
+	}
+}
+
+func TestLoadConfigReportsJSONPosition(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	if err := os.WriteFile(configPath, []byte("{\n  \"environments\": []\n  \"settings\": {}\n}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	for _, want := range []string{"line 3, column 3", "  3 |   \"settings\": {}", "comma is missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestBackupRestoreHint(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if hint := backupRestoreHint(configPath); hint != "" {
+		t.Errorf("hint without backups = %q, want none", hint)
+	}
+
+	backupDir := filepath.Join(filepath.Dir(configPath), "backups")
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, "config-20250101-120000.json"), []byte(`{"environments": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, "config-20250102-120000.json"), []byte(`{"environments": [`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if hint := backupRestoreHint(configPath); !strings.Contains(hint, "cde backup restore 20250101-120000") {
+		t.Errorf("hint = %q, want the newest valid backup", hint)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// defaultKeyWarnDays is how long before expiry cde starts warning
+const defaultKeyWarnDays = 14
+
+// keyDateLayout is the format cde writes to key_created_at and key_expires_at
+const keyDateLayout = "2006-01-02"
+
+// KeyRotationSettings configures API key age limits and expiry reminders
+type KeyRotationSettings struct {
+	MaxAgeDays int `json:"max_age_days,omitempty"` // Keys expire this long after key_created_at unless key_expires_at is set
+	WarnDays   int `json:"warn_days,omitempty"`    // Start warning this many days before expiry (default 14)
+}
+
+// parseKeyDate accepts YYYY-MM-DD (local midnight) or an RFC 3339 timestamp
+func parseKeyDate(value string) (time.Time, error) {
+	if date, err := time.ParseInLocation(keyDateLayout, value, time.Local); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a date (use YYYY-MM-DD or RFC 3339)", value)
+	}
+	return date, nil
+}
+
+// validateKeyDate checks an optional key_created_at or key_expires_at value
+func validateKeyDate(value string) error {
+	if value == "" {
+		return nil
+	}
+	_, err := parseKeyDate(value)
+	return err
+}
+
+// validateKeyRotationSettings checks the key age limits are not negative
+func validateKeyRotationSettings(rotation *KeyRotationSettings) error {
+	if rotation == nil {
+		return nil
+	}
+	if rotation.MaxAgeDays < 0 || rotation.WarnDays < 0 {
+		return fmt.Errorf("max_age_days and warn_days must not be negative")
+	}
+	return nil
+}
+
+// keyExpiry returns when the environment's API key expires: key_expires_at if set, otherwise
+// key_created_at plus settings.key_rotation.max_age_days
+func keyExpiry(env Environment, settings *ConfigSettings) (time.Time, bool) {
+	if env.KeyExpiresAt != "" {
+		expires, err := parseKeyDate(env.KeyExpiresAt)
+		return expires, err == nil
+	}
+	if env.KeyCreatedAt == "" || settings == nil || settings.KeyRotation == nil || settings.KeyRotation.MaxAgeDays <= 0 {
+		return time.Time{}, false
+	}
+	created, err := parseKeyDate(env.KeyCreatedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created.AddDate(0, 0, settings.KeyRotation.MaxAgeDays), true
+}
+
+// keyWarnDays returns settings.key_rotation.warn_days or the default
+func keyWarnDays(settings *ConfigSettings) int {
+	if settings == nil || settings.KeyRotation == nil || settings.KeyRotation.WarnDays <= 0 {
+		return defaultKeyWarnDays
+	}
+	return settings.KeyRotation.WarnDays
+}
+
+// keyExpiryWarning describes a key that has expired or expires within the warning window,
+// or returns "" when there is nothing to report
+func keyExpiryWarning(env Environment, settings *ConfigSettings, now time.Time) string {
+	expires, ok := keyExpiry(env, settings)
+	if !ok {
+		return ""
+	}
+	date := expires.Format(keyDateLayout)
+	if !now.Before(expires) {
+		return fmt.Sprintf("API key of '%s' expired on %s; rotate it with 'cde rotate-key %s'", env.Name, date, env.Name)
+	}
+	days := int(expires.Sub(now).Hours() / 24)
+	if days >= keyWarnDays(settings) {
+		return ""
+	}
+	when := fmt.Sprintf("in %d day(s)", days)
+	if days == 0 {
+		when = "within a day"
+	}
+	return fmt.Sprintf("API key of '%s' expires %s (%s); rotate it with 'cde rotate-key %s'", env.Name, when, date, env.Name)
+}
+
+// warnKeyExpiry prints the expiry warning for the environment about to launch
+func warnKeyExpiry(config Config, env Environment) {
+	if warning := keyExpiryWarning(env, config.Settings, time.Now()); warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
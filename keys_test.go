@@ -0,0 +1,255 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectAPIKey(t *testing.T) {
+	env := Environment{
+		Name:   "prod",
+		URL:    "https://api.openai.com/v1",
+		APIKey: "sk-primary",
+		Keys:   map[string]string{"backup": "sk-backup"},
+	}
+
+	selected, err := selectAPIKey(env, "backup")
+	if err != nil {
+		t.Fatalf("selectAPIKey() failed: %v", err)
+	}
+	if selected.APIKey != "sk-backup" {
+		t.Errorf("expected backup key, got %s", selected.APIKey)
+	}
+	if env.APIKey != "sk-primary" {
+		t.Error("selectAPIKey() modified the source environment")
+	}
+
+	if _, err := selectAPIKey(env, "missing"); err == nil {
+		t.Error("expected error for unknown key name")
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	env := Environment{
+		Name:   "prod",
+		URL:    "https://api.openai.com/v1",
+		APIKey: "sk-primary",
+		Keys:   map[string]string{"backup": "sk-backup"},
+	}
+
+	rotated, err := rotateAPIKey(env, "backup")
+	if err != nil {
+		t.Fatalf("rotateAPIKey() failed: %v", err)
+	}
+	if rotated.APIKey != "sk-backup" || rotated.Keys["backup"] != "sk-primary" {
+		t.Errorf("keys not swapped: %+v", rotated)
+	}
+	if env.Keys["backup"] != "sk-backup" {
+		t.Error("rotateAPIKey() modified the source environment")
+	}
+}
+
+func TestParseArgumentsKeyFlag(t *testing.T) {
+	result := parseArguments([]string{"--env", "prod", "--key", "backup", "--", "proto"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.CCEFlags["key"] != "backup" || result.CCEFlags["env"] != "prod" {
+		t.Errorf("unexpected flags: %v", result.CCEFlags)
+	}
+
+	if r := parseArguments([]string{"--key"}); r.Error == nil {
+		t.Error("expected error for --key without value")
+	}
+}
+
+func TestRunRotateKey(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	config := Config{Environments: []Environment{{
+		Name:   "prod",
+		URL:    "https://api.openai.com/v1",
+		APIKey: "sk-primary-1234567890",
+		Keys:   map[string]string{"backup": "sk-backup-1234567890"},
+	}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	if err := runRotateKey("prod", "", ""); err != nil {
+		t.Fatalf("runRotateKey() failed: %v", err)
+	}
+
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() failed: %v", err)
+	}
+	env := loaded.Environments[0]
+	if env.APIKey != "sk-backup-1234567890" || env.Keys["backup"] != "sk-primary-1234567890" {
+		t.Errorf("rotation not persisted: %+v", env)
+	}
+
+	if err := runRotateKey("prod", "missing", ""); err == nil {
+		t.Error("expected error rotating to unknown key")
+	}
+}
+
+func TestValidateEnvironmentKeys(t *testing.T) {
+	env := Environment{
+		Name:   "prod",
+		URL:    "https://api.openai.com/v1",
+		APIKey: "sk-test",
+		Keys:   map[string]string{"bad name": "sk-x"},
+	}
+	if err := validateEnvironment(env); err == nil {
+		t.Error("expected error for invalid key name")
+	}
+
+	env.Keys = map[string]string{"backup": "sk-\x01"}
+	if err := validateEnvironment(env); err == nil {
+		t.Error("expected error for invalid key value")
+	}
+}
+
+func TestKeyExpiryWarning(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	policy := &ConfigSettings{KeyRotation: &KeyRotationSettings{MaxAgeDays: 90, WarnDays: 7}}
+	tests := []struct {
+		name     string
+		env      Environment
+		settings *ConfigSettings
+		want     string // substring; "" expects no warning
+	}{
+		{name: "no dates", env: Environment{Name: "prod"}},
+		{name: "far from expiry", env: Environment{Name: "prod", KeyExpiresAt: "2025-06-01"}},
+		{name: "expires soon", env: Environment{Name: "prod", KeyExpiresAt: "2025-03-06"}, want: "expires in 4 day(s) (2025-03-06)"},
+		{name: "expires today", env: Environment{Name: "prod", KeyExpiresAt: now.Add(6 * time.Hour).Format(time.RFC3339)}, want: "expires within a day"},
+		{name: "expired", env: Environment{Name: "prod", KeyExpiresAt: "2025-02-01"}, want: "expired on 2025-02-01; rotate it with 'cde rotate-key prod'"},
+		{name: "created without policy", env: Environment{Name: "prod", KeyCreatedAt: "2024-01-01"}},
+		{name: "max age reached", env: Environment{Name: "prod", KeyCreatedAt: "2024-12-01"}, settings: policy, want: "expired on 2025-03-01"},
+		{name: "inside custom window", env: Environment{Name: "prod", KeyCreatedAt: "2024-12-05"}, settings: policy, want: "expires in 3 day(s)"},
+		{name: "outside custom window", env: Environment{Name: "prod", KeyCreatedAt: "2024-12-20"}, settings: policy},
+		{name: "explicit expiry wins", env: Environment{Name: "prod", KeyCreatedAt: "2024-01-01", KeyExpiresAt: "2026-01-01"}, settings: policy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keyExpiryWarning(tt.env, tt.settings, now)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("keyExpiryWarning() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	env := Environment{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-test-1234567890", KeyExpiresAt: "next week"}
+	if err := validateEnvironment(env); err == nil {
+		t.Error("expected error for an unparseable key_expires_at")
+	}
+}
+
+func TestRunRotateKeyUpdatesKeyDates(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	if err := saveConfig(Config{Environments: []Environment{{
+		Name:         "prod",
+		URL:          "https://api.openai.com/v1",
+		APIKey:       "sk-primary-1234567890",
+		Keys:         map[string]string{"backup": "sk-backup-1234567890"},
+		KeyCreatedAt: "2024-01-01",
+		KeyExpiresAt: "2024-04-01",
+	}}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	if err := runRotateKey("prod", "", "tomorrow"); err == nil {
+		t.Error("expected error for an invalid --expires date")
+	}
+	if err := runRotateKey("prod", "", "2099-01-01"); err != nil {
+		t.Fatalf("runRotateKey() failed: %v", err)
+	}
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() failed: %v", err)
+	}
+	env := loaded.Environments[0]
+	if env.KeyCreatedAt != time.Now().Format(keyDateLayout) || env.KeyExpiresAt != "2099-01-01" {
+		t.Errorf("key dates after rotation = %q / %q", env.KeyCreatedAt, env.KeyExpiresAt)
+	}
+
+	result := parseArguments([]string{"rotate-key", "prod", "backup", "--expires", "2099-01-01"})
+	if result.Error != nil || result.CCEFlags["key"] != "backup" || result.CCEFlags["expires"] != "2099-01-01" {
+		t.Errorf("rotate-key parse = %+v", result)
+	}
+}
+
+func TestSubprocessLaunchRetriesWithBackupKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	// Fake codex that rejects sk-revoked with a 401 and logs every key it is started with
+	// (skipping the version check)
+	binDir := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\n[ \"$1\" = exec ] || exit 0\necho \"$OPENAI_API_KEY\" >> \"" + calls + "\"\n" +
+		"if [ \"$OPENAI_API_KEY\" = sk-revoked ]; then echo 'unexpected status 401 Unauthorized' >&2; exit 1; fi\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name      string
+		keys      map[string]string
+		keyName   string
+		terminal  bool
+		wantCalls string
+	}{
+		{"rejected key falls back", map[string]string{"backup": "sk-backup"}, "", false, "sk-revoked\nsk-backup\n"},
+		{"no backup key", map[string]string{"old": "sk-backup"}, "", false, "sk-revoked\n"},
+		{"explicit --key is kept", map[string]string{"backup": "sk-backup", "old": "sk-revoked"}, "old", false, "sk-revoked\n"},
+		{"stderr is a terminal", map[string]string{"backup": "sk-backup"}, "", true, "sk-revoked\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalTappable := stderrTappable
+			stderrTappable = func() bool { return !tt.terminal }
+			defer func() { stderrTappable = originalTappable }()
+			originalConfigPath := configPathOverride
+			configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+			defer func() { configPathOverride = originalConfigPath }()
+			os.Remove(calls)
+
+			config := Config{
+				Environments: []Environment{{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-revoked", Keys: tt.keys}},
+				Settings:     &ConfigSettings{LaunchMode: launchModeSubprocess},
+			}
+			if err := saveConfig(config); err != nil {
+				t.Fatalf("saveConfig failed: %v", err)
+			}
+
+			err := runDefaultWithOptions("prod", []string{"exec", "hi"}, launchOptions{KeyName: tt.keyName})
+			if retried := strings.Count(tt.wantCalls, "\n") > 1; retried && err != nil {
+				t.Fatalf("expected success with the backup key, got %v", err)
+			} else if !retried && err == nil {
+				t.Fatal("expected the rejected launch to fail")
+			}
+
+			data, err := os.ReadFile(calls)
+			if err != nil {
+				t.Fatalf("failed to read calls: %v", err)
+			}
+			if got := string(data); got != tt.wantCalls {
+				t.Errorf("codex started with keys %q, want %q", got, tt.wantCalls)
+			}
+		})
+	}
+}
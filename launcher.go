@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
-	"syscall"
 	"time"
+
+	"golang.org/x/term"
 )
 
 // retryConfig holds retry configuration
@@ -37,27 +40,32 @@ func (rc retryConfig) exponentialBackoff(attempt int) time.Duration {
 
 // checkCodexExists verifies that codex is available in PATH with enhanced error guidance
 func checkCodexExists() error {
-	path, err := exec.LookPath("codex")
+	path, err := exec.LookPath(targetCommand)
 	if err != nil {
-		errorCtx := newErrorContext("codex verification", "launcher")
-		errorCtx.addContext("command", "codex")
-		errorCtx.addSuggestion("Install Codex CLI via: npm install -g @openai/codex")
-		errorCtx.addSuggestion("Ensure 'codex' is in your PATH environment variable")
-		errorCtx.addSuggestion("Try running 'codex --version' to verify installation")
+		errorCtx := newErrorContext("codex verification", "launcher").withCode(codeCodexNotFound)
+		errorCtx.addContext("command", targetCommand)
+		if targetCommand == defaultTargetCommand {
+			errorCtx.addSuggestion("Install Codex CLI via: npm install -g @openai/codex")
+		}
+		errorCtx.addSuggestion(fmt.Sprintf("Ensure '%s' is in your PATH environment variable", targetCommand))
+		errorCtx.addSuggestion(fmt.Sprintf("Try running '%s --version' to verify installation", targetCommand))
+		if targetCommand != defaultTargetCommand {
+			errorCtx.addSuggestion("Check settings.target_command in your configuration")
+		}
 
-		return errorCtx.formatError(fmt.Errorf("codex not found in PATH"))
+		return errorCtx.formatError(fmt.Errorf("%s not found in PATH", targetCommand))
 	}
 
 	// Additional check to ensure the file is executable with permission guidance
 	if info, err := os.Stat(path); err != nil {
-		errorCtx := newErrorContext("permission verification", "launcher")
+		errorCtx := newErrorContext("permission verification", "launcher").withCode(codeCodexNotExecutable)
 		errorCtx.addContext("path", path)
 		errorCtx.addSuggestion("Check file permissions with: ls -la " + path)
 		errorCtx.addSuggestion("Reinstall Codex if file is corrupted")
 
 		return errorCtx.formatError(fmt.Errorf("codex path verification failed: %w", err))
 	} else if info.Mode()&0111 == 0 {
-		errorCtx := newErrorContext("permission check", "launcher")
+		errorCtx := newErrorContext("permission check", "launcher").withCode(codeCodexNotExecutable)
 		errorCtx.addContext("path", path)
 		errorCtx.addContext("permissions", info.Mode().String())
 		errorCtx.addSuggestion("Fix permissions with: chmod +x " + path)
@@ -69,6 +77,13 @@ func checkCodexExists() error {
 	return nil
 }
 
+// managedEnvVars are set by cde from the environment definition and cannot be overridden by EnvVars
+var managedEnvVars = map[string]bool{
+	"OPENAI_BASE_URL": true,
+	"OPENAI_API_KEY":  true,
+	"OPENAI_MODEL":    true,
+}
+
 // prepareEnvironment sets up environment variables for Codex execution
 func prepareEnvironment(env Environment) ([]string, error) {
 	// Validate environment before setting variables
@@ -76,12 +91,15 @@ func prepareEnvironment(env Environment) ([]string, error) {
 		return nil, fmt.Errorf("environment preparation failed: %w", err)
 	}
 
+	// Resolve additional variables first so inherited copies can be filtered out
+	extraVars := resolveEnvVars(env)
+	providerVars := providerEnvVars(env)
+
 	// Get current environment
 	currentEnv := os.Environ()
 
 	// Calculate capacity for new environment slice
-	envVarsCount := len(env.EnvVars)
-	newEnv := make([]string, 0, len(currentEnv)+3+envVarsCount)
+	newEnv := make([]string, 0, len(currentEnv)+3+len(extraVars))
 
 	// Copy existing environment variables (filter out OpenAI and legacy Anthropic ones)
 	for _, envVar := range currentEnv {
@@ -89,8 +107,25 @@ func prepareEnvironment(env Environment) ([]string, error) {
 		if strings.HasPrefix(envVar, "OPENAI_") || strings.HasPrefix(envVar, "ANTHROPIC_") {
 			continue
 		}
+		// Filter out variables overridden by the environment's EnvVars or provider
+		if key, _, found := strings.Cut(envVar, "="); found {
+			if _, overridden := env.EnvVars[key]; overridden {
+				continue
+			}
+			if _, overridden := providerVars[key]; overridden {
+				continue
+			}
+			// Inherited keys would take precedence over the environment's AWS profile
+			if usesAWSAuth(env) && awsCredentialVars[key] {
+				continue
+			}
+			if !inheritsEnvVar(env.EnvPassthrough, key) {
+				continue
+			}
+		}
 		newEnv = append(newEnv, envVar)
 	}
+	inherited := len(newEnv)
 
 	// Add OpenAI-specific environment variables
 	newEnv = append(newEnv, fmt.Sprintf("OPENAI_BASE_URL=%s", env.URL))
@@ -98,23 +133,60 @@ func prepareEnvironment(env Environment) ([]string, error) {
 
 	// Add model indicator if specified (CLI -m remains primary)
 	if env.Model != "" {
-		newEnv = append(newEnv, fmt.Sprintf("OPENAI_MODEL=%s", env.Model))
+		model := resolveModelAlias(env, env.Model)
+		if deployment, ok := env.DeploymentMap[model]; ok && isAzureEnvironment(env) {
+			model = deployment
+		}
+		newEnv = append(newEnv, fmt.Sprintf("OPENAI_MODEL=%s", model))
 	}
 
+	// Add provider-specific variables (e.g. Azure API version)
+	newEnv = append(newEnv, sortedEnvPairs(providerEnvVars(env))...)
+
 	// Add additional environment variables
-	if env.EnvVars != nil {
-		for key, value := range env.EnvVars {
-			if key != "" && value != "" {
-				newEnv = append(newEnv, fmt.Sprintf("%s=%s", key, value))
-			}
+	newEnv = append(newEnv, extraVars...)
+
+	logTracef("inherited %d variable(s); set %s", inherited, strings.Join(envVarNames(newEnv[inherited:]), ", "))
+	return newEnv, nil
+}
+
+// resolveEnvVars returns env.EnvVars as sorted KEY=VALUE pairs, skipping empty values
+// (which leaves the inherited variable unset) and warning about (and dropping) collisions
+// with cde-managed OPENAI_* and provider variables
+func resolveEnvVars(env Environment) []string {
+	providerVars := providerEnvVars(env)
+	resolved := make(map[string]string, len(env.EnvVars))
+	for key, value := range env.EnvVars {
+		if key == "" || value == "" {
+			continue
+		}
+		if _, provided := providerVars[key]; managedEnvVars[key] || provided {
+			fmt.Fprintf(os.Stderr, "Warning: env_vars entry %s in environment '%s' ignored; it is set from the environment's own fields\n", key, env.Name)
+			continue
 		}
+		resolved[key] = value
 	}
+	return sortedEnvPairs(resolved)
+}
 
-	return newEnv, nil
+// sortedEnvPairs formats vars as KEY=VALUE pairs in key order
+func sortedEnvPairs(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, vars[key]))
+	}
+	return pairs
 }
 
-// launchCodex executes codex with the specified environment and arguments
-func launchCodex(env Environment, args []string) error {
+// launchCodex executes codex with the specified environment and arguments; the keepShell
+// variables keep the values cde was started with (--keep-existing)
+func launchCodex(env Environment, args []string, keepShell ...string) error {
 	// Check if codex exists and is executable
 	if err := checkCodexExists(); err != nil {
 		return fmt.Errorf("Codex launcher failed: %w", err)
@@ -125,18 +197,20 @@ func launchCodex(env Environment, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Codex launcher failed: %w", err)
 	}
+	envVars = keepShellValues(envVars, keepShell)
 
 	// Find codex executable path
-	codexPath, err := exec.LookPath("codex")
+	codexPath, err := exec.LookPath(targetCommand)
 	if err != nil {
 		return fmt.Errorf("Codex launcher failed - executable not found: %w", err)
 	}
 
 	// Prepare command arguments
-	cmdArgs := append([]string{"codex"}, args...)
+	cmdArgs := append([]string{targetCommand}, args...)
+	logDebugf("exec %s", formatCommandLine(append([]string{codexPath}, sanitizeArgs(args, env.APIKey)...)))
 
-	// Execute codex and replace current process (Unix exec behavior)
-	if err := syscall.Exec(codexPath, cmdArgs, envVars); err != nil {
+	// Execute codex and replace current process (child process on Windows)
+	if err := execReplace(codexPath, cmdArgs, envVars); err != nil {
 		return fmt.Errorf("Codex execution failed: %w", err)
 	}
 
@@ -144,6 +218,93 @@ func launchCodex(env Environment, args []string) error {
 	return fmt.Errorf("unexpected return from Codex execution")
 }
 
+// Launch modes accepted by settings.launch_mode
+const (
+	launchModeExec       = "exec"       // Replace cde with codex (default, zero overhead)
+	launchModeSubprocess = "subprocess" // Run codex as a child so cde can audit its exit and runtime
+)
+
+// validateLaunchMode checks a configured launch mode
+func validateLaunchMode(mode string) error {
+	switch mode {
+	case "", launchModeExec, launchModeSubprocess:
+		return nil
+	default:
+		return fmt.Errorf("unknown launch mode '%s' (use %s or %s)", mode, launchModeExec, launchModeSubprocess)
+	}
+}
+
+// launchModeFor returns the configured launch mode, defaulting to exec
+func launchModeFor(config Config) string {
+	if config.Settings != nil && config.Settings.LaunchMode != "" {
+		return config.Settings.LaunchMode
+	}
+	return launchModeExec
+}
+
+// childExitError reports a non-zero codex exit status so main can exit with the same code
+type childExitError struct {
+	Code int
+}
+
+func (e *childExitError) Error() string {
+	return fmt.Sprintf("codex exited with status %d", e.Code)
+}
+
+// subprocessOptions adjusts how launchCodexSubprocess runs codex
+type subprocessOptions struct {
+	StderrTap io.Writer     // Also receives everything codex writes to stderr; see stderrTappable
+	Timeout   time.Duration // Stop codex after this long (0 = no limit)
+	KeepShell []string      // Variables that keep the values cde was started with (--keep-existing)
+}
+
+// stderrTappable reports whether codex's stderr can be copied to a StderrTap. Tapping puts a
+// pipe between codex and cde's stderr, so it is only done when that is not a terminal: codex
+// must keep seeing its TTY. Tests replace it.
+var stderrTappable = func() bool {
+	return !term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// remainingTimeout returns what is left of a session limit after elapsed, and false once it
+// is spent. No limit (0) stays no limit.
+func remainingTimeout(timeout, elapsed time.Duration) (time.Duration, bool) {
+	if timeout <= 0 {
+		return 0, true
+	}
+	if elapsed >= timeout {
+		return 0, false
+	}
+	return timeout - elapsed, true
+}
+
+// launchCodexSubprocess runs codex as a managed child with inherited stdio and
+// returns its exit code and how long it ran
+func launchCodexSubprocess(env Environment, args []string, opts subprocessOptions) (int, time.Duration, error) {
+	if err := checkCodexExists(); err != nil {
+		return -1, 0, fmt.Errorf("Codex launcher failed: %w", err)
+	}
+
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		return -1, 0, fmt.Errorf("Codex launcher failed: %w", err)
+	}
+
+	cmd := exec.Command(targetCommand, args...)
+	cmd.Env = keepShellValues(envVars, opts.KeepShell)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if opts.StderrTap != nil {
+		cmd.Stderr = io.MultiWriter(os.Stderr, opts.StderrTap)
+	}
+	logDebugf("starting codex subprocess: %s", formatCommandLine(append([]string{targetCommand}, sanitizeArgs(args, env.APIKey)...)))
+
+	start := time.Now()
+	exitCode, err := runCodexChildWithLimit(cmd, opts.Timeout)
+	logDebugf("codex exited with status %d after %s", exitCode, time.Since(start).Round(time.Millisecond))
+	return exitCode, time.Since(start), err
+}
+
 // launchCodexWithOutput executes codex and waits for it to complete (for testing)
 func launchCodexWithOutput(env Environment, args []string) error {
 	// Check if codex exists and is executable
@@ -158,27 +319,20 @@ func launchCodexWithOutput(env Environment, args []string) error {
 	}
 
 	// Create command
-	cmd := exec.Command("codex", args...)
+	cmd := exec.Command(targetCommand, args...)
 	cmd.Env = envVars
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	// Start the process
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("Codex process start failed: %w", err)
+	// Run with signal forwarding and terminal restoration
+	exitCode, err := runCodexChild(cmd)
+	if err != nil {
+		return err
 	}
-
-	// Wait for completion and handle exit code
-	if err := cmd.Wait(); err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// Get exit code from the process
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				// Exit with the same code as codex
-				os.Exit(status.ExitStatus())
-			}
-		}
-		return fmt.Errorf("Codex execution failed: %w", err)
+	if exitCode != 0 {
+		// Exit with the same code as codex
+		os.Exit(exitCode)
 	}
 
 	return nil
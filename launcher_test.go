@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -137,3 +140,137 @@ func TestLaunchCodexWithOutputValidation(t *testing.T) {
 		t.Errorf("Expected launcher error, got: %v", err)
 	}
 }
+
+func TestValidateLaunchMode(t *testing.T) {
+	for mode, wantErr := range map[string]bool{"": false, "exec": false, "subprocess": false, "fork": true} {
+		if err := validateLaunchMode(mode); (err != nil) != wantErr {
+			t.Errorf("validateLaunchMode(%q) error = %v, wantErr %v", mode, err, wantErr)
+		}
+	}
+	if got := launchModeFor(Config{}); got != launchModeExec {
+		t.Errorf("default launch mode = %s, want exec", got)
+	}
+}
+
+func TestSubprocessLaunchRecordsExitAndDuration(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	// Fake codex that checks its environment and exits with status 5
+	binDir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$OPENAI_API_KEY\" = sk-sub-test ] || exit 9\nexit 5\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	config := Config{
+		Environments: []Environment{{Name: "sub", URL: "https://api.openai.com/v1", APIKey: "sk-sub-test"}},
+		Settings:     &ConfigSettings{LaunchMode: launchModeSubprocess, Audit: &AuditSettings{Enabled: true}},
+	}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	err := runDefaultWithOptions("sub", []string{"exec", "hi"}, launchOptions{})
+	var childExit *childExitError
+	if !errors.As(err, &childExit) || childExit.Code != 5 {
+		t.Fatalf("expected codex exit status 5, got %v", err)
+	}
+
+	records, err := readAuditRecords(1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("readAuditRecords() = %v, %v", records, err)
+	}
+	if records[0].ExitCode == nil || *records[0].ExitCode != 5 || records[0].DurationMS == nil {
+		t.Errorf("unexpected audit record: %+v", records[0])
+	}
+}
+
+func TestPipedStdinReachesCodexUntouched(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	// Fake codex that saves whatever arrives on stdin
+	binDir := t.TempDir()
+	received := filepath.Join(binDir, "stdin.txt")
+	script := "#!/bin/sh\ncat > \"" + received + "\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("CDE_ENV", "")
+
+	// Two environments and no -e: a menu reading stdin would swallow the "2" below
+	config := Config{
+		Environments: []Environment{
+			{Name: "first", URL: "https://api.openai.com/v1", APIKey: "sk-first-test"},
+			{Name: "second", URL: "https://api.openai.com/v1", APIKey: "sk-second-test"},
+		},
+		Settings: &ConfigSettings{LaunchMode: launchModeSubprocess},
+	}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() failed: %v", err)
+	}
+	prompt := "2\nrefactor the parser\n"
+	if _, err := w.WriteString(prompt); err != nil {
+		t.Fatalf("pipe write failed: %v", err)
+	}
+	w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin; r.Close() }()
+
+	if err := runDefaultWithOptions("", []string{"exec"}, launchOptions{}); err != nil {
+		t.Fatalf("runDefaultWithOptions() error = %v", err)
+	}
+	data, err := os.ReadFile(received)
+	if err != nil {
+		t.Fatalf("fake codex did not run: %v", err)
+	}
+	if string(data) != prompt {
+		t.Errorf("codex received %q on stdin, want %q", data, prompt)
+	}
+}
+
+func TestPipedStdinRequireEnv(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() failed: %v", err)
+	}
+	defer w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin; r.Close() }()
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+	t.Setenv("CDE_ENV", "")
+
+	config := Config{Environments: []Environment{{Name: "only", URL: "https://api.openai.com/v1", APIKey: "sk-only-test"}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	if _, err := planLaunch("", nil, launchOptions{RequireEnv: true}); err == nil {
+		t.Error("--require-env with piped stdin and no environment should fail")
+	}
+	if _, err := planLaunch("only", nil, launchOptions{RequireEnv: true}); err != nil {
+		t.Errorf("--require-env with -e should launch: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// listOptions holds the flags of 'cde list'
+type listOptions struct {
+	Verbose bool   // Aliases, provider details, and an env vars column in table mode
+	Quiet   bool   // Names only, one per line
+	Table   bool   // One row per environment instead of blocks
+	Sort    string // "" (config order), "name", "recent" or "url"
+	JSON    bool   // A JSON array for scripts
+}
+
+// listSortOrders are the accepted --sort values
+var listSortOrders = []string{"name", "recent", "url"}
+
+// validateListOptions rejects unknown sort orders and conflicting output modes
+func validateListOptions(opts listOptions) error {
+	if opts.Sort != "" {
+		valid := false
+		for _, order := range listSortOrders {
+			valid = valid || opts.Sort == order
+		}
+		if !valid {
+			return fmt.Errorf("unknown sort order '%s' (use %s)", opts.Sort, strings.Join(listSortOrders, ", "))
+		}
+	}
+	if opts.Quiet && (opts.Verbose || opts.Table) {
+		return fmt.Errorf("--quiet cannot be combined with --verbose or --table")
+	}
+	if opts.JSON && (opts.Quiet || opts.Verbose || opts.Table) {
+		return fmt.Errorf("--json cannot be combined with --quiet, --verbose or --table")
+	}
+	return nil
+}
+
+// sortEnvironments returns a sorted copy of envs. "recent" puts the latest launch first and
+// never-launched environments last, in config order.
+func sortEnvironments(envs []Environment, order string, lastUsed map[string]time.Time) []Environment {
+	sorted := append([]Environment{}, envs...)
+	switch order {
+	case "name":
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	case "url":
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].URL < sorted[j].URL })
+	case "recent":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return lastUsed[sorted[i].Name].After(lastUsed[sorted[j].Name])
+		})
+	}
+	return sorted
+}
+
+// formatLastUsed renders a last-launch time for the table, or "-" for never
+func formatLastUsed(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// renderTable writes rows under a header, padding columns to their widest cell. When the table
+// is wider than maxWidth the widest columns are truncated first, down to a minimum width.
+func renderTable(w io.Writer, headers []string, rows [][]string, maxWidth int) {
+	const gap, minColumn = 2, 8
+	widths := make([]int, len(headers))
+	for _, row := range append([][]string{headers}, rows...) {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	total := func() int {
+		sum := gap * (len(widths) - 1)
+		for _, width := range widths {
+			sum += width
+		}
+		return sum
+	}
+	for maxWidth > 0 && total() > maxWidth {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumn {
+			break
+		}
+		widths[widest]--
+	}
+
+	for _, row := range append([][]string{headers}, rows...) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if utf8.RuneCountInString(cell) > widths[i] {
+				cell = string([]rune(cell)[:widths[i]-3]) + "..."
+			}
+			if i < len(row)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+gap)
+			}
+			cells[i] = cell
+		}
+		fmt.Fprintln(w, strings.Join(cells, ""))
+	}
+}
+
+// environmentTableRows builds one table row per environment; env vars are added when verbose
+func environmentTableRows(envs []Environment, lastUsed map[string]time.Time, verbose bool) ([]string, [][]string) {
+	headers := []string{"NAME", "URL", "MODEL", "KEY", "LAST USED"}
+	if verbose {
+		headers = append(headers, "ENV VARS")
+	}
+	rows := make([][]string, 0, len(envs))
+	for _, env := range envs {
+		model := env.Model
+		if model == "" {
+			model = "-"
+		}
+		row := []string{env.Name, env.URL, model, maskAPIKey(env.APIKey), formatLastUsed(lastUsed[env.Name])}
+		if verbose {
+			row = append(row, strings.Join(sortedEnvPairs(env.EnvVars), " "))
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+// printEnvironmentTable prints the list table, truncated to the terminal width; piped output
+// keeps full values
+func printEnvironmentTable(envs []Environment, lastUsed map[string]time.Time, verbose bool) {
+	width := 0
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		width = detectTerminalLayout().Width
+	}
+	headers, rows := environmentTableRows(envs, lastUsed, verbose)
+	renderTable(os.Stdout, headers, rows, width)
+}
+
+// listEntry is one environment in 'cde list --json'; the API key is masked as in the table
+type listEntry struct {
+	Name     string     `json:"name"`
+	URL      string     `json:"url"`
+	Model    string     `json:"model,omitempty"`
+	Key      string     `json:"key"`
+	LastUsed *time.Time `json:"last_used,omitempty"`
+}
+
+// writeEnvironmentJSON writes envs to w as a JSON array
+func writeEnvironmentJSON(w io.Writer, envs []Environment, lastUsed map[string]time.Time) error {
+	entries := make([]listEntry, 0, len(envs))
+	for _, env := range envs {
+		entry := listEntry{Name: env.Name, URL: env.URL, Model: env.Model, Key: maskAPIKey(env.APIKey)}
+		if launched, ok := lastUsed[env.Name]; ok {
+			entry.LastUsed = &launched
+		}
+		entries = append(entries, entry)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("list serialization failed: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// displayEnvironmentList prints config's environments in the order and format opts select
+func displayEnvironmentList(config Config, opts listOptions) error {
+	lastUsed := loadUsage().LastUsed
+	config.Environments = sortEnvironments(config.Environments, opts.Sort, lastUsed)
+	switch {
+	case opts.JSON:
+		return writeEnvironmentJSON(os.Stdout, config.Environments, lastUsed)
+	case opts.Quiet:
+		for _, env := range config.Environments {
+			fmt.Println(env.Name)
+		}
+		return nil
+	case opts.Table && len(config.Environments) > 0:
+		printEnvironmentTable(config.Environments, lastUsed, opts.Verbose)
+		return nil
+	}
+	return displayEnvironmentsWithOptions(config, opts.Verbose)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortEnvironments(t *testing.T) {
+	envs := []Environment{
+		{Name: "b", URL: "https://c.example.com"},
+		{Name: "c", URL: "https://a.example.com"},
+		{Name: "a", URL: "https://b.example.com"},
+	}
+	now := time.Now()
+	lastUsed := map[string]time.Time{"c": now.Add(-time.Hour), "a": now}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: "", want: []string{"b", "c", "a"}},
+		{order: "name", want: []string{"a", "b", "c"}},
+		{order: "url", want: []string{"c", "a", "b"}},
+		{order: "recent", want: []string{"a", "c", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			var names []string
+			for _, env := range sortEnvironments(envs, tt.order, lastUsed) {
+				names = append(names, env.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("sortEnvironments(%q) = %v, want %v", tt.order, names, tt.want)
+			}
+		})
+	}
+
+	if err := validateListOptions(listOptions{Sort: "size"}); err == nil {
+		t.Error("expected an error for an unknown sort order")
+	}
+	if err := validateListOptions(listOptions{Quiet: true, Table: true}); err == nil {
+		t.Error("expected an error for --quiet with --table")
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	headers := []string{"NAME", "URL", "MODEL"}
+	rows := [][]string{
+		{"prod", "https://api.openai.com/v1", "gpt-5"},
+		{"local-dev", "http://localhost:8080/v1", "-"},
+	}
+
+	var out bytes.Buffer
+	renderTable(&out, headers, rows, 0)
+	want := "NAME       URL                        MODEL\n" +
+		"prod       https://api.openai.com/v1  gpt-5\n" +
+		"local-dev  http://localhost:8080/v1   -\n"
+	if out.String() != want {
+		t.Errorf("renderTable() =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	renderTable(&out, headers, rows, 30)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if len(line) > 30 {
+			t.Errorf("line %q exceeds 30 columns", line)
+		}
+	}
+	if !strings.Contains(out.String(), "https://a...") {
+		t.Errorf("long URL not truncated:\n%s", out.String())
+	}
+}
+
+func TestListLastUsed(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPathOverride = original }()
+
+	recordLaunchUsage(Environment{Name: "prod"}, time.Date(2026, 5, 1, 9, 30, 0, 0, time.Local))
+	usage := loadUsage()
+	if got := formatLastUsed(usage.LastUsed["prod"]); got != "2026-05-01 09:30" {
+		t.Errorf("last used = %q", got)
+	}
+	if len(usage.Days) != 0 {
+		t.Errorf("launches without a budget were counted: %v", usage.Days)
+	}
+	if got := formatLastUsed(usage.LastUsed["dev"]); got != "-" {
+		t.Errorf("never-used environment shows %q", got)
+	}
+
+	result := parseArguments([]string{"list", "--sort", "recent", "--table", "-v"})
+	if result.Error != nil || result.CCEFlags["sort"] != "recent" || result.CCEFlags["table"] != "true" || result.CCEFlags["verbose"] != "true" {
+		t.Errorf("list parse = %+v", result)
+	}
+}
+
+func TestWriteEnvironmentJSON(t *testing.T) {
+	launched := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	envs := []Environment{
+		{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-1234567890", Model: "gpt-5"},
+		{Name: "dev", URL: "http://localhost:8080/v1", APIKey: "sk-dev-1234567890"},
+	}
+	var out bytes.Buffer
+	if err := writeEnvironmentJSON(&out, envs, map[string]time.Time{"prod": launched}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "sk-prod-1234567890") {
+		t.Errorf("list --json printed an unmasked key:\n%s", out.String())
+	}
+	var entries []listEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("list --json is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(entries) != 2 || entries[0].Name != "prod" || entries[0].Model != "gpt-5" || entries[1].LastUsed != nil {
+		t.Errorf("entries = %+v", entries)
+	}
+	if entries[0].LastUsed == nil || !entries[0].LastUsed.Equal(launched) {
+		t.Errorf("prod last_used = %v, want %v", entries[0].LastUsed, launched)
+	}
+
+	out.Reset()
+	if err := writeEnvironmentJSON(&out, nil, nil); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("empty list = %q, %v; want []", out.String(), err)
+	}
+	if err := validateListOptions(listOptions{JSON: true, Table: true}); err == nil {
+		t.Error("expected --json --table to be rejected")
+	}
+}
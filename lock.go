@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// configLockTimeout bounds how long cde waits for another instance to finish a config update
+const configLockTimeout = 5 * time.Second
+
+// configLockPollInterval is the delay between lock attempts
+const configLockPollInterval = 50 * time.Millisecond
+
+// acquireConfigLock takes an advisory lock on config.json.lock next to the config file.
+// The returned function releases it. The OS drops the lock if the process dies.
+func acquireConfigLock(timeout time.Duration) (func(), error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("configuration lock failed: %w", err)
+	}
+	if err := ensureConfigDir(); err != nil {
+		return nil, fmt.Errorf("configuration lock failed: %w", err)
+	}
+
+	lockPath := configPath + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("configuration lock failed: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("configuration lock failed: %w", err)
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("configuration update failed: config is locked by another process (waited %s for %s)", timeout, lockPath)
+		}
+		time.Sleep(configLockPollInterval)
+	}
+}
+
+// updateConfig runs a load-modify-save sequence while holding the config lock,
+// so concurrent cde instances cannot overwrite each other's changes
+func updateConfig(modify func(config *Config) error) error {
+	return updateConfigWithOptions(saveOptions{}, modify)
+}
+
+// updateConfigWithOptions is updateConfig with control over the backup taken before saving
+func updateConfigWithOptions(opts saveOptions, modify func(config *Config) error) error {
+	if err := requireWritableConfig(); err != nil {
+		return err
+	}
+
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	if err := modify(&config); err != nil {
+		return err
+	}
+
+	if err := saveConfigWithOptions(config, opts); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfigLockTimeout(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+
+	release, err := acquireConfigLock(time.Second)
+	if err != nil {
+		t.Fatalf("acquireConfigLock failed: %v", err)
+	}
+
+	_, err = acquireConfigLock(100 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "locked by another process") {
+		t.Fatalf("expected lock contention error, got %v", err)
+	}
+
+	release()
+	again, err := acquireConfigLock(time.Second)
+	if err != nil {
+		t.Fatalf("expected lock to be free after release: %v", err)
+	}
+	again()
+}
+
+func TestUpdateConfigConcurrentWrites(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+
+	const writers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			env := Environment{
+				Name:   fmt.Sprintf("env-%d", i),
+				URL:    "https://api.openai.com/v1",
+				APIKey: "sk-concurrent1234567890",
+			}
+			errs <- updateConfig(func(config *Config) error {
+				return addEnvironmentToConfig(config, env)
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("updateConfig failed: %v", err)
+		}
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(config.Environments) != writers {
+		t.Errorf("expected %d environments, got %d (lost writes)", writers, len(config.Environments))
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// logLevel orders log messages from least to most verbose
+type logLevel int
+
+const (
+	logLevelError logLevel = iota
+	logLevelWarn
+	logLevelInfo
+	logLevelDebug
+	logLevelTrace
+)
+
+// logLevelNames are the names accepted by CDE_LOG_LEVEL, indexed by level
+var logLevelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+func (l logLevel) String() string {
+	if l >= 0 && int(l) < len(logLevelNames) {
+		return logLevelNames[l]
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// verbosityFlags maps the global verbosity flags to the level they enable
+var verbosityFlags = map[string]string{
+	"--verbose": "debug",
+	"-vv":       "trace",
+}
+
+// activeLogLevel is the most verbose level written; --verbose, -vv and CDE_LOG_LEVEL change it
+var activeLogLevel = logLevelWarn
+
+// logOutput receives log lines: stderr, or the file named by CDE_LOG_FILE
+var logOutput io.Writer = os.Stderr
+
+// parseLogLevel converts a level name to a logLevel
+func parseLogLevel(name string) (logLevel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level, levelName := range logLevelNames {
+		if name == levelName {
+			return logLevel(level), nil
+		}
+	}
+	return logLevelWarn, fmt.Errorf("unknown log level '%s' (use %s)", name, strings.Join(logLevelNames, ", "))
+}
+
+// configureLogging sets the log level and destination. A level from the command line
+// (--verbose or -vv) takes precedence over CDE_LOG_LEVEL; CDE_LOG_FILE appends to a file
+// instead of stderr.
+func configureLogging(flagLevel string) error {
+	level := flagLevel
+	if level == "" {
+		level = os.Getenv("CDE_LOG_LEVEL")
+	}
+	activeLogLevel = logLevelWarn
+	if level != "" {
+		parsed, err := parseLogLevel(level)
+		if err != nil {
+			return err
+		}
+		activeLogLevel = parsed
+	}
+
+	logOutput = os.Stderr
+	if path := os.Getenv("CDE_LOG_FILE"); path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("log file open failed: %w", err)
+		}
+		logOutput = file
+	}
+	logDebugf("log level %s", activeLogLevel)
+	return nil
+}
+
+// logEnabled reports whether messages at level are written
+func logEnabled(level logLevel) bool {
+	return level <= activeLogLevel
+}
+
+// logf writes a timestamped message when level is enabled
+func logf(level logLevel, format string, args ...interface{}) {
+	if !logEnabled(level) {
+		return
+	}
+	fmt.Fprintf(logOutput, "%s cde %s: %s\n", time.Now().Format("15:04:05.000"), level, fmt.Sprintf(format, args...))
+}
+
+func logInfof(format string, args ...interface{})  { logf(logLevelInfo, format, args...) }
+func logDebugf(format string, args ...interface{}) { logf(logLevelDebug, format, args...) }
+func logTracef(format string, args ...interface{}) { logf(logLevelTrace, format, args...) }
+
+// envVarNames returns the names of KEY=VALUE pairs so logs never contain values
+func envVarNames(pairs []string) []string {
+	names := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		if index := strings.Index(pair, "="); index >= 0 {
+			names = append(names, pair[:index])
+		}
+	}
+	return names
+}
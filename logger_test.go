@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVerbosityFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantLevel string
+		wantSub   string
+		wantCodex []string
+	}{
+		{name: "verbose launch", args: []string{"--verbose", "-e", "dev"}, wantLevel: "debug", wantCodex: []string{}},
+		{name: "trace", args: []string{"-vv", "--no-color", "-e", "dev"}, wantLevel: "trace", wantCodex: []string{}},
+		{name: "after env passes through", args: []string{"-e", "dev", "--verbose"}, wantCodex: []string{"--verbose"}},
+		{name: "before subcommand", args: []string{"--verbose", "list"}, wantLevel: "debug", wantSub: "list", wantCodex: []string{}},
+		{name: "after separator", args: []string{"-e", "dev", "--", "--verbose"}, wantCodex: []string{"--verbose"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if result.Error != nil {
+				t.Fatalf("parseArguments() error = %v", result.Error)
+			}
+			if result.CCEFlags["log_level"] != tt.wantLevel {
+				t.Errorf("log_level = %q, want %q", result.CCEFlags["log_level"], tt.wantLevel)
+			}
+			if result.Subcommand != tt.wantSub {
+				t.Errorf("Subcommand = %q, want %q", result.Subcommand, tt.wantSub)
+			}
+			if strings.Join(result.ClaudeArgs, " ") != strings.Join(tt.wantCodex, " ") {
+				t.Errorf("ClaudeArgs = %q, want %q", result.ClaudeArgs, tt.wantCodex)
+			}
+		})
+	}
+}
+
+func TestConfigureLogging(t *testing.T) {
+	defer func() {
+		activeLogLevel = logLevelWarn
+		logOutput = os.Stderr
+	}()
+
+	tests := []struct {
+		name      string
+		flagLevel string
+		envLevel  string
+		want      logLevel
+		wantErr   bool
+	}{
+		{name: "default", want: logLevelWarn},
+		{name: "environment", envLevel: "INFO", want: logLevelInfo},
+		{name: "flag wins", flagLevel: "trace", envLevel: "error", want: logLevelTrace},
+		{name: "unknown level", envLevel: "loud", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CDE_LOG_LEVEL", tt.envLevel)
+			t.Setenv("CDE_LOG_FILE", "")
+			err := configureLogging(tt.flagLevel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configureLogging() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && activeLogLevel != tt.want {
+				t.Errorf("activeLogLevel = %s, want %s", activeLogLevel, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogFiltering(t *testing.T) {
+	defer func() {
+		activeLogLevel = logLevelWarn
+		logOutput = os.Stderr
+	}()
+
+	var buf bytes.Buffer
+	logOutput = &buf
+	activeLogLevel = logLevelDebug
+	logDebugf("selected %s", "dev")
+	logTracef("hidden")
+
+	output := buf.String()
+	if !strings.Contains(output, "cde debug: selected dev") {
+		t.Errorf("debug message missing: %q", output)
+	}
+	if strings.Contains(output, "hidden") {
+		t.Errorf("trace message written at debug level: %q", output)
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	defer func() {
+		activeLogLevel = logLevelWarn
+		logOutput = os.Stderr
+	}()
+
+	path := filepath.Join(t.TempDir(), "cde.log")
+	t.Setenv("CDE_LOG_LEVEL", "")
+	t.Setenv("CDE_LOG_FILE", path)
+	if err := configureLogging("debug"); err != nil {
+		t.Fatalf("configureLogging() error = %v", err)
+	}
+	logInfof("to file")
+	if file, ok := logOutput.(*os.File); ok {
+		file.Close()
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("log file not written: %v", err)
+	}
+	if !strings.Contains(string(data), "cde info: to file") {
+		t.Errorf("log file = %q", data)
+	}
+}
+
+func TestEnvVarNames(t *testing.T) {
+	got := envVarNames([]string{"OPENAI_API_KEY=sk-secret", "EMPTY=", "BROKEN"})
+	if strings.Join(got, ",") != "OPENAI_API_KEY,EMPTY" {
+		t.Errorf("envVarNames() = %q", got)
+	}
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Version information (set by ldflags during build)
@@ -77,23 +82,82 @@ func (mv *modelValidator) validatePattern(pattern string) error {
 
 // Environment represents a single Codex API configuration
 type Environment struct {
-	Name    string            `json:"name"`
-	URL     string            `json:"url"`
-	APIKey  string            `json:"api_key"`
-	Model   string            `json:"model,omitempty"`
-	EnvVars map[string]string `json:"env_vars,omitempty"`
+	Name             string             `json:"name"`
+	URL              string             `json:"url"`
+	URLs             []string           `json:"urls,omitempty"`         // Further endpoints rotated with url (load-balanced gateways)
+	URLStrategy      string             `json:"url_strategy,omitempty"` // round-robin (default), random or sticky
+	APIKey           string             `json:"api_key"`
+	Provider         string             `json:"provider,omitempty"` // Provider preset used to create the environment
+	Model            string             `json:"model,omitempty"`
+	FallbackModel    string             `json:"fallback_model,omitempty"` // Retried once in subprocess mode when the provider rejects model
+	OrgID            string             `json:"org_id,omitempty"`         // OpenAI organization the requests are billed to
+	ProjectID        string             `json:"project_id,omitempty"`     // OpenAI project the requests are scoped to
+	ModelAliases     map[string]string  `json:"model_aliases,omitempty"`  // Short names for -m, e.g. "fast": "gpt-5-mini"
+	EnvVars          map[string]string  `json:"env_vars,omitempty"`
+	ModelParams      map[string]string  `json:"model_params,omitempty"`       // Forwarded to codex as -c key=value overrides
+	Keys             map[string]string  `json:"keys,omitempty"`               // Named alternate API keys (e.g. backup)
+	AutoFlags        []string           `json:"auto_flags,omitempty"`         // Overrides settings.auto_flags for 'cde auto'
+	Proxy            *ProxySettings     `json:"proxy,omitempty"`              // Proxy for codex and cde's own requests
+	Headers          map[string]string  `json:"headers,omitempty"`            // Extra HTTP headers sent to the endpoint
+	WireAPI          string             `json:"wire_api,omitempty"`           // API the headers provider speaks: responses (default) or chat
+	Budget           *BudgetSettings    `json:"budget,omitempty"`             // Daily launch limit
+	RateLimit        *RateLimitSettings `json:"rate_limit,omitempty"`         // Cooldown and hourly cap between launches
+	Auth             *AuthSettings      `json:"auth,omitempty"`               // OAuth token flow that supplies the API key at launch
+	KeyCreatedAt     string             `json:"key_created_at,omitempty"`     // When api_key was issued (YYYY-MM-DD or RFC 3339)
+	KeyExpiresAt     string             `json:"key_expires_at,omitempty"`     // When api_key stops working; cde warns ahead of it
+	ConfirmBeforeUse bool               `json:"confirm_before_use,omitempty"` // Ask before every launch (production endpoints); --yes skips
+	Timeout          string             `json:"timeout,omitempty"`            // Default session limit, e.g. "30m"; codex then runs as a subprocess
+	EnvPassthrough   *EnvPassthrough    `json:"env_passthrough,omitempty"`    // Which of cde's own variables codex inherits
+
+	// Azure OpenAI (provider "azure"): model name -> deployment name, and REST API version
+	DeploymentMap map[string]string `json:"deployment_map,omitempty"`
+	APIVersion    string            `json:"api_version,omitempty"`
 }
 
 // Config represents the complete configuration with all environments
 type Config struct {
-	Environments []Environment   `json:"environments"`
-	Settings     *ConfigSettings `json:"settings,omitempty"`
+	Environments []Environment        `json:"environments"`
+	Settings     *ConfigSettings      `json:"settings,omitempty"`
+	Trash        []TrashedEnvironment `json:"trash,omitempty"` // Removed environments restorable with 'cde restore'
+
+	nameIndex map[string]int // Environment name to position, built by loadConfig
 }
 
 // ConfigSettings holds optional configuration settings
 type ConfigSettings struct {
-	Terminal   *TerminalSettings   `json:"terminal,omitempty"`
-	Validation *ValidationSettings `json:"validation,omitempty"`
+	Terminal          *TerminalSettings    `json:"terminal,omitempty"`
+	Validation        *ValidationSettings  `json:"validation,omitempty"`
+	Update            *UpdateSettings      `json:"update,omitempty"`
+	AutoSelect        string               `json:"auto_select,omitempty"`     // "latency" picks the fastest endpoint when no env is given
+	HeadlessPolicy    string               `json:"headless_policy,omitempty"` // Non-interactive launch without -e: "first" (default), "default" or "fail"
+	DefaultEnv        string               `json:"default_env,omitempty"`     // Environment used by headless_policy "default"
+	Audit             *AuditSettings       `json:"audit,omitempty"`
+	Presets           map[string][]string  `json:"presets,omitempty"`    // Named codex argument profiles for 'cde preset <name>'
+	AutoFlags         []string             `json:"auto_flags,omitempty"` // Flags injected by 'cde auto' (default -a never --sandbox workspace-write)
+	Trash             *TrashSettings       `json:"trash,omitempty"`
+	Backup            *BackupSettings      `json:"backup,omitempty"`
+	LaunchMode        string               `json:"launch_mode,omitempty"`         // "exec" (default) or "subprocess"
+	CodexVersionCheck *bool                `json:"codex_version_check,omitempty"` // Warn before launch about incompatible codex versions (default true)
+	Sync              *SyncSettings        `json:"sync,omitempty"`                // Shared environment list for 'cde sync'
+	Metrics           *MetricsSettings     `json:"metrics,omitempty"`             // Opt-in launch metrics for 'cde serve-metrics' or a textfile
+	Hooks             *HookSettings        `json:"hooks,omitempty"`               // Opt-in pre_launch/post_exit commands
+	Security          *SecuritySettings    `json:"security,omitempty"`            // Controls for 'cde show-key'
+	KeyRotation       *KeyRotationSettings `json:"key_rotation,omitempty"`        // Key age limit and expiry warning window
+	Notify            *NotifySettings      `json:"notify,omitempty"`              // Desktop notifications when codex exits or runs long
+	ModelFallback     *bool                `json:"model_fallback,omitempty"`      // Retry with an environment's fallback_model when its model is rejected (default true)
+	TargetCommand     string               `json:"target_command,omitempty"`      // CLI to launch instead of codex (a renamed binary or fork)
+	Language          string               `json:"language,omitempty"`            // Language of help, prompts and error summaries (en, zh-CN); LANG when empty
+}
+
+// AuditSettings configures the local launch audit log
+type AuditSettings struct {
+	Enabled   bool `json:"enabled,omitempty"`
+	MaxSizeKB int  `json:"max_size_kb,omitempty"` // Rotate audit.jsonl beyond this size (default 1024)
+}
+
+// UpdateSettings configures release update checks
+type UpdateSettings struct {
+	Check bool `json:"check,omitempty"` // Report newer releases in --version output
 }
 
 // TerminalSettings configures terminal behavior
@@ -101,12 +165,20 @@ type TerminalSettings struct {
 	ForceFallback     bool   `json:"force_fallback,omitempty"`
 	DisableANSI       bool   `json:"disable_ansi,omitempty"`
 	CompatibilityMode string `json:"compatibility_mode,omitempty"`
+	Theme             string `json:"theme,omitempty"`      // Color theme: default, light, mono, none
+	AltScreen         *bool  `json:"alt_screen,omitempty"` // Draw the interactive menu on the alternate screen (default true)
+	Mouse             bool   `json:"mouse,omitempty"`      // Click and scroll in the interactive menu
+	Accessible        bool   `json:"accessible,omitempty"` // Screen-reader friendly menus: a plain numbered list, no redraws
 }
 
 // ValidationSettings configures model validation behavior
 type ValidationSettings struct {
-	ModelPatterns    []string `json:"model_patterns,omitempty"`
-	StrictValidation bool     `json:"strict_validation,omitempty"`
+	ModelPatterns     []string `json:"model_patterns,omitempty"`
+	StrictValidation  bool     `json:"strict_validation,omitempty"`
+	ArgPolicy         string   `json:"arg_policy,omitempty"`         // Dangerous codex arguments: warn (default), strict or off
+	ArgAllow          []string `json:"arg_allow,omitempty"`          // Regexps for arguments that skip every check
+	ArgDeny           []string `json:"arg_deny,omitempty"`           // Regexps for arguments that are always rejected
+	StrictPassthrough bool     `json:"strict_passthrough,omitempty"` // Apply the full policy after --, which otherwise only warns
 	// UnknownModelAction string   `json:"unknown_model_action,omitempty"`
 }
 
@@ -122,6 +194,7 @@ type ParseResult struct {
 	CCEFlags   map[string]string
 	ClaudeArgs []string
 	Subcommand string
+	Separator  bool // ClaudeArgs followed an explicit -- separator
 	Error      error
 }
 
@@ -145,6 +218,7 @@ const (
 
 // errorContext provides structured error information with recovery guidance
 type errorContext struct {
+	Code        errorCode // Stable code attached to the formatted error (see errcodes.go)
 	Operation   string
 	Component   string
 	Context     map[string]string
@@ -174,6 +248,12 @@ func (ec *errorContext) addSuggestion(suggestion string) *errorContext {
 	return ec
 }
 
+// withCode sets the stable error code reported for this error
+func (ec *errorContext) withCode(code errorCode) *errorContext {
+	ec.Code = code
+	return ec
+}
+
 // withRecovery adds a recovery function
 func (ec *errorContext) withRecovery(recovery func() error) *errorContext {
 	ec.Recovery = recovery
@@ -199,22 +279,18 @@ func (ec *errorContext) formatError(baseErr error) error {
 		}
 	}
 
+	if ec.Code != "" {
+		return withErrorCode(ec.Code, fmt.Errorf("%s", msg.String()))
+	}
 	return fmt.Errorf("%s", msg.String())
 }
 
 // validateEnvironment performs comprehensive validation of environment data
 func validateEnvironment(env Environment) error {
-	if err := validateName(env.Name); err != nil {
-		return fmt.Errorf("invalid name: %w", err)
-	}
-	if err := validateURL(env.URL); err != nil {
-		return fmt.Errorf("invalid URL: %w", err)
-	}
-	if err := validateAPIKey(env.APIKey); err != nil {
-		return fmt.Errorf("invalid API key: %w", err)
-	}
-	if err := validateModel(env.Model); err != nil {
-		return fmt.Errorf("invalid model: %w", err)
+	for _, v := range environmentValidators {
+		if err := v.validate(env); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -298,6 +374,29 @@ func validateModel(model string) error {
 	return nil
 }
 
+// validateModelParams checks codex config override keys and values for basic safety
+func validateModelParams(params map[string]string) error {
+	for key, value := range params {
+		if key == "" {
+			return fmt.Errorf("parameter name cannot be empty")
+		}
+		// Codex config keys are dotted TOML paths (e.g. model_reasoning_effort, tools.web_search)
+		matched, err := regexp.MatchString(`^[a-zA-Z0-9_.-]+$`, key)
+		if err != nil {
+			return fmt.Errorf("parameter validation failed: %w", err)
+		}
+		if !matched {
+			return fmt.Errorf("parameter '%s' contains invalid characters", key)
+		}
+		for _, r := range value {
+			if r < 32 || r == 127 {
+				return fmt.Errorf("parameter '%s' value contains invalid characters", key)
+			}
+		}
+	}
+	return nil
+}
+
 // validateModelAdaptive performs adaptive model validation with graceful degradation (relaxed for Codex)
 func (mv *modelValidator) validateModelAdaptive(model string) error {
 	if model == "" {
@@ -319,6 +418,22 @@ func (mv *modelValidator) validateModelAdaptive(model string) error {
 	return nil
 }
 
+// launchValueFlags are the launch flags that take a value; each also accepts --flag=value
+var launchValueFlags = map[string]bool{
+	"--env": true, "-e": true, "--var": true, "--failover": true, "--key": true, "--snapshot": true, "--timeout": true,
+	"--lang": true,
+}
+
+// splitFlagValue rewrites args[i] from --flag=value to --flag value when --flag is one of
+// valueFlags. args itself is not modified.
+func splitFlagValue(args []string, i int, valueFlags map[string]bool) []string {
+	name, value, found := strings.Cut(args[i], "=")
+	if !found || !valueFlags[name] {
+		return args
+	}
+	return append(args[:i:i], append([]string{name, value}, args[i+1:]...)...)
+}
+
 // parseArguments performs two-phase argument parsing to separate CDE flags from codex arguments
 func parseArguments(args []string) ParseResult {
 	result := ParseResult{
@@ -326,32 +441,90 @@ func parseArguments(args []string) ParseResult {
 		ClaudeArgs: []string{},
 	}
 
+	// --no-color, --config, --lang and the verbosity flags may precede any command; later they pass
+	// through to codex. codex's own --config takes key=value, so a value with '=' is left for it.
+	for len(args) > 0 {
+		if args[0] == "--no-color" {
+			result.CCEFlags["no_color"] = "true"
+		} else if args[0] == "--lang" && len(args) > 1 {
+			result.CCEFlags["lang"] = args[1]
+			args = args[1:]
+		} else if args[0] == "--config" && len(args) > 1 && !strings.Contains(args[1], "=") {
+			result.CCEFlags["config"] = args[1]
+			args = args[1:]
+		} else if level, ok := verbosityFlags[args[0]]; ok {
+			result.CCEFlags["log_level"] = level
+		} else {
+			break
+		}
+		args = args[1:]
+	}
+
 	if len(args) == 0 {
 		return result
 	}
+	if _, ok := cdeSubcommands[args[0]]; ok {
+		if err := parseCDECommand(args, &result); err != nil {
+			result.Error = err
+			result.Subcommand = ""
+		}
+		return result
+	}
 
 	// Phase 1: Check for subcommands first
 	switch args[0] {
-	case "list":
-		result.Subcommand = "list"
-		return result
-	case "add":
-		result.Subcommand = "add"
-		return result
-	case "remove":
-		if len(args) < 2 {
-			result.Error = fmt.Errorf("remove command requires environment name")
+	case "__complete":
+		// Hidden: used by shell completion scripts
+		if len(args) != 2 {
+			result.Error = fmt.Errorf("__complete requires exactly one kind (env-names)")
 			return result
 		}
-		result.Subcommand = "remove"
-		result.CCEFlags["remove_target"] = args[1]
+		result.Subcommand = "complete"
+		result.CCEFlags["complete_kind"] = args[1]
 		return result
-	case "help", "--help", "-h":
+	case "--help", "-h", "--help-all":
 		result.Subcommand = "help"
+		if args[0] == "--help-all" {
+			result.CCEFlags["help_all"] = "true"
+		}
+		for _, arg := range args[1:] {
+			switch {
+			case arg == "--help-all":
+				result.CCEFlags["help_all"] = "true"
+			case arg == "--man":
+				result.CCEFlags["help_man"] = "true"
+			case !strings.HasPrefix(arg, "-") && result.CCEFlags["help_topic"] == "":
+				result.CCEFlags["help_topic"] = arg
+			default:
+				result.Error = fmt.Errorf("help usage: help [command] [--help-all] [--man]")
+				return result
+			}
+		}
 		return result
 	case "auto":
+		// CDE flags and codex args may follow the subcommand
 		result.Subcommand = "auto"
-		return result
+		args = args[1:]
+	case "which":
+		// Same flags as a launch; 'which auto' explains an auto launch
+		result.Subcommand = "which"
+		args = args[1:]
+		if len(args) > 0 && args[0] == "auto" {
+			result.CCEFlags["auto"] = "true"
+			args = args[1:]
+		}
+	case "wrap":
+		// Launch flags, then -- and the command to run
+		result.Subcommand = "wrap"
+		args = args[1:]
+	case "preset":
+		if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+			result.Error = fmt.Errorf("preset command requires preset name")
+			return result
+		}
+		result.Subcommand = "preset"
+		result.CCEFlags["preset_name"] = args[1]
+		args = args[2:]
 	}
 
 	// Phase 1: Scan for CDE flags and -- separator
@@ -359,6 +532,8 @@ func parseArguments(args []string) ParseResult {
 	separatorFound := false
 
 	for i < len(args) {
+		// --env=prod is the same as --env prod
+		args = splitFlagValue(args, i, launchValueFlags)
 		arg := args[i]
 
 		// Check for -- separator
@@ -379,7 +554,73 @@ func parseArguments(args []string) ParseResult {
 			continue
 		}
 
+		if arg == "--no-color" {
+			result.CCEFlags["no_color"] = "true"
+			i++
+			continue
+		}
+
+		if arg == "--fastest" {
+			result.CCEFlags["fastest"] = "true"
+			i++
+			continue
+		}
+
+		if arg == "--dry-run" || arg == "--json" || arg == "--override" || arg == "--ignore-rate-limit" || arg == "--yes" || arg == "--keep-existing" || arg == "--require-env" || arg == "--no-arg-check" {
+			result.CCEFlags[strings.ReplaceAll(strings.TrimPrefix(arg, "--"), "-", "_")] = "true"
+			i++
+			continue
+		}
+
+		if arg == "--var" {
+			if i+1 >= len(args) {
+				result.Error = fmt.Errorf("flag %s requires a value", arg)
+				return result
+			}
+			if _, err := parseTemplateVars([]string{args[i+1]}); err != nil {
+				result.Error = err
+				return result
+			}
+			if vars := result.CCEFlags["vars"]; vars != "" {
+				result.CCEFlags["vars"] = vars + "\n" + args[i+1]
+			} else {
+				result.CCEFlags["vars"] = args[i+1]
+			}
+			i += 2
+			continue
+		}
+
+		if arg == "--failover" {
+			if i+1 >= len(args) {
+				result.Error = fmt.Errorf("flag %s requires a value", arg)
+				return result
+			}
+			result.CCEFlags["failover"] = args[i+1]
+			i += 2
+			continue
+		}
+
+		if arg == "--key" || arg == "--snapshot" || arg == "--timeout" || arg == "--lang" {
+			if i+1 >= len(args) {
+				result.Error = fmt.Errorf("flag %s requires a value", arg)
+				return result
+			}
+			if arg == "--timeout" {
+				if err := validateSessionTimeout(args[i+1]); err != nil {
+					result.Error = fmt.Errorf("invalid --timeout: %w", err)
+					return result
+				}
+			}
+			result.CCEFlags[strings.TrimPrefix(arg, "--")] = args[i+1]
+			i += 2
+			continue
+		}
+
 		if arg == "--help" || arg == "-h" {
+			// 'cde wrap --help' shows the page for wrap
+			if result.Subcommand != "" {
+				result.CCEFlags["help_topic"] = result.Subcommand
+			}
 			result.Subcommand = "help"
 			return result
 		}
@@ -392,78 +633,85 @@ func parseArguments(args []string) ParseResult {
 	if separatorFound || i < len(args) {
 		result.ClaudeArgs = args[i:]
 	}
+	result.Separator = separatorFound
 
 	return result
 }
 
-// validatePassthroughArgs performs security validation on codex arguments
-func validatePassthroughArgs(args []string) error {
-	for _, arg := range args {
-		// Check for potential command injection patterns
-		if strings.Contains(arg, ";") || strings.Contains(arg, "&") ||
-			strings.Contains(arg, "|") || strings.Contains(arg, "`") ||
-			strings.Contains(arg, "$(") {
-			// Allow these in quoted strings, but warn about potential risks
-			fmt.Fprintf(os.Stderr, "Warning: Argument contains shell metacharacters: %s\n", arg)
-		}
-
-		// Block obvious command injection attempts
-		if strings.Contains(arg, "rm -rf") || strings.Contains(arg, "sudo") ||
-			strings.Contains(arg, "/etc/passwd") || strings.Contains(arg, "../") {
-			return fmt.Errorf("potentially dangerous argument rejected: %s", arg)
-		}
-	}
-	return nil
-}
-
 func main() {
+	// Restore the terminal and save a crash report if anything below panics
+	snapshotTerminal()
+	defer recoverFromPanic()
+
 	// Check for version flag first
 	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
-		fmt.Printf("cde version %s (commit: %s, built: %s)\n", version, commit, date)
+		showVersion()
 		os.Exit(0)
 	}
 
 	if err := handleCommand(os.Args[1:]); err != nil {
+		// codex already reported its own failure; pass its status through
+		var childExit *childExitError
+		if errors.As(err, &childExit) {
+			os.Exit(childExit.Code)
+		}
+		var quietExit *quietExitError
+		if errors.As(err, &quietExit) {
+			os.Exit(quietExit.Code)
+		}
+
 		// Enhanced error categorization with clear messaging
 		errorType := categorizeError(err)
+		exitCode := exitCodeFor(err)
+		recordFailureMetric(errorType)
+
+		if parseArguments(os.Args[1:]).CCEFlags["json"] == "true" {
+			writeErrorJSON(os.Stderr, err, errorType, exitCode)
+			os.Exit(exitCode)
+		}
 
+		code := errorCodeOf(err)
 		switch errorType {
 		case "cde_argument":
-			fmt.Fprintf(os.Stderr, "CDE Argument Error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Use 'cde help' for usage information.\n")
+			fmt.Fprintf(os.Stderr, tr("CDE Argument Error [%s]: %v\n"), code, err)
+			fmt.Fprintln(os.Stderr, tr("Use 'cde help' for usage information."))
 		case "cde_config":
-			fmt.Fprintf(os.Stderr, "CDE Configuration Error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Check your environment configuration with 'cde list'.\n")
+			fmt.Fprintf(os.Stderr, tr("CDE Configuration Error [%s]: %v\n"), code, err)
+			fmt.Fprintln(os.Stderr, tr("Check your environment configuration with 'cde list'."))
 		case "codex_execution":
-			fmt.Fprintf(os.Stderr, "Codex Error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "This error originated from the codex command.\n")
+			fmt.Fprintf(os.Stderr, tr("Codex Error [%s]: %v\n"), code, err)
+			fmt.Fprintln(os.Stderr, tr("This error originated from the codex command."))
 		case "terminal":
-			fmt.Fprintf(os.Stderr, "Terminal Compatibility Error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Try using a different terminal or check terminal capabilities.\n")
+			fmt.Fprintf(os.Stderr, tr("Terminal Compatibility Error [%s]: %v\n"), code, err)
+			fmt.Fprintln(os.Stderr, tr("Try using a different terminal or check terminal capabilities."))
 		case "permission":
-			fmt.Fprintf(os.Stderr, "Permission Error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Check file permissions and access rights.\n")
+			fmt.Fprintf(os.Stderr, tr("Permission Error [%s]: %v\n"), code, err)
+			fmt.Fprintln(os.Stderr, tr("Check file permissions and access rights."))
 		default:
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, tr("Error [%s]: %v\n"), code, err)
 		}
 
-		// Enhanced error categorization with exit codes
-		switch {
-		case strings.Contains(err.Error(), "terminal"):
-			os.Exit(4) // Terminal compatibility error
-		case strings.Contains(err.Error(), "permission"):
-			os.Exit(5) // Permission/access error
-		case strings.Contains(err.Error(), "configuration"):
-			os.Exit(2) // Configuration error
-		case strings.Contains(strings.ToLower(err.Error()), "codex"):
-			os.Exit(3) // Codex launcher error
-		case strings.Contains(err.Error(), "argument parsing"):
-			os.Exit(6) // CDE argument parsing error
-		case strings.Contains(err.Error(), "argument validation"):
-			os.Exit(7) // CDE argument validation error
-		default:
-			os.Exit(1) // General application error
-		}
+		os.Exit(exitCode)
+	}
+}
+
+// exitCodeFor maps an error to the process exit status
+func exitCodeFor(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "terminal"):
+		return 4 // Terminal compatibility error
+	case strings.Contains(err.Error(), "permission"):
+		return 5 // Permission/access error
+	case strings.Contains(err.Error(), "configuration"):
+		return 2 // Configuration error
+	case strings.Contains(strings.ToLower(err.Error()), "codex"):
+		return 3 // Codex launcher error
+	case strings.Contains(err.Error(), "argument parsing"):
+		return 6 // CDE argument parsing error
+	case strings.Contains(err.Error(), "argument validation"):
+		return 7 // CDE argument validation error
+	default:
+		return 1 // General application error
 	}
 }
 
@@ -511,163 +759,863 @@ func handleCommand(args []string) error {
 	// Use new two-phase argument parsing
 	parseResult := parseArguments(args)
 	if parseResult.Error != nil {
+		selectLanguage("")
 		return fmt.Errorf("argument parsing failed: %w", parseResult.Error)
 	}
+	noColorRequested = parseResult.CCEFlags["no_color"] == "true"
+	if err := configureLogging(parseResult.CCEFlags["log_level"]); err != nil {
+		return fmt.Errorf("logging setup failed: %w", err)
+	}
+	logDebugf("command %q, codex args: %s", parseResult.Subcommand, formatCommandLine(sanitizeArgs(parseResult.ClaudeArgs, "")))
+
+	if path := parseResult.CCEFlags["config"]; path != "" {
+		original := configPathOverride
+		configPathOverride = path
+		defer func() { configPathOverride = original }()
+	}
+
+	if err := validateLanguage(parseResult.CCEFlags["lang"]); err != nil {
+		selectLanguage("")
+		return fmt.Errorf("argument parsing failed: %w", err)
+	}
+	selectLanguage(parseResult.CCEFlags["lang"])
+
+	restoreTerminalIO, err := setupTerminalIO()
+	defer restoreTerminalIO()
+	if err != nil {
+		return err
+	}
+
+	if configWriteSubcommands[parseResult.Subcommand] {
+		if err := requireWritableConfig(); err != nil {
+			return err
+		}
+	}
 
 	// Handle subcommands
 	switch parseResult.Subcommand {
 	case "list":
-		return runList()
+		return runListWithOptions(listOptions{
+			Verbose: parseResult.CCEFlags["verbose"] == "true",
+			Quiet:   parseResult.CCEFlags["quiet"] == "true",
+			Table:   parseResult.CCEFlags["table"] == "true",
+			Sort:    parseResult.CCEFlags["sort"],
+			JSON:    parseResult.CCEFlags["json"] == "true",
+		})
 	case "add":
-		return runAdd()
+		return runAddWithOptions(addOptions{
+			Preset:           parseResult.CCEFlags["preset"],
+			KeyFromClipboard: parseResult.CCEFlags["key_from_clipboard"] == "true",
+			ClearClipboard:   parseResult.CCEFlags["clear_clipboard"] == "true",
+			KeyFromStdin:     parseResult.CCEFlags["key_from_stdin"] == "true",
+			Name:             parseResult.CCEFlags["name"],
+			URL:              parseResult.CCEFlags["url"],
+			Model:            parseResult.CCEFlags["model"],
+			EnvVars:          parseResult.CCEFlags["env_vars"],
+		})
 	case "remove":
+		force := parseResult.CCEFlags["force"] == "true"
+		noBackup := parseResult.CCEFlags["no_backup"] == "true"
+		if parseResult.CCEFlags["all"] == "true" {
+			return runRemoveAll(force, noBackup)
+		}
 		if target, exists := parseResult.CCEFlags["remove_target"]; exists {
-			return runRemove(target)
+			return runRemoveWithOptions(target, force, noBackup)
 		}
 		return fmt.Errorf("remove command requires environment name")
+	case "restore":
+		return runRestore(parseResult.CCEFlags["restore_target"])
+	case "search":
+		return runSearch(parseResult.CCEFlags["pattern"], listOptions{
+			Quiet: parseResult.CCEFlags["quiet"] == "true",
+			Table: parseResult.CCEFlags["table"] == "true",
+		})
+	case "pin":
+		switch {
+		case parseResult.CCEFlags["list"] == "true":
+			return runPinList()
+		case parseResult.CCEFlags["unpin"] == "true":
+			return runUnpin()
+		}
+		return runPin(parseResult.CCEFlags["env"])
+	case "dedupe":
+		return runDedupe(parseResult.CCEFlags["dry_run"] == "true")
+	case "backup-list":
+		return runBackupList()
+	case "backup-restore":
+		return runBackupRestore(parseResult.CCEFlags["backup_id"])
+	case "backup-prune":
+		return runBackupPrune()
+	case "sync-pull":
+		return runSyncPull(parseResult.CCEFlags["dry_run"] == "true", parseResult.CCEFlags["theirs"] == "true")
+	case "sync-push":
+		return runSyncPush(parseResult.CCEFlags["dry_run"] == "true", parseResult.CCEFlags["force"] == "true")
+	case "clone":
+		return runClone(parseResult.CCEFlags)
+	case "rotate-key":
+		return runRotateKey(parseResult.CCEFlags["rotate_target"], parseResult.CCEFlags["key"], parseResult.CCEFlags["expires"])
+	case "show-key":
+		return runShowKey(parseResult.CCEFlags["env"], parseResult.CCEFlags["key"])
+	case "env-print":
+		return runEnvPrint(parseResult.CCEFlags["env"], parseResult.CCEFlags["shell"], parseResult.CCEFlags["no_secrets"] == "true")
+	case "audit-show":
+		last := 20
+		if value, ok := parseResult.CCEFlags["last"]; ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("argument validation failed: --last must be a positive number")
+			}
+			last = n
+		}
+		return runAuditShow(last)
+	case "models":
+		return runModels(parseResult.CCEFlags["env"])
+	case "import-from":
+		return runImportFrom(parseResult.CCEFlags["import_source"], parseResult.CCEFlags["dry_run"] == "true", parseResult.CCEFlags["no_backup"] == "true")
+	case "export-codex-profiles":
+		return runExportCodexProfiles(parseResult.CCEFlags["dry_run"] == "true")
+	case "serve-metrics":
+		return runServeMetrics(parseResult.CCEFlags["listen"])
+	case "config-validate":
+		return runConfigValidate(parseResult.CCEFlags["config_file"])
+	case "config-schema":
+		return runConfigSchema()
+	case "config-edit":
+		return runConfigEdit()
+	case "config-repair":
+		return runConfigRepair()
+	case "config-diff":
+		return runConfigDiff(parseResult.CCEFlags["config_file"], parseResult.CCEFlags["config_file_b"])
+	case "compare":
+		var envNames []string
+		if names := parseResult.CCEFlags["compare_envs"]; names != "" {
+			envNames = strings.Split(names, ",")
+		}
+		return runCompare(envNames, parseResult.ClaudeArgs, parseResult.CCEFlags["out"])
+	case "replay":
+		opts := launchOptions{DryRun: parseResult.CCEFlags["dry_run"] == "true", JSON: parseResult.CCEFlags["json"] == "true"}
+		return runReplay(parseResult.CCEFlags["snapshot_file"], opts)
+	case "profile-list":
+		return runProfileList()
+	case "profile-use":
+		return runProfileUse(parseResult.CCEFlags["profile"])
+	case "complete":
+		return runComplete(parseResult.CCEFlags["complete_kind"])
+	case "codex-version":
+		return runCodexVersion()
+	case "doctor":
+		return runDoctor(parseResult.CCEFlags["selftest"] == "true")
+	case "update-check":
+		return runUpdateCheck()
+	case "self-update":
+		return runSelfUpdate()
 	case "help":
-		showHelp()
-		return nil
-	case "auto":
-		// Validate passthrough arguments for security
-		if err := validatePassthroughArgs(parseResult.ClaudeArgs); err != nil {
-			return fmt.Errorf("argument validation failed: %w", err)
+		if config, err := loadConfigLazy(); err == nil {
+			useTargetCommand(config)
 		}
-		envName := parseResult.CCEFlags["env"]
-		return runAuto(envName, parseResult.ClaudeArgs)
+		return runHelp(parseResult.CCEFlags["help_topic"], parseResult.CCEFlags["help_all"] == "true", parseResult.CCEFlags["help_man"] == "true")
+	case "preset":
+		return runPreset(parseResult.CCEFlags["env"], parseResult.CCEFlags["preset_name"], parseResult.ClaudeArgs, launchOptionsFromFlags(parseResult))
 	}
 
-	// Validate passthrough arguments for security
-	if err := validatePassthroughArgs(parseResult.ClaudeArgs); err != nil {
-		return fmt.Errorf("argument validation failed: %w", err)
+	// A bare 'cde' before anything is configured offers to add or import instead of failing
+	if len(args) == 0 {
+		if config, ok := startMenuConfig(); ok {
+			return runStartMenu(config)
+		}
 	}
 
 	// Handle default behavior with environment selection and codex arguments
-	envName := parseResult.CCEFlags["env"]
-	return runDefault(envName, parseResult.ClaudeArgs)
+	opts := launchOptionsFromFlags(parseResult)
+	if parseResult.Subcommand == "which" {
+		return runWhich(parseResult.CCEFlags["env"], parseResult.ClaudeArgs, opts)
+	}
+	if parseResult.Subcommand == "wrap" {
+		if !parseResult.Separator {
+			return fmt.Errorf("argument validation failed: wrap needs -- before the command, e.g. cde wrap -e prod -- aider")
+		}
+		return runWrap(parseResult.CCEFlags["env"], parseResult.ClaudeArgs, opts)
+	}
+	return runDefaultWithOptions(parseResult.CCEFlags["env"], parseResult.ClaudeArgs, opts)
+}
+
+// launchOptionsFromFlags builds launch options from parsed CDE flags
+func launchOptionsFromFlags(parseResult ParseResult) launchOptions {
+	opts := launchOptions{
+		KeyName:    parseResult.CCEFlags["key"],
+		Fastest:    parseResult.CCEFlags["fastest"] == "true",
+		Auto:       parseResult.Subcommand == "auto" || parseResult.CCEFlags["auto"] == "true",
+		DryRun:     parseResult.CCEFlags["dry_run"] == "true",
+		JSON:       parseResult.CCEFlags["json"] == "true",
+		Override:   parseResult.CCEFlags["override"] == "true",
+		IgnoreRate: parseResult.CCEFlags["ignore_rate_limit"] == "true",
+		KeepShell:  parseResult.CCEFlags["keep_existing"] == "true",
+		Yes:        parseResult.CCEFlags["yes"] == "true",
+		RequireEnv: parseResult.CCEFlags["require_env"] == "true",
+		NoArgCheck: parseResult.CCEFlags["no_arg_check"] == "true",
+		Separator:  parseResult.Separator,
+		Snapshot:   parseResult.CCEFlags["snapshot"],
+		Timeout:    parseResult.CCEFlags["timeout"],
+	}
+	if vars := parseResult.CCEFlags["vars"]; vars != "" {
+		opts.Vars, _ = parseTemplateVars(strings.Split(vars, "\n")) // Checked while parsing
+	}
+	if failover := parseResult.CCEFlags["failover"]; failover != "" {
+		for _, name := range strings.Split(failover, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.Failover = append(opts.Failover, name)
+			}
+		}
+	}
+	return opts
 }
 
 // showHelp displays usage information including flag passthrough capability
 func showHelp() {
 	fmt.Println("Codex Env (cde) Launcher")
-	fmt.Println("\nUsage:")
-	fmt.Println("  cde [command] [options] [-- codex-args...]")
-	fmt.Println("\nCommands:")
-	fmt.Println("  list                列出所有已配置环境")
-	fmt.Println("  add                 新增环境配置（可选模型）")
-	fmt.Println("  remove <name>       删除环境配置")
-	fmt.Println("  auto                自动批准并使用沙箱（-a never --sandbox workspace-write）")
-	fmt.Println("  help                显示帮助")
-	fmt.Println("\nOptions:")
-	fmt.Println("  -e, --env <name>    选择环境")
-	fmt.Println("  -h, --help          显示帮助")
-	fmt.Println("\n说明:")
-	fmt.Println("  - 所有 CDE 选项之后的参数都会直接透传给 codex 命令。")
-	fmt.Println("  - 使用 '--' 明确分隔 CDE 与 codex 参数。")
-	fmt.Println("  - 如果环境配置了 model 且未在参数中指定 '-m/--model'，将自动追加 '-m <env.model>'（默认模型示例: gpt-5）。")
-	fmt.Println("\n示例:")
-	fmt.Println("  cde                              交互式选择并启动 Codex")
-	fmt.Println("  cde --env prod                   使用 'prod' 环境启动 Codex")
-	fmt.Println("  cde auto -e dev -- mcp           自动批准 + 沙箱，执行 mcp")
-	fmt.Println("  cde -e staging -- --help         透传 '--help' 到 codex")
+	fmt.Println("\n" + tr("Usage:"))
+	fmt.Printf("  cde [command] [options] [-- %s-args...]\n", targetCommand)
+	fmt.Println("\n" + tr("Commands:"))
+	printHelpEntries(os.Stdout, commandOverview())
+	fmt.Println("\n" + tr("Options:"))
+	printHelpEntries(os.Stdout, launchOptionDocs)
+	fmt.Println("\n" + tr("Notes:"))
+	for _, note := range []string{
+		"Arguments after the CDE options are passed straight through to codex.",
+		"Use '--' to separate CDE options from codex arguments explicitly.",
+		"cde help <command> or cde <command> --help shows one command's usage, options and examples.",
+		"When the environment sets a model and the arguments do not choose one with '-m/--model', '-m <env.model>' is added (default model example: gpt-5).",
+		"The environment's model_params are passed to codex as '-c key=value' (a -c for the same key on the command line wins).",
+		"api_key may be op://vault/item/field (1Password CLI) or vault://path#key (requires VAULT_ADDR/VAULT_TOKEN); it is resolved at launch and kept in memory only.",
+		"An environment may set auth (type: oauth, client_credentials or device_code flow) to fetch a short-lived token as the API key at launch, cached until it expires.",
+		"auth type: gcp (project/location/account/adc) fetches an access token through gcloud at launch and uses it as the API key.",
+		"auth type: aws (region/profile/role_arn) verifies credentials with the AWS CLI before launch and exports AWS_PROFILE/AWS_REGION or temporary credentials for a SigV4 signing proxy.",
+		"Passthrough arguments containing rm -rf, sudo, /etc/passwd or ../ only warn by default; settings.validation.arg_policy may be strict (reject) or off, and arg_allow/arg_deny are regex lists.",
+		"Arguments after '--' are codex content and only warn; settings.validation.strict_passthrough applies the full policy to them.",
+		"An environment may list several endpoints in urls with url_strategy round-robin (default), random or sticky (switches when a health check fails); --fastest/--failover probe every endpoint.",
+		"With CDE_ENVIRONMENTS_JSON (an environment array or full config) or CDE_ENV_ONLY=1 (uses OPENAI_API_KEY/OPENAI_BASE_URL/OPENAI_MODEL) no config file is read and commands that modify the config are refused.",
+		"settings.hooks (with enabled: true) run commands before launch and after codex exits (pre_launch/post_exit) with a minimal environment plus CDE_ENV_* details and a timeout.",
+	} {
+		fmt.Printf("  - %s\n", tr(note))
+	}
+	fmt.Println("\n" + tr("Examples:"))
+	for _, example := range []flagDoc{
+		{"cde", "Pick an environment interactively and launch Codex"},
+		{"cde --env prod", "Launch Codex with the 'prod' environment"},
+		{"cde auto -e dev -- mcp", "Auto-approve with a sandbox and run mcp"},
+		{"cde -e staging -- --help", "Pass '--help' through to codex"},
+	} {
+		fmt.Printf("  %-33s%s\n", example.Flag, tr(example.Desc))
+	}
 }
 
 // runDefault handles the default behavior: environment selection and Codex launch with arguments
 // prepareCodexArgs applies model injection rules to codex args
 func prepareCodexArgs(selectedEnv Environment, codexArgs []string) []string {
-	// If environment specifies model and user didn't pass -m/--model, prepend it
-	hasModelFlag := false
+	// If environment specifies model and the user's args don't already choose one, prepend it
+	if !codexModelSet(codexArgs) && strings.TrimSpace(selectedEnv.Model) != "" {
+		codexArgs = append([]string{"-m", selectedEnv.Model}, codexArgs...)
+	}
+	codexArgs = applyModelAliases(selectedEnv, codexArgs)
+	codexArgs = applyDeploymentMap(selectedEnv, codexArgs)
+	codexArgs = applyRequestHeaders(selectedEnv, codexArgs)
+	return applyModelParams(selectedEnv, codexArgs)
+}
+
+// applyModelParams prepends '-c key=value' overrides for environment model params
+// that the user did not already override on the command line
+func applyModelParams(selectedEnv Environment, codexArgs []string) []string {
+	if len(selectedEnv.ModelParams) == 0 {
+		return codexArgs
+	}
+
+	// Skip keys the user already set via -c/--config
+	userKeys := configOverrideKeys(codexArgs)
+
+	// Sort keys for deterministic argument order
+	keys := make([]string, 0, len(selectedEnv.ModelParams))
+	for key := range selectedEnv.ModelParams {
+		if !userKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	overrides := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		overrides = append(overrides, "-c", fmt.Sprintf("%s=%s", key, selectedEnv.ModelParams[key]))
+	}
+	return append(overrides, codexArgs...)
+}
+
+// configOverrideKeys returns the keys set by -c/--config overrides in codexArgs
+func configOverrideKeys(codexArgs []string) map[string]bool {
+	keys := make(map[string]bool)
 	for i := 0; i < len(codexArgs); i++ {
-		if codexArgs[i] == "-m" || codexArgs[i] == "--model" {
-			hasModelFlag = true
-			break
+		var override string
+		switch {
+		case (codexArgs[i] == "-c" || codexArgs[i] == "--config") && i+1 < len(codexArgs):
+			override = codexArgs[i+1]
+			i++
+		case strings.HasPrefix(codexArgs[i], "--config="):
+			override = strings.TrimPrefix(codexArgs[i], "--config=")
+		default:
+			continue
+		}
+		if key, _, found := strings.Cut(override, "="); found {
+			keys[strings.TrimSpace(key)] = true
 		}
 	}
-	if !hasModelFlag && strings.TrimSpace(selectedEnv.Model) != "" {
-		codexArgs = append([]string{"-m", selectedEnv.Model}, codexArgs...)
+	return keys
+}
+
+// codexModelSet reports whether codex args already choose the model: -m/--model, a
+// -c model=... override, or --profile, whose model codex reads from its config.toml
+func codexModelSet(codexArgs []string) bool {
+	for _, arg := range codexArgs {
+		if arg == "-m" || arg == "--model" || strings.HasPrefix(arg, "--model=") ||
+			arg == "-p" || arg == "--profile" || strings.HasPrefix(arg, "--profile=") {
+			return true
+		}
 	}
-	return codexArgs
+	return configOverrideKeys(codexArgs)["model"]
+}
+
+// modelOverride returns the model set by a -c override such as model="gpt-5"
+func modelOverride(override string) (string, bool) {
+	key, value, found := strings.Cut(override, "=")
+	if !found || strings.TrimSpace(key) != "model" {
+		return "", false
+	}
+	return strings.Trim(strings.TrimSpace(value), `"'`), true
+}
+
+// launchOptions carries optional launch-time selections from CDE flags
+type launchOptions struct {
+	KeyName    string            // Named key from Environment.Keys to use instead of api_key
+	Fastest    bool              // Probe all environments and use the lowest-latency healthy one
+	Failover   []string          // Health-check these environments in order and use the first healthy one
+	Auto       bool              // Prepend the environment's auto flags (cde auto)
+	NoPrompt   bool              // Fail instead of showing the interactive menu (cde which)
+	RequireEnv bool              // Fail non-interactive launches that do not name an environment
+	NoArgCheck bool              // Skip the argument policy for this launch (refused under arg_policy "strict")
+	Separator  bool              // Codex args followed an explicit --, so the policy only warns about them
+	DryRun     bool              // Print the command and environment changes instead of launching
+	JSON       bool              // Report dry-run and which output as JSON
+	Override   bool              // Launch even when the environment's budget blocks it
+	IgnoreRate bool              // Launch even when the environment's rate limit blocks it
+	Yes        bool              // Skip the confirm_before_use prompt
+	Wrap       bool              // Plan for an arbitrary command (cde wrap): no codex argument handling
+	KeepShell  bool              // Prefer OPENAI_* and provider values already set in the shell (--keep-existing)
+	Vars       map[string]string // Values for {placeholders} in the URL and env_vars (--var)
+	Snapshot   string            // Write a reproducibility manifest to this file before launching
+	Timeout    string            // Session limit overriding the environment's timeout ("0" = none)
+}
+
+// launchPlan is the result of environment selection and argument preparation
+type launchPlan struct {
+	Config      Config
+	Environment Environment
+	Source      string            // How the environment was chosen
+	Args        []string          // Final codex arguments
+	UserArgs    []string          // Codex arguments before prepareCodexArgs added cde's overrides
+	Vars        map[string]string // Template values used, given with --var or prompted
 }
 
 func runDefault(envName string, codexArgs []string) error {
-	// Load configuration
-	config, err := loadConfig()
+	return runDefaultWithOptions(envName, codexArgs, launchOptions{})
+}
+
+// planLaunch selects an environment and prepares the codex arguments without launching
+func planLaunch(envName string, codexArgs []string, opts launchOptions) (launchPlan, error) {
+	if envName != "" && len(opts.Failover) > 0 {
+		return launchPlan{}, fmt.Errorf("flags --env and --failover cannot be combined")
+	}
+
+	// Load configuration; only the selected environment is validated
+	config, err := loadConfigLazy()
 	if err != nil {
-		return fmt.Errorf("configuration loading failed: %w", err)
+		return launchPlan{}, fmt.Errorf("configuration loading failed: %w", err)
 	}
+	useTargetCommand(config)
 
-	var selectedEnv Environment
+	// Apply the argument policy now that settings.validation is known; wrapped commands are not codex
+	if !opts.Wrap {
+		if err := checkPassthroughArgs(config, codexArgs, opts); err != nil {
+			return launchPlan{}, fmt.Errorf("argument validation failed: %w", err)
+		}
+	}
+
+	plan := launchPlan{Config: config}
+
+	// Probes need concrete URLs, so --var values are applied to every environment before
+	// latency or health checks; the selected environment is resolved from its template below
+	probeConfig := config
+	if len(opts.Vars) > 0 {
+		probeConfig.Environments = make([]Environment, len(config.Environments))
+		for i, env := range config.Environments {
+			probeConfig.Environments[i] = applyTemplateVars(env, opts.Vars)
+		}
+	}
 
-	if envName != "" {
+	// Precedence: --env/--failover/--fastest flags, then CDE_ENV, then a workspace pin, then
+	// .cde.json branch rules, then settings.auto_select, then the menu
+	envSource := "--env flag"
+	if envName == "" && len(opts.Failover) == 0 && !opts.Fastest {
+		if fromEnv := strings.TrimSpace(os.Getenv("CDE_ENV")); fromEnv != "" {
+			if err := validateName(fromEnv); err != nil {
+				return launchPlan{}, fmt.Errorf("argument validation failed: CDE_ENV: %w", err)
+			}
+			envName, envSource = fromEnv, "CDE_ENV"
+		} else if pinned, dir := pinnedEnvironment(config); pinned != "" {
+			envName, envSource = pinned, pinSourcePrefix+dir
+		} else if ruled, source, err := branchRuleEnvironment(config); err != nil {
+			return launchPlan{}, fmt.Errorf("environment selection failed: %w", err)
+		} else if ruled != "" {
+			envName, envSource = ruled, source
+		}
+	}
+
+	probed := false // Failover and latency selection choose a specific endpoint
+	if len(opts.Failover) > 0 {
+		probed = true
+		plan.Source = "--failover " + strings.Join(opts.Failover, ",")
+		plan.Environment, err = selectFailoverEnvironment(probeConfig, opts.Failover)
+		if err != nil {
+			return launchPlan{}, fmt.Errorf("environment selection failed: %w", err)
+		}
+	} else if envName != "" {
 		// Use specified environment
 		index, exists := findEnvironmentByName(config, envName)
 		if !exists {
-			return fmt.Errorf("environment '%s' not found", envName)
+			return launchPlan{}, fmt.Errorf("environment '%s' not found", envName)
+		}
+		plan.Source = envSource
+		plan.Environment = config.Environments[index]
+	} else if opts.Fastest || latencyAutoSelectEnabled(config) {
+		// Latency-based selection
+		probed = true
+		plan.Source = "--fastest"
+		if !opts.Fastest {
+			plan.Source = "settings.auto_select=latency"
+		}
+		plan.Environment, err = selectFastestEnvironment(probeConfig)
+		if err != nil {
+			return launchPlan{}, fmt.Errorf("environment selection failed: %w", err)
+		}
+	} else if opts.NoPrompt && len(config.Environments) > 1 {
+		return launchPlan{}, fmt.Errorf("environment would be chosen from an interactive menu of %d environments; pass -e <name>", len(config.Environments))
+	} else if stdinPiped() {
+		// The numbered menu would read the piped input codex is meant to receive
+		plan.Environment, plan.Source, err = selectHeadlessEnvironment(config, opts.RequireEnv)
+		if err != nil {
+			return launchPlan{}, fmt.Errorf("environment selection failed: %w", err)
 		}
-		selectedEnv = config.Environments[index]
 	} else {
 		// Interactive selection
-		selectedEnv, err = selectEnvironment(config)
+		plan.Source = "interactive menu"
+		if len(config.Environments) == 1 {
+			plan.Source = "only configured environment"
+		}
+		plan.Environment, err = selectEnvironment(config)
+		if err != nil {
+			return launchPlan{}, fmt.Errorf("environment selection failed: %w", err)
+		}
+	}
+
+	// Fill {placeholders} in the URLs and env_vars from --var, prompting on a terminal
+	probedURL := ""
+	if probed {
+		probedURL = plan.Environment.URL
+	}
+	if index, exists := findEnvironmentByName(config, plan.Environment.Name); exists {
+		plan.Environment = config.Environments[index]
+	}
+	prompt := !opts.NoPrompt && !opts.JSON && !stdinPiped()
+	plan.Environment, plan.Vars, err = resolveEnvironmentTemplate(plan.Environment, opts.Vars, prompt)
+	if err != nil {
+		return launchPlan{}, fmt.Errorf("argument validation failed: %w", err)
+	}
+
+	// Pick one of several endpoints; a probe-based selection already chose the URL
+	if probedURL != "" {
+		plan.Environment.URL = probedURL
+	} else if plan.Environment.URL, err = pickEndpoint(plan.Environment, !opts.DryRun && !opts.NoPrompt); err != nil {
+		return launchPlan{}, fmt.Errorf("environment selection failed: %w", err)
+	}
+
+	if err := validateSelectedEnvironment(plan.Environment); err != nil {
+		return launchPlan{}, err
+	}
+
+	// Switch to a named key if requested
+	if opts.KeyName != "" {
+		plan.Environment, err = selectAPIKey(plan.Environment, opts.KeyName)
 		if err != nil {
-			return fmt.Errorf("environment selection failed: %w", err)
+			return launchPlan{}, fmt.Errorf("key selection failed: %w", err)
 		}
 	}
 
+	// CDE_MODEL replaces the environment's default model; -m/--model still wins
+	if model := strings.TrimSpace(os.Getenv("CDE_MODEL")); model != "" {
+		if err := validateModel(model); err != nil {
+			return launchPlan{}, fmt.Errorf("argument validation failed: CDE_MODEL: %w", err)
+		}
+		plan.Environment.Model = model
+	}
+
+	if opts.Wrap {
+		plan.Args, plan.UserArgs = codexArgs, codexArgs
+		logInfof("selected environment '%s' via %s", plan.Environment.Name, plan.Source)
+		return plan, nil
+	}
+
+	// Prepend auto-approval flags; explicit CLI flags take precedence
+	if opts.Auto {
+		codexArgs, err = mergeAutoFlags(autoFlagsFor(config, plan.Environment), codexArgs)
+		if err != nil {
+			return launchPlan{}, fmt.Errorf("argument validation failed: %w", err)
+		}
+	}
+
+	// Prepare final codex args with model injection if needed
+	plan.Args = prepareCodexArgs(plan.Environment, codexArgs)
+	plan.UserArgs = codexArgs
+	logInfof("selected environment '%s' via %s", plan.Environment.Name, plan.Source)
+	logDebugf("codex args: %s", formatCommandLine(sanitizeArgs(plan.Args, plan.Environment.APIKey)))
+	return plan, nil
+}
+
+// runDefaultWithOptions selects an environment, applies launch options and launches Codex
+func runDefaultWithOptions(envName string, codexArgs []string, opts launchOptions) error {
+	selectionStart := time.Now()
+	plan, err := planLaunch(envName, codexArgs, opts)
+	if err != nil {
+		return err
+	}
+	return executeLaunchPlan(plan, opts, time.Since(selectionStart))
+}
+
+// executeLaunchPlan resolves secrets, runs the pre-launch checks and hooks and launches a
+// planned codex invocation; selection is how long choosing the environment took
+func executeLaunchPlan(plan launchPlan, opts launchOptions, selection time.Duration) error {
+	// Resolve op:// and vault:// references now; the value stays in memory only
+	selectedEnv, err := resolveEnvironmentSecrets(plan.Environment)
+	if err != nil {
+		return fmt.Errorf("API key resolution failed: %w", err)
+	}
+
+	// Warn when the installed codex predates flags cde is about to pass
+	warnCodexCompatibility(plan.Config, plan.Args)
+
+	// Expiry dates describe api_key, not the named keys selected with --key
+	if opts.KeyName == "" {
+		warnKeyExpiry(plan.Config, plan.Environment)
+	}
+
+	// Shell exports that the launch replaces or drops are easy to miss
+	conflicts := findShellConflicts(selectedEnv)
+	warnShellConflicts(conflicts, opts.KeepShell)
+	var keepShell []string
+	if opts.KeepShell {
+		keepShell = conflictKeys(conflicts)
+	}
+
+	now := time.Now()
+	if err := checkBudget(plan.Environment, opts.Override, now); err != nil {
+		return err
+	}
+	if err := checkRateLimit(plan.Environment, opts.IgnoreRate, now); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		return runDryRun(plan, selectedEnv, opts)
+	}
+	if err := confirmLaunch(plan.Environment, opts.Yes); err != nil {
+		return err
+	}
+	if opts.Snapshot != "" {
+		if err := writeLaunchSnapshot(opts.Snapshot, plan, opts.KeyName); err != nil {
+			return err
+		}
+	}
+	if err := runPreLaunchHooks(plan.Config, selectedEnv); err != nil {
+		return err
+	}
+	recordLaunchUsage(plan.Environment, now)
+	recordLaunchMetrics(plan, selection)
+
 	// Display selected environment
 	if _, err := fmt.Printf("Using environment: %s (%s)\n", selectedEnv.Name, selectedEnv.URL); err != nil {
 		return fmt.Errorf("failed to display selected environment: %w", err)
 	}
 
-	// Prepare final codex args with model injection if needed
-	codexArgs = prepareCodexArgs(selectedEnv, codexArgs)
+	// In subprocess mode cde outlives codex and records how the run ended; a session limit
+	// needs that too, so it implies subprocess mode
+	timeout := sessionTimeout(selectedEnv, opts.Timeout)
+	if launchModeFor(plan.Config) == launchModeSubprocess || timeout > 0 {
+		stopNotifier := startLongRunningNotifier(plan.Config, selectedEnv)
+		childOpts := subprocessOptions{Timeout: timeout, KeepShell: keepShell}
+		var modelDetector, authDetector *lineDetector
+		if stderrTappable() {
+			var taps []io.Writer
+			if modelFallbackEnabled(plan.Config, selectedEnv, plan.Args) {
+				modelDetector = newLineDetector(modelRejectedPattern)
+				taps = append(taps, modelDetector)
+			}
+			if usesGCPAuth(selectedEnv) || backupKeyFallbackEnabled(plan.Environment, opts.KeyName) {
+				authDetector = newLineDetector(authRejectedPattern)
+				taps = append(taps, authDetector)
+			}
+			if len(taps) > 0 {
+				childOpts.StderrTap = io.MultiWriter(taps...)
+			}
+		}
+		exitCode, elapsed, err := launchCodexSubprocess(selectedEnv, plan.Args, childOpts)
+		if err == nil && exitCode != 0 && exitCode != sessionTimeoutExitCode && modelDetector != nil && modelDetector.Matched() {
+			// The retry gets what is left of the session limit, and is skipped once it is spent
+			if remaining, ok := remainingTimeout(timeout, elapsed); !ok {
+				fmt.Fprintf(os.Stderr, "Model '%s' was rejected by the provider; not retrying with fallback_model '%s' because the session limit is spent\n",
+					argsModel(plan.Args), selectedEnv.FallbackModel)
+			} else {
+				fmt.Fprintf(os.Stderr, "Model '%s' was rejected by the provider; retrying once with fallback_model '%s'\n",
+					argsModel(plan.Args), selectedEnv.FallbackModel)
+				selectedEnv, plan.Args = withFallbackModel(selectedEnv, plan.Args)
+				var retryElapsed time.Duration
+				exitCode, retryElapsed, err = launchCodexSubprocess(selectedEnv, plan.Args, subprocessOptions{Timeout: remaining, KeepShell: keepShell})
+				elapsed += retryElapsed
+			}
+		}
+		if err == nil && gcpTokenRejected(selectedEnv, exitCode, elapsed, authDetector) {
+			selectedEnv, exitCode, elapsed, err = relaunchWithFreshGCPToken(selectedEnv, plan.Args, subprocessOptions{Timeout: timeout, KeepShell: keepShell}, exitCode, elapsed)
+		} else if err == nil && backupKeyFallbackEnabled(plan.Environment, opts.KeyName) && backupKeyRejected(exitCode, authDetector) {
+			selectedEnv, exitCode, elapsed, err = relaunchWithBackupKey(plan.Environment, selectedEnv, plan.Args, subprocessOptions{Timeout: timeout, KeepShell: keepShell}, exitCode, elapsed)
+		}
+		stopNotifier()
+		if err != nil {
+			return err
+		}
+		notifySessionEnd(plan.Config, selectedEnv, exitCode, elapsed)
+		recordLaunchResult(plan.Config, selectedEnv, plan.Args, exitCode, elapsed)
+		recordRunMetrics(plan.Config, selectedEnv, exitCode, elapsed)
+		runPostExitHooks(plan.Config, selectedEnv, exitCode, elapsed)
+		if exitCode != 0 {
+			return &childExitError{Code: exitCode}
+		}
+		return nil
+	}
+
+	// Record the launch before exec replaces this process
+	recordLaunch(plan.Config, selectedEnv, plan.Args, nil)
+	warnSkippedPostExitHooks(plan.Config)
+	warnSkippedNotifications(plan.Config)
 
 	// Launch Codex with arguments
-	return launchCodex(selectedEnv, codexArgs)
+	return launchCodex(selectedEnv, plan.Args, keepShell...)
 }
 
-// runAuto appends auto-approval and sandbox flags then launches Codex
-// applyAutoFlags prepends automatic approval and sandbox flags
-func applyAutoFlags(args []string) []string {
-	return append([]string{"-a", "never", "--sandbox", "workspace-write"}, args...)
+// runAuto launches Codex with the configured auto flags
+func runAuto(envName string, codexArgs []string) error {
+	return runDefaultWithOptions(envName, codexArgs, launchOptions{Auto: true})
 }
 
-func runAuto(envName string, codexArgs []string) error {
-	autoArgs := applyAutoFlags(codexArgs)
-	return runDefault(envName, autoArgs)
+// resolvePreset returns the codex arguments of a named preset from settings.presets
+func resolvePreset(config Config, name string) ([]string, error) {
+	if config.Settings != nil {
+		if presetArgs, exists := config.Settings.Presets[name]; exists {
+			return presetArgs, nil
+		}
+	}
+
+	names := []string{}
+	if config.Settings != nil {
+		for presetName := range config.Settings.Presets {
+			names = append(names, presetName)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("preset '%s' not found (no presets defined in settings.presets)", name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("preset '%s' not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// runPreset prepends a named preset's arguments then launches Codex
+func runPreset(envName, presetName string, codexArgs []string, opts launchOptions) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	presetArgs, err := resolvePreset(config, presetName)
+	if err != nil {
+		return err
+	}
+	args := append(append([]string{}, presetArgs...), codexArgs...)
+	return runDefaultWithOptions(envName, args, opts)
 }
 
 // runList displays all configured environments
 func runList() error {
+	return runListWithOptions(listOptions{})
+}
+
+// runListWithOptions displays all configured environments as blocks, a table or bare names
+func runListWithOptions(opts listOptions) error {
+	if err := validateListOptions(opts); err != nil {
+		return fmt.Errorf("argument validation failed: %w", err)
+	}
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("configuration loading failed: %w", err)
 	}
 
-	return displayEnvironments(config)
+	return displayEnvironmentList(config, opts)
 }
 
 // runAdd adds a new environment configuration
 func runAdd() error {
+	return runAddWithPreset("")
+}
+
+// addOptions holds the flags of 'cde add'
+type addOptions struct {
+	Preset           string // Provider preset that pre-fills the form
+	KeyFromClipboard bool   // Take the API key from the system clipboard instead of prompting
+	ClearClipboard   bool   // Empty the clipboard once the key has been read
+	KeyFromStdin     bool   // Read the API key from stdin and take every other field from flags
+	Name             string // --name, with KeyFromStdin
+	URL              string // --url, with KeyFromStdin (default: the preset URL)
+	Model            string // --model, with KeyFromStdin (default: the preset model)
+	EnvVars          string // --env-var KEY=VALUE entries, one per line
+}
+
+// runAddWithPreset adds a new environment, pre-filling fields from a provider preset if named
+func runAddWithPreset(presetName string) error {
+	return runAddWithOptions(addOptions{Preset: presetName})
+}
+
+// environmentFromAddFlags builds an environment from add flags and preset defaults without prompting
+func environmentFromAddFlags(opts addOptions, preset *ProviderPreset, apiKey string) (Environment, error) {
+	env := Environment{Name: opts.Name, URL: opts.URL, APIKey: apiKey, Model: opts.Model, EnvVars: make(map[string]string)}
+	if env.Name == "" {
+		return Environment{}, fmt.Errorf("--name is required with --api-key-stdin")
+	}
+	if preset != nil {
+		env.Provider = preset.Name
+		env.APIVersion = preset.APIVersion
+		if env.URL == "" {
+			if placeholders := preset.urlPlaceholders(); len(placeholders) > 0 {
+				return Environment{}, fmt.Errorf("preset '%s' needs --url (its template has {%s})", preset.Name, strings.Join(placeholders, "}, {"))
+			}
+			env.URL = preset.URLTemplate
+		}
+		if env.Model == "" {
+			env.Model = preset.DefaultModel
+		}
+		for key, value := range preset.EnvVars {
+			env.EnvVars[key] = value
+		}
+	}
+	if env.URL == "" {
+		return Environment{}, fmt.Errorf("--url is required with --api-key-stdin")
+	}
+
+	for _, entry := range strings.Split(opts.EnvVars, "\n") {
+		if entry == "" {
+			continue
+		}
+		key, value, found := strings.Cut(entry, "=")
+		if !found || !isValidEnvVarName(key) {
+			return Environment{}, fmt.Errorf("invalid --env-var '%s': expected KEY=VALUE", entry)
+		}
+		env.EnvVars[key] = value
+	}
+	for key, value := range env.EnvVars {
+		if value == "" {
+			return Environment{}, fmt.Errorf("preset '%s' requires %s; pass --env-var %s=<value>", preset.Name, key, key)
+		}
+	}
+	return env, nil
+}
+
+// runAddWithOptions adds a new environment with the given add flags
+func runAddWithOptions(opts addOptions) error {
+	var preset *ProviderPreset
+	if opts.Preset != "" {
+		found, err := findProviderPreset(opts.Preset)
+		if err != nil {
+			return fmt.Errorf("failed to load provider preset: %w", err)
+		}
+		preset = &found
+	}
+
 	// Load existing configuration
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("configuration loading failed: %w", err)
 	}
 
-	// Prompt for new environment details
-	env, err := promptForEnvironment(config)
-	if err != nil {
-		return fmt.Errorf("environment input failed: %w", err)
+	var apiKey string
+	if opts.KeyFromClipboard {
+		if apiKey, err = readClipboardKey(opts.ClearClipboard); err != nil {
+			return fmt.Errorf("failed to read API key from clipboard: %w", err)
+		}
+		fmt.Printf("Using API key from clipboard: %s\n", maskAPIKey(apiKey))
 	}
 
-	// Add environment to configuration
-	if err := addEnvironmentToConfig(&config, env); err != nil {
-		return fmt.Errorf("failed to add environment: %w", err)
+	var env Environment
+	if opts.KeyFromStdin {
+		// Scripted add: nothing is prompted, so stdin only carries the key
+		if apiKey, err = readSecretFromStdin(os.Stdin); err != nil {
+			return fmt.Errorf("failed to read API key from stdin: %w", err)
+		}
+		if env, err = environmentFromAddFlags(opts, preset, apiKey); err != nil {
+			return fmt.Errorf("environment input failed: %w", err)
+		}
+		if preset != nil {
+			if warning := preset.keyFormatWarning(env.APIKey); warning != "" {
+				fmt.Println(warning)
+			}
+		}
+	} else {
+		// Prompt for new environment details
+		if env, err = promptForEnvironmentWithKey(config, preset, apiKey); err != nil {
+			return fmt.Errorf("environment input failed: %w", err)
+		}
 	}
 
-	// Save updated configuration
-	if err := saveConfig(config); err != nil {
-		return fmt.Errorf("failed to save configuration: %w", err)
+	// Same URL and key as an existing environment: usually an accidental second add
+	if names := findDuplicatesOf(config, env); len(names) > 0 {
+		fmt.Printf("Warning: '%s' already uses this URL and API key (merge duplicates with 'cde dedupe').\n", strings.Join(names, "', '"))
+		if !opts.KeyFromStdin && isInteractiveInput() {
+			answer, err := regularInput(tr("Add anyway? [y/N]: "))
+			if err != nil {
+				return fmt.Errorf("failed to get confirmation: %w", err)
+			}
+			if answer != "y" && answer != "yes" {
+				fmt.Println("Add cancelled.")
+				return nil
+			}
+		}
+	}
+
+	// A preset already warned about its own key format
+	if preset == nil || preset.keyFormatWarning(env.APIKey) == "" {
+		for _, warning := range keyProviderWarnings(env.URL, env.APIKey) {
+			fmt.Println(warning)
+		}
+	}
+
+	// Add environment under the config lock (re-reading picks up concurrent changes)
+	if err := updateConfig(func(config *Config) error {
+		if err := addEnvironmentToConfig(config, env); err != nil {
+			return fmt.Errorf("failed to add environment: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	if _, err := fmt.Printf("Environment '%s' added successfully.\n", env.Name); err != nil {
@@ -679,6 +1627,12 @@ func runAdd() error {
 
 // runRemove removes an environment configuration
 func runRemove(name string) error {
+	return runRemoveWithOptions(name, false, false)
+}
+
+// runRemoveWithOptions removes an environment, asking for confirmation on a terminal unless
+// forced, after a labeled backup unless noBackup is set
+func runRemoveWithOptions(name string, force, noBackup bool) error {
 	// Validate name parameter
 	if err := validateName(name); err != nil {
 		return fmt.Errorf("invalid environment name: %w", err)
@@ -690,19 +1644,190 @@ func runRemove(name string) error {
 		return fmt.Errorf("configuration loading failed: %w", err)
 	}
 
-	// Remove environment from configuration
-	if err := removeEnvironmentFromConfig(&config, name); err != nil {
-		return fmt.Errorf("failed to remove environment: %w", err)
+	// Confirm interactively, showing what is about to be deleted
+	if index, exists := findEnvironmentByName(config, name); exists && !force && isInteractiveInput() {
+		confirmed, err := confirmRemoval(config.Environments[index])
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Removal cancelled.")
+			return nil
+		}
+	}
+
+	// Move environment to the trash so it can be restored
+	opts := saveOptions{BackupLabel: "remove-" + name, NoBackup: noBackup}
+	if err := updateConfigWithOptions(opts, func(config *Config) error {
+		now := time.Now()
+		if err := moveToTrash(config, name, now); err != nil {
+			return fmt.Errorf("failed to remove environment: %w", err)
+		}
+		purgeExpiredTrash(config, now)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Printf("Environment '%s' removed successfully (undo with 'cde restore %s').\n", name, name); err != nil {
+		return fmt.Errorf("failed to display success message: %w", err)
+	}
+
+	return nil
+}
+
+// runRemoveAll deletes every environment; --force is required
+func runRemoveAll(force, noBackup bool) error {
+	if !force {
+		return fmt.Errorf("argument validation failed: remove --all requires --force")
+	}
+
+	count := 0
+	opts := saveOptions{BackupLabel: "remove-all", NoBackup: noBackup}
+	if err := updateConfigWithOptions(opts, func(config *Config) error {
+		count = len(config.Environments)
+		now := time.Now()
+		for _, env := range append([]Environment{}, config.Environments...) {
+			if err := moveToTrash(config, env.Name, now); err != nil {
+				return fmt.Errorf("failed to remove environment: %w", err)
+			}
+		}
+		purgeExpiredTrash(config, now)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Printf("Removed %d environment(s).\n", count); err != nil {
+		return fmt.Errorf("failed to display success message: %w", err)
+	}
+	return nil
+}
+
+// runClone duplicates an existing environment under a new name, applying overrides
+func runClone(flags map[string]string) error {
+	source := flags["clone_source"]
+	target := flags["clone_target"]
+
+	if err := validateName(target); err != nil {
+		return fmt.Errorf("invalid environment name: %w", err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	index, exists := findEnvironmentByName(config, source)
+	if !exists {
+		return fmt.Errorf("environment '%s' not found", source)
 	}
 
-	// Save updated configuration
-	if err := saveConfig(config); err != nil {
-		return fmt.Errorf("failed to save configuration: %w", err)
+	env := cloneEnvironment(config.Environments[index], target)
+
+	_, hasURL := flags["url"]
+	_, hasModel := flags["model"]
+	_, hasEnvVars := flags["env_vars"]
+	if hasURL || hasModel || hasEnvVars {
+		// Non-interactive: apply only the overrides given on the command line
+		if hasURL {
+			env.URL = flags["url"]
+		}
+		if hasModel {
+			env.Model = flags["model"]
+		}
+		overrides := map[string]string{}
+		for _, entry := range strings.Split(flags["env_vars"], "\n") {
+			if entry == "" {
+				continue
+			}
+			key, value, found := strings.Cut(entry, "=")
+			if !found || !isValidEnvVarName(key) {
+				return fmt.Errorf("invalid --env-var '%s': expected KEY=VALUE", entry)
+			}
+			overrides[key] = value
+		}
+		setEnvVars(&env, overrides)
+	} else if isInteractiveInput() {
+		env, err = promptForCloneChanges(env)
+		if err != nil {
+			return fmt.Errorf("environment input failed: %w", err)
+		}
+	}
+
+	// addEnvironmentToConfig validates the result and rejects duplicate names
+	if err := updateConfig(func(config *Config) error {
+		if err := addEnvironmentToConfig(config, env); err != nil {
+			return fmt.Errorf("failed to clone environment: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	if _, err := fmt.Printf("Environment '%s' removed successfully.\n", name); err != nil {
+	if _, err := fmt.Printf("Environment '%s' cloned to '%s' successfully.\n", source, target); err != nil {
 		return fmt.Errorf("failed to display success message: %w", err)
 	}
 
 	return nil
 }
+
+// setEnvVars applies KEY=VALUE overrides to env's env_vars; an empty value removes the variable
+func setEnvVars(env *Environment, overrides map[string]string) {
+	for key, value := range overrides {
+		if value == "" {
+			delete(env.EnvVars, key)
+			continue
+		}
+		if env.EnvVars == nil {
+			env.EnvVars = map[string]string{}
+		}
+		env.EnvVars[key] = value
+	}
+}
+
+// runRotateKey swaps the active API key with a named key (default "backup"), records today as
+// key_created_at and sets key_expires_at to expires (cleared when empty, since it described the old key)
+func runRotateKey(name, keyName, expires string) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("invalid environment name: %w", err)
+	}
+	if keyName == "" {
+		keyName = "backup"
+	}
+	if err := validateKeyDate(expires); err != nil {
+		return fmt.Errorf("invalid --expires: %w", err)
+	}
+
+	var rotated Environment
+	var previousExpiry string
+	if err := updateConfig(func(config *Config) error {
+		index, exists := findEnvironmentByName(*config, name)
+		if !exists {
+			return fmt.Errorf("environment '%s' not found", name)
+		}
+
+		var err error
+		rotated, err = rotateAPIKey(config.Environments[index], keyName)
+		if err != nil {
+			return fmt.Errorf("failed to rotate key: %w", err)
+		}
+		previousExpiry = rotated.KeyExpiresAt
+		rotated.KeyCreatedAt = time.Now().Format(keyDateLayout)
+		rotated.KeyExpiresAt = expires
+		config.Environments[index] = rotated
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Printf("Environment '%s' now uses key '%s' (%s); previous key stored as '%s'.\n",
+		name, keyName, maskAPIKey(rotated.APIKey), keyName); err != nil {
+		return fmt.Errorf("failed to display success message: %w", err)
+	}
+	if previousExpiry != "" && expires == "" {
+		fmt.Printf("Cleared key_expires_at (%s); pass --expires <date> to set one for the new key.\n", previousExpiry)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -341,6 +342,51 @@ func TestFindEnvironmentByName(t *testing.T) {
 	}
 }
 
+func TestFindEnvironmentByNameStaleIndex(t *testing.T) {
+	config := Config{Environments: []Environment{{Name: "prod"}, {Name: "staging"}, {Name: "dev"}}}
+	config.nameIndex = indexEnvironments(config.Environments)
+
+	// Remove an entry and add another after the index was built
+	config.Environments = append(config.Environments[:0], config.Environments[1:]...)
+	config.Environments = append(config.Environments, Environment{Name: "qa"})
+
+	for name, want := range map[string]int{"staging": 0, "dev": 1, "qa": 2} {
+		if index, found := findEnvironmentByName(config, name); !found || index != want {
+			t.Errorf("findEnvironmentByName(%q) = %d, %t, want %d", name, index, found, want)
+		}
+	}
+	if _, found := findEnvironmentByName(config, "prod"); found {
+		t.Error("removed environment should not be found through the stale index")
+	}
+}
+
+func TestLazyValidationOnLaunch(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	data := `{"environments":[
+		{"name":"good","url":"https://api.openai.com/v1","api_key":"sk-good-123456789"},
+		{"name":"broken","url":"not a url","api_key":"sk-broken-123456789"}]}`
+	if err := ensureConfigDir(); err != nil {
+		t.Fatalf("ensureConfigDir() failed: %v", err)
+	}
+	if err := ioutil.WriteFile(configPathOverride, []byte(data), 0600); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if _, err := loadConfig(); err == nil {
+		t.Error("loadConfig() should still validate every environment")
+	}
+	if _, err := planLaunch("good", nil, launchOptions{}); err != nil {
+		t.Errorf("planLaunch(good) error = %v, an unrelated invalid environment should not block it", err)
+	}
+	_, err := planLaunch("broken", nil, launchOptions{})
+	if err == nil || !strings.Contains(err.Error(), "validation failed for environment 'broken'") {
+		t.Errorf("planLaunch(broken) error = %v, want a validation error", err)
+	}
+}
+
 func TestMaskAPIKey(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -778,6 +824,97 @@ func TestParseArgumentsEdgeCases(t *testing.T) {
 }
 
 // TestValidatePassthroughArgs tests the security validation for claude arguments
+func TestParseArgumentsEqualsForm(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantFlags map[string]string
+		wantArgs  []string
+	}{
+		{"long env", []string{"--env=prod"}, map[string]string{"env": "prod"}, []string{}},
+		{"short env", []string{"-e=prod", "exec", "hi"}, map[string]string{"env": "prod"}, []string{"exec", "hi"}},
+		{"mixed forms", []string{"-e", "prod", "--key=backup", "--timeout", "30m"}, map[string]string{"env": "prod", "key": "backup", "timeout": "30m"}, []string{}},
+		{"value with equals", []string{"--var=region=eu", "--env=prod"}, map[string]string{"vars": "region=eu", "env": "prod"}, []string{}},
+		{"codex args untouched", []string{"--env=prod", "--model=gpt-5", "--", "--env=x"}, map[string]string{"env": "prod"}, []string{"--model=gpt-5", "--", "--env=x"}},
+		{"after separator", []string{"--", "--env=prod"}, map[string]string{}, []string{"--env=prod"}},
+		{"subcommand flag", []string{"list", "--sort=recent"}, map[string]string{"sort": "recent"}, nil},
+		{"compare", []string{"compare", "-e=a", "--env=b", "--out=dir", "--", "exec", "--model=x"}, map[string]string{"compare_envs": "a,b", "out": "dir"}, []string{"exec", "--model=x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if result.Error != nil {
+				t.Fatalf("parseArguments() error = %v", result.Error)
+			}
+			for key, want := range tt.wantFlags {
+				if got := result.CCEFlags[key]; got != want {
+					t.Errorf("flag %s = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantArgs != nil && !reflect.DeepEqual(result.ClaudeArgs, tt.wantArgs) {
+				t.Errorf("codex args = %v, want %v", result.ClaudeArgs, tt.wantArgs)
+			}
+		})
+	}
+
+	// The caller's slice is never rewritten
+	args := []string{"--env=prod", "exec"}
+	parseArguments(args)
+	if args[0] != "--env=prod" {
+		t.Errorf("parseArguments modified its input: %v", args)
+	}
+}
+
+func TestParseArgumentsGlobalFlagsAfterSubcommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		subcommand string
+		wantFlags  map[string]string
+		wantErr    bool
+	}{
+		{"no-color after list", []string{"list", "--table", "--no-color"}, "list", map[string]string{"no_color": "true", "table": "true"}, false},
+		{"list --json", []string{"list", "--json", "--sort=name"}, "list", map[string]string{"json": "true", "sort": "name"}, false},
+		{"flags before a nested subcommand", []string{"sync", "--no-color", "pull", "--dry-run"}, "sync-pull", map[string]string{"no_color": "true", "dry_run": "true"}, false},
+		{"json is not a remove flag", []string{"remove", "dev", "--json", "--force"}, "", nil, true},
+		{"flag of another subcommand", []string{"list", "--force"}, "", nil, true},
+		{"value given to a switch", []string{"list", "--table=yes"}, "", nil, true},
+		{"-- after a command without codex args", []string{"list", "--", "exec"}, "", nil, true},
+		{"config after add", []string{"add", "--api-key-stdin", "--config", "/tmp/alt.json", "--name", "dev", "--url", "https://api.openai.com/v1"}, "add", map[string]string{"config": "/tmp/alt.json", "key_from_stdin": "true", "name": "dev"}, false},
+		{"config equals form", []string{"pin", "--list", "--config=/tmp/alt.json"}, "pin", map[string]string{"config": "/tmp/alt.json", "list": "true"}, false},
+		{"debug logging", []string{"dedupe", "-vv", "--dry-run"}, "dedupe", map[string]string{"log_level": "trace", "dry_run": "true"}, false},
+		{"lang after help", []string{"help", "add", "--lang=zh-CN"}, "help", map[string]string{"lang": "zh-CN", "help_topic": "add"}, false},
+		{"list keeps its own --verbose", []string{"list", "--verbose"}, "list", map[string]string{"verbose": "true"}, false},
+		{"replay keeps its own --json", []string{"replay", "snap.json", "--json"}, "replay", map[string]string{"json": "true", "snapshot_file": "snap.json"}, false},
+		{"compare args after -- untouched", []string{"compare", "-e", "a", "-e", "b", "--no-color", "--", "exec", "--json"}, "compare", map[string]string{"no_color": "true"}, false},
+		{"config without value", []string{"list", "--config"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if (result.Error != nil) != tt.wantErr {
+				t.Fatalf("parseArguments() error = %v, wantErr %v", result.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Subcommand != tt.subcommand {
+				t.Errorf("subcommand = %q, want %q", result.Subcommand, tt.subcommand)
+			}
+			for key, want := range tt.wantFlags {
+				if got := result.CCEFlags[key]; got != want {
+					t.Errorf("flag %s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+
+	result := parseArguments([]string{"compare", "-e", "a", "-e", "b", "--", "exec", "--json"})
+	if !reflect.DeepEqual(result.ClaudeArgs, []string{"exec", "--json"}) || result.CCEFlags["json"] != "" {
+		t.Errorf("codex args after -- must pass through: %v, flags %v", result.ClaudeArgs, result.CCEFlags)
+	}
+}
+
 func TestValidatePassthroughArgs(t *testing.T) {
 	tests := []struct {
 		name      string
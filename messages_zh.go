@@ -0,0 +1,198 @@
+package main
+
+// zhCNMessages is the Simplified Chinese catalog, keyed by the English message
+var zhCNMessages = map[string]string{
+	// Help headings and option descriptions
+	"Usage: cde %s":   "用法: cde %s",
+	"Options:":        "选项:",
+	"Launch options:": "启动选项:",
+	"Examples:":       "示例:",
+	"Usage:":          "用法:",
+	"Commands:":       "命令:",
+	"Notes:":          "说明:",
+	"Interface language (en, zh-CN; defaults to settings.language or LANG)": "选择界面语言（en、zh-CN；默认取 settings.language 或 LANG）",
+
+	// Command summaries and flags
+	"Show model aliases, deployment maps, auto flags and other details":                       "显示模型别名、部署映射、auto flags 等详细信息",
+	"Sort by name, most recently used or URL":                                                 "按名称、最近使用或 URL 排序",
+	"Table output with one environment per line":                                              "每个环境一行的表格输出",
+	"Print environments as a JSON array with masked keys":                                     "以 JSON 数组输出环境（密钥已遮盖）",
+	"Print environment names only":                                                            "仅输出环境名称",
+	"List all configured environments":                                                        "列出所有已配置环境",
+	"Search by name, URL, model, provider or env var name (exit code 1 when nothing matches)": "按名称、URL、模型、提供商或环境变量名搜索（无匹配时退出码为 1）",
+	"Table output": "表格输出",
+	"Add an environment (optional model; presets: openai, azure, openrouter, local)": "新增环境配置（可选模型；预设: openai, azure, openrouter, local）",
+	"Use a provider preset (openai, azure, openrouter, local)":                       "使用提供商预设（openai, azure, openrouter, local）",
+	"Read the API key from the clipboard":                                            "从剪贴板读取 API 密钥",
+	"Clear the clipboard after reading":                                              "读取后清空剪贴板",
+	"Read the key from stdin and add without prompts (requires --name and --url)":    "从标准输入读取密钥，无交互添加（需 --name 与 --url）",
+	"Environment name and API URL":                                                   "环境名称与 API 地址",
+	"Default model":                                                                  "默认模型",
+	"Extra environment variable (repeatable)":                                        "附加环境变量（可重复）",
+	"Remove an environment (creates a pre-remove-* backup first)":                    "删除环境配置（先创建 pre-remove-* 备份）",
+	"Skip confirmation":                                                              "跳过确认",
+	"Remove every environment (requires --force)":                                    "删除全部环境（需 --force）",
+	"Do not create a backup":                                                         "不创建备份",
+	"Restore a removed environment from the trash (kept 30 days by default)":         "从回收站恢复已删除的环境（默认保留 30 天）",
+	"Manage config backups (retention in settings.backup)":                           "管理配置备份（保留策略见 settings.backup）",
+	"List config backups (newest first)":                                             "列出配置备份（最新在前）",
+	"Restore config.json from a backup":                                              "从备份恢复 config.json",
+	"Apply the retention policy now":                                                 "立即应用保留策略",
+	"Run any command (aider, llm, scripts) with the environment's variables, without codex argument handling": "使用环境变量运行任意命令（aider、llm、脚本等），不做 codex 参数处理",
+	"Run the same codex exec against several environments and show outputs and timings side by side":          "对多个环境依次运行同一 codex exec，并排显示输出与耗时",
+	"Environment to compare (at least two, repeatable)":                                                       "参与比较的环境（至少两个，可重复）",
+	"Write each environment's output to DIR/<env>.txt":                                                        "将每个环境的输出写入 DIR/<env>.txt",
+	"Relaunch codex with the environment, URL and arguments recorded by --snapshot":                           "按 --snapshot 记录的环境、URL 与参数重新启动 codex",
+	"Preview only":              "仅预览",
+	"Print the preview as JSON": "以 JSON 输出预览",
+	"List or switch config profiles (config.<name>.json; CDE_PROFILE wins, default is config.json)": "列出/切换配置 profile（config.<name>.json；CDE_PROFILE 优先，default 为 config.json）",
+	"Sync with the team's shared environment list (keys excluded)":                                  "与团队共享环境列表同步（不含密钥）",
+	"Preview changes only":                                                                            "仅预览变更",
+	"Take the remote version on conflicts (pull)":                                                     "冲突时使用远端版本（pull）",
+	"Overwrite the remote (push)":                                                                     "覆盖远端（push）",
+	"Copy an environment (prompts when --url/--model/--env-var are not given)":                        "复制环境配置（未指定 --url/--model/--env-var 时交互提示）",
+	"Set (KEY=VALUE) or remove (KEY=) an env var of the copy (repeatable)":                            "设置（KEY=VALUE）或删除（KEY=）副本的环境变量（可重复）",
+	"Find and merge environments with the same URL and key":                                           "查找并合并 URL 与密钥相同的环境",
+	"Only list the duplicate groups":                                                                  "仅列出重复的环境组",
+	"Pin the current directory (git repository root) to an environment that a plain cde then selects": "将当前目录（git 仓库根目录）固定到环境，之后直接运行 cde 自动选择",
+	"List all pins":               "列出所有固定",
+	"Unpin the current directory": "取消当前目录的固定",
+	"Swap a named key (backup by default) with the current api_key":                                     "将命名密钥（默认 backup）与当前 api_key 互换",
+	"Expiry date of the new key (YYYY-MM-DD)":                                                           "新密钥的过期日期（YYYY-MM-DD）",
+	"Show the full API key after confirmation":                                                          "确认后显示完整 API 密钥",
+	"Show a named key instead of api_key":                                                               "显示命名密钥而非 api_key",
+	"Launch with a named argument set from settings.presets":                                            "使用 settings.presets 中的命名参数组合启动",
+	"Print environment variable export statements":                                                      "输出环境变量导出语句",
+	"Shell syntax of the exports":                                                                       "导出语句的 shell 语法",
+	"Mask secrets":                                                                                      "遮蔽密钥",
+	"Show recent launch audit records (requires settings.audit.enabled)":                                "显示最近的启动审计记录（需 settings.audit.enabled）",
+	"Number of records to show (default 20)":                                                            "显示的记录数（默认 20）",
+	"List the models from an environment's /models endpoint, then launch one or save it as the default": "列出环境 /models 接口提供的模型，可选择后立即启动或保存为默认模型",
+	"Environment to query":                                                                              "查询的环境",
+	"Validate, repair, compare or edit the config file":                                                 "校验、修复、比较或编辑配置文件",
+	"Check the config against the JSON Schema and field validators and list every problem (with path and line)":                   "按 JSON Schema 与字段校验器检查配置，列出全部问题（含路径与行号）",
+	"Print the config file's JSON Schema (for editor completion and validation)":                                                  "输出配置文件的 JSON Schema（供编辑器补全与校验）",
+	"Recovery wizard for a corrupt config: shows salvageable environments and backups, restores after confirmation":               "配置损坏时的恢复向导：显示可挽救的环境与备份列表，确认后恢复",
+	"Compare two configs field by field (latest backup against the current config by default; arguments are files or backup IDs)": "按字段比较两个配置（默认最新备份对比当前配置，参数可为文件或备份 ID）",
+	"Edit the config in $VISUAL/$EDITOR and validate it on save":                                                                  "用 $VISUAL/$EDITOR 编辑配置，保存后校验",
+	"Import environments from codex/claude-code-env configs and environment variables (deduplicated by URL)":                      "从 codex/claude-code-env 配置与环境变量导入环境（按 URL 去重）",
+	"Do not create a pre-import-* backup": "不创建 pre-import-* 备份",
+	"Write environments as profiles in ~/.codex/config.toml (only the cde-managed block is updated)": "将环境写入 ~/.codex/config.toml 的 profile（仅更新 cde 管理区块）",
+	"Show the diff without writing": "显示差异，不写入",
+	"Expose launch metrics in Prometheus format on /metrics (requires settings.metrics.enabled)":                                    "以 Prometheus 格式在 /metrics 暴露启动指标（需 settings.metrics.enabled）",
+	"Listen address (default 127.0.0.1:9464)":                                                                                       "监听地址（默认 127.0.0.1:9464）",
+	"Show the installed codex version and known incompatibilities (disable the pre-launch check with settings.codex_version_check)": "显示已安装 codex 版本与已知不兼容项（启动前检查可用 settings.codex_version_check 关闭）",
+	"Check the config and the codex install":                                                                                        "检查配置与 codex 安装",
+	"Run launches against a fake codex: model injection, env export, exit status, auto flags":                                       "用模拟 codex 运行启动流程：模型注入、环境变量导出、退出码、auto flags",
+	"Check for a newer release (skipped when CDE_OFFLINE=1)":                                                                        "检查是否有新版本（CDE_OFFLINE=1 时跳过）",
+	"Download and verify the latest release, then atomically replace the current binary":                                            "下载并校验最新版本后原子替换当前二进制",
+	"Show the environment that would be selected, the final codex arguments and environment variables (no launch, secrets masked)":  "显示将选择的环境、最终 codex 参数与环境变量（不启动，密钥已遮蔽）",
+	"Auto-approve with a sandbox (default -a never --sandbox workspace-write, configurable with auto_flags)":                        "自动批准并使用沙箱（默认 -a never --sandbox workspace-write，可由 auto_flags 配置）",
+	"Show help (cde <command> --help shows one command)":                                                                            "显示帮助（cde <command> --help 显示单个命令）",
+	"Print the overview followed by every command's help":                                                                           "依次输出总览与全部命令帮助",
+	"Print the man page (roff)": "输出 man 手册页（roff 格式）",
+	"Select the environment (CDE_ENV when omitted; CDE_MODEL overrides the environment's default model)":                   "选择环境（未指定时使用 CDE_ENV；CDE_MODEL 覆盖环境默认模型）",
+	"Launch with a named key from the environment's keys":                                                                  "使用环境中的命名密钥（keys 字段）启动",
+	"Probe every environment concurrently and pick the lowest-latency healthy endpoint":                                    "并发探测所有环境，选择延迟最低的可用端点",
+	"Health-check in order and use the first healthy environment":                                                          "按顺序健康检查，使用第一个可用的环境",
+	"Select, validate and prepare arguments, then print the final command and environment changes without launching codex": "完成选择、校验与参数准备后输出最终命令和环境变量变化，不启动 codex",
+	"Launch despite an environment budget with budget.action=block":                                                        "忽略环境预算（budget.action=block）限制强制启动",
+	"Skip the launch confirmation of confirm_before_use environments":                                                      "跳过 confirm_before_use 环境的启动确认",
+	"Stop codex after a session timeout such as 30m and exit with status 124 (runs as a child process)":                    "会话超时（如 30m）后终止 codex 并以状态 124 退出（以子进程模式运行）",
+	"Launch despite the environment's rate_limit":                                                                          "忽略环境的启动频率限制（rate_limit）强制启动",
+	"Keep the shell's value when OPENAI_* and similar variables conflict with the environment":                             "shell 中已设置的 OPENAI_* 等变量与环境冲突时保留 shell 的值",
+	"Fail when a non-interactive run names no environment with -e or CDE_ENV (or set settings.headless_policy)":            "非交互调用未指定环境（-e 或 CDE_ENV）时直接失败（亦可设置 settings.headless_policy）",
+	"Skip argument checks for this launch (not allowed when settings.validation.arg_policy is strict)":                     "本次启动跳过参数检查（settings.validation.arg_policy 为 strict 时不允许）",
+	"Print --dry-run/which results as JSON; errors go to stderr as JSON with an error code":                                "以 JSON 输出 --dry-run/which 结果；出错时向 stderr 输出含错误码的 JSON",
+	"Disable colored output (NO_COLOR is also honored)":                                                                    "禁用彩色输出（也支持 NO_COLOR 环境变量）",
+	"Set a {name} placeholder in url/env_vars (repeatable; missing values are prompted for in a terminal)":                 "为 URL/env_vars 中的 {name} 占位符赋值（可重复；终端中缺失的值会提示输入）",
+	"Write a reproducible manifest before launch (version, environment, URL, model, arguments; no secrets)":                "启动前写入可复现清单（版本、环境、URL、模型、参数；不含密钥）",
+	"Use this config file instead of the current profile (before launch arguments, or after a management subcommand)":      "使用指定配置文件而非当前 profile（放在启动参数之前，或管理子命令之后）",
+	"Debug/trace logging to stderr (or set CDE_LOG_LEVEL=error|warn|info|debug|trace; CDE_LOG_FILE writes to a file)":      "向 stderr 输出调试/追踪日志（或设置 CDE_LOG_LEVEL=error|warn|info|debug|trace，CDE_LOG_FILE 写入文件）",
+	"Show help": "显示帮助",
+
+	// Help notes and examples
+	"Arguments after the CDE options are passed straight through to codex.":                                                                                                                                         "所有 CDE 选项之后的参数都会直接透传给 codex 命令。",
+	"Use '--' to separate CDE options from codex arguments explicitly.":                                                                                                                                             "使用 '--' 明确分隔 CDE 与 codex 参数。",
+	"cde help <command> or cde <command> --help shows one command's usage, options and examples.":                                                                                                                   "cde help <command> 或 cde <command> --help 显示单个命令的用法、选项与示例。",
+	"When the environment sets a model and the arguments do not choose one with '-m/--model', '-m <env.model>' is added (default model example: gpt-5).":                                                            "如果环境配置了 model 且未在参数中指定 '-m/--model'，将自动追加 '-m <env.model>'（默认模型示例: gpt-5）。",
+	"The environment's model_params are passed to codex as '-c key=value' (a -c for the same key on the command line wins).":                                                                                        "环境中的 model_params 会以 '-c key=value' 形式传给 codex（命令行中已指定的同名 -c 优先）。",
+	"api_key may be op://vault/item/field (1Password CLI) or vault://path#key (requires VAULT_ADDR/VAULT_TOKEN); it is resolved at launch and kept in memory only.":                                                 "api_key 可写为 op://vault/item/field（1Password CLI）或 vault://path#key（需 VAULT_ADDR/VAULT_TOKEN），启动时解析，仅保存在内存中。",
+	"An environment may set auth (type: oauth, client_credentials or device_code flow) to fetch a short-lived token as the API key at launch, cached until it expires.":                                             "环境可配置 auth（type: oauth，client_credentials 或 device_code 流程），启动时获取短期令牌作为 API key，并缓存至过期。",
+	"auth type: gcp (project/location/account/adc) fetches an access token through gcloud at launch and uses it as the API key.":                                                                                    "auth type: gcp（project/location/account/adc）启动时通过 gcloud 获取访问令牌作为 API key。",
+	"auth type: aws (region/profile/role_arn) verifies credentials with the AWS CLI before launch and exports AWS_PROFILE/AWS_REGION or temporary credentials for a SigV4 signing proxy.":                           "auth type: aws（region/profile/role_arn）启动前用 AWS CLI 验证凭证，并导出 AWS_PROFILE/AWS_REGION 或临时凭证，用于 SigV4 签名代理。",
+	"Passthrough arguments containing rm -rf, sudo, /etc/passwd or ../ only warn by default; settings.validation.arg_policy may be strict (reject) or off, and arg_allow/arg_deny are regex lists.":                 "透传参数含 rm -rf、sudo、/etc/passwd、../ 时默认仅警告；settings.validation.arg_policy 可设为 strict（拒绝）或 off，arg_allow/arg_deny 为正则列表。",
+	"Arguments after '--' are codex content and only warn; settings.validation.strict_passthrough applies the full policy to them.":                                                                                 "'--' 之后的参数视为 codex 内容，仅警告不拒绝；设置 settings.validation.strict_passthrough 可对其应用完整策略。",
+	"An environment may list several endpoints in urls with url_strategy round-robin (default), random or sticky (switches when a health check fails); --fastest/--failover probe every endpoint.":                  "环境可用 urls 配置多个端点，url_strategy 为 round-robin（默认）、random 或 sticky（健康检查失败时切换）；--fastest/--failover 会探测全部端点。",
+	"With CDE_ENVIRONMENTS_JSON (an environment array or full config) or CDE_ENV_ONLY=1 (uses OPENAI_API_KEY/OPENAI_BASE_URL/OPENAI_MODEL) no config file is read and commands that modify the config are refused.": "设置 CDE_ENVIRONMENTS_JSON（环境数组或完整配置）或 CDE_ENV_ONLY=1（使用 OPENAI_API_KEY/OPENAI_BASE_URL/OPENAI_MODEL）时不读取配置文件，修改配置的命令将被拒绝。",
+	"settings.hooks (with enabled: true) run commands before launch and after codex exits (pre_launch/post_exit) with a minimal environment plus CDE_ENV_* details and a timeout.":                                  "settings.hooks（需 enabled: true）在启动前/codex 退出后运行命令（pre_launch/post_exit），仅传入最小环境变量与 CDE_ENV_* 信息，带超时。",
+	"Pick an environment interactively and launch Codex": "交互式选择并启动 Codex",
+	"Launch Codex with the 'prod' environment":           "使用 'prod' 环境启动 Codex",
+	"Auto-approve with a sandbox and run mcp":            "自动批准 + 沙箱，执行 mcp",
+	"Pass '--help' through to codex":                     "透传 '--help' 到 codex",
+
+	// Prompts
+	"Configuration is invalid. [e]dit again or [r]evert? [E/r]: ":                 "配置无效。[e] 重新编辑或 [r] 还原？[E/r]: ",
+	"Environment '%s' (%s) requires confirmation. Type its name or y to launch: ": "环境 '%s'（%s）需要确认。输入环境名称或 y 启动: ",
+	"\nGroup %d: keep which environment? [1-%d, Enter to skip]: ":                 "\n第 %d 组：保留哪个环境？[1-%d，回车跳过]: ",
+	"Name [%s] (- to skip): ":                                  "名称 [%s]（- 跳过）: ",
+	"API Key (Enter to skip): ":                                "API 密钥（回车跳过）: ",
+	"Add anyway? [y/N]: ":                                      "仍然添加？[y/N]: ",
+	"Select model (1-%d, Enter to quit): ":                     "选择模型（1-%d，回车退出）: ",
+	"%s: [l]aunch now, [s]ave as default for '%s', [c]ancel: ": "%s: [l] 立即启动，[s] 保存为 '%s' 的默认模型，[c] 取消: ",
+	"\nChoose: %s [q]: ":                                       "\n请选择: %s [q]: ",
+	"1-%d restore a backup":                                    "1-%d 恢复备份",
+	"[s]ave the recoverable environments":                      "[s] 保存可恢复的环境",
+	"[m]inimal empty config":                                   "[m] 最小空配置",
+	"[q]uit":                                                   "[q] 退出",
+	"This replaces %s with an empty environment list. Type 'yes' to continue: ":          "这将用空环境列表替换 %s。输入 'yes' 继续: ",
+	"Type the environment name to continue: ":                                            "输入环境名称以继续: ",
+	"Select environment (↑↓/j/k, g/G, PgUp/PgDn, 1-9; Enter to confirm, Esc to cancel):": "选择环境（↑↓/j/k、g/G、PgUp/PgDn、1-9；回车确认，Esc 取消）:",
+	"Enter number (1-%d): ": "输入编号（1-%d）: ",
+	"Environment name: ":    "环境名称: ",
+	"Base URL: ":            "API 地址: ",
+	"API Key (hidden): ":    "API 密钥（隐藏输入）: ",
+	"Model [%s]: ":          "模型 [%s]: ",
+	"Model (optional, press Enter for default): ":  "模型（可选，回车使用默认值）: ",
+	"API version [%s]: ":                           "API 版本 [%s]: ",
+	"Variable name: ":                              "变量名: ",
+	"Enter variable name (press Enter when done):": "输入变量名（完成时直接回车）:",
+	"Value for %s: ":                               "%s 的值: ",
+	"Value for %s (required): ":                    "%s 的值（必填）: ",
+	"Value for %s [%s]: ":                          "%s 的值 [%s]: ",
+	"Base URL [%s]: ":                              "API 地址 [%s]: ",
+	"Env vars to change (KEY=VALUE, comma separated; KEY= removes) [%s]: ":                                      "要修改的环境变量（KEY=VALUE，逗号分隔；KEY= 表示删除）[%s]: ",
+	"Remove environment '%s'? [y/N]: ":                                                                          "删除环境 '%s'？[y/N]: ",
+	"↑↓/Tab move, Enter next (submit on last field), Esc cancel":                                                "↑↓/Tab 移动，回车下一项（最后一项提交），Esc 取消",
+	"Select environment (arrows/j/k, g/G, PgUp/PgDn, 1-9; Enter to confirm, Esc to cancel):":                    "选择环境（方向键/j/k、g/G、PgUp/PgDn、1-9；回车确认，Esc 取消）:",
+	"No environments configured yet. What would you like to do? (↑↓/j/k, 1-%d; Enter to confirm, Esc to quit):": "尚未配置任何环境。要做什么？（↑↓/j/k、1-%d；回车确认，Esc 退出）:",
+	"No environments configured yet. What would you like to do?":                                                "尚未配置任何环境。要做什么？",
+	"Choose (1-%d, Enter to quit): ":                                                                            "请选择（1-%d，回车退出）: ",
+	"Select environment:":                                                                                       "选择环境:",
+	"model %s":                                                                                                  "模型 %s",
+	"Enter number (1-%d, Enter to cancel): ":                                                                    "输入编号（1-%d，回车取消）: ",
+	"Please enter a number between 1 and %d.":                                                                   "请输入 1 到 %d 之间的数字。",
+	"Probing %d endpoint(s)":                                                                                    "正在探测 %d 个端点",
+	"Checking %s":                                                                                               "正在检查 %s",
+	"Fetching shared environments":                                                                              "正在获取共享环境",
+	"Publishing shared environments":                                                                            "正在发布共享环境",
+	"Checking for updates":                                                                                      "正在检查更新",
+	"Downloading %s":                                                                                            "正在下载 %s",
+	"Add environment":                                                                                           "添加环境",
+	"Import from codex or claude-code-env":                                                                      "从 codex 或 claude-code-env 导入",
+	"Help":                                                                                                      "帮助",
+	"Quit":                                                                                                      "退出",
+
+	// Error categories
+	"CDE Argument Error [%s]: %v\n":                                  "CDE 参数错误 [%s]: %v\n",
+	"Use 'cde help' for usage information.":                          "运行 'cde help' 查看用法。",
+	"CDE Configuration Error [%s]: %v\n":                             "CDE 配置错误 [%s]: %v\n",
+	"Check your environment configuration with 'cde list'.":          "请用 'cde list' 检查环境配置。",
+	"Codex Error [%s]: %v\n":                                         "Codex 错误 [%s]: %v\n",
+	"This error originated from the codex command.":                  "该错误来自 codex 命令。",
+	"Terminal Compatibility Error [%s]: %v\n":                        "终端兼容性错误 [%s]: %v\n",
+	"Try using a different terminal or check terminal capabilities.": "请尝试其他终端或检查终端功能。",
+	"Permission Error [%s]: %v\n":                                    "权限错误 [%s]: %v\n",
+	"Check file permissions and access rights.":                      "请检查文件权限与访问权限。",
+	"Error [%s]: %v\n":                                               "错误 [%s]: %v\n",
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultMetricsListen is where 'cde serve-metrics' listens unless --listen is given
+const defaultMetricsListen = "127.0.0.1:9464"
+
+// MetricsSettings configures the opt-in launch metrics
+type MetricsSettings struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Textfile string `json:"textfile,omitempty"` // Prometheus textfile rewritten after each update (node_exporter textfile collector)
+}
+
+// metricsState holds cumulative values keyed by series, e.g. cde_launches_total{environment="dev",mode="exec"}
+type metricsState struct {
+	Series map[string]float64 `json:"series"`
+}
+
+// metricFamily describes a metric for the Prometheus exposition format
+type metricFamily struct {
+	Name string
+	Type string
+	Help string
+}
+
+// metricFamilies lists every metric cde emits, in output order
+var metricFamilies = []metricFamily{
+	{Name: "cde_launches_total", Type: "counter", Help: "Codex launches by environment and launch mode."},
+	{Name: "cde_failures_total", Type: "counter", Help: "cde commands that failed, by error category."},
+	{Name: "cde_selection_seconds", Type: "summary", Help: "Time spent choosing an environment, by selection method."},
+	{Name: "cde_codex_run_seconds", Type: "summary", Help: "Codex run time in subprocess launch mode, by environment."},
+	{Name: "cde_codex_exits_total", Type: "counter", Help: "Codex exits in subprocess launch mode, by environment and result."},
+}
+
+// metricsEnabled reports whether settings.metrics.enabled is set
+func metricsEnabled(config Config) bool {
+	return config.Settings != nil && config.Settings.Metrics != nil && config.Settings.Metrics.Enabled
+}
+
+// getMetricsPath returns the active profile's metrics state file
+func getMetricsPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "metrics.json"), nil
+}
+
+// loadMetrics reads metrics.json; a missing or unreadable file starts from zero
+func loadMetrics() metricsState {
+	state := metricsState{Series: map[string]float64{}}
+	path, err := getMetricsPath()
+	if err != nil {
+		return state
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	if state.Series == nil {
+		state.Series = map[string]float64{}
+	}
+	return state
+}
+
+// metricSeries builds a series key from a metric name and label name/value pairs
+func metricSeries(name string, labels ...string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], escapeLabelValue(labels[i+1])))
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabelValue escapes a label value for the exposition format
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// seriesName returns the metric name of a series key
+func seriesName(series string) string {
+	if index := strings.Index(series, "{"); index >= 0 {
+		return series[:index]
+	}
+	return series
+}
+
+// formatMetrics renders state in the Prometheus text exposition format
+func formatMetrics(state metricsState) string {
+	keys := make([]string, 0, len(state.Series))
+	for key := range state.Series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, family := range metricFamilies {
+		builder.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", family.Name, family.Help, family.Name, family.Type))
+		for _, key := range keys {
+			name := seriesName(key)
+			if name != family.Name && (family.Type != "summary" || (name != family.Name+"_sum" && name != family.Name+"_count")) {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("%s %s\n", key, formatMetricValue(state.Series[key])))
+		}
+	}
+	return builder.String()
+}
+
+// formatMetricValue prints integers without a fraction and other values with millisecond precision
+func formatMetricValue(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.3f", value)
+}
+
+// writeFileAtomic writes data via a temporary file and rename
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tempPath := path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// updateMetrics applies update to the stored metrics under the config lock and refreshes
+// the textfile. It does nothing unless metrics are enabled.
+func updateMetrics(config Config, update func(series map[string]float64)) error {
+	if !metricsEnabled(config) {
+		return nil
+	}
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	state := loadMetrics()
+	update(state.Series)
+
+	path, err := getMetricsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("metrics serialization failed: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("metrics write failed: %w", err)
+	}
+
+	if textfile := config.Settings.Metrics.Textfile; textfile != "" {
+		// node_exporter only reads *.prom files, so a rename keeps scrapes from seeing partial output
+		if err := writeFileAtomic(textfile, []byte(formatMetrics(state)), 0644); err != nil {
+			return fmt.Errorf("metrics textfile write failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// warnMetrics reports a failed metrics update without failing the command
+func warnMetrics(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: metrics not recorded: %v\n", err)
+	}
+}
+
+// selectionMethod maps a launch plan source to a low-cardinality label value
+func selectionMethod(source string) string {
+	switch {
+	case strings.HasPrefix(source, "--failover"):
+		return "failover"
+	case source == "--env flag":
+		return "env"
+	case source == "CDE_ENV":
+		return "env_var"
+	case strings.HasPrefix(source, pinSourcePrefix):
+		return "pin"
+	case strings.HasPrefix(source, branchRuleSourcePrefix):
+		return "branch_rule"
+	case source == "--fastest":
+		return "fastest"
+	case strings.HasPrefix(source, "settings.auto_select"):
+		return "auto_select"
+	case source == "only configured environment":
+		return "only"
+	case source == "headless_policy=first", source == "settings.default_env":
+		return "headless"
+	}
+	return "menu"
+}
+
+// recordLaunchMetrics counts a launch and how long choosing its environment took
+func recordLaunchMetrics(plan launchPlan, selection time.Duration) {
+	method := selectionMethod(plan.Source)
+	warnMetrics(updateMetrics(plan.Config, func(series map[string]float64) {
+		series[metricSeries("cde_launches_total", "environment", plan.Environment.Name, "mode", launchModeFor(plan.Config))]++
+		series[metricSeries("cde_selection_seconds_sum", "method", method)] += selection.Seconds()
+		series[metricSeries("cde_selection_seconds_count", "method", method)]++
+	}))
+}
+
+// recordRunMetrics records a subprocess-mode codex run
+func recordRunMetrics(config Config, env Environment, exitCode int, elapsed time.Duration) {
+	result := "success"
+	if exitCode != 0 {
+		result = "failure"
+	}
+	warnMetrics(updateMetrics(config, func(series map[string]float64) {
+		series[metricSeries("cde_codex_run_seconds_sum", "environment", env.Name)] += elapsed.Seconds()
+		series[metricSeries("cde_codex_run_seconds_count", "environment", env.Name)]++
+		series[metricSeries("cde_codex_exits_total", "environment", env.Name, "result", result)]++
+	}))
+}
+
+// recordFailureMetric counts a failed command by error category; it is silent when the
+// configuration cannot be read, since that failure is already being reported
+func recordFailureMetric(category string) {
+	config, err := loadConfig()
+	if err != nil {
+		return
+	}
+	warnMetrics(updateMetrics(config, func(series map[string]float64) {
+		series[metricSeries("cde_failures_total", "category", category)]++
+	}))
+}
+
+// metricsHandler serves the stored metrics, read fresh on every scrape
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, formatMetrics(loadMetrics()))
+}
+
+// runServeMetrics exposes the metrics on /metrics for Prometheus to scrape
+func runServeMetrics(listen string) error {
+	if listen == "" {
+		listen = defaultMetricsListen
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	if !metricsEnabled(config) {
+		fmt.Fprintln(os.Stderr, "Warning: settings.metrics.enabled is not set; no new launches will be recorded")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler)
+	fmt.Printf("Serving metrics on http://%s/metrics (Ctrl+C to stop)\n", listen)
+	if err := http.ListenAndServe(listen, mux); err != nil {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+	return nil
+}
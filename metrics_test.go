@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseServeMetricsArguments(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantListen string
+		wantErr    bool
+	}{
+		{name: "default", args: []string{"serve-metrics"}},
+		{name: "listen", args: []string{"serve-metrics", "--listen", ":9000"}, wantListen: ":9000"},
+		{name: "missing value", args: []string{"serve-metrics", "--listen"}, wantErr: true},
+		{name: "unknown flag", args: []string{"serve-metrics", "--port", "9000"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if (result.Error != nil) != tt.wantErr {
+				t.Fatalf("parseArguments() error = %v, wantErr %v", result.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Subcommand != "serve-metrics" || result.CCEFlags["listen"] != tt.wantListen {
+				t.Errorf("parseArguments() = %q listen %q, want serve-metrics listen %q", result.Subcommand, result.CCEFlags["listen"], tt.wantListen)
+			}
+		})
+	}
+}
+
+func TestSelectionMethod(t *testing.T) {
+	tests := map[string]string{
+		"--failover a,b":               "failover",
+		"--env flag":                   "env",
+		"CDE_ENV":                      "env_var",
+		"--fastest":                    "fastest",
+		"settings.auto_select=latency": "auto_select",
+		"only configured environment":  "only",
+		"interactive menu":             "menu",
+	}
+	for source, want := range tests {
+		if got := selectionMethod(source); got != want {
+			t.Errorf("selectionMethod(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestUpdateMetrics(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(dir, ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	// Disabled metrics record nothing
+	recordLaunchMetrics(launchPlan{Environment: Environment{Name: "dev"}, Source: "--env flag"}, time.Second)
+	if len(loadMetrics().Series) != 0 {
+		t.Fatal("metrics recorded while disabled")
+	}
+
+	textfile := filepath.Join(dir, "cde.prom")
+	config := Config{Settings: &ConfigSettings{Metrics: &MetricsSettings{Enabled: true, Textfile: textfile}}}
+	plan := launchPlan{Config: config, Environment: Environment{Name: `dev"1`}, Source: "--env flag"}
+	recordLaunchMetrics(plan, 250*time.Millisecond)
+	recordLaunchMetrics(plan, 250*time.Millisecond)
+	recordRunMetrics(config, plan.Environment, 1, 3*time.Second)
+
+	series := loadMetrics().Series
+	if got := series[`cde_launches_total{environment="dev\"1",mode="exec"}`]; got != 2 {
+		t.Errorf("launch counter = %v, want 2", got)
+	}
+	if got := series[`cde_selection_seconds_sum{method="env"}`]; got != 0.5 {
+		t.Errorf("selection sum = %v, want 0.5", got)
+	}
+	if got := series[`cde_codex_exits_total{environment="dev\"1",result="failure"}`]; got != 1 {
+		t.Errorf("exit counter = %v, want 1", got)
+	}
+
+	data, err := ioutil.ReadFile(textfile)
+	if err != nil {
+		t.Fatalf("textfile not written: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE cde_launches_total counter\n",
+		`cde_launches_total{environment="dev\"1",mode="exec"} 2` + "\n",
+		"# TYPE cde_selection_seconds summary\n",
+		`cde_selection_seconds_count{method="env"} 2` + "\n",
+		`cde_codex_run_seconds_sum{environment="dev\"1"} 3` + "\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("textfile missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	config := Config{Settings: &ConfigSettings{Metrics: &MetricsSettings{Enabled: true}}}
+	if err := updateMetrics(config, func(series map[string]float64) {
+		series[metricSeries("cde_failures_total", "category", "cde_config")]++
+	}); err != nil {
+		t.Fatalf("updateMetrics() error = %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	metricsHandler(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type = %q", recorder.Header().Get("Content-Type"))
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, `cde_failures_total{category="cde_config"} 1`) {
+		t.Errorf("handler output missing failure counter:\n%s", body)
+	}
+}
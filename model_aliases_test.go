@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyModelAliases(t *testing.T) {
+	env := Environment{
+		Name:         "prod",
+		URL:          "https://api.openai.com/v1",
+		APIKey:       "sk-test",
+		Model:        "smart",
+		ModelAliases: map[string]string{"fast": "gpt-5-mini", "smart": "gpt-5"},
+	}
+
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"alias after -m", []string{"-m", "fast", "exec"}, []string{"-m", "gpt-5-mini", "exec"}},
+		{"alias in --model=", []string{"--model=fast"}, []string{"--model=gpt-5-mini"}},
+		{"concrete model untouched", []string{"--model", "o3"}, []string{"--model", "o3"}},
+		{"env default alias injected", []string{"exec"}, []string{"-m", "gpt-5", "exec"}},
+		{"alias name as prompt untouched", []string{"exec", "fast"}, []string{"-m", "gpt-5", "exec", "fast"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prepareCodexArgs(env, tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prepareCodexArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, pair := range environmentExports(env) {
+		if pair.Key == "OPENAI_MODEL" && pair.Value != "gpt-5" {
+			t.Errorf("OPENAI_MODEL = %s, want alias resolved to gpt-5", pair.Value)
+		}
+	}
+}
+
+func TestModelAliasesWithDeploymentMap(t *testing.T) {
+	env := Environment{
+		Name:          "azure",
+		URL:           "https://res.openai.azure.com/openai",
+		APIKey:        "key",
+		Provider:      "azure",
+		ModelAliases:  map[string]string{"fast": "gpt-5-mini"},
+		DeploymentMap: map[string]string{"gpt-5-mini": "mini-deploy"},
+	}
+	got := prepareCodexArgs(env, []string{"-m", "fast"})
+	if want := []string{"-m", "mini-deploy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prepareCodexArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateModelAliases(t *testing.T) {
+	base := Environment{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-test"}
+
+	valid := base
+	valid.ModelAliases = map[string]string{"fast": "gpt-5-mini"}
+	if err := validateEnvironment(valid); err != nil {
+		t.Errorf("valid aliases rejected: %v", err)
+	}
+
+	empty := base
+	empty.ModelAliases = map[string]string{"fast": ""}
+	if err := validateEnvironment(empty); err == nil {
+		t.Error("alias with empty target should be rejected")
+	}
+
+	result := parseArguments([]string{"list", "--verbose"})
+	if result.Subcommand != "list" || result.CCEFlags["verbose"] != "true" {
+		t.Errorf("list --verbose parse = %+v", result)
+	}
+	if result := parseArguments([]string{"list", "--bogus"}); result.Error == nil {
+		t.Error("unknown list flag should be rejected")
+	}
+}
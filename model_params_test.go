@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyModelParams(t *testing.T) {
+	env := Environment{
+		Name:   "gateway",
+		URL:    "https://gateway.example.com/v1",
+		APIKey: "sk-test",
+		ModelParams: map[string]string{
+			"model_reasoning_effort":  "high",
+			"model_max_output_tokens": "4096",
+		},
+	}
+
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{
+			name: "params prepended in sorted order",
+			in:   []string{"exec"},
+			want: []string{"-c", "model_max_output_tokens=4096", "-c", "model_reasoning_effort=high", "exec"},
+		},
+		{
+			name: "user -c override wins",
+			in:   []string{"-c", "model_reasoning_effort=low"},
+			want: []string{"-c", "model_max_output_tokens=4096", "-c", "model_reasoning_effort=low"},
+		},
+		{
+			name: "user --config= override wins",
+			in:   []string{"--config=model_max_output_tokens=100"},
+			want: []string{"-c", "model_reasoning_effort=high", "--config=model_max_output_tokens=100"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyModelParams(env, tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyModelParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareCodexArgsWithModelParams(t *testing.T) {
+	env := Environment{
+		Name:        "gateway",
+		URL:         "https://gateway.example.com/v1",
+		APIKey:      "sk-test",
+		Model:       "gpt-5",
+		ModelParams: map[string]string{"model_reasoning_effort": "medium"},
+	}
+
+	got := prepareCodexArgs(env, []string{"proto"})
+	want := []string{"-c", "model_reasoning_effort=medium", "-m", "gpt-5", "proto"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("prepareCodexArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateModelParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]string
+		wantError bool
+	}{
+		{"nil params", nil, false},
+		{"dotted key", map[string]string{"tools.web_search": "true"}, false},
+		{"empty key", map[string]string{"": "x"}, true},
+		{"key with spaces", map[string]string{"bad key": "x"}, true},
+		{"key with equals", map[string]string{"a=b": "x"}, true},
+		{"control char value", map[string]string{"effort": "hi\nx"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateModelParams(tt.params)
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateModelParams() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// modelsRequestTimeout bounds a model list request against a provider
+const modelsRequestTimeout = 10 * time.Second
+
+// maxModelsResponseSize caps the model list body cde will read
+const maxModelsResponseSize = 4 << 20
+
+// errAuthRejected reports that the provider refused the environment's API key
+var errAuthRejected = errors.New("authentication rejected")
+
+// providerClient issues authenticated requests against an environment's API
+type providerClient struct {
+	env    Environment
+	client *http.Client
+}
+
+// newProviderClient returns a client for env; env.APIKey must already be resolved
+func newProviderClient(env Environment, timeout time.Duration) *providerClient {
+	return &providerClient{env: env, client: httpClientFor(env, timeout)}
+}
+
+// newRequest builds a request for path below the environment URL with provider-specific auth
+func (pc *providerClient) newRequest(ctx context.Context, method, path string) (*http.Request, error) {
+	endpoint, err := url.Parse(strings.TrimRight(pc.env.URL, "/") + "/" + strings.TrimLeft(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+
+	// Azure authenticates with an api-key header and versions every request
+	if isAzureEnvironment(pc.env) && pc.env.APIVersion != "" {
+		query := endpoint.Query()
+		query.Set("api-version", pc.env.APIVersion)
+		endpoint.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if isAzureEnvironment(pc.env) {
+		req.Header.Set("api-key", pc.env.APIKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+pc.env.APIKey)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "cde/"+version)
+	setRequestHeaders(req, pc.env)
+	return req, nil
+}
+
+// getJSON performs a GET against path and decodes the JSON response into out
+func (pc *providerClient) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := pc.newRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+
+	resp, err := pc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxModelsResponseSize))
+	if err != nil {
+		return fmt.Errorf("response read failed: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w (%s); check the environment's API key", errAuthRejected, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return nil
+}
+
+// fetchModels lists the model IDs served by the environment's /models endpoint, sorted
+func fetchModels(pc *providerClient) ([]string, error) {
+	if isOfflineMode() {
+		return nil, fmt.Errorf("model list skipped: offline mode (CDE_OFFLINE) is set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), modelsRequestTimeout)
+	defer cancel()
+
+	var listing struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := pc.getJSON(ctx, "models", &listing); err != nil {
+		return nil, fmt.Errorf("model list request failed: %w", err)
+	}
+
+	seen := make(map[string]bool, len(listing.Data))
+	models := make([]string, 0, len(listing.Data))
+	for _, entry := range listing.Data {
+		if entry.ID == "" || seen[entry.ID] {
+			continue
+		}
+		seen[entry.ID] = true
+		models = append(models, entry.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// modelsTarget picks the environment for 'models': the named one, the only one, or a menu choice
+func modelsTarget(config Config, envName string) (Environment, error) {
+	if envName != "" {
+		index, exists := findEnvironmentByName(config, envName)
+		if !exists {
+			return Environment{}, fmt.Errorf("environment '%s' not found", envName)
+		}
+		return config.Environments[index], nil
+	}
+	switch {
+	case len(config.Environments) == 0:
+		return Environment{}, fmt.Errorf("no environments configured - use 'add' command to create one")
+	case len(config.Environments) == 1:
+		return config.Environments[0], nil
+	case !isInteractiveInput():
+		return Environment{}, fmt.Errorf("several environments configured - choose one with -e <name>")
+	}
+	return selectEnvironment(config)
+}
+
+// printModelList shows numbered model IDs, marking the environment's current default
+func printModelList(env Environment, models []string) {
+	fmt.Printf("Models available in '%s' (%s):\n", env.Name, env.URL)
+	for i, model := range models {
+		marker := " "
+		if model == env.Model {
+			marker = "*"
+		}
+		fmt.Printf("  %s %3d. %s\n", marker, i+1, model)
+	}
+}
+
+// runModels lists the models an environment serves and lets the user launch with or save one
+func runModels(envName string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	env, err := modelsTarget(config, envName)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveEnvironmentSecrets(env)
+	if err != nil {
+		return fmt.Errorf("API key resolution failed: %w", err)
+	}
+
+	models, err := fetchModels(newProviderClient(resolved, modelsRequestTimeout))
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		fmt.Printf("Environment '%s' reported no models\n", env.Name)
+		return nil
+	}
+	printModelList(env, models)
+
+	// Without a terminal the list is the whole result, so scripts can consume it
+	if !isInteractiveInput() {
+		return nil
+	}
+
+	input, err := regularInput(trf("Select model (1-%d, Enter to quit): ", len(models)))
+	if err != nil {
+		return fmt.Errorf("model selection failed: %w", err)
+	}
+	if input == "" {
+		return nil
+	}
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > len(models) {
+		return fmt.Errorf("invalid selection - must be between 1 and %d", len(models))
+	}
+	model := models[choice-1]
+
+	action, err := regularInput(trf("%s: [l]aunch now, [s]ave as default for '%s', [c]ancel: ", model, env.Name))
+	if err != nil {
+		return fmt.Errorf("model selection failed: %w", err)
+	}
+	switch strings.ToLower(action) {
+	case "l", "launch":
+		return runDefaultWithOptions(env.Name, []string{"-m", model}, launchOptions{})
+	case "s", "save":
+		return saveDefaultModel(env.Name, model)
+	}
+	return nil
+}
+
+// saveDefaultModel persists model as the environment's default
+func saveDefaultModel(envName, model string) error {
+	if err := validateModel(model); err != nil {
+		return fmt.Errorf("model '%s' cannot be saved: %w", model, err)
+	}
+	err := updateConfig(func(config *Config) error {
+		index, exists := findEnvironmentByName(*config, envName)
+		if !exists {
+			return fmt.Errorf("environment '%s' not found", envName)
+		}
+		config.Environments[index].Model = model
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Default model for '%s' set to %s\n", envName, model)
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseModelsCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantEnv string
+		wantErr bool
+	}{
+		{name: "no flags", args: []string{"models"}},
+		{name: "short env flag", args: []string{"models", "-e", "dev"}, wantEnv: "dev"},
+		{name: "long env flag", args: []string{"models", "--env", "prod"}, wantEnv: "prod"},
+		{name: "missing value", args: []string{"models", "-e"}, wantErr: true},
+		{name: "unknown flag", args: []string{"models", "--all"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if (result.Error != nil) != tt.wantErr {
+				t.Fatalf("parseArguments() error = %v, wantErr %v", result.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				if result.Subcommand != "" {
+					t.Errorf("Subcommand = %q on error, want empty", result.Subcommand)
+				}
+				return
+			}
+			if result.Subcommand != "models" || result.CCEFlags["env"] != tt.wantEnv {
+				t.Errorf("unexpected parse result: %+v", result)
+			}
+		})
+	}
+}
+
+func TestFetchModels(t *testing.T) {
+	var gotAuth, gotAPIKey, gotPath, gotVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAPIKey = r.Header.Get("api-key")
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		if r.Header.Get("Authorization") == "Bearer sk-revoked-123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"o3"},{"id":"gpt-5"},{"id":"gpt-4o"},{"id":"o3"},{"id":""}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("CDE_OFFLINE", "")
+
+	t.Run("bearer auth and sorted ids", func(t *testing.T) {
+		env := Environment{Name: "dev", URL: server.URL + "/v1/", APIKey: "sk-dev-123456"}
+		models, err := fetchModels(newProviderClient(env, time.Second))
+		if err != nil {
+			t.Fatalf("fetchModels() error = %v", err)
+		}
+		if want := []string{"gpt-4o", "gpt-5", "o3"}; !reflect.DeepEqual(models, want) {
+			t.Errorf("models = %v, want %v", models, want)
+		}
+		if gotAuth != "Bearer sk-dev-123456" || gotPath != "/v1/models" {
+			t.Errorf("request auth = %q path = %q", gotAuth, gotPath)
+		}
+	})
+
+	t.Run("azure api-key header and version", func(t *testing.T) {
+		env := Environment{Name: "az", URL: server.URL + "/openai", APIKey: "az-key-123456", Provider: "azure", APIVersion: "2024-10-21"}
+		if _, err := fetchModels(newProviderClient(env, time.Second)); err != nil {
+			t.Fatalf("fetchModels() error = %v", err)
+		}
+		if gotAPIKey != "az-key-123456" || gotAuth != "" || gotVersion != "2024-10-21" {
+			t.Errorf("api-key = %q auth = %q api-version = %q", gotAPIKey, gotAuth, gotVersion)
+		}
+	})
+
+	t.Run("rejected key", func(t *testing.T) {
+		env := Environment{Name: "dev", URL: server.URL, APIKey: "sk-revoked-123456"}
+		_, err := fetchModels(newProviderClient(env, time.Second))
+		if err == nil || !strings.Contains(err.Error(), "authentication rejected") {
+			t.Fatalf("fetchModels() error = %v, want authentication rejected", err)
+		}
+		if errorCodeOf(err) != codeNetwork {
+			t.Errorf("errorCodeOf() = %s, want %s", errorCodeOf(err), codeNetwork)
+		}
+	})
+
+	t.Run("offline mode", func(t *testing.T) {
+		t.Setenv("CDE_OFFLINE", "1")
+		env := Environment{Name: "dev", URL: server.URL, APIKey: "sk-dev-123456"}
+		if _, err := fetchModels(newProviderClient(env, time.Second)); err == nil || errorCodeOf(err) != codeOffline {
+			t.Errorf("fetchModels() error = %v, want offline error", err)
+		}
+	})
+}
+
+func TestFetchModelsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	t.Setenv("CDE_OFFLINE", "")
+	env := Environment{Name: "slow", URL: server.URL, APIKey: "sk-slow-123456"}
+	if _, err := fetchModels(newProviderClient(env, 50*time.Millisecond)); err == nil {
+		t.Fatal("fetchModels() succeeded against a hung server, want timeout")
+	}
+}
+
+func TestSaveDefaultModel(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	if err := saveConfig(Config{Environments: []Environment{
+		{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456", Model: "gpt-4o"},
+	}}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := saveDefaultModel("dev", "gpt-5"); err != nil {
+		t.Fatalf("saveDefaultModel() error = %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if config.Environments[0].Model != "gpt-5" {
+		t.Errorf("Model = %q, want gpt-5", config.Environments[0].Model)
+	}
+
+	if err := saveDefaultModel("missing", "gpt-5"); err == nil {
+		t.Error("saveDefaultModel() on unknown environment succeeded")
+	}
+}
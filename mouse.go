@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// xterm mouse reporting: button events (1000) in SGR encoding (1006), which has no column limit
+const (
+	mouseEnable  = "\033[?1000h\033[?1006h"
+	mouseDisable = "\033[?1006l\033[?1000l"
+)
+
+// SGR mouse button codes
+const (
+	mouseLeft      = 0
+	mouseWheelUp   = 64
+	mouseWheelDown = 65
+)
+
+// mouseActive is set while mouse reporting is enabled
+var mouseActive bool
+
+// mouseEvent is one decoded SGR mouse report
+type mouseEvent struct {
+	Button  int
+	Column  int // 1-based
+	Row     int // 1-based
+	Release bool
+}
+
+// mouseEnabled reports whether the selector should turn on mouse reporting: it is opt-in
+// through settings.terminal.mouse and skipped on terminals known not to report the mouse
+func mouseEnabled(config Config) bool {
+	if config.Settings == nil || config.Settings.Terminal == nil {
+		return false
+	}
+	terminal := config.Settings.Terminal
+	if !terminal.Mouse || terminal.DisableANSI {
+		return false
+	}
+	termType := os.Getenv("TERM")
+	return termType != "linux" && !strings.HasPrefix(termType, "vt") && platformSupportsANSI()
+}
+
+// enableMouse turns on mouse reporting
+func enableMouse() {
+	fmt.Fprint(uiTerminal, mouseEnable)
+	mouseActive = true
+}
+
+// disableMouse turns mouse reporting off if enableMouse turned it on
+func disableMouse() {
+	if mouseActive {
+		fmt.Fprint(uiTerminal, mouseDisable)
+		mouseActive = false
+	}
+}
+
+// parseMouseEvent decodes an SGR mouse report (ESC [ < b ; x ; y M or m)
+func parseMouseEvent(input []byte) (mouseEvent, bool) {
+	if len(input) < 9 || input[0] != '\x1b' || input[1] != '[' || input[2] != '<' {
+		return mouseEvent{}, false
+	}
+	final := input[len(input)-1]
+	if final != 'M' && final != 'm' {
+		return mouseEvent{}, false
+	}
+	fields := strings.Split(string(input[3:len(input)-1]), ";")
+	if len(fields) != 3 {
+		return mouseEvent{}, false
+	}
+	values := make([]int, 3)
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil || value < 0 {
+			return mouseEvent{}, false
+		}
+		values[i] = value
+	}
+	return mouseEvent{Button: values[0], Column: values[1], Row: values[2], Release: final == 'm'}, true
+}
+
+// menuRowToIndex maps a screen row to the environment drawn there. The renderer leaves the
+// cursor on the last screen row, so the menu occupies the bottom rows of the terminal.
+func menuRowToIndex(state *DisplayState, row, count int) (int, bool) {
+	if state == nil || len(state.currentLines) == 0 {
+		return 0, false
+	}
+	line := len(state.currentLines) - 1 - (state.terminalHeight - row)
+	index := line - (len(state.currentLines) - count) // skip the header
+	if index < 0 || index >= count {
+		return 0, false
+	}
+	return index, true
+}
+
+// applyMouseEvent updates the selection for a mouse event: the wheel moves it, a click
+// selects the row under the pointer and a click on the selected row confirms it
+func applyMouseEvent(state *DisplayState, event mouseEvent, index, count int) (next int, confirm bool) {
+	if event.Release {
+		return index, false
+	}
+	switch event.Button {
+	case mouseWheelUp:
+		if index > 0 {
+			index--
+		}
+	case mouseWheelDown:
+		if index < count-1 {
+			index++
+		}
+	case mouseLeft:
+		if clicked, ok := menuRowToIndex(state, event.Row, count); ok {
+			return clicked, clicked == index
+		}
+	}
+	return index, false
+}
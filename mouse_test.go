@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestParseMouseEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   mouseEvent
+		wantOK bool
+	}{
+		{name: "left press", input: "\x1b[<0;12;24M", want: mouseEvent{Button: 0, Column: 12, Row: 24}, wantOK: true},
+		{name: "left release", input: "\x1b[<0;12;24m", want: mouseEvent{Button: 0, Column: 12, Row: 24, Release: true}, wantOK: true},
+		{name: "wheel down wide terminal", input: "\x1b[<65;300;5M", want: mouseEvent{Button: 65, Column: 300, Row: 5}, wantOK: true},
+		{name: "arrow key", input: "\x1b[A"},
+		{name: "missing field", input: "\x1b[<0;12M"},
+		{name: "not a number", input: "\x1b[<0;x;24M"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseMouseEvent([]byte(tt.input))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseMouseEvent(%q) = %+v, %t, want %+v, %t", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyMouseEvent(t *testing.T) {
+	// Header plus three environments drawn on the bottom four rows of a 24-row terminal
+	state := &DisplayState{terminalHeight: 24, currentLines: []string{"header", "a", "b", "c"}, initialized: true}
+
+	tests := []struct {
+		name        string
+		event       mouseEvent
+		index       int
+		wantIndex   int
+		wantConfirm bool
+	}{
+		{name: "click other row", event: mouseEvent{Button: mouseLeft, Row: 24}, index: 0, wantIndex: 2},
+		{name: "click selected row confirms", event: mouseEvent{Button: mouseLeft, Row: 22}, index: 0, wantIndex: 0, wantConfirm: true},
+		{name: "click header ignored", event: mouseEvent{Button: mouseLeft, Row: 21}, index: 1, wantIndex: 1},
+		{name: "click above menu ignored", event: mouseEvent{Button: mouseLeft, Row: 3}, index: 1, wantIndex: 1},
+		{name: "release ignored", event: mouseEvent{Button: mouseLeft, Row: 24, Release: true}, index: 0, wantIndex: 0},
+		{name: "wheel down", event: mouseEvent{Button: mouseWheelDown}, index: 1, wantIndex: 2},
+		{name: "wheel down at end", event: mouseEvent{Button: mouseWheelDown}, index: 2, wantIndex: 2},
+		{name: "wheel up", event: mouseEvent{Button: mouseWheelUp}, index: 1, wantIndex: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, confirm := applyMouseEvent(state, tt.event, tt.index, 3)
+			if index != tt.wantIndex || confirm != tt.wantConfirm {
+				t.Errorf("applyMouseEvent() = %d, %t, want %d, %t", index, confirm, tt.wantIndex, tt.wantConfirm)
+			}
+		})
+	}
+}
+
+func TestMouseEnabled(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	if mouseEnabled(Config{}) {
+		t.Error("mouse reporting should be opt-in")
+	}
+	config := Config{Settings: &ConfigSettings{Terminal: &TerminalSettings{Mouse: true}}}
+	if platformSupportsANSI() && !mouseEnabled(config) {
+		t.Error("mouse reporting should be enabled when requested on an xterm")
+	}
+	t.Setenv("TERM", "linux")
+	if mouseEnabled(config) {
+		t.Error("mouse reporting should stay off on the Linux console")
+	}
+}
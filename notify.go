@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// notifyTimeout bounds how long a notification command may take
+const notifyTimeout = 5 * time.Second
+
+// NotifySettings configures desktop notifications for codex sessions (subprocess launch mode only)
+type NotifySettings struct {
+	Enabled            bool `json:"enabled,omitempty"`
+	MinDurationSeconds int  `json:"min_duration_seconds,omitempty"` // Only notify on exit after sessions at least this long (default 0)
+	LongRunningSeconds int  `json:"long_running_seconds,omitempty"` // Also notify once while codex is still running after this long (0 = off)
+}
+
+// sendNotification shows a desktop notification; tests replace it
+var sendNotification = sendDesktopNotification
+
+// activeNotify returns the notification settings when enabled, or nil
+func activeNotify(config Config) *NotifySettings {
+	if config.Settings == nil || config.Settings.Notify == nil || !config.Settings.Notify.Enabled {
+		return nil
+	}
+	return config.Settings.Notify
+}
+
+// validateNotifySettings checks the durations are not negative
+func validateNotifySettings(notify *NotifySettings) error {
+	if notify == nil {
+		return nil
+	}
+	if notify.MinDurationSeconds < 0 || notify.LongRunningSeconds < 0 {
+		return fmt.Errorf("min_duration_seconds and long_running_seconds must not be negative")
+	}
+	return nil
+}
+
+// notificationCommand returns the platform command for a notification. Title and body travel in
+// CDE_NOTIFY_TITLE/CDE_NOTIFY_BODY so they never need shell or script quoting.
+func notificationCommand(ctx context.Context) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.CommandContext(ctx, "osascript", "-e",
+			`display notification (system attribute "CDE_NOTIFY_BODY") with title (system attribute "CDE_NOTIFY_TITLE")`), nil
+	case "windows":
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:CDE_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:CDE_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('cde').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+		return exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+	}
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return nil, fmt.Errorf("notify-send not found")
+	}
+	return exec.CommandContext(ctx, "sh", "-c", `notify-send "$CDE_NOTIFY_TITLE" "$CDE_NOTIFY_BODY"`), nil
+}
+
+// sendDesktopNotification shows a notification with osascript, notify-send or a Windows toast
+func sendDesktopNotification(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	cmd, err := notificationCommand(ctx)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), "CDE_NOTIFY_TITLE="+title, "CDE_NOTIFY_BODY="+body)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notification failed: %w (%s)", err, output)
+	}
+	return nil
+}
+
+// startLongRunningNotifier notifies once if codex is still running after long_running_seconds.
+// Failures are only logged because codex owns the terminal. The returned func stops the timer.
+func startLongRunningNotifier(config Config, env Environment) func() {
+	notify := activeNotify(config)
+	if notify == nil || notify.LongRunningSeconds <= 0 {
+		return func() {}
+	}
+	limit := time.Duration(notify.LongRunningSeconds) * time.Second
+	timer := time.AfterFunc(limit, func() {
+		body := fmt.Sprintf("Environment '%s' has been running for %s", env.Name, limit)
+		if err := sendNotification("codex is still running", body); err != nil {
+			logDebugf("long-running notification: %v", err)
+		}
+	})
+	return func() { timer.Stop() }
+}
+
+// notifySessionEnd notifies that codex exited, unless the session was shorter than
+// min_duration_seconds; a failure only warns
+func notifySessionEnd(config Config, env Environment, exitCode int, elapsed time.Duration) {
+	notify := activeNotify(config)
+	if notify == nil || elapsed < time.Duration(notify.MinDurationSeconds)*time.Second {
+		return
+	}
+	title := "codex finished"
+	if exitCode != 0 {
+		title = fmt.Sprintf("codex failed (status %d)", exitCode)
+	}
+	body := fmt.Sprintf("Environment '%s' ran for %s", env.Name, elapsed.Round(time.Second))
+	if err := sendNotification(title, body); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// warnSkippedNotifications explains that notifications need subprocess launch mode
+func warnSkippedNotifications(config Config) {
+	if activeNotify(config) != nil {
+		fmt.Fprintf(os.Stderr, "Warning: notifications need \"launch_mode\": \"subprocess\"; none will be shown\n")
+	}
+}
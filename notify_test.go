@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordNotifications replaces sendNotification with one that collects titles
+func recordNotifications(t *testing.T) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var titles []string
+	original := sendNotification
+	sendNotification = func(title, body string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		titles = append(titles, title)
+		return nil
+	}
+	t.Cleanup(func() { sendNotification = original })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, titles...)
+	}
+}
+
+func TestNotifySessionEnd(t *testing.T) {
+	env := Environment{Name: "prod"}
+	tests := []struct {
+		name     string
+		notify   *NotifySettings
+		exitCode int
+		elapsed  time.Duration
+		want     []string
+	}{
+		{name: "disabled", notify: &NotifySettings{}, elapsed: time.Hour},
+		{name: "success", notify: &NotifySettings{Enabled: true}, elapsed: time.Second, want: []string{"codex finished"}},
+		{name: "failure", notify: &NotifySettings{Enabled: true}, exitCode: 2, elapsed: time.Second, want: []string{"codex failed (status 2)"}},
+		{name: "shorter than minimum", notify: &NotifySettings{Enabled: true, MinDurationSeconds: 60}, elapsed: 59 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := recordNotifications(t)
+			notifySessionEnd(Config{Settings: &ConfigSettings{Notify: tt.notify}}, env, tt.exitCode, tt.elapsed)
+			if got := sent(); len(got) != len(tt.want) || len(got) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notifications = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := validateNotifySettings(&NotifySettings{LongRunningSeconds: -1}); err == nil {
+		t.Error("expected an error for a negative duration")
+	}
+}
+
+func TestSubprocessLaunchNotifies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte("#!/bin/sh\nsleep 1.3\nexit 3\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	config := Config{
+		Environments: []Environment{{Name: "long", URL: "https://api.openai.com/v1", APIKey: "sk-long-test"}},
+		Settings: &ConfigSettings{
+			LaunchMode: launchModeSubprocess,
+			Notify:     &NotifySettings{Enabled: true, LongRunningSeconds: 1},
+		},
+	}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	sent := recordNotifications(t)
+
+	err := runDefaultWithOptions("long", []string{"exec", "hi"}, launchOptions{})
+	var childExit *childExitError
+	if !errors.As(err, &childExit) || childExit.Code != 3 {
+		t.Fatalf("expected codex exit status 3, got %v", err)
+	}
+	want := []string{"codex is still running", "codex failed (status 3)"}
+	if got := sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("notifications = %v, want %v", got, want)
+	}
+}
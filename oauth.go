@@ -0,0 +1,397 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Auth types and OAuth flows accepted in an environment's "auth" block
+const (
+	authTypeOAuth              = "oauth"
+	oauthFlowClientCredentials = "client_credentials"
+	oauthFlowDeviceCode        = "device_code"
+)
+
+const (
+	oauthRequestTimeout    = 30 * time.Second
+	oauthExpiryMargin      = time.Minute      // Refresh tokens this close to expiry
+	oauthDefaultLifetime   = 5 * time.Minute  // Assumed when a token response has no expires_in
+	oauthDefaultDeviceWait = 10 * time.Minute // Device code lifetime when the server omits expires_in
+)
+
+// oauthSleep waits between device code polls; tests replace it
+var oauthSleep = time.Sleep
+
+// AuthSettings replaces a stored API key: "oauth" obtains a token from an OAuth endpoint,
+// "aws" resolves AWS credentials for a SigV4-signing endpoint, "gcp" asks gcloud for a token
+type AuthSettings struct {
+	Type                string   `json:"type"`           // "oauth", "aws" or "gcp"
+	Flow                string   `json:"flow,omitempty"` // "client_credentials" (default) or "device_code"
+	TokenURL            string   `json:"token_url,omitempty"`
+	DeviceAuthorization string   `json:"device_authorization_url,omitempty"` // Required for device_code
+	ClientID            string   `json:"client_id,omitempty"`
+	ClientSecret        string   `json:"client_secret,omitempty"` // Literal, op:// or vault:// reference
+	Scopes              []string `json:"scopes,omitempty"`
+	Audience            string   `json:"audience,omitempty"`
+
+	// aws: named profile, region and optional role to assume
+	Profile string `json:"profile,omitempty"`
+	Region  string `json:"region,omitempty"`
+	RoleARN string `json:"role_arn,omitempty"`
+
+	// gcp: project and location exported to codex, gcloud account, or application default credentials
+	Project  string `json:"project,omitempty"`
+	Location string `json:"location,omitempty"`
+	Account  string `json:"account,omitempty"`
+	ADC      bool   `json:"adc,omitempty"`
+}
+
+// oauthToken is a cached access token
+type oauthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// tokenCache is the on-disk token store, keyed by tokenCacheKey
+type tokenCache struct {
+	Tokens map[string]oauthToken `json:"tokens"`
+}
+
+// oauthTokenResponse covers token and device authorization responses (RFC 6749, RFC 8628)
+type oauthTokenResponse struct {
+	AccessToken             string      `json:"access_token"`
+	RefreshToken            string      `json:"refresh_token"`
+	ExpiresIn               json.Number `json:"expires_in"`
+	DeviceCode              string      `json:"device_code"`
+	UserCode                string      `json:"user_code"`
+	VerificationURI         string      `json:"verification_uri"`
+	VerificationURIComplete string      `json:"verification_uri_complete"`
+	Interval                json.Number `json:"interval"`
+	Error                   string      `json:"error"`
+	ErrorDescription        string      `json:"error_description"`
+}
+
+// validateOAuthEndpoint requires https, except for local test servers
+func validateOAuthEndpoint(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid URL '%s'", rawURL)
+	}
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && isLoopbackHost(parsed.Hostname())) {
+		return fmt.Errorf("'%s' must use https", rawURL)
+	}
+	return nil
+}
+
+// validateAuthSettings checks the auth type and the fields it requires
+func validateAuthSettings(auth *AuthSettings) error {
+	if auth == nil {
+		return nil
+	}
+	switch auth.Type {
+	case authTypeOAuth:
+		return validateOAuthSettings(auth)
+	case authTypeAWS:
+		return validateAWSAuth(auth)
+	case authTypeGCP:
+		return validateGCPAuth(auth)
+	}
+	return fmt.Errorf("unknown auth type '%s' (use %s, %s or %s)", auth.Type, authTypeOAuth, authTypeAWS, authTypeGCP)
+}
+
+// validateOAuthSettings checks the flow and required fields of an oauth auth block
+func validateOAuthSettings(auth *AuthSettings) error {
+	if auth.ClientID == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	if err := validateOAuthEndpoint(auth.TokenURL); err != nil {
+		return fmt.Errorf("token_url: %w", err)
+	}
+	if err := validateAPIKey(auth.ClientSecret); err != nil {
+		return fmt.Errorf("client_secret: %w", err)
+	}
+	switch auth.Flow {
+	case "", oauthFlowClientCredentials:
+		if auth.ClientSecret == "" {
+			return fmt.Errorf("client_secret is required for the client_credentials flow")
+		}
+	case oauthFlowDeviceCode:
+		if err := validateOAuthEndpoint(auth.DeviceAuthorization); err != nil {
+			return fmt.Errorf("device_authorization_url: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown OAuth flow '%s' (use %s or %s)", auth.Flow, oauthFlowClientCredentials, oauthFlowDeviceCode)
+	}
+	return nil
+}
+
+// getTokenCachePath returns the active profile's token cache file
+func getTokenCachePath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "tokens.json"), nil
+}
+
+// loadTokenCache reads tokens.json; a missing or unreadable file is an empty cache
+func loadTokenCache() tokenCache {
+	cache := tokenCache{Tokens: map[string]oauthToken{}}
+	path, err := getTokenCachePath()
+	if err != nil {
+		return cache
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	if cache.Tokens == nil {
+		cache.Tokens = map[string]oauthToken{}
+	}
+	return cache
+}
+
+// saveCachedToken stores token under key, dropping expired tokens that cannot be refreshed
+func saveCachedToken(key string, token oauthToken, now time.Time) error {
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	cache := loadTokenCache()
+	for cachedKey, cached := range cache.Tokens {
+		if cached.RefreshToken == "" && now.After(cached.ExpiresAt) {
+			delete(cache.Tokens, cachedKey)
+		}
+	}
+	cache.Tokens[key] = token
+
+	path, err := getTokenCachePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("token cache serialization failed: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("token cache write failed: %w", err)
+	}
+	return nil
+}
+
+// tokenCacheKey identifies a token by environment and the settings that issued it, so
+// editing the auth block invalidates the cached token
+func tokenCacheKey(env Environment) string {
+	auth := env.Auth
+	sum := sha256.Sum256([]byte(strings.Join([]string{auth.TokenURL, auth.ClientID, strings.Join(auth.Scopes, " "), auth.Audience, auth.Flow}, "\n")))
+	return env.Name + ":" + hex.EncodeToString(sum[:])[:12]
+}
+
+// usable reports whether the token can be used at now without refreshing
+func (t oauthToken) usable(now time.Time) bool {
+	return t.AccessToken != "" && now.Add(oauthExpiryMargin).Before(t.ExpiresAt)
+}
+
+// postOAuthForm posts form to endpoint and decodes the JSON reply, including OAuth error replies
+func postOAuthForm(client *http.Client, endpoint string, form url.Values) (oauthTokenResponse, error) {
+	var reply oauthTokenResponse
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return reply, fmt.Errorf("OAuth request creation failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "cde/"+version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return reply, fmt.Errorf("OAuth request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return reply, fmt.Errorf("OAuth response read failed: %w", err)
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return reply, fmt.Errorf("OAuth response parse failed (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if reply.Error == "" && resp.StatusCode >= 300 {
+		reply.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return reply, nil
+}
+
+// oauthReplyError formats an OAuth error reply
+func oauthReplyError(reply oauthTokenResponse) error {
+	if reply.ErrorDescription != "" {
+		return fmt.Errorf("%s: %s", reply.Error, reply.ErrorDescription)
+	}
+	return fmt.Errorf("%s", reply.Error)
+}
+
+// tokenFromReply turns a successful token reply into a cacheable token
+func tokenFromReply(reply oauthTokenResponse, now time.Time) (oauthToken, error) {
+	if reply.Error != "" {
+		return oauthToken{}, oauthReplyError(reply)
+	}
+	if reply.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("token response has no access_token")
+	}
+	lifetime := oauthDefaultLifetime
+	if seconds, err := reply.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		lifetime = time.Duration(seconds) * time.Second
+	}
+	return oauthToken{AccessToken: reply.AccessToken, RefreshToken: reply.RefreshToken, ExpiresAt: now.Add(lifetime)}, nil
+}
+
+// oauthScopeForm returns the scope and audience parameters shared by all requests
+func oauthScopeForm(auth *AuthSettings) url.Values {
+	form := url.Values{"client_id": {auth.ClientID}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	if auth.Audience != "" {
+		form.Set("audience", auth.Audience)
+	}
+	return form
+}
+
+// requestClientCredentialsToken runs the client credentials grant
+func requestClientCredentialsToken(client *http.Client, auth *AuthSettings, secret string, now time.Time) (oauthToken, error) {
+	form := oauthScopeForm(auth)
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_secret", secret)
+	reply, err := postOAuthForm(client, auth.TokenURL, form)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	return tokenFromReply(reply, now)
+}
+
+// refreshOAuthToken exchanges a refresh token for a new access token
+func refreshOAuthToken(client *http.Client, auth *AuthSettings, secret, refreshToken string, now time.Time) (oauthToken, error) {
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}, "client_id": {auth.ClientID}}
+	if secret != "" {
+		form.Set("client_secret", secret)
+	}
+	reply, err := postOAuthForm(client, auth.TokenURL, form)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	token, err := tokenFromReply(reply, now)
+	if err == nil && token.RefreshToken == "" {
+		// Servers without refresh token rotation keep the old one valid
+		token.RefreshToken = refreshToken
+	}
+	return token, err
+}
+
+// requestDeviceCodeToken runs the device authorization grant: it prints the verification
+// URL and user code, then polls the token endpoint until the user signs in
+func requestDeviceCodeToken(client *http.Client, auth *AuthSettings, envName string) (oauthToken, error) {
+	reply, err := postOAuthForm(client, auth.DeviceAuthorization, oauthScopeForm(auth))
+	if err != nil {
+		return oauthToken{}, err
+	}
+	if reply.Error != "" {
+		return oauthToken{}, oauthReplyError(reply)
+	}
+	if reply.DeviceCode == "" || reply.VerificationURI == "" {
+		return oauthToken{}, fmt.Errorf("device authorization response is missing device_code or verification_uri")
+	}
+
+	if reply.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "To sign in for '%s', open %s (code %s)\n", envName, reply.VerificationURIComplete, reply.UserCode)
+	} else {
+		fmt.Fprintf(os.Stderr, "To sign in for '%s', open %s and enter code %s\n", envName, reply.VerificationURI, reply.UserCode)
+	}
+
+	interval := 5 * time.Second
+	if seconds, err := reply.Interval.Int64(); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+	wait := oauthDefaultDeviceWait
+	if seconds, err := reply.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {reply.DeviceCode},
+		"client_id":   {auth.ClientID},
+	}
+	for waited := time.Duration(0); waited < wait; waited += interval {
+		oauthSleep(interval)
+		poll, err := postOAuthForm(client, auth.TokenURL, form)
+		if err != nil {
+			return oauthToken{}, err
+		}
+		switch poll.Error {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		}
+		return tokenFromReply(poll, time.Now())
+	}
+	return oauthToken{}, fmt.Errorf("device code expired before sign-in completed")
+}
+
+// acquireAuthToken returns an access token for env, from the cache when still valid,
+// otherwise by refreshing or running the configured flow
+func acquireAuthToken(env Environment) (string, error) {
+	auth := env.Auth
+	now := time.Now()
+	key := tokenCacheKey(env)
+	cached := loadTokenCache().Tokens[key]
+	if cached.usable(now) {
+		logDebugf("using cached OAuth token for '%s' (expires %s)", env.Name, cached.ExpiresAt.Format(time.RFC3339))
+		return cached.AccessToken, nil
+	}
+	if isOfflineMode() {
+		return "", fmt.Errorf("OAuth token request skipped: offline mode (CDE_OFFLINE) is set")
+	}
+
+	secret, err := resolveSecret(auth.ClientSecret)
+	if err != nil {
+		return "", err
+	}
+	client := httpClientFor(env, oauthRequestTimeout)
+
+	var token oauthToken
+	if cached.RefreshToken != "" {
+		logDebugf("refreshing OAuth token for '%s'", env.Name)
+		token, err = refreshOAuthToken(client, auth, secret, cached.RefreshToken, now)
+		if err != nil {
+			logInfof("OAuth refresh for '%s' failed, requesting a new token: %v", env.Name, err)
+		}
+	}
+	if token.AccessToken == "" {
+		if auth.Flow == oauthFlowDeviceCode {
+			token, err = requestDeviceCodeToken(client, auth, env.Name)
+		} else {
+			token, err = requestClientCredentialsToken(client, auth, secret, now)
+		}
+		if err != nil {
+			return "", fmt.Errorf("OAuth token request failed: %w", err)
+		}
+	}
+
+	if err := saveCachedToken(key, token, now); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: OAuth token not cached: %v\n", err)
+	}
+	return token.AccessToken, nil
+}
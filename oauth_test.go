@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidateAuthSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    *AuthSettings
+		wantErr bool
+	}{
+		{name: "none", auth: nil},
+		{name: "client credentials", auth: &AuthSettings{Type: "oauth", TokenURL: "https://sso.example.com/token", ClientID: "cde", ClientSecret: "s3cret"}},
+		{name: "device code", auth: &AuthSettings{Type: "oauth", Flow: "device_code", TokenURL: "https://sso.example.com/token", DeviceAuthorization: "https://sso.example.com/device", ClientID: "cde"}},
+		{name: "loopback http", auth: &AuthSettings{Type: "oauth", TokenURL: "http://127.0.0.1:8080/token", ClientID: "cde", ClientSecret: "s3cret"}},
+		{name: "unknown type", auth: &AuthSettings{Type: "saml", TokenURL: "https://sso.example.com/token", ClientID: "cde", ClientSecret: "s3cret"}, wantErr: true},
+		{name: "plain http", auth: &AuthSettings{Type: "oauth", TokenURL: "http://sso.example.com/token", ClientID: "cde", ClientSecret: "s3cret"}, wantErr: true},
+		{name: "missing secret", auth: &AuthSettings{Type: "oauth", TokenURL: "https://sso.example.com/token", ClientID: "cde"}, wantErr: true},
+		{name: "missing client id", auth: &AuthSettings{Type: "oauth", TokenURL: "https://sso.example.com/token", ClientSecret: "s3cret"}, wantErr: true},
+		{name: "device code without endpoint", auth: &AuthSettings{Type: "oauth", Flow: "device_code", TokenURL: "https://sso.example.com/token", ClientID: "cde"}, wantErr: true},
+		{name: "unknown flow", auth: &AuthSettings{Type: "oauth", Flow: "password", TokenURL: "https://sso.example.com/token", ClientID: "cde", ClientSecret: "s3cret"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAuthSettings(tt.auth); (err != nil) != tt.wantErr {
+				t.Errorf("validateAuthSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAcquireAuthTokenClientCredentials(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+	t.Setenv("CDE_OFFLINE", "")
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "cde" ||
+			r.Form.Get("client_secret") != "s3cret" || r.Form.Get("scope") != "llm.invoke admin" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok-1", "token_type": "Bearer", "expires_in": 3600})
+	}))
+	defer server.Close()
+
+	env := Environment{Name: "gateway", URL: "https://llm.example.com", Auth: &AuthSettings{
+		Type: "oauth", TokenURL: server.URL, ClientID: "cde", ClientSecret: "s3cret", Scopes: []string{"llm.invoke", "admin"},
+	}}
+	for i := 0; i < 2; i++ {
+		resolved, err := resolveEnvironmentSecrets(env)
+		if err != nil {
+			t.Fatalf("resolveEnvironmentSecrets() error = %v", err)
+		}
+		if resolved.APIKey != "tok-1" {
+			t.Errorf("APIKey = %q, want tok-1", resolved.APIKey)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("token endpoint called %d times, want 1 (second launch should use the cache)", got)
+	}
+
+	// Changing the auth block invalidates the cached token
+	env.Auth.Scopes = []string{"llm.invoke"}
+	_, err := resolveEnvironmentSecrets(env)
+	if err == nil || errorCodeOf(err) != codeAuthFailed {
+		t.Errorf("error = %v, want %s", err, codeAuthFailed)
+	}
+}
+
+func TestAcquireAuthTokenRefresh(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+	t.Setenv("CDE_OFFLINE", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok-refreshed", "expires_in": "600"})
+	}))
+	defer server.Close()
+
+	env := Environment{Name: "gateway", Auth: &AuthSettings{
+		Type: "oauth", Flow: "device_code", TokenURL: server.URL, DeviceAuthorization: server.URL + "/device", ClientID: "cde",
+	}}
+	expired := oauthToken{AccessToken: "tok-old", RefreshToken: "refresh-1", ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := saveCachedToken(tokenCacheKey(env), expired, time.Now()); err != nil {
+		t.Fatalf("saveCachedToken() error = %v", err)
+	}
+
+	token, err := acquireAuthToken(env)
+	if err != nil {
+		t.Fatalf("acquireAuthToken() error = %v", err)
+	}
+	if token != "tok-refreshed" {
+		t.Errorf("token = %q, want tok-refreshed", token)
+	}
+	cached := loadTokenCache().Tokens[tokenCacheKey(env)]
+	if cached.RefreshToken != "refresh-1" || !cached.usable(time.Now()) {
+		t.Errorf("cached token = %+v, want usable with the previous refresh token", cached)
+	}
+}
+
+func TestRequestDeviceCodeToken(t *testing.T) {
+	oldSleep := oauthSleep
+	oauthSleep = func(time.Duration) {}
+	defer func() { oauthSleep = oldSleep }()
+
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code": "dev-123", "user_code": "ABCD-EFGH", "verification_uri": "https://sso.example.com/activate",
+			"interval": 1, "expires_in": 60,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("device_code") != "dev-123" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		switch atomic.AddInt32(&polls, 1) {
+		case 1:
+			json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+		case 2:
+			json.NewEncoder(w).Encode(map[string]string{"error": "slow_down"})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok-user", "refresh_token": "refresh-user", "expires_in": 900})
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	auth := &AuthSettings{Type: "oauth", Flow: "device_code", TokenURL: server.URL + "/token", DeviceAuthorization: server.URL + "/device", ClientID: "cde"}
+	token, err := requestDeviceCodeToken(server.Client(), auth, "gateway")
+	if err != nil {
+		t.Fatalf("requestDeviceCodeToken() error = %v", err)
+	}
+	if token.AccessToken != "tok-user" || token.RefreshToken != "refresh-user" {
+		t.Errorf("token = %+v", token)
+	}
+	if got := atomic.LoadInt32(&polls); got != 3 {
+		t.Errorf("polled %d times, want 3", got)
+	}
+}
+
+func TestEqualAuthSettings(t *testing.T) {
+	a := Environment{Name: "gw", Auth: &AuthSettings{Type: "oauth", ClientID: "cde", Scopes: []string{"x"}}}
+	clone := cloneEnvironment(a, "gw")
+	if !equalEnvironments(a, clone) {
+		t.Error("clone should equal the original")
+	}
+	clone.Auth.Scopes[0] = "y"
+	if a.Auth.Scopes[0] != "x" {
+		t.Error("cloneEnvironment shares the scopes slice")
+	}
+	if equalEnvironments(a, clone) {
+		t.Error("environments with different scopes compare equal")
+	}
+}
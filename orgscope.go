@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// scopeIDPattern matches organization and project IDs such as org-AbC123 or proj_AbC123
+var scopeIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,127}$`)
+
+// validateScopeID checks an optional organization or project ID
+func validateScopeID(id string) error {
+	if id == "" {
+		return nil
+	}
+	if !scopeIDPattern.MatchString(id) {
+		return fmt.Errorf("'%s' must be up to 128 letters, digits, '_', '-', '.' or ':'", id)
+	}
+	return nil
+}
+
+// scopingEnvVars returns the organization and project variables for the codex child. Codex
+// reads OPENAI_ORGANIZATION and OPENAI_PROJECT; the OpenAI SDKs read the _ID forms.
+func scopingEnvVars(env Environment) map[string]string {
+	if env.OrgID == "" && env.ProjectID == "" {
+		return nil
+	}
+	vars := make(map[string]string, 4)
+	if env.OrgID != "" {
+		vars["OPENAI_ORGANIZATION"] = env.OrgID
+		vars["OPENAI_ORG_ID"] = env.OrgID
+	}
+	if env.ProjectID != "" {
+		vars["OPENAI_PROJECT"] = env.ProjectID
+		vars["OPENAI_PROJECT_ID"] = env.ProjectID
+	}
+	return vars
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateScopeID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{"", false},
+		{"org-AbC123", false},
+		{"proj_XyZ789", false},
+		{"-org", true},
+		{"org 123", true},
+		{"org\n123", true},
+	}
+	for _, tt := range tests {
+		if err := validateScopeID(tt.id); (err != nil) != tt.wantErr {
+			t.Errorf("validateScopeID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPrepareEnvironmentScopingVariables(t *testing.T) {
+	t.Setenv("OPENAI_ORGANIZATION", "org-inherited")
+	env := Environment{Name: "scoped", URL: "https://api.openai.com/v1", APIKey: "sk-test", OrgID: "org-AbC123", ProjectID: "proj_XyZ789"}
+
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		t.Fatalf("prepareEnvironment() failed: %v", err)
+	}
+	want := map[string]string{
+		"OPENAI_ORGANIZATION": "org-AbC123",
+		"OPENAI_ORG_ID":       "org-AbC123",
+		"OPENAI_PROJECT":      "proj_XyZ789",
+		"OPENAI_PROJECT_ID":   "proj_XyZ789",
+	}
+	for key, value := range want {
+		count := 0
+		for _, envVar := range envVars {
+			if envVar == key+"="+value {
+				count++
+			} else if strings.HasPrefix(envVar, key+"=") {
+				t.Errorf("unexpected %s", envVar)
+			}
+		}
+		if count != 1 {
+			t.Errorf("expected %s=%s once, found %d", key, value, count)
+		}
+	}
+
+	unscoped, err := prepareEnvironment(Environment{Name: "plain", URL: "https://api.openai.com/v1", APIKey: "sk-test"})
+	if err != nil {
+		t.Fatalf("prepareEnvironment() failed: %v", err)
+	}
+	for _, envVar := range unscoped {
+		if envVar == "OPENAI_ORGANIZATION=org-inherited" {
+			t.Error("inherited OPENAI_ORGANIZATION should be filtered out")
+		}
+	}
+}
+
+func TestValidateEnvironmentRejectsBadProjectID(t *testing.T) {
+	env := Environment{Name: "scoped", URL: "https://api.openai.com/v1", APIKey: "sk-test", ProjectID: "proj 1"}
+	if err := validateEnvironment(env); err == nil {
+		t.Error("expected an invalid project_id to be rejected")
+	}
+}
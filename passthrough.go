@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Passthrough modes
+const (
+	passthroughAll       = "all"
+	passthroughAllowlist = "allowlist"
+	passthroughDenylist  = "denylist"
+	passthroughClean     = "clean"
+)
+
+// envPatternPattern matches a variable name or a glob over names, e.g. AWS_* or SSH_AUTH_SOCK
+var envPatternPattern = regexp.MustCompile(`^[A-Za-z0-9_*?]+$`)
+
+// EnvPassthrough limits which of cde's own environment variables codex inherits, so host
+// credentials do not reach third-party gateways
+type EnvPassthrough struct {
+	Mode  string   `json:"mode,omitempty"`  // "all" (default), "allowlist", "denylist" or "clean"
+	Allow []string `json:"allow,omitempty"` // allowlist: inherited on top of the essential variables
+	Deny  []string `json:"deny,omitempty"`  // denylist: withheld; everything else is inherited
+}
+
+// validateEnvPassthrough checks the mode and that each list is used with its mode
+func validateEnvPassthrough(policy *EnvPassthrough) error {
+	if policy == nil {
+		return nil
+	}
+	switch policy.Mode {
+	case "", passthroughAll, passthroughAllowlist, passthroughDenylist, passthroughClean:
+	default:
+		return fmt.Errorf("unknown mode '%s' (use all, allowlist, denylist or clean)", policy.Mode)
+	}
+	if len(policy.Allow) > 0 && policy.Mode != passthroughAllowlist {
+		return fmt.Errorf("allow needs mode \"allowlist\"")
+	}
+	if len(policy.Deny) > 0 && policy.Mode != passthroughDenylist {
+		return fmt.Errorf("deny needs mode \"denylist\"")
+	}
+	for _, pattern := range append(append([]string{}, policy.Allow...), policy.Deny...) {
+		if !envPatternPattern.MatchString(pattern) {
+			return fmt.Errorf("invalid variable name or pattern '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+// matchesEnvPattern reports whether name matches one of patterns, ignoring case on Windows
+// where variable names are case-insensitive (Path is PATH)
+func matchesEnvPattern(patterns []string, name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, pattern := range patterns {
+		if runtime.GOOS == "windows" {
+			pattern = strings.ToUpper(pattern)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// inheritsEnvVar reports whether codex may inherit the variable name from cde under policy.
+// allowlist and clean always keep the essentials hooks get too (PATH, HOME, locale, temp dirs).
+func inheritsEnvVar(policy *EnvPassthrough, name string) bool {
+	if policy == nil {
+		return true
+	}
+	switch policy.Mode {
+	case passthroughDenylist:
+		return !matchesEnvPattern(policy.Deny, name)
+	case passthroughAllowlist:
+		return matchesEnvPattern(hookBaseEnvVars, name) || matchesEnvPattern(policy.Allow, name)
+	case passthroughClean:
+		return matchesEnvPattern(hookBaseEnvVars, name)
+	}
+	return true
+}
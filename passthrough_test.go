@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateEnvPassthrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *EnvPassthrough
+		wantErr bool
+	}{
+		{name: "none", policy: nil},
+		{name: "denylist", policy: &EnvPassthrough{Mode: "denylist", Deny: []string{"AWS_*", "SSH_AUTH_SOCK"}}},
+		{name: "allowlist", policy: &EnvPassthrough{Mode: "allowlist", Allow: []string{"HTTPS_PROXY"}}},
+		{name: "clean", policy: &EnvPassthrough{Mode: "clean"}},
+		{name: "unknown mode", policy: &EnvPassthrough{Mode: "strict"}, wantErr: true},
+		{name: "deny without denylist", policy: &EnvPassthrough{Mode: "clean", Deny: []string{"AWS_*"}}, wantErr: true},
+		{name: "allow without allowlist", policy: &EnvPassthrough{Allow: []string{"FOO"}}, wantErr: true},
+		{name: "bad pattern", policy: &EnvPassthrough{Mode: "denylist", Deny: []string{"AWS-KEY"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateEnvPassthrough(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("validateEnvPassthrough() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInheritsEnvVar(t *testing.T) {
+	deny := &EnvPassthrough{Mode: "denylist", Deny: []string{"AWS_*", "SSH_AUTH_SOCK"}}
+	allow := &EnvPassthrough{Mode: "allowlist", Allow: []string{"HTTPS_PROXY", "GIT_*"}}
+	clean := &EnvPassthrough{Mode: "clean"}
+	tests := []struct {
+		name   string
+		policy *EnvPassthrough
+		key    string
+		want   bool
+	}{
+		{"default inherits", nil, "AWS_SECRET_ACCESS_KEY", true},
+		{"denied glob", deny, "AWS_SECRET_ACCESS_KEY", false},
+		{"denied name", deny, "SSH_AUTH_SOCK", false},
+		{"not denied", deny, "EDITOR", true},
+		{"allowed", allow, "GIT_AUTHOR_NAME", true},
+		{"essential in allowlist", allow, "PATH", true},
+		{"not allowed", allow, "AWS_SECRET_ACCESS_KEY", false},
+		{"essential in clean", clean, "HOME", true},
+		{"dropped in clean", clean, "HTTPS_PROXY", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inheritsEnvVar(tt.policy, tt.key); got != tt.want {
+				t.Errorf("inheritsEnvVar(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareEnvironmentAppliesPassthrough(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "host-secret")
+	t.Setenv("CDE_TEST_KEEP", "kept")
+	env := Environment{
+		Name:           "gateway",
+		URL:            "https://gateway.example.com/v1",
+		APIKey:         "sk-test",
+		EnvVars:        map[string]string{"AWS_REGION": "us-east-1"},
+		EnvPassthrough: &EnvPassthrough{Mode: "denylist", Deny: []string{"AWS_*"}},
+	}
+
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		t.Fatalf("prepareEnvironment() failed: %v", err)
+	}
+	joined := "\n" + strings.Join(envVars, "\n") + "\n"
+	if strings.Contains(joined, "AWS_SECRET_ACCESS_KEY=") {
+		t.Error("denied host variable reached codex")
+	}
+	// The policy filters inherited variables only; the environment's own env_vars are still set
+	for _, want := range []string{"\nCDE_TEST_KEEP=kept\n", "\nAWS_REGION=us-east-1\n", "\nOPENAI_API_KEY=sk-test\n"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in the child environment", strings.TrimSpace(want))
+		}
+	}
+}
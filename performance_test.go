@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -74,6 +76,78 @@ func BenchmarkLoadConfig(b *testing.B) {
 	}
 }
 
+// writeLargeConfig saves a configuration with n environments for the large-config benchmarks
+func writeLargeConfig(b *testing.B, n int) Config {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(b.TempDir(), ".codex-env", "config.json")
+	b.Cleanup(func() { configPathOverride = originalConfigPath })
+
+	config := Config{}
+	for i := 0; i < n; i++ {
+		config.Environments = append(config.Environments, Environment{
+			Name:    fmt.Sprintf("env-%d", i),
+			URL:     fmt.Sprintf("https://api-%d.example.com/v1", i),
+			APIKey:  fmt.Sprintf("sk-benchmark-%040d", i),
+			Model:   "gpt-5",
+			EnvVars: map[string]string{"OPENAI_ORG": fmt.Sprintf("org-%d", i)},
+		})
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		b.Fatalf("json.Marshal() failed: %v", err)
+	}
+	if err := ensureConfigDir(); err != nil {
+		b.Fatalf("ensureConfigDir() failed: %v", err)
+	}
+	if err := ioutil.WriteFile(configPathOverride, data, 0600); err != nil {
+		b.Fatalf("WriteFile() failed: %v", err)
+	}
+	return config
+}
+
+// BenchmarkLoadConfigLarge measures full validation of 500 environments
+func BenchmarkLoadConfigLarge(b *testing.B) {
+	writeLargeConfig(b, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loadConfig(); err != nil {
+			b.Fatalf("loadConfig() failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkLoadConfigLazyLarge measures the launch path: lazy load plus validating one environment
+func BenchmarkLoadConfigLazyLarge(b *testing.B) {
+	writeLargeConfig(b, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		config, err := loadConfigLazy()
+		if err != nil {
+			b.Fatalf("loadConfigLazy() failed: %v", err)
+		}
+		index, _ := findEnvironmentByName(config, "env-499")
+		if err := validateSelectedEnvironment(config.Environments[index]); err != nil {
+			b.Fatalf("validateSelectedEnvironment() failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkFindEnvironmentByName compares the load-time index with a linear scan
+func BenchmarkFindEnvironmentByName(b *testing.B) {
+	config := writeLargeConfig(b, 500)
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			findEnvironmentByName(config, "env-499")
+		}
+	})
+	config.nameIndex = indexEnvironments(config.Environments)
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			findEnvironmentByName(config, "env-499")
+		}
+	})
+}
+
 func BenchmarkValidateEnvironment(b *testing.B) {
 	env := Environment{
 		Name:   "benchmark-validation",
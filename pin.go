@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pinSourcePrefix starts the launch plan source of a pinned environment
+const pinSourcePrefix = "pin for "
+
+// workspacePins maps workspace directories to the environment bare 'cde' selects there. Each
+// profile has its own pins (see profileStatePath), so a pin only names that profile's environments.
+type workspacePins struct {
+	Pins map[string]string `json:"pins"`
+}
+
+// getPinsPath returns the active profile's pin state file
+func getPinsPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "pins.json"), nil
+}
+
+// pinScope names the profile whose pins a command changes, for its messages: empty for the
+// default profile, " in profile 'work'" for pins.work.json
+func pinScope() string {
+	path, err := getPinsPath()
+	if err != nil || filepath.Base(path) == "pins.json" {
+		return ""
+	}
+	return fmt.Sprintf(" in profile '%s'", strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "pins."), ".json"))
+}
+
+// loadPins reads pins.json; a missing or unreadable file means no pins
+func loadPins() workspacePins {
+	pins := workspacePins{Pins: map[string]string{}}
+	path, err := getPinsPath()
+	if err != nil {
+		return pins
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, &pins)
+	}
+	if pins.Pins == nil {
+		pins.Pins = map[string]string{}
+	}
+	return pins
+}
+
+// updatePins applies change to pins.json under the config lock
+func updatePins(change func(pins *workspacePins) error) error {
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	pins := loadPins()
+	if err := change(&pins); err != nil {
+		return err
+	}
+	path, err := getPinsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("pins directory creation failed: %w", err)
+	}
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("pins serialization failed: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("pins write failed: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("pins write failed: %w", err)
+	}
+	return nil
+}
+
+// workspaceRoot returns the git repository root containing dir, or dir itself outside a repository
+func workspaceRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// workingDir returns the working directory with symlinks resolved, so pins match however it was reached
+func workingDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return dir, nil
+}
+
+// pathWithin reports whether path is dir or inside it
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// findPin returns the pinned directory closest to dir (dir itself or its nearest pinned ancestor)
+func findPin(pins workspacePins, dir string) (string, bool) {
+	best := ""
+	for pinned := range pins.Pins {
+		if pathWithin(dir, pinned) && len(pinned) > len(best) {
+			best = pinned
+		}
+	}
+	return best, best != ""
+}
+
+// pinnedEnvironment returns the environment pinned to the working directory's workspace and the
+// pinned directory. Pins to environments that no longer exist are ignored with a warning.
+func pinnedEnvironment(config Config) (string, string) {
+	pins := loadPins()
+	if len(pins.Pins) == 0 {
+		return "", ""
+	}
+	dir, err := workingDir()
+	if err != nil {
+		return "", ""
+	}
+	pinned, found := findPin(pins, dir)
+	if !found {
+		return "", ""
+	}
+	name := pins.Pins[pinned]
+	if _, exists := findEnvironmentByName(config, name); !exists {
+		fmt.Fprintf(os.Stderr, "Warning: %s is pinned to environment '%s', which no longer exists; run 'cde pin --unpin' there\n", pinned, name)
+		return "", ""
+	}
+	return name, pinned
+}
+
+// runPin pins the current workspace (git repository root, or the working directory) to an environment
+func runPin(name string) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("invalid environment name: %w", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	if _, exists := findEnvironmentByName(config, name); !exists {
+		return fmt.Errorf("environment '%s' not found", name)
+	}
+	dir, err := workingDir()
+	if err != nil {
+		return err
+	}
+	workspace := workspaceRoot(dir)
+
+	if err := updatePins(func(pins *workspacePins) error {
+		pins.Pins[workspace] = name
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save pin: %w", err)
+	}
+	fmt.Printf("Pinned %s to environment '%s'%s.\n", workspace, name, pinScope())
+	return nil
+}
+
+// runUnpin removes the pin that applies to the working directory
+func runUnpin() error {
+	dir, err := workingDir()
+	if err != nil {
+		return err
+	}
+
+	removed, name := "", ""
+	if err := updatePins(func(pins *workspacePins) error {
+		pinned, found := findPin(*pins, dir)
+		if !found {
+			return fmt.Errorf("no pin applies to %s%s", dir, pinScope())
+		}
+		removed, name = pinned, pins.Pins[pinned]
+		delete(pins.Pins, pinned)
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Unpinned %s (was '%s'%s).\n", removed, name, pinScope())
+	return nil
+}
+
+// runPinList prints every pin, flagging environments that no longer exist
+func runPinList() error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	pins := loadPins()
+	if len(pins.Pins) == 0 {
+		fmt.Printf("No workspaces are pinned%s. Run 'cde pin <env>' inside one.\n", pinScope())
+		return nil
+	}
+
+	dirs := make([]string, 0, len(pins.Pins))
+	for dir := range pins.Pins {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		name := pins.Pins[dir]
+		if _, exists := findEnvironmentByName(config, name); !exists {
+			name += " (missing)"
+		}
+		fmt.Printf("%s -> %s\n", dir, name)
+	}
+	return nil
+}
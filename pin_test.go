@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceRoot(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	plain := t.TempDir()
+
+	if got := workspaceRoot(nested); got != repo {
+		t.Errorf("workspaceRoot(nested) = %q, want %q", got, repo)
+	}
+	if got := workspaceRoot(plain); got != plain {
+		t.Errorf("workspaceRoot(plain) = %q, want %q", got, plain)
+	}
+}
+
+func TestFindPin(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "work")
+	pins := workspacePins{Pins: map[string]string{
+		filepath.Join(root, "app"):          "dev",
+		filepath.Join(root, "app", "infra"): "prod",
+	}}
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{filepath.Join(root, "app"), "dev"},
+		{filepath.Join(root, "app", "src"), "dev"},
+		{filepath.Join(root, "app", "infra", "tf"), "prod"},
+		{filepath.Join(root, "application"), ""},
+		{root, ""},
+	}
+	for _, tt := range tests {
+		pinned, _ := findPin(pins, tt.dir)
+		if got := pins.Pins[pinned]; got != tt.want {
+			t.Errorf("findPin(%q) selects %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestPinSelectsEnvironment(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+	t.Setenv("CDE_ENV", "")
+
+	config := Config{Environments: []Environment{
+		{Name: "dev", URL: "https://api.openai.com/v1", APIKey: "sk-dev-123456"},
+		{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-123456"},
+	}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	subdir := filepath.Join(repo, "cmd")
+	if err := os.Mkdir(subdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(subdir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runPin("prod"); err != nil {
+		t.Fatalf("runPin() error = %v", err)
+	}
+	if got := loadPins().Pins[repo]; got != "prod" {
+		t.Fatalf("pin for %s = %q, want prod", repo, got)
+	}
+
+	plan, err := planLaunch("", nil, launchOptions{NoPrompt: true})
+	if err != nil {
+		t.Fatalf("planLaunch() error = %v", err)
+	}
+	if plan.Environment.Name != "prod" || plan.Source != pinSourcePrefix+repo {
+		t.Errorf("selected %q via %q, want prod via the pin", plan.Environment.Name, plan.Source)
+	}
+
+	// An explicit environment still wins
+	if plan, err := planLaunch("dev", nil, launchOptions{NoPrompt: true}); err != nil || plan.Environment.Name != "dev" {
+		t.Errorf("planLaunch(dev) = %q, %v", plan.Environment.Name, err)
+	}
+
+	if err := runUnpin(); err != nil {
+		t.Fatalf("runUnpin() error = %v", err)
+	}
+	if _, err := planLaunch("", nil, launchOptions{NoPrompt: true}); err == nil {
+		t.Error("expected the menu to be needed again after unpinning")
+	}
+	if err := runUnpin(); err == nil {
+		t.Error("expected an error when no pin applies")
+	}
+}
+
+func TestPinsArePerProfile(t *testing.T) {
+	withHome(t)
+	t.Setenv("CDE_ENV", "")
+	for _, profile := range []string{"", "work"} {
+		t.Setenv("CDE_PROFILE", profile)
+		config := Config{Environments: []Environment{
+			{Name: "dev", URL: "https://api.openai.com/v1", APIKey: "sk-dev-123456"},
+			{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-123456"},
+		}}
+		if err := saveConfig(config); err != nil {
+			t.Fatalf("saveConfig failed: %v", err)
+		}
+	}
+
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CDE_PROFILE", "work")
+	if err := runPin("prod"); err != nil {
+		t.Fatalf("runPin() error = %v", err)
+	}
+	if plan, err := planLaunch("", nil, launchOptions{NoPrompt: true}); err != nil || plan.Source != pinSourcePrefix+repo {
+		t.Errorf("work profile: planLaunch() = %q via %q, %v; want the pin", plan.Environment.Name, plan.Source, err)
+	}
+
+	t.Setenv("CDE_PROFILE", "")
+	if plan, err := planLaunch("", nil, launchOptions{NoPrompt: true}); err == nil {
+		t.Errorf("default profile selected %q via %q from the work profile's pin", plan.Environment.Name, plan.Source)
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// forwardedSignals are relayed to the codex child process group
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// execReplace replaces the current process with the target binary (Unix exec behavior)
+func execReplace(path string, args []string, env []string) error {
+	return syscall.Exec(path, args, env)
+}
+
+// isConPTY reports whether the console is a Windows pseudo console (never on Unix)
+func isConPTY() bool {
+	return false
+}
+
+// platformSupportsANSI reports ANSI escape support based on TERM
+func platformSupportsANSI() bool {
+	termType := os.Getenv("TERM")
+	return termType != "" && termType != "dumb" && !strings.HasPrefix(termType, "vt5")
+}
+
+// tryLockFile attempts a non-blocking exclusive flock; false means another process holds it
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases a lock taken by tryLockFile
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// configureChildProcess puts codex in its own process group. When cde owns the terminal the
+// group becomes the foreground group, so Ctrl+C reaches codex directly and only once.
+func configureChildProcess(cmd *exec.Cmd) {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	fd := stdinFd()
+	if term.IsTerminal(fd) {
+		if pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP); err == nil && pgrp == syscall.Getpgrp() {
+			attr.Foreground = true
+			attr.Ctty = fd
+		}
+	}
+	cmd.SysProcAttr = attr
+}
+
+// configureHookProcess runs a hook in its own process group so a timeout kills
+// everything it started, not just the shell
+func configureHookProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// forwardSignal sends sig to the child's process group, falling back to the child itself
+func forwardSignal(p *os.Process, sig os.Signal) {
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		sysSig = syscall.SIGTERM
+	}
+	if err := syscall.Kill(-p.Pid, sysSig); err != nil {
+		p.Signal(sysSig)
+	}
+}
+
+// stopChild asks codex's process group to exit with SIGTERM
+func stopChild(p *os.Process) {
+	forwardSignal(p, syscall.SIGTERM)
+}
+
+// reclaimForeground makes cde's process group the terminal's foreground group again
+// after a foreground child exits. SIGTTOU is ignored because cde is in the background then.
+func reclaimForeground() {
+	fd := stdinFd()
+	if !term.IsTerminal(fd) {
+		return
+	}
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	unix.IoctlSetPointerInt(fd, unix.TIOCSPGRP, syscall.Getpgrp())
+}
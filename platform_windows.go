@@ -0,0 +1,106 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	vtOnce    sync.Once
+	vtEnabled bool
+)
+
+// execReplace runs the target binary as a child process and exits with its status,
+// since Windows has no exec(2) equivalent
+func execReplace(path string, args []string, env []string) error {
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	exitCode, err := runCodexChild(cmd)
+	if err != nil {
+		return err
+	}
+	os.Exit(exitCode)
+	return nil
+}
+
+// forwardedSignals are intercepted so cde outlives codex while it shuts down
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// configureChildProcess keeps codex in cde's console process group, so the console
+// delivers Ctrl+C and close events to it directly
+func configureChildProcess(cmd *exec.Cmd) {}
+
+// configureHookProcess keeps the default timeout handling, which kills the shell
+func configureHookProcess(cmd *exec.Cmd) {}
+
+// forwardSignal does nothing: the console already sent the event to codex. cde only
+// keeps waiting, and runCodexChild kills codex if it ignores the event.
+func forwardSignal(p *os.Process, sig os.Signal) {}
+
+// stopChild kills codex; Windows offers no signal a console program can be asked to exit with
+func stopChild(p *os.Process) {
+	p.Kill()
+}
+
+// reclaimForeground is a no-op; Windows consoles have no foreground process groups
+func reclaimForeground() {}
+
+// enableVirtualTerminal turns on VT output processing for the console once per process.
+// Input VT mode is enabled by term.MakeRaw, so arrow keys arrive as escape sequences.
+func enableVirtualTerminal() bool {
+	vtOnce.Do(func() {
+		out := windows.Handle(os.Stdout.Fd())
+		var mode uint32
+		if err := windows.GetConsoleMode(out, &mode); err != nil {
+			return
+		}
+		if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+			vtEnabled = true
+			return
+		}
+		vtEnabled = windows.SetConsoleMode(out, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+	})
+	return vtEnabled
+}
+
+// isConPTY reports whether the console is hosted by a pseudo console (Windows Terminal, VS Code)
+func isConPTY() bool {
+	return os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") == "vscode"
+}
+
+// platformSupportsANSI reports ANSI escape support for Windows consoles. TERM is
+// rarely set there, so ConPTY hosts and consoles accepting VT mode are detected directly.
+func platformSupportsANSI() bool {
+	if isConPTY() || os.Getenv("ConEmuANSI") == "ON" || enableVirtualTerminal() {
+		return true
+	}
+	termType := os.Getenv("TERM")
+	return termType != "" && termType != "dumb" && !strings.HasPrefix(termType, "vt5")
+}
+
+// tryLockFile attempts a non-blocking exclusive LockFileEx; false means another process holds it
+func tryLockFile(f *os.File) (bool, error) {
+	ol := new(windows.Overlapped)
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases a lock taken by tryLockFile
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgumentsPreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		preset   string
+		env      string
+		codex    []string
+		hasError bool
+	}{
+		{"name only", []string{"preset", "review"}, "review", "", []string{}, false},
+		{"with env and extra args", []string{"preset", "review", "-e", "prod", "--", "exec", "hi"}, "review", "prod", []string{"exec", "hi"}, false},
+		{"missing name", []string{"preset"}, "", "", nil, true},
+		{"flag instead of name", []string{"preset", "-e", "prod"}, "", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if tt.hasError {
+				if result.Error == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+			if result.Subcommand != "preset" || result.CCEFlags["preset_name"] != tt.preset || result.CCEFlags["env"] != tt.env {
+				t.Errorf("unexpected parse result: %+v", result)
+			}
+			if !reflect.DeepEqual(result.ClaudeArgs, tt.codex) {
+				t.Errorf("codex args = %v, want %v", result.ClaudeArgs, tt.codex)
+			}
+		})
+	}
+}
+
+func TestParseArgumentsAutoWithFlags(t *testing.T) {
+	result := parseArguments([]string{"auto", "-e", "dev", "--", "mcp"})
+	if result.Error != nil || result.Subcommand != "auto" || result.CCEFlags["env"] != "dev" {
+		t.Fatalf("unexpected parse result: %+v", result)
+	}
+	if !reflect.DeepEqual(result.ClaudeArgs, []string{"mcp"}) {
+		t.Errorf("codex args = %v, want [mcp]", result.ClaudeArgs)
+	}
+}
+
+func TestResolvePreset(t *testing.T) {
+	config := Config{Settings: &ConfigSettings{Presets: map[string][]string{
+		"review": {"-a", "never", "--sandbox", "read-only"},
+	}}}
+
+	args, err := resolvePreset(config, "review")
+	if err != nil {
+		t.Fatalf("resolvePreset failed: %v", err)
+	}
+	if !reflect.DeepEqual(args, []string{"-a", "never", "--sandbox", "read-only"}) {
+		t.Errorf("unexpected preset args: %v", args)
+	}
+
+	if _, err := resolvePreset(config, "missing"); err == nil || !strings.Contains(err.Error(), "available: review") {
+		t.Errorf("expected error listing available presets, got %v", err)
+	}
+	if _, err := resolvePreset(Config{}, "review"); err == nil {
+		t.Error("expected error when no presets are defined")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultProbeTimeout bounds each endpoint probe
+const defaultProbeTimeout = 3 * time.Second
+
+// probeResult holds the measured latency for one environment endpoint
+type probeResult struct {
+	Env     Environment
+	Latency time.Duration
+	Err     error
+}
+
+// healthy reports whether the endpoint answered without a server-side failure
+func (pr probeResult) healthy() bool {
+	return pr.Err == nil
+}
+
+// probeEndpoint measures the round trip of a GET against the environment URL.
+// Any HTTP response below 500 counts as reachable; auth errors still prove the endpoint is up.
+func probeEndpoint(ctx context.Context, client *http.Client, env Environment) probeResult {
+	result := probeResult{Env: env}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, env.URL, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	setRequestHeaders(req, env)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		result.Err = fmt.Errorf("server error: %s", resp.Status)
+	}
+	return result
+}
+
+// probeEnvironments probes all environments concurrently; results keep the input order.
+// Cancelling ctx (or hitting timeout) aborts outstanding probes.
+func probeEnvironments(ctx context.Context, envs []Environment, timeout time.Duration) []probeResult {
+	return probeEnvironmentsReporting(ctx, envs, timeout, nil)
+}
+
+// probeEnvironmentsReporting is probeEnvironments with a progress step for each finished probe
+func probeEnvironmentsReporting(ctx context.Context, envs []Environment, timeout time.Duration, report *progress) []probeResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]probeResult, len(envs))
+
+	var wg sync.WaitGroup
+	for i, env := range envs {
+		wg.Add(1)
+		go func(i int, env Environment) {
+			defer wg.Done()
+			// Each endpoint is probed through its own proxy settings
+			results[i] = probeEndpoint(ctx, httpClientFor(env, 0), env)
+			report.Step()
+		}(i, env)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// fastestHealthy returns the healthy result with the lowest latency
+func fastestHealthy(results []probeResult) (probeResult, bool) {
+	var best probeResult
+	found := false
+	for _, result := range results {
+		if !result.healthy() {
+			continue
+		}
+		if !found || result.Latency < best.Latency {
+			best = result
+			found = true
+		}
+	}
+	return best, found
+}
+
+// formatProbeSummary renders a one-line latency summary
+func formatProbeSummary(results []probeResult) string {
+	parts := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.healthy() {
+			parts = append(parts, fmt.Sprintf("%s %dms", endpointLabel(result.Env), result.Latency.Milliseconds()))
+		} else if errors.Is(result.Err, context.DeadlineExceeded) {
+			parts = append(parts, fmt.Sprintf("%s timeout", endpointLabel(result.Env)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s unreachable", endpointLabel(result.Env)))
+		}
+	}
+	return "Latency: " + strings.Join(parts, ", ")
+}
+
+// selectFastestEnvironment probes every endpoint of every configured environment and returns
+// the quickest healthy one, with URL set to that endpoint
+func selectFastestEnvironment(config Config) (Environment, error) {
+	if len(config.Environments) == 0 {
+		return Environment{}, fmt.Errorf("no environments configured - use 'add' command to create one")
+	}
+
+	endpoints := expandEndpoints(config.Environments)
+	report := startProgress(trf("Probing %d endpoint(s)", len(endpoints)), len(endpoints))
+	results := probeEnvironmentsReporting(context.Background(), endpoints, defaultProbeTimeout, report)
+	report.Finish("")
+	fmt.Println(formatProbeSummary(results))
+
+	best, ok := fastestHealthy(results)
+	if !ok {
+		return Environment{}, fmt.Errorf("no healthy environment endpoint responded within %s", defaultProbeTimeout)
+	}
+	return best.Env, nil
+}
+
+// latencyAutoSelectEnabled reports whether settings.auto_select requests latency-based selection
+func latencyAutoSelectEnabled(config Config) bool {
+	return config.Settings != nil && config.Settings.AutoSelect == "latency"
+}
+
+// selectFailoverEnvironment health-checks the named environments (each of their endpoints) in
+// order and returns the first healthy one, backing off between attempts with the launcher's
+// retry configuration
+func selectFailoverEnvironment(config Config, names []string) (Environment, error) {
+	if len(names) == 0 {
+		return Environment{}, fmt.Errorf("failover requires at least one environment name")
+	}
+
+	// Resolve all names up front so typos fail before any network activity
+	candidates := make([]Environment, 0, len(names))
+	for _, name := range names {
+		index, exists := findEnvironmentByName(config, name)
+		if !exists {
+			return Environment{}, fmt.Errorf("environment '%s' not found", name)
+		}
+		candidates = append(candidates, expandEndpoints(config.Environments[index:index+1])...)
+	}
+
+	rc := defaultRetryConfig()
+	for attempt, env := range candidates {
+		if attempt > 0 {
+			time.Sleep(rc.exponentialBackoff(attempt - 1))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultProbeTimeout)
+		report := startProgress(trf("Checking %s", endpointLabel(env)), 0)
+		// Each candidate is checked through its own proxy settings
+		result := probeEndpoint(ctx, httpClientFor(env, defaultProbeTimeout), env)
+		report.Finish("")
+		cancel()
+
+		if result.healthy() {
+			return env, nil
+		}
+		fmt.Printf("Failover: environment '%s' unhealthy (%v), trying next\n", endpointLabel(env), result.Err)
+	}
+
+	return Environment{}, fmt.Errorf("failover exhausted: none of %s is healthy", strings.Join(names, ", "))
+}
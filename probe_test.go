@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeEnvironmentsSelectsFastest(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized) // reachable even without auth
+	}))
+	defer fast.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	envs := []Environment{
+		{Name: "slow", URL: slow.URL, APIKey: "k"},
+		{Name: "broken", URL: broken.URL, APIKey: "k"},
+		{Name: "fast", URL: fast.URL, APIKey: "k"},
+	}
+
+	results := probeEnvironments(context.Background(), envs, 2*time.Second)
+	if len(results) != 3 || results[0].Env.Name != "slow" {
+		t.Fatalf("results should keep input order: %+v", results)
+	}
+	if results[1].healthy() {
+		t.Error("5xx endpoint should be unhealthy")
+	}
+
+	best, ok := fastestHealthy(results)
+	if !ok || best.Env.Name != "fast" {
+		t.Errorf("expected fast endpoint, got %+v (ok=%v)", best, ok)
+	}
+
+	summary := formatProbeSummary(results)
+	if !strings.HasPrefix(summary, "Latency: ") || !strings.Contains(summary, "broken unreachable") {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+}
+
+func TestProbeEnvironmentsTimeout(t *testing.T) {
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hang.Close()
+
+	start := time.Now()
+	results := probeEnvironments(context.Background(), []Environment{{Name: "hang", URL: hang.URL}}, 50*time.Millisecond)
+	if time.Since(start) > time.Second {
+		t.Error("probe did not honour timeout")
+	}
+	if _, ok := fastestHealthy(results); ok {
+		t.Error("timed out endpoint should not be selected")
+	}
+	if !strings.Contains(formatProbeSummary(results), "hang timeout") {
+		t.Errorf("expected timeout in summary, got %s", formatProbeSummary(results))
+	}
+}
+
+func TestParseArgumentsFastest(t *testing.T) {
+	result := parseArguments([]string{"--fastest", "--", "proto"})
+	if result.CCEFlags["fastest"] != "true" || len(result.ClaudeArgs) != 1 {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+}
+
+func TestSelectFailoverEnvironment(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	config := Config{Environments: []Environment{
+		{Name: "primary", URL: down.URL, APIKey: "k"},
+		{Name: "secondary", URL: healthy.URL, APIKey: "k"},
+	}}
+
+	env, err := selectFailoverEnvironment(config, []string{"primary", "secondary"})
+	if err != nil {
+		t.Fatalf("selectFailoverEnvironment() failed: %v", err)
+	}
+	if env.Name != "secondary" {
+		t.Errorf("expected failover to secondary, got %s", env.Name)
+	}
+
+	if _, err := selectFailoverEnvironment(config, []string{"primary"}); err == nil {
+		t.Error("expected error when all environments are unhealthy")
+	}
+	if _, err := selectFailoverEnvironment(config, []string{"missing", "secondary"}); err == nil {
+		t.Error("expected error for unknown environment name")
+	}
+}
+
+func TestFailoverConflictsWithEnv(t *testing.T) {
+	err := handleCommand([]string{"--env", "a", "--failover", "a,b"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
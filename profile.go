@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultProfile names the profile stored in config.json
+const defaultProfile = "default"
+
+// profileFileName records the profile chosen with 'cde profile use'
+const profileFileName = "profile"
+
+// getConfigDir returns ~/.codex-env, where every profile's config file lives
+func getConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".codex-env"), nil
+}
+
+// profileConfigName maps a profile to its file name: config.json or config.<profile>.json
+func profileConfigName(profile string) string {
+	if profile == "" || profile == defaultProfile {
+		return "config.json"
+	}
+	return "config." + profile + ".json"
+}
+
+// profileStatePath returns the path of a state file such as pins.json for the profile whose
+// config file is configPath. Profiles share a directory, so like their backups each
+// non-default profile keeps its own copy: pins.json goes with config.json, pins.work.json with
+// config.work.json.
+func profileStatePath(configPath, name string) string {
+	dir, base := filepath.Split(configPath)
+	if base == "config.json" {
+		return filepath.Join(dir, name)
+	}
+	scope := strings.TrimPrefix(strings.TrimSuffix(base, filepath.Ext(base)), "config.")
+	ext := filepath.Ext(name)
+	return filepath.Join(dir, strings.TrimSuffix(name, ext)+"."+scope+ext)
+}
+
+// validateProfileName applies the environment naming rules to profile names
+func validateProfileName(profile string) error {
+	if err := validateName(profile); err != nil {
+		return fmt.Errorf("invalid profile name '%s': %w", profile, err)
+	}
+	return nil
+}
+
+// activeProfile returns the selected profile and what selected it. CDE_PROFILE wins over
+// 'cde profile use'; with neither, the default profile (config.json) is active.
+func activeProfile(configDir string) (string, string, error) {
+	if profile := strings.TrimSpace(os.Getenv("CDE_PROFILE")); profile != "" {
+		if err := validateProfileName(profile); err != nil {
+			return "", "", fmt.Errorf("CDE_PROFILE: %w", err)
+		}
+		return profile, "CDE_PROFILE", nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(configDir, profileFileName))
+	if os.IsNotExist(err) {
+		return defaultProfile, "", nil
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to read active profile: %w", err)
+	}
+	profile := strings.TrimSpace(string(data))
+	if profile == "" {
+		return defaultProfile, "", nil
+	}
+	if err := validateProfileName(profile); err != nil {
+		return "", "", fmt.Errorf("%s: %w", filepath.Join(configDir, profileFileName), err)
+	}
+	return profile, "cde profile use", nil
+}
+
+// listProfiles returns the profiles that have a config file, sorted, default first
+func listProfiles(configDir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(configDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "config.") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		profile := defaultProfile
+		if name != "config.json" {
+			profile = strings.TrimSuffix(strings.TrimPrefix(name, "config."), ".json")
+		}
+		if validateProfileName(profile) == nil {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i] == defaultProfile || profiles[j] == defaultProfile {
+			return profiles[i] == defaultProfile
+		}
+		return profiles[i] < profiles[j]
+	})
+	return profiles, nil
+}
+
+// runProfileList prints the known profiles and marks the active one
+func runProfileList() error {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+	profiles, err := listProfiles(configDir)
+	if err != nil {
+		return err
+	}
+	active, source, err := activeProfile(configDir)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, profile := range profiles {
+		found = found || profile == active
+	}
+	if !found {
+		profiles = append(profiles, active)
+	}
+
+	for _, profile := range profiles {
+		marker := " "
+		if profile == active && configPathOverride == "" {
+			marker = "*"
+		}
+		count := "no config yet"
+		if n, err := loadProfileSummary(filepath.Join(configDir, profileConfigName(profile))); err == nil {
+			count = fmt.Sprintf("%d environment(s)", n)
+		}
+		fmt.Printf("%s %-16s %-28s %s\n", marker, profile, profileConfigName(profile), count)
+	}
+
+	switch {
+	case configPathOverride != "":
+		fmt.Printf("\n--config %s overrides the profile.\n", configPathOverride)
+	case source != "":
+		fmt.Printf("\nActive profile '%s' set by %s.\n", active, source)
+	}
+	return nil
+}
+
+// loadProfileSummary counts the environments in a profile's config file
+func loadProfileSummary(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	names, err := readEnvironmentNames(newJSONCReader(file))
+	return len(names), err
+}
+
+// runProfileUse makes profile the default for later commands; "default" selects config.json
+func runProfileUse(profile string) error {
+	if err := validateProfileName(profile); err != nil {
+		return err
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return fmt.Errorf("failed to create configuration directory: %w", err)
+	}
+
+	path := filepath.Join(configDir, profileFileName)
+	if profile == defaultProfile {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to reset active profile: %w", err)
+		}
+	} else if err := ioutil.WriteFile(path, []byte(profile+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save active profile: %w", err)
+	}
+
+	configPath := filepath.Join(configDir, profileConfigName(profile))
+	fmt.Printf("Active profile: %s (%s)\n", profile, configPath)
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		fmt.Println("This profile has no configuration yet; 'cde add' will create it.")
+	}
+	if os.Getenv("CDE_PROFILE") != "" {
+		fmt.Println("Note: CDE_PROFILE is set in this shell and takes precedence.")
+	}
+	return nil
+}
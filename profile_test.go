@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withHome points the home directory (and so ~/.codex-env) at a temporary directory
+func withHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CDE_PROFILE", "")
+	original := configPathOverride
+	configPathOverride = ""
+	t.Cleanup(func() { configPathOverride = original })
+	return filepath.Join(home, ".codex-env")
+}
+
+func TestGetConfigPathProfiles(t *testing.T) {
+	configDir := withHome(t)
+
+	path, err := getConfigPath()
+	if err != nil || path != filepath.Join(configDir, "config.json") {
+		t.Fatalf("default path = %q, %v", path, err)
+	}
+
+	if err := runProfileUse("work"); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := getConfigPath(); path != filepath.Join(configDir, "config.work.json") {
+		t.Errorf("after 'profile use work' path = %q", path)
+	}
+
+	t.Setenv("CDE_PROFILE", "client-a")
+	if path, _ := getConfigPath(); path != filepath.Join(configDir, "config.client-a.json") {
+		t.Errorf("CDE_PROFILE should win, path = %q", path)
+	}
+
+	t.Setenv("CDE_PROFILE", "../etc")
+	if _, err := getConfigPath(); err == nil || !strings.Contains(err.Error(), "invalid profile name") {
+		t.Errorf("invalid CDE_PROFILE error = %v", err)
+	}
+
+	t.Setenv("CDE_PROFILE", "")
+	if err := runProfileUse(defaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := getConfigPath(); path != filepath.Join(configDir, "config.json") {
+		t.Errorf("after 'profile use default' path = %q", path)
+	}
+}
+
+func TestProfilesAreSeparated(t *testing.T) {
+	configDir := withHome(t)
+	work := Environment{Name: "client", URL: "https://work.example.com/v1", APIKey: "sk-work-1234567890"}
+	personal := Environment{Name: "mine", URL: "https://api.openai.com/v1", APIKey: "sk-home-1234567890"}
+
+	t.Setenv("CDE_PROFILE", "work")
+	for i := 0; i < 2; i++ {
+		if err := saveConfig(Config{Environments: []Environment{work}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CDE_PROFILE", "")
+	if err := saveConfig(Config{Environments: []Environment{personal}}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig()
+	if err != nil || len(config.Environments) != 1 || config.Environments[0].Name != "mine" {
+		t.Errorf("default profile = %+v, %v", config.Environments, err)
+	}
+	t.Setenv("CDE_PROFILE", "work")
+	config, err = loadConfig()
+	if err != nil || len(config.Environments) != 1 || config.Environments[0].Name != "client" {
+		t.Errorf("work profile = %+v, %v", config.Environments, err)
+	}
+
+	// The second save backed up the work profile into its own directory
+	backups, err := ioutil.ReadDir(filepath.Join(configDir, "backups", "config.work"))
+	if err != nil || len(backups) == 0 {
+		t.Errorf("work backups = %d, %v", len(backups), err)
+	}
+
+	profiles, err := listProfiles(configDir)
+	if err != nil || !reflect.DeepEqual(profiles, []string{defaultProfile, "work"}) {
+		t.Errorf("listProfiles() = %v, %v", profiles, err)
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	withHome(t)
+	custom := filepath.Join(t.TempDir(), "client.json")
+	if err := os.WriteFile(custom, []byte(`{"environments": [{"name": "client", "url": "https://client.example.com/v1", "api_key": "sk-client-1234567890"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result := parseArguments([]string{"--config", custom, "list"})
+	if result.Error != nil || result.CCEFlags["config"] != custom || result.Subcommand != "list" {
+		t.Fatalf("parseArguments() = %+v", result)
+	}
+	if result := parseArguments([]string{"--config", "model=gpt-5"}); result.CCEFlags["config"] != "" {
+		t.Errorf("codex --config key=value should pass through, got %q", result.CCEFlags["config"])
+	}
+
+	if err := handleCommand([]string{"--config", custom, "config", "validate"}); err != nil {
+		t.Errorf("config validate with --config: %v", err)
+	}
+	if configPathOverride != "" {
+		t.Errorf("--config leaked into later commands: %q", configPathOverride)
+	}
+}
+
+func TestParseProfileCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		profile string
+		wantErr bool
+	}{
+		{args: []string{"profile", "list"}, want: "profile-list"},
+		{args: []string{"profile", "use", "work"}, want: "profile-use", profile: "work"},
+		{args: []string{"profile"}, wantErr: true},
+		{args: []string{"profile", "use"}, wantErr: true},
+		{args: []string{"profile", "delete", "work"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		result := parseArguments(tt.args)
+		if (result.Error != nil) != tt.wantErr {
+			t.Errorf("parseArguments(%q) error = %v, wantErr %v", tt.args, result.Error, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (result.Subcommand != tt.want || result.CCEFlags["profile"] != tt.profile) {
+			t.Errorf("parseArguments(%q) = %s %q", tt.args, result.Subcommand, result.CCEFlags["profile"])
+		}
+	}
+}
+
+func TestProfileStatePath(t *testing.T) {
+	dir := filepath.Join("home", ".codex-env")
+	tests := []struct {
+		config, name, want string
+	}{
+		{"config.json", "pins.json", "pins.json"},
+		{"config.work.json", "pins.json", "pins.work.json"},
+		{"config.work.json", "audit.jsonl", "audit.work.jsonl"},
+		{"client.json", "usage.json", "usage.client.json"},
+	}
+	for _, tt := range tests {
+		if got := profileStatePath(filepath.Join(dir, tt.config), tt.name); got != filepath.Join(dir, tt.want) {
+			t.Errorf("profileStatePath(%q, %q) = %q, want %q", tt.config, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStateFilesFollowProfile(t *testing.T) {
+	configDir := withHome(t)
+	getters := map[string]func() (string, error){
+		"pins.json": getPinsPath, "sync_state.json": getSyncStatePath, "usage.json": getUsagePath,
+		"audit.jsonl": getAuditPath, "tokens.json": getTokenCachePath, "metrics.json": getMetricsPath,
+		"endpoints.json": getEndpointStatePath,
+	}
+
+	for name, get := range getters {
+		if path, err := get(); err != nil || path != filepath.Join(configDir, name) {
+			t.Errorf("default profile %s = %q, %v", name, path, err)
+		}
+	}
+	t.Setenv("CDE_PROFILE", "work")
+	for name, get := range getters {
+		want := filepath.Join(configDir, profileStatePath("config.work.json", name))
+		if path, err := get(); err != nil || path != want {
+			t.Errorf("work profile %s = %q, %v; want %q", name, path, err, want)
+		}
+	}
+}
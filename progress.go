@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressOutput receives progress output; tests replace it. Progress goes to stderr so
+// stdout stays parseable (--json, env print).
+var progressOutput io.Writer = os.Stderr
+
+// progressANSI reports whether progress may redraw in place; tests replace it
+var progressANSI = func() bool {
+	return term.IsTerminal(int(os.Stderr.Fd())) && platformSupportsANSI() && !accessibleMode(Config{})
+}
+
+// Progress timing: spinner frames on a terminal, status lines otherwise
+const (
+	progressFrameInterval = 100 * time.Millisecond
+	progressLineInterval  = 5 * time.Second
+	progressBarWidth      = 20
+)
+
+// progressFrames are the spinner frames drawn on ANSI terminals
+var progressFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progress reports a slow step (probing endpoints, sync, update checks). On an ANSI terminal it
+// draws a spinner, or a bar when the number of steps is known, on one line. Elsewhere it prints
+// a plain line when it starts and again every progressLineInterval, so CI logs show it is alive.
+// A nil *progress does nothing.
+type progress struct {
+	label   string
+	total   int // Number of steps, or 0 for a spinner
+	ansi    bool
+	started time.Time
+
+	mu    sync.Mutex
+	done  int
+	frame int
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// startProgress starts reporting label; total is the number of steps, or 0 when unknown
+func startProgress(label string, total int) *progress {
+	p := &progress{
+		label:   label,
+		total:   total,
+		ansi:    progressANSI(),
+		started: time.Now(),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	interval := progressLineInterval
+	if p.ansi {
+		interval = progressFrameInterval
+		p.draw()
+	} else {
+		fmt.Fprintf(progressOutput, "%s...\n", label)
+	}
+	go p.run(interval)
+	return p
+}
+
+// run redraws the spinner or prints a status line until Finish
+func (p *progress) run(interval time.Duration) {
+	defer close(p.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if p.ansi {
+				p.draw()
+			} else {
+				p.mu.Lock()
+				fmt.Fprintf(progressOutput, "%s... %s\n", p.label, p.status())
+				p.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Step records one finished step
+func (p *progress) Step() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.done++
+	p.mu.Unlock()
+	if p.ansi {
+		p.draw()
+	}
+}
+
+// draw overwrites the current line with the spinner or bar
+func (p *progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	indicator := progressFrames[p.frame%len(progressFrames)]
+	p.frame++
+	if p.total > 0 {
+		filled := p.done * progressBarWidth / p.total
+		indicator = "[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "]"
+	}
+	fmt.Fprintf(progressOutput, "\r\033[K%s %s %s", indicator, p.label, p.status())
+}
+
+// status describes how far along the step is: "2/5, 3s" or "3s"
+func (p *progress) status() string {
+	elapsed := time.Since(p.started).Round(time.Second)
+	if p.total > 0 {
+		return fmt.Sprintf("(%d/%d, %s)", p.done, p.total, elapsed)
+	}
+	return fmt.Sprintf("(%s)", elapsed)
+}
+
+// Finish stops reporting and clears the spinner line; a non-empty summary is printed in its place
+func (p *progress) Finish(summary string) {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.stopped
+	if p.ansi {
+		fmt.Fprint(progressOutput, "\r\033[K")
+	}
+	if summary != "" {
+		fmt.Fprintln(progressOutput, summary)
+	}
+}
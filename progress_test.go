@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureProgress sends progress output to a buffer, drawn as on a terminal when ansi is set
+func captureProgress(t *testing.T, ansi bool) *bytes.Buffer {
+	t.Helper()
+	out := &bytes.Buffer{}
+	oldOutput, oldANSI := progressOutput, progressANSI
+	progressOutput = out
+	progressANSI = func() bool { return ansi }
+	t.Cleanup(func() { progressOutput, progressANSI = oldOutput, oldANSI })
+	return out
+}
+
+func TestProgressPlain(t *testing.T) {
+	out := captureProgress(t, false)
+	report := startProgress("Probing 2 endpoint(s)", 2)
+	report.Step()
+	report.Step()
+	report.Finish("Latency: a 10ms, b 20ms")
+
+	if got, want := out.String(), "Probing 2 endpoint(s)...\nLatency: a 10ms, b 20ms\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestProgressPlainStatusLines(t *testing.T) {
+	out := captureProgress(t, false)
+	report := &progress{label: "Fetching shared environments", started: time.Now(), stop: make(chan struct{}), stopped: make(chan struct{})}
+	go report.run(5 * time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	report.Finish("")
+
+	if !strings.Contains(out.String(), "Fetching shared environments... (0s)\n") {
+		t.Errorf("expected periodic status lines, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "\r") || strings.Contains(out.String(), "\033") {
+		t.Errorf("plain progress must not write control characters: %q", out.String())
+	}
+}
+
+func TestProgressANSIBar(t *testing.T) {
+	out := captureProgress(t, true)
+	report := startProgress("Probing", 2)
+	report.Step()
+	report.Finish("")
+
+	if !strings.Contains(out.String(), "\r\033[K[##########----------] Probing (1/2") {
+		t.Errorf("expected a half-filled bar, got %q", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\r\033[K") {
+		t.Errorf("Finish should clear the progress line, got %q", out.String())
+	}
+}
+
+func TestProgressNil(t *testing.T) {
+	var report *progress
+	report.Step()
+	report.Finish("done")
+}
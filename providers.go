@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProviderPreset pre-fills environment fields for a known API provider
+type ProviderPreset struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description,omitempty"`
+	URLTemplate  string            `json:"url_template"`            // May contain {placeholders} prompted during add
+	DefaultModel string            `json:"default_model,omitempty"` // Suggested model
+	EnvVars      map[string]string `json:"env_vars,omitempty"`      // Required variables; empty value means prompt
+	KeyPrefix    string            `json:"key_prefix,omitempty"`    // Expected API key prefix, used for hints only
+	KeyHint      string            `json:"key_hint,omitempty"`      // Where to obtain the key
+	APIVersion   string            `json:"api_version,omitempty"`   // Default REST API version (Azure)
+}
+
+// urlPlaceholderPattern matches {placeholder} segments in URL templates
+var urlPlaceholderPattern = regexp.MustCompile(`\{([a-zA-Z0-9_]+)\}`)
+
+// builtinProviderPresets returns the presets shipped with cde
+func builtinProviderPresets() []ProviderPreset {
+	return []ProviderPreset{
+		{
+			Name:         "openai",
+			Description:  "OpenAI API",
+			URLTemplate:  "https://api.openai.com/v1",
+			DefaultModel: "gpt-5",
+			KeyPrefix:    "sk-",
+			KeyHint:      "Create a key at https://platform.openai.com/api-keys",
+		},
+		{
+			Name:        "azure",
+			Description: "Azure OpenAI Service",
+			URLTemplate: "https://{resource}.openai.azure.com/openai",
+			APIVersion:  "2025-04-01-preview",
+			KeyHint:     "Use a key from the Azure portal (Resource > Keys and Endpoint)",
+		},
+		{
+			Name:         "openrouter",
+			Description:  "OpenRouter",
+			URLTemplate:  "https://openrouter.ai/api/v1",
+			DefaultModel: "openai/gpt-5",
+			KeyPrefix:    "sk-or-",
+			KeyHint:      "Create a key at https://openrouter.ai/keys",
+		},
+		{
+			Name:        "local",
+			Description: "Local OpenAI-compatible server (Ollama, LM Studio, vLLM)",
+			URLTemplate: "http://localhost:11434/v1",
+			KeyHint:     "Local servers usually accept any key value",
+		},
+	}
+}
+
+// getProvidersPath returns the path of the user-defined presets file next to config.json
+func getProvidersPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "providers.json"), nil
+}
+
+// loadProviderPresets returns built-in presets merged with providers.json (user entries win)
+func loadProviderPresets() (map[string]ProviderPreset, error) {
+	presets := make(map[string]ProviderPreset)
+	for _, preset := range builtinProviderPresets() {
+		presets[preset.Name] = preset
+	}
+
+	providersPath, err := getProvidersPath()
+	if err != nil {
+		return nil, fmt.Errorf("provider presets loading failed: %w", err)
+	}
+
+	data, err := ioutil.ReadFile(providersPath)
+	if os.IsNotExist(err) {
+		return presets, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("provider presets read failed: %w", err)
+	}
+
+	var userPresets []ProviderPreset
+	if err := json.Unmarshal(data, &userPresets); err != nil {
+		return nil, fmt.Errorf("provider presets parsing failed (invalid JSON in %s): %w", providersPath, err)
+	}
+
+	for i, preset := range userPresets {
+		if err := validateName(preset.Name); err != nil {
+			return nil, fmt.Errorf("provider preset %d: invalid name: %w", i, err)
+		}
+		if preset.URLTemplate == "" {
+			return nil, fmt.Errorf("provider preset '%s': url_template is required", preset.Name)
+		}
+		for key := range preset.EnvVars {
+			if !isValidEnvVarName(key) {
+				return nil, fmt.Errorf("provider preset '%s': invalid env var name '%s'", preset.Name, key)
+			}
+		}
+		presets[preset.Name] = preset
+	}
+
+	return presets, nil
+}
+
+// findProviderPreset looks up a preset by name
+func findProviderPreset(name string) (ProviderPreset, error) {
+	presets, err := loadProviderPresets()
+	if err != nil {
+		return ProviderPreset{}, err
+	}
+
+	preset, exists := presets[name]
+	if !exists {
+		names := make([]string, 0, len(presets))
+		for presetName := range presets {
+			names = append(names, presetName)
+		}
+		sort.Strings(names)
+		return ProviderPreset{}, fmt.Errorf("unknown provider preset '%s' (available: %s)", name, strings.Join(names, ", "))
+	}
+	return preset, nil
+}
+
+// urlPlaceholders returns the placeholder names in a URL template in order of appearance
+func (p ProviderPreset) urlPlaceholders() []string {
+	matches := urlPlaceholderPattern.FindAllStringSubmatch(p.URLTemplate, -1)
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// expandURL substitutes placeholder values into the URL template
+func (p ProviderPreset) expandURL(values map[string]string) string {
+	return urlPlaceholderPattern.ReplaceAllStringFunc(p.URLTemplate, func(m string) string {
+		return values[m[1:len(m)-1]]
+	})
+}
+
+// keyFormatWarning returns a hint when apiKey does not look like this provider's keys
+func (p ProviderPreset) keyFormatWarning(apiKey string) string {
+	if p.KeyPrefix == "" || apiKey == "" || strings.HasPrefix(apiKey, p.KeyPrefix) {
+		return ""
+	}
+	return fmt.Sprintf("Warning: %s keys usually start with '%s'", p.Name, p.KeyPrefix)
+}
+
+// providerFingerprint recognizes a provider's API by host and its keys by prefix
+type providerFingerprint struct {
+	Provider  string
+	Hosts     []string // Exact hosts, or ".suffix" for any subdomain
+	Prefixes  []string // Key prefixes specific enough to identify this provider
+	KeyFormat string   // Prefix every key for this host has ("" when keys have no common prefix)
+	Guidance  string   // What to do when a key from another provider is used here
+}
+
+// providerFingerprints drives the add-time mismatch warnings. Gateways and local servers
+// match no host and are never warned about, since they may accept any provider's keys.
+var providerFingerprints = []providerFingerprint{
+	{Provider: "OpenAI", Hosts: []string{"api.openai.com"}, Prefixes: []string{"sk-proj-", "sk-svcacct-", "sk-admin-"}, KeyFormat: "sk-",
+		Guidance: "create an OpenAI key at https://platform.openai.com/api-keys"},
+	{Provider: "Anthropic", Hosts: []string{"api.anthropic.com"}, Prefixes: []string{"sk-ant-"}, KeyFormat: "sk-ant-",
+		Guidance: "Anthropic keys only work against api.anthropic.com or a gateway that translates to it"},
+	{Provider: "OpenRouter", Hosts: []string{"openrouter.ai"}, Prefixes: []string{"sk-or-"}, KeyFormat: "sk-or-",
+		Guidance: "create an OpenRouter key at https://openrouter.ai/keys"},
+	{Provider: "Azure OpenAI", Hosts: []string{".openai.azure.com"},
+		Guidance: "use a key from the Azure portal (Resource > Keys and Endpoint)"},
+	{Provider: "Groq", Hosts: []string{"api.groq.com"}, Prefixes: []string{"gsk_"}, KeyFormat: "gsk_"},
+	{Provider: "xAI", Hosts: []string{"api.x.ai"}, Prefixes: []string{"xai-"}, KeyFormat: "xai-"},
+	{Provider: "Google AI Studio", Hosts: []string{"generativelanguage.googleapis.com"}, Prefixes: []string{"AIza"}, KeyFormat: "AIza"},
+	{Provider: "Perplexity", Hosts: []string{"api.perplexity.ai"}, Prefixes: []string{"pplx-"}, KeyFormat: "pplx-"},
+	{Provider: "Hugging Face", Hosts: []string{"router.huggingface.co", "api-inference.huggingface.co"}, Prefixes: []string{"hf_"}, KeyFormat: "hf_"},
+}
+
+// fingerprintForHost returns the provider whose API serves host
+func fingerprintForHost(host string) (providerFingerprint, bool) {
+	host = strings.ToLower(host)
+	for _, fp := range providerFingerprints {
+		for _, h := range fp.Hosts {
+			if host == h || (strings.HasPrefix(h, ".") && strings.HasSuffix(host, h)) {
+				return fp, true
+			}
+		}
+	}
+	return providerFingerprint{}, false
+}
+
+// fingerprintForKey returns the provider whose key prefix apiKey has, preferring the longest prefix
+func fingerprintForKey(apiKey string) (providerFingerprint, bool) {
+	var best providerFingerprint
+	longest := 0
+	for _, fp := range providerFingerprints {
+		for _, prefix := range fp.Prefixes {
+			if strings.HasPrefix(apiKey, prefix) && len(prefix) > longest {
+				best, longest = fp, len(prefix)
+			}
+		}
+	}
+	return best, longest > 0
+}
+
+// keyProviderWarnings returns hints when the API key looks like it belongs to a different
+// provider than the URL, or does not have the format the URL's provider uses. They never block.
+func keyProviderWarnings(rawURL, apiKey string) []string {
+	if apiKey == "" || secretReferenceScheme(apiKey) != "" {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	host, hostKnown := fingerprintForHost(parsed.Hostname())
+	if !hostKnown {
+		return nil
+	}
+
+	guidance := ""
+	if host.Guidance != "" {
+		guidance = "; " + host.Guidance
+	}
+	if key, ok := fingerprintForKey(apiKey); ok && key.Provider != host.Provider {
+		return []string{fmt.Sprintf("Warning: this key has the %s format, but %s is the %s API%s", key.Provider, parsed.Host, host.Provider, guidance)}
+	}
+	if host.KeyFormat != "" && !strings.HasPrefix(apiKey, host.KeyFormat) {
+		return []string{fmt.Sprintf("Warning: %s keys usually start with '%s'%s", host.Provider, host.KeyFormat, guidance)}
+	}
+	return nil
+}
+
+// isAzureEnvironment reports whether env targets Azure OpenAI
+func isAzureEnvironment(env Environment) bool {
+	return env.Provider == "azure"
+}
+
+// applyDeploymentMap rewrites the -m/--model value to the Azure deployment name
+func applyDeploymentMap(env Environment, codexArgs []string) []string {
+	if !isAzureEnvironment(env) || len(env.DeploymentMap) == 0 {
+		return codexArgs
+	}
+
+	mapped := make([]string, len(codexArgs))
+	copy(mapped, codexArgs)
+	for i := 0; i < len(mapped); i++ {
+		switch {
+		case (mapped[i] == "-m" || mapped[i] == "--model") && i+1 < len(mapped):
+			if deployment, ok := env.DeploymentMap[mapped[i+1]]; ok {
+				mapped[i+1] = deployment
+			}
+			i++
+		case strings.HasPrefix(mapped[i], "--model="):
+			if deployment, ok := env.DeploymentMap[strings.TrimPrefix(mapped[i], "--model=")]; ok {
+				mapped[i] = "--model=" + deployment
+			}
+		}
+	}
+	return mapped
+}
+
+// resolveModelAlias returns the concrete model for an alias defined on env, or model unchanged
+func resolveModelAlias(env Environment, model string) string {
+	if concrete, ok := env.ModelAliases[model]; ok {
+		return concrete
+	}
+	return model
+}
+
+// applyModelAliases rewrites -m/--model values that name an alias defined on env
+func applyModelAliases(env Environment, codexArgs []string) []string {
+	if len(env.ModelAliases) == 0 {
+		return codexArgs
+	}
+
+	mapped := make([]string, len(codexArgs))
+	copy(mapped, codexArgs)
+	for i := 0; i < len(mapped); i++ {
+		switch {
+		case (mapped[i] == "-m" || mapped[i] == "--model") && i+1 < len(mapped):
+			mapped[i+1] = resolveModelAlias(env, mapped[i+1])
+			i++
+		case strings.HasPrefix(mapped[i], "--model="):
+			mapped[i] = "--model=" + resolveModelAlias(env, strings.TrimPrefix(mapped[i], "--model="))
+		}
+	}
+	return mapped
+}
+
+// providerEnvVars returns variables cde sets for the environment's provider, proxy and headers
+func providerEnvVars(env Environment) map[string]string {
+	vars := proxyEnvVars(env)
+	for _, extra := range []map[string]string{scopingEnvVars(env), headerEnvVars(env), awsEnvVars(env), gcpEnvVars(env)} {
+		for key, value := range extra {
+			if vars == nil {
+				vars = make(map[string]string)
+			}
+			vars[key] = value
+		}
+	}
+	if !isAzureEnvironment(env) {
+		return vars
+	}
+
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	vars["AZURE_OPENAI_API_KEY"] = env.APIKey
+	if env.APIVersion != "" {
+		vars["AZURE_OPENAI_API_VERSION"] = env.APIVersion
+		vars["OPENAI_API_VERSION"] = env.APIVersion
+	}
+	return vars
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinProviderPresets(t *testing.T) {
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	for _, name := range []string{"openai", "azure", "openrouter", "local"} {
+		preset, err := findProviderPreset(name)
+		if err != nil {
+			t.Fatalf("findProviderPreset(%q) failed: %v", name, err)
+		}
+		if len(preset.urlPlaceholders()) == 0 {
+			if err := validateURL(preset.URLTemplate); err != nil {
+				t.Errorf("preset %s has invalid URL: %v", name, err)
+			}
+		}
+	}
+
+	if _, err := findProviderPreset("nope"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+func TestProviderPresetURLTemplate(t *testing.T) {
+	preset := ProviderPreset{Name: "azure", URLTemplate: "https://{resource}.openai.azure.com/openai"}
+	if got := preset.urlPlaceholders(); len(got) != 1 || got[0] != "resource" {
+		t.Fatalf("unexpected placeholders: %v", got)
+	}
+	url := preset.expandURL(map[string]string{"resource": "myres"})
+	if url != "https://myres.openai.azure.com/openai" {
+		t.Errorf("unexpected expanded URL: %s", url)
+	}
+}
+
+func TestProviderPresetKeyWarning(t *testing.T) {
+	preset := ProviderPreset{Name: "openrouter", KeyPrefix: "sk-or-"}
+	if preset.keyFormatWarning("sk-or-abc") != "" {
+		t.Error("expected no warning for matching prefix")
+	}
+	if preset.keyFormatWarning("sk-abc") == "" {
+		t.Error("expected warning for mismatched prefix")
+	}
+}
+
+func TestKeyProviderWarnings(t *testing.T) {
+	tests := []struct {
+		url, key string
+		want     string // Substring of the warning, "" for none
+	}{
+		{url: "https://api.openai.com/v1", key: "sk-ant-api03-abcdef", want: "Anthropic format, but api.openai.com is the OpenAI API"},
+		{url: "https://api.anthropic.com/v1", key: "sk-proj-abcdef", want: "OpenAI format, but api.anthropic.com is the Anthropic API"},
+		{url: "https://res.openai.azure.com/openai", key: "sk-or-v1-abcdef", want: "OpenRouter format"},
+		{url: "https://openrouter.ai/api/v1", key: "abcdef123456", want: "OpenRouter keys usually start with 'sk-or-'"},
+		{url: "https://api.openai.com/v1", key: "sk-abcdef123456"},
+		{url: "https://api.openai.com/v1", key: "sk-proj-abcdef"},
+		{url: "https://res.openai.azure.com/openai", key: "0123456789abcdef"},
+		{url: "https://gateway.example.com/v1", key: "sk-ant-api03-abcdef"},     // Gateways may accept any key
+		{url: "https://api.openai.com/v1", key: "op://vault/openai/credential"}, // References are resolved at launch
+	}
+	for _, tt := range tests {
+		warnings := keyProviderWarnings(tt.url, tt.key)
+		if tt.want == "" {
+			if len(warnings) != 0 {
+				t.Errorf("keyProviderWarnings(%s, %s) = %q, want none", tt.url, tt.key, warnings)
+			}
+			continue
+		}
+		if len(warnings) != 1 || !strings.Contains(warnings[0], tt.want) {
+			t.Errorf("keyProviderWarnings(%s, %s) = %q, want %q", tt.url, tt.key, warnings, tt.want)
+		}
+	}
+}
+
+func TestUserProviderPresets(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".codex-env")
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(dir, "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	data := `[{"name":"gateway","url_template":"https://gw.example.com/v1","env_vars":{"GW_TEAM":""}},
+	          {"name":"openai","url_template":"https://proxy.example.com/v1"}]`
+	if err := ioutil.WriteFile(filepath.Join(dir, "providers.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gateway, err := findProviderPreset("gateway")
+	if err != nil {
+		t.Fatalf("user preset not loaded: %v", err)
+	}
+	if _, ok := gateway.EnvVars["GW_TEAM"]; !ok {
+		t.Error("user preset env vars not loaded")
+	}
+
+	openai, err := findProviderPreset("openai")
+	if err != nil || openai.URLTemplate != "https://proxy.example.com/v1" {
+		t.Errorf("user preset should override builtin, got %+v (%v)", openai, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "providers.json"), []byte(`[{"name":"bad name","url_template":"x"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadProviderPresets(); err == nil {
+		t.Error("expected error for invalid user preset name")
+	}
+}
+
+func TestParseArgumentsAddPreset(t *testing.T) {
+	result := parseArguments([]string{"add", "--preset", "azure"})
+	if result.Error != nil || result.Subcommand != "add" || result.CCEFlags["preset"] != "azure" {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+	if r := parseArguments([]string{"add", "--preset"}); r.Error == nil {
+		t.Error("expected error for --preset without value")
+	}
+}
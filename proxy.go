@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ProxySettings routes an environment's traffic through a proxy
+type ProxySettings struct {
+	URL     string   `json:"url"`                // http://, https://, socks5:// or socks5h:// proxy
+	NoProxy []string `json:"no_proxy,omitempty"` // Hosts, .domains, IPs or CIDRs reached directly
+}
+
+// proxyVarNames are the variables a configured proxy sets for the codex child; both
+// spellings are set because tools disagree on which one takes precedence
+var proxyVarNames = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"}
+
+// noProxyEntryPattern matches one NO_PROXY entry: host, .domain, *, IP or CIDR, optionally with a port
+var noProxyEntryPattern = regexp.MustCompile(`^(\*|\.?[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*(:\d+)?|[0-9A-Fa-f:.\[\]]+(/\d+)?)$`)
+
+// validateProxySettings checks the proxy URL format and no_proxy entries
+func validateProxySettings(proxy *ProxySettings) error {
+	if proxy == nil {
+		return nil
+	}
+
+	parsed, err := url.Parse(proxy.URL)
+	if err != nil || proxy.URL == "" {
+		return fmt.Errorf("invalid proxy URL '%s'", redactProxyURL(proxy.URL))
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme '%s' (use http, https, socks5 or socks5h)", parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("proxy URL '%s' has no host", redactProxyURL(proxy.URL))
+	}
+	if port := parsed.Port(); port == "" && strings.HasPrefix(parsed.Scheme, "socks5") {
+		return fmt.Errorf("SOCKS proxy URL '%s' requires a port", redactProxyURL(proxy.URL))
+	}
+	if (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("proxy URL '%s' must not contain a path, query or fragment", redactProxyURL(proxy.URL))
+	}
+
+	for _, entry := range proxy.NoProxy {
+		if !noProxyEntryPattern.MatchString(entry) {
+			return fmt.Errorf("invalid no_proxy entry '%s'", entry)
+		}
+	}
+	return nil
+}
+
+// redactProxyURL hides the password of a proxy URL for display
+func redactProxyURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.User == nil {
+		return raw
+	}
+	return parsed.Redacted()
+}
+
+// isProxyVar reports whether key is one of the proxy variables cde manages
+func isProxyVar(key string) bool {
+	for _, name := range proxyVarNames {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyEnvVars returns the proxy variables for the codex child, or nil without proxy settings
+func proxyEnvVars(env Environment) map[string]string {
+	if env.Proxy == nil || env.Proxy.URL == "" {
+		return nil
+	}
+
+	vars := make(map[string]string, len(proxyVarNames)+2)
+	for _, name := range proxyVarNames {
+		vars[name] = env.Proxy.URL
+	}
+	if len(env.Proxy.NoProxy) > 0 {
+		noProxy := strings.Join(env.Proxy.NoProxy, ",")
+		vars["NO_PROXY"] = noProxy
+		vars["no_proxy"] = noProxy
+	}
+	return vars
+}
+
+// bypassesProxy reports whether host matches a no_proxy entry
+func bypassesProxy(host string, noProxy []string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.ToLower(strings.Trim(hostname, "[]"))
+	ip := net.ParseIP(hostname)
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(entry)
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.Trim(entry, "[]")
+		domain := strings.TrimPrefix(entry, ".")
+		if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyFuncFor returns the transport proxy function for env; without proxy settings the
+// process environment applies, as for any other Go HTTP client
+func proxyFuncFor(env Environment) func(*http.Request) (*url.URL, error) {
+	if env.Proxy == nil || env.Proxy.URL == "" {
+		return http.ProxyFromEnvironment
+	}
+	proxyURL, err := url.Parse(env.Proxy.URL)
+	noProxy := env.Proxy.NoProxy
+	return func(req *http.Request) (*url.URL, error) {
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		if bypassesProxy(req.URL.Host, noProxy) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// httpClientFor returns an HTTP client that reaches env's endpoint through its proxy
+func httpClientFor(env Environment, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFuncFor(env)
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// rateLimitWindow is the span max_per_hour counts launches over
+const rateLimitWindow = time.Hour
+
+// RateLimitSettings spaces out launches of an environment, e.g. one behind a shared gateway
+type RateLimitSettings struct {
+	MinIntervalSeconds int `json:"min_interval_seconds,omitempty"` // Cooldown after each launch
+	MaxPerHour         int `json:"max_per_hour,omitempty"`         // Launches allowed in any rolling hour
+}
+
+// validateRateLimitSettings checks the limits are not negative
+func validateRateLimitSettings(limit *RateLimitSettings) error {
+	if limit == nil {
+		return nil
+	}
+	if limit.MinIntervalSeconds < 0 || limit.MaxPerHour < 0 {
+		return fmt.Errorf("min_interval_seconds and max_per_hour must not be negative")
+	}
+	return nil
+}
+
+// rateLimited reports whether env has a limit that needs launch timestamps
+func rateLimited(env Environment) bool {
+	return env.RateLimit != nil && (env.RateLimit.MinIntervalSeconds > 0 || env.RateLimit.MaxPerHour > 0)
+}
+
+// recentLaunches returns the launch times within the rate limit window before now, oldest first
+func recentLaunches(launches []time.Time, now time.Time) []time.Time {
+	recent := []time.Time{}
+	for _, launch := range launches {
+		if now.Sub(launch) < rateLimitWindow && !launch.After(now) {
+			recent = append(recent, launch)
+		}
+	}
+	return recent
+}
+
+// rateLimitViolation describes why env may not launch now, or returns "" when it may
+func rateLimitViolation(env Environment, launches []time.Time, now time.Time) string {
+	recent := recentLaunches(launches, now)
+	if len(recent) == 0 {
+		return ""
+	}
+	if interval := time.Duration(env.RateLimit.MinIntervalSeconds) * time.Second; interval > 0 {
+		if since := now.Sub(recent[len(recent)-1]); since < interval {
+			return fmt.Sprintf("environment '%s' was launched %s ago; its rate limit allows one launch every %s (wait %s)",
+				env.Name, since.Round(time.Second), interval, (interval - since).Round(time.Second))
+		}
+	}
+	if max := env.RateLimit.MaxPerHour; max > 0 && len(recent) >= max {
+		wait := rateLimitWindow - now.Sub(recent[len(recent)-max])
+		return fmt.Sprintf("environment '%s' reached its rate limit of %d launches per hour (wait %s)",
+			env.Name, max, wait.Round(time.Second))
+	}
+	return ""
+}
+
+// checkRateLimit refuses a launch of env that would break its rate limit, unless ignore is set,
+// in which case it only warns
+func checkRateLimit(env Environment, ignore bool, now time.Time) error {
+	if !rateLimited(env) {
+		return nil
+	}
+	message := rateLimitViolation(env, loadUsage().Launches[env.Name], now)
+	if message == "" {
+		return nil
+	}
+	if ignore {
+		fmt.Fprintf(os.Stderr, "Warning: %s; launching because of --ignore-rate-limit\n", message)
+		return nil
+	}
+	return withErrorCode(codeRateLimited, fmt.Errorf("launch blocked: %s; rerun with --ignore-rate-limit to launch anyway", message))
+}
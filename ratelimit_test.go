@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimitViolation(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	tests := []struct {
+		name     string
+		limit    RateLimitSettings
+		launches []time.Time
+		blocked  bool
+	}{
+		{"no launches", RateLimitSettings{MinIntervalSeconds: 60, MaxPerHour: 1}, nil, false},
+		{"within cooldown", RateLimitSettings{MinIntervalSeconds: 60}, []time.Time{ago(30 * time.Second)}, true},
+		{"after cooldown", RateLimitSettings{MinIntervalSeconds: 60}, []time.Time{ago(90 * time.Second)}, false},
+		{"hourly cap reached", RateLimitSettings{MaxPerHour: 2}, []time.Time{ago(50 * time.Minute), ago(10 * time.Minute)}, true},
+		{"older launches expired", RateLimitSettings{MaxPerHour: 2}, []time.Time{ago(70 * time.Minute), ago(10 * time.Minute)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := Environment{Name: "gw", RateLimit: &tt.limit}
+			if got := rateLimitViolation(env, tt.launches, now); (got != "") != tt.blocked {
+				t.Errorf("rateLimitViolation() = %q, blocked %v", got, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	env := Environment{Name: "shared", RateLimit: &RateLimitSettings{MinIntervalSeconds: 30, MaxPerHour: 3}}
+
+	for i := 0; i < 3; i++ {
+		launch := now.Add(time.Duration(i) * time.Minute)
+		if err := checkRateLimit(env, false, launch); err != nil {
+			t.Fatalf("launch %d blocked early: %v", i+1, err)
+		}
+		recordLaunchUsage(env, launch)
+	}
+
+	if err := checkRateLimit(env, false, now.Add(2*time.Minute+10*time.Second)); errorCodeOf(err) != codeRateLimited {
+		t.Errorf("launch inside the cooldown: error = %v, want %s", err, codeRateLimited)
+	}
+	if err := checkRateLimit(env, false, now.Add(5*time.Minute)); errorCodeOf(err) != codeRateLimited {
+		t.Errorf("fourth launch in an hour: error = %v, want %s", err, codeRateLimited)
+	}
+	if err := checkRateLimit(env, true, now.Add(5*time.Minute)); err != nil {
+		t.Errorf("--ignore-rate-limit should bypass the limit: %v", err)
+	}
+	if err := checkRateLimit(env, false, now.Add(61*time.Minute)); err != nil {
+		t.Errorf("oldest launch should have left the window: %v", err)
+	}
+
+	// Environments without a rate limit keep no launch times
+	recordLaunchUsage(Environment{Name: "free"}, now.Add(3*time.Minute))
+	if launches := loadUsage().Launches; len(launches["free"]) != 0 || len(launches["shared"]) != 3 {
+		t.Errorf("unexpected launch times: %v", launches)
+	}
+}
@@ -106,8 +106,8 @@ func TestRegressionScenarios(t *testing.T) {
 
 		invalidJSONs := []string{
 			`{invalid json`,
-			`{"environments": [{"name": "test", "url": "https://api.openai.com/v1",}]}`, // trailing comma
-			`{"environments": [{"name": "test" "url": "https://api.openai.com/v1"}]}`,   // missing comma
+			`{"environments": [{"name": "test", "url": "https://api.openai.com/v1",,}]}`, // doubled comma
+			`{"environments": [{"name": "test" "url": "https://api.openai.com/v1"}]}`,    // missing comma
 			// 'null' is treated as minimal config by design; exclude from invalid set
 			`{"environments": "not an array"}`,
 			string([]byte{0xFF, 0xFE, 0xFD}), // binary data
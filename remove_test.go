@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseArgumentsRemoveFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		target   string
+		force    string
+		all      string
+		hasError bool
+	}{
+		{"name only", []string{"remove", "prod"}, "prod", "", "", false},
+		{"force after name", []string{"remove", "prod", "--force"}, "prod", "true", "", false},
+		{"short force first", []string{"remove", "-f", "prod"}, "prod", "true", "", false},
+		{"all with force", []string{"remove", "--all", "--force"}, "", "true", "true", false},
+		{"all with name", []string{"remove", "--all", "prod"}, "", "", "", true},
+		{"unknown flag", []string{"remove", "prod", "--yes"}, "", "", "", true},
+		{"two names", []string{"remove", "a", "b"}, "", "", "", true},
+		{"force without name", []string{"remove", "-f"}, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseArguments(tt.args)
+			if tt.hasError {
+				if result.Error == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+			if result.CCEFlags["remove_target"] != tt.target || result.CCEFlags["force"] != tt.force || result.CCEFlags["all"] != tt.all {
+				t.Errorf("unexpected flags: %v", result.CCEFlags)
+			}
+		})
+	}
+}
+
+func TestRunRemoveAll(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+
+	config := Config{Environments: []Environment{
+		{Name: "a", URL: "https://a.example.com", APIKey: "sk-aaaaaaaaaaaa"},
+		{Name: "b", URL: "https://b.example.com", APIKey: "sk-bbbbbbbbbbbb"},
+	}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := handleCommand([]string{"remove", "--all"}); err == nil {
+		t.Fatal("expected remove --all without --force to fail")
+	}
+	if loaded, _ := loadConfig(); len(loaded.Environments) != 2 {
+		t.Fatalf("environments removed without --force: %d left", len(loaded.Environments))
+	}
+
+	if err := handleCommand([]string{"remove", "--all", "--force"}); err != nil {
+		t.Fatalf("remove --all --force failed: %v", err)
+	}
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(loaded.Environments) != 0 {
+		t.Errorf("expected no environments, got %d", len(loaded.Environments))
+	}
+	if _, err := os.Stat(configPathOverride); err != nil {
+		t.Errorf("config file should still exist: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// describeBackupContents summarizes a backup for the recovery menu
+func describeBackupContents(path string) string {
+	if err := detectCorruption(path); err != nil {
+		return "unusable: " + err.Error()
+	}
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return "unusable: " + err.Error()
+	}
+	return fmt.Sprintf("%d environment(s)", len(doc.Environments))
+}
+
+// runConfigRepair runs the recovery wizard for the active configuration under the config lock
+func runConfigRepair() error {
+	if err := requireWritableConfig(); err != nil {
+		return err
+	}
+	configPath, err := getConfigPath()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	release, err := acquireConfigLock(configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return repairConfiguration(configPath)
+}
+
+// repairConfiguration shows what can be recovered from a corrupted configuration: the
+// environments that still parse and the available backups. Nothing is written without an
+// explicit choice on a terminal, and replacing the file with an empty configuration must be
+// confirmed; the damaged file is backed up before it is replaced.
+func repairConfiguration(configPath string) error {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		fmt.Printf("No configuration at %s; nothing to repair.\n", configPath)
+		return nil
+	}
+	problem := detectCorruption(configPath)
+	if problem == nil {
+		fmt.Printf("%s is valid; nothing to repair.\n", configPath)
+		return nil
+	}
+	fmt.Printf("Configuration problem: %v\n", problem)
+
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("configuration file read failed: %w", err)
+	}
+	salvaged, skipped := salvageEnvironments(data)
+	fmt.Printf("\nRecoverable from %s: %d environment(s)\n", configPath, len(salvaged))
+	for _, env := range salvaged {
+		fmt.Printf("  %s (%s)\n", env.Name, env.URL)
+	}
+	for _, note := range skipped {
+		fmt.Printf("  skipped %s\n", note)
+	}
+
+	backup := newConfigBackup(configPath)
+	backups, err := backup.listBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		fmt.Println("\nBackups (newest first):")
+		for i := len(backups) - 1; i >= 0; i-- {
+			fmt.Printf("  [%d] %s  %s  %s\n", len(backups)-i, backups[i].ID, backups[i].Created.Format("2006-01-02 15:04:05"), describeBackupContents(backups[i].Path))
+		}
+	} else {
+		fmt.Println("\nNo backups found.")
+	}
+
+	if !confirmationPromptsAllowed() {
+		return fmt.Errorf("config repair needs a terminal to choose a recovery; %s was left unchanged", configPath)
+	}
+
+	choices := []string{}
+	if len(backups) > 0 {
+		choices = append(choices, trf("1-%d restore a backup", len(backups)))
+	}
+	if len(salvaged) > 0 {
+		choices = append(choices, tr("[s]ave the recoverable environments"))
+	}
+	choices = append(choices, tr("[m]inimal empty config"), tr("[q]uit"))
+	for {
+		answer, err := regularInput(trf("\nChoose: %s [q]: ", strings.Join(choices, ", ")))
+		if err != nil {
+			return fmt.Errorf("failed to read choice: %w", err)
+		}
+
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		switch {
+		case answer == "" || answer == "q":
+			fmt.Printf("%s was left unchanged.\n", configPath)
+			return nil
+		case answer == "s" && len(salvaged) > 0:
+			if err := backupCorruptConfig(backup); err != nil {
+				return err
+			}
+			if err := saveConfigDirect(Config{Environments: salvaged}, configPath); err != nil {
+				return fmt.Errorf("configuration save failed: %w", err)
+			}
+			fmt.Printf("Saved %d recovered environment(s) to %s.\n", len(salvaged), configPath)
+			return nil
+		case answer == "m":
+			confirm, err := regularInput(trf("This replaces %s with an empty environment list. Type 'yes' to continue: ", configPath))
+			if err != nil {
+				return fmt.Errorf("failed to get confirmation: %w", err)
+			}
+			if confirm != "yes" {
+				fmt.Printf("%s was left unchanged.\n", configPath)
+				return nil
+			}
+			if err := backupCorruptConfig(backup); err != nil {
+				return err
+			}
+			if err := saveConfigDirect(Config{Environments: []Environment{}}, configPath); err != nil {
+				return fmt.Errorf("configuration save failed: %w", err)
+			}
+			fmt.Printf("Wrote an empty configuration to %s.\n", configPath)
+			return nil
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(backups) {
+			chosen := backups[len(backups)-n]
+			if err := detectCorruption(chosen.Path); err != nil {
+				fmt.Printf("Backup %s is not usable: %v\n", chosen.ID, err)
+				continue
+			}
+			if err := backupCorruptConfig(backup); err != nil {
+				return err
+			}
+			if err := copyFile(chosen.Path, configPath); err != nil {
+				return fmt.Errorf("failed to restore backup %s: %w", chosen.ID, err)
+			}
+			fmt.Printf("Configuration restored from backup %s.\n", chosen.ID)
+			return nil
+		}
+		fmt.Println("Invalid choice.")
+	}
+}
+
+// backupCorruptConfig keeps the damaged file as pre-repair-<timestamp>.json before it is replaced
+func backupCorruptConfig(backup *configBackup) error {
+	backupPath, err := backup.createLabeledBackup("repair")
+	if err != nil {
+		return fmt.Errorf("failed to back up the damaged configuration: %w", err)
+	}
+	fmt.Printf("Damaged configuration backed up to: %s\n", backupPath)
+	return nil
+}
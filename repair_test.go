@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const truncatedConfig = `{
+  "environments": [
+    {"name": "prod", "url": "https://api.openai.com/v1", "api_key": "sk-prod-1234567890"},
+    {"name": "bad", "url": "not-a-url", "api_key": "sk-bad-1234567890"},
+    {"name": "dev", "url": "http://localhost:8080/v1", "api_key": "sk-dev-1234567890"},
+    {"name": "cut", "url": "https://cut.exa`
+
+func TestRepairConfiguration(t *testing.T) {
+	setup := func(t *testing.T) (string, *configBackup) {
+		configPath := filepath.Join(t.TempDir(), "config.json")
+		if err := ioutil.WriteFile(configPath, []byte(truncatedConfig), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cb := newConfigBackup(configPath)
+		os.MkdirAll(cb.backupDir, 0700)
+		ioutil.WriteFile(filepath.Join(cb.backupDir, "config-20250101-120000.json"), []byte(`{"environments": [{"name": "old", "url": "https://old.example.com", "api_key": "sk-old-1234567890"}]}`), 0600)
+		return configPath, cb
+	}
+	original := confirmationPromptsAllowed
+	defer func() { confirmationPromptsAllowed = original }()
+
+	t.Run("non-interactive leaves the file alone", func(t *testing.T) {
+		confirmationPromptsAllowed = func() bool { return false }
+		configPath, _ := setup(t)
+		if err := repairConfiguration(configPath); err == nil {
+			t.Error("expected an error without a terminal")
+		}
+		if data, _ := ioutil.ReadFile(configPath); string(data) != truncatedConfig {
+			t.Error("config changed without confirmation")
+		}
+	})
+
+	confirmationPromptsAllowed = func() bool { return true }
+	tests := []struct {
+		name      string
+		input     string
+		wantNames []string // nil: file unchanged
+	}{
+		{name: "quit", input: "\n"},
+		{name: "minimal without confirmation", input: "m\n"},
+		{name: "salvage", input: "s\n", wantNames: []string{"prod", "dev"}},
+		{name: "restore backup", input: "1\n", wantNames: []string{"old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath, cb := setup(t)
+			withStdin(t, tt.input)
+			repairConfiguration(configPath)
+
+			data, _ := ioutil.ReadFile(configPath)
+			if tt.wantNames == nil {
+				if string(data) != truncatedConfig {
+					t.Errorf("config changed: %s", data)
+				}
+				return
+			}
+			doc, err := loadConfigDocument(configPath)
+			if err != nil {
+				t.Fatalf("repaired config does not parse: %v", err)
+			}
+			var names []string
+			for _, env := range doc.Environments {
+				names = append(names, env["name"].(string))
+			}
+			if len(names) != len(tt.wantNames) || names[0] != tt.wantNames[0] {
+				t.Errorf("repaired environments = %v, want %v", names, tt.wantNames)
+			}
+			backups, _ := cb.listBackups()
+			if len(backups) != 2 || string(mustRead(t, backups[1].Path)) != truncatedConfig {
+				t.Error("damaged file was not backed up before repair")
+			}
+		})
+	}
+}
+
+// mustRead returns the contents of path or fails the test
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// SecuritySettings controls commands that expose stored secrets
+type SecuritySettings struct {
+	AllowReveal       *bool  `json:"allow_reveal,omitempty"`        // Permit 'cde show-key' (default true)
+	RevealAuthCommand string `json:"reveal_auth_command,omitempty"` // Must succeed before a key is shown, e.g. "sudo -v"
+}
+
+// revealAllowed reports whether settings.security.allow_reveal permits printing full keys
+func revealAllowed(config Config) bool {
+	if config.Settings == nil || config.Settings.Security == nil || config.Settings.Security.AllowReveal == nil {
+		return true
+	}
+	return *config.Settings.Security.AllowReveal
+}
+
+// revealAuthCommand returns the configured re-authentication command, or ""
+func revealAuthCommand(config Config) string {
+	if config.Settings == nil || config.Settings.Security == nil {
+		return ""
+	}
+	return config.Settings.Security.RevealAuthCommand
+}
+
+// runRevealAuth runs the re-authentication command attached to the terminal so it can prompt
+// (for a password, Touch ID or a keychain unlock)
+func runRevealAuth(command string) error {
+	name, args := hookShell(command)
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runShowKey prints the full API key of an environment after the user types its name to
+// confirm. References are resolved; environments that obtain keys through auth have none.
+func runShowKey(name, keyName string) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("invalid environment name: %w", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	if !revealAllowed(config) {
+		return fmt.Errorf("show-key is disabled by settings.security.allow_reveal")
+	}
+
+	index, exists := findEnvironmentByName(config, name)
+	if !exists {
+		return fmt.Errorf("environment '%s' not found", name)
+	}
+	env := config.Environments[index]
+	if keyName != "" {
+		if env, err = selectAPIKey(env, keyName); err != nil {
+			return err
+		}
+	}
+	if env.APIKey == "" {
+		return fmt.Errorf("environment '%s' has no stored API key", name)
+	}
+
+	if !confirmationPromptsAllowed() {
+		return fmt.Errorf("show-key needs a terminal to confirm")
+	}
+	fmt.Printf("This prints the full API key of '%s' (%s) to the terminal.\n", name, maskAPIKey(env.APIKey))
+	answer, err := regularInput(tr("Type the environment name to continue: "))
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	if answer != name {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if command := revealAuthCommand(config); command != "" {
+		if err := runRevealAuth(command); err != nil {
+			return fmt.Errorf("re-authentication failed: %w", err)
+		}
+	}
+
+	key, err := resolveSecret(env.APIKey)
+	if err != nil {
+		return withErrorCode(codeSecretResolution, fmt.Errorf("API key resolution failed: %w", err))
+	}
+	fmt.Println(key)
+	return nil
+}
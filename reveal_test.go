@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunShowKey(t *testing.T) {
+	originalPath := configPathOverride
+	originalPrompts := confirmationPromptsAllowed
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() {
+		configPathOverride = originalPath
+		confirmationPromptsAllowed = originalPrompts
+	}()
+
+	disabled, enabled := false, true
+	env := Environment{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-1234567890", Keys: map[string]string{"backup": "sk-backup-1234567890"}}
+	tests := []struct {
+		name        string
+		security    *SecuritySettings
+		interactive bool
+		keyName     string
+		input       string
+		want        string // "" expects no key in the output
+		wantErr     bool
+	}{
+		{name: "confirmed", interactive: true, input: "prod\n", want: "sk-prod-1234567890"},
+		{name: "named key", interactive: true, keyName: "backup", input: "prod\n", want: "sk-backup-1234567890"},
+		{name: "wrong confirmation", interactive: true, input: "yes\n"},
+		{name: "no terminal", input: "prod\n", wantErr: true},
+		{name: "unknown key", interactive: true, keyName: "other", input: "prod\n", wantErr: true},
+		{name: "disabled", security: &SecuritySettings{AllowReveal: &disabled}, interactive: true, input: "prod\n", wantErr: true},
+		{name: "re-auth succeeds", security: &SecuritySettings{AllowReveal: &enabled, RevealAuthCommand: "exit 0"}, interactive: true, input: "prod\n", want: "sk-prod-1234567890"},
+		{name: "re-auth fails", security: &SecuritySettings{RevealAuthCommand: "exit 1"}, interactive: true, input: "prod\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := saveConfig(Config{Environments: []Environment{env}, Settings: &ConfigSettings{Security: tt.security}}); err != nil {
+				t.Fatal(err)
+			}
+			confirmationPromptsAllowed = func() bool { return tt.interactive }
+			withStdin(t, tt.input)
+
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("pipe failed: %v", err)
+			}
+			oldStdout := os.Stdout
+			os.Stdout = writer
+			runErr := runShowKey("prod", tt.keyName)
+			writer.Close()
+			os.Stdout = oldStdout
+			output, _ := ioutil.ReadAll(reader)
+
+			if (runErr != nil) != tt.wantErr {
+				t.Fatalf("runShowKey() error = %v, wantErr %v", runErr, tt.wantErr)
+			}
+			if tt.want == "" {
+				for _, key := range []string{env.APIKey, env.Keys["backup"]} {
+					if strings.Contains(string(output), key) {
+						t.Errorf("key revealed without confirmation:\n%s", output)
+					}
+				}
+				return
+			}
+			if !strings.HasSuffix(string(output), tt.want+"\n") {
+				t.Errorf("output = %q, want key %s", output, tt.want)
+			}
+		})
+	}
+
+	result := parseArguments([]string{"show-key", "prod", "--key", "backup"})
+	if result.Error != nil || result.Subcommand != "show-key" || result.CCEFlags["env"] != "prod" || result.CCEFlags["key"] != "backup" {
+		t.Errorf("show-key parse = %+v", result)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// salvageFrame is an open object or array seen by the salvage scanner
+type salvageFrame struct {
+	open  byte   // '{' or '['
+	key   string // Key the container is the value of, "" for array elements and the root
+	start int
+}
+
+// salvageEnvironments scans a damaged configuration for syntactically complete environment
+// objects and returns the valid ones in file order, with a note for each environment-like
+// object that was skipped. It tolerates truncation, garbage between objects and unbalanced
+// brackets: every balanced {...} with a "name" is tried on its own. Objects inside "trash"
+// are ignored so removed environments do not come back.
+func salvageEnvironments(data []byte) ([]Environment, []string) {
+	data = stripJSONC(data)
+
+	salvaged := []Environment{}
+	skipped := []string{}
+	seen := map[string]bool{}
+	var stack []salvageFrame
+	lastString, pendingKey := "", ""
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '"':
+			// Skip the string, honoring escapes; an unterminated string ends the scan
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(data) {
+				return salvaged, skipped
+			}
+			json.Unmarshal(data[i:end+1], &lastString)
+			i = end
+		case ':':
+			pendingKey = lastString
+		case ',':
+			pendingKey = ""
+		case '{', '[':
+			stack = append(stack, salvageFrame{open: c, key: pendingKey, start: i})
+			pendingKey = ""
+		case '}', ']':
+			// Unbalanced input: close the nearest matching container, dropping the rest
+			want := byte('{')
+			if c == ']' {
+				want = '['
+			}
+			top := len(stack) - 1
+			for top >= 0 && stack[top].open != want {
+				top--
+			}
+			if top < 0 {
+				continue
+			}
+			frame := stack[top]
+			stack = stack[:top]
+			pendingKey = ""
+			if c != '}' || insideTrash(stack) {
+				continue
+			}
+
+			var probe map[string]json.RawMessage
+			if json.Unmarshal(data[frame.start:i+1], &probe) != nil || probe["name"] == nil || frame.key != "" {
+				continue
+			}
+			var env Environment
+			if err := json.Unmarshal(data[frame.start:i+1], &env); err != nil {
+				skipped = append(skipped, fmt.Sprintf("object at offset %d: %v", frame.start, err))
+				continue
+			}
+			switch err := validateEnvironment(env); {
+			case err != nil:
+				skipped = append(skipped, fmt.Sprintf("%s: %v", env.Name, err))
+			case seen[env.Name]:
+				skipped = append(skipped, fmt.Sprintf("%s: duplicate name", env.Name))
+			default:
+				seen[env.Name] = true
+				salvaged = append(salvaged, env)
+			}
+		}
+	}
+	return salvaged, skipped
+}
+
+// insideTrash reports whether the open containers include the trash section
+func insideTrash(stack []salvageFrame) bool {
+	for _, frame := range stack {
+		if frame.key == "trash" {
+			return true
+		}
+	}
+	return false
+}
+
+// salvageHint tells the user how many environments 'cde config repair' can recover from data
+func salvageHint(data []byte) string {
+	salvaged, _ := salvageEnvironments(data)
+	if len(salvaged) == 0 {
+		return "\nrun 'cde config repair' to pick a backup"
+	}
+	return fmt.Sprintf("\nrun 'cde config repair' to recover %d environment(s) from the file or pick a backup", len(salvaged))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSalvageEnvironments(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantNames   []string
+		wantSkipped int
+	}{
+		{
+			name:        "truncated",
+			data:        truncatedConfig,
+			wantNames:   []string{"prod", "dev"},
+			wantSkipped: 1, // "bad" has an invalid URL
+		},
+		{
+			name: "garbage between objects",
+			data: `{"environments": [
+  {"name": "a", "url": "https://a.example.com", "api_key": "sk-a-1234567890"},
+  @@@ <<<<<<< HEAD
+  {"name": "b", "url": "https://b.example.com", "api_key": "sk-b-1234567890", "env_vars": {"X": "1"}}
+]}`,
+			wantNames: []string{"a", "b"},
+		},
+		{
+			name: "garbled object is dropped, neighbours kept",
+			data: `{"environments": [
+  {"name": "a", "url": "https://a.example.com", "api_key": "sk-a-1234567890"},
+  {"name": "broken", "url": "https://x.example.com",, "api_key": "sk-x-1234567890"},
+  {"name": "c", "url": "https://c.example.com", "api_key": "sk-c-1234567890"}
+]`,
+			wantNames: []string{"a", "c"},
+		},
+		{
+			name: "missing closing brace",
+			data: `{"environments": [
+  {"name": "a", "url": "https://a.example.com", "api_key": "sk-a-1234567890",
+  {"name": "b", "url": "https://b.example.com", "api_key": "sk-b-1234567890"}
+]}`,
+			wantNames: []string{"b"},
+		},
+		{
+			name: "comments, braces in strings and duplicates",
+			data: `{
+  // production
+  "environments": [
+    {"name": "a", "url": "https://a.example.com", "api_key": "sk-{not-json}-1234567890"},
+    {"name": "a", "url": "https://a2.example.com", "api_key": "sk-a2-1234567890"},
+  ],
+  "trash": [{"environment": {"name": "old", "url": "https://old.example.com", "api_key": "sk-old-1234567890"}}],
+  "settings": {"theme": `,
+			wantNames:   []string{"a"},
+			wantSkipped: 1,
+		},
+		{
+			name:      "garbage",
+			data:      "\x00\x01 not json at all }]{",
+			wantNames: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			salvaged, skipped := salvageEnvironments([]byte(tt.data))
+			names := []string{}
+			for _, env := range salvaged {
+				names = append(names, env.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("salvaged %v, want %v", names, tt.wantNames)
+			}
+			if len(skipped) != tt.wantSkipped {
+				t.Errorf("skipped %q, want %d note(s)", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestSalvageHintInErrors(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPathOverride = original }()
+
+	if err := ioutil.WriteFile(configPathOverride, []byte(truncatedConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	want := "run 'cde config repair' to recover 2 environment(s)"
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("loadConfig() error = %v, want %q", err, want)
+	}
+	if err := runConfigValidate(""); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("runConfigValidate() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// quietExitError ends cde with a status code and no error message, like grep without matches
+type quietExitError struct {
+	Code int
+}
+
+func (e *quietExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// environmentMatcher reports whether a field value matches a search pattern
+type environmentMatcher func(value string) bool
+
+// newEnvironmentMatcher matches case-insensitively, as a regular expression when pattern is one
+// and as a plain substring otherwise (so "gpt-4(" still works)
+func newEnvironmentMatcher(pattern string) environmentMatcher {
+	if re, err := regexp.Compile("(?i)" + pattern); err == nil {
+		return re.MatchString
+	}
+	lowered := strings.ToLower(pattern)
+	return func(value string) bool { return strings.Contains(strings.ToLower(value), lowered) }
+}
+
+// searchableFields returns the values 'cde search' looks at: name, URLs, model, provider and
+// env var names (never values, which may be secrets)
+func searchableFields(env Environment) []string {
+	fields := append([]string{env.Name, env.URL, env.Model, env.Provider}, env.URLs...)
+	for key := range env.EnvVars {
+		fields = append(fields, key)
+	}
+	return fields
+}
+
+// searchEnvironments returns the environments with at least one matching field, in config order
+func searchEnvironments(envs []Environment, match environmentMatcher) []Environment {
+	matches := []Environment{}
+	for _, env := range envs {
+		for _, field := range searchableFields(env) {
+			if field != "" && match(field) {
+				matches = append(matches, env)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// runSearch prints the environments matching pattern in the list format; no match exits 1
+func runSearch(pattern string, opts listOptions) error {
+	if err := validateListOptions(opts); err != nil {
+		return fmt.Errorf("argument validation failed: %w", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	config.Environments = searchEnvironments(config.Environments, newEnvironmentMatcher(pattern))
+	if len(config.Environments) == 0 {
+		fmt.Fprintf(os.Stderr, "No environments match '%s'.\n", pattern)
+		return &quietExitError{Code: 1}
+	}
+	return displayEnvironmentList(config, opts)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearchEnvironments(t *testing.T) {
+	envs := []Environment{
+		{Name: "prod", URL: "https://api.openai.com/v1", Model: "gpt-5", EnvVars: map[string]string{"OPENAI_ORG": "org-secret"}},
+		{Name: "azure-eu", URL: "https://eu.openai.azure.com/openai", Provider: "azure", Model: "gpt-4.1"},
+		{Name: "local", URL: "http://localhost:8080/v1", URLs: []string{"http://127.0.0.1:8081/v1"}},
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "PROD", want: []string{"prod"}},
+		{pattern: "openai", want: []string{"prod", "azure-eu"}},
+		{pattern: "^gpt-(5|4\\.1)$", want: []string{"prod", "azure-eu"}},
+		{pattern: "gpt-4(", want: []string{}},
+		{pattern: "8081", want: []string{"local"}},
+		{pattern: "OPENAI_ORG", want: []string{"prod"}},
+		{pattern: "org-secret", want: []string{}},
+		{pattern: "azure", want: []string{"azure-eu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			names := []string{}
+			for _, env := range searchEnvironments(envs, newEnvironmentMatcher(tt.pattern)) {
+				names = append(names, env.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("search %q = %v, want %v", tt.pattern, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSearchExitStatus(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPathOverride = original }()
+
+	if err := saveConfig(Config{Environments: []Environment{{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-1234567890"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSearch("prod", listOptions{Quiet: true}); err != nil {
+		t.Errorf("runSearch() with a match error = %v", err)
+	}
+	var quietExit *quietExitError
+	if err := runSearch("nothing-here", listOptions{}); !errors.As(err, &quietExit) || quietExit.Code != 1 {
+		t.Errorf("runSearch() without a match error = %v, want exit status 1", err)
+	}
+
+	result := parseArguments([]string{"search", "gpt", "-q"})
+	if result.Error != nil || result.Subcommand != "search" || result.CCEFlags["pattern"] != "gpt" || result.CCEFlags["quiet"] != "true" {
+		t.Errorf("search parse = %+v", result)
+	}
+}
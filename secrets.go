@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretResolveTimeout bounds a single secret store lookup
+const secretResolveTimeout = 15 * time.Second
+
+// secretProvider resolves references of one scheme (e.g. op://) to secret values
+type secretProvider interface {
+	resolve(ref string) (string, error)
+}
+
+// secretProviders maps reference schemes to their providers; add new stores here
+var secretProviders = map[string]secretProvider{
+	"op":    onePasswordProvider{},
+	"vault": vaultProvider{},
+}
+
+// secretCache keeps resolved values in memory for the lifetime of the process only
+var secretCache = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// secretReferenceScheme returns the provider scheme of value, or "" for a literal key
+func secretReferenceScheme(value string) string {
+	scheme, _, found := strings.Cut(value, "://")
+	if !found {
+		return ""
+	}
+	if _, exists := secretProviders[scheme]; !exists {
+		return ""
+	}
+	return scheme
+}
+
+// resolveSecret returns the secret a reference points to; literal values are returned unchanged
+func resolveSecret(value string) (string, error) {
+	scheme := secretReferenceScheme(value)
+	if scheme == "" {
+		return value, nil
+	}
+
+	secretCache.Lock()
+	defer secretCache.Unlock()
+	if cached, exists := secretCache.values[value]; exists {
+		return cached, nil
+	}
+
+	secret, err := secretProviders[scheme].resolve(value)
+	if err != nil {
+		return "", fmt.Errorf("secret resolution failed for %s: %w", value, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("secret resolution failed for %s: empty value", value)
+	}
+	if err := validateAPIKey(secret); err != nil {
+		return "", fmt.Errorf("secret resolution failed for %s: %w", value, err)
+	}
+	secretCache.values[value] = secret
+	return secret, nil
+}
+
+// resolveEnvironmentSecrets replaces a secret reference in env.APIKey with its value, or
+// with an OAuth or gcloud access token when the environment has an auth block. AWS
+// environments have their credentials verified (or a role assumed) instead.
+func resolveEnvironmentSecrets(env Environment) (Environment, error) {
+	if usesAWSAuth(env) || usesGCPAuth(env) {
+		resolve := resolveAWSCredentials
+		if usesGCPAuth(env) {
+			resolve = resolveGCPToken
+		}
+		resolved, err := resolve(env)
+		if err != nil {
+			return env, withErrorCode(codeAuthFailed, err)
+		}
+		return resolved, nil
+	}
+	if env.Auth != nil {
+		token, err := acquireAuthToken(env)
+		if err != nil {
+			return env, withErrorCode(codeAuthFailed, err)
+		}
+		env.APIKey = token
+		return env, nil
+	}
+	apiKey, err := resolveSecret(env.APIKey)
+	if err != nil {
+		return env, err
+	}
+	env.APIKey = apiKey
+	return env, nil
+}
+
+// runSecretCommand runs an external secret CLI; tests replace it
+var runSecretCommand = func(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if !stdinPiped() {
+		cmd.Stdin = os.Stdin // op may prompt for biometric or account unlock; piped input is left for codex
+	}
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// onePasswordProvider resolves op://vault/item/field with the 1Password CLI
+type onePasswordProvider struct{}
+
+func (onePasswordProvider) resolve(ref string) (string, error) {
+	if _, err := exec.LookPath("op"); err != nil {
+		return "", fmt.Errorf("1Password CLI 'op' not found in PATH")
+	}
+	output, err := runSecretCommand("op", "read", "--no-newline", ref)
+	if err != nil {
+		return "", fmt.Errorf("op read failed: %w", err)
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// vaultProvider resolves vault://path#key through Vault's HTTP API using VAULT_ADDR and VAULT_TOKEN
+type vaultProvider struct {
+	client *http.Client // nil uses a client with secretResolveTimeout
+}
+
+// parseVaultReference splits vault://secret/path#key into the API path and field
+func parseVaultReference(ref string) (string, string, error) {
+	rest := strings.TrimPrefix(ref, "vault://")
+	path, key, found := strings.Cut(rest, "#")
+	path = strings.Trim(path, "/")
+	if !found || path == "" || key == "" {
+		return "", "", fmt.Errorf("invalid vault reference (expected vault://<path>#<key>)")
+	}
+	return path, key, nil
+}
+
+func (p vaultProvider) resolve(ref string) (string, error) {
+	path, key, err := parseVaultReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+	if _, err := url.Parse(addr); err != nil {
+		return "", fmt.Errorf("invalid VAULT_ADDR: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := p.client
+	if client == nil {
+		client = &http.Client{Timeout: secretResolveTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault request failed: unexpected status %s", resp.Status)
+	}
+
+	// KV v1 returns {"data": {...}}; KV v2 nests the secret as {"data": {"data": {...}}}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault response parsing failed: %w", err)
+	}
+	fields := body.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := fields["metadata"]; hasMetadata {
+			fields = nested
+		}
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found at %s", key, path)
+	}
+	return value, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeSecretProvider counts lookups to verify caching
+type fakeSecretProvider struct {
+	values map[string]string
+	calls  *int
+}
+
+func (p fakeSecretProvider) resolve(ref string) (string, error) {
+	*p.calls++
+	return p.values[ref], nil
+}
+
+func TestResolveSecret(t *testing.T) {
+	calls := 0
+	oldProviders := secretProviders
+	secretProviders = map[string]secretProvider{
+		"fake": fakeSecretProvider{values: map[string]string{"fake://team/key": "sk-resolved"}, calls: &calls},
+	}
+	defer func() { secretProviders = oldProviders }()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"literal key", "sk-literal", "sk-literal", false},
+		{"unknown scheme is literal", "other://x", "other://x", false},
+		{"reference", "fake://team/key", "sk-resolved", false},
+		{"empty secret", "fake://team/missing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSecret(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	calls = 0
+	for i := 0; i < 3; i++ {
+		if _, err := resolveSecret("fake://team/key"); err != nil {
+			t.Fatalf("resolveSecret failed: %v", err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("cached reference resolved %d more times", calls)
+	}
+}
+
+func TestVaultProviderResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/codex":
+			w.Write([]byte(`{"data":{"data":{"api_key":"sk-kv2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/codex":
+			w.Write([]byte(`{"data":{"api_key":"sk-kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"vault://secret/data/codex#api_key", "sk-kv2", false},
+		{"vault://kv/codex#api_key", "sk-kv1", false},
+		{"vault://kv/codex#missing", "", true},
+		{"vault://kv/missing#api_key", "", true},
+		{"vault://kv/codex", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := vaultProvider{}.resolve(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnePasswordProviderUsesOpRead(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake op script requires a POSIX shell")
+	}
+
+	// Fake op CLI that echoes back the reference it was asked to read
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1\" = read ] && [ \"$2\" = --no-newline ] && printf 'sk-%s' \"$3\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "op"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake op: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	got, err := onePasswordProvider{}.resolve("op://vault/item/field")
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if got != "sk-op://vault/item/field" {
+		t.Errorf("resolve() = %q", got)
+	}
+	if maskAPIKey("op://vault/item/field") != "op://vault/item/field" {
+		t.Error("secret references should be displayed unmasked")
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// The self-test runs cde end to end against a fake codex placed first on PATH in a temporary
+// directory. The fake records its arguments and environment instead of running an agent.
+// 'cde doctor --selftest' writes a small script as the fake (installFakeCodexScript); the tests
+// use the test binary instead (see e2e_test.go). cde itself never acts as codex.
+const (
+	fakeCodexReportEnv = "CDE_FAKE_CODEX_REPORT" // File the fake codex writes its report to
+	fakeCodexExitEnv   = "CDE_FAKE_CODEX_EXIT"   // Exit status the fake codex returns
+	fakeCodexVersion   = "0.20.0"                // Version the fake codex reports
+)
+
+// fakeCodexScript is the POSIX fake codex. Its report has one "arg=" line per argument and
+// one "env=" line per variable.
+const fakeCodexScript = `#!/bin/sh
+if [ "$#" -eq 1 ] && [ "$1" = --version ]; then
+	echo "codex-cli ` + fakeCodexVersion + `"
+	exit 0
+fi
+{
+	for arg in "$@"; do printf 'arg=%s\n' "$arg"; done
+	env | sed 's/^/env=/'
+} > "$` + fakeCodexReportEnv + `"
+exit "${` + fakeCodexExitEnv + `:-0}"
+`
+
+// fakeCodexBatch is fakeCodexScript for Windows, where codex is found as codex.cmd
+const fakeCodexBatch = "@echo off\r\n" +
+	"if \"%~1\"==\"--version\" if \"%~2\"==\"\" (echo codex-cli " + fakeCodexVersion + "& exit /b 0)\r\n" +
+	"type nul > \"%" + fakeCodexReportEnv + "%\"\r\n" +
+	":args\r\n" +
+	"if \"%~1\"==\"\" goto env\r\n" +
+	"echo arg=%~1>> \"%" + fakeCodexReportEnv + "%\"\r\n" +
+	"shift\r\n" +
+	"goto args\r\n" +
+	":env\r\n" +
+	"for /f \"delims=\" %%e in ('set') do echo env=%%e>> \"%" + fakeCodexReportEnv + "%\"\r\n" +
+	"if \"%" + fakeCodexExitEnv + "%\"==\"\" exit /b 0\r\n" +
+	"exit /b %" + fakeCodexExitEnv + "%\r\n"
+
+// fakeCodexReport is what the fake codex was started with
+type fakeCodexReport struct {
+	Args []string
+	Env  map[string]string
+}
+
+// parseFakeCodexReport reads the "arg=" and "env=" lines a fake codex wrote. Lines without
+// either prefix continue a multi-line variable and are skipped.
+func parseFakeCodexReport(data []byte) *fakeCodexReport {
+	report := &fakeCodexReport{Args: []string{}, Env: make(map[string]string)}
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if arg, ok := strings.CutPrefix(line, "arg="); ok {
+			report.Args = append(report.Args, arg)
+		} else if pair, ok := strings.CutPrefix(line, "env="); ok {
+			if key, value, found := strings.Cut(pair, "="); found {
+				report.Env[key] = value
+			}
+		}
+	}
+	return report
+}
+
+// installFakeCodexScript writes the script fake codex into bin
+func installFakeCodexScript(bin string) error {
+	path, script := filepath.Join(bin, defaultTargetCommand), fakeCodexScript
+	if runtime.GOOS == "windows" {
+		path, script = path+".cmd", fakeCodexBatch
+	}
+	return ioutil.WriteFile(path, []byte(script), 0755)
+}
+
+// selfTestEnvironment is the environment every self-test launch selects
+var selfTestEnvironment = Environment{
+	Name:    "selftest",
+	URL:     "https://selftest.invalid/v1",
+	APIKey:  "sk-selftest-0123456789abcdef",
+	Model:   "gpt-selftest",
+	EnvVars: map[string]string{"CDE_SELFTEST_VAR": "exported"},
+}
+
+// selfTestHarness runs a cde executable against a fake codex in a scratch directory
+type selfTestHarness struct {
+	cde    string   // cde executable to run
+	extra  []string // Extra variables for cde, e.g. to make a test binary act as cde
+	dir    string
+	bin    string // Holds the fake codex; first on PATH
+	config string
+}
+
+// newSelfTestHarness puts a fake codex into dir/bin with installCodex and writes a config
+// holding selfTestEnvironment. HOME points at dir too, so no user state is read or written.
+func newSelfTestHarness(cde, dir string, installCodex func(bin string) error, extra ...string) (*selfTestHarness, error) {
+	h := &selfTestHarness{cde: cde, extra: extra, dir: dir, bin: filepath.Join(dir, "bin"), config: filepath.Join(dir, "config.json")}
+	if err := os.MkdirAll(h.bin, 0700); err != nil {
+		return nil, fmt.Errorf("self-test setup failed: %w", err)
+	}
+	if err := installCodex(h.bin); err != nil {
+		return nil, fmt.Errorf("self-test setup failed: %w", err)
+	}
+
+	data, err := json.MarshalIndent(Config{Environments: []Environment{selfTestEnvironment}}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("self-test setup failed: %w", err)
+	}
+	if err := ioutil.WriteFile(h.config, data, 0600); err != nil {
+		return nil, fmt.Errorf("self-test setup failed: %w", err)
+	}
+	return h, nil
+}
+
+// environ is the variables cde runs with: the caller's, minus anything that would change the
+// launch (OPENAI_*, CDE_*), with the fake codex first on PATH and HOME in the scratch directory
+func (h *selfTestHarness) environ(reportPath string, exitCode int) []string {
+	env := []string{}
+	for _, pair := range os.Environ() {
+		key, _, _ := strings.Cut(pair, "=")
+		switch {
+		case strings.HasPrefix(key, "OPENAI_"), strings.HasPrefix(key, "CDE_"):
+		case key == "PATH", key == "HOME", key == "USERPROFILE":
+		default:
+			env = append(env, pair)
+		}
+	}
+	env = append(env,
+		"PATH="+h.bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"HOME="+h.dir,
+		"USERPROFILE="+h.dir,
+		"CDE_OFFLINE=1",
+		fakeCodexReportEnv+"="+reportPath,
+		fakeCodexExitEnv+"="+strconv.Itoa(exitCode),
+	)
+	return append(env, h.extra...)
+}
+
+// run starts cde with args against the fake codex, which exits with exitCode. It returns what
+// codex was started with (nil when cde did not launch it), cde's exit status and its output.
+func (h *selfTestHarness) run(exitCode int, args ...string) (*fakeCodexReport, int, string, error) {
+	reportPath := filepath.Join(h.dir, "report.json")
+	os.Remove(reportPath)
+
+	cmd := exec.Command(h.cde, append([]string{"--config", h.config}, args...)...)
+	cmd.Env = h.environ(reportPath, exitCode)
+	output, err := cmd.CombinedOutput()
+	status := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, 0, "", fmt.Errorf("failed to run cde: %w", err)
+		}
+		status = exitErr.ExitCode()
+	}
+
+	data, err := ioutil.ReadFile(reportPath)
+	if os.IsNotExist(err) {
+		return nil, status, string(output), nil
+	}
+	if err != nil {
+		return nil, status, string(output), fmt.Errorf("failed to read fake codex report: %w", err)
+	}
+	return parseFakeCodexReport(data), status, string(output), nil
+}
+
+// selfTestCase is one end-to-end launch and what codex must have received
+type selfTestCase struct {
+	Name  string
+	Args  []string
+	Exit  int // Status the fake codex returns
+	Check func(report *fakeCodexReport, status int) error
+}
+
+// selfTestCases cover model injection, environment export, exit status propagation and auto flags
+var selfTestCases = []selfTestCase{
+	{Name: "model injection", Args: []string{"-e", "selftest", "--", "exec", "hello"}, Check: func(report *fakeCodexReport, status int) error {
+		return expectArgs(report, "-m gpt-selftest exec hello")
+	}},
+	{Name: "environment export", Args: []string{"-e", "selftest"}, Check: func(report *fakeCodexReport, status int) error {
+		want := map[string]string{
+			"OPENAI_BASE_URL":  selfTestEnvironment.URL,
+			"OPENAI_API_KEY":   selfTestEnvironment.APIKey,
+			"OPENAI_MODEL":     selfTestEnvironment.Model,
+			"CDE_SELFTEST_VAR": "exported",
+		}
+		for key, value := range want {
+			if report.Env[key] != value {
+				return fmt.Errorf("%s = %q, want %q", key, report.Env[key], value)
+			}
+		}
+		return nil
+	}},
+	{Name: "exit status", Args: []string{"-e", "selftest"}, Exit: 7, Check: func(report *fakeCodexReport, status int) error {
+		if status != 7 {
+			return fmt.Errorf("cde exited with %d, want codex's 7", status)
+		}
+		return nil
+	}},
+	{Name: "auto flags", Args: []string{"auto", "-e", "selftest"}, Check: func(report *fakeCodexReport, status int) error {
+		return expectArgs(report, "-a never --sandbox workspace-write")
+	}},
+}
+
+// expectArgs checks that codex's arguments contain want (space separated) in order
+func expectArgs(report *fakeCodexReport, want string) error {
+	if got := strings.Join(report.Args, " "); !strings.Contains(got, want) {
+		return fmt.Errorf("codex args %q, want them to contain %q", got, want)
+	}
+	return nil
+}
+
+// runSelfTestCases runs every case, printing one line each, and reports whether all passed
+func runSelfTestCases(h *selfTestHarness, w io.Writer) bool {
+	passed := true
+	for _, tc := range selfTestCases {
+		report, status, output, err := h.run(tc.Exit, tc.Args...)
+		if err == nil && report == nil {
+			err = fmt.Errorf("codex was not launched (exit %d): %s", status, strings.TrimSpace(output))
+		}
+		if err == nil {
+			err = tc.Check(report, status)
+		}
+		if err != nil {
+			passed = false
+			fmt.Fprintf(w, "FAIL %s: %v\n", tc.Name, err)
+			continue
+		}
+		fmt.Fprintf(w, "ok   %s\n", tc.Name)
+	}
+	return passed
+}
+
+// runDoctor checks the configuration and the codex install; selftest instead runs the launch
+// flows against a fake codex
+func runDoctor(selftest bool) error {
+	if selftest {
+		return runSelfTest()
+	}
+
+	problems := 0
+	if configPath, err := getConfigPath(); err != nil {
+		problems++
+		fmt.Printf("FAIL config: %v\n", err)
+	} else if config, err := loadConfigLazy(); err != nil {
+		problems++
+		fmt.Printf("FAIL config %s: %v\n", configPath, err)
+	} else {
+		useTargetCommand(config)
+		fmt.Printf("ok   config %s (%d environment(s))\n", configPath, len(config.Environments))
+	}
+
+	if version, codexPath, err := detectCodexVersion(); err != nil {
+		problems++
+		fmt.Printf("FAIL %s: %v\n", targetCommand, err)
+	} else {
+		fmt.Printf("ok   %s %s (%s)\n", targetCommand, version, codexPath)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// runSelfTest runs selfTestCases with this executable against the script fake codex in a
+// temporary directory
+func runSelfTest() error {
+	cde, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("self-test failed: cannot locate current executable: %w", err)
+	}
+	dir, err := ioutil.TempDir("", "cde-selftest-")
+	if err != nil {
+		return fmt.Errorf("self-test failed: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := newSelfTestHarness(cde, dir, installFakeCodexScript)
+	if err != nil {
+		return err
+	}
+	if !runSelfTestCases(h, os.Stdout) {
+		return fmt.Errorf("self-test failed")
+	}
+	fmt.Println("Self-test passed.")
+	return nil
+}
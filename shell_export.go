@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// secretVarPattern identifies variable names whose values are credentials
+var secretVarPattern = regexp.MustCompile(`(?i)(key|token|secret|password)`)
+
+// exportPair is a single variable to emit in shell syntax
+type exportPair struct {
+	Key   string
+	Value string
+}
+
+// environmentExports returns the variables cde would set for env, in launch order
+func environmentExports(env Environment) []exportPair {
+	pairs := []exportPair{
+		{"OPENAI_BASE_URL", env.URL},
+		{"OPENAI_API_KEY", env.APIKey},
+	}
+	if env.Model != "" {
+		model := resolveModelAlias(env, env.Model)
+		if deployment, ok := env.DeploymentMap[model]; ok && isAzureEnvironment(env) {
+			model = deployment
+		}
+		pairs = append(pairs, exportPair{"OPENAI_MODEL", model})
+	}
+
+	for _, group := range [][]string{sortedEnvPairs(providerEnvVars(env)), resolveEnvVars(env)} {
+		for _, pair := range group {
+			key, value, _ := strings.Cut(pair, "=")
+			pairs = append(pairs, exportPair{key, value})
+		}
+	}
+	return pairs
+}
+
+// formatShellExport renders one assignment for the given shell
+func formatShellExport(shell string, pair exportPair) (string, error) {
+	switch shell {
+	case "", "bash", "sh", "zsh":
+		return fmt.Sprintf("export %s='%s'", pair.Key, strings.ReplaceAll(pair.Value, "'", `'\''`)), nil
+	case "fish":
+		escaped := strings.ReplaceAll(strings.ReplaceAll(pair.Value, `\`, `\\`), "'", `\'`)
+		return fmt.Sprintf("set -gx %s '%s'", pair.Key, escaped), nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf("$env:%s = '%s'", pair.Key, strings.ReplaceAll(pair.Value, "'", "''")), nil
+	default:
+		return "", fmt.Errorf("unsupported shell '%s' (use bash, zsh, fish, or powershell)", shell)
+	}
+}
+
+// runEnvPrint prints shell statements exporting the environment's variables
+func runEnvPrint(name, shell string, noSecrets bool) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("invalid environment name: %w", err)
+	}
+
+	config, err := loadConfigLazy()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+
+	index, exists := findEnvironmentByName(config, name)
+	if !exists {
+		return fmt.Errorf("environment '%s' not found", name)
+	}
+
+	env := config.Environments[index]
+	if err := validateSelectedEnvironment(env); err != nil {
+		return err
+	}
+	if !noSecrets {
+		if env, err = resolveEnvironmentSecrets(env); err != nil {
+			return fmt.Errorf("API key resolution failed: %w", err)
+		}
+	}
+
+	for _, pair := range environmentExports(env) {
+		if noSecrets && (secretVarPattern.MatchString(pair.Key) || strings.HasPrefix(pair.Key, headerEnvPrefix)) {
+			pair.Value = "<" + pair.Key + ">"
+		} else if noSecrets && isProxyVar(pair.Key) {
+			pair.Value = redactProxyURL(pair.Value)
+		}
+		line, err := formatShellExport(shell, pair)
+		if err != nil {
+			return fmt.Errorf("argument validation failed: %w", err)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
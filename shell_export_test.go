@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFormatShellExport(t *testing.T) {
+	pair := exportPair{Key: "OPENAI_API_KEY", Value: `it's\x`}
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{"bash", `export OPENAI_API_KEY='it'\''s\x'`},
+		{"", `export OPENAI_API_KEY='it'\''s\x'`},
+		{"fish", `set -gx OPENAI_API_KEY 'it\'s\\x'`},
+		{"powershell", `$env:OPENAI_API_KEY = 'it''s\x'`},
+	}
+
+	for _, tt := range tests {
+		got, err := formatShellExport(tt.shell, pair)
+		if err != nil {
+			t.Fatalf("formatShellExport(%q) failed: %v", tt.shell, err)
+		}
+		if got != tt.want {
+			t.Errorf("formatShellExport(%q) = %s, want %s", tt.shell, got, tt.want)
+		}
+	}
+
+	if _, err := formatShellExport("tcsh", pair); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
+
+func TestEnvironmentExports(t *testing.T) {
+	env := Environment{
+		Name:    "prod",
+		URL:     "https://api.openai.com/v1",
+		APIKey:  "sk-test",
+		Model:   "gpt-5",
+		EnvVars: map[string]string{"OPENAI_TIMEOUT": "30"},
+	}
+
+	pairs := environmentExports(env)
+	keys := make([]string, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.Key
+	}
+	want := []string{"OPENAI_BASE_URL", "OPENAI_API_KEY", "OPENAI_MODEL", "OPENAI_TIMEOUT"}
+	if len(keys) != len(want) {
+		t.Fatalf("unexpected exports: %v", keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("export %d = %s, want %s", i, keys[i], want[i])
+		}
+	}
+}
+
+func TestParseArgumentsEnvPrint(t *testing.T) {
+	result := parseArguments([]string{"env", "print", "prod", "--shell", "fish", "--no-secrets"})
+	if result.Error != nil || result.Subcommand != "env-print" || result.CCEFlags["env"] != "prod" ||
+		result.CCEFlags["shell"] != "fish" || result.CCEFlags["no_secrets"] != "true" {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+	if r := parseArguments([]string{"env", "print"}); r.Error == nil {
+		t.Error("expected error without environment name")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// shellConflict is a variable the user's shell exports that the launch would replace or drop
+type shellConflict struct {
+	Key    string
+	Shell  string
+	Launch string // "" when cde removes the variable
+}
+
+// findShellConflicts returns the OPENAI_* and provider variables whose shell values differ from
+// what a launch of env sets, plus OPENAI_* variables the launch drops. The environment's own
+// env_vars are deliberate overrides and are not reported.
+func findShellConflicts(env Environment) []shellConflict {
+	providerVars := providerEnvVars(env)
+	launched := make(map[string]string)
+	for _, pair := range environmentExports(env) {
+		if _, overridden := env.EnvVars[pair.Key]; overridden {
+			continue
+		}
+		if strings.HasPrefix(pair.Key, "OPENAI_") {
+			launched[pair.Key] = pair.Value
+		} else if _, provided := providerVars[pair.Key]; provided {
+			launched[pair.Key] = pair.Value
+		}
+	}
+
+	var conflicts []shellConflict
+	for key, value := range launched {
+		if shell, exists := os.LookupEnv(key); exists && shell != "" && shell != value {
+			conflicts = append(conflicts, shellConflict{Key: key, Shell: shell, Launch: value})
+		}
+	}
+	for _, envVar := range os.Environ() {
+		key, value, _ := strings.Cut(envVar, "=")
+		_, set := launched[key]
+		_, overridden := env.EnvVars[key]
+		if !set && !overridden && strings.HasPrefix(key, "OPENAI_") && value != "" {
+			conflicts = append(conflicts, shellConflict{Key: key, Shell: value})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	return conflicts
+}
+
+// warnShellConflicts lists the conflicts and which value codex will see, secrets masked
+func warnShellConflicts(conflicts []shellConflict, keepExisting bool) {
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: your shell already sets variables this launch changes:\n")
+	for _, conflict := range conflicts {
+		shell := maskedExport(exportPair{conflict.Key, conflict.Shell}).Value
+		launch := "(removed)"
+		if conflict.Launch != "" {
+			launch = maskedExport(exportPair{conflict.Key, conflict.Launch}).Value
+		}
+		if keepExisting {
+			fmt.Fprintf(os.Stderr, "  %s: keeping shell value %s (environment: %s)\n", conflict.Key, shell, launch)
+		} else {
+			fmt.Fprintf(os.Stderr, "  %s: using %s (shell: %s)\n", conflict.Key, launch, shell)
+		}
+	}
+	if !keepExisting {
+		fmt.Fprintf(os.Stderr, "Pass --keep-existing to prefer the shell's values.\n")
+	}
+}
+
+// conflictKeys returns the names of conflicts
+func conflictKeys(conflicts []shellConflict) []string {
+	keys := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		keys[i] = conflict.Key
+	}
+	return keys
+}
+
+// keepShellValues replaces the listed variables in envVars with the values from cde's own
+// environment, so --keep-existing lets the shell win
+func keepShellValues(envVars []string, keys []string) []string {
+	if len(keys) == 0 {
+		return envVars
+	}
+	keep := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		keep[key] = true
+	}
+	kept := make([]string, 0, len(envVars)+len(keys))
+	for _, envVar := range envVars {
+		if key, _, _ := strings.Cut(envVar, "="); !keep[key] {
+			kept = append(kept, envVar)
+		}
+	}
+	for _, key := range keys {
+		if value, exists := os.LookupEnv(key); exists {
+			kept = append(kept, key+"="+value)
+		}
+	}
+	return kept
+}
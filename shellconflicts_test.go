@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// clearOpenAIEnv unsets every OPENAI_* variable for the duration of the test
+func clearOpenAIEnv(t *testing.T) {
+	t.Helper()
+	for _, envVar := range os.Environ() {
+		if key, value, _ := strings.Cut(envVar, "="); strings.HasPrefix(key, "OPENAI_") {
+			t.Setenv(key, value)
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestFindShellConflicts(t *testing.T) {
+	env := Environment{
+		Name:    "gateway",
+		URL:     "https://gateway.example.com/v1",
+		APIKey:  "sk-gateway",
+		EnvVars: map[string]string{"OPENAI_TIMEOUT": "30s"},
+	}
+	tests := []struct {
+		name  string
+		shell map[string]string
+		want  []shellConflict
+	}{
+		{name: "clean shell"},
+		{name: "same value", shell: map[string]string{"OPENAI_BASE_URL": "https://gateway.example.com/v1"}},
+		{
+			name:  "different key",
+			shell: map[string]string{"OPENAI_API_KEY": "sk-personal"},
+			want:  []shellConflict{{Key: "OPENAI_API_KEY", Shell: "sk-personal", Launch: "sk-gateway"}},
+		},
+		{
+			name:  "dropped variable",
+			shell: map[string]string{"OPENAI_ORG_ID": "org-personal"},
+			want:  []shellConflict{{Key: "OPENAI_ORG_ID", Shell: "org-personal"}},
+		},
+		{name: "env_vars override", shell: map[string]string{"OPENAI_TIMEOUT": "10s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOpenAIEnv(t)
+			for key, value := range tt.shell {
+				t.Setenv(key, value)
+			}
+			if got := findShellConflicts(env); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findShellConflicts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepShellValues(t *testing.T) {
+	clearOpenAIEnv(t)
+	t.Setenv("OPENAI_API_KEY", "sk-personal")
+	t.Setenv("OPENAI_ORG_ID", "org-personal")
+
+	envVars := []string{"PATH=/usr/bin", "OPENAI_BASE_URL=https://gateway.example.com/v1", "OPENAI_API_KEY=sk-gateway"}
+	got := keepShellValues(envVars, []string{"OPENAI_API_KEY", "OPENAI_ORG_ID"})
+	want := []string{"PATH=/usr/bin", "OPENAI_BASE_URL=https://gateway.example.com/v1", "OPENAI_API_KEY=sk-personal", "OPENAI_ORG_ID=org-personal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keepShellValues() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// childShutdownGrace is how long a forwarded signal may take to stop codex before it is killed
+const childShutdownGrace = 10 * time.Second
+
+// sessionTimeoutExitCode is cde's exit status when codex is stopped for exceeding its time
+// limit (the status coreutils timeout uses)
+const sessionTimeoutExitCode = 124
+
+// runCodexChild runs cmd as a child process, forwarding termination signals to its process
+// group and restoring the terminal afterwards. It returns the child's exit code.
+func runCodexChild(cmd *exec.Cmd) (int, error) {
+	return runCodexChildWithLimit(cmd, 0)
+}
+
+// runCodexChildWithLimit is runCodexChild with a session time limit: once limit (if positive)
+// elapses codex is asked to stop, killed after the grace period, and sessionTimeoutExitCode is
+// returned.
+func runCodexChildWithLimit(cmd *exec.Cmd, limit time.Duration) (int, error) {
+	// Snapshot the terminal so a crashed or interrupted codex cannot leave it in raw mode
+	fd := stdinFd()
+	var saved *term.State
+	if term.IsTerminal(fd) {
+		saved, _ = term.GetState(fd)
+	}
+	restore := func() {
+		if saved != nil {
+			term.Restore(fd, saved)
+		}
+	}
+	defer restore()
+
+	configureChildProcess(cmd)
+	name := filepath.Base(cmd.Args[0])
+
+	// Subscribe before starting so no signal slips through between start and wait
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return -1, fmt.Errorf("Codex process start failed: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var expired <-chan time.Time
+	if limit > 0 {
+		timer := time.NewTimer(limit)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	timedOut := false
+	var deadline <-chan time.Time
+	for {
+		select {
+		case sig := <-signals:
+			forwardSignal(cmd.Process, sig)
+			if deadline == nil {
+				deadline = time.After(childShutdownGrace)
+			}
+		case <-expired:
+			fmt.Fprintf(os.Stderr, "%s exceeded the session limit of %s; stopping it\n", name, limit)
+			timedOut = true
+			expired = nil
+			stopChild(cmd.Process)
+			if deadline == nil {
+				deadline = time.After(childShutdownGrace)
+			}
+		case <-deadline:
+			fmt.Fprintf(os.Stderr, "%s did not exit within %s; killing it\n", name, childShutdownGrace)
+			cmd.Process.Kill()
+			deadline = nil
+		case err := <-done:
+			reclaimForeground()
+			if timedOut {
+				return sessionTimeoutExitCode, nil
+			}
+			if err == nil {
+				return 0, nil
+			}
+			if exitError, ok := err.(*exec.ExitError); ok {
+				return exitError.ExitCode(), nil
+			}
+			return -1, fmt.Errorf("Codex execution failed: %w", err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunCodexChildExitCode(t *testing.T) {
+	for _, want := range []int{0, 3} {
+		code, err := runCodexChild(exec.Command("sh", "-c", "exit "+strconv.Itoa(want)))
+		if err != nil {
+			t.Fatalf("runCodexChild failed: %v", err)
+		}
+		if code != want {
+			t.Errorf("exit code = %d, want %d", code, want)
+		}
+	}
+
+	if _, err := runCodexChild(exec.Command("/nonexistent/codex")); err == nil {
+		t.Error("expected start failure for missing binary")
+	}
+}
+
+func TestRunCodexChildForwardsSignals(t *testing.T) {
+	// The child exits with 7 once it receives SIGTERM
+	cmd := exec.Command("sh", "-c", `trap 'exit 7' TERM; while :; do sleep 0.05; done`)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	code, err := runCodexChild(cmd)
+	if err != nil {
+		t.Fatalf("runCodexChild failed: %v", err)
+	}
+	if code != 7 {
+		t.Errorf("exit code = %d, want 7 (SIGTERM forwarded to child)", code)
+	}
+}
+
+func TestRunCodexChildWithLimitStopsChild(t *testing.T) {
+	// The child would run for a minute but exits on SIGTERM
+	cmd := exec.Command("sh", "-c", `trap 'exit 0' TERM; i=0; while [ $i -lt 1200 ]; do sleep 0.05; i=$((i+1)); done`)
+	start := time.Now()
+	code, err := runCodexChildWithLimit(cmd, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("runCodexChildWithLimit failed: %v", err)
+	}
+	if code != sessionTimeoutExitCode {
+		t.Errorf("exit code = %d, want %d", code, sessionTimeoutExitCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("child stopped after %s, want shortly after the limit", elapsed)
+	}
+
+	// A child that finishes in time keeps its own status
+	if code, err := runCodexChildWithLimit(exec.Command("sh", "-c", "exit 3"), time.Minute); err != nil || code != 3 {
+		t.Errorf("runCodexChildWithLimit() = %d, %v; want 3", code, err)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// launchSnapshot is the reproducibility manifest written by --snapshot and read by 'cde replay'.
+// It never contains the API key; secret-looking codex arguments are masked. Replay starts from
+// UserArgs and re-derives the overrides cde adds, so those never need to survive masking.
+type launchSnapshot struct {
+	CDEVersion   string            `json:"cde_version"`
+	CodexVersion string            `json:"codex_version,omitempty"`
+	Environment  string            `json:"environment"`
+	URL          string            `json:"url"`
+	Model        string            `json:"model,omitempty"`
+	Key          string            `json:"key,omitempty"`  // Named key selected with --key
+	Vars         map[string]string `json:"vars,omitempty"` // Template values
+	Args         []string          `json:"args"`           // Final codex arguments
+	UserArgs     []string          `json:"user_args"`      // Codex arguments before cde's overrides
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// argsModel returns the model codex receives through -m/--model or -c model=..., or "" if none
+func argsModel(args []string) string {
+	for i, arg := range args {
+		if (arg == "-m" || arg == "--model") && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--model="); ok {
+			return value
+		}
+		if (arg == "-c" || arg == "--config") && i+1 < len(args) {
+			if model, ok := modelOverride(args[i+1]); ok {
+				return model
+			}
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			if model, ok := modelOverride(value); ok {
+				return model
+			}
+		}
+	}
+	return ""
+}
+
+// newLaunchSnapshot describes plan; keyName is the --key selection, if any
+func newLaunchSnapshot(plan launchPlan, keyName string) launchSnapshot {
+	snapshot := launchSnapshot{
+		CDEVersion:  version,
+		Environment: plan.Environment.Name,
+		URL:         plan.Environment.URL,
+		Model:       argsModel(plan.Args),
+		Key:         keyName,
+		Vars:        plan.Vars,
+		Args:        sanitizeArgs(plan.Args, plan.Environment.APIKey),
+		UserArgs:    sanitizeArgs(plan.UserArgs, plan.Environment.APIKey),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if codexVersion, _, err := detectCodexVersion(); err == nil {
+		snapshot.CodexVersion = codexVersion
+	} else {
+		logDebugf("codex version not recorded in snapshot: %v", err)
+	}
+	return snapshot
+}
+
+// writeLaunchSnapshot saves the manifest for plan to path
+func writeLaunchSnapshot(path string, plan launchPlan, keyName string) error {
+	data, err := json.MarshalIndent(newLaunchSnapshot(plan, keyName), "", "  ")
+	if err != nil {
+		return fmt.Errorf("snapshot serialization failed: %w", err)
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	fmt.Printf("Snapshot written to %s (replay with: cde replay %s)\n", path, quoteCommandArg(path))
+	return nil
+}
+
+// loadLaunchSnapshot reads a manifest written by --snapshot
+func loadLaunchSnapshot(path string) (launchSnapshot, error) {
+	var snapshot launchSnapshot
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("invalid snapshot %s: %w", path, describeJSONError(data, err))
+	}
+	if snapshot.Environment == "" || snapshot.URL == "" {
+		return snapshot, fmt.Errorf("invalid snapshot %s: environment and url are required", path)
+	}
+	return snapshot, nil
+}
+
+// planReplay rebuilds the launch recorded in snapshot from the current configuration. The
+// recorded URL must still be one of the environment's endpoints, so a snapshot received from
+// someone else cannot send the API key to a different server.
+func planReplay(snapshot launchSnapshot) (launchPlan, error) {
+	config, err := loadConfigLazy()
+	if err != nil {
+		return launchPlan{}, fmt.Errorf("configuration loading failed: %w", err)
+	}
+	index, exists := findEnvironmentByName(config, snapshot.Environment)
+	if !exists {
+		return launchPlan{}, fmt.Errorf("environment '%s' from the snapshot not found", snapshot.Environment)
+	}
+
+	// Snapshots from before user_args was recorded replay their final arguments as they are
+	args := snapshot.Args
+	if snapshot.UserArgs != nil {
+		args = snapshot.UserArgs
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "***") {
+			return launchPlan{}, fmt.Errorf("snapshot argument %s was masked when recorded; re-run the command with the secret instead", arg)
+		}
+	}
+	if err := checkPassthroughArgs(config, args, launchOptions{}); err != nil {
+		return launchPlan{}, fmt.Errorf("argument validation failed: %w", err)
+	}
+
+	env, vars, err := resolveEnvironmentTemplate(config.Environments[index], snapshot.Vars, false)
+	if err != nil {
+		return launchPlan{}, fmt.Errorf("argument validation failed: %w", err)
+	}
+	known := false
+	for _, endpoint := range environmentEndpoints(env) {
+		if endpoint == snapshot.URL {
+			known = true
+		}
+	}
+	if !known {
+		return launchPlan{}, fmt.Errorf("snapshot URL %s is not an endpoint of environment '%s' (now %s)", snapshot.URL, env.Name, strings.Join(environmentEndpoints(env), ", "))
+	}
+	env.URL = snapshot.URL
+	if err := validateSelectedEnvironment(env); err != nil {
+		return launchPlan{}, err
+	}
+	if snapshot.Key != "" {
+		env, err = selectAPIKey(env, snapshot.Key)
+		if err != nil {
+			return launchPlan{}, fmt.Errorf("key selection failed: %w", err)
+		}
+	}
+
+	plan := launchPlan{
+		Config:      config,
+		Environment: env,
+		Source:      "snapshot from " + snapshot.CreatedAt.Local().Format("2006-01-02 15:04"),
+		Args:        args,
+		UserArgs:    args,
+		Vars:        vars,
+	}
+	if snapshot.UserArgs != nil {
+		// Keep the recorded model even if the environment's default has changed since
+		if !codexModelSet(args) && snapshot.Model != "" {
+			args = append([]string{"-m", snapshot.Model}, args...)
+		}
+		plan.Args = prepareCodexArgs(env, args)
+	}
+	return plan, nil
+}
+
+// runReplay re-launches codex with the environment, endpoint and arguments of a snapshot
+func runReplay(path string, opts launchOptions) error {
+	start := time.Now()
+	snapshot, err := loadLaunchSnapshot(path)
+	if err != nil {
+		return err
+	}
+	plan, err := planReplay(snapshot)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.CDEVersion != version {
+		fmt.Fprintf(os.Stderr, "Warning: snapshot was recorded with cde %s, this is cde %s\n", snapshot.CDEVersion, version)
+	}
+	if snapshot.CodexVersion != "" {
+		if current, _, err := detectCodexVersion(); err == nil && current != snapshot.CodexVersion {
+			fmt.Fprintf(os.Stderr, "Warning: snapshot was recorded with codex %s, installed codex is %s\n", snapshot.CodexVersion, current)
+		}
+	}
+
+	opts.KeyName = snapshot.Key
+	return executeLaunchPlan(plan, opts, time.Since(start))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	original := configPathOverride
+	configPathOverride = filepath.Join(dir, "config.json")
+	defer func() { configPathOverride = original }()
+
+	if err := saveConfig(Config{Environments: []Environment{{
+		Name: "gw", URL: "https://{region}.a.example.com/v1", URLs: []string{"https://b.example.com/v1"},
+		APIKey: "sk-gw-1234567890", Model: "gpt-5", Keys: map[string]string{"backup": "sk-backup-1234567890"},
+	}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := planLaunch("gw", []string{"exec", "hi"}, launchOptions{NoPrompt: true, KeyName: "backup", Vars: map[string]string{"region": "eu"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "run.json")
+	if err := writeLaunchSnapshot(path, plan, "backup"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), "sk-") {
+		t.Errorf("snapshot contains a key:\n%s", data)
+	}
+
+	snapshot, err := loadLaunchSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Model != "gpt-5" || snapshot.URL != "https://eu.a.example.com/v1" || snapshot.Vars["region"] != "eu" {
+		t.Errorf("snapshot = %+v", snapshot)
+	}
+	replay, err := planReplay(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replay.Args, plan.Args) || replay.Environment.URL != plan.Environment.URL || replay.Environment.APIKey != "sk-backup-1234567890" {
+		t.Errorf("replay = %+v, want %+v", replay, plan)
+	}
+
+	// A snapshot must not redirect the key to a URL the environment does not use
+	tampered := snapshot
+	tampered.URL = "https://evil.example.com/v1"
+	if _, err := planReplay(tampered); err == nil {
+		t.Error("expected an error for a foreign URL")
+	}
+	masked := snapshot
+	masked.UserArgs = []string{"exec", "--api-key=***"}
+	if _, err := planReplay(masked); err == nil {
+		t.Error("expected an error for masked arguments")
+	}
+	legacy := snapshot
+	legacy.UserArgs = nil
+	legacy.Args = []string{"exec", "--api-key=***"}
+	if _, err := planReplay(legacy); err == nil {
+		t.Error("expected an error for masked arguments in a snapshot without user_args")
+	}
+}
+
+func TestSnapshotReplayRederivesOverrides(t *testing.T) {
+	dir := t.TempDir()
+	original := configPathOverride
+	configPathOverride = filepath.Join(dir, "config.json")
+	defer func() { configPathOverride = original }()
+
+	env := Environment{
+		Name: "gw", URL: "https://gw.example.com/v1", APIKey: "sk-gw-1234567890", Model: "gpt-5",
+		Headers:     map[string]string{"X-Team": "platform"},
+		ModelParams: map[string]string{"model_reasoning_effort": "high"},
+	}
+	if err := saveConfig(Config{Environments: []Environment{env}}); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := planLaunch("gw", []string{"exec", "hi"}, launchOptions{NoPrompt: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := newLaunchSnapshot(plan, "")
+	if !reflect.DeepEqual(snapshot.UserArgs, []string{"exec", "hi"}) {
+		t.Errorf("user_args = %v, want the arguments before cde's overrides", snapshot.UserArgs)
+	}
+
+	replay, err := planReplay(snapshot)
+	if err != nil {
+		t.Fatalf("planReplay() failed for an environment with headers: %v", err)
+	}
+	if !reflect.DeepEqual(replay.Args, plan.Args) {
+		t.Errorf("replayed args = %v, want %v", replay.Args, plan.Args)
+	}
+
+	// The recorded model wins over a changed default
+	env.Model = "gpt-4.1"
+	if err := saveConfig(Config{Environments: []Environment{env}}); err != nil {
+		t.Fatal(err)
+	}
+	if replay, err := planReplay(snapshot); err != nil || argsModel(replay.Args) != "gpt-5" {
+		t.Errorf("replayed model = %q, %v; want gpt-5", argsModel(replay.Args), err)
+	}
+}
+
+func TestParseSnapshotAndReplay(t *testing.T) {
+	result := parseArguments([]string{"-e", "prod", "--snapshot", "run.json", "--", "exec", "hi"})
+	if result.Error != nil || result.CCEFlags["snapshot"] != "run.json" || !reflect.DeepEqual(result.ClaudeArgs, []string{"exec", "hi"}) {
+		t.Errorf("--snapshot parse = %+v", result)
+	}
+	if opts := launchOptionsFromFlags(result); opts.Snapshot != "run.json" {
+		t.Errorf("launch options snapshot = %q", opts.Snapshot)
+	}
+
+	result = parseArguments([]string{"replay", "run.json", "--dry-run"})
+	if result.Error != nil || result.Subcommand != "replay" || result.CCEFlags["snapshot_file"] != "run.json" || result.CCEFlags["dry_run"] != "true" {
+		t.Errorf("replay parse = %+v", result)
+	}
+	for _, args := range [][]string{{"replay"}, {"replay", "run.json", "--force"}} {
+		if result := parseArguments(args); result.Error == nil {
+			t.Errorf("parseArguments(%q) should fail", args)
+		}
+	}
+}
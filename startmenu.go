@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// startAction is one row of the menu a bare 'cde' shows before any environment exists
+type startAction struct {
+	Label string
+	Run   func() error // nil quits
+}
+
+// startActions are the start menu rows in display order; Quit stays last
+func startActions() []startAction {
+	return []startAction{
+		{"Add environment", runAdd},
+		{"Import from codex or claude-code-env", importFromAllSources},
+		{"Help", func() error {
+			showHelp()
+			return nil
+		}},
+		{"Quit", nil},
+	}
+}
+
+// importFromAllSources runs 'cde import-from' for every known source
+func importFromAllSources() error {
+	for _, source := range []string{"codex", "claude-code-env"} {
+		if err := runImportFrom(source, false, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startMenuConfig returns the configuration when a bare 'cde' should open the start menu:
+// nothing is configured yet and someone is at the terminal to choose what to do
+func startMenuConfig() (Config, bool) {
+	if envOnlySource() != "" || strings.TrimSpace(os.Getenv("CDE_ENV")) != "" || !isInteractiveInput() || isHeadlessMode() {
+		return Config{}, false
+	}
+	config, err := loadConfigLazy()
+	if err != nil || len(config.Environments) > 0 {
+		return Config{}, false
+	}
+	return config, true
+}
+
+// runStartMenu lets a new user add, import, read help or quit instead of failing on an empty config
+func runStartMenu(config Config) error {
+	actions := startActions()
+	menuTheme = activeTheme(config)
+	var index int
+	var err error
+	if accessibleMode(config) {
+		index, err = numberedStartAction(actions)
+	} else {
+		index, err = selectStartAction(actions)
+	}
+	if err != nil {
+		return err
+	}
+	if actions[index].Run == nil {
+		return nil
+	}
+	return actions[index].Run()
+}
+
+// selectStartAction picks an action with the arrow-key menu, or by number without raw mode
+func selectStartAction(actions []startAction) (int, error) {
+	caps := detectTerminalCapabilities()
+	if !caps.SupportsRaw || !caps.SupportsANSI || !caps.SupportsCursor {
+		return numberedStartAction(actions)
+	}
+
+	if err := uiTerminal.MakeRaw(); err != nil {
+		return numberedStartAction(actions)
+	}
+	defer restoreTerminal()
+	defer cleanupDisplayState()
+
+	header := trf("No environments configured yet. What would you like to do? (↑↓/j/k, 1-%d; Enter to confirm, Esc to quit):", len(actions))
+	selected := 0
+	buffer := make([]byte, 10)
+	for {
+		renderRowsStatefully(len(actions), selected, header, true, func(prefix string, i int) string {
+			return prefix + tr(actions[i].Label)
+		})
+
+		n, err := uiTerminal.Read(buffer)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read choice: %w", err)
+		}
+		arrow, char, err := parseKeyInput(buffer[:n])
+		if err != nil {
+			continue
+		}
+		if next, moved := navigateSelection(selected, len(actions), len(actions), arrow, char); moved {
+			selected = next
+			continue
+		}
+		if arrow == ArrowNone {
+			switch char {
+			case '\n', '\r':
+				return selected, nil
+			case '\x1b', '\x03', 'q':
+				return len(actions) - 1, nil
+			}
+		}
+	}
+}
+
+// numberedStartAction lists the actions and reads a number
+func numberedStartAction(actions []startAction) (int, error) {
+	fmt.Println(tr("No environments configured yet. What would you like to do?"))
+	for i, action := range actions {
+		fmt.Printf("  %d. %s\n", i+1, tr(action.Label))
+	}
+	input, err := regularInput(trf("Choose (1-%d, Enter to quit): ", len(actions)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read choice: %w", err)
+	}
+	return parseStartChoice(input, len(actions))
+}
+
+// parseStartChoice maps a typed number to an action index; an empty answer quits
+func parseStartChoice(input string, count int) (int, error) {
+	if input == "" {
+		return count - 1, nil
+	}
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > count {
+		return 0, fmt.Errorf("invalid selection - must be between 1 and %d", count)
+	}
+	return choice - 1, nil
+}
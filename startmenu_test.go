@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseStartChoice(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"1", 0, false},
+		{"4", 3, false},
+		{"", 3, false},
+		{"0", 0, true},
+		{"5", 0, true},
+		{"add", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseStartChoice(tt.input, 4)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseStartChoice(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseStartChoice(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStartActions(t *testing.T) {
+	actions := startActions()
+	if last := actions[len(actions)-1]; last.Label != "Quit" || last.Run != nil {
+		t.Errorf("last action = %q, want Quit without a handler", last.Label)
+	}
+	for _, action := range actions {
+		if _, ok := zhCNMessages[action.Label]; !ok {
+			t.Errorf("no zh-CN translation for %q", action.Label)
+		}
+	}
+}
+
+func TestRenderStartMenuRows(t *testing.T) {
+	out := &bytes.Buffer{}
+	oldOutput := menuOutput
+	menuOutput = out
+	defer func() { menuOutput = oldOutput }()
+	cleanupDisplayState() // Start from a fresh renderer without ANSI
+	defer cleanupDisplayState()
+
+	actions := startActions()
+	renderRowsStatefully(len(actions), 1, "Header", false, func(prefix string, i int) string {
+		return prefix + actions[i].Label
+	})
+	for _, want := range []string{"Header", "  Add environment", "* Import from codex or claude-code-env", "  Quit"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("menu missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,591 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// syncDocumentVersion is the schema version of the shared environment document
+const syncDocumentVersion = 1
+
+// SyncSettings configures where 'cde sync' shares environment definitions
+type SyncSettings struct {
+	Git            string `json:"git,omitempty"`             // Git repository URL
+	Branch         string `json:"branch,omitempty"`          // Git branch (default: remote HEAD)
+	Path           string `json:"path,omitempty"`            // File inside the repository (default environments.json)
+	URL            string `json:"url,omitempty"`             // HTTPS endpoint: GET to pull, PUT to push
+	ExcludeSecrets *bool  `json:"exclude_secrets,omitempty"` // Always true; secrets are never synced
+}
+
+// sharedEnvironment is the secret-free subset of an Environment that is synced
+type sharedEnvironment struct {
+	Name          string            `json:"name"`
+	URL           string            `json:"url"`
+	URLs          []string          `json:"urls,omitempty"`
+	URLStrategy   string            `json:"url_strategy,omitempty"`
+	Provider      string            `json:"provider,omitempty"`
+	Model         string            `json:"model,omitempty"`
+	OrgID         string            `json:"org_id,omitempty"`
+	ProjectID     string            `json:"project_id,omitempty"`
+	ModelAliases  map[string]string `json:"model_aliases,omitempty"`
+	EnvVars       map[string]string `json:"env_vars,omitempty"`
+	ModelParams   map[string]string `json:"model_params,omitempty"`
+	DeploymentMap map[string]string `json:"deployment_map,omitempty"`
+	APIVersion    string            `json:"api_version,omitempty"`
+	AutoFlags     []string          `json:"auto_flags,omitempty"`
+}
+
+// sharedDocument is the file exchanged with the sync remote
+type sharedDocument struct {
+	Version      int                 `json:"version"`
+	Environments []sharedEnvironment `json:"environments"`
+}
+
+// syncChange describes one planned or applied sync action
+type syncChange struct {
+	Name   string
+	Action string // add, update, remove, conflict, skip
+	Detail string
+}
+
+// syncRemote reads and writes the shared document
+type syncRemote interface {
+	fetch() ([]byte, error) // nil data means the remote has no document yet
+	store(data []byte) error
+}
+
+// toSharedEnvironment strips credentials: api_key, keys and secret-looking env vars
+func toSharedEnvironment(env Environment) sharedEnvironment {
+	shared := sharedEnvironment{
+		Name:          env.Name,
+		URL:           env.URL,
+		URLs:          env.URLs,
+		URLStrategy:   env.URLStrategy,
+		Provider:      env.Provider,
+		Model:         env.Model,
+		OrgID:         env.OrgID,
+		ProjectID:     env.ProjectID,
+		ModelAliases:  copyStringMap(env.ModelAliases),
+		ModelParams:   copyStringMap(env.ModelParams),
+		DeploymentMap: copyStringMap(env.DeploymentMap),
+		APIVersion:    env.APIVersion,
+		AutoFlags:     env.AutoFlags,
+	}
+	for key, value := range env.EnvVars {
+		if secretVarPattern.MatchString(key) {
+			continue
+		}
+		if shared.EnvVars == nil {
+			shared.EnvVars = make(map[string]string)
+		}
+		shared.EnvVars[key] = value
+	}
+	return shared
+}
+
+// sharedDocumentFor builds the shared document for all local environments, sorted by name
+func sharedDocumentFor(config Config) sharedDocument {
+	doc := sharedDocument{Version: syncDocumentVersion, Environments: []sharedEnvironment{}}
+	for _, env := range config.Environments {
+		doc.Environments = append(doc.Environments, toSharedEnvironment(env))
+	}
+	sort.Slice(doc.Environments, func(i, j int) bool { return doc.Environments[i].Name < doc.Environments[j].Name })
+	return doc
+}
+
+// verifyNoSecrets refuses to publish data containing any local API key
+func verifyNoSecrets(data []byte, config Config) error {
+	for _, env := range config.Environments {
+		secrets := []string{env.APIKey}
+		for _, key := range env.Keys {
+			secrets = append(secrets, key)
+		}
+		for key, value := range env.EnvVars {
+			if secretVarPattern.MatchString(key) {
+				secrets = append(secrets, value)
+			}
+		}
+		for _, secret := range secrets {
+			if len(secret) >= 4 && bytes.Contains(data, []byte(secret)) {
+				return fmt.Errorf("sync aborted: shared document would contain a secret from environment '%s'", env.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// parseSharedDocument decodes remote data; empty data is an empty document
+func parseSharedDocument(data []byte) (sharedDocument, error) {
+	doc := sharedDocument{Version: syncDocumentVersion, Environments: []sharedEnvironment{}}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return doc, nil
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return sharedDocument{}, fmt.Errorf("sync document parsing failed (invalid JSON): %w", err)
+	}
+	if doc.Version > syncDocumentVersion {
+		return sharedDocument{}, fmt.Errorf("sync document version %d is newer than supported (%d); update cde", doc.Version, syncDocumentVersion)
+	}
+	return doc, nil
+}
+
+// sameShared compares two shared environments by their serialized form
+func sameShared(a, b sharedEnvironment) bool {
+	dataA, _ := json.Marshal(a)
+	dataB, _ := json.Marshal(b)
+	return bytes.Equal(dataA, dataB)
+}
+
+// indexShared maps shared environments by name
+func indexShared(doc sharedDocument) map[string]sharedEnvironment {
+	index := make(map[string]sharedEnvironment, len(doc.Environments))
+	for _, env := range doc.Environments {
+		index[env.Name] = env
+	}
+	return index
+}
+
+// applyShared copies shared fields onto env, keeping local credentials and secret env vars
+func applyShared(env Environment, shared sharedEnvironment) Environment {
+	updated := cloneEnvironment(env, shared.Name)
+	updated.URL = shared.URL
+	updated.URLs = shared.URLs
+	updated.URLStrategy = shared.URLStrategy
+	updated.Provider = shared.Provider
+	updated.Model = shared.Model
+	updated.OrgID = shared.OrgID
+	updated.ProjectID = shared.ProjectID
+	updated.ModelAliases = copyStringMap(shared.ModelAliases)
+	updated.ModelParams = copyStringMap(shared.ModelParams)
+	updated.DeploymentMap = copyStringMap(shared.DeploymentMap)
+	updated.APIVersion = shared.APIVersion
+	updated.AutoFlags = shared.AutoFlags
+
+	envVars := copyStringMap(shared.EnvVars)
+	for key, value := range env.EnvVars {
+		if secretVarPattern.MatchString(key) {
+			if envVars == nil {
+				envVars = make(map[string]string)
+			}
+			envVars[key] = value
+		}
+	}
+	updated.EnvVars = envVars
+	return updated
+}
+
+// mergeRemote performs a three-way merge of remote into config using base (the last synced document).
+// Local edits win over unchanged remote entries; when both sides changed, theirs selects the remote
+// version, otherwise the local version is kept and a conflict is reported.
+func mergeRemote(config *Config, base, remote sharedDocument, theirs bool) []syncChange {
+	baseIndex := indexShared(base)
+	remoteIndex := indexShared(remote)
+	changes := []syncChange{}
+
+	for _, remoteEnv := range remote.Environments {
+		if err := validateEnvironment(applyShared(Environment{}, remoteEnv)); err != nil {
+			changes = append(changes, syncChange{Name: remoteEnv.Name, Action: "skip", Detail: fmt.Sprintf("invalid remote definition: %v", err)})
+			continue
+		}
+		index, exists := findEnvironmentByName(*config, remoteEnv.Name)
+		if !exists {
+			if _, wasSynced := baseIndex[remoteEnv.Name]; wasSynced {
+				// Deleted locally since the last sync; keep it deleted unless the remote changed it
+				if sameShared(baseIndex[remoteEnv.Name], remoteEnv) {
+					continue
+				}
+			}
+			config.Environments = append(config.Environments, applyShared(Environment{}, remoteEnv))
+			changes = append(changes, syncChange{Name: remoteEnv.Name, Action: "add", Detail: "new environment without API key (set api_key before use)"})
+			continue
+		}
+
+		localShared := toSharedEnvironment(config.Environments[index])
+		if sameShared(localShared, remoteEnv) {
+			continue
+		}
+
+		baseEnv, wasSynced := baseIndex[remoteEnv.Name]
+		localChanged := !wasSynced || !sameShared(localShared, baseEnv)
+		remoteChanged := !wasSynced || !sameShared(remoteEnv, baseEnv)
+
+		switch {
+		case !remoteChanged:
+			// Only the local side changed; it will be published by push
+		case !localChanged || theirs:
+			config.Environments[index] = applyShared(config.Environments[index], remoteEnv)
+			changes = append(changes, syncChange{Name: remoteEnv.Name, Action: "update", Detail: "updated from remote"})
+		default:
+			changes = append(changes, syncChange{Name: remoteEnv.Name, Action: "conflict", Detail: "changed locally and remotely; kept local (use --theirs to take remote)"})
+		}
+	}
+
+	// Environments removed upstream go to the trash if they were not edited locally
+	for _, baseEnv := range base.Environments {
+		if _, stillRemote := remoteIndex[baseEnv.Name]; stillRemote {
+			continue
+		}
+		index, exists := findEnvironmentByName(*config, baseEnv.Name)
+		if !exists {
+			continue
+		}
+		if !sameShared(toSharedEnvironment(config.Environments[index]), baseEnv) && !theirs {
+			changes = append(changes, syncChange{Name: baseEnv.Name, Action: "conflict", Detail: "removed remotely but changed locally; kept local"})
+			continue
+		}
+		if err := moveToTrash(config, baseEnv.Name, time.Now()); err == nil {
+			changes = append(changes, syncChange{Name: baseEnv.Name, Action: "remove", Detail: "removed remotely (moved to trash)"})
+		}
+	}
+
+	return changes
+}
+
+// syncSettings validates and returns the sync configuration
+func syncSettings(config Config) (SyncSettings, error) {
+	if config.Settings == nil || config.Settings.Sync == nil {
+		return SyncSettings{}, fmt.Errorf("sync is not configured: set settings.sync.git or settings.sync.url")
+	}
+	settings := *config.Settings.Sync
+	if settings.ExcludeSecrets != nil && !*settings.ExcludeSecrets {
+		return SyncSettings{}, fmt.Errorf("settings.sync.exclude_secrets cannot be disabled; secrets are never synced")
+	}
+	if (settings.Git == "") == (settings.URL == "") {
+		return SyncSettings{}, fmt.Errorf("settings.sync requires exactly one of git or url")
+	}
+	if settings.Path == "" {
+		settings.Path = "environments.json"
+	}
+	if filepath.IsAbs(settings.Path) || strings.Contains(settings.Path, "..") {
+		return SyncSettings{}, fmt.Errorf("settings.sync.path must be a relative path inside the repository")
+	}
+	return settings, nil
+}
+
+// newSyncRemote builds the transport for the configured remote
+func newSyncRemote(settings SyncSettings) (syncRemote, error) {
+	if isOfflineMode() {
+		return nil, fmt.Errorf("sync skipped: offline mode (CDE_OFFLINE) is set")
+	}
+	if settings.Git != "" {
+		return &gitSyncRemote{repo: settings.Git, branch: settings.Branch, path: settings.Path}, nil
+	}
+
+	parsed, err := url.Parse(settings.URL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid settings.sync.url '%s'", settings.URL)
+	}
+	// Plain HTTP is only allowed for local servers; credentials-free data still deserves TLS
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && isLoopbackHost(parsed.Hostname())) {
+		return nil, fmt.Errorf("settings.sync.url must use https")
+	}
+	return &httpSyncRemote{url: settings.URL, token: os.Getenv("CDE_SYNC_TOKEN"), client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// isLoopbackHost reports whether host refers to the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// httpSyncRemote exchanges the document with an HTTPS endpoint
+type httpSyncRemote struct {
+	url    string
+	token  string // Sent as a bearer token when CDE_SYNC_TOKEN is set
+	client *http.Client
+}
+
+func (r *httpSyncRemote) do(method string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cde/"+version)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	return r.client.Do(req)
+}
+
+func (r *httpSyncRemote) fetch() ([]byte, error) {
+	resp, err := r.do(http.MethodGet, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sync fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sync fetch failed: unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (r *httpSyncRemote) store(data []byte) error {
+	resp, err := r.do(http.MethodPut, data)
+	if err != nil {
+		return fmt.Errorf("sync push failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sync push failed: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// gitSyncRemote exchanges the document through a git repository using the git CLI
+type gitSyncRemote struct {
+	repo   string
+	branch string
+	path   string
+}
+
+// runGit runs a git command in dir and returns its combined output on failure
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// checkout clones the repository into a temporary directory
+func (r *gitSyncRemote) checkout() (string, error) {
+	dir, err := ioutil.TempDir("", "cde-sync-")
+	if err != nil {
+		return "", fmt.Errorf("sync checkout failed: %w", err)
+	}
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if r.branch != "" {
+		args = append(args, "--branch", r.branch)
+	}
+	args = append(args, r.repo, dir)
+	if err := runGit("", args...); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("sync checkout failed: %w", err)
+	}
+	return dir, nil
+}
+
+func (r *gitSyncRemote) fetch() ([]byte, error) {
+	dir, err := r.checkout()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, r.path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (r *gitSyncRemote) store(data []byte) error {
+	dir, err := r.checkout()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, r.path)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("sync push failed: %w", err)
+	}
+	if err := ioutil.WriteFile(target, data, 0644); err != nil {
+		return fmt.Errorf("sync push failed: %w", err)
+	}
+
+	if err := runGit(dir, "add", r.path); err != nil {
+		return fmt.Errorf("sync push failed: %w", err)
+	}
+	if err := runGit(dir, "diff", "--cached", "--quiet"); err == nil {
+		return nil // Nothing changed
+	}
+	if err := runGit(dir, "commit", "--quiet", "-m", "cde sync: update shared environments"); err != nil {
+		return fmt.Errorf("sync push failed: %w", err)
+	}
+	if err := runGit(dir, "push", "--quiet", "origin", "HEAD"); err != nil {
+		return fmt.Errorf("sync push failed: %w", err)
+	}
+	return nil
+}
+
+// getSyncStatePath returns the file holding the active profile's last synced document (the merge base)
+func getSyncStatePath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return profileStatePath(configPath, "sync_state.json"), nil
+}
+
+// loadSyncBase reads the last synced document; missing state is an empty document
+func loadSyncBase() (sharedDocument, error) {
+	path, err := getSyncStatePath()
+	if err != nil {
+		return sharedDocument{}, fmt.Errorf("sync state loading failed: %w", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return sharedDocument{}, fmt.Errorf("sync state loading failed: %w", err)
+	}
+	return parseSharedDocument(data)
+}
+
+// saveSyncBase records doc as the new merge base
+func saveSyncBase(doc sharedDocument) error {
+	path, err := getSyncStatePath()
+	if err != nil {
+		return fmt.Errorf("sync state saving failed: %w", err)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("sync state saving failed: %w", err)
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// printSyncChanges reports planned or applied changes
+func printSyncChanges(changes []syncChange, dryRun bool) {
+	prefix := ""
+	if dryRun {
+		prefix = "[dry-run] "
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%sAlready in sync.\n", prefix)
+		return
+	}
+	for _, change := range changes {
+		fmt.Printf("%s%-8s %s: %s\n", prefix, change.Action, change.Name, change.Detail)
+	}
+}
+
+// runSyncPull merges the shared environment list into the local configuration
+func runSyncPull(dryRun, theirs bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	settings, err := syncSettings(config)
+	if err != nil {
+		return err
+	}
+	remote, err := newSyncRemote(settings)
+	if err != nil {
+		return err
+	}
+
+	report := startProgress(tr("Fetching shared environments"), 0)
+	data, err := remote.fetch()
+	report.Finish("")
+	if err != nil {
+		return err
+	}
+	remoteDoc, err := parseSharedDocument(data)
+	if err != nil {
+		return err
+	}
+	base, err := loadSyncBase()
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		printSyncChanges(mergeRemote(&config, base, remoteDoc, theirs), true)
+		return nil
+	}
+
+	var changes []syncChange
+	if err := updateConfig(func(config *Config) error {
+		changes = mergeRemote(config, base, remoteDoc, theirs)
+		return nil
+	}); err != nil {
+		return err
+	}
+	printSyncChanges(changes, false)
+	return saveSyncBase(remoteDoc)
+}
+
+// runSyncPush publishes local environment definitions (without secrets)
+func runSyncPush(dryRun, force bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("configuration loading failed: %w", err)
+	}
+	settings, err := syncSettings(config)
+	if err != nil {
+		return err
+	}
+	remote, err := newSyncRemote(settings)
+	if err != nil {
+		return err
+	}
+
+	report := startProgress(tr("Fetching shared environments"), 0)
+	data, err := remote.fetch()
+	report.Finish("")
+	if err != nil {
+		return err
+	}
+	remoteDoc, err := parseSharedDocument(data)
+	if err != nil {
+		return err
+	}
+	base, err := loadSyncBase()
+	if err != nil {
+		return err
+	}
+
+	// Refuse to overwrite changes we have not merged yet
+	baseData, _ := json.Marshal(base)
+	remoteData, _ := json.Marshal(remoteDoc)
+	if !force && len(remoteDoc.Environments) > 0 && !bytes.Equal(baseData, remoteData) {
+		return fmt.Errorf("sync push rejected: remote changed since last sync; run 'cde sync pull' first (or --force)")
+	}
+
+	doc := sharedDocumentFor(config)
+	payload, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("sync document serialization failed: %w", err)
+	}
+	if err := verifyNoSecrets(payload, config); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("[dry-run] would push %d environment(s) without secrets:\n", len(doc.Environments))
+		for _, env := range doc.Environments {
+			fmt.Printf("[dry-run]   %s (%s)\n", env.Name, env.URL)
+		}
+		return nil
+	}
+
+	report = startProgress(tr("Publishing shared environments"), 0)
+	err = remote.store(payload)
+	report.Finish("")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pushed %d environment(s) (secrets excluded).\n", len(doc.Environments))
+	return saveSyncBase(doc)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedDocumentExcludesSecrets(t *testing.T) {
+	config := Config{Environments: []Environment{{
+		Name:    "prod",
+		URL:     "https://api.example.com",
+		APIKey:  "sk-prod-secret",
+		Keys:    map[string]string{"backup": "sk-backup-secret"},
+		EnvVars: map[string]string{"OPENAI_ORG": "org-1", "EXTRA_TOKEN": "tok-secret"},
+	}}}
+
+	data, err := json.Marshal(sharedDocumentFor(config))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	for _, secret := range []string{"sk-prod-secret", "sk-backup-secret", "tok-secret", "EXTRA_TOKEN"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("shared document contains %q: %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "org-1") {
+		t.Errorf("shared document lost non-secret env var: %s", data)
+	}
+	if err := verifyNoSecrets(data, config); err != nil {
+		t.Errorf("verifyNoSecrets rejected clean document: %v", err)
+	}
+	if err := verifyNoSecrets([]byte(`{"url":"sk-prod-secret"}`), config); err == nil {
+		t.Error("verifyNoSecrets accepted a leaked key")
+	}
+}
+
+func TestSyncSettingsValidation(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name     string
+		settings *SyncSettings
+		wantErr  bool
+	}{
+		{"not configured", nil, true},
+		{"git", &SyncSettings{Git: "git@example.com:team/envs.git"}, false},
+		{"url", &SyncSettings{URL: "https://example.com/envs.json"}, false},
+		{"both", &SyncSettings{Git: "repo", URL: "https://example.com"}, true},
+		{"secrets enabled", &SyncSettings{URL: "https://example.com", ExcludeSecrets: &disabled}, true},
+		{"escaping path", &SyncSettings{Git: "repo", Path: "../config.json"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Settings: &ConfigSettings{Sync: tt.settings}}
+			_, err := syncSettings(config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("syncSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeRemote(t *testing.T) {
+	shared := func(name, url string) sharedEnvironment {
+		return sharedEnvironment{Name: name, URL: url}
+	}
+	base := sharedDocument{Environments: []sharedEnvironment{
+		shared("same", "https://same.example.com"),
+		shared("remote-edit", "https://old.example.com"),
+		shared("local-edit", "https://old.example.com"),
+		shared("both-edit", "https://old.example.com"),
+		shared("gone", "https://gone.example.com"),
+	}}
+	remote := sharedDocument{Environments: []sharedEnvironment{
+		shared("same", "https://same.example.com"),
+		shared("remote-edit", "https://new.example.com"),
+		shared("local-edit", "https://old.example.com"),
+		shared("both-edit", "https://remote.example.com"),
+		shared("fresh", "https://fresh.example.com"),
+	}}
+	newConfig := func() Config {
+		return Config{Environments: []Environment{
+			{Name: "same", URL: "https://same.example.com", APIKey: "k1"},
+			{Name: "remote-edit", URL: "https://old.example.com", APIKey: "k2"},
+			{Name: "local-edit", URL: "https://mine.example.com", APIKey: "k3"},
+			{Name: "both-edit", URL: "https://mine.example.com", APIKey: "k4"},
+			{Name: "gone", URL: "https://gone.example.com", APIKey: "k5"},
+		}}
+	}
+	urlOf := func(config Config, name string) string {
+		if index, exists := findEnvironmentByName(config, name); exists {
+			return config.Environments[index].URL
+		}
+		return ""
+	}
+
+	config := newConfig()
+	changes := mergeRemote(&config, base, remote, false)
+	actions := map[string]string{}
+	for _, change := range changes {
+		actions[change.Name] = change.Action
+	}
+	want := map[string]string{"remote-edit": "update", "both-edit": "conflict", "fresh": "add", "gone": "remove"}
+	for name, action := range want {
+		if actions[name] != action {
+			t.Errorf("change for %s = %q, want %q (all: %v)", name, actions[name], action, actions)
+		}
+	}
+	if len(actions) != len(want) {
+		t.Errorf("unexpected changes: %v", actions)
+	}
+
+	if got := urlOf(config, "remote-edit"); got != "https://new.example.com" {
+		t.Errorf("remote-edit URL = %s", got)
+	}
+	if index, _ := findEnvironmentByName(config, "remote-edit"); config.Environments[index].APIKey != "k2" {
+		t.Error("merge dropped the local API key")
+	}
+	if got := urlOf(config, "local-edit"); got != "https://mine.example.com" {
+		t.Errorf("local-edit URL = %s, local change lost", got)
+	}
+	if got := urlOf(config, "both-edit"); got != "https://mine.example.com" {
+		t.Errorf("both-edit URL = %s, want local kept on conflict", got)
+	}
+	if _, exists := findEnvironmentByName(config, "gone"); exists || len(config.Trash) != 1 {
+		t.Error("environment removed upstream should move to trash")
+	}
+
+	config = newConfig()
+	mergeRemote(&config, base, remote, true)
+	if got := urlOf(config, "both-edit"); got != "https://remote.example.com" {
+		t.Errorf("both-edit URL with --theirs = %s", got)
+	}
+}
+
+func TestSyncPushAndPullOverHTTP(t *testing.T) {
+	oldPath := configPathOverride
+	defer func() { configPathOverride = oldPath }()
+
+	var mu sync.Mutex
+	var stored []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer team-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(stored)
+		case http.MethodPut:
+			stored, _ = ioutil.ReadAll(r.Body)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CDE_SYNC_TOKEN", "team-token")
+	t.Setenv("CDE_OFFLINE", "")
+
+	settings := &ConfigSettings{Sync: &SyncSettings{URL: server.URL + "/envs.json"}}
+
+	// Publisher
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	if err := saveConfig(Config{
+		Environments: []Environment{{Name: "shared", URL: "https://api.example.com", APIKey: "sk-publisher-secret", Model: "gpt-5"}},
+		Settings:     settings,
+	}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	if err := runSyncPush(true, false); err != nil {
+		t.Fatalf("dry-run push failed: %v", err)
+	}
+	if stored != nil {
+		t.Fatal("dry-run push wrote to the remote")
+	}
+	if err := runSyncPush(false, false); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	if strings.Contains(string(stored), "sk-publisher-secret") {
+		t.Fatalf("pushed document contains the API key: %s", stored)
+	}
+
+	// Subscriber
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	if err := saveConfig(Config{Environments: []Environment{}, Settings: settings}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	if err := runSyncPull(true, false); err != nil {
+		t.Fatalf("dry-run pull failed: %v", err)
+	}
+	if config, _ := loadConfig(); len(config.Environments) != 0 {
+		t.Fatal("dry-run pull modified the configuration")
+	}
+	if err := runSyncPull(false, false); err != nil {
+		t.Fatalf("pull failed: %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(config.Environments) != 1 || config.Environments[0].Model != "gpt-5" || config.Environments[0].APIKey != "" {
+		t.Fatalf("pulled environments = %+v", config.Environments)
+	}
+
+	// A stale subscriber cannot overwrite newer remote changes
+	stored = []byte(`{"version":1,"environments":[{"name":"other","url":"https://other.example.com"}]}`)
+	if err := runSyncPush(false, false); err == nil {
+		t.Error("push over unmerged remote changes should fail")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultTargetCommand is the CLI cde launches unless settings.target_command names another
+const defaultTargetCommand = "codex"
+
+// targetCommand is the CLI launched by this invocation; useTargetCommand sets it from the
+// loaded configuration
+var targetCommand = defaultTargetCommand
+
+// validateTargetCommand checks settings.target_command: a command name or path, without arguments
+func validateTargetCommand(name string) error {
+	if name == "" {
+		return nil
+	}
+	if strings.TrimSpace(name) != name || strings.ContainsAny(name, "\x00\r\n\t") {
+		return fmt.Errorf("'%s' must be a command name or path without surrounding spaces", name)
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("'%s' must be a command name or path, not a flag", name)
+	}
+	return nil
+}
+
+// targetCommandFor returns the configured target command, defaulting to codex
+func targetCommandFor(config Config) string {
+	if config.Settings != nil && config.Settings.TargetCommand != "" {
+		return config.Settings.TargetCommand
+	}
+	return defaultTargetCommand
+}
+
+// useTargetCommand makes config's target command the one launched, checked and shown in messages
+func useTargetCommand(config Config) {
+	targetCommand = targetCommandFor(config)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateTargetCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{name: "unset", command: ""},
+		{name: "name", command: "codex-fork"},
+		{name: "path", command: "/opt/forks/bin/codex"},
+		{name: "surrounding spaces", command: " codex", wantErr: true},
+		{name: "newline", command: "codex\n--help", wantErr: true},
+		{name: "flag", command: "--codex", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTargetCommand(tt.command); (err != nil) != tt.wantErr {
+				t.Errorf("validateTargetCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLaunchUsesTargetCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+	defer func() { targetCommand = defaultTargetCommand }()
+
+	// Only the fork is on PATH; it records its arguments
+	binDir := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\n[ \"$1\" = --version ] && exit 0\necho \"$*\" > \"" + calls + "\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex-fork"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+	config := Config{
+		Environments: []Environment{{Name: "gw", URL: "https://api.openai.com/v1", APIKey: "sk-target-test", Model: "gpt-5"}},
+		Settings:     &ConfigSettings{LaunchMode: launchModeSubprocess, TargetCommand: "codex-fork"},
+	}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := runDefaultWithOptions("gw", []string{"exec", "hi"}, launchOptions{}); err != nil {
+		t.Fatalf("launch failed: %v", err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("codex-fork was not started: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "-m gpt-5 exec hi" {
+		t.Errorf("codex-fork started with %q", got)
+	}
+
+	// Errors name the configured command
+	config.Settings.TargetCommand = "missing-fork"
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	err = runDefaultWithOptions("gw", []string{"exec", "hi"}, launchOptions{})
+	if err == nil || !strings.Contains(err.Error(), "missing-fork not found in PATH") {
+		t.Errorf("expected an error naming missing-fork, got %v", err)
+	}
+}
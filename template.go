@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templateValuePattern limits substituted values so a variable cannot change the URL's
+// scheme, host boundaries or path (no '/', '@', '?', '#', ':' or whitespace)
+var templateValuePattern = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+
+// fillPlaceholders replaces each {name} in s with value(name); a false result keeps the placeholder
+func fillPlaceholders(s string, value func(name string) (string, bool)) string {
+	return urlPlaceholderPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := value(m[1 : len(m)-1]); ok {
+			return v
+		}
+		return m
+	})
+}
+
+// templatePlaceholders returns the distinct placeholders in the URLs and env_vars values,
+// URLs first, then env_vars in key order
+func templatePlaceholders(env Environment) []string {
+	sources := append([]string{env.URL}, env.URLs...)
+	keys := make([]string, 0, len(env.EnvVars))
+	for key := range env.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sources = append(sources, env.EnvVars[key])
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, source := range sources {
+		for _, match := range urlPlaceholderPattern.FindAllStringSubmatch(source, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// validateTemplateURL validates a URL that may contain {placeholders} by checking it with
+// a sample value substituted
+func validateTemplateURL(rawURL string) error {
+	return validateURL(fillPlaceholders(rawURL, func(string) (string, bool) { return "placeholder", true }))
+}
+
+// validateTemplateValue checks one --var or prompted value
+func validateTemplateValue(name, value string) error {
+	if !templateValuePattern.MatchString(value) {
+		return fmt.Errorf("value for {%s} must contain only letters, digits, '.', '_', '~' or '-'", name)
+	}
+	return nil
+}
+
+// parseTemplateVars parses "name=value" pairs given with --var
+func parseTemplateVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || !urlPlaceholderPattern.MatchString("{"+name+"}") {
+			return nil, fmt.Errorf("--var expects name=value, got '%s'", pair)
+		}
+		if err := validateTemplateValue(name, value); err != nil {
+			return nil, err
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// applyTemplateVars substitutes the given values without prompting; missing placeholders stay
+func applyTemplateVars(env Environment, vars map[string]string) Environment {
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+	env.URL = fillPlaceholders(env.URL, lookup)
+	if len(env.URLs) > 0 {
+		urls := make([]string, len(env.URLs))
+		for i, u := range env.URLs {
+			urls[i] = fillPlaceholders(u, lookup)
+		}
+		env.URLs = urls
+	}
+	if len(env.EnvVars) > 0 {
+		expanded := make(map[string]string, len(env.EnvVars))
+		for key, value := range env.EnvVars {
+			expanded[key] = fillPlaceholders(value, lookup)
+		}
+		env.EnvVars = expanded
+	}
+	return env
+}
+
+// resolveEnvironmentTemplate fills every placeholder of env from vars, prompting for the rest
+// when prompt is set, and returns the values used. Unknown variables and missing values are errors.
+func resolveEnvironmentTemplate(env Environment, vars map[string]string, prompt bool) (Environment, map[string]string, error) {
+	names := templatePlaceholders(env)
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	for name := range vars {
+		if !known[name] {
+			if len(names) == 0 {
+				return env, nil, fmt.Errorf("--var %s given but environment '%s' has no template placeholders", name, env.Name)
+			}
+			return env, nil, fmt.Errorf("unknown template variable '%s' for environment '%s' (placeholders: %s)", name, env.Name, strings.Join(names, ", "))
+		}
+	}
+
+	values := make(map[string]string, len(names))
+	var missing []string
+	for _, name := range names {
+		if value, ok := vars[name]; ok {
+			values[name] = value
+			continue
+		}
+		if !prompt {
+			missing = append(missing, name)
+			continue
+		}
+		for {
+			value, err := regularInput(fmt.Sprintf("%s (for %s): ", name, env.Name))
+			if err != nil {
+				return env, nil, fmt.Errorf("failed to read template value: %w", err)
+			}
+			if err := validateTemplateValue(name, value); err != nil {
+				fmt.Printf("Invalid value: %v\n", err)
+				continue
+			}
+			values[name] = value
+			break
+		}
+	}
+	if len(missing) > 0 {
+		flags := make([]string, len(missing))
+		for i, name := range missing {
+			flags[i] = "--var " + name + "=..."
+		}
+		return env, nil, fmt.Errorf("environment '%s' needs template values for %s (pass %s)", env.Name, strings.Join(missing, ", "), strings.Join(flags, " "))
+	}
+	return applyTemplateVars(env, values), values, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvironmentTemplate(t *testing.T) {
+	env := Environment{
+		Name:    "gateway",
+		URL:     "https://{region}.gateway.corp/{version}",
+		APIKey:  "sk-gw-1234567890",
+		EnvVars: map[string]string{"GW_REGION": "{region}", "GW_TEAM": "team-{team}", "STATIC": "x"},
+	}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		wantURL string
+		wantEnv map[string]string
+		wantErr string
+	}{
+		{
+			name:    "all supplied",
+			vars:    map[string]string{"region": "eu", "version": "v1", "team": "ml"},
+			wantURL: "https://eu.gateway.corp/v1",
+			wantEnv: map[string]string{"GW_REGION": "eu", "GW_TEAM": "team-ml", "STATIC": "x"},
+		},
+		{name: "missing", vars: map[string]string{"region": "eu"}, wantErr: "needs template values for version, team (pass --var version=... --var team=...)"},
+		{name: "unknown", vars: map[string]string{"region": "eu", "version": "v1", "team": "ml", "zone": "a"}, wantErr: "unknown template variable 'zone'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := resolveEnvironmentTemplate(env, tt.vars, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.wantURL || !reflect.DeepEqual(got.EnvVars, tt.wantEnv) {
+				t.Errorf("got %s %v, want %s %v", got.URL, got.EnvVars, tt.wantURL, tt.wantEnv)
+			}
+			if env.EnvVars["GW_REGION"] != "{region}" {
+				t.Error("the configured environment was modified")
+			}
+		})
+	}
+
+	if _, _, err := resolveEnvironmentTemplate(Environment{Name: "plain", URL: "https://api.openai.com/v1"}, map[string]string{"region": "eu"}, false); err == nil {
+		t.Error("--var for an environment without placeholders should fail")
+	}
+}
+
+func TestParseTemplateVars(t *testing.T) {
+	tests := []struct {
+		pair    string
+		wantErr bool
+	}{
+		{pair: "region=eu-west-1"},
+		{pair: "v=1.2"},
+		{pair: "region", wantErr: true},
+		{pair: "bad-name=eu", wantErr: true},
+		{pair: "region=evil.com/x", wantErr: true},
+		{pair: "region=a@b", wantErr: true},
+		{pair: "region=", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := parseTemplateVars([]string{tt.pair}); (err != nil) != tt.wantErr {
+			t.Errorf("parseTemplateVars(%q) error = %v, wantErr %v", tt.pair, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateTemplateURL(t *testing.T) {
+	if err := validateTemplateURL("https://{region}.gateway.corp/v1"); err != nil {
+		t.Errorf("template URL rejected: %v", err)
+	}
+	if err := validateTemplateURL("ftp://{region}.gateway.corp"); err == nil {
+		t.Error("template with a bad scheme accepted")
+	}
+}
+
+func TestPlanLaunchTemplateVars(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := configPathOverride
+	configPathOverride = configPath
+	defer func() { configPathOverride = original }()
+
+	if err := saveConfig(Config{Environments: []Environment{{Name: "gw", URL: "https://{region}.gateway.corp/v1", APIKey: "sk-gw-1234567890"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := parseArguments([]string{"-e", "gw", "--var", "region=eu", "--", "exec", "hi"})
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	opts := launchOptionsFromFlags(result)
+	opts.NoPrompt = true
+	plan, err := planLaunch(result.CCEFlags["env"], result.ClaudeArgs, opts)
+	if err != nil {
+		t.Fatalf("planLaunch() error = %v", err)
+	}
+	if plan.Environment.URL != "https://eu.gateway.corp/v1" {
+		t.Errorf("URL = %s", plan.Environment.URL)
+	}
+
+	if _, err := planLaunch("gw", nil, launchOptions{NoPrompt: true}); err == nil || !strings.Contains(err.Error(), "--var region=") {
+		t.Errorf("missing --var error = %v", err)
+	}
+	if result := parseArguments([]string{"--var", "region=a/b"}); result.Error == nil {
+		t.Error("unsafe --var value accepted by the parser")
+	}
+}
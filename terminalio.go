@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// TerminalIO is the terminal interactive menus, forms and prompts read keys from and draw on.
+// The real one is stdin/stdout; tests script key presses with scriptedTerminal, and
+// CDE_TERMINAL_RECORD / CDE_TERMINAL_REPLAY record and replay a session for bug reports.
+type TerminalIO interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	IsTerminal() bool
+	Size() (width, height int, err error)
+	MakeRaw() error // Switch to raw mode, remembering the previous mode for Restore
+	Restore() error // Undo MakeRaw; does nothing when the terminal is not in raw mode
+}
+
+// Variables that record an interactive session to a file, or replay one instead of reading keys
+const (
+	terminalRecordEnv = "CDE_TERMINAL_RECORD"
+	terminalReplayEnv = "CDE_TERMINAL_REPLAY"
+)
+
+// uiTerminal is the terminal the UI uses; tests replace it
+var uiTerminal TerminalIO = &stdTerminal{}
+
+// stdTerminal is the process's own terminal: keys from stdin, frames to menuOutput
+type stdTerminal struct {
+	state *terminalState
+}
+
+// Read reads keys from stdin
+func (t *stdTerminal) Read(p []byte) (int, error) {
+	return os.Stdin.Read(p)
+}
+
+// Write draws on menuOutput
+func (t *stdTerminal) Write(p []byte) (int, error) {
+	return menuOutput.Write(p)
+}
+
+// IsTerminal reports whether stdin is attached to a terminal
+func (t *stdTerminal) IsTerminal() bool {
+	return term.IsTerminal(stdinFd())
+}
+
+// Size returns the terminal's dimensions
+func (t *stdTerminal) Size() (int, int, error) {
+	return term.GetSize(stdinFd())
+}
+
+// MakeRaw puts stdin into raw mode
+func (t *stdTerminal) MakeRaw() error {
+	fd := stdinFd()
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	t.state = &terminalState{fd: fd, oldState: oldState}
+	return nil
+}
+
+// Restore returns stdin to the mode it had before MakeRaw
+func (t *stdTerminal) Restore() error {
+	if t.state == nil {
+		return nil
+	}
+	state := t.state
+	t.state = nil
+	return state.restore()
+}
+
+// restoreTerminal undoes uiTerminal.MakeRaw; deferred by every raw-mode reader
+func restoreTerminal() {
+	if err := uiTerminal.Restore(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to restore terminal: %v\n", err)
+	}
+}
+
+// scriptedTerminal plays back queued input, one chunk per Read like keys arriving, and captures
+// what is drawn. Tests script key presses with it; CDE_TERMINAL_REPLAY feeds it a recording.
+type scriptedTerminal struct {
+	width  int
+	height int
+	input  []string
+	out    io.Writer
+	raw    bool
+}
+
+// newScriptedTerminal creates a width x height terminal that reads input and draws on out
+func newScriptedTerminal(width, height int, out io.Writer, input ...string) *scriptedTerminal {
+	return &scriptedTerminal{width: width, height: height, input: input, out: out}
+}
+
+// Read returns the next input chunk, or io.EOF once the script is used up
+func (t *scriptedTerminal) Read(p []byte) (int, error) {
+	if len(t.input) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, t.input[0])
+	if n < len(t.input[0]) {
+		t.input[0] = t.input[0][n:]
+	} else {
+		t.input = t.input[1:]
+	}
+	return n, nil
+}
+
+// Write captures output
+func (t *scriptedTerminal) Write(p []byte) (int, error) {
+	return t.out.Write(p)
+}
+
+// IsTerminal is always true so the interactive paths run
+func (t *scriptedTerminal) IsTerminal() bool {
+	return true
+}
+
+// Size returns the scripted dimensions
+func (t *scriptedTerminal) Size() (int, int, error) {
+	return t.width, t.height, nil
+}
+
+// MakeRaw records that raw mode is on
+func (t *scriptedTerminal) MakeRaw() error {
+	t.raw = true
+	return nil
+}
+
+// Restore records that raw mode is off
+func (t *scriptedTerminal) Restore() error {
+	t.raw = false
+	return nil
+}
+
+// terminalRecording is the file CDE_TERMINAL_RECORD writes and CDE_TERMINAL_REPLAY reads
+type terminalRecording struct {
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Input  []string `json:"input"` // One entry per read
+}
+
+// recordingTerminal passes through to a terminal and saves everything read from it to path.
+// The file is rewritten after every read, so it is complete even if cde exits abruptly.
+type recordingTerminal struct {
+	TerminalIO
+	path string
+
+	mu        sync.Mutex
+	recording terminalRecording
+	redact    bool // Secret input is being read; record it masked
+}
+
+// newRecordingTerminal records reads from t to path
+func newRecordingTerminal(t TerminalIO, path string) *recordingTerminal {
+	r := &recordingTerminal{TerminalIO: t, path: path, recording: terminalRecording{Width: 80, Height: 24, Input: []string{}}}
+	if width, height, err := t.Size(); err == nil {
+		r.recording.Width, r.recording.Height = width, height
+	}
+	return r
+}
+
+// Read reads from the terminal and appends what arrived to the recording
+func (r *recordingTerminal) Read(p []byte) (int, error) {
+	n, err := r.TerminalIO.Read(p)
+	if n > 0 {
+		r.mu.Lock()
+		chunk := p[:n]
+		if r.redact {
+			chunk = maskSecretInput(chunk)
+		}
+		r.recording.Input = append(r.recording.Input, string(chunk))
+		data, marshalErr := json.MarshalIndent(r.recording, "", "  ")
+		r.mu.Unlock()
+		if marshalErr == nil {
+			ioutil.WriteFile(r.path, data, 0600)
+		}
+	}
+	return n, err
+}
+
+// maskSecretInput replaces printable characters with '*', keeping Enter, Backspace and Ctrl keys
+// so a replay still follows the same path
+func maskSecretInput(input []byte) []byte {
+	masked := make([]byte, len(input))
+	for i, b := range input {
+		masked[i] = b
+		if b >= 32 && b != 127 {
+			masked[i] = '*'
+		}
+	}
+	return masked
+}
+
+// hideFromRecording masks what is read until the returned function is called; secureInput uses
+// it so API keys never reach a recording
+func hideFromRecording() func() {
+	r, ok := uiTerminal.(*recordingTerminal)
+	if !ok {
+		return func() {}
+	}
+	r.mu.Lock()
+	r.redact = true
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		r.redact = false
+		r.mu.Unlock()
+	}
+}
+
+// loadTerminalRecording reads a CDE_TERMINAL_RECORD file
+func loadTerminalRecording(path string) (terminalRecording, error) {
+	var recording terminalRecording
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return recording, fmt.Errorf("failed to read terminal recording: %w", err)
+	}
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return recording, fmt.Errorf("invalid terminal recording %s: %w", path, err)
+	}
+	return recording, nil
+}
+
+// setupTerminalIO switches uiTerminal to a replay of CDE_TERMINAL_REPLAY, or records it to
+// CDE_TERMINAL_RECORD, and returns the function that puts the previous terminal back
+func setupTerminalIO() (func(), error) {
+	original := uiTerminal
+	restore := func() { uiTerminal = original }
+
+	if path := os.Getenv(terminalReplayEnv); path != "" {
+		recording, err := loadTerminalRecording(path)
+		if err != nil {
+			return restore, err
+		}
+		uiTerminal = newScriptedTerminal(recording.Width, recording.Height, menuOutput, recording.Input...)
+		logDebugf("replaying %d terminal reads from %s", len(recording.Input), path)
+		return restore, nil
+	}
+	if path := os.Getenv(terminalRecordEnv); path != "" {
+		uiTerminal = newRecordingTerminal(original, path)
+		logDebugf("recording terminal input to %s", path)
+	}
+	return restore, nil
+}
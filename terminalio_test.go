@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useScriptedTerminal makes the UI read input and draw on an ANSI-capable scripted terminal
+func useScriptedTerminal(t *testing.T, input ...string) (*scriptedTerminal, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("ACCESSIBLE", "")
+	out := &bytes.Buffer{}
+	scripted := newScriptedTerminal(100, 30, out, input...)
+	original := uiTerminal
+	uiTerminal = scripted
+	cleanupDisplayState()
+	t.Cleanup(func() {
+		uiTerminal = original
+		cleanupDisplayState()
+	})
+	return scripted, out
+}
+
+func TestScriptedEnvironmentSelection(t *testing.T) {
+	config := Config{Environments: []Environment{
+		{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod-1234567890"},
+		{Name: "staging", URL: "https://staging.example.com/v1", APIKey: "sk-staging-1234567890"},
+		{Name: "dev", URL: "http://localhost:8080/v1", APIKey: "sk-dev-1234567890"},
+	}}
+
+	tests := []struct {
+		name    string
+		input   []string
+		want    string
+		wantErr bool
+	}{
+		{"enter picks first", []string{"\r"}, "prod", false},
+		{"arrow down", []string{"\x1b[B", "\r"}, "staging", false},
+		{"vim keys", []string{"j", "j", "k", "j", "\r"}, "dev", false},
+		{"wraps upward", []string{"\x1b[A", "\n"}, "dev", false},
+		{"escape cancels", []string{"\x1b"}, "", true},
+		{"input ends", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripted, out := useScriptedTerminal(t, tt.input...)
+			env, err := selectEnvironmentWithArrows(config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectEnvironmentWithArrows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if env.Name != tt.want {
+				t.Errorf("selected %q, want %q", env.Name, tt.want)
+			}
+			if scripted.raw {
+				t.Error("terminal left in raw mode")
+			}
+			if !strings.Contains(out.String(), "prod") {
+				t.Errorf("menu was not drawn on the terminal:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestScriptedStartMenu(t *testing.T) {
+	tests := []struct {
+		input []string
+		want  int
+	}{
+		{[]string{"\r"}, 0},
+		{[]string{"\x1b[B", "\r"}, 1},
+		{[]string{"3", "\r"}, 2},
+		{[]string{"\x1b"}, 3},
+	}
+	for _, tt := range tests {
+		useScriptedTerminal(t, tt.input...)
+		got, err := selectStartAction(startActions())
+		if err != nil || got != tt.want {
+			t.Errorf("selectStartAction(%q) = %d, %v; want %d", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestScriptedPrompts(t *testing.T) {
+	useScriptedTerminal(t, "prod\n")
+	if got, err := regularInput(""); err != nil || got != "prod" {
+		t.Errorf("regularInput() = %q, %v; want prod", got, err)
+	}
+
+	scripted, _ := useScriptedTerminal(t, "sk-1", "\x7f", "2\r")
+	if got, err := secureInput(""); err != nil || got != "sk-2" {
+		t.Errorf("secureInput() = %q, %v; want sk-2", got, err)
+	}
+	if scripted.raw {
+		t.Error("secureInput left the terminal in raw mode")
+	}
+}
+
+func TestTerminalRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	scripted, _ := useScriptedTerminal(t, "j", "\r", "sk-secret\r")
+	recorder := newRecordingTerminal(scripted, path)
+	uiTerminal = recorder
+
+	if _, err := selectStartAction(startActions()); err != nil {
+		t.Fatalf("selectStartAction() failed: %v", err)
+	}
+	if key, err := secureInput(""); err != nil || key != "sk-secret" {
+		t.Fatalf("secureInput() = %q, %v; want sk-secret", key, err)
+	}
+
+	recording, err := loadTerminalRecording(path)
+	if err != nil {
+		t.Fatalf("loadTerminalRecording() failed: %v", err)
+	}
+	// secureInput reads a byte at a time
+	want := terminalRecording{Width: 100, Height: 30, Input: []string{"j", "\r"}}
+	for range "sk-secret" {
+		want.Input = append(want.Input, "*")
+	}
+	want.Input = append(want.Input, "\r")
+	if !reflect.DeepEqual(recording, want) {
+		t.Errorf("recording = %+v, want %+v", recording, want)
+	}
+
+	oldOutput := menuOutput
+	menuOutput = &bytes.Buffer{}
+	defer func() { menuOutput = oldOutput }()
+	t.Setenv(terminalReplayEnv, path)
+	restore, err := setupTerminalIO()
+	defer restore()
+	if err != nil {
+		t.Fatalf("setupTerminalIO() failed: %v", err)
+	}
+	if got, err := selectStartAction(startActions()); err != nil || got != 1 {
+		t.Errorf("replayed selectStartAction() = %d, %v; want 1", got, err)
+	}
+}
+
+func TestSetupTerminalIOMissingReplay(t *testing.T) {
+	t.Setenv(terminalReplayEnv, filepath.Join(t.TempDir(), "missing.json"))
+	restore, err := setupTerminalIO()
+	defer restore()
+	if err == nil {
+		t.Error("setupTerminalIO() succeeded with a missing recording")
+	}
+}
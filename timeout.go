@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// validateSessionTimeout checks a session limit such as "30m" or "1h30m"; "0" means no limit
+func validateSessionTimeout(value string) error {
+	if value == "" {
+		return nil
+	}
+	limit, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a duration (e.g. 90s, 30m, 2h)", value)
+	}
+	if limit < 0 {
+		return fmt.Errorf("'%s' must not be negative", value)
+	}
+	return nil
+}
+
+// sessionTimeout returns the time limit for a launch of env: --timeout when given, otherwise the
+// environment's timeout. Both are validated before launch, so parse errors mean no limit.
+func sessionTimeout(env Environment, flag string) time.Duration {
+	value := env.Timeout
+	if flag != "" {
+		value = flag
+	}
+	if value == "" {
+		return 0
+	}
+	limit, err := time.ParseDuration(value)
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSessionTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		flag    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "environment default", env: "30m", want: 30 * time.Minute},
+		{name: "flag overrides", env: "30m", flag: "90s", want: 90 * time.Second},
+		{name: "flag disables", env: "30m", flag: "0", want: 0},
+		{name: "not a duration", flag: "soon", wantErr: true},
+		{name: "negative", flag: "-5m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSessionTimeout(tt.flag); (err != nil) != tt.wantErr {
+				t.Fatalf("validateSessionTimeout(%q) error = %v, wantErr %v", tt.flag, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := sessionTimeout(Environment{Timeout: tt.env}, tt.flag); got != tt.want {
+				t.Errorf("sessionTimeout() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaunchTimeoutStopsCodex(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake codex script requires a POSIX shell")
+	}
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+
+	// Fake codex that answers the version check and otherwise hangs until stopped
+	binDir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1\" = --version ] && exit 0\ntrap 'exit 0' TERM\nwhile :; do sleep 0.05; done\n"
+	if err := os.WriteFile(filepath.Join(binDir, "codex"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake codex: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Exec launch mode: the timeout alone makes cde run codex as a subprocess
+	config := Config{Environments: []Environment{{Name: "ci", URL: "https://api.openai.com/v1", APIKey: "sk-timeout-test", Timeout: "1h"}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	err := runDefaultWithOptions("ci", []string{"exec", "hi"}, launchOptions{Timeout: "200ms"})
+	var childExit *childExitError
+	if !errors.As(err, &childExit) || childExit.Code != sessionTimeoutExitCode {
+		t.Fatalf("expected exit status %d, got %v", sessionTimeoutExitCode, err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultTrashTTLDays is how long removed environments stay restorable
+const defaultTrashTTLDays = 30
+
+// TrashedEnvironment is a removed environment kept for 'cde restore'
+type TrashedEnvironment struct {
+	Environment Environment `json:"environment"`
+	RemovedAt   time.Time   `json:"removed_at"`
+}
+
+// TrashSettings configures retention of removed environments
+type TrashSettings struct {
+	TTLDays int `json:"ttl_days,omitempty"` // Purge entries older than this (default 30)
+}
+
+// trashTTL returns the configured retention period
+func trashTTL(config Config) time.Duration {
+	days := defaultTrashTTLDays
+	if config.Settings != nil && config.Settings.Trash != nil && config.Settings.Trash.TTLDays > 0 {
+		days = config.Settings.Trash.TTLDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// moveToTrash removes the named environment and records it in the trash.
+// An older trash entry with the same name is replaced.
+func moveToTrash(config *Config, name string, now time.Time) error {
+	index, exists := findEnvironmentByName(*config, name)
+	if !exists {
+		return fmt.Errorf("environment '%s' not found", name)
+	}
+
+	env := config.Environments[index]
+	dropFromTrash(config, name)
+	config.Trash = append(config.Trash, TrashedEnvironment{Environment: env, RemovedAt: now.UTC()})
+	return removeEnvironmentFromConfig(config, name)
+}
+
+// dropFromTrash deletes any trash entry with the given name
+func dropFromTrash(config *Config, name string) {
+	kept := config.Trash[:0]
+	for _, entry := range config.Trash {
+		if entry.Environment.Name != name {
+			kept = append(kept, entry)
+		}
+	}
+	config.Trash = kept
+}
+
+// purgeExpiredTrash drops trash entries older than the TTL and returns how many were removed
+func purgeExpiredTrash(config *Config, now time.Time) int {
+	cutoff := now.Add(-trashTTL(*config))
+	kept := config.Trash[:0]
+	for _, entry := range config.Trash {
+		if entry.RemovedAt.After(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	purged := len(config.Trash) - len(kept)
+	config.Trash = kept
+	return purged
+}
+
+// restoreFromTrash moves a trashed environment back into the active list
+func restoreFromTrash(config *Config, name string) (Environment, error) {
+	if _, exists := findEnvironmentByName(*config, name); exists {
+		return Environment{}, fmt.Errorf("environment '%s' already exists; remove or rename it first", name)
+	}
+
+	for _, entry := range config.Trash {
+		if entry.Environment.Name == name {
+			dropFromTrash(config, name)
+			config.Environments = append(config.Environments, entry.Environment)
+			return entry.Environment, nil
+		}
+	}
+
+	names := make([]string, 0, len(config.Trash))
+	for _, entry := range config.Trash {
+		names = append(names, entry.Environment.Name)
+	}
+	if len(names) == 0 {
+		return Environment{}, fmt.Errorf("environment '%s' not found in trash (trash is empty)", name)
+	}
+	sort.Strings(names)
+	return Environment{}, fmt.Errorf("environment '%s' not found in trash (available: %s)", name, strings.Join(names, ", "))
+}
+
+// runRestore brings a removed environment back from the trash
+func runRestore(name string) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("invalid environment name: %w", err)
+	}
+
+	if err := updateConfig(func(config *Config) error {
+		purgeExpiredTrash(config, time.Now())
+		_, err := restoreFromTrash(config, name)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Printf("Environment '%s' restored.\n", name); err != nil {
+		return fmt.Errorf("failed to display success message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMoveToTrashAndRestore(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	config := Config{Environments: []Environment{
+		{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod1234567890"},
+		{Name: "dev", URL: "https://dev.example.com/v1", APIKey: "sk-dev1234567890"},
+	}}
+
+	if err := moveToTrash(&config, "prod", now); err != nil {
+		t.Fatalf("moveToTrash failed: %v", err)
+	}
+	if len(config.Environments) != 1 || len(config.Trash) != 1 || !config.Trash[0].RemovedAt.Equal(now) {
+		t.Fatalf("unexpected state after trash: %+v", config)
+	}
+	if err := moveToTrash(&config, "missing", now); err == nil {
+		t.Error("expected error trashing unknown environment")
+	}
+
+	env, err := restoreFromTrash(&config, "prod")
+	if err != nil {
+		t.Fatalf("restoreFromTrash failed: %v", err)
+	}
+	if env.APIKey != "sk-prod1234567890" || len(config.Environments) != 2 || len(config.Trash) != 0 {
+		t.Errorf("unexpected state after restore: %+v", config)
+	}
+
+	if _, err := restoreFromTrash(&config, "prod"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	if _, err := restoreFromTrash(&config, "ghost"); err == nil || !strings.Contains(err.Error(), "trash is empty") {
+		t.Errorf("expected empty trash error, got %v", err)
+	}
+}
+
+func TestMoveToTrashReplacesSameName(t *testing.T) {
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := Config{
+		Environments: []Environment{{Name: "prod", URL: "https://new.example.com", APIKey: "sk-new1234567890"}},
+		Trash:        []TrashedEnvironment{{Environment: Environment{Name: "prod", URL: "https://old.example.com"}, RemovedAt: first}},
+	}
+
+	if err := moveToTrash(&config, "prod", first.Add(time.Hour)); err != nil {
+		t.Fatalf("moveToTrash failed: %v", err)
+	}
+	if len(config.Trash) != 1 || config.Trash[0].Environment.URL != "https://new.example.com" {
+		t.Errorf("expected newest entry to replace older one, got %+v", config.Trash)
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	config := Config{Trash: []TrashedEnvironment{
+		{Environment: Environment{Name: "old"}, RemovedAt: now.Add(-31 * 24 * time.Hour)},
+		{Environment: Environment{Name: "recent"}, RemovedAt: now.Add(-2 * 24 * time.Hour)},
+	}}
+
+	if purged := purgeExpiredTrash(&config, now); purged != 1 {
+		t.Errorf("expected 1 purged entry, got %d", purged)
+	}
+	if len(config.Trash) != 1 || config.Trash[0].Environment.Name != "recent" {
+		t.Errorf("unexpected trash after purge: %+v", config.Trash)
+	}
+
+	config.Settings = &ConfigSettings{Trash: &TrashSettings{TTLDays: 1}}
+	if purged := purgeExpiredTrash(&config, now); purged != 1 || len(config.Trash) != 0 {
+		t.Errorf("expected custom TTL to purge remaining entry, trash=%+v", config.Trash)
+	}
+}
+
+func TestRemoveThenRestoreCommand(t *testing.T) {
+	original := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = original }()
+
+	config := Config{Environments: []Environment{{Name: "prod", URL: "https://api.openai.com/v1", APIKey: "sk-prod1234567890"}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	if err := handleCommand([]string{"remove", "prod", "--force"}); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(loaded.Environments) != 0 || len(loaded.Trash) != 1 {
+		t.Fatalf("expected environment in trash, got %+v", loaded)
+	}
+
+	if err := handleCommand([]string{"restore", "prod"}); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	loaded, err = loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if len(loaded.Environments) != 1 || len(loaded.Trash) != 0 || loaded.Environments[0].APIKey != "sk-prod1234567890" {
+		t.Errorf("expected environment restored, got %+v", loaded)
+	}
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
-	"syscall"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -51,6 +54,7 @@ type EnvironmentDisplay struct {
 type DisplayState struct {
 	// Content tracking
 	currentLines []string // Current displayed lines
+	changedLines []int    // Rows that differ from the previous render
 	headerLine   string   // Menu header text
 	footerLine   string   // Optional footer/instructions
 
@@ -107,19 +111,19 @@ func (ds *DisplayState) UpdateContent(lines []string, selection int) {
 		return
 	}
 
-	// Check if content changed
+	// A different line count needs a full render; otherwise only the changed rows are redrawn
 	contentChanged := len(lines) != len(ds.currentLines)
+	ds.changedLines = nil
 	if !contentChanged {
 		for i, line := range lines {
-			if i >= len(ds.currentLines) || line != ds.currentLines[i] {
-				contentChanged = true
-				break
+			if line != ds.currentLines[i] {
+				ds.changedLines = append(ds.changedLines, i)
 			}
 		}
 	}
 
-	// Check if only selection changed
-	selectionChanged := selection != ds.currentSelection && !contentChanged
+	// Moving the selection rewrites the rows it left and entered
+	selectionChanged := !contentChanged && len(ds.changedLines) > 0
 
 	// Update state
 	ds.currentLines = make([]string, len(lines))
@@ -137,6 +141,7 @@ func (ds *DisplayState) ClearDisplay() {
 	}
 
 	ds.currentLines = []string{}
+	ds.changedLines = nil
 	ds.headerLine = ""
 	ds.footerLine = ""
 	ds.lastSelection = -1
@@ -157,6 +162,40 @@ func (ds *DisplayState) RecoverFromError() error {
 	return nil
 }
 
+// menuOutput receives what the real terminal draws; tests replace it to capture menu frames
+var menuOutput io.Writer = os.Stdout
+
+// frameBuffer composes a menu frame in memory so it reaches the terminal in one write
+type frameBuffer struct {
+	buf bytes.Buffer
+	out io.Writer
+}
+
+// newFrameBuffer creates a frameBuffer that flushes to uiTerminal
+func newFrameBuffer() *frameBuffer {
+	return &frameBuffer{out: uiTerminal}
+}
+
+// WriteString appends s to the frame
+func (fb *frameBuffer) WriteString(s string) {
+	fb.buf.WriteString(s)
+}
+
+// Printf appends formatted text to the frame
+func (fb *frameBuffer) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(&fb.buf, format, args...)
+}
+
+// Flush writes the composed frame and empties the buffer
+func (fb *frameBuffer) Flush() error {
+	if fb.buf.Len() == 0 {
+		return nil
+	}
+	_, err := fb.out.Write(fb.buf.Bytes())
+	fb.buf.Reset()
+	return err
+}
+
 // TextPositioner provides ANSI-free cursor positioning and line control
 type TextPositioner struct {
 	width int
@@ -184,15 +223,24 @@ func (tp *TextPositioner) ClearLine() string {
 	return tp.MoveToStartOfLine() + tp.ClearToEndOfLine() + tp.MoveToStartOfLine()
 }
 
+// FitLine truncates plain text to the terminal width; color it afterwards so the escape
+// sequences survive
+func (tp *TextPositioner) FitLine(content string) string {
+	if len(content) <= tp.width || tp.width < 3 {
+		return content
+	}
+	return content[:tp.width-3] + "..."
+}
+
 // OverwriteLine creates a string that overwrites a line with new content
 func (tp *TextPositioner) OverwriteLine(content string) string {
-	// Ensure content doesn't exceed terminal width
-	if len(content) > tp.width {
-		content = content[:tp.width-3] + "..."
+	// Ensure content doesn't exceed terminal width (colors are dropped rather than cut mid-sequence)
+	if visibleLen(content) > tp.width {
+		content = tp.FitLine(ansiEscapePattern.ReplaceAllString(content, ""))
 	}
 
 	// Pad content to full width to clear any remaining characters
-	paddedContent := content + strings.Repeat(" ", tp.width-len(content))
+	paddedContent := content + strings.Repeat(" ", tp.width-visibleLen(content))
 
 	return tp.MoveToStartOfLine() + paddedContent + tp.MoveToStartOfLine()
 }
@@ -201,7 +249,8 @@ func (tp *TextPositioner) OverwriteLine(content string) string {
 type LineRenderer struct {
 	state      *DisplayState
 	positioner *TextPositioner
-	useANSI    bool // Optional enhancement only
+	useANSI    bool       // Optional enhancement only
+	theme      colorTheme // Applied only when useANSI is set
 }
 
 // newLineRenderer creates a LineRenderer with display state
@@ -215,21 +264,26 @@ func newLineRenderer(state *DisplayState, useANSI bool) *LineRenderer {
 
 // RenderMenu renders the complete environment menu using stateful display
 func (lr *LineRenderer) RenderMenu(environments []Environment, selectedIndex int, header string) {
+	// Detect terminal layout and create formatter
+	formatter := newDisplayFormatter(detectTerminalLayout())
+	lr.RenderRows(len(environments), selectedIndex, header, func(prefix string, i int) string {
+		return formatter.formatSingleLine(prefix, environments[i])
+	})
+}
+
+// RenderRows renders a menu of count rows, each formatted by formatRow after its selection prefix
+func (lr *LineRenderer) RenderRows(count, selectedIndex int, header string, formatRow func(prefix string, i int) string) {
 	if !lr.state.initialized {
 		return
 	}
 
-	// Detect terminal layout and create formatter
-	layout := detectTerminalLayout()
-	formatter := newDisplayFormatter(layout)
-
 	// Build new content lines
 	newLines := []string{}
 	if header != "" {
 		newLines = append(newLines, header)
 	}
 
-	for i, env := range environments {
+	for i := 0; i < count; i++ {
 		prefix := "  "
 		if i == selectedIndex {
 			if lr.useANSI {
@@ -239,8 +293,11 @@ func (lr *LineRenderer) RenderMenu(environments []Environment, selectedIndex int
 			}
 		}
 
-		// Format complete line to fit within terminal width
-		line := formatter.formatSingleLine(prefix, env)
+		// Format complete line to fit within terminal width, then color what is left
+		line := lr.positioner.FitLine(formatRow(prefix, i))
+		if lr.useANSI {
+			line = colorizeMenuLine(line, i == selectedIndex, lr.theme)
+		}
 		newLines = append(newLines, line)
 	}
 
@@ -251,49 +308,65 @@ func (lr *LineRenderer) RenderMenu(environments []Environment, selectedIndex int
 	if lr.state.contentChanged {
 		lr.renderFullContent()
 	} else if lr.state.selectionChanged {
-		lr.renderSelectionChange(environments, formatter)
+		lr.renderSelectionChange()
 	}
 }
 
 // renderFullContent renders all content lines (used when content changes)
 func (lr *LineRenderer) renderFullContent() {
+	frame := newFrameBuffer()
+
 	// Clear the screen first to prevent content stacking
-	clearScreen()
+	writeClearScreen(frame)
 
 	// For ANSI-free display, we simply print all lines fresh
 	// This avoids complex cursor positioning issues
 	for i, line := range lr.state.currentLines {
-		if i == 0 {
-			// First line - print without newline
-			fmt.Print(lr.positioner.OverwriteLine(line))
-		} else {
-			// Subsequent lines - new line then content
-			fmt.Print("\n")
-			fmt.Print(lr.positioner.OverwriteLine(line))
+		if i > 0 {
+			frame.WriteString("\n")
 		}
+		frame.WriteString(lr.positioner.OverwriteLine(line))
 	}
+	frame.Flush()
 }
 
-// renderSelectionChange optimized rendering for selection-only changes
-func (lr *LineRenderer) renderSelectionChange(environments []Environment, formatter *DisplayFormatter) {
-	// For ANSI-free terminals, we'll render the full content when selection changes
-	// This ensures compatibility while still being more efficient than clearScreen
-	lr.renderFullContent()
+// renderSelectionChange redraws only the rows that changed (normally the old and new selection).
+// Without cursor movement it falls back to a full render.
+func (lr *LineRenderer) renderSelectionChange() {
+	if !lr.useANSI {
+		lr.renderFullContent()
+		return
+	}
+
+	// One write per keypress so the terminal never shows a half-drawn menu
+	frame := newFrameBuffer()
+	for _, lineNum := range lr.state.changedLines {
+		frame.WriteString(lr.lineUpdate(lineNum, lr.state.currentLines[lineNum]))
+	}
+	frame.Flush()
 }
 
-// moveToLineAndOverwrite moves cursor to specific line and overwrites content
-func (lr *LineRenderer) moveToLineAndOverwrite(lineNum int, content string) {
-	// Move up to the target line using carriage returns and up sequences
-	// For ANSI-free approach, we'll use multiple carriage returns with newlines
-	fmt.Print(strings.Repeat("\r\n", lineNum))
-	fmt.Print(lr.positioner.OverwriteLine(content))
+// lineUpdate returns the ANSI sequence that overwrites one menu row. The cursor rests at the
+// start of the last row after every render, so it moves up to the row and back down.
+func (lr *LineRenderer) lineUpdate(lineNum int, content string) string {
+	up := len(lr.state.currentLines) - 1 - lineNum
+	if up <= 0 {
+		return lr.positioner.OverwriteLine(content)
+	}
+	return fmt.Sprintf("\033[%dA%s\033[%dB", up, lr.positioner.OverwriteLine(content), up)
 }
 
 // OverwriteLine overwrites a specific line with new content
 func (lr *LineRenderer) OverwriteLine(lineNum int, content string) {
 	if lineNum >= 0 && lineNum < len(lr.state.currentLines) {
 		lr.state.currentLines[lineNum] = content
-		lr.moveToLineAndOverwrite(lineNum, content)
+		if lr.useANSI {
+			frame := newFrameBuffer()
+			frame.WriteString(lr.lineUpdate(lineNum, content))
+			frame.Flush()
+			return
+		}
+		lr.renderFullContent()
 	}
 }
 
@@ -313,31 +386,34 @@ func (ts *terminalState) ensureRestore() {
 	}
 }
 
+// stdinFd returns the stdin file descriptor in the form golang.org/x/term expects on every platform
+func stdinFd() int {
+	return int(os.Stdin.Fd())
+}
+
 // detectTerminalCapabilities performs comprehensive terminal capability detection
 func detectTerminalCapabilities() terminalCapabilities {
-	fd := int(syscall.Stdin)
 	caps := terminalCapabilities{
-		IsTerminal: term.IsTerminal(fd),
+		IsTerminal: uiTerminal.IsTerminal(),
 		Width:      80, // Default fallback
 		Height:     24, // Default fallback
 	}
 
-	// Determine ANSI/cursor support based on TERM even if not a TTY
-	termType := os.Getenv("TERM")
-	caps.SupportsANSI = termType != "" && termType != "dumb" && !strings.HasPrefix(termType, "vt5")
+	// Determine ANSI/cursor support from the platform even if not a TTY
+	caps.SupportsANSI = platformSupportsANSI()
 	caps.SupportsCursor = caps.SupportsANSI
 
 	// Only probe raw mode and size when running in a real terminal
 	if caps.IsTerminal {
-		if oldState, err := term.MakeRaw(fd); err == nil {
+		if err := uiTerminal.MakeRaw(); err == nil {
 			caps.SupportsRaw = true
 			// Immediately restore to avoid corruption
-			if err := term.Restore(fd, oldState); err != nil {
+			if err := uiTerminal.Restore(); err != nil {
 				caps.SupportsRaw = false
 			}
 		}
 
-		if width, height, err := term.GetSize(fd); err == nil {
+		if width, height, err := uiTerminal.Size(); err == nil {
 			caps.Width = width
 			caps.Height = height
 		}
@@ -589,6 +665,10 @@ const (
 	ArrowDown
 	ArrowLeft
 	ArrowRight
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
 )
 
 // parseKeyInput handles cross-platform key input parsing
@@ -597,6 +677,11 @@ func parseKeyInput(input []byte) (ArrowKey, rune, error) {
 		return ArrowNone, 0, fmt.Errorf("empty input")
 	}
 
+	// Treat CRLF (Windows Enter) as a single Enter key
+	if len(input) == 2 && input[0] == '\r' && input[1] == '\n' {
+		return ArrowNone, '\n', nil
+	}
+
 	// Single character keys
 	if len(input) == 1 {
 		switch input[0] {
@@ -611,9 +696,36 @@ func parseKeyInput(input []byte) (ArrowKey, rune, error) {
 		}
 	}
 
-	// Arrow key sequences (cross-platform)
-	if len(input) >= 3 && input[0] == '\x1b' && input[1] == '[' {
-		switch input[2] {
+	// Windows console scan codes: 0xE0 or 0x00 prefix followed by the key code
+	if len(input) == 2 && (input[0] == 0xe0 || input[0] == 0x00) {
+		switch input[1] {
+		case 0x48:
+			return ArrowUp, 0, nil
+		case 0x50:
+			return ArrowDown, 0, nil
+		case 0x4d:
+			return ArrowRight, 0, nil
+		case 0x4b:
+			return ArrowLeft, 0, nil
+		case 0x47:
+			return KeyHome, 0, nil
+		case 0x4f:
+			return KeyEnd, 0, nil
+		case 0x49:
+			return KeyPageUp, 0, nil
+		case 0x51:
+			return KeyPageDown, 0, nil
+		}
+	}
+
+	// Arrow key sequences: CSI (ESC [) and SS3 (ESC O, application cursor mode).
+	// ConPTY may add modifier parameters (ESC [ 1 ; 5 A), so match on the final byte.
+	if len(input) >= 3 && input[0] == '\x1b' && (input[1] == '[' || input[1] == 'O') {
+		final := 2
+		for final < len(input)-1 && ((input[final] >= '0' && input[final] <= '9') || input[final] == ';') {
+			final++
+		}
+		switch input[final] {
 		case 'A':
 			return ArrowUp, 0, nil
 		case 'B':
@@ -622,14 +734,93 @@ func parseKeyInput(input []byte) (ArrowKey, rune, error) {
 			return ArrowRight, 0, nil
 		case 'D':
 			return ArrowLeft, 0, nil
+		case 'H':
+			return KeyHome, 0, nil
+		case 'F':
+			return KeyEnd, 0, nil
+		case '~':
+			// VT-style editing keys: ESC [ <n> ~ (optionally ESC [ <n> ; <mod> ~)
+			param, _, _ := strings.Cut(string(input[2:final]), ";")
+			switch param {
+			case "1", "7":
+				return KeyHome, 0, nil
+			case "4", "8":
+				return KeyEnd, 0, nil
+			case "5":
+				return KeyPageUp, 0, nil
+			case "6":
+				return KeyPageDown, 0, nil
+			}
 		}
 	}
 
 	return ArrowNone, 0, fmt.Errorf("unrecognized key sequence")
 }
 
+// menuPageSize returns how many entries PageUp/PageDown jump (terminal height minus the header)
+func menuPageSize(caps terminalCapabilities) int {
+	if caps.Height > 2 {
+		return caps.Height - 2
+	}
+	return 1
+}
+
+// navigateSelection applies a navigation key to the menu selection.
+// Supports arrows, j/k, g/G, Home/End, PageUp/PageDown and digits 1-9 for direct selection.
+// Returns the new index and whether the key was a navigation key.
+func navigateSelection(index, count, pageSize int, arrow ArrowKey, char rune) (int, bool) {
+	if count == 0 {
+		return index, false
+	}
+	clamp := func(i int) int {
+		if i < 0 {
+			return 0
+		}
+		if i >= count {
+			return count - 1
+		}
+		return i
+	}
+
+	switch arrow {
+	case ArrowUp:
+		return (index - 1 + count) % count, true
+	case ArrowDown:
+		return (index + 1) % count, true
+	case KeyHome:
+		return 0, true
+	case KeyEnd:
+		return count - 1, true
+	case KeyPageUp:
+		return clamp(index - pageSize), true
+	case KeyPageDown:
+		return clamp(index + pageSize), true
+	case ArrowNone:
+		switch {
+		case char == 'k':
+			return (index - 1 + count) % count, true
+		case char == 'j':
+			return (index + 1) % count, true
+		case char == 'g':
+			return 0, true
+		case char == 'G':
+			return count - 1, true
+		case char >= '1' && char <= '9' && int(char-'0') <= count:
+			return int(char - '1'), true
+		}
+	}
+	return index, false
+}
+
 // clearScreen provides ANSI-free screen clearing using line-by-line approach
 func clearScreen() {
+	frame := newFrameBuffer()
+	writeClearScreen(frame)
+	frame.Flush()
+}
+
+// writeClearScreen appends the line-by-line clearing sequence to a frame
+func writeClearScreen(frame *frameBuffer) {
 	caps := detectTerminalCapabilities()
 	positioner := newTextPositioner(caps.Width)
 
@@ -641,38 +832,53 @@ func clearScreen() {
 	}
 
 	for i := 0; i < linesToClear; i++ {
-		fmt.Print(positioner.ClearLine())
+		frame.WriteString(positioner.ClearLine())
 		if i < linesToClear-1 {
-			fmt.Print("\n")
+			frame.WriteString("\n")
 		}
 	}
 
 	// Move cursor to top by printing enough carriage returns
-	fmt.Print(strings.Repeat("\r", linesToClear))
+	frame.WriteString(strings.Repeat("\r", linesToClear))
 }
 
 // Global display state for interactive menu rendering
 var globalDisplayState *DisplayState
 var globalLineRenderer *LineRenderer
 
+// menuTheme is the color theme for the interactive menu, set before selection starts
+var menuTheme colorTheme
+
 // renderMenuStatefully provides centralized stateful rendering for both interactive modes
 func renderMenuStatefully(environments []Environment, selectedIndex int, header string, useANSI bool) {
+	formatter := newDisplayFormatter(detectTerminalLayout())
+	renderRowsStatefully(len(environments), selectedIndex, header, useANSI, func(prefix string, i int) string {
+		return formatter.formatSingleLine(prefix, environments[i])
+	})
+}
+
+// renderRowsStatefully renders any menu (environments or start menu actions) through the shared state
+func renderRowsStatefully(count, selectedIndex int, header string, useANSI bool, formatRow func(prefix string, i int) string) {
 	// Initialize global state if needed
 	if globalDisplayState == nil {
 		globalDisplayState = initializeDisplayState()
 		globalLineRenderer = newLineRenderer(globalDisplayState, useANSI)
+		globalLineRenderer.theme = menuTheme
 		// Clear screen on first initialization to ensure clean start
 		clearScreen()
 	}
 
-	// Update terminal dimensions in case of resize
+	// Update terminal dimensions in case of resize; rows may have wrapped, so redraw everything
 	caps := detectTerminalCapabilities()
+	if caps.Width != globalDisplayState.terminalWidth || caps.Height != globalDisplayState.terminalHeight {
+		globalDisplayState.currentLines = nil
+	}
 	globalDisplayState.terminalWidth = caps.Width
 	globalDisplayState.terminalHeight = caps.Height
 	globalLineRenderer.positioner = newTextPositioner(caps.Width)
 
 	// Render using the line renderer
-	globalLineRenderer.RenderMenu(environments, selectedIndex, header)
+	globalLineRenderer.RenderRows(count, selectedIndex, header, formatRow)
 }
 
 // cleanupDisplayState cleans up global display state
@@ -687,7 +893,7 @@ func cleanupDisplayState() {
 // displayEnvironmentMenu shows interactive menu with responsive layout and selection indicator
 func displayEnvironmentMenu(environments []Environment, selectedIndex int) {
 	// Use stateful rendering instead of clearScreen
-	header := "Select environment (use ↑↓ arrows, Enter to confirm, Esc to cancel):"
+	header := tr("Select environment (↑↓/j/k, g/G, PgUp/PgDn, 1-9; Enter to confirm, Esc to cancel):")
 	renderMenuStatefully(environments, selectedIndex, header, true)
 }
 
@@ -703,70 +909,128 @@ func selectEnvironmentWithArrows(config Config) (Environment, error) {
 
 	// Detect terminal capabilities
 	caps := detectTerminalCapabilities()
+	logDebugf("terminal: tty=%t raw=%t ansi=%t cursor=%t size=%dx%d", caps.IsTerminal, caps.SupportsRaw, caps.SupportsANSI, caps.SupportsCursor, caps.Width, caps.Height)
+
+	// Accessible mode prints one plain numbered list; scripts still take the headless path
+	if accessibleMode(config) && (caps.IsTerminal || !isHeadlessMode()) {
+		logDebugf("menu: accessible numbered list")
+		return accessibleSelection(config)
+	}
 
 	// Tier 4: Headless mode (no terminal or pipe detected)
 	if !caps.IsTerminal {
 		// Check if this is a script/pipe scenario
 		if isHeadlessMode() {
-			if len(config.Environments) > 0 {
-				fmt.Printf("Headless mode: using first environment '%s'\n", config.Environments[0].Name)
-				return config.Environments[0], nil
-			}
-			return Environment{}, fmt.Errorf("no environments available for headless mode")
+			env, _, err := selectHeadlessEnvironment(config, false)
+			return env, err
 		}
 		return fallbackToNumberedSelection(config)
 	}
 
 	// Tier 1: Full interactive mode (raw + ANSI + cursor)
 	if caps.SupportsRaw && caps.SupportsANSI && caps.SupportsCursor {
+		logDebugf("menu: full interactive mode")
+		menuTheme = activeTheme(config)
 		return fullInteractiveSelection(config, caps)
 	}
 
 	// Tier 2: Basic interactive mode (raw mode only, no ANSI)
 	if caps.SupportsRaw {
+		logDebugf("menu: basic interactive mode (no ANSI)")
 		return basicInteractiveSelection(config, caps)
 	}
 
 	// Tier 3: Numbered selection mode (no raw mode support)
+	logDebugf("menu: numbered selection (no raw mode)")
 	return fallbackToNumberedSelection(config)
 }
 
+// Alternate screen sequences; entering also homes the cursor
+const (
+	altScreenEnter = "\033[?1049h\033[H"
+	altScreenLeave = "\033[?1049l"
+)
+
+// altScreenActive is set while the menu is drawn on the alternate screen
+var altScreenActive bool
+
+// altScreenEnabled reports whether the interactive menu may use the alternate screen
+func altScreenEnabled(config Config) bool {
+	if config.Settings == nil || config.Settings.Terminal == nil {
+		return true
+	}
+	terminal := config.Settings.Terminal
+	return !terminal.DisableANSI && (terminal.AltScreen == nil || *terminal.AltScreen)
+}
+
+// enterAltScreen switches to the alternate screen so menu frames stay out of the scrollback
+func enterAltScreen() {
+	fmt.Fprint(uiTerminal, altScreenEnter)
+	altScreenActive = true
+}
+
+// leaveAltScreen restores the original screen if the menu switched away from it
+func leaveAltScreen() {
+	if altScreenActive {
+		fmt.Fprint(uiTerminal, altScreenLeave)
+		altScreenActive = false
+	}
+}
+
 // fullInteractiveSelection implements Tier 1: full featured arrow navigation with ANSI
 func fullInteractiveSelection(config Config, caps terminalCapabilities) (Environment, error) {
-	fd := int(syscall.Stdin)
-	termState := &terminalState{fd: fd}
-
 	// Set up raw mode with guaranteed cleanup
-	var err error
-	termState.oldState, err = term.MakeRaw(fd)
-	if err != nil {
+	if err := uiTerminal.MakeRaw(); err != nil {
 		return basicInteractiveSelection(config, caps)
 	}
-	defer termState.ensureRestore()
+	defer restoreTerminal()
 	defer cleanupDisplayState() // Clean up display state on exit
 
+	// Runs first on return, so the original screen is back before anything else prints
+	if altScreenEnabled(config) {
+		enterAltScreen()
+		defer leaveAltScreen()
+	}
+	mouse := mouseEnabled(config)
+	if mouse {
+		enableMouse()
+		defer disableMouse()
+	}
+
 	selectedIndex := 0
-	buffer := make([]byte, 10)
+	pageSize := menuPageSize(caps)
+	buffer := make([]byte, 32) // Room for SGR mouse reports
 
 	for {
 		displayEnvironmentMenu(config.Environments, selectedIndex)
 
-		n, err := os.Stdin.Read(buffer)
+		n, err := uiTerminal.Read(buffer)
 		if err != nil {
+			disableMouse()
+			leaveAltScreen()
 			return fallbackToNumberedSelection(config)
 		}
 
+		if event, ok := parseMouseEvent(buffer[:n]); ok && mouse {
+			next, confirm := applyMouseEvent(globalDisplayState, event, selectedIndex, len(config.Environments))
+			if confirm {
+				return config.Environments[next], nil
+			}
+			selectedIndex = next
+			continue
+		}
+
 		arrow, char, err := parseKeyInput(buffer[:n])
 		if err != nil {
 			continue
 		}
 
-		switch arrow {
-		case ArrowUp:
-			selectedIndex = (selectedIndex - 1 + len(config.Environments)) % len(config.Environments)
-		case ArrowDown:
-			selectedIndex = (selectedIndex + 1) % len(config.Environments)
-		case ArrowNone:
+		if next, moved := navigateSelection(selectedIndex, len(config.Environments), pageSize, arrow, char); moved {
+			selectedIndex = next
+			continue
+		}
+
+		if arrow == ArrowNone {
 			switch char {
 			case '\n', '\r':
 				return config.Environments[selectedIndex], nil
@@ -779,24 +1043,20 @@ func fullInteractiveSelection(config Config, caps terminalCapabilities) (Environ
 
 // basicInteractiveSelection implements Tier 2: arrow navigation without ANSI styling
 func basicInteractiveSelection(config Config, caps terminalCapabilities) (Environment, error) {
-	fd := int(syscall.Stdin)
-	termState := &terminalState{fd: fd}
-
-	var err error
-	termState.oldState, err = term.MakeRaw(fd)
-	if err != nil {
+	if err := uiTerminal.MakeRaw(); err != nil {
 		return fallbackToNumberedSelection(config)
 	}
-	defer termState.ensureRestore()
+	defer restoreTerminal()
 	defer cleanupDisplayState() // Clean up display state on exit
 
 	selectedIndex := 0
+	pageSize := menuPageSize(caps)
 	buffer := make([]byte, 10)
 
 	for {
 		displayBasicEnvironmentMenu(config.Environments, selectedIndex)
 
-		n, err := os.Stdin.Read(buffer)
+		n, err := uiTerminal.Read(buffer)
 		if err != nil {
 			return fallbackToNumberedSelection(config)
 		}
@@ -806,12 +1066,12 @@ func basicInteractiveSelection(config Config, caps terminalCapabilities) (Enviro
 			continue
 		}
 
-		switch arrow {
-		case ArrowUp:
-			selectedIndex = (selectedIndex - 1 + len(config.Environments)) % len(config.Environments)
-		case ArrowDown:
-			selectedIndex = (selectedIndex + 1) % len(config.Environments)
-		case ArrowNone:
+		if next, moved := navigateSelection(selectedIndex, len(config.Environments), pageSize, arrow, char); moved {
+			selectedIndex = next
+			continue
+		}
+
+		if arrow == ArrowNone {
 			switch char {
 			case '\n', '\r':
 				return config.Environments[selectedIndex], nil
@@ -825,7 +1085,7 @@ func basicInteractiveSelection(config Config, caps terminalCapabilities) (Enviro
 // displayBasicEnvironmentMenu shows menu without ANSI escape sequences but with responsive layout
 func displayBasicEnvironmentMenu(environments []Environment, selectedIndex int) {
 	// Use stateful rendering with ANSI disabled for basic mode
-	header := "Select environment (use arrows, Enter to confirm, Esc to cancel):"
+	header := tr("Select environment (arrows/j/k, g/G, PgUp/PgDn, 1-9; Enter to confirm, Esc to cancel):")
 	renderMenuStatefully(environments, selectedIndex, header, false)
 }
 
@@ -849,43 +1109,60 @@ func isHeadlessMode() bool {
 
 // fallbackToNumberedSelection uses existing numbered selection menu
 func fallbackToNumberedSelection(config Config) (Environment, error) {
-	fmt.Println("Arrow key navigation not supported, using numbered selection:")
+	fmt.Fprintln(uiTerminal, "Arrow key navigation not supported, using numbered selection:")
 	return selectEnvironmentOriginal(config)
 }
 
+// maxStdinSecretSize bounds how much readSecretFromStdin reads
+const maxStdinSecretSize = 64 * 1024
+
+// readSecretFromStdin reads a secret from a pipe or file: the first line, or everything up to
+// EOF when there is no newline, trimmed. Unlike secureInput it needs no terminal and echoes nothing.
+func readSecretFromStdin(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(io.LimitReader(r, maxStdinSecretSize)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("stdin read failed: %w", err)
+	}
+	secret := strings.TrimSpace(line)
+	if secret == "" {
+		return "", fmt.Errorf("no key on stdin")
+	}
+	if err := validateAPIKey(secret); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
 // secureInput prompts for input without echoing characters to terminal
 func secureInput(prompt string) (string, error) {
 	if _, err := fmt.Print(prompt); err != nil {
 		return "", fmt.Errorf("failed to display prompt: %w", err)
 	}
 
-	// Get file descriptor for stdin
-	fd := int(syscall.Stdin)
-
 	// Check if stdin is a terminal
-	if !term.IsTerminal(fd) {
+	if !uiTerminal.IsTerminal() {
 		return "", fmt.Errorf("secure input requires a terminal")
 	}
 
 	// Save original terminal state
-	oldState, err := term.MakeRaw(fd)
-	if err != nil {
+	if err := uiTerminal.MakeRaw(); err != nil {
 		return "", fmt.Errorf("failed to set terminal raw mode: %w", err)
 	}
 
 	// Ensure terminal state is restored on exit
 	defer func() {
-		if err := term.Restore(fd, oldState); err != nil {
+		if err := uiTerminal.Restore(); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to restore terminal state: %v\n", err)
 		}
 	}()
+	defer hideFromRecording()()
 
 	var input []byte
 	buffer := make([]byte, 1)
 
 	for {
 		// Read one character at a time
-		n, err := os.Stdin.Read(buffer)
+		n, err := uiTerminal.Read(buffer)
 		if err != nil {
 			return "", fmt.Errorf("failed to read input: %w", err)
 		}
@@ -936,7 +1213,7 @@ func regularInput(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to display prompt: %w", err)
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := bufio.NewReader(uiTerminal)
 	input, err := reader.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("failed to read input: %w", err)
@@ -962,9 +1239,8 @@ func selectEnvironmentOriginal(config Config) (Environment, error) {
 	}
 
 	// Display environments with responsive formatting
-	if _, err := fmt.Println("Select environment:"); err != nil {
-		return Environment{}, fmt.Errorf("failed to display menu: %w", err)
-	}
+	frame := newFrameBuffer()
+	frame.WriteString(tr("Select environment:") + "\n")
 
 	// Detect terminal layout and create formatter
 	layout := detectTerminalLayout()
@@ -973,15 +1249,14 @@ func selectEnvironmentOriginal(config Config) (Environment, error) {
 	for i, env := range config.Environments {
 		// Format complete line to fit within terminal width
 		prefix := fmt.Sprintf("%d. ", i+1)
-		line := formatter.formatSingleLine(prefix, env)
-
-		if _, err := fmt.Println(line); err != nil {
-			return Environment{}, fmt.Errorf("failed to display environment option: %w", err)
-		}
+		frame.WriteString(formatter.formatSingleLine(prefix, env) + "\n")
+	}
+	if err := frame.Flush(); err != nil {
+		return Environment{}, fmt.Errorf("failed to display menu: %w", err)
 	}
 
 	// Get user selection
-	input, err := regularInput(fmt.Sprintf("Enter number (1-%d): ", len(config.Environments)))
+	input, err := regularInput(trf("Enter number (1-%d): ", len(config.Environments)))
 	if err != nil {
 		return Environment{}, fmt.Errorf("environment selection failed: %w", err)
 	}
@@ -1001,12 +1276,35 @@ func selectEnvironmentOriginal(config Config) (Environment, error) {
 
 // promptForEnvironment collects new environment details with validation
 func promptForEnvironment(config Config) (Environment, error) {
+	return promptForEnvironmentWithPreset(config, nil)
+}
+
+// promptForEnvironmentWithPreset collects new environment details, using preset values as defaults
+func promptForEnvironmentWithPreset(config Config, preset *ProviderPreset) (Environment, error) {
+	return promptForEnvironmentWithKey(config, preset, "")
+}
+
+// promptForEnvironmentWithKey collects new environment details; a non-empty apiKey (read from
+// the clipboard) is used instead of prompting for the key
+func promptForEnvironmentWithKey(config Config, preset *ProviderPreset, apiKey string) (Environment, error) {
 	var env Environment
 	var err error
 
+	// Prefer the form editor; fall back to sequential prompts without raw mode or ANSI
+	if env, err := formPromptForEnvironment(config, preset, apiKey); err != errFormUnavailable {
+		return env, err
+	}
+
+	if preset != nil {
+		env.Provider = preset.Name
+		if _, err := fmt.Printf("Using provider preset '%s'\n", preset.Name); err != nil {
+			return Environment{}, fmt.Errorf("failed to display preset: %w", err)
+		}
+	}
+
 	// Get environment name
 	for {
-		env.Name, err = regularInput("Environment name: ")
+		env.Name, err = regularInput(tr("Environment name: "))
 		if err != nil {
 			return Environment{}, fmt.Errorf("failed to get environment name: %w", err)
 		}
@@ -1032,13 +1330,17 @@ func promptForEnvironment(config Config) (Environment, error) {
 
 	// Get base URL
 	for {
-		env.URL, err = regularInput("Base URL: ")
+		if preset != nil {
+			env.URL, err = promptForPresetURL(*preset)
+		} else {
+			env.URL, err = regularInput(tr("Base URL: "))
+		}
 		if err != nil {
 			return Environment{}, fmt.Errorf("failed to get base URL: %w", err)
 		}
 
 		// Validate URL
-		if err := validateURL(env.URL); err != nil {
+		if err := validateTemplateURL(env.URL); err != nil {
 			if _, printErr := fmt.Printf("Invalid URL: %v\n", err); printErr != nil {
 				return Environment{}, fmt.Errorf("failed to display error: %w", printErr)
 			}
@@ -1048,27 +1350,53 @@ func promptForEnvironment(config Config) (Environment, error) {
 		break
 	}
 
-	// Get API key (secure input)
+	if preset != nil && preset.KeyHint != "" {
+		if _, err := fmt.Println(preset.KeyHint); err != nil {
+			return Environment{}, fmt.Errorf("failed to display key hint: %w", err)
+		}
+	}
+
+	// Get API key (secure input) unless it came from the clipboard
+	env.APIKey = apiKey
 	for {
-		env.APIKey, err = secureInput("API Key (hidden): ")
-		if err != nil {
-			return Environment{}, fmt.Errorf("failed to get API key: %w", err)
+		if env.APIKey == "" {
+			env.APIKey, err = secureInput(tr("API Key (hidden): "))
+			if err != nil {
+				return Environment{}, fmt.Errorf("failed to get API key: %w", err)
+			}
 		}
 
 		// Validate API key
 		if err := validateAPIKey(env.APIKey); err != nil {
+			env.APIKey = ""
 			if _, printErr := fmt.Printf("Invalid API key: %v\n", err); printErr != nil {
 				return Environment{}, fmt.Errorf("failed to display error: %w", printErr)
 			}
 			continue
 		}
 
+		// Key format hints never block; providers change formats
+		if preset != nil {
+			if warning := preset.keyFormatWarning(env.APIKey); warning != "" {
+				if _, printErr := fmt.Println(warning); printErr != nil {
+					return Environment{}, fmt.Errorf("failed to display warning: %w", printErr)
+				}
+			}
+		}
+
 		break
 	}
 
 	// Get model (optional)
 	for {
-		env.Model, err = regularInput("Model (optional, press Enter for default): ")
+		if preset != nil && preset.DefaultModel != "" {
+			env.Model, err = regularInput(trf("Model [%s]: ", preset.DefaultModel))
+			if env.Model == "" {
+				env.Model = preset.DefaultModel
+			}
+		} else {
+			env.Model, err = regularInput(tr("Model (optional, press Enter for default): "))
+		}
 		if err != nil {
 			return Environment{}, fmt.Errorf("failed to get model: %w", err)
 		}
@@ -1084,21 +1412,38 @@ func promptForEnvironment(config Config) (Environment, error) {
 		break
 	}
 
+	// Get API version for providers that need one (Azure)
+	if preset != nil && preset.APIVersion != "" {
+		input, err := regularInput(trf("API version [%s]: ", preset.APIVersion))
+		if err != nil {
+			return Environment{}, fmt.Errorf("failed to get API version: %w", err)
+		}
+		env.APIVersion = preset.APIVersion
+		if input != "" {
+			env.APIVersion = input
+		}
+	}
+
 	// Get additional environment variables (optional)
 	env.EnvVars = make(map[string]string)
+	if preset != nil {
+		if err := promptForPresetEnvVars(*preset, env.EnvVars); err != nil {
+			return Environment{}, err
+		}
+	}
 	if _, printErr := fmt.Println("Additional environment variables (optional):"); printErr != nil {
 		return Environment{}, fmt.Errorf("failed to display prompt: %w", printErr)
 	}
 	if _, printErr := fmt.Println("Examples: ANTHROPIC_SMALL_FAST_MODEL, ANTHROPIC_TIMEOUT, etc."); printErr != nil {
 		return Environment{}, fmt.Errorf("failed to display examples: %w", printErr)
 	}
-	if _, printErr := fmt.Println("Enter variable name (press Enter when done):"); printErr != nil {
+	if _, printErr := fmt.Println(tr("Enter variable name (press Enter when done):")); printErr != nil {
 		return Environment{}, fmt.Errorf("failed to display prompt: %w", printErr)
 	}
 
 	for {
 		var varName string
-		varName, err = regularInput("Variable name: ")
+		varName, err = regularInput(tr("Variable name: "))
 		if err != nil {
 			return Environment{}, fmt.Errorf("failed to get variable name: %w", err)
 		}
@@ -1125,7 +1470,7 @@ func promptForEnvironment(config Config) (Environment, error) {
 
 		// Get variable value
 		var varValue string
-		varValue, err = regularInput(fmt.Sprintf("Value for %s: ", varName))
+		varValue, err = regularInput(trf("Value for %s: ", varName))
 		if err != nil {
 			return Environment{}, fmt.Errorf("failed to get variable value: %w", err)
 		}
@@ -1140,8 +1485,167 @@ func promptForEnvironment(config Config) (Environment, error) {
 	return env, nil
 }
 
+// promptForPresetURL builds the base URL from a preset template, prompting for placeholders
+func promptForPresetURL(preset ProviderPreset) (string, error) {
+	placeholders := preset.urlPlaceholders()
+	if len(placeholders) == 0 {
+		input, err := regularInput(trf("Base URL [%s]: ", preset.URLTemplate))
+		if err != nil || input != "" {
+			return input, err
+		}
+		return preset.URLTemplate, nil
+	}
+
+	values := make(map[string]string, len(placeholders))
+	for _, name := range placeholders {
+		value, err := regularInput(fmt.Sprintf("%s (for %s): ", name, preset.URLTemplate))
+		if err != nil {
+			return "", err
+		}
+		values[name] = value
+	}
+	return preset.expandURL(values), nil
+}
+
+// promptForPresetEnvVars fills the preset's required variables, prompting when no default exists
+func promptForPresetEnvVars(preset ProviderPreset, envVars map[string]string) error {
+	keys := make([]string, 0, len(preset.EnvVars))
+	for key := range preset.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		defaultValue := preset.EnvVars[key]
+		prompt := trf("Value for %s (required): ", key)
+		if defaultValue != "" {
+			prompt = trf("Value for %s [%s]: ", key, defaultValue)
+		}
+		for {
+			value, err := regularInput(prompt)
+			if err != nil {
+				return fmt.Errorf("failed to get variable value: %w", err)
+			}
+			if value == "" {
+				value = defaultValue
+			}
+			if value == "" {
+				continue
+			}
+			envVars[key] = value
+			break
+		}
+	}
+	return nil
+}
+
+// isInteractiveInput reports whether stdin is attached to a terminal
+func isInteractiveInput() bool {
+	return uiTerminal.IsTerminal()
+}
+
+// confirmationPromptsAllowed reports whether commands that must not act without a typed
+// confirmation (config repair, show-key) may prompt; tests replace it
+var confirmationPromptsAllowed = isInteractiveInput
+
+// promptForCloneChanges asks for the fields that commonly differ between cloned environments
+func promptForCloneChanges(env Environment) (Environment, error) {
+	if _, err := fmt.Printf("Cloning into '%s' (press Enter to keep current value)\n", env.Name); err != nil {
+		return Environment{}, fmt.Errorf("failed to display prompt: %w", err)
+	}
+
+	// Get base URL
+	for {
+		input, err := regularInput(trf("Base URL [%s]: ", env.URL))
+		if err != nil {
+			return Environment{}, fmt.Errorf("failed to get base URL: %w", err)
+		}
+		if input == "" {
+			break
+		}
+		if err := validateTemplateURL(input); err != nil {
+			if _, printErr := fmt.Printf("Invalid URL: %v\n", err); printErr != nil {
+				return Environment{}, fmt.Errorf("failed to display error: %w", printErr)
+			}
+			continue
+		}
+		env.URL = input
+		break
+	}
+
+	// Get model
+	current := env.Model
+	if current == "" {
+		current = "default"
+	}
+	for {
+		input, err := regularInput(trf("Model [%s]: ", current))
+		if err != nil {
+			return Environment{}, fmt.Errorf("failed to get model: %w", err)
+		}
+		if input == "" {
+			break
+		}
+		if err := validateModel(input); err != nil {
+			if _, printErr := fmt.Printf("Invalid model: %v\n", err); printErr != nil {
+				return Environment{}, fmt.Errorf("failed to display error: %w", printErr)
+			}
+			continue
+		}
+		env.Model = input
+		break
+	}
+
+	// Get env var changes; values are not echoed since they may hold secrets
+	keys := make([]string, 0, len(env.EnvVars))
+	for key := range env.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for {
+		input, err := regularInput(trf("Env vars to change (KEY=VALUE, comma separated; KEY= removes) [%s]: ", strings.Join(keys, ", ")))
+		if err != nil {
+			return Environment{}, fmt.Errorf("failed to get env vars: %w", err)
+		}
+		overrides, err := parseEnvVarList(input)
+		if err != nil {
+			if _, printErr := fmt.Printf("Invalid env vars: %v\n", err); printErr != nil {
+				return Environment{}, fmt.Errorf("failed to display error: %w", printErr)
+			}
+			continue
+		}
+		setEnvVars(&env, overrides)
+		break
+	}
+
+	return env, nil
+}
+
+// confirmRemoval shows the environment (with masked key) and asks for a yes/no answer
+func confirmRemoval(env Environment) (bool, error) {
+	model := env.Model
+	if model == "" {
+		model = "default"
+	}
+	if _, err := fmt.Printf("  Name:  %s\n  URL:   %s\n  Model: %s\n  Key:   %s\n", env.Name, env.URL, model, maskAPIKey(env.APIKey)); err != nil {
+		return false, fmt.Errorf("failed to display environment: %w", err)
+	}
+
+	answer, err := regularInput(trf("Remove environment '%s'? [y/N]: ", env.Name))
+	if err != nil {
+		return false, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
 // displayEnvironments formats and shows the environment list with responsive layout and API key masking
 func displayEnvironments(config Config) error {
+	return displayEnvironmentsWithOptions(config, false)
+}
+
+// displayEnvironmentsWithOptions shows environments; verbose adds model aliases and provider details
+func displayEnvironmentsWithOptions(config Config, verbose bool) error {
 	if len(config.Environments) == 0 {
 		if _, err := fmt.Println("No environments configured."); err != nil {
 			return fmt.Errorf("failed to display message: %w", err)
@@ -1159,6 +1663,7 @@ func displayEnvironments(config Config) error {
 	// Detect terminal layout for responsive formatting
 	layout := detectTerminalLayout()
 	formatter := newDisplayFormatter(layout)
+	theme := activeTheme(config)
 
 	for _, env := range config.Environments {
 		// Mask API key (show only first 4 and last 4 characters)
@@ -1170,7 +1675,7 @@ func displayEnvironments(config Config) error {
 		if _, err := fmt.Printf("\n  Name:  %s\n", display.DisplayName); err != nil {
 			return fmt.Errorf("failed to display environment name: %w", err)
 		}
-		if _, err := fmt.Printf("  URL:   %s\n", display.DisplayURL); err != nil {
+		if _, err := fmt.Printf("  URL:   %s\n", colorize(theme.URL, display.DisplayURL)); err != nil {
 			return fmt.Errorf("failed to display environment URL: %w", err)
 		}
 		if _, err := fmt.Printf("  Model: %s\n", display.DisplayModel); err != nil {
@@ -1179,6 +1684,26 @@ func displayEnvironments(config Config) error {
 		if _, err := fmt.Printf("  Key:   %s\n", maskedKey); err != nil {
 			return fmt.Errorf("failed to display masked API key: %w", err)
 		}
+		if warning := keyExpiryWarning(env, config.Settings, time.Now()); warning != "" {
+			if _, err := fmt.Printf("  %s\n", colorize(theme.Warning, warning)); err != nil {
+				return fmt.Errorf("failed to display key expiry warning: %w", err)
+			}
+		}
+		if env.Provider != "" {
+			if _, err := fmt.Printf("  Provider: %s\n", env.Provider); err != nil {
+				return fmt.Errorf("failed to display provider: %w", err)
+			}
+		}
+		if env.OrgID != "" {
+			if _, err := fmt.Printf("  Organization: %s\n", env.OrgID); err != nil {
+				return fmt.Errorf("failed to display organization: %w", err)
+			}
+		}
+		if env.ProjectID != "" {
+			if _, err := fmt.Printf("  Project: %s\n", env.ProjectID); err != nil {
+				return fmt.Errorf("failed to display project: %w", err)
+			}
+		}
 
 		// Display additional environment variables if any
 		if len(env.EnvVars) > 0 {
@@ -1192,9 +1717,40 @@ func displayEnvironments(config Config) error {
 			}
 		}
 
+		// Display named alternate keys (masked)
+		if len(env.Keys) > 0 {
+			if _, err := fmt.Printf("  Keys:\n"); err != nil {
+				return fmt.Errorf("failed to display keys header: %w", err)
+			}
+			for keyName, apiKey := range env.Keys {
+				if _, err := fmt.Printf("    %s: %s\n", keyName, maskAPIKey(apiKey)); err != nil {
+					return fmt.Errorf("failed to display key: %w", err)
+				}
+			}
+		}
+
+		// Display model params forwarded as codex -c overrides
+		if len(env.ModelParams) > 0 {
+			if _, err := fmt.Printf("  Model Params:\n"); err != nil {
+				return fmt.Errorf("failed to display model params header: %w", err)
+			}
+			for key, value := range env.ModelParams {
+				if _, err := fmt.Printf("    %s=%s\n", key, value); err != nil {
+					return fmt.Errorf("failed to display model param: %w", err)
+				}
+			}
+		}
+
+		if verbose {
+			if err := displayEnvironmentDetails(env); err != nil {
+				return err
+			}
+		}
+
 		// Show truncation warning if any fields were truncated
 		if len(display.TruncatedFields) > 0 {
-			if _, err := fmt.Printf("  (Truncated: %s)\n", strings.Join(display.TruncatedFields, ", ")); err != nil {
+			warning := fmt.Sprintf("(Truncated: %s)", strings.Join(display.TruncatedFields, ", "))
+			if _, err := fmt.Printf("  %s\n", colorize(theme.Warning, warning)); err != nil {
 				return fmt.Errorf("failed to display truncation warning: %w", err)
 			}
 		}
@@ -1203,6 +1759,118 @@ func displayEnvironments(config Config) error {
 	return nil
 }
 
+// displayEnvironmentDetails prints the fields only shown by 'list --verbose'
+func displayEnvironmentDetails(env Environment) error {
+	sections := []struct {
+		title  string
+		values map[string]string
+	}{
+		{"Model Aliases", env.ModelAliases},
+		{"Deployment Map", env.DeploymentMap},
+	}
+	for _, section := range sections {
+		if len(section.values) == 0 {
+			continue
+		}
+		if _, err := fmt.Printf("  %s:\n", section.title); err != nil {
+			return fmt.Errorf("failed to display %s header: %w", strings.ToLower(section.title), err)
+		}
+		for _, pair := range sortedEnvPairs(section.values) {
+			from, to, _ := strings.Cut(pair, "=")
+			if _, err := fmt.Printf("    %s -> %s\n", from, to); err != nil {
+				return fmt.Errorf("failed to display %s entry: %w", strings.ToLower(section.title), err)
+			}
+		}
+	}
+
+	if len(env.URLs) > 0 {
+		strategy := env.URLStrategy
+		if strategy == "" {
+			strategy = urlStrategyRoundRobin
+		}
+		if _, err := fmt.Printf("  Endpoints (%s): %s\n", strategy, strings.Join(environmentEndpoints(env), ", ")); err != nil {
+			return fmt.Errorf("failed to display endpoints: %w", err)
+		}
+	}
+	if env.APIVersion != "" {
+		if _, err := fmt.Printf("  API Version: %s\n", env.APIVersion); err != nil {
+			return fmt.Errorf("failed to display API version: %w", err)
+		}
+	}
+	if len(env.AutoFlags) > 0 {
+		if _, err := fmt.Printf("  Auto Flags: %s\n", strings.Join(env.AutoFlags, " ")); err != nil {
+			return fmt.Errorf("failed to display auto flags: %w", err)
+		}
+	}
+	if len(env.Headers) > 0 {
+		if _, err := fmt.Println("  Headers:"); err != nil {
+			return fmt.Errorf("failed to display headers header: %w", err)
+		}
+		for _, pair := range sortedEnvPairs(env.Headers) {
+			name, value, _ := strings.Cut(pair, "=")
+			if _, err := fmt.Printf("    %s: %s\n", name, maskAPIKey(value)); err != nil {
+				return fmt.Errorf("failed to display header: %w", err)
+			}
+		}
+	}
+	if env.Budget != nil && env.Budget.MaxLaunchesPerDay > 0 {
+		action := env.Budget.Action
+		if action == "" {
+			action = budgetActionWarn
+		}
+		if _, err := fmt.Printf("  Budget: %d launches/day (%s, %d used today)\n", env.Budget.MaxLaunchesPerDay, action, launchesToday(env.Name, time.Now())); err != nil {
+			return fmt.Errorf("failed to display budget: %w", err)
+		}
+	}
+	if env.Auth != nil {
+		line := ""
+		if usesAWSAuth(env) {
+			line = fmt.Sprintf("  Auth: aws (region %s", env.Auth.Region)
+			if env.Auth.Profile != "" {
+				line += ", profile " + env.Auth.Profile
+			}
+			if env.Auth.RoleARN != "" {
+				line += ", role " + env.Auth.RoleARN
+			}
+			line += ")"
+		} else if usesGCPAuth(env) {
+			source := "gcloud"
+			if env.Auth.ADC {
+				source = "application default credentials"
+			} else if env.Auth.Account != "" {
+				source = "gcloud " + env.Auth.Account
+			}
+			line = fmt.Sprintf("  Auth: gcp (%s", source)
+			if env.Auth.Project != "" {
+				line += ", project " + env.Auth.Project
+			}
+			if env.Auth.Location != "" {
+				line += ", location " + env.Auth.Location
+			}
+			line += ")"
+		} else {
+			flow := env.Auth.Flow
+			if flow == "" {
+				flow = oauthFlowClientCredentials
+			}
+			line = fmt.Sprintf("  Auth: %s %s (client %s, token %s)", env.Auth.Type, flow, env.Auth.ClientID, env.Auth.TokenURL)
+		}
+		if _, err := fmt.Println(line); err != nil {
+			return fmt.Errorf("failed to display auth: %w", err)
+		}
+	}
+	if env.Proxy != nil && env.Proxy.URL != "" {
+		line := "  Proxy: " + redactProxyURL(env.Proxy.URL)
+		if len(env.Proxy.NoProxy) > 0 {
+			line += " (no_proxy: " + strings.Join(env.Proxy.NoProxy, ",") + ")"
+		}
+		if _, err := fmt.Println(line); err != nil {
+			return fmt.Errorf("failed to display proxy: %w", err)
+		}
+	}
+	return nil
+}
+
 // isValidEnvVarName validates environment variable names using proper naming conventions
 func isValidEnvVarName(name string) bool {
 	// Environment variable names should:
@@ -1251,6 +1919,10 @@ func isCommonSystemVar(name string) bool {
 
 // maskAPIKey masks an API key showing only first and last few characters
 func maskAPIKey(apiKey string) string {
+	// Secret references are not secrets themselves
+	if secretReferenceScheme(apiKey) != "" {
+		return apiKey
+	}
 	if len(apiKey) <= 8 {
 		return strings.Repeat("*", len(apiKey))
 	}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// updateAPIURL points at the GitHub latest-release endpoint (overridable for tests)
+var updateAPIURL = "https://api.github.com/repos/cexll/codex-env/releases/latest"
+
+// updateCheckTimeout bounds the passive check performed by --version
+const updateCheckTimeout = 2 * time.Second
+
+// releaseInfo is the subset of the GitHub release payload cde needs
+type releaseInfo struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+// releaseAsset is a downloadable file attached to a release
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// isOfflineMode reports whether network access is disabled via CDE_OFFLINE
+func isOfflineMode() bool {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv("CDE_OFFLINE")))
+	return value != "" && value != "0" && value != "false"
+}
+
+// fetchLatestRelease queries the release endpoint with the given timeout
+func fetchLatestRelease(timeout time.Duration) (releaseInfo, error) {
+	if isOfflineMode() {
+		return releaseInfo{}, fmt.Errorf("update check skipped: offline mode (CDE_OFFLINE) is set")
+	}
+
+	client := &http.Client{Timeout: timeout}
+	req, err := http.NewRequest(http.MethodGet, updateAPIURL, nil)
+	if err != nil {
+		return releaseInfo{}, fmt.Errorf("update check failed: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "cde/"+version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return releaseInfo{}, fmt.Errorf("update check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return releaseInfo{}, fmt.Errorf("update check failed: unexpected status %s", resp.Status)
+	}
+
+	var release releaseInfo
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return releaseInfo{}, fmt.Errorf("update check failed (invalid response): %w", err)
+	}
+	if release.TagName == "" {
+		return releaseInfo{}, fmt.Errorf("update check failed: release has no tag")
+	}
+
+	return release, nil
+}
+
+// parseVersion converts "v1.2.3" (optionally with a "-suffix") into numeric parts
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// isNewerVersion reports whether latest is strictly newer than current
+func isNewerVersion(current, latest string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	lat, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < len(cur) || i < len(lat); i++ {
+		var c, l int
+		if i < len(cur) {
+			c = cur[i]
+		}
+		if i < len(lat) {
+			l = lat[i]
+		}
+		if l != c {
+			return l > c
+		}
+	}
+	return false
+}
+
+// updateCheckRequested peeks at settings.update.check without loading the configuration, so
+// --version works whatever state config.json is in; anything unreadable means no check
+func updateCheckRequested() bool {
+	if envOnlySource() != "" {
+		return false
+	}
+	configPath, err := getConfigPath()
+	if err != nil {
+		return false
+	}
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return false
+	}
+	var peek struct {
+		Settings struct {
+			Update struct {
+				Check bool `json:"check"`
+			} `json:"update"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &peek); err != nil {
+		return false
+	}
+	return peek.Settings.Update.Check
+}
+
+// showVersion prints version information and, when enabled, a notice about newer releases
+func showVersion() {
+	fmt.Printf("cde version %s (commit: %s, built: %s)\n", version, commit, date)
+
+	if !updateCheckRequested() || isOfflineMode() {
+		return
+	}
+
+	if release, err := fetchLatestRelease(updateCheckTimeout); err == nil && isNewerVersion(version, release.TagName) {
+		fmt.Printf("A newer version is available: %s (run 'cde self-update')\n", release.TagName)
+	}
+}
+
+// runUpdateCheck reports whether a newer cde release is available
+func runUpdateCheck() error {
+	report := startProgress(tr("Checking for updates"), 0)
+	release, err := fetchLatestRelease(10 * time.Second)
+	report.Finish("")
+	if err != nil {
+		return err
+	}
+
+	if _, ok := parseVersion(version); !ok {
+		fmt.Printf("Current: %s (development build)\nLatest:  %s\n", version, release.TagName)
+		return nil
+	}
+
+	if isNewerVersion(version, release.TagName) {
+		fmt.Printf("A newer version is available: %s (current %s)\n", release.TagName, version)
+		fmt.Println("Run 'cde self-update' to install it.")
+		return nil
+	}
+
+	fmt.Printf("cde %s is up to date.\n", version)
+	return nil
+}
+
+// releaseArchiveName returns the CI package name for this platform
+func releaseArchiveName(tag, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("cde-%s-%s-%s%s", tag, goos, goarch, ext)
+}
+
+// findAsset locates a release asset by exact name
+func findAsset(release releaseInfo, name string) (releaseAsset, bool) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// downloadAsset fetches an asset body into memory
+func downloadAsset(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// verifyChecksum compares data against a sha256sum-formatted checksum file
+func verifyChecksum(data []byte, checksumFile []byte) error {
+	fields := strings.Fields(string(checksumFile))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file is empty")
+	}
+
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+		return fmt.Errorf("checksum mismatch: archive may be corrupted or tampered with")
+	}
+	return nil
+}
+
+// extractBinary returns the cde executable contained in a release archive
+func extractBinary(archive []byte, goos string) ([]byte, error) {
+	binaryName := "cde"
+	if goos == "windows" {
+		binaryName = "cde.exe"
+
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zip archive: %w", err)
+		}
+		for _, file := range reader.File {
+			if filepath.Base(file.Name) == binaryName && !file.FileInfo().IsDir() {
+				rc, err := file.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return ioutil.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("%s not found in archive", binaryName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tar.gz archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tar.gz archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binaryName {
+			return ioutil.ReadAll(tr)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in archive", binaryName)
+}
+
+// replaceExecutable atomically swaps the binary at target for data (temp file + rename)
+func replaceExecutable(target string, data []byte) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("cannot stat current executable: %w", err)
+	}
+
+	tempPath := target + ".new"
+	if err := ioutil.WriteFile(tempPath, data, info.Mode().Perm()|0700); err != nil {
+		return fmt.Errorf("failed to write new binary (permission denied?): %w", err)
+	}
+
+	// Windows cannot overwrite a running executable, so move it aside first
+	if runtime.GOOS == "windows" {
+		oldPath := target + ".old"
+		os.Remove(oldPath)
+		if err := os.Rename(target, oldPath); err != nil {
+			os.Remove(tempPath)
+			return fmt.Errorf("failed to move current binary aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(tempPath, target); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace binary (atomic move): %w", err)
+	}
+	return nil
+}
+
+// runSelfUpdate downloads, verifies and installs the latest release
+func runSelfUpdate() error {
+	report := startProgress(tr("Checking for updates"), 0)
+	release, err := fetchLatestRelease(10 * time.Second)
+	report.Finish("")
+	if err != nil {
+		return err
+	}
+
+	if _, ok := parseVersion(version); ok && !isNewerVersion(version, release.TagName) {
+		fmt.Printf("cde %s is up to date.\n", version)
+		return nil
+	}
+
+	archiveName := releaseArchiveName(release.TagName, runtime.GOOS, runtime.GOARCH)
+	archiveAsset, ok := findAsset(release, archiveName)
+	if !ok {
+		return fmt.Errorf("self-update failed: no release asset %s for this platform", archiveName)
+	}
+	checksumAsset, ok := findAsset(release, archiveName+".sha256")
+	if !ok {
+		return fmt.Errorf("self-update failed: release has no checksum for %s", archiveName)
+	}
+
+	report = startProgress(trf("Downloading %s", archiveName), 2)
+	archive, err := downloadAsset(archiveAsset.URL)
+	if err != nil {
+		report.Finish("")
+		return fmt.Errorf("self-update failed: %w", err)
+	}
+	report.Step()
+	checksum, err := downloadAsset(checksumAsset.URL)
+	report.Finish("")
+	if err != nil {
+		return fmt.Errorf("self-update failed: %w", err)
+	}
+	if err := verifyChecksum(archive, checksum); err != nil {
+		return fmt.Errorf("self-update failed: %w", err)
+	}
+
+	binary, err := extractBinary(archive, runtime.GOOS)
+	if err != nil {
+		return fmt.Errorf("self-update failed: %w", err)
+	}
+
+	target, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("self-update failed: cannot locate current executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	if err := replaceExecutable(target, binary); err != nil {
+		return fmt.Errorf("self-update failed: %w", err)
+	}
+
+	fmt.Printf("Updated cde to %s (%s).\n", release.TagName, target)
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		current string
+		latest  string
+		want    bool
+	}{
+		{"v1.0.0", "v1.0.1", true},
+		{"v1.2.0", "v1.10.0", true},
+		{"1.2.3", "v1.2.3", false},
+		{"v2.0.0", "v1.9.9", false},
+		{"v1.0", "v1.0.1", true},
+		{"v1.0.0-rc1", "v1.0.0", false},
+		{"dev", "v1.0.0", false},
+		{"v1.0.0", "garbage", false},
+	}
+
+	for _, tt := range tests {
+		if got := isNewerVersion(tt.current, tt.latest); got != tt.want {
+			t.Errorf("isNewerVersion(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestFetchLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name":"v9.9.9","assets":[{"name":"a","browser_download_url":"http://x/a"}]}`))
+	}))
+	defer server.Close()
+
+	original := updateAPIURL
+	updateAPIURL = server.URL
+	defer func() { updateAPIURL = original }()
+
+	release, err := fetchLatestRelease(updateCheckTimeout)
+	if err != nil {
+		t.Fatalf("fetchLatestRelease() failed: %v", err)
+	}
+	if release.TagName != "v9.9.9" || len(release.Assets) != 1 {
+		t.Errorf("unexpected release: %+v", release)
+	}
+
+	t.Setenv("CDE_OFFLINE", "1")
+	if _, err := fetchLatestRelease(updateCheckTimeout); err == nil {
+		t.Error("expected offline mode to skip the network request")
+	}
+}
+
+func TestVerifyChecksumAndExtract(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("#!/bin/sh\necho new\n")
+	tw.WriteHeader(&tar.Header{Name: "cde-v1.0.0-linux-amd64/cde", Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	archive := buf.Bytes()
+
+	sum := sha256.Sum256(archive)
+	good := []byte(hex.EncodeToString(sum[:]) + "  cde-v1.0.0-linux-amd64.tar.gz\n")
+	if err := verifyChecksum(archive, good); err != nil {
+		t.Errorf("verifyChecksum() rejected a valid checksum: %v", err)
+	}
+	if err := verifyChecksum(append(archive, 0), good); err == nil {
+		t.Error("verifyChecksum() accepted a tampered archive")
+	}
+
+	binary, err := extractBinary(archive, "linux")
+	if err != nil {
+		t.Fatalf("extractBinary() failed: %v", err)
+	}
+	if !bytes.Equal(binary, content) {
+		t.Errorf("extracted content mismatch: %q", binary)
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "cde")
+	if err := ioutil.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceExecutable(target, []byte("new")); err != nil {
+		t.Fatalf("replaceExecutable() failed: %v", err)
+	}
+	data, _ := ioutil.ReadFile(target)
+	if string(data) != "new" {
+		t.Errorf("expected replaced content, got %q", data)
+	}
+}
+
+func TestReleaseArchiveName(t *testing.T) {
+	if got := releaseArchiveName("v1.2.3", "linux", "arm64"); got != "cde-v1.2.3-linux-arm64.tar.gz" {
+		t.Errorf("unexpected archive name: %s", got)
+	}
+	if got := releaseArchiveName("v1.2.3", "windows", "amd64"); got != "cde-v1.2.3-windows-amd64.zip" {
+		t.Errorf("unexpected archive name: %s", got)
+	}
+}
+
+func TestUpdateCheckRequested(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   bool
+	}{
+		{"enabled", `{"environments": [], "settings": {"update": {"check": true}}}`, true},
+		{"enabled with comments", "{\n  // check daily\n  \"settings\": {\"update\": {\"check\": true}},\n}", true},
+		{"disabled", `{"environments": [], "settings": {"update": {"check": false}}}`, false},
+		{"corrupt", `{"environments": [`, false},
+		{"invalid environment", `{"environments": [{"name": "bad name!"}], "settings": {"update": {"check": true}}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := configPathOverride
+			configPathOverride = filepath.Join(t.TempDir(), "config.json")
+			defer func() { configPathOverride = original }()
+			if err := ioutil.WriteFile(configPathOverride, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := updateCheckRequested(); got != tt.want {
+				t.Errorf("updateCheckRequested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
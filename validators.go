@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// environmentValidator checks one field of an environment
+type environmentValidator struct {
+	field    string // JSON key inside the environment object
+	validate func(env Environment) error
+}
+
+// settingsValidator checks one field of settings
+type settingsValidator struct {
+	field    string // JSON path inside the settings object
+	validate func(settings *ConfigSettings) error
+}
+
+// environmentValidators run in order; validateEnvironment stops at the first failure while
+// 'cde config validate' reports every one
+var environmentValidators = []environmentValidator{
+	{"name", func(env Environment) error { return invalidField("name", validateName(env.Name)) }},
+	{"url", func(env Environment) error { return invalidField("URL", validateTemplateURL(env.URL)) }},
+	{"urls", validateURLStrategy},
+	{"api_key", func(env Environment) error { return invalidField("API key", validateAPIKey(env.APIKey)) }},
+	{"model", func(env Environment) error { return invalidField("model", validateModel(env.Model)) }},
+	{"fallback_model", func(env Environment) error { return invalidField("fallback model", validateModel(env.FallbackModel)) }},
+	{"org_id", func(env Environment) error { return invalidField("org_id", validateScopeID(env.OrgID)) }},
+	{"project_id", func(env Environment) error { return invalidField("project_id", validateScopeID(env.ProjectID)) }},
+	{"model_params", func(env Environment) error { return invalidField("model params", validateModelParams(env.ModelParams)) }},
+	{"model_aliases", func(env Environment) error { return validateModelAliases(env.ModelAliases) }},
+	{"deployment_map", func(env Environment) error { return validateDeploymentMap(env.DeploymentMap) }},
+	{"api_version", func(env Environment) error { return validateAPIVersion(env.APIVersion) }},
+	{"keys", func(env Environment) error { return validateKeys(env.Keys) }},
+	{"auto_flags", func(env Environment) error { return invalidField("auto flags", validateAutoFlags(env.AutoFlags)) }},
+	{"proxy", func(env Environment) error { return invalidField("proxy", validateProxySettings(env.Proxy)) }},
+	{"headers", func(env Environment) error { return invalidField("headers", validateEnvironmentHeaders(env)) }},
+	{"wire_api", func(env Environment) error { return invalidField("wire_api", validateWireAPI(env.WireAPI)) }},
+	{"budget", func(env Environment) error { return invalidField("budget", validateBudgetSettings(env.Budget)) }},
+	{"env_passthrough", func(env Environment) error {
+		return invalidField("env_passthrough", validateEnvPassthrough(env.EnvPassthrough))
+	}},
+	{"timeout", func(env Environment) error { return invalidField("timeout", validateSessionTimeout(env.Timeout)) }},
+	{"rate_limit", func(env Environment) error {
+		return invalidField("rate limit", validateRateLimitSettings(env.RateLimit))
+	}},
+	{"auth", func(env Environment) error { return invalidField("auth", validateAuthSettings(env.Auth)) }},
+	{"key_created_at", func(env Environment) error { return invalidField("key_created_at", validateKeyDate(env.KeyCreatedAt)) }},
+	{"key_expires_at", func(env Environment) error { return invalidField("key_expires_at", validateKeyDate(env.KeyExpiresAt)) }},
+}
+
+// settingsValidators run in order; loadConfigLazy stops at the first failure
+var settingsValidators = []settingsValidator{
+	{"auto_flags", func(settings *ConfigSettings) error { return validateAutoFlags(settings.AutoFlags) }},
+	{"launch_mode", func(settings *ConfigSettings) error { return validateLaunchMode(settings.LaunchMode) }},
+	{"target_command", func(settings *ConfigSettings) error { return validateTargetCommand(settings.TargetCommand) }},
+	{"language", func(settings *ConfigSettings) error { return validateLanguage(settings.Language) }},
+	{"hooks", func(settings *ConfigSettings) error { return validateHookSettings(settings.Hooks) }},
+	{"key_rotation", func(settings *ConfigSettings) error { return validateKeyRotationSettings(settings.KeyRotation) }},
+	{"notify", func(settings *ConfigSettings) error { return validateNotifySettings(settings.Notify) }},
+	{"headless_policy", validateHeadlessPolicy},
+	{"validation", func(settings *ConfigSettings) error { return validateArgPolicySettings(settings.Validation) }},
+	{"terminal.theme", func(settings *ConfigSettings) error {
+		if settings.Terminal == nil {
+			return nil
+		}
+		return validateTheme(settings.Terminal.Theme)
+	}},
+}
+
+// validateSettings runs the settings validators and returns the first failure
+func validateSettings(settings *ConfigSettings) error {
+	if settings == nil {
+		return nil
+	}
+	for _, v := range settingsValidators {
+		if err := v.validate(settings); err != nil {
+			return fmt.Errorf("configuration validation failed for settings.%s: %w", v.field, err)
+		}
+	}
+	return nil
+}
+
+// invalidField prefixes a validation error with the field it concerns
+func invalidField(label string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid %s: %w", label, err)
+}
+
+// validateModelAliases checks alias names and the models they stand for
+func validateModelAliases(aliases map[string]string) error {
+	for alias, model := range aliases {
+		if err := validateModel(alias); err != nil || alias == "" {
+			return fmt.Errorf("invalid model alias '%s'", alias)
+		}
+		if err := validateModel(model); err != nil || model == "" {
+			return fmt.Errorf("invalid model for alias '%s'", alias)
+		}
+	}
+	return nil
+}
+
+// validateDeploymentMap checks Azure model and deployment names
+func validateDeploymentMap(deployments map[string]string) error {
+	for modelName, deployment := range deployments {
+		if err := validateModel(modelName); err != nil || modelName == "" {
+			return fmt.Errorf("invalid deployment map model '%s'", modelName)
+		}
+		if err := validateModel(deployment); err != nil || deployment == "" {
+			return fmt.Errorf("invalid deployment name for model '%s'", modelName)
+		}
+	}
+	return nil
+}
+
+// validateAPIVersion checks the Azure REST API version format
+func validateAPIVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	if matched, _ := regexp.MatchString(`^[a-zA-Z0-9.-]+$`, version); !matched {
+		return fmt.Errorf("invalid API version '%s'", version)
+	}
+	return nil
+}
+
+// validateKeys checks named alternate API keys
+func validateKeys(keys map[string]string) error {
+	for keyName, apiKey := range keys {
+		if err := validateName(keyName); err != nil {
+			return fmt.Errorf("invalid key name '%s': %w", keyName, err)
+		}
+		if err := validateAPIKey(apiKey); err != nil {
+			return fmt.Errorf("invalid API key '%s': %w", keyName, err)
+		}
+	}
+	return nil
+}
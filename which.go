@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// launchEnvDiff returns the variables a launch sets and the inherited variables it drops
+func launchEnvDiff(env Environment) ([]exportPair, []string) {
+	set := environmentExports(env)
+	setKeys := make(map[string]bool, len(set))
+	for _, pair := range set {
+		setKeys[pair.Key] = true
+	}
+
+	removed := []string{}
+	for _, envVar := range os.Environ() {
+		key, _, _ := strings.Cut(envVar, "=")
+		if setKeys[key] {
+			continue
+		}
+		if strings.HasPrefix(key, "OPENAI_") || strings.HasPrefix(key, "ANTHROPIC_") || !inheritsEnvVar(env.EnvPassthrough, key) {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return set, removed
+}
+
+// maskedExport hides the value of credential and header variables and proxy passwords
+func maskedExport(pair exportPair) exportPair {
+	if secretVarPattern.MatchString(pair.Key) || strings.HasPrefix(pair.Key, headerEnvPrefix) {
+		pair.Value = maskAPIKey(pair.Value)
+	} else if isProxyVar(pair.Key) {
+		pair.Value = redactProxyURL(pair.Value)
+	}
+	return pair
+}
+
+// quoteCommandArg single-quotes an argument for display when the shell would split or expand it
+func quoteCommandArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteWindowsArg double-quotes an argument following the CommandLineToArgvW rules, so
+// backslashes are doubled only where they precede a quote
+func quoteWindowsArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"") {
+		return arg
+	}
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	slashes := 0
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			slashes++
+		case '"':
+			quoted.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		quoted.WriteRune(r)
+	}
+	quoted.WriteString(strings.Repeat(`\`, slashes))
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// formatCommandLine renders argv as a command line that can be pasted into the platform's
+// shell. It is for display only: codex is always started with the argv slice itself.
+func formatCommandLine(argv []string) string {
+	quote := quoteCommandArg
+	if runtime.GOOS == "windows" {
+		quote = quoteWindowsArg
+	}
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// launchReport describes a planned launch with secrets masked
+type launchReport struct {
+	Environment string            `json:"environment"`
+	URL         string            `json:"url"`
+	SelectedBy  string            `json:"selected_by"`
+	Key         string            `json:"key"`
+	Command     []string          `json:"command"`
+	EnvSet      map[string]string `json:"env_set"`
+	EnvRemoved  []string          `json:"env_removed"`
+	DryRun      bool              `json:"dry_run,omitempty"`
+
+	setOrder []string // EnvSet keys in launch order for human output
+}
+
+// newLaunchReport builds the report for plan; keyName is the --key selection, if any
+func newLaunchReport(plan launchPlan, keyName string) launchReport {
+	env := plan.Environment
+	keyLabel := "api_key"
+	if keyName != "" {
+		keyLabel = "keys." + keyName
+	}
+
+	report := launchReport{
+		Environment: env.Name,
+		URL:         env.URL,
+		SelectedBy:  plan.Source,
+		Key:         fmt.Sprintf("%s (%s)", keyLabel, maskAPIKey(env.APIKey)),
+		Command:     append([]string{targetCommand}, sanitizeArgs(plan.Args, env.APIKey)...),
+		EnvSet:      make(map[string]string),
+	}
+	set, removed := launchEnvDiff(env)
+	for _, pair := range set {
+		pair = maskedExport(pair)
+		report.EnvSet[pair.Key] = pair.Value
+		report.setOrder = append(report.setOrder, pair.Key)
+	}
+	report.EnvRemoved = removed
+	return report
+}
+
+// printLaunchReport writes the report as indented JSON or human-readable text
+func printLaunchReport(report launchReport, asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("report serialization failed: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if report.DryRun {
+		fmt.Println("Dry run: Codex will not be launched.")
+	}
+	fmt.Printf("Environment: %s (%s)\n", report.Environment, report.URL)
+	fmt.Printf("  Selected by: %s\n", report.SelectedBy)
+	fmt.Printf("  Key:         %s\n", report.Key)
+	fmt.Printf("\nCommand:\n  %s\n", formatCommandLine(report.Command))
+
+	fmt.Println("\nEnvironment variables set:")
+	for _, key := range report.setOrder {
+		fmt.Printf("  %s=%s\n", key, report.EnvSet[key])
+	}
+	if len(report.EnvRemoved) > 0 {
+		fmt.Println("\nInherited variables removed:")
+		for _, key := range report.EnvRemoved {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+	return nil
+}
+
+// runWhich explains what a launch with the same flags would do, without launching
+func runWhich(envName string, codexArgs []string, opts launchOptions) error {
+	opts.NoPrompt = true
+	plan, err := planLaunch(envName, codexArgs, opts)
+	if err != nil {
+		return err
+	}
+	return printLaunchReport(newLaunchReport(plan, opts.KeyName), opts.JSON)
+}
+
+// runDryRun reports a fully validated launch instead of exec'ing codex
+func runDryRun(plan launchPlan, resolved Environment, opts launchOptions) error {
+	// Run the same validation the launcher would
+	if _, err := prepareEnvironment(resolved); err != nil {
+		return fmt.Errorf("Codex launcher failed: %w", err)
+	}
+	if _, err := exec.LookPath(targetCommand); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s not found in PATH; a real launch would fail\n", targetCommand)
+	}
+
+	report := newLaunchReport(plan, opts.KeyName)
+	report.DryRun = true
+	return printLaunchReport(report, opts.JSON)
+}
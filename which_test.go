@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseWhichCommand(t *testing.T) {
+	result := parseArguments([]string{"which", "auto", "-e", "dev", "--key", "backup", "--", "-m", "o3"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.Subcommand != "which" || result.CCEFlags["auto"] != "true" || result.CCEFlags["env"] != "dev" || result.CCEFlags["key"] != "backup" {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+	if !reflect.DeepEqual(result.ClaudeArgs, []string{"-m", "o3"}) {
+		t.Errorf("ClaudeArgs = %v", result.ClaudeArgs)
+	}
+	if opts := launchOptionsFromFlags(result); !opts.Auto || opts.KeyName != "backup" {
+		t.Errorf("launchOptionsFromFlags = %+v", opts)
+	}
+}
+
+func TestPlanLaunch(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	config := Config{Environments: []Environment{
+		{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456", Model: "gpt-5", Keys: map[string]string{"backup": "sk-backup-123456"}},
+		{Name: "prod", URL: "https://prod.example.com", APIKey: "sk-prod-123456"},
+	}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		envName    string
+		args       []string
+		opts       launchOptions
+		cdeEnv     string // CDE_ENV
+		cdeModel   string // CDE_MODEL
+		wantSource string
+		wantArgs   []string
+		wantKey    string
+		wantErr    bool
+	}{
+		{
+			name:       "env flag with model injection",
+			envName:    "dev",
+			wantSource: "--env flag",
+			wantArgs:   []string{"-m", "gpt-5"},
+			wantKey:    "sk-dev-123456",
+		},
+		{
+			name:       "explicit model wins",
+			envName:    "dev",
+			args:       []string{"--model", "o3"},
+			wantSource: "--env flag",
+			wantArgs:   []string{"--model", "o3"},
+			wantKey:    "sk-dev-123456",
+		},
+		{
+			name:       "auto flags",
+			envName:    "prod",
+			opts:       launchOptions{Auto: true},
+			wantSource: "--env flag",
+			wantArgs:   []string{"-a", "never", "--sandbox", "workspace-write"},
+			wantKey:    "sk-prod-123456",
+		},
+		{
+			name:       "named key",
+			envName:    "dev",
+			opts:       launchOptions{KeyName: "backup"},
+			wantSource: "--env flag",
+			wantArgs:   []string{"-m", "gpt-5"},
+			wantKey:    "sk-backup-123456",
+		},
+		{
+			name:       "CDE_ENV selects without a flag",
+			cdeEnv:     "prod",
+			opts:       launchOptions{NoPrompt: true},
+			wantSource: "CDE_ENV",
+			wantKey:    "sk-prod-123456",
+		},
+		{
+			name:       "env flag beats CDE_ENV",
+			envName:    "dev",
+			cdeEnv:     "prod",
+			wantSource: "--env flag",
+			wantArgs:   []string{"-m", "gpt-5"},
+			wantKey:    "sk-dev-123456",
+		},
+		{
+			name:       "CDE_MODEL replaces the default model",
+			envName:    "dev",
+			cdeModel:   "o4-mini",
+			wantSource: "--env flag",
+			wantArgs:   []string{"-m", "o4-mini"},
+			wantKey:    "sk-dev-123456",
+		},
+		{
+			name:       "model flag beats CDE_MODEL",
+			envName:    "dev",
+			cdeModel:   "o4-mini",
+			args:       []string{"-m", "o3"},
+			wantSource: "--env flag",
+			wantArgs:   []string{"-m", "o3"},
+			wantKey:    "sk-dev-123456",
+		},
+		{
+			name:    "CDE_ENV names an unknown environment",
+			cdeEnv:  "missing",
+			wantErr: true,
+		},
+		{
+			name:     "invalid CDE_MODEL",
+			envName:  "dev",
+			cdeModel: "gpt;rm",
+			wantErr:  true,
+		},
+		{
+			name:    "menu is not shown without prompt",
+			opts:    launchOptions{NoPrompt: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown environment",
+			envName: "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CDE_ENV", tt.cdeEnv)
+			t.Setenv("CDE_MODEL", tt.cdeModel)
+			plan, err := planLaunch(tt.envName, tt.args, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("planLaunch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if plan.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", plan.Source, tt.wantSource)
+			}
+			if !reflect.DeepEqual(plan.Args, tt.wantArgs) {
+				t.Errorf("Args = %v, want %v", plan.Args, tt.wantArgs)
+			}
+			if plan.Environment.APIKey != tt.wantKey {
+				t.Errorf("APIKey = %q, want %q", plan.Environment.APIKey, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestLaunchEnvDiffAndCommandLine(t *testing.T) {
+	t.Setenv("OPENAI_ORG_ID", "org-inherited")
+	t.Setenv("OPENAI_API_KEY", "sk-inherited")
+
+	env := Environment{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456", EnvVars: map[string]string{"HTTP_PROXY": "http://proxy:8080"}}
+	set, removed := launchEnvDiff(env)
+
+	got := map[string]string{}
+	for _, pair := range set {
+		pair = maskedExport(pair)
+		got[pair.Key] = pair.Value
+	}
+	if got["OPENAI_API_KEY"] != maskAPIKey("sk-dev-123456") || got["HTTP_PROXY"] != "http://proxy:8080" {
+		t.Errorf("unexpected variables: %v", got)
+	}
+	if !contains(removed, "OPENAI_ORG_ID") || contains(removed, "OPENAI_API_KEY") {
+		t.Errorf("removed = %v, want OPENAI_ORG_ID only among those set", removed)
+	}
+
+	line := formatCommandLine([]string{"codex", "-c", "reasoning=high", "fix the bug's cause"})
+	want := `codex -c reasoning=high 'fix the bug'\''s cause'`
+	if runtime.GOOS == "windows" {
+		want = `codex -c reasoning=high "fix the bug's cause"`
+	}
+	if line != want {
+		t.Errorf("formatCommandLine() = %s", line)
+	}
+}
+
+func TestQuoteCommandArg(t *testing.T) {
+	tests := []struct {
+		arg     string
+		posix   string
+		windows string
+	}{
+		{arg: "plain", posix: "plain", windows: "plain"},
+		{arg: "", posix: "''", windows: `""`},
+		{arg: "two words", posix: "'two words'", windows: `"two words"`},
+		{arg: `say "hi"`, posix: `'say "hi"'`, windows: `"say \"hi\""`},
+		{arg: `C:\dir with space\`, posix: `'C:\dir with space\'`, windows: `"C:\dir with space\\"`},
+		{arg: `a\"b`, posix: `'a\"b'`, windows: `"a\\\"b"`},
+		{arg: "$HOME/*.go", posix: "'$HOME/*.go'", windows: "$HOME/*.go"},
+		{arg: "line1\nline2", posix: "'line1\nline2'", windows: "\"line1\nline2\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			if got := quoteCommandArg(tt.arg); got != tt.posix {
+				t.Errorf("quoteCommandArg(%q) = %s, want %s", tt.arg, got, tt.posix)
+			}
+			if got := quoteWindowsArg(tt.arg); got != tt.windows {
+				t.Errorf("quoteWindowsArg(%q) = %s, want %s", tt.arg, got, tt.windows)
+			}
+		})
+	}
+}
+
+func TestFormatCommandLineRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("round trip uses a POSIX shell")
+	}
+	args := []string{"exec", "fix the bug's \"cause\"", "", "$(whoami)", "a\\b", "*.go", "tab\there", "multi\nline"}
+	line := formatCommandLine(append([]string{"printf", "%s\\0"}, args...))
+
+	output, err := exec.Command("sh", "-c", line).Output()
+	if err != nil {
+		t.Fatalf("sh -c %s failed: %v", line, err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("shell saw %q, want %q", got, args)
+	}
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDryRunReportsInsteadOfLaunching(t *testing.T) {
+	oldPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = oldPath }()
+
+	if err := saveConfig(Config{Environments: []Environment{
+		{Name: "dev", URL: "https://dev.example.com", APIKey: "sk-dev-123456789", Model: "gpt-5"},
+	}}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	result := parseArguments([]string{"auto", "-e", "dev", "--dry-run", "--json", "--", "--sandbox", "read-only"})
+	if result.Error != nil {
+		t.Fatalf("unexpected parse error: %v", result.Error)
+	}
+	opts := launchOptionsFromFlags(result)
+	if !opts.DryRun || !opts.JSON || !opts.Auto {
+		t.Fatalf("launchOptionsFromFlags = %+v", opts)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe failed: %v", err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = writer
+	runErr := runDefaultWithOptions(result.CCEFlags["env"], result.ClaudeArgs, opts)
+	writer.Close()
+	os.Stdout = oldStdout
+	output, _ := ioutil.ReadAll(reader)
+	if runErr != nil {
+		t.Fatalf("dry run failed: %v", runErr)
+	}
+
+	var report launchReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("dry run output is not JSON: %v\n%s", err, output)
+	}
+	wantCommand := []string{"codex", "-m", "gpt-5", "-a", "never", "--sandbox", "read-only"}
+	if !report.DryRun || report.Environment != "dev" || !reflect.DeepEqual(report.Command, wantCommand) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if strings.Contains(string(output), "sk-dev-123456789") {
+		t.Error("dry run output leaked the API key")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// runWrap runs an arbitrary command (aider, llm, a curl script) with the selected environment's
+// variables: cde wrap -e prod -- <command>. Codex-specific handling is skipped, so the argument
+// policy, auto flags and -m injection do not apply; the model reaches the command as OPENAI_MODEL.
+func runWrap(envName string, command []string, opts launchOptions) error {
+	if len(command) == 0 {
+		return fmt.Errorf("wrap requires a command after --, e.g. cde wrap -e prod -- aider")
+	}
+	if opts.DryRun || opts.Snapshot != "" {
+		return fmt.Errorf("wrap does not support --dry-run or --snapshot")
+	}
+
+	opts.Wrap = true
+	plan, err := planLaunch(envName, command, opts)
+	if err != nil {
+		return err
+	}
+	env, err := resolveEnvironmentSecrets(plan.Environment)
+	if err != nil {
+		return fmt.Errorf("API key resolution failed: %w", err)
+	}
+
+	conflicts := findShellConflicts(env)
+	warnShellConflicts(conflicts, opts.KeepShell)
+	childOpts := subprocessOptions{Timeout: sessionTimeout(env, opts.Timeout)}
+	if opts.KeepShell {
+		childOpts.KeepShell = conflictKeys(conflicts)
+	}
+
+	now := time.Now()
+	if err := checkBudget(plan.Environment, opts.Override, now); err != nil {
+		return err
+	}
+	if err := checkRateLimit(plan.Environment, opts.IgnoreRate, now); err != nil {
+		return err
+	}
+	if err := confirmLaunch(plan.Environment, opts.Yes); err != nil {
+		return err
+	}
+	if err := runPreLaunchHooks(plan.Config, env); err != nil {
+		return err
+	}
+	recordLaunchUsage(plan.Environment, now)
+
+	// stdout may be the wrapped command's output, so the banner goes to stderr
+	fmt.Fprintf(os.Stderr, "Using environment: %s (%s)\n", env.Name, env.URL)
+	exitCode, elapsed, err := launchWrappedCommand(env, command, childOpts)
+	if err != nil {
+		return err
+	}
+	recordLaunchResult(plan.Config, env, command, exitCode, elapsed)
+	runPostExitHooks(plan.Config, env, exitCode, elapsed)
+	if exitCode != 0 {
+		return &childExitError{Code: exitCode}
+	}
+	return nil
+}
+
+// launchWrappedCommand runs command as a managed child with the environment's variables and
+// inherited stdio, returning its exit code and how long it ran
+func launchWrappedCommand(env Environment, command []string, opts subprocessOptions) (int, time.Duration, error) {
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return -1, 0, fmt.Errorf("wrapped command not found: %w", err)
+	}
+	envVars, err := prepareEnvironment(env)
+	if err != nil {
+		return -1, 0, fmt.Errorf("environment preparation failed: %w", err)
+	}
+
+	cmd := exec.Command(path, command[1:]...)
+	cmd.Env = keepShellValues(envVars, opts.KeepShell)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	logDebugf("starting wrapped command: %s", formatCommandLine(sanitizeArgs(command, env.APIKey)))
+
+	start := time.Now()
+	exitCode, err := runCodexChildWithLimit(cmd, opts.Timeout)
+	return exitCode, time.Since(start), err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunWrapPassesEnvironmentWithoutCodexArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tool script requires a POSIX shell")
+	}
+
+	// Fake tool that records its arguments and the variables cde set, then exits with $1
+	binDir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	script := "#!/bin/sh\necho \"$* $OPENAI_BASE_URL $OPENAI_API_KEY $OPENAI_MODEL\" > \"" + out + "\"\nexit \"$1\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "fake-tool"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake tool: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	clearOpenAIEnv(t)
+
+	originalConfigPath := configPathOverride
+	configPathOverride = filepath.Join(t.TempDir(), ".codex-env", "config.json")
+	defer func() { configPathOverride = originalConfigPath }()
+	config := Config{Environments: []Environment{{Name: "gw", URL: "https://gw.example.com/v1", APIKey: "sk-wrap-test", Model: "gpt-5"}}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		command  []string
+		want     string
+		wantExit int
+	}{
+		{"success", []string{"fake-tool", "0", "--model", "other"}, "0 --model other https://gw.example.com/v1 sk-wrap-test gpt-5\n", 0},
+		{"exit status", []string{"fake-tool", "3"}, "3 https://gw.example.com/v1 sk-wrap-test gpt-5\n", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runWrap("gw", tt.command, launchOptions{})
+			var childExit *childExitError
+			switch {
+			case tt.wantExit == 0 && err != nil:
+				t.Fatalf("runWrap() failed: %v", err)
+			case tt.wantExit != 0 && (!errors.As(err, &childExit) || childExit.Code != tt.wantExit):
+				t.Fatalf("expected exit status %d, got %v", tt.wantExit, err)
+			}
+
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("failed to read output: %v", err)
+			}
+			if got := string(data); got != tt.want {
+				t.Errorf("wrapped command saw %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgumentsWrap(t *testing.T) {
+	result := parseArguments([]string{"wrap", "-e", "prod", "--yes", "--", "aider", "--model", "x"})
+	if result.Error != nil {
+		t.Fatalf("parseArguments() error = %v", result.Error)
+	}
+	if result.Subcommand != "wrap" || result.CCEFlags["env"] != "prod" || result.CCEFlags["yes"] != "true" || !result.Separator {
+		t.Errorf("unexpected parse result: %+v", result)
+	}
+	if len(result.ClaudeArgs) != 3 || result.ClaudeArgs[0] != "aider" {
+		t.Errorf("command = %v, want [aider --model x]", result.ClaudeArgs)
+	}
+
+	if err := handleCommand([]string{"wrap", "-e", "prod", "aider"}); err == nil {
+		t.Error("expected wrap without -- to fail")
+	}
+}